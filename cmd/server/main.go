@@ -2,7 +2,10 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -14,9 +17,14 @@ import (
 	"confighub/internal/database"
 	"confighub/internal/middleware"
 	"confighub/internal/model"
+	"confighub/internal/tracing"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.uber.org/zap"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"golang.org/x/net/netutil"
 )
 
 func main() {
@@ -33,33 +41,18 @@ func main() {
 	}
 	defer logger.Sync()
 
-	// 连接数据库
-	db, err := database.Connect(cfg.Database)
-	if err != nil {
-		logger.Fatal("Failed to connect database", zap.Error(err))
-	}
-
-	// 自动迁移数据库表
-	if err := db.AutoMigrate(
-		&model.User{},
-		&model.Project{},
-		&model.Config{},
-		&model.ConfigVersion{},
-		&model.ProjectKey{},
-		&model.Release{},
-		&model.AuditLog{},
-		&model.ProjectMember{},
-		&model.ClientConnection{},
-	); err != nil {
-		logger.Warn("Failed to auto migrate database", zap.Error(err))
-	} else {
-		logger.Info("Database auto migration completed")
+	// 启动自检: 生产环境存在不安全的默认配置时拒绝启动
+	findings := cfg.CheckSettings()
+	for _, f := range findings {
+		switch f.Severity {
+		case config.SeverityError:
+			logger.Error("Self-check failed", zap.String("check", f.Check), zap.String("message", f.Message), zap.String("hint", f.Hint))
+		case config.SeverityWarn:
+			logger.Warn("Self-check warning", zap.String("check", f.Check), zap.String("message", f.Message), zap.String("hint", f.Hint))
+		}
 	}
-
-	// 连接 Redis
-	rdb, err := database.ConnectRedis(cfg.Redis)
-	if err != nil {
-		logger.Warn("Failed to connect Redis, cache disabled", zap.Error(err))
+	if insecure := config.InsecureFindings(findings); len(insecure) > 0 && cfg.Env == "production" && cfg.SelfCheck.RefuseInsecure {
+		logger.Fatal("Refusing to start with insecure configuration in production, set selfcheck.refuse_insecure=false to override", zap.Int("findings", len(insecure)))
 	}
 
 	// 设置 Gin 模式
@@ -67,32 +60,84 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	// 创建路由
-	router := gin.New()
+	// 链路追踪: 未启用时使用空操作的 TracerProvider
+	if cfg.Tracing.Enabled {
+		shutdown, err := tracing.Setup(context.Background(), tracing.Options{
+			ServiceName: cfg.Tracing.ServiceName,
+			Env:         cfg.Env,
+			Endpoint:    cfg.Tracing.Endpoint,
+			Insecure:    cfg.Tracing.Insecure,
+			SampleRatio: cfg.Tracing.SampleRatio,
+		})
+		if err != nil {
+			logger.Fatal("Failed to init tracing", zap.Error(err))
+		}
+		defer shutdown(context.Background())
+		logger.Info("Tracing enabled", zap.String("endpoint", cfg.Tracing.Endpoint), zap.Float64("sample_ratio", cfg.Tracing.SampleRatio))
+	}
 
-	// 全局中间件
-	router.Use(middleware.Recovery(logger))
-	router.Use(middleware.Logger(logger))
-	router.Use(middleware.CORS())
+	// 创建路由: 配置了独立的管理面地址时, 数据面和管理面使用各自的 Engine 和中间件链
+	instanceID := middleware.NewInstanceID()
+	router := newRouter(logger, cfg, instanceID)
+	management := router
+	split := cfg.Server.Management.Addr != "" && cfg.Server.Role != config.ServerRoleFollower
+	if split {
+		management = newRouter(logger, cfg, instanceID)
+		allow, err := middleware.AllowCIDRs(cfg.Server.Management.AllowedCIDRs)
+		if err != nil {
+			logger.Fatal("Invalid server.management.allowed_cidrs", zap.Error(err))
+		}
+		management.Use(allow)
+	}
 
-	// 注册路由
-	api.RegisterRoutes(router, db, rdb, logger, cfg)
+	// 注册路由: 跟随节点不连接数据库和 Redis, 仅提供只读接口
+	if cfg.Server.Role == config.ServerRoleFollower {
+		logger.Info("Running as read-only follower", zap.String("peer", cfg.Replication.PeerURL))
+		api.RegisterFollowerRoutes(router, logger, cfg)
+	} else {
+		registerRoutes(router, management, logger, cfg)
+	}
 
-	// 创建 HTTP 服务器
+	// 创建 HTTP 服务器: 开启 http2 时同时接受明文 HTTP/2 (h2c), 长轮询和 SSE 可复用同一连接
+	handler := withHTTP2(router, cfg)
 	srv := &http.Server{
-		Addr:         cfg.Server.Addr,
-		Handler:      router,
-		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
-		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
+		Addr:              cfg.Server.Addr,
+		Handler:           handler,
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		WriteTimeout:      time.Duration(cfg.Server.WriteTimeout) * time.Second,
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadHeaderTimeout) * time.Second,
+		IdleTimeout:       time.Duration(cfg.Server.IdleTimeout) * time.Second,
 	}
+	servers := []*http.Server{srv}
 
 	// 启动服务器
-	go func() {
-		logger.Info("Server starting", zap.String("addr", cfg.Server.Addr))
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Fatal("Server failed", zap.Error(err))
+	go serve(logger, srv, cfg.Server.MaxConns, cfg.Server.TLS)
+
+	// 管理面的独立监听地址, 超时设置与数据面相同, TLS 独立设置
+	if split {
+		managementSrv := &http.Server{
+			Addr:              cfg.Server.Management.Addr,
+			Handler:           withHTTP2(management, cfg),
+			ReadTimeout:       time.Duration(cfg.Server.ReadTimeout) * time.Second,
+			WriteTimeout:      time.Duration(cfg.Server.WriteTimeout) * time.Second,
+			ReadHeaderTimeout: time.Duration(cfg.Server.ReadHeaderTimeout) * time.Second,
+			IdleTimeout:       time.Duration(cfg.Server.IdleTimeout) * time.Second,
 		}
-	}()
+		servers = append(servers, managementSrv)
+		go serve(logger, managementSrv, 0, cfg.Server.Management.TLS)
+	}
+
+	// 独立的监听地址: 仅提供监听接口, 不设置全局读写超时, 由监听接口按请求设置
+	if cfg.Server.Watch.Addr != "" {
+		watchSrv := &http.Server{
+			Addr:              cfg.Server.Watch.Addr,
+			Handler:           watchOnly(handler),
+			ReadHeaderTimeout: time.Duration(cfg.Server.ReadHeaderTimeout) * time.Second,
+			IdleTimeout:       time.Duration(cfg.Server.Watch.IdleTimeout) * time.Second,
+		}
+		servers = append(servers, watchSrv)
+		go serve(logger, watchSrv, cfg.Server.Watch.MaxConns, cfg.Server.TLS)
+	}
 
 	// 优雅关闭
 	quit := make(chan os.Signal, 1)
@@ -104,13 +149,149 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Fatal("Server forced to shutdown", zap.Error(err))
+	for _, s := range servers {
+		if err := s.Shutdown(ctx); err != nil {
+			logger.Fatal("Server forced to shutdown", zap.Error(err))
+		}
 	}
 
 	logger.Info("Server exited")
 }
 
+// newRouter 创建 Engine 并注册全局中间件: 追踪中间件在日志之前, 请求日志可带上 trace_id; 压缩在最内层, 日志记录压缩后的大小
+func newRouter(logger *zap.Logger, cfg *config.Config, instanceID string) *gin.Engine {
+	router := gin.New()
+	router.Use(middleware.Recovery(logger))
+	router.Use(otelgin.Middleware(cfg.Tracing.ServiceName))
+	router.Use(middleware.Logger(logger))
+	router.Use(middleware.CORS())
+	router.Use(middleware.Instance(instanceID))
+	if cfg.Server.GzipMinSize > 0 {
+		router.Use(middleware.Gzip(cfg.Server.GzipMinSize))
+	}
+	return router
+}
+
+// withHTTP2 开启 http2 时接受明文 HTTP/2 (h2c); 启用 TLS 的监听地址通过 ALPN 协商 HTTP/2
+func withHTTP2(router *gin.Engine, cfg *config.Config) http.Handler {
+	if !cfg.Server.HTTP2 {
+		return router
+	}
+	return h2c.NewHandler(router, &http2.Server{
+		IdleTimeout: time.Duration(cfg.Server.IdleTimeout) * time.Second,
+	})
+}
+
+// serve 监听并启动 HTTP 服务, maxConns 大于 0 时限制并发连接数, 超出的连接等待空闲后再被接受
+// 设置了证书时使用 TLS, 设置了客户端 CA 时要求并校验客户端证书
+func serve(logger *zap.Logger, srv *http.Server, maxConns int, tlsCfg config.TLSConfig) {
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		logger.Fatal("Server failed", zap.String("addr", srv.Addr), zap.Error(err))
+	}
+	if maxConns > 0 {
+		ln = netutil.LimitListener(ln, maxConns)
+	}
+
+	if !tlsCfg.Enabled() {
+		logger.Info("Server starting", zap.String("addr", srv.Addr), zap.Int("max_conns", maxConns))
+		err = srv.Serve(ln)
+	} else {
+		if tlsCfg.ClientCAFile != "" {
+			pem, err := os.ReadFile(tlsCfg.ClientCAFile)
+			if err != nil {
+				logger.Fatal("Failed to read client CA", zap.String("file", tlsCfg.ClientCAFile), zap.Error(err))
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				logger.Fatal("No certificates found in client CA", zap.String("file", tlsCfg.ClientCAFile))
+			}
+			srv.TLSConfig = &tls.Config{ClientCAs: pool, ClientAuth: tls.RequireAndVerifyClientCert}
+		}
+		logger.Info("Server starting with TLS", zap.String("addr", srv.Addr), zap.Int("max_conns", maxConns), zap.Bool("mtls", tlsCfg.ClientCAFile != ""))
+		err = srv.ServeTLS(ln, tlsCfg.CertFile, tlsCfg.KeyFile)
+	}
+	if err != nil && err != http.ErrServerClosed {
+		logger.Fatal("Server failed", zap.String("addr", srv.Addr), zap.Error(err))
+	}
+}
+
+// watchPaths 独立监听地址上提供的接口
+var watchPaths = map[string]bool{
+	"/health":                     true,
+	"/api/v1/health":              true,
+	"/api/v1/config/watch":        true,
+	"/api/v1/config/watch/stream": true,
+	"/api/v1/config/events":       true,
+}
+
+// watchOnly 只放行监听接口和健康检查, 其他请求返回 404
+func watchOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !watchPaths[r.URL.Path] {
+			http.NotFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// registerRoutes 连接数据库和 Redis 后注册完整路由
+func registerRoutes(router, management *gin.Engine, logger *zap.Logger, cfg *config.Config) {
+	// 连接数据库
+	db, err := database.Connect(cfg.Database)
+	if err != nil {
+		logger.Fatal("Failed to connect database", zap.Error(err))
+	}
+	if err := tracing.InstrumentGORM(db, cfg.Database.Driver); err != nil {
+		logger.Warn("Failed to instrument database queries", zap.Error(err))
+	}
+
+	// 自动迁移数据库表; 已使用 golang-migrate 管理 (存在 schema_migrations 表) 时以迁移脚本为准,
+	// 不再自动迁移, 避免旧版本实例启动时改动新版本的表结构
+	if db.Migrator().HasTable("schema_migrations") {
+		logger.Info("Database managed by golang-migrate, skipping auto migration")
+	} else if err := db.AutoMigrate(
+		&model.User{},
+		&model.Project{},
+		&model.ProjectEnvironment{},
+		&model.Config{},
+		&model.ConfigVersion{},
+		&model.ConfigNotification{},
+		&model.ProjectKey{},
+		&model.Release{},
+		&model.AuditLog{},
+		&model.ProjectMember{},
+		&model.ClientConnection{},
+		&model.GrayExposure{},
+		&model.ReleaseApproval{},
+		&model.ConfigContract{},
+		&model.InboundIntegration{},
+		&model.ProjectUsage{},
+		&model.Webhook{},
+		&model.WebhookDelivery{},
+		&model.SigningKey{},
+		&model.VersionSignature{},
+		&model.ProtoDescriptor{},
+		&model.OwnershipTransfer{},
+		&model.ConfigKey{},
+		&model.ConfigKeyHistory{},
+		&model.ProjectDataKey{},
+	); err != nil {
+		logger.Warn("Failed to auto migrate database", zap.Error(err))
+	} else {
+		logger.Info("Database auto migration completed")
+	}
+
+	// 连接 Redis
+	rdb, err := database.ConnectRedis(cfg.Redis)
+	if err != nil {
+		logger.Warn("Failed to connect Redis, cache disabled", zap.Error(err))
+	}
+
+	api.RegisterRoutes(router, management, db, rdb, logger, cfg)
+}
+
 func initLogger(level string) (*zap.Logger, error) {
 	var cfg zap.Config
 	if level == "debug" {
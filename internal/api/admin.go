@@ -0,0 +1,146 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler 运维管理处理器
+type AdminHandler struct {
+	orphanSvc       *service.OrphanService
+	migrationSvc    *service.MigrationService
+	usageSvc        *service.UsageService
+	selfCheckSvc    *service.SelfCheckService
+	accessReviewSvc *service.AccessReviewService
+}
+
+// NewAdminHandler 创建运维管理处理器
+func NewAdminHandler(orphanSvc *service.OrphanService, migrationSvc *service.MigrationService, usageSvc *service.UsageService, selfCheckSvc *service.SelfCheckService, accessReviewSvc *service.AccessReviewService) *AdminHandler {
+	return &AdminHandler{
+		orphanSvc:       orphanSvc,
+		migrationSvc:    migrationSvc,
+		usageSvc:        usageSvc,
+		selfCheckSvc:    selfCheckSvc,
+		accessReviewSvc: accessReviewSvc,
+	}
+}
+
+// CleanupOrphans 清理孤儿数据
+// POST /api/admin/orphans/cleanup?dry_run=true
+func (h *AdminHandler) CleanupOrphans(c *gin.Context) {
+	dryRun := isDryRun(c)
+
+	counts, err := h.orphanSvc.Cleanup(c.Request.Context(), dryRun)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dry_run": dryRun,
+		"orphans": counts,
+	})
+}
+
+// MigrationStatus 获取数据库迁移版本状态, 请求时重新检查
+// GET /api/admin/migrations
+func (h *AdminHandler) MigrationStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.migrationSvc.Refresh(c.Request.Context()))
+}
+
+// SelfCheck 获取启动自检报告 (关键配置项及数据库、Redis 连通性), refresh=true 时重新探测
+// GET /api/admin/selfcheck?refresh=true
+func (h *AdminHandler) SelfCheck(c *gin.Context) {
+	if c.Query("refresh") == "true" {
+		c.JSON(http.StatusOK, h.selfCheckSvc.Refresh(c.Request.Context()))
+		return
+	}
+	c.JSON(http.StatusOK, h.selfCheckSvc.Report(c.Request.Context()))
+}
+
+// ExportUsage 导出项目月度用量, 用于成本分摊; 默认为当月 (UTC)
+// GET /api/admin/usage?month=2026-01&format=csv|json&project_id=1
+func (h *AdminHandler) ExportUsage(c *gin.Context) {
+	month := c.DefaultQuery("month", time.Now().UTC().Format("2006-01"))
+
+	var projectID int64
+	if idStr := c.Query("project_id"); idStr != "" {
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "无效的项目 ID",
+			})
+			return
+		}
+		projectID = id
+	}
+
+	switch c.DefaultQuery("format", "json") {
+	case "csv":
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", "attachment; filename=usage-"+month+".csv")
+		if err := h.usageSvc.ExportCSV(c.Request.Context(), month, projectID, c.Writer); err != nil {
+			handleServiceError(c, err)
+		}
+	case "json":
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		if err := h.usageSvc.ExportJSON(c.Request.Context(), month, projectID, c.Writer); err != nil {
+			handleServiceError(c, err)
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "不支持的导出格式",
+		})
+	}
+}
+
+// ExportAccessReview 导出访问审查清单: 调用方作为所有者或管理员的各项目中所有用户和密钥的权限、最近使用时间和有效期, 用于定期访问审查
+// GET /api/admin/access-review?format=csv|json&project_id=1
+func (h *AdminHandler) ExportAccessReview(c *gin.Context) {
+	var projectID int64
+	if idStr := c.Query("project_id"); idStr != "" {
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "无效的项目 ID",
+			})
+			return
+		}
+		projectID = id
+	}
+
+	userID := getUserID(c)
+	day := time.Now().UTC().Format("2006-01-02")
+	switch c.DefaultQuery("format", "json") {
+	case "csv":
+		// 先生成完整内容, 出错时仍可返回错误响应
+		var buf bytes.Buffer
+		if err := h.accessReviewSvc.ExportCSV(c.Request.Context(), projectID, userID, &buf); err != nil {
+			handleServiceError(c, err)
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename=access-review-"+day+".csv")
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+	case "json":
+		var buf bytes.Buffer
+		if err := h.accessReviewSvc.ExportJSON(c.Request.Context(), projectID, userID, &buf); err != nil {
+			handleServiceError(c, err)
+			return
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", buf.Bytes())
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "不支持的导出格式",
+		})
+	}
+}
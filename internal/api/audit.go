@@ -3,6 +3,7 @@ package api
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"confighub/internal/repository"
@@ -37,9 +38,46 @@ func (h *AuditHandler) List(c *gin.Context) {
 
 	// 解析查询参数
 	filter := &repository.AuditFilter{
-		ProjectID: projectID,
-		Action:    c.Query("action"),
-		Limit:     100,
+		ProjectID:    projectID,
+		Action:       c.Query("action"),
+		ResourceType: c.Query("resource_type"),
+		ResourceName: c.Query("resource_name"),
+		Query:        c.Query("q"),
+		Limit:        100,
+	}
+
+	for param, target := range map[string]**int64{
+		"user_id":       &filter.UserID,
+		"access_key_id": &filter.AccessKeyID,
+	} {
+		if idStr := c.Query(param); idStr != "" {
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"code":    "INVALID_REQUEST",
+					"message": "无效的 " + param,
+				})
+				return
+			}
+			*target = &id
+		}
+	}
+
+	if statusStr := c.Query("status"); statusStr != "" {
+		lo, hi, ok := parseStatusFilter(statusStr)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "无效的状态码, 支持如 404 或 4xx",
+			})
+			return
+		}
+		filter.StatusMin, filter.StatusMax = lo, hi
+	}
+
+	if filter.Origin, err = service.ParseOriginFilter(c.Query("origin")); err != nil {
+		handleServiceError(c, err)
+		return
 	}
 
 	if limitStr := c.Query("limit"); limitStr != "" {
@@ -72,7 +110,28 @@ func (h *AuditHandler) List(c *gin.Context) {
 		return
 	}
 
+	total, err := h.auditSvc.Count(c.Request.Context(), filter)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"logs": logs,
+		"logs":  logs,
+		"total": total,
 	})
 }
+
+// parseStatusFilter 解析状态码过滤条件, 支持精确值 (404) 或状态码类别 (4xx)
+func parseStatusFilter(s string) (int, int, bool) {
+	s = strings.ToLower(s)
+	if len(s) == 3 && strings.HasSuffix(s, "xx") && s[0] >= '1' && s[0] <= '5' {
+		class := int(s[0]-'0') * 100
+		return class, class + 99, true
+	}
+	code, err := strconv.Atoi(s)
+	if err != nil || code < 100 || code > 599 {
+		return 0, 0, false
+	}
+	return code, code, true
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"net/http"
 	"time"
 
@@ -11,19 +12,22 @@ import (
 	"gorm.io/gorm"
 
 	"confighub/internal/model"
+	"confighub/internal/service"
 )
 
 // AuthHandler 认证处理器
 type AuthHandler struct {
 	db        *gorm.DB
 	jwtSecret string
+	ldapSvc   *service.LDAPService // 未启用 LDAP 时为 nil
 }
 
 // NewAuthHandler 创建认证处理器
-func NewAuthHandler(db *gorm.DB, jwtSecret string) *AuthHandler {
+func NewAuthHandler(db *gorm.DB, jwtSecret string, ldapSvc *service.LDAPService) *AuthHandler {
 	return &AuthHandler{
 		db:        db,
 		jwtSecret: jwtSecret,
+		ldapSvc:   ldapSvc,
 	}
 }
 
@@ -74,6 +78,7 @@ func (h *AuthHandler) generateToken(user *model.User) (string, error) {
 }
 
 // Login 用户登录
+// 启用 LDAP 时先以 LDAP 认证, 目录中没有该用户或 LDAP 不可用且允许本地账号时再校验本地密码
 // POST /api/auth/login
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
@@ -85,6 +90,18 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	if h.ldapSvc != nil {
+		user, err := h.ldapSvc.Login(c.Request.Context(), req.Username, req.Password)
+		if err == nil {
+			h.completeLogin(c, user)
+			return
+		}
+		if !h.ldapSvc.FallbackToLocal(err) {
+			h.ldapLoginError(c, err)
+			return
+		}
+	}
+
 	// 查找用户
 	var user model.User
 	if err := h.db.Where("username = ?", req.Username).First(&user).Error; err != nil {
@@ -111,6 +128,11 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	h.completeLogin(c, &user)
+}
+
+// completeLogin 检查用户状态并签发登录令牌
+func (h *AuthHandler) completeLogin(c *gin.Context, user *model.User) {
 	// 检查用户是否激活
 	if !user.IsActive {
 		c.JSON(http.StatusForbidden, gin.H{
@@ -121,7 +143,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// 生成 token
-	token, err := h.generateToken(&user)
+	token, err := h.generateToken(user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    "TOKEN_ERROR",
@@ -130,6 +152,9 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// 记录最近登录时间, 供访问审查使用; 失败不影响登录
+	h.db.Model(user).UpdateColumn("last_login_at", time.Now())
+
 	c.JSON(http.StatusOK, gin.H{
 		"data": AuthResponse{
 			Token: token,
@@ -142,9 +167,40 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	})
 }
 
+// ldapLoginError 返回 LDAP 登录失败的响应
+func (h *AuthHandler) ldapLoginError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrLDAPInvalidCredentials), errors.Is(err, service.ErrLDAPUserNotFound):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    "INVALID_CREDENTIALS",
+			"message": "用户名或密码错误",
+		})
+	case errors.Is(err, service.ErrLDAPUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"code":    "LDAP_UNAVAILABLE",
+			"message": "LDAP 服务不可用",
+		})
+	case errors.Is(err, service.ErrLDAPLocalUserExists):
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    "LOCAL_USER_EXISTS",
+			"message": err.Error(),
+		})
+	default:
+		handleServiceError(c, err)
+	}
+}
+
 // Register 用户注册
 // POST /api/auth/register
 func (h *AuthHandler) Register(c *gin.Context) {
+	if h.ldapSvc != nil && !h.ldapSvc.AllowLocal() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":    "REGISTRATION_DISABLED",
+			"message": "已启用 LDAP 登录, 不允许注册本地账号",
+		})
+		return
+	}
+
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
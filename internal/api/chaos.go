@@ -0,0 +1,146 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChaosHandler 监听链路故障注入处理器, 仅在 chaos.enabled 开启时注册
+type ChaosHandler struct {
+	chaosSvc *service.ChaosService
+}
+
+// NewChaosHandler 创建故障注入处理器
+func NewChaosHandler(chaosSvc *service.ChaosService) *ChaosHandler {
+	return &ChaosHandler{
+		chaosSvc: chaosSvc,
+	}
+}
+
+// List 列出未到期的投递延迟
+// GET /api/admin/chaos
+func (h *ChaosHandler) List(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"delays": h.chaosSvc.Faults(),
+	})
+}
+
+// InjectEvents 向项目的监听连接推送伪造的变更事件, 请求体可为空
+// POST /api/admin/chaos/projects/:id/events
+func (h *ChaosHandler) InjectEvents(c *gin.Context) {
+	projectID, ok := chaosProjectID(c)
+	if !ok {
+		return
+	}
+
+	var req service.InjectEventsRequest
+	if !bindChaosRequest(c, &req) {
+		return
+	}
+
+	result, err := h.chaosSvc.InjectEvents(c.Request.Context(), projectID, &req)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// SetDelay 为项目的变更分发设置延迟
+// PUT /api/admin/chaos/projects/:id/delay
+func (h *ChaosHandler) SetDelay(c *gin.Context) {
+	projectID, ok := chaosProjectID(c)
+	if !ok {
+		return
+	}
+
+	var req service.SetDelayRequest
+	if !bindChaosRequest(c, &req) {
+		return
+	}
+
+	fault, err := h.chaosSvc.SetDelay(c.Request.Context(), projectID, &req)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, fault)
+}
+
+// ClearDelay 清除项目的投递延迟
+// DELETE /api/admin/chaos/projects/:id/delay
+func (h *ChaosHandler) ClearDelay(c *gin.Context) {
+	projectID, ok := chaosProjectID(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cleared": h.chaosSvc.ClearDelay(projectID),
+	})
+}
+
+// Disconnect 断开项目的监听连接, 客户端会重新连接
+// POST /api/admin/chaos/projects/:id/disconnect?percent=100
+func (h *ChaosHandler) Disconnect(c *gin.Context) {
+	projectID, ok := chaosProjectID(c)
+	if !ok {
+		return
+	}
+
+	percent := 0
+	if value := c.Query("percent"); value != "" {
+		var err error
+		if percent, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "无效的断开比例",
+			})
+			return
+		}
+	}
+
+	count, err := h.chaosSvc.Disconnect(c.Request.Context(), projectID, percent)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"disconnected": count,
+	})
+}
+
+// chaosProjectID 解析路径中的项目 ID, 无效时返回 400
+func chaosProjectID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// bindChaosRequest 解析请求体, 请求体为空时使用默认参数
+func bindChaosRequest(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return false
+	}
+	return true
+}
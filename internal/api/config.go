@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
 
@@ -12,18 +13,30 @@ import (
 
 // ConfigHandler 配置处理器
 type ConfigHandler struct {
-	configSvc *service.ConfigService
-	auditSvc  *service.AuditService
+	configSvc   *service.ConfigService
+	metricsSvc  *service.MetricsService
+	auditSvc    *service.AuditService
+	contractSvc *service.ContractService
+	schemaSvc   *service.SchemaService
 }
 
 // NewConfigHandler 创建配置处理器
-func NewConfigHandler(configSvc *service.ConfigService, auditSvc *service.AuditService) *ConfigHandler {
+func NewConfigHandler(configSvc *service.ConfigService, metricsSvc *service.MetricsService, auditSvc *service.AuditService, contractSvc *service.ContractService, schemaSvc *service.SchemaService) *ConfigHandler {
 	return &ConfigHandler{
-		configSvc: configSvc,
-		auditSvc:  auditSvc,
+		configSvc:   configSvc,
+		metricsSvc:  metricsSvc,
+		auditSvc:    auditSvc,
+		contractSvc: contractSvc,
+		schemaSvc:   schemaSvc,
 	}
 }
 
+// configListItem 配置列表项, 附带大小与复杂度指标
+type configListItem struct {
+	*model.Config
+	Metrics *service.ConfigMetrics `json:"metrics"`
+}
+
 // Upload 上传配置
 // POST /api/projects/:id/configs
 func (h *ConfigHandler) Upload(c *gin.Context) {
@@ -46,6 +59,13 @@ func (h *ConfigHandler) Upload(c *gin.Context) {
 		return
 	}
 
+	origin, err := changeOrigin(c)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+	req.Origin = origin
+
 	userID := getUserID(c)
 	author := "user"
 	if userID > 0 {
@@ -68,6 +88,7 @@ func (h *ConfigHandler) Upload(c *gin.Context) {
 		ResourceName: config.Name,
 		IPAddress:    c.ClientIP(),
 		UserAgent:    c.Request.UserAgent(),
+		Origin:       origin,
 	})
 
 	c.JSON(http.StatusCreated, gin.H{
@@ -94,8 +115,14 @@ func (h *ConfigHandler) List(c *gin.Context) {
 		return
 	}
 
+	metrics := h.metricsSvc.ForConfigs(c.Request.Context(), configs)
+	items := make([]configListItem, 0, len(configs))
+	for _, config := range configs {
+		items = append(items, configListItem{Config: config, Metrics: metrics[config.ID]})
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"configs": configs,
+		"configs": items,
 	})
 }
 
@@ -149,13 +176,19 @@ func (h *ConfigHandler) Update(c *gin.Context) {
 		return
 	}
 
+	origin, err := changeOrigin(c)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
 	userID := getUserID(c)
 	author := "user"
 	if userID > 0 {
 		author = strconv.FormatInt(userID, 10)
 	}
 
-	version, err := h.configSvc.Update(c.Request.Context(), id, req.Content, req.Message, author)
+	version, err := h.configSvc.Update(c.Request.Context(), id, req.Content, req.Message, author, origin)
 	if err != nil {
 		handleServiceError(c, err)
 		return
@@ -173,17 +206,26 @@ func (h *ConfigHandler) Update(c *gin.Context) {
 			ResourceName: config.Name,
 			IPAddress:    c.ClientIP(),
 			UserAgent:    c.Request.UserAgent(),
+			Origin:       origin,
 		})
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	// 仅标记的契约违反项和 warn 策略下的 Schema 不符合项不阻止写入, 随结果返回
+	resp := gin.H{
 		"version": version,
-	})
+	}
+	if warnings := h.contractSvc.Warnings(c.Request.Context(), id, version.Version); len(warnings) > 0 {
+		resp["contract_warnings"] = warnings
+	}
+	if warnings := h.schemaSvc.Warnings(c.Request.Context(), id, version.Version); len(warnings) > 0 {
+		resp["schema_warnings"] = warnings
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 
 // Delete 删除配置
-// DELETE /api/configs/:id
+// DELETE /api/configs/:id?dry_run=true
 func (h *ConfigHandler) Delete(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -195,10 +237,18 @@ func (h *ConfigHandler) Delete(c *gin.Context) {
 	}
 
 	config, _ := h.configSvc.GetConfigByID(c.Request.Context(), id)
-	if err := h.configSvc.Delete(c.Request.Context(), id); err != nil {
+	impact, err := h.configSvc.Delete(c.Request.Context(), id, isDryRun(c))
+	if err != nil {
 		handleServiceError(c, err)
 		return
 	}
+	if impact != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"dry_run": true,
+			"impact":  impact,
+		})
+		return
+	}
 
 	// 记录审计日志
 	userID := getUserID(c)
@@ -220,6 +270,184 @@ func (h *ConfigHandler) Delete(c *gin.Context) {
 	})
 }
 
+// GetDiffRules 获取对比忽略规则
+// GET /api/configs/:id/diff-rules
+func (h *ConfigHandler) GetDiffRules(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return
+	}
+
+	rules, err := h.configSvc.GetDiffRules(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules": rules,
+	})
+}
+
+// UpdateDiffRules 更新对比忽略规则
+// PUT /api/configs/:id/diff-rules
+func (h *ConfigHandler) UpdateDiffRules(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return
+	}
+
+	var rules service.DiffRules
+	if err := c.ShouldBindJSON(&rules); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.configSvc.UpdateDiffRules(c.Request.Context(), id, &rules); err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "对比规则已更新",
+	})
+}
+
+// GetInheritance 获取配置的继承链、合并后的内容和各键的来源 (自身定义、继承或覆盖)
+// GET /api/configs/:id/inheritance
+func (h *ConfigHandler) GetInheritance(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return
+	}
+
+	info, err := h.configSvc.Inheritance(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// SetParent 设置或解除配置继承的父配置, parent_id 为 null 时解除
+// PUT /api/configs/:id/parent
+func (h *ConfigHandler) SetParent(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return
+	}
+
+	var req struct {
+		ParentID *int64 `json:"parent_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	info, err := h.configSvc.SetParent(c.Request.Context(), id, req.ParentID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// GetNormalization 获取项目的内容规范化策略
+// GET /api/projects/:id/normalization
+func (h *ConfigHandler) GetNormalization(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	policy, err := h.configSvc.GetNormalization(c.Request.Context(), projectID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"normalization": policy,
+	})
+}
+
+// UpdateNormalization 更新项目的内容规范化策略
+// PUT /api/projects/:id/normalization
+func (h *ConfigHandler) UpdateNormalization(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	var policy service.NormalizationPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.configSvc.UpdateNormalization(c.Request.Context(), projectID, &policy); err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	body, _ := json.Marshal(policy)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    projectID,
+		UserID:       &userID,
+		Action:       model.AuditActionUpdate,
+		ResourceType: model.AuditResourceProject,
+		ResourceID:   projectID,
+		ResourceName: "normalization",
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		RequestBody:  string(body),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"normalization": policy,
+	})
+}
+
 // Compare 环境对比
 // GET /api/configs/:id/compare
 func (h *ConfigHandler) Compare(c *gin.Context) {
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContractHandler 配置消费契约处理器
+type ContractHandler struct {
+	contractSvc *service.ContractService
+	configSvc   *service.ConfigService
+}
+
+// NewContractHandler 创建契约处理器
+func NewContractHandler(contractSvc *service.ContractService, configSvc *service.ConfigService) *ContractHandler {
+	return &ContractHandler{
+		contractSvc: contractSvc,
+		configSvc:   configSvc,
+	}
+}
+
+// contractRequest 注册契约请求
+type contractRequest struct {
+	Consumer    string                `json:"consumer" binding:"required"`
+	Keys        []service.ContractKey `json:"keys" binding:"required"`
+	Enforcement string                `json:"enforcement"` // block (默认) 或 warn
+}
+
+// Register 消费方通过 Access Key 注册自己依赖的键
+// PUT /api/v1/config/contract?name=xxx&namespace=xxx&env=xxx
+func (h *ContractHandler) Register(c *gin.Context) {
+	projectID := getProjectID(c)
+	if projectID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    "UNAUTHORIZED",
+			"message": "未授权访问",
+		})
+		return
+	}
+
+	var req contractRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !requireConfigAllowed(c, c.Query("name")) {
+		return
+	}
+
+	config, _, err := h.configSvc.GetByAccessKey(c.Request.Context(), projectID, c.Query("name"), c.Query("namespace"), c.Query("env"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "NOT_FOUND",
+			"message": "配置不存在",
+		})
+		return
+	}
+
+	var accessKeyID *int64
+	if id := getAccessKeyID(c); id > 0 {
+		accessKeyID = &id
+	}
+
+	contract, err := h.contractSvc.Register(c.Request.Context(), config.ID, req.Consumer, req.Keys, req.Enforcement, accessKeyID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"contract": contract,
+	})
+}
+
+// List 获取配置的消费契约
+// GET /api/configs/:id/contracts
+func (h *ContractHandler) List(c *gin.Context) {
+	configID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return
+	}
+
+	contracts, err := h.contractSvc.List(c.Request.Context(), configID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"contracts": contracts,
+	})
+}
+
+// Upsert 管理端注册或更新消费契约
+// PUT /api/configs/:id/contracts
+func (h *ContractHandler) Upsert(c *gin.Context) {
+	configID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return
+	}
+
+	var req contractRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	contract, err := h.contractSvc.Register(c.Request.Context(), configID, req.Consumer, req.Keys, req.Enforcement, nil)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"contract": contract,
+	})
+}
+
+// Delete 删除消费方的契约
+// DELETE /api/configs/:id/contracts/:consumer
+func (h *ContractHandler) Delete(c *gin.Context) {
+	configID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return
+	}
+
+	if err := h.contractSvc.Delete(c.Request.Context(), configID, c.Param("consumer")); err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "契约已删除",
+	})
+}
+
+// Check 检查配置版本是否满足所有契约, 不指定版本时检查当前版本
+// GET /api/configs/:id/contracts/check?version=3
+func (h *ContractHandler) Check(c *gin.Context) {
+	configID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return
+	}
+
+	version := 0
+	if v := c.Query("version"); v != "" {
+		if version, err = strconv.Atoi(v); err != nil || version < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "无效的版本号",
+			})
+			return
+		}
+	}
+
+	violations, err := h.contractSvc.CheckVersion(c.Request.Context(), configID, version)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+	if violations == nil {
+		violations = []service.ContractViolation{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"satisfied":  len(violations) == 0,
+		"violations": violations,
+	})
+}
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"confighub/internal/service"
 
@@ -78,8 +80,149 @@ func (h *EnvironmentHandler) Create(c *gin.Context) {
 	})
 }
 
+// Impact 评估环境变更影响
+// GET /api/projects/:id/environments/:env/impact
+func (h *EnvironmentHandler) Impact(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	impact, err := h.envSvc.Impact(c.Request.Context(), projectID, c.Param("env"))
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, impact)
+}
+
+// Update 更新环境 (支持改名, 绑定的配置和发布同步迁移)
+// PUT /api/projects/:id/environments/:env
+func (h *EnvironmentHandler) Update(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	var req service.UpdateEnvironmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.envSvc.Update(c.Request.Context(), projectID, c.Param("env"), &req); err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "环境更新成功",
+	})
+}
+
+// Delete 删除环境
+// DELETE /api/projects/:id/environments/:env?migrate_to=prod&dry_run=true
+func (h *EnvironmentHandler) Delete(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	impact, err := h.envSvc.Delete(c.Request.Context(), projectID, c.Param("env"), c.Query("migrate_to"), isDryRun(c))
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+	if impact != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"dry_run": true,
+			"impact":  impact,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "环境删除成功",
+	})
+}
+
+// GetVariables 获取环境变量
+// GET /api/projects/:id/environments/:env/variables
+func (h *EnvironmentHandler) GetVariables(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	vars, err := h.envSvc.GetVariables(c.Request.Context(), projectID, c.Param("env"))
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"variables": vars,
+	})
+}
+
+// SetVariables 设置环境变量 (整体替换), 配置中以 ${env:VAR} 引用
+// PUT /api/projects/:id/environments/:env/variables
+func (h *EnvironmentHandler) SetVariables(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	var req struct {
+		Variables map[string]string `json:"variables" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.envSvc.SetVariables(c.Request.Context(), projectID, c.Param("env"), req.Variables); err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "环境变量更新成功",
+	})
+}
+
 // Compare 对比环境配置
-// GET /api/configs/:id/compare?source=dev&target=prod
+// GET /api/configs/:id/compare?source=dev&target=prod&effective=true
+// effective=true 时对比继承合并、变量解析后的生效内容
 func (h *EnvironmentHandler) Compare(c *gin.Context) {
 	configID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -101,7 +244,7 @@ func (h *EnvironmentHandler) Compare(c *gin.Context) {
 		return
 	}
 
-	comparison, err := h.envDiffSvc.Compare(c.Request.Context(), configID, sourceEnv, targetEnv)
+	comparison, err := h.envDiffSvc.Compare(c.Request.Context(), configID, sourceEnv, targetEnv, c.Query("effective") == "true")
 	if err != nil {
 		handleServiceError(c, err)
 		return
@@ -110,8 +253,40 @@ func (h *EnvironmentHandler) Compare(c *gin.Context) {
 	c.JSON(http.StatusOK, comparison)
 }
 
+// Drift 项目环境漂移报告
+// GET /api/projects/:id/drift?source=staging&target=prod&effective=true
+func (h *EnvironmentHandler) Drift(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	sourceEnv := c.Query("source")
+	targetEnv := c.Query("target")
+
+	if sourceEnv == "" || targetEnv == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请指定源环境和目标环境",
+		})
+		return
+	}
+
+	report, err := h.envDiffSvc.ProjectDrift(c.Request.Context(), projectID, sourceEnv, targetEnv, c.Query("effective") == "true")
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
 // Sync 同步配置到目标环境
-// POST /api/configs/:id/sync
+// POST /api/configs/:id/sync?dry_run=true
 func (h *EnvironmentHandler) Sync(c *gin.Context) {
 	configID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -136,13 +311,23 @@ func (h *EnvironmentHandler) Sync(c *gin.Context) {
 		return
 	}
 
-	if err := h.envDiffSvc.Sync(c.Request.Context(), configID, req.SourceEnv, req.TargetEnv, req.Keys); err != nil {
+	dryRun := isDryRun(c)
+	result, err := h.envDiffSvc.Sync(c.Request.Context(), configID, req.SourceEnv, req.TargetEnv, req.Keys, dryRun)
+	if err != nil {
 		handleServiceError(c, err)
 		return
 	}
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"dry_run": true,
+			"sync":    result,
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "同步成功",
+		"sync":    result,
 	})
 }
 
@@ -173,3 +358,45 @@ func (h *EnvironmentHandler) MergeConfig(c *gin.Context) {
 	})
 }
 
+
+// CloneToSandbox 将环境当前生效的配置克隆到临时沙箱环境
+// POST /api/projects/:id/environments/:env/clone-to-sandbox
+func (h *EnvironmentHandler) CloneToSandbox(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	var req struct {
+		Name     string `json:"name"`      // 沙箱环境名, 为空时自动生成
+		TTLHours int    `json:"ttl_hours"` // 有效期 (小时), 默认 24
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	author := "user"
+	if userID := getUserID(c); userID > 0 {
+		author = strconv.FormatInt(userID, 10)
+	}
+
+	result, err := h.envSvc.CloneToSandbox(c.Request.Context(), projectID, c.Param("env"), req.Name, time.Duration(req.TTLHours)*time.Hour, author)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"sandbox": result.Environment,
+		"configs": result.Configs,
+	})
+}
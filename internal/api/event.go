@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// sseHeartbeatInterval SSE 心跳间隔, 防止代理因空闲断开连接
+const sseHeartbeatInterval = 15 * time.Second
+
+// EventHandler 配置变更事件流处理器
+type EventHandler struct {
+	eventSvc  *service.EventService
+	notifySvc *service.NotificationService
+}
+
+// NewEventHandler 创建事件流处理器
+func NewEventHandler(eventSvc *service.EventService, notifySvc *service.NotificationService) *EventHandler {
+	return &EventHandler{
+		eventSvc:  eventSvc,
+		notifySvc: notifySvc,
+	}
+}
+
+// Stream 以 Server-Sent Events 推送命名空间下的配置变更
+// GET /api/v1/config/events?namespace=xxx&env=xxx
+// Access Key 限制了配置范围时只推送范围内配置的变更
+// 重连时通过 Last-Event-ID 头 (或 last_event_id 参数) 补发断开期间的事件;
+// 事件已过期时先发送 reset 事件, 客户端应全量重新加载
+func (h *EventHandler) Stream(c *gin.Context) {
+	projectID := getProjectID(c)
+	if projectID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    "UNAUTHORIZED",
+			"message": "未授权访问",
+		})
+		return
+	}
+
+	namespace, env := service.ResolveNamespaceEnv(c.Query("namespace"), c.Query("env"))
+
+	var lastEventID int64
+	if id := c.GetHeader("Last-Event-ID"); id != "" {
+		lastEventID, _ = strconv.ParseInt(id, 10, 64)
+	} else if id := c.Query("last_event_id"); id != "" {
+		lastEventID, _ = strconv.ParseInt(id, 10, 64)
+	}
+
+	// 先订阅再补发, 避免补发期间的变更丢失
+	clientID := uuid.New().String()
+	subscriber := watchSubscriber(c, projectID)
+	sub, err := h.notifySvc.Subscribe(c.Request.Context(), clientID, subscriber, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    "INTERNAL_ERROR",
+			"message": "订阅失败",
+		})
+		return
+	}
+	defer h.notifySvc.Unsubscribe(c.Request.Context(), clientID)
+
+	var backlog []*service.ConfigChange
+	complete := true
+	if lastEventID > 0 {
+		if backlog, complete, err = h.eventSvc.Since(c.Request.Context(), projectID, namespace, env, lastEventID); err != nil {
+			handleServiceError(c, err)
+			return
+		}
+	}
+
+	// 事件流为长连接, 不受服务端写超时限制
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+
+	if !complete {
+		writeSSE(c, 0, "reset", gin.H{"message": "部分事件已过期, 请重新加载配置"})
+	}
+	sent := lastEventID
+	for _, change := range backlog {
+		sent = change.ID
+		if !configAllowed(c, change.ConfigName) {
+			continue
+		}
+		writeSSE(c, change.ID, "change", change)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-sub.Revoked:
+			writeSSE(c, 0, "revoked", gin.H{"code": "ACCESS_REVOKED", "message": "访问权限已被撤销"})
+			c.Writer.Flush()
+			return
+		case change, ok := <-sub.Changes:
+			if !ok {
+				return
+			}
+			if change.ProjectID != projectID || change.Namespace != namespace || change.Env != env || !configAllowed(c, change.ConfigName) {
+				continue
+			}
+			// 补发与实时推送可能重叠
+			if change.ID > 0 && change.ID <= sent {
+				continue
+			}
+			writeSSE(c, change.ID, "change", change)
+			if change.ID > 0 {
+				sent = change.ID
+			}
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeSSE 写入一条 SSE 事件, id 为 0 时不设置事件 ID
+func writeSSE(c *gin.Context, id int64, event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	if id > 0 {
+		fmt.Fprintf(c.Writer, "id: %d\n", id)
+	}
+	fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event, payload)
+}
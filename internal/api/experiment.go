@@ -0,0 +1,92 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"confighub/internal/repository"
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExperimentHandler 灰度实验处理器
+type ExperimentHandler struct {
+	experimentSvc *service.ExperimentService
+}
+
+// NewExperimentHandler 创建灰度实验处理器
+func NewExperimentHandler(experimentSvc *service.ExperimentService) *ExperimentHandler {
+	return &ExperimentHandler{
+		experimentSvc: experimentSvc,
+	}
+}
+
+// ExportExposures 导出灰度发布的客户端分组记录
+// GET /api/releases/:id/exposures?format=csv|json&client_id=xxx&variant=gray&start_time=xxx&end_time=xxx
+func (h *ExperimentHandler) ExportExposures(c *gin.Context) {
+	releaseID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的发布 ID",
+		})
+		return
+	}
+
+	filter := &repository.ExposureFilter{
+		ReleaseID: releaseID,
+		ClientID:  c.Query("client_id"),
+		Variant:   c.Query("variant"),
+	}
+
+	if startStr := c.Query("start_time"); startStr != "" {
+		t, err := time.Parse(time.RFC3339, startStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "无效的 start_time, 格式为 RFC 3339",
+			})
+			return
+		}
+		filter.StartTime = &t
+	}
+
+	if endStr := c.Query("end_time"); endStr != "" {
+		t, err := time.Parse(time.RFC3339, endStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "无效的 end_time, 格式为 RFC 3339",
+			})
+			return
+		}
+		filter.EndTime = &t
+	}
+
+	switch c.DefaultQuery("format", "json") {
+	case "csv":
+		// 先生成完整内容, 出错时仍可返回错误响应
+		var buf bytes.Buffer
+		if err := h.experimentSvc.ExportCSV(c.Request.Context(), filter, &buf); err != nil {
+			handleServiceError(c, err)
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename=release-"+c.Param("id")+"-exposures.csv")
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+	case "json":
+		var buf bytes.Buffer
+		if err := h.experimentSvc.ExportJSON(c.Request.Context(), filter, &buf); err != nil {
+			handleServiceError(c, err)
+			return
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", buf.Bytes())
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "不支持的导出格式",
+		})
+	}
+}
@@ -0,0 +1,399 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"confighub/internal/middleware"
+	"confighub/internal/model"
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// FollowerHandler 只读跟随节点处理器, 仅提供公开读取和监听接口
+type FollowerHandler struct {
+	followerSvc *service.FollowerService
+	encryptSvc  *service.EncryptionService
+	notifySvc   *service.NotificationService
+}
+
+// NewFollowerHandler 创建跟随节点处理器
+func NewFollowerHandler(followerSvc *service.FollowerService, encryptSvc *service.EncryptionService, notifySvc *service.NotificationService) *FollowerHandler {
+	return &FollowerHandler{
+		followerSvc: followerSvc,
+		encryptSvc:  encryptSvc,
+		notifySvc:   notifySvc,
+	}
+}
+
+// Health 健康检查, 首次同步完成前返回 503 以便负载均衡摘除节点
+// GET /health
+func (h *FollowerHandler) Health(c *gin.Context) {
+	status := h.followerSvc.Status()
+	code := http.StatusOK
+	state := "ok"
+	if !status.Ready {
+		code = http.StatusServiceUnavailable
+		state = "syncing"
+	}
+
+	c.JSON(code, gin.H{
+		"status":   state,
+		"mode":     "follower",
+		"follower": status,
+	})
+}
+
+// Transports 返回支持的传输方式, 与主实例一致
+// GET /api/v1/config/transports
+func (h *FollowerHandler) Transports(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"transports": supportedTransports,
+	})
+}
+
+// ReadOnly 跟随节点拒绝写入, 客户端需直接连接主实例修改配置
+// PUT/POST /api/v1/config
+func (h *FollowerHandler) ReadOnly(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"code":    "READ_ONLY",
+		"message": "只读节点不支持修改配置, 请连接主实例",
+	})
+}
+
+// Get 获取配置
+// GET /api/v1/config?name=xxx&namespace=xxx&env=xxx&locale=xxx&path=xxx&format=xxx
+func (h *FollowerHandler) Get(c *gin.Context) {
+	path, ok := contentPath(c)
+	if !ok {
+		return
+	}
+	format, ok := responseFormat(c)
+	if !ok {
+		return
+	}
+	config, ok := h.lookup(c)
+	if !ok {
+		return
+	}
+
+	response := h.response(c, config)
+	if format != service.FormatJSON {
+		writeRenderedContent(c, response, config.FileType, path, format)
+		return
+	}
+	if path != nil {
+		err := projectItem(response, config.FileType, path, c.Query("path"))
+		if errors.Is(err, service.ErrInvalidPath) {
+			handleServiceError(c, err)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":    "PATH_NOT_FOUND",
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Ping 连通性测试伪配置, 与主实例一致
+// GET /api/v1/config/_ping
+func (h *FollowerHandler) Ping(c *gin.Context) {
+	writePing(c)
+}
+
+// Bootstrap 一次返回调用方可读取的全部配置, 与主实例一致
+// GET /api/v1/bootstrap?namespace=xxx&env=xxx&locale=xxx
+func (h *FollowerHandler) Bootstrap(c *gin.Context) {
+	projectID := getProjectID(c)
+	if projectID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    "UNAUTHORIZED",
+			"message": "未授权访问",
+		})
+		return
+	}
+
+	namespace, env := service.ResolveNamespaceEnv(c.Query("namespace"), c.Query("env"))
+	if !h.validLocale(c, projectID) {
+		return
+	}
+
+	configs := h.followerSvc.ListConfigs(projectID, namespace, env)
+	items := make([]gin.H, 0, len(configs))
+	for _, config := range configs {
+		if !configAllowed(c, config.Name) {
+			continue
+		}
+		items = append(items, h.response(c, config))
+	}
+
+	writeCompressedJSON(c, http.StatusOK, gin.H{
+		"namespace":    namespace,
+		"environment":  env,
+		"configs":      items,
+		"generated_at": time.Now(),
+	})
+}
+
+// List 按命名空间和环境批量获取配置, 与主实例一致
+// GET /api/v1/configs?namespace=xxx&env=xxx&names=a,b&content=false&locale=xxx&path=xxx
+func (h *FollowerHandler) List(c *gin.Context) {
+	projectID := getProjectID(c)
+	if projectID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    "UNAUTHORIZED",
+			"message": "未授权访问",
+		})
+		return
+	}
+
+	namespace, env := service.ResolveNamespaceEnv(c.Query("namespace"), c.Query("env"))
+	if !h.validLocale(c, projectID) {
+		return
+	}
+	path, ok := contentPath(c)
+	if !ok {
+		return
+	}
+
+	requested := splitNames(c.Query("names"))
+	if len(requested) > maxBulkNames {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": fmt.Sprintf("一次最多获取 %d 个配置", maxBulkNames),
+		})
+		return
+	}
+
+	var configs []*service.FollowerConfig
+	missing := []string{}
+	if len(requested) == 0 {
+		configs = h.followerSvc.ListConfigs(projectID, namespace, env)
+	} else {
+		for _, name := range requested {
+			config, err := h.followerSvc.GetConfig(projectID, name, namespace, env)
+			if err != nil || !configAllowed(c, name) {
+				missing = append(missing, name)
+				continue
+			}
+			configs = append(configs, config)
+		}
+	}
+
+	withContent := c.Query("content") != "false"
+	items := make([]gin.H, 0, len(configs))
+	for _, config := range configs {
+		if !configAllowed(c, config.Name) {
+			continue
+		}
+		item := h.response(c, config)
+		if path != nil {
+			// 无法按路径读取的配置附带 path_error
+			projectItem(item, config.FileType, path, c.Query("path"))
+		}
+		if !withContent {
+			delete(item, "content")
+		}
+		items = append(items, item)
+	}
+
+	writeCompressedJSON(c, http.StatusOK, gin.H{
+		"namespace":   namespace,
+		"environment": env,
+		"configs":     items,
+		"missing":     missing,
+	})
+}
+
+// Watch 监听配置变更 (Long-Polling), 变更来自主实例快照的定时刷新
+// GET /api/v1/config/watch?name=xxx&namespace=xxx&env=xxx&version=xxx&timeout=xxx
+func (h *FollowerHandler) Watch(c *gin.Context) {
+	config, ok := h.lookup(c)
+	if !ok {
+		return
+	}
+
+	currentVersion := 0
+	if v := c.Query("version"); v != "" {
+		currentVersion, _ = strconv.Atoi(v)
+	}
+
+	timeout := 30
+	if t := c.Query("timeout"); t != "" {
+		timeout, _ = strconv.Atoi(t)
+		if timeout > 60 {
+			timeout = 60
+		}
+		if timeout < 1 {
+			timeout = 1
+		}
+	}
+
+	if config.Version > currentVersion {
+		c.JSON(http.StatusOK, h.changed(c, config))
+		return
+	}
+
+	clientID := uuid.New().String()
+	subscriber := watchSubscriber(c, config.ProjectID)
+	sub, err := h.notifySvc.Subscribe(c.Request.Context(), clientID, subscriber, []int64{config.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    "INTERNAL_ERROR",
+			"message": "订阅失败",
+		})
+		return
+	}
+	defer h.notifySvc.Unsubscribe(c.Request.Context(), clientID)
+
+	// 订阅前可能已刷新快照, 订阅后再检查一次
+	if latest, err := h.followerSvc.GetConfig(config.ProjectID, config.Name, config.Namespace, config.Environment); err == nil && latest.Version > currentVersion {
+		c.JSON(http.StatusOK, h.changed(c, latest))
+		return
+	}
+
+	deadline := time.NewTimer(time.Duration(timeout) * time.Second)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-sub.Revoked:
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":    "ACCESS_REVOKED",
+				"message": "访问权限已被撤销",
+			})
+			return
+		case change, ok := <-sub.Changes:
+			if !ok {
+				c.Status(http.StatusNotModified)
+				return
+			}
+			// 其他配置的变更不结束本次监听
+			if change == nil || change.ConfigID != config.ID {
+				continue
+			}
+			latest, err := h.followerSvc.GetConfig(config.ProjectID, config.Name, config.Namespace, config.Environment)
+			if errors.Is(err, service.ErrConfigNotFound) {
+				// 配置已从快照中移除, 与主实例一致返回 410
+				configDeleted(c, &model.Config{Name: config.Name, Namespace: config.Namespace, Environment: config.Environment, CurrentVersion: config.Version})
+				return
+			}
+			if err != nil {
+				c.Status(http.StatusNotModified)
+				return
+			}
+			if latest.Version > currentVersion {
+				c.JSON(http.StatusOK, h.changed(c, latest))
+				return
+			}
+		case <-deadline.C:
+			c.Status(http.StatusNotModified)
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// lookup 按请求参数查找缓存的配置, 失败时写入错误响应
+func (h *FollowerHandler) lookup(c *gin.Context) (*service.FollowerConfig, bool) {
+	projectID := getProjectID(c)
+	if projectID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    "UNAUTHORIZED",
+			"message": "未授权访问",
+		})
+		return nil, false
+	}
+
+	configName := c.Query("name")
+	if configName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "配置名称不能为空",
+		})
+		return nil, false
+	}
+	if !requireConfigAllowed(c, configName) {
+		return nil, false
+	}
+	if !h.validLocale(c, projectID) {
+		return nil, false
+	}
+
+	config, err := h.followerSvc.GetConfig(projectID, configName, c.Query("namespace"), c.Query("env"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "NOT_FOUND",
+			"message": "配置不存在",
+		})
+		return nil, false
+	}
+	return config, true
+}
+
+// response 构造与主实例一致的配置响应; 跟随节点不参与灰度发布, 始终下发最新版本
+func (h *FollowerHandler) response(c *gin.Context, config *service.FollowerConfig) gin.H {
+	response := gin.H{
+		"name":        config.Name,
+		"namespace":   config.Namespace,
+		"environment": config.Environment,
+		"version":     config.Version,
+	}
+	if config.Version == 0 {
+		return response
+	}
+
+	response["content_hash"] = config.CommitHash
+	if config.Release != nil {
+		response["release_type"] = config.Release.ReleaseType
+		response["released_at"] = config.Release.ReleasedAt
+	}
+
+	content := config.Content
+	authCtx := middleware.GetAuthContext(c)
+	if authCtx != nil && authCtx.Permissions.Decrypt {
+		if decrypted, err := h.encryptSvc.DecryptFields(c.Request.Context(), config.ProjectID, content); err == nil {
+			content = decrypted
+		}
+	}
+	if locales, err := h.followerSvc.LocaleChain(config.ProjectID, c.Query("locale")); err == nil && len(locales) > 0 {
+		content = service.LocalizeContent(config.FileType, content, locales)
+		response["locale"] = locales[0]
+	}
+	response["content"] = content
+	return response
+}
+
+// validLocale 校验请求的 locale 参数, 无效时写入 400 响应
+func (h *FollowerHandler) validLocale(c *gin.Context, projectID int64) bool {
+	if _, err := h.followerSvc.LocaleChain(projectID, c.Query("locale")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// changed 构造监听接口的变更响应, mode=notify 时省略内容
+func (h *FollowerHandler) changed(c *gin.Context, config *service.FollowerConfig) gin.H {
+	response := h.response(c, config)
+	response["changed"] = true
+	if c.Query("mode") == "notify" {
+		delete(response, "content")
+		response["notify_only"] = true
+	}
+	return response
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"confighub/internal/model"
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GitHandler 项目 Git 同步处理器
+type GitHandler struct {
+	gitSyncSvc *service.GitSyncService
+	auditSvc   *service.AuditService
+}
+
+// NewGitHandler 创建 Git 同步处理器
+func NewGitHandler(gitSyncSvc *service.GitSyncService, auditSvc *service.AuditService) *GitHandler {
+	return &GitHandler{
+		gitSyncSvc: gitSyncSvc,
+		auditSvc:   auditSvc,
+	}
+}
+
+// Sync 从关联仓库导入修改并导出尚未提交的版本
+// POST /api/projects/:id/git/sync?resolve=confighub|git
+// 未指定 resolve 时冲突文件保持不变并在 conflicts 中返回
+func (h *GitHandler) Sync(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	result, err := h.gitSyncSvc.Sync(c.Request.Context(), projectID, c.Query("resolve"))
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    projectID,
+		UserID:       &userID,
+		Action:       model.AuditActionSync,
+		ResourceType: model.AuditResourceProject,
+		ResourceID:   projectID,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		Origin:       model.OriginSync,
+	})
+
+	c.JSON(http.StatusOK, result)
+}
@@ -1,9 +1,16 @@
 package api
 
 import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"confighub/internal/middleware"
+	"confighub/internal/model"
 	"confighub/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -29,6 +36,63 @@ func getAccessKeyID(c *gin.Context) int64 {
 	return 0
 }
 
+// watchSubscriber 监听连接的调用方身份, 包含 Access Key 的监听优先级
+func watchSubscriber(c *gin.Context, projectID int64) service.Subscriber {
+	subscriber := service.Subscriber{ProjectID: projectID}
+	if authCtx := middleware.GetAuthContext(c); authCtx != nil {
+		subscriber.AccessKeyID = authCtx.AccessKeyID
+		subscriber.Priority = authCtx.Priority
+	}
+	return subscriber
+}
+
+// configAllowed 调用方的权限是否覆盖指定配置, Access Key 可通过 configs 限制可访问的配置名称
+func configAllowed(c *gin.Context, configName string) bool {
+	authCtx := middleware.GetAuthContext(c)
+	return authCtx == nil || authCtx.Permissions.AllowsConfig(configName)
+}
+
+// requireConfigAllowed 配置不在调用方权限范围内时返回 403
+func requireConfigAllowed(c *gin.Context, configName string) bool {
+	if configAllowed(c, configName) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"code":    "FORBIDDEN",
+		"message": "无权访问此配置",
+	})
+	return false
+}
+
+// ChangeOriginHeader 声明变更来源的请求头, 如 ci、sync 或 automation:renovate
+const ChangeOriginHeader = "X-Change-Origin"
+
+// changeOrigin 根据请求头和认证方式确定配置变更来源
+// 未声明时登录用户为 human, 其他调用方为 api; 只有登录用户可以声明 human
+func changeOrigin(c *gin.Context) (string, error) {
+	header := c.GetHeader(ChangeOriginHeader)
+	if header == "" {
+		if getUserID(c) > 0 && getAccessKeyID(c) == 0 {
+			return model.OriginHuman, nil
+		}
+		return model.OriginAPI, nil
+	}
+
+	origin, err := service.ParseOrigin(header)
+	if err != nil {
+		return "", err
+	}
+	if origin == model.OriginHuman && (getUserID(c) == 0 || getAccessKeyID(c) > 0) {
+		return "", service.ErrInvalidOrigin
+	}
+	return origin, nil
+}
+
+// isDryRun 请求是否为预演 (?dry_run=true): 破坏性操作只做检查并返回将受影响的数据, 不提交修改
+func isDryRun(c *gin.Context) bool {
+	return c.Query("dry_run") == "true"
+}
+
 // getProjectID 从上下文获取项目 ID
 func getProjectID(c *gin.Context) int64 {
 	if authCtx, exists := c.Get("auth_context"); exists {
@@ -39,14 +103,237 @@ func getProjectID(c *gin.Context) int64 {
 	return 0
 }
 
+// writePing 输出连通性测试结果: 调用方身份、权限和服务端时间
+// 请求携带 X-Timestamp 时附带时钟偏差, 便于排查签名过期问题
+func writePing(c *gin.Context) {
+	authCtx := middleware.GetAuthContext(c)
+	if authCtx == nil {
+		authCtx = &middleware.AuthContext{}
+	}
+
+	auth := "anonymous"
+	switch {
+	case authCtx.AccessKeyID != 0:
+		auth = "access_key"
+	case authCtx.UserID != 0:
+		auth = "user"
+	}
+
+	now := time.Now()
+	response := gin.H{
+		"name":          "_ping",
+		"pong":          true,
+		"auth":          auth,
+		"project_id":    authCtx.ProjectID,
+		"access_key_id": authCtx.AccessKeyID,
+		"permissions":   authCtx.Permissions,
+		"server_time":   now.Unix(),
+	}
+	if ts, err := strconv.ParseInt(c.GetHeader(middleware.TimestampHeader), 10, 64); err == nil {
+		response["skew_seconds"] = ts - now.Unix()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// writeCompressedJSON 输出 JSON 响应, 客户端接受 gzip 时压缩
+func writeCompressedJSON(c *gin.Context, status int, obj interface{}) {
+	if !strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+		c.JSON(status, obj)
+		return
+	}
+
+	c.Header("Content-Encoding", "gzip")
+	c.Header("Vary", "Accept-Encoding")
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(status)
+
+	gz := gzip.NewWriter(c.Writer)
+	defer gz.Close()
+	json.NewEncoder(gz).Encode(obj)
+}
+
 // handleServiceError 处理服务层错误
 func handleServiceError(c *gin.Context, err error) {
+	// YAML 校验错误附带行列位置, 供编辑器定位
+	var lintErr *service.YAMLLintError
+	if errors.As(err, &lintErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "VALIDATION_ERROR",
+			"message": "无效的 YAML 格式",
+			"details": lintErr.Error(),
+			"issues":  lintErr.Issues,
+		})
+		return
+	}
+
+	// HCL 解析错误附带行列位置
+	var hclErr *service.HCLSyntaxError
+	if errors.As(err, &hclErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "VALIDATION_ERROR",
+			"message": "无效的 HCL 格式",
+			"details": hclErr.Error(),
+			"issues":  hclErr.Issues,
+		})
+		return
+	}
+
+	// protobuf 内容不符合描述符时附带解析错误
+	if errors.Is(err, service.ErrInvalidProtobuf) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "VALIDATION_ERROR",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// 环境删除/改名的影响检查附带引用数量或冲突配置
+	var inUseErr *service.EnvironmentInUseError
+	if errors.As(err, &inUseErr) {
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    "ENVIRONMENT_IN_USE",
+			"message": inUseErr.Error(),
+			"usage":   inUseErr.Usage,
+		})
+		return
+	}
+	var conflictErr *service.EnvironmentConflictError
+	if errors.As(err, &conflictErr) {
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    "CONFLICT",
+			"message": conflictErr.Error(),
+			"configs": conflictErr.Configs,
+		})
+		return
+	}
+
+	// 发布护栏错误附带违规项和最早可发布时间
+	var guardrailErr *service.GuardrailViolationError
+	if errors.As(err, &guardrailErr) {
+		if retry := time.Until(guardrailErr.RetryAt()); retry > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(retry.Seconds())+1))
+		}
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"code":       "RELEASE_GUARDRAIL",
+			"message":    guardrailErr.Error(),
+			"violations": guardrailErr.Violations,
+		})
+		return
+	}
+
+	// 破坏消费方契约的写入或发布附带违反项
+	var contractErr *service.ContractViolationError
+	if errors.As(err, &contractErr) {
+		c.JSON(http.StatusConflict, gin.H{
+			"code":       "CONTRACT_VIOLATION",
+			"message":    contractErr.Error(),
+			"violations": contractErr.Violations,
+		})
+		return
+	}
+
+	// 入站集成推送的内容不符合 Schema 时附带校验错误
+	var schemaErr *service.IntegrationValidationError
+	if errors.As(err, &schemaErr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"code":    "VALIDATION_ERROR",
+			"message": "推送内容不符合 Schema",
+			"errors":  schemaErr.Errors,
+		})
+		return
+	}
+
+	// 配置内容不符合生效的 Schema 时附带错误明细
+	var validationErr *service.SchemaValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"code":    "SCHEMA_VALIDATION_FAILED",
+			"message": validationErr.Error(),
+			"source":  validationErr.Source,
+			"errors":  validationErr.Errors,
+		})
+		return
+	}
+
+	// 发布流水线步骤失败附带失败的步骤
+	var transformErr *service.TransformError
+	if errors.As(err, &transformErr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"code":    "RELEASE_PIPELINE_FAILED",
+			"message": transformErr.Error(),
+			"step":    transformErr.Step,
+			"index":   transformErr.Index,
+		})
+		return
+	}
+
+	// 模板或推送内容错误附带具体原因
+	if errors.Is(err, service.ErrInvalidIntegration) || errors.Is(err, service.ErrInvalidWebhook) || errors.Is(err, service.ErrInvalidPayload) || errors.Is(err, service.ErrInvalidProjectTemplate) || errors.Is(err, service.ErrInvalidReleasePipeline) || errors.Is(err, service.ErrInvalidSchemaDefaults) || errors.Is(err, service.ErrInvalidProjectBundle) || errors.Is(err, service.ErrInvalidPreflightCheck) || errors.Is(err, service.ErrInvalidProtoDescriptor) || errors.Is(err, service.ErrInvalidSchemaPolicy) || errors.Is(err, service.ErrInvalidParent) || errors.Is(err, service.ErrInvalidTransfer) || errors.Is(err, service.ErrInvalidKV) || errors.Is(err, service.ErrInvalidPatch) || errors.Is(err, service.ErrInvalidBaseVersion) || errors.Is(err, service.ErrInvalidChaos) || errors.Is(err, service.ErrInvalidPath) || errors.Is(err, service.ErrInvalidNormalization) || errors.Is(err, service.ErrInvalidEncryptedValue) || errors.Is(err, service.ErrInvalidGrayRule) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Git 命令失败附带命令输出 (已隐藏仓库凭据)
+	var gitErr *service.GitError
+	if errors.As(err, &gitErr) {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"code":    "GIT_ERROR",
+			"message": gitErr.Error(),
+		})
+		return
+	}
+
+	// 基于过期版本的更新附带当前版本和三方差异
+	var updateConflict *service.UpdateConflictError
+	if errors.As(err, &updateConflict) {
+		c.JSON(http.StatusConflict, gin.H{
+			"code":                "VERSION_CONFLICT",
+			"message":             updateConflict.Error(),
+			"base_version":        updateConflict.BaseVersion,
+			"current_version":     updateConflict.CurrentVersion,
+			"current_commit_hash": updateConflict.CurrentCommitHash,
+			"diff":                updateConflict.Diff,
+		})
+		return
+	}
+
+	// 补丁的 test 操作不满足或基础版本已过期, 附带具体原因
+	if errors.Is(err, service.ErrPatchTestFailed) || errors.Is(err, service.ErrPatchBaseOutdated) {
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    "CONFLICT",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// 宽限期错误附带可删除时间
+	if errors.Is(err, service.ErrProjectDeleteTooSoon) {
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    "CONFLICT",
+			"message": err.Error(),
+		})
+		return
+	}
+
 	switch err {
 	case service.ErrProjectNotFound:
 		c.JSON(http.StatusNotFound, gin.H{
 			"code":    "NOT_FOUND",
 			"message": "项目不存在",
 		})
+	case service.ErrProjectArchived:
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":    "PROJECT_ARCHIVED",
+			"message": "项目已归档, 不允许修改或发布",
+		})
+	case service.ErrProjectNotArchived:
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    "CONFLICT",
+			"message": "项目未归档, 请先归档后再删除",
+		})
 	case service.ErrProjectNameExists:
 		c.JSON(http.StatusConflict, gin.H{
 			"code":    "CONFLICT",
@@ -62,11 +349,26 @@ func handleServiceError(c *gin.Context, err error) {
 			"code":    "NOT_FOUND",
 			"message": "配置不存在",
 		})
+	case service.ErrConfigNotExistAt:
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "NOT_FOUND",
+			"message": err.Error(),
+		})
 	case service.ErrConfigNameExists:
 		c.JSON(http.StatusConflict, gin.H{
 			"code":    "CONFLICT",
 			"message": "配置名称已存在",
 		})
+	case service.ErrConfigHasChildren:
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    "CONFLICT",
+			"message": err.Error(),
+		})
+	case service.ErrConfigVersionConflict:
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    "VERSION_CONFLICT",
+			"message": err.Error(),
+		})
 	case service.ErrInvalidJSON:
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code":    "VALIDATION_ERROR",
@@ -77,6 +379,11 @@ func handleServiceError(c *gin.Context, err error) {
 			"code":    "VALIDATION_ERROR",
 			"message": "无效的 YAML 格式",
 		})
+	case service.ErrInvalidHCL:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "VALIDATION_ERROR",
+			"message": "无效的 HCL 格式",
+		})
 	case service.ErrInvalidFileType:
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code":    "VALIDATION_ERROR",
@@ -87,16 +394,111 @@ func handleServiceError(c *gin.Context, err error) {
 			"code":    "NOT_FOUND",
 			"message": "版本不存在",
 		})
+	case service.ErrEnvironmentNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "NOT_FOUND",
+			"message": "环境不存在",
+		})
+	case service.ErrEnvironmentExists:
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    "CONFLICT",
+			"message": "环境已存在",
+		})
+	case service.ErrEnvironmentLast, service.ErrEnvironmentSameName, service.ErrInvalidVariableName, service.ErrInvalidDiffRules, service.ErrInvalidReleaseGuardrails, service.ErrInvalidRiskPolicy, service.ErrInvalidSandboxTTL, service.ErrInvalidContract, service.ErrInvalidUsageMonth, service.ErrInvalidOrigin, service.ErrGitNotLinked, service.ErrInvalidGitResolve, service.ErrInvalidGitRepoURL, service.ErrInvalidGitBranch, service.ErrInvalidKeyConfigs, service.ErrInvalidKeyPriority, service.ErrInvalidBundleTTL, service.ErrInvalidLocale, service.ErrInvalidConflictPolicy, service.ErrInvalidSigningKey, service.ErrInvalidVersionRetention:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": err.Error(),
+		})
 	case service.ErrKeyNotFound:
 		c.JSON(http.StatusNotFound, gin.H{
 			"code":    "NOT_FOUND",
 			"message": "密钥不存在",
 		})
+	case service.ErrContractNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "NOT_FOUND",
+			"message": "消费契约不存在",
+		})
+	case service.ErrProjectTemplateNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "NOT_FOUND",
+			"message": "项目模板不存在",
+		})
+	case service.ErrWebhookNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "NOT_FOUND",
+			"message": "Webhook 不存在",
+		})
+	case service.ErrWebhookDeliveryNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "NOT_FOUND",
+			"message": "投递记录不存在",
+		})
+	case service.ErrWebhookDeliveryPending:
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    "CONFLICT",
+			"message": "投递仍在重试中, 无法重放",
+		})
+	case service.ErrSigningKeyNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "NOT_FOUND",
+			"message": "签名密钥不存在",
+		})
+	case service.ErrSigningKeyExists:
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    "CONFLICT",
+			"message": err.Error(),
+		})
+	case service.ErrInvalidSignature:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"code":    "SIGNATURE_INVALID",
+			"message": err.Error(),
+		})
+	case service.ErrIntegrationNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "NOT_FOUND",
+			"message": "入站集成不存在",
+		})
+	case service.ErrInvalidIntegrationToken:
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    "UNAUTHORIZED",
+			"message": err.Error(),
+		})
+	case service.ErrIntegrationDisabled:
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":    "FORBIDDEN",
+			"message": err.Error(),
+		})
 	case service.ErrReleaseNotFound:
 		c.JSON(http.StatusNotFound, gin.H{
 			"code":    "NOT_FOUND",
 			"message": "发布记录不存在",
 		})
+	case service.ErrReleaseNotPending, service.ErrAlreadyApproved:
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    "CONFLICT",
+			"message": err.Error(),
+		})
+	case service.ErrSelfApproval:
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":    "FORBIDDEN",
+			"message": err.Error(),
+		})
+	case service.ErrGrayReleaseNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "NOT_FOUND",
+			"message": "灰度发布不存在",
+		})
+	case service.ErrGrayReleaseActive:
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    "CONFLICT",
+			"message": "已有活跃的灰度发布",
+		})
+	case service.ErrInvalidGrayPercentage, service.ErrGrayEnvironmentMissing:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "VALIDATION_ERROR",
+			"message": err.Error(),
+		})
 	case service.ErrInvalidSchema:
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code":    "VALIDATION_ERROR",
@@ -107,11 +509,51 @@ func handleServiceError(c *gin.Context, err error) {
 			"code":    "NOT_FOUND",
 			"message": "Schema 不存在",
 		})
+	case service.ErrProtoDescriptorNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "NOT_FOUND",
+			"message": "Protobuf 描述符不存在",
+		})
+	case service.ErrReplicationProjectNotAllowed, service.ErrReplicationNotPushTarget:
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":    "FORBIDDEN",
+			"message": err.Error(),
+		})
+	case service.ErrConfigKeyNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "NOT_FOUND",
+			"message": "键不存在",
+		})
+	case service.ErrConfigKeyConflict:
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    "CONFLICT",
+			"message": err.Error(),
+		})
+	case service.ErrTransferNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "NOT_FOUND",
+			"message": "转移申请不存在",
+		})
+	case service.ErrTransferForbidden, service.ErrDecryptForbidden:
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":    "FORBIDDEN",
+			"message": err.Error(),
+		})
+	case service.ErrTransferNotPending, service.ErrTransferPending:
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    "CONFLICT",
+			"message": err.Error(),
+		})
+	case service.ErrReplicationDisabled, service.ErrReplicationInvalidSnapshot:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": err.Error(),
+		})
 	default:
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    "INTERNAL_ERROR",
 			"message": "服务器内部错误",
-			"details": err.Error(),
+			"details": service.RedactCredentials(err.Error()),
 		})
 	}
 }
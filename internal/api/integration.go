@@ -0,0 +1,285 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"confighub/internal/model"
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IntegrationTokenHeader 入站集成令牌请求头, 也可使用 Authorization: Bearer
+const IntegrationTokenHeader = "X-Integration-Token"
+
+// maxInboundPayload 推送内容大小上限
+const maxInboundPayload = 1 << 20
+
+// IntegrationHandler 入站集成处理器
+type IntegrationHandler struct {
+	integrationSvc *service.IntegrationService
+	auditSvc       *service.AuditService
+}
+
+// NewIntegrationHandler 创建入站集成处理器
+func NewIntegrationHandler(integrationSvc *service.IntegrationService, auditSvc *service.AuditService) *IntegrationHandler {
+	return &IntegrationHandler{
+		integrationSvc: integrationSvc,
+		auditSvc:       auditSvc,
+	}
+}
+
+// Create 创建入站集成
+// POST /api/projects/:id/integrations
+func (h *IntegrationHandler) Create(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	var req service.IntegrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	userID := getUserID(c)
+	integration, token, err := h.integrationSvc.Create(c.Request.Context(), projectID, &req, userID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    projectID,
+		UserID:       &userID,
+		Action:       model.AuditActionCreate,
+		ResourceType: model.AuditResourceIntegration,
+		ResourceID:   integration.ID,
+		ResourceName: integration.Name,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"integration": integration,
+		"token":       token, // 仅此一次返回
+	})
+}
+
+// List 获取项目下的入站集成
+// GET /api/projects/:id/integrations
+func (h *IntegrationHandler) List(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	integrations, err := h.integrationSvc.List(c.Request.Context(), projectID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"integrations": integrations,
+	})
+}
+
+// Update 更新入站集成
+// PUT /api/integrations/:id
+func (h *IntegrationHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的集成 ID",
+		})
+		return
+	}
+
+	var req service.IntegrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	integration, err := h.integrationSvc.Update(c.Request.Context(), id, &req)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    integration.ProjectID,
+		UserID:       &userID,
+		Action:       model.AuditActionUpdate,
+		ResourceType: model.AuditResourceIntegration,
+		ResourceID:   integration.ID,
+		ResourceName: integration.Name,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"integration": integration,
+	})
+}
+
+// Delete 删除入站集成
+// DELETE /api/integrations/:id
+func (h *IntegrationHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的集成 ID",
+		})
+		return
+	}
+
+	integration, err := h.integrationSvc.Get(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+	if err := h.integrationSvc.Delete(c.Request.Context(), id); err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    integration.ProjectID,
+		UserID:       &userID,
+		Action:       model.AuditActionDelete,
+		ResourceType: model.AuditResourceIntegration,
+		ResourceID:   id,
+		ResourceName: integration.Name,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "删除成功",
+	})
+}
+
+// RegenerateToken 重新生成集成令牌
+// POST /api/integrations/:id/regenerate
+func (h *IntegrationHandler) RegenerateToken(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的集成 ID",
+		})
+		return
+	}
+
+	integration, token, err := h.integrationSvc.RegenerateToken(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"integration": integration,
+		"token":       token,
+	})
+}
+
+// Receive 接收外部系统推送, 映射后生成配置新版本
+// POST /api/v1/inbound/:id
+func (h *IntegrationHandler) Receive(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的集成 ID",
+		})
+		return
+	}
+
+	token := c.GetHeader(IntegrationTokenHeader)
+	if token == "" {
+		token = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	}
+	integration, err := h.integrationSvc.Authenticate(c.Request.Context(), id, token)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxInboundPayload))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"code":    "PAYLOAD_TOO_LARGE",
+				"message": "推送内容超过 1MB",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "读取推送内容失败",
+		})
+		return
+	}
+
+	result, err := h.integrationSvc.Receive(c.Request.Context(), integration, payload)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	if !result.Unchanged {
+		requestBody := string(payload)
+		if len(requestBody) > 2000 {
+			requestBody = requestBody[:2000] + "..."
+		}
+		h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+			ProjectID:    integration.ProjectID,
+			Action:       model.AuditActionUpdate,
+			ResourceType: model.AuditResourceConfig,
+			ResourceID:   result.Config.ID,
+			ResourceName: result.Config.Name,
+			IPAddress:    c.ClientIP(),
+			UserAgent:    c.Request.UserAgent(),
+			RequestBody:  requestBody,
+			Origin:       model.OriginAutomationPrefix + "integration",
+		})
+	}
+
+	resp := gin.H{
+		"config_id": result.Config.ID,
+		"version":   result.Version,
+		"unchanged": result.Unchanged,
+	}
+	if len(result.Warnings) > 0 {
+		resp["contract_warnings"] = result.Warnings
+	}
+	c.JSON(http.StatusOK, resp)
+}
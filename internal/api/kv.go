@@ -0,0 +1,365 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"confighub/internal/middleware"
+	"confighub/internal/model"
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KVHandler kv 配置的单键读写处理器
+type KVHandler struct {
+	kvSvc     *service.KVService
+	configSvc *service.ConfigService
+	auditSvc  *service.AuditService
+}
+
+// NewKVHandler 创建 kv 配置处理器
+func NewKVHandler(kvSvc *service.KVService, configSvc *service.ConfigService, auditSvc *service.AuditService) *KVHandler {
+	return &KVHandler{
+		kvSvc:     kvSvc,
+		configSvc: configSvc,
+		auditSvc:  auditSvc,
+	}
+}
+
+// publicKeyRequest 公开接口修改单个键的请求
+type publicKeyRequest struct {
+	Name      string  `json:"name" binding:"required"`
+	Namespace string  `json:"namespace"`
+	Env       string  `json:"env"`
+	Key       string  `json:"key" binding:"required"`
+	Value     *string `json:"value" binding:"required"`
+	Revision  *int    `json:"revision"`
+	Message   string  `json:"message"`
+}
+
+// List 获取 kv 配置的所有键
+// GET /api/configs/:id/keys
+func (h *KVHandler) List(c *gin.Context) {
+	id, ok := kvConfigID(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.kvSvc.List(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"keys": keys,
+	})
+}
+
+// Get 获取单个键
+// GET /api/configs/:id/keys/:key
+func (h *KVHandler) Get(c *gin.Context) {
+	id, ok := kvConfigID(c)
+	if !ok {
+		return
+	}
+
+	key, err := h.kvSvc.Get(c.Request.Context(), id, c.Param("key"))
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// History 获取单个键的修改历史
+// GET /api/configs/:id/keys/:key/history?limit=100
+func (h *KVHandler) History(c *gin.Context) {
+	id, ok := kvConfigID(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	history, err := h.kvSvc.History(c.Request.Context(), id, c.Param("key"), limit)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"history": history,
+	})
+}
+
+// Set 设置单个键
+// PUT /api/configs/:id/keys/:key
+func (h *KVHandler) Set(c *gin.Context) {
+	id, ok := kvConfigID(c)
+	if !ok {
+		return
+	}
+
+	var req service.SetKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	origin, err := changeOrigin(c)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+	req.Origin = origin
+
+	userID := getUserID(c)
+	author := "user"
+	if userID > 0 {
+		author = strconv.FormatInt(userID, 10)
+	}
+
+	key, err := h.kvSvc.Set(c.Request.Context(), id, c.Param("key"), &req, author)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	h.audit(c, id, key.Key, key.Revision, model.AuditActionUpdate, origin)
+	c.JSON(http.StatusOK, key)
+}
+
+// Delete 删除单个键
+// DELETE /api/configs/:id/keys/:key?revision=3
+func (h *KVHandler) Delete(c *gin.Context) {
+	id, ok := kvConfigID(c)
+	if !ok {
+		return
+	}
+	revision, ok := kvRevision(c)
+	if !ok {
+		return
+	}
+
+	origin, err := changeOrigin(c)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	author := "user"
+	if userID > 0 {
+		author = strconv.FormatInt(userID, 10)
+	}
+
+	key := c.Param("key")
+	if err := h.kvSvc.Delete(c.Request.Context(), id, key, revision, c.Query("message"), author, origin); err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	h.audit(c, id, key, 0, model.AuditActionDelete, origin)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "删除成功",
+	})
+}
+
+// PublicGet 通过 Access Key 获取单个键
+// GET /api/v1/config/key?name=xxx&namespace=xxx&env=xxx&key=xxx
+func (h *KVHandler) PublicGet(c *gin.Context) {
+	config, ok := h.publicConfig(c, c.Query("name"), c.Query("namespace"), c.Query("env"))
+	if !ok {
+		return
+	}
+
+	key, err := h.kvSvc.Get(c.Request.Context(), config.ID, c.Query("key"))
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// PublicSet 通过 Access Key 设置单个键
+// PATCH /api/v1/config/key
+func (h *KVHandler) PublicSet(c *gin.Context) {
+	var req publicKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	config, ok := h.publicConfig(c, req.Name, req.Namespace, req.Env)
+	if !ok {
+		return
+	}
+
+	origin, err := changeOrigin(c)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	message := req.Message
+	if message == "" {
+		message = "通过 API 设置 " + req.Key
+	}
+	key, err := h.kvSvc.Set(c.Request.Context(), config.ID, req.Key, &service.SetKeyRequest{
+		Value:    req.Value,
+		Revision: req.Revision,
+		Message:  message,
+		Origin:   origin,
+	}, publicAuthor(c))
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	h.audit(c, config.ID, key.Key, key.Revision, model.AuditActionUpdate, origin)
+	c.JSON(http.StatusOK, key)
+}
+
+// PublicDelete 通过 Access Key 删除单个键
+// DELETE /api/v1/config/key?name=xxx&namespace=xxx&env=xxx&key=xxx&revision=3
+func (h *KVHandler) PublicDelete(c *gin.Context) {
+	config, ok := h.publicConfig(c, c.Query("name"), c.Query("namespace"), c.Query("env"))
+	if !ok {
+		return
+	}
+	revision, ok := kvRevision(c)
+	if !ok {
+		return
+	}
+
+	origin, err := changeOrigin(c)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	key := c.Query("key")
+	message := c.Query("message")
+	if message == "" {
+		message = "通过 API 删除 " + key
+	}
+	if err := h.kvSvc.Delete(c.Request.Context(), config.ID, key, revision, message, publicAuthor(c), origin); err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	h.audit(c, config.ID, key, 0, model.AuditActionDelete, origin)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "删除成功",
+	})
+}
+
+// publicConfig 按 Access Key 所属项目查找配置, 失败时写入响应
+func (h *KVHandler) publicConfig(c *gin.Context, name, namespace, env string) (*model.Config, bool) {
+	projectID := getProjectID(c)
+	if projectID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    "UNAUTHORIZED",
+			"message": "未授权访问",
+		})
+		return nil, false
+	}
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "缺少配置名称",
+		})
+		return nil, false
+	}
+	if !requireConfigAllowed(c, name) {
+		return nil, false
+	}
+
+	config, _, err := h.configSvc.GetByAccessKey(c.Request.Context(), projectID, name, namespace, env)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "NOT_FOUND",
+			"message": "配置不存在",
+		})
+		return nil, false
+	}
+	return config, true
+}
+
+// audit 记录单键修改的审计日志, 请求体只记录键名和修订号
+func (h *KVHandler) audit(c *gin.Context, configID int64, key string, revision int, action, origin string) {
+	config, err := h.configSvc.GetConfigByID(c.Request.Context(), configID)
+	if err != nil {
+		return
+	}
+	body, _ := json.Marshal(gin.H{"key": key, "revision": revision})
+	entry := &model.AuditLog{
+		ProjectID:    config.ProjectID,
+		Action:       action,
+		ResourceType: model.AuditResourceConfig,
+		ResourceID:   configID,
+		ResourceName: config.Name,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		RequestBody:  string(body),
+		Origin:       origin,
+	}
+	if userID := getUserID(c); userID > 0 {
+		entry.UserID = &userID
+	}
+	if keyID := getAccessKeyID(c); keyID > 0 {
+		entry.AccessKeyID = &keyID
+	}
+	h.auditSvc.Log(c.Request.Context(), entry)
+}
+
+// publicAuthor 公开接口写入的作者: 使用 Access Key 时为 key:<ID>, 否则为 api
+func publicAuthor(c *gin.Context) string {
+	authCtx := middleware.GetAuthContext(c)
+	if authCtx != nil && authCtx.AccessKeyID > 0 {
+		return "key:" + strconv.FormatInt(authCtx.AccessKeyID, 10)
+	}
+	return "api"
+}
+
+// kvConfigID 解析路径中的配置 ID, 无效时返回 400
+func kvConfigID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// kvRevision 解析查询参数中期望的键修订号, 未指定时返回空
+func kvRevision(c *gin.Context) (*int, bool) {
+	value := c.Query("revision")
+	if value == "" {
+		return nil, true
+	}
+	revision, err := strconv.Atoi(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的修订号",
+		})
+		return nil, false
+	}
+	return &revision, true
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"confighub/internal/model"
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocaleHandler 多语言设置处理器
+type LocaleHandler struct {
+	localeSvc *service.LocaleService
+	auditSvc  *service.AuditService
+}
+
+// NewLocaleHandler 创建多语言设置处理器
+func NewLocaleHandler(localeSvc *service.LocaleService, auditSvc *service.AuditService) *LocaleHandler {
+	return &LocaleHandler{
+		localeSvc: localeSvc,
+		auditSvc:  auditSvc,
+	}
+}
+
+// Get 获取项目的多语言设置
+// GET /api/projects/:id/locales
+func (h *LocaleHandler) Get(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	settings, err := h.localeSvc.Get(c.Request.Context(), projectID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"locales": settings,
+	})
+}
+
+// Update 更新项目的多语言设置
+// PUT /api/projects/:id/locales
+func (h *LocaleHandler) Update(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	var settings service.LocaleSettings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.localeSvc.Update(c.Request.Context(), projectID, &settings); err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	body, _ := json.Marshal(settings)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    projectID,
+		UserID:       &userID,
+		Action:       model.AuditActionUpdate,
+		ResourceType: model.AuditResourceProject,
+		ResourceID:   projectID,
+		ResourceName: "locales",
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		RequestBody:  string(body),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"locales": settings,
+	})
+}
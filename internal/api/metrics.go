@@ -0,0 +1,194 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsHandler 指标处理器
+type MetricsHandler struct {
+	metricsSvc   *service.MetricsService
+	hotCache     *service.HotConfigCache
+	readCache    *service.ConfigReadCache
+	notifySvc    *service.NotificationService
+	retentionSvc *service.RetentionService
+	resolveStats *service.ResolveStats
+}
+
+// NewMetricsHandler 创建指标处理器
+func NewMetricsHandler(metricsSvc *service.MetricsService, hotCache *service.HotConfigCache, readCache *service.ConfigReadCache, notifySvc *service.NotificationService, retentionSvc *service.RetentionService, resolveStats *service.ResolveStats) *MetricsHandler {
+	return &MetricsHandler{
+		metricsSvc:   metricsSvc,
+		hotCache:     hotCache,
+		readCache:    readCache,
+		notifySvc:    notifySvc,
+		retentionSvc: retentionSvc,
+		resolveStats: resolveStats,
+	}
+}
+
+// configGauges 配置指标对应的 Prometheus gauge
+var configGauges = []struct {
+	name  string
+	help  string
+	value func(m *service.ConfigMetrics) int
+}{
+	{"confighub_config_size_bytes", "Size of the latest config version in bytes", func(m *service.ConfigMetrics) int { return m.ByteSize }},
+	{"confighub_config_keys", "Number of keys in the latest config version", func(m *service.ConfigMetrics) int { return m.KeyCount }},
+	{"confighub_config_depth", "Maximum nesting depth of the latest config version", func(m *service.ConfigMetrics) int { return m.Depth }},
+	{"confighub_config_encrypted_fields", "Number of encrypted fields in the latest config version", func(m *service.ConfigMetrics) int { return m.EncryptedFieldCount }},
+}
+
+// Prometheus 以 Prometheus 文本格式导出配置指标
+// GET /metrics
+func (h *MetricsHandler) Prometheus(c *gin.Context) {
+	metrics, err := h.metricsSvc.Snapshot(c.Request.Context())
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	var b strings.Builder
+	for _, gauge := range configGauges {
+		fmt.Fprintf(&b, "# HELP %s %s\n", gauge.name, gauge.help)
+		fmt.Fprintf(&b, "# TYPE %s gauge\n", gauge.name)
+		for _, m := range metrics {
+			fmt.Fprintf(&b, "%s{project_id=\"%d\",config=\"%s\",namespace=\"%s\",environment=\"%s\"} %d\n",
+				gauge.name, m.ProjectID, escapeLabel(m.Name), escapeLabel(m.Namespace), escapeLabel(m.Environment), gauge.value(m))
+		}
+	}
+
+	// 热点缓存命中情况
+	stats := h.hotCache.Stats()
+	fmt.Fprintf(&b, "# HELP confighub_hot_cache_entries Number of configs in the hot read cache\n")
+	fmt.Fprintf(&b, "# TYPE confighub_hot_cache_entries gauge\n")
+	fmt.Fprintf(&b, "confighub_hot_cache_entries %d\n", stats.Size)
+	fmt.Fprintf(&b, "# HELP confighub_hot_cache_hits_total Public config reads served from the hot cache\n")
+	fmt.Fprintf(&b, "# TYPE confighub_hot_cache_hits_total counter\n")
+	fmt.Fprintf(&b, "confighub_hot_cache_hits_total %d\n", stats.Hits)
+	fmt.Fprintf(&b, "# HELP confighub_hot_cache_misses_total Public config reads that missed the hot cache\n")
+	fmt.Fprintf(&b, "# TYPE confighub_hot_cache_misses_total counter\n")
+	fmt.Fprintf(&b, "confighub_hot_cache_misses_total %d\n", stats.Misses)
+
+	// Redis 读取缓存命中情况
+	if readStats := h.readCache.Stats(); readStats.Enabled {
+		fmt.Fprintf(&b, "# HELP confighub_read_cache_hits_total Config lookups served from the Redis read cache\n")
+		fmt.Fprintf(&b, "# TYPE confighub_read_cache_hits_total counter\n")
+		fmt.Fprintf(&b, "confighub_read_cache_hits_total %d\n", readStats.Hits)
+		fmt.Fprintf(&b, "# HELP confighub_read_cache_misses_total Config lookups that missed the Redis read cache\n")
+		fmt.Fprintf(&b, "# TYPE confighub_read_cache_misses_total counter\n")
+		fmt.Fprintf(&b, "confighub_read_cache_misses_total %d\n", readStats.Misses)
+		fmt.Fprintf(&b, "# HELP confighub_read_cache_errors_total Redis read cache operations that failed\n")
+		fmt.Fprintf(&b, "# TYPE confighub_read_cache_errors_total counter\n")
+		fmt.Fprintf(&b, "confighub_read_cache_errors_total %d\n", readStats.Errors)
+	}
+
+	// 监听连接与变更分发
+	fanout := h.notifySvc.FanoutStats()
+	fmt.Fprintf(&b, "# HELP confighub_watch_subscribers Number of open watch and event stream connections\n")
+	fmt.Fprintf(&b, "# TYPE confighub_watch_subscribers gauge\n")
+	fmt.Fprintf(&b, "confighub_watch_subscribers %d\n", fanout.Subscribers)
+	fmt.Fprintf(&b, "# HELP confighub_watch_delivered_total Config changes delivered to watchers\n")
+	fmt.Fprintf(&b, "# TYPE confighub_watch_delivered_total counter\n")
+	fmt.Fprintf(&b, "confighub_watch_delivered_total %d\n", fanout.Delivered)
+	fmt.Fprintf(&b, "# HELP confighub_watch_dropped_total Config changes dropped because a watcher was not keeping up\n")
+	fmt.Fprintf(&b, "# TYPE confighub_watch_dropped_total counter\n")
+	fmt.Fprintf(&b, "confighub_watch_dropped_total %d\n", fanout.Dropped)
+	fmt.Fprintf(&b, "# HELP confighub_watch_fanout_seconds Time from a config change to delivery to all watchers\n")
+	fmt.Fprintf(&b, "# TYPE confighub_watch_fanout_seconds histogram\n")
+	var cumulative uint64
+	for i, upper := range service.FanoutLatencyBuckets {
+		cumulative += fanout.Latency[i]
+		fmt.Fprintf(&b, "confighub_watch_fanout_seconds_bucket{le=\"%g\"} %d\n", upper, cumulative)
+	}
+	fmt.Fprintf(&b, "confighub_watch_fanout_seconds_bucket{le=\"+Inf\"} %d\n", fanout.LatencyCount)
+	fmt.Fprintf(&b, "confighub_watch_fanout_seconds_sum %g\n", fanout.LatencySum)
+	fmt.Fprintf(&b, "confighub_watch_fanout_seconds_count %d\n", fanout.LatencyCount)
+
+	// 按监听优先级分类的分发情况
+	shedding := 0
+	if fanout.Shedding {
+		shedding = 1
+	}
+	fmt.Fprintf(&b, "# HELP confighub_watch_shedding Whether watch load shedding is active\n")
+	fmt.Fprintf(&b, "# TYPE confighub_watch_shedding gauge\n")
+	fmt.Fprintf(&b, "confighub_watch_shedding %d\n", shedding)
+	fmt.Fprintf(&b, "# HELP confighub_watch_class_subscribers Open watch connections by key priority class\n")
+	fmt.Fprintf(&b, "# TYPE confighub_watch_class_subscribers gauge\n")
+	for _, cs := range fanout.Classes {
+		fmt.Fprintf(&b, "confighub_watch_class_subscribers{class=\"%s\"} %d\n", cs.Class, cs.Subscribers)
+	}
+	fmt.Fprintf(&b, "# HELP confighub_watch_class_delivered_total Config changes delivered to watchers by key priority class\n")
+	fmt.Fprintf(&b, "# TYPE confighub_watch_class_delivered_total counter\n")
+	for _, cs := range fanout.Classes {
+		fmt.Fprintf(&b, "confighub_watch_class_delivered_total{class=\"%s\"} %d\n", cs.Class, cs.Delivered)
+	}
+	fmt.Fprintf(&b, "# HELP confighub_watch_class_dropped_total Config changes dropped by key priority class\n")
+	fmt.Fprintf(&b, "# TYPE confighub_watch_class_dropped_total counter\n")
+	for _, cs := range fanout.Classes {
+		fmt.Fprintf(&b, "confighub_watch_class_dropped_total{class=\"%s\"} %d\n", cs.Class, cs.Dropped)
+	}
+	fmt.Fprintf(&b, "# HELP confighub_watch_throttled_total Watch requests rejected during load shedding by key priority class\n")
+	fmt.Fprintf(&b, "# TYPE confighub_watch_throttled_total counter\n")
+	for _, cs := range fanout.Classes {
+		fmt.Fprintf(&b, "confighub_watch_throttled_total{class=\"%s\"} %d\n", cs.Class, cs.Throttled)
+	}
+	fmt.Fprintf(&b, "# HELP confighub_watch_class_fanout_seconds Time from a config change to delivery to all watchers of a key priority class\n")
+	fmt.Fprintf(&b, "# TYPE confighub_watch_class_fanout_seconds histogram\n")
+	for _, cs := range fanout.Classes {
+		var cumulative uint64
+		for i, upper := range service.FanoutLatencyBuckets {
+			cumulative += cs.Latency[i]
+			fmt.Fprintf(&b, "confighub_watch_class_fanout_seconds_bucket{class=\"%s\",le=\"%g\"} %d\n", cs.Class, upper, cumulative)
+		}
+		fmt.Fprintf(&b, "confighub_watch_class_fanout_seconds_bucket{class=\"%s\",le=\"+Inf\"} %d\n", cs.Class, cs.LatencyCount)
+		fmt.Fprintf(&b, "confighub_watch_class_fanout_seconds_sum{class=\"%s\"} %g\n", cs.Class, cs.LatencySum)
+		fmt.Fprintf(&b, "confighub_watch_class_fanout_seconds_count{class=\"%s\"} %d\n", cs.Class, cs.LatencyCount)
+	}
+
+	// 公开读取的配置解析耗时
+	fmt.Fprintf(&b, "# HELP confighub_config_resolve_seconds Time spent per public config read in each resolution stage (db, cache, gray, decrypt)\n")
+	fmt.Fprintf(&b, "# TYPE confighub_config_resolve_seconds histogram\n")
+	for _, st := range h.resolveStats.Stats() {
+		var cumulative uint64
+		for i, upper := range service.ResolveLatencyBuckets {
+			cumulative += st.Latency[i]
+			fmt.Fprintf(&b, "confighub_config_resolve_seconds_bucket{stage=\"%s\",le=\"%g\"} %d\n", st.Stage, upper, cumulative)
+		}
+		fmt.Fprintf(&b, "confighub_config_resolve_seconds_bucket{stage=\"%s\",le=\"+Inf\"} %d\n", st.Stage, st.LatencyCount)
+		fmt.Fprintf(&b, "confighub_config_resolve_seconds_sum{stage=\"%s\"} %g\n", st.Stage, st.LatencySum)
+		fmt.Fprintf(&b, "confighub_config_resolve_seconds_count{stage=\"%s\"} %d\n", st.Stage, st.LatencyCount)
+	}
+
+	// 版本清理
+	prune := h.retentionSvc.Stats()
+	fmt.Fprintf(&b, "# HELP confighub_version_prune_runs_total Version pruning runs, including failed ones\n")
+	fmt.Fprintf(&b, "# TYPE confighub_version_prune_runs_total counter\n")
+	fmt.Fprintf(&b, "confighub_version_prune_runs_total %d\n", prune.Runs)
+	fmt.Fprintf(&b, "# HELP confighub_version_prune_failures_total Version pruning runs that stopped on an error\n")
+	fmt.Fprintf(&b, "# TYPE confighub_version_prune_failures_total counter\n")
+	fmt.Fprintf(&b, "confighub_version_prune_failures_total %d\n", prune.Failures)
+	fmt.Fprintf(&b, "# HELP confighub_versions_pruned_total Config versions deleted by the retention policy\n")
+	fmt.Fprintf(&b, "# TYPE confighub_versions_pruned_total counter\n")
+	fmt.Fprintf(&b, "confighub_versions_pruned_total %d\n", prune.Versions)
+	if prune.LastRunAt != nil {
+		fmt.Fprintf(&b, "# HELP confighub_version_prune_last_run_timestamp_seconds Start time of the last version pruning run\n")
+		fmt.Fprintf(&b, "# TYPE confighub_version_prune_last_run_timestamp_seconds gauge\n")
+		fmt.Fprintf(&b, "confighub_version_prune_last_run_timestamp_seconds %d\n", prune.LastRunAt.Unix())
+		fmt.Fprintf(&b, "# HELP confighub_version_prune_last_duration_seconds Duration of the last version pruning run\n")
+		fmt.Fprintf(&b, "# TYPE confighub_version_prune_last_duration_seconds gauge\n")
+		fmt.Fprintf(&b, "confighub_version_prune_last_duration_seconds %g\n", prune.LastDuration)
+	}
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
+
+// escapeLabel 转义 Prometheus 标签值
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
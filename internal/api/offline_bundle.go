@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"confighub/internal/middleware"
+	"confighub/internal/model"
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OfflineBundleHandler 离线配置包处理器
+type OfflineBundleHandler struct {
+	offlineSvc *service.OfflineBundleService
+}
+
+// NewOfflineBundleHandler 创建离线配置包处理器
+func NewOfflineBundleHandler(offlineSvc *service.OfflineBundleService) *OfflineBundleHandler {
+	return &OfflineBundleHandler{
+		offlineSvc: offlineSvc,
+	}
+}
+
+// Export 导出签名的离线配置包, 仅包含密钥可访问的已发布配置
+// GET /api/v1/offline-bundle?namespace=xxx&env=xxx&ttl_hours=168
+func (h *OfflineBundleHandler) Export(c *gin.Context) {
+	projectID := getProjectID(c)
+	if projectID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    "UNAUTHORIZED",
+			"message": "未授权访问",
+		})
+		return
+	}
+
+	var ttl time.Duration
+	if raw := c.Query("ttl_hours"); raw != "" {
+		hours, err := strconv.Atoi(raw)
+		if err != nil || hours <= 0 {
+			handleServiceError(c, service.ErrInvalidBundleTTL)
+			return
+		}
+		ttl = time.Duration(hours) * time.Hour
+	}
+
+	decrypt := false
+	if authCtx := middleware.GetAuthContext(c); authCtx != nil {
+		decrypt = authCtx.Permissions.Decrypt
+	}
+
+	bundle, payload, err := h.offlineSvc.Export(c.Request.Context(), projectID, c.Query("namespace"), c.Query("env"), ttl, func(name string) bool {
+		return configAllowed(c, name)
+	}, decrypt)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\"confighub-"+payload.Namespace+"-"+payload.Environment+".bundle.json\"")
+	c.Header("X-Bundle-Expires-At", payload.ExpiresAt.UTC().Format(time.RFC3339))
+	c.JSON(http.StatusOK, bundle)
+}
+
+// PublicKey 返回项目离线配置包的校验公钥, 用于预置到设备
+// GET /api/v1/offline-bundle/public-key?project_id=1
+func (h *OfflineBundleHandler) PublicKey(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Query("project_id"), 10, 64)
+	if err != nil || projectID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "缺少或无效的项目 ID",
+		})
+		return
+	}
+
+	publicKey, keyID := h.offlineSvc.PublicKey(projectID)
+	c.JSON(http.StatusOK, gin.H{
+		"algorithm":  model.OfflineBundleAlgorithm,
+		"key_id":     keyID,
+		"project_id": projectID,
+		"public_key": publicKey,
+	})
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+
+	"confighub/internal/model"
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OnboardingHandler 项目自助开通处理器
+type OnboardingHandler struct {
+	onboardingSvc *service.OnboardingService
+	auditSvc      *service.AuditService
+}
+
+// NewOnboardingHandler 创建项目开通处理器
+func NewOnboardingHandler(onboardingSvc *service.OnboardingService, auditSvc *service.AuditService) *OnboardingHandler {
+	return &OnboardingHandler{
+		onboardingSvc: onboardingSvc,
+		auditSvc:      auditSvc,
+	}
+}
+
+// Templates 获取可用的项目模板
+// GET /api/projects/templates
+func (h *OnboardingHandler) Templates(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"templates": h.onboardingSvc.Templates(),
+	})
+}
+
+// CreateFromTemplate 按模板创建项目
+// POST /api/projects/from-template
+func (h *OnboardingHandler) CreateFromTemplate(c *gin.Context) {
+	var req service.CreateFromTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	origin, err := changeOrigin(c)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+	req.Origin = origin
+
+	userID := getUserID(c)
+	result, err := h.onboardingSvc.CreateFromTemplate(c.Request.Context(), &req, userID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	// 记录审计日志
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    result.Project.ID,
+		UserID:       &userID,
+		Action:       model.AuditActionCreate,
+		ResourceType: model.AuditResourceProject,
+		ResourceID:   result.Project.ID,
+		ResourceName: result.Project.Name,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		RequestBody:  "template: " + req.Template,
+		Origin:       req.Origin,
+	})
+
+	// 密钥和集成令牌仅此一次返回
+	c.JSON(http.StatusCreated, result)
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PreflightHandler 发布预检处理器
+type PreflightHandler struct {
+	preflightSvc *service.PreflightService
+}
+
+// NewPreflightHandler 创建发布预检处理器
+func NewPreflightHandler(preflightSvc *service.PreflightService) *PreflightHandler {
+	return &PreflightHandler{
+		preflightSvc: preflightSvc,
+	}
+}
+
+// Run 对目标环境的全部配置执行发布预检, 返回汇总报告
+// GET /api/projects/:id/preflight?env=prod&baseline=staging&checks=schema,references&strict=true
+func (h *PreflightHandler) Run(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	env := c.Query("env")
+	if env == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请指定目标环境",
+		})
+		return
+	}
+
+	checks, err := service.ParsePreflightChecks(c.Query("checks"))
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	report, err := h.preflightSvc.Run(c.Request.Context(), projectID, service.PreflightOptions{
+		Environment: env,
+		Baseline:    c.Query("baseline"),
+		Checks:      checks,
+		Strict:      c.Query("strict") == "true",
+	})
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -64,10 +65,11 @@ func (h *ProjectHandler) Create(c *gin.Context) {
 
 
 // List 获取项目列表
-// GET /api/projects
+// GET /api/projects?include_archived=true
 func (h *ProjectHandler) List(c *gin.Context) {
 	userID := getUserID(c)
-	projects, err := h.projectSvc.List(c.Request.Context(), userID)
+	includeArchived := c.Query("include_archived") == "true"
+	projects, err := h.projectSvc.List(c.Request.Context(), userID, includeArchived)
 	if err != nil {
 		handleServiceError(c, err)
 		return
@@ -150,8 +152,62 @@ func (h *ProjectHandler) Update(c *gin.Context) {
 }
 
 
-// Delete 删除项目
-// DELETE /api/projects/:id
+// Archive 归档项目
+// POST /api/projects/:id/archive
+func (h *ProjectHandler) Archive(c *gin.Context) {
+	h.setArchived(c, true)
+}
+
+// Unarchive 取消归档
+// POST /api/projects/:id/unarchive
+func (h *ProjectHandler) Unarchive(c *gin.Context) {
+	h.setArchived(c, false)
+}
+
+// setArchived 归档或取消归档项目并记录审计日志
+func (h *ProjectHandler) setArchived(c *gin.Context, archived bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	var project *model.Project
+	action := model.AuditActionArchive
+	if archived {
+		project, err = h.projectSvc.Archive(c.Request.Context(), id)
+	} else {
+		project, err = h.projectSvc.Unarchive(c.Request.Context(), id)
+		action = model.AuditActionUnarchive
+	}
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	// 记录审计日志
+	userID := getUserID(c)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    id,
+		UserID:       &userID,
+		Action:       action,
+		ResourceType: model.AuditResourceProject,
+		ResourceID:   id,
+		ResourceName: project.Name,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"project": project,
+	})
+}
+
+// Delete 删除项目 (需先归档并超过宽限期)
+// DELETE /api/projects/:id?dry_run=true
 func (h *ProjectHandler) Delete(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -168,10 +224,18 @@ func (h *ProjectHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.projectSvc.Delete(c.Request.Context(), id); err != nil {
+	impact, err := h.projectSvc.Delete(c.Request.Context(), id, isDryRun(c))
+	if err != nil {
 		handleServiceError(c, err)
 		return
 	}
+	if impact != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"dry_run": true,
+			"impact":  impact,
+		})
+		return
+	}
 
 	// 记录审计日志
 	userID := getUserID(c)
@@ -191,6 +255,157 @@ func (h *ProjectHandler) Delete(c *gin.Context) {
 	})
 }
 
+// ListDataKeys 获取项目的数据加密密钥
+// GET /api/projects/:id/data-keys
+func (h *ProjectHandler) ListDataKeys(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	keys, err := h.projectSvc.ListDataKeys(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data_keys": keys,
+	})
+}
+
+// RotateDataKey 轮换项目的数据加密密钥
+// POST /api/projects/:id/data-keys/rotate
+func (h *ProjectHandler) RotateDataKey(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	key, err := h.projectSvc.RotateDataKey(c.Request.Context(), id, getUserID(c))
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    id,
+		UserID:       &userID,
+		Action:       model.AuditActionCreate,
+		ResourceType: model.AuditResourceProject,
+		ResourceID:   id,
+		ResourceName: "data_key:" + key.KeyID,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"data_key": key,
+	})
+}
+
+// EncryptValue 使用项目的数据加密密钥加密值, 需要写权限
+// POST /api/projects/:id/encrypt
+func (h *ProjectHandler) EncryptValue(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	var req struct {
+		Value string `json:"value" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	encrypted, err := h.projectSvc.EncryptValue(c.Request.Context(), id, getUserID(c), req.Value)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"value": encrypted,
+	})
+}
+
+// DecryptValue 解密项目中的单个加密值, 需要解密权限; 成功和因权限被拒绝的调用都记录审计日志 (不含明文)
+// POST /api/projects/:id/decrypt
+func (h *ProjectHandler) DecryptValue(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	var req struct {
+		Value string `json:"value" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	userID := getUserID(c)
+	plaintext, err := h.projectSvc.DecryptValue(c.Request.Context(), id, userID, req.Value)
+	// 被拒绝的解密尝试同样记录, 以 status_code 区分
+	switch {
+	case err == nil:
+		h.auditDecrypt(c, id, userID, http.StatusOK)
+	case errors.Is(err, service.ErrDecryptForbidden):
+		h.auditDecrypt(c, id, userID, http.StatusForbidden)
+	}
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"value": plaintext,
+	})
+}
+
+// auditDecrypt 记录一次解密调用 (不含明文)
+func (h *ProjectHandler) auditDecrypt(c *gin.Context, projectID, userID int64, status int) {
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    projectID,
+		UserID:       &userID,
+		Action:       model.AuditActionDecrypt,
+		ResourceType: model.AuditResourceProject,
+		ResourceID:   projectID,
+		ResourceName: "encrypted_value",
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		StatusCode:   status,
+	})
+}
+
 // Login 用户登录 (占位)
 // POST /api/auth/login
 func (h *ProjectHandler) Login(c *gin.Context) {
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"confighub/internal/middleware"
+	"confighub/internal/model"
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProjectBundleHandler 项目导入导出处理器
+type ProjectBundleHandler struct {
+	bundleSvc *service.ProjectBundleService
+	auditSvc  *service.AuditService
+}
+
+// NewProjectBundleHandler 创建项目导入导出处理器
+func NewProjectBundleHandler(bundleSvc *service.ProjectBundleService, auditSvc *service.AuditService) *ProjectBundleHandler {
+	return &ProjectBundleHandler{
+		bundleSvc: bundleSvc,
+		auditSvc:  auditSvc,
+	}
+}
+
+// Export 导出项目的环境、配置、版本和发布记录
+// POST /api/projects/:id/export?format=json|zip&include_keys=false&history=true
+func (h *ProjectBundleHandler) Export(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	opts := service.ProjectExportOptions{
+		Format:         c.Query("format"),
+		IncludeKeys:    c.Query("include_keys") == "true",
+		IncludeHistory: c.Query("history") != "false",
+	}
+	if authCtx := middleware.GetAuthContext(c); authCtx != nil {
+		opts.ExportedBy = authCtx.Username
+	}
+
+	bundle, data, err := h.bundleSvc.Export(c.Request.Context(), projectID, opts)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    projectID,
+		UserID:       &userID,
+		Action:       model.AuditActionExport,
+		ResourceType: model.AuditResourceProject,
+		ResourceID:   projectID,
+		ResourceName: bundle.Project.Name,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+
+	contentType := "application/json"
+	if opts.Format == service.ProjectBundleZip {
+		contentType = "application/zip"
+	}
+	c.Header("Content-Disposition", "attachment; filename=\""+service.ProjectBundleFilename(bundle.Project.Name, bundle.ExportedAt, opts.Format)+"\"")
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// Import 将导出包 (JSON 或 zip, 作为请求体上传) 导入到项目
+// POST /api/projects/:id/import?conflict_policy=source_wins&include_keys=false&settings=true
+func (h *ProjectBundleHandler) Import(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, service.MaxProjectBundleSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"code":    "PAYLOAD_TOO_LARGE",
+				"message": "导入包超过 64MB",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "读取导入包失败",
+		})
+		return
+	}
+
+	result, err := h.bundleSvc.Import(c.Request.Context(), projectID, data, service.ProjectImportOptions{
+		ConflictPolicy: c.Query("conflict_policy"),
+		IncludeKeys:    c.Query("include_keys") == "true",
+		MergeSettings:  c.Query("settings") != "false",
+	})
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    projectID,
+		UserID:       &userID,
+		Action:       model.AuditActionImport,
+		ResourceType: model.AuditResourceProject,
+		ResourceID:   projectID,
+		ResourceName: result.Project,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+
+	c.JSON(http.StatusOK, result)
+}
@@ -1,9 +1,11 @@
 package api
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"confighub/internal/middleware"
@@ -14,26 +16,58 @@ import (
 	"github.com/google/uuid"
 )
 
+// maxBulkNames 批量获取时一次最多指定的配置数
+const maxBulkNames = 200
+
+// splitNames 解析逗号分隔的配置名称, 忽略空项和重复项
+func splitNames(value string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
 // PublicConfigHandler 公开配置 API 处理器
 type PublicConfigHandler struct {
-	configSvc  *service.ConfigService
-	encryptSvc *service.EncryptionService
-	notifySvc  *service.NotificationService
-	auditSvc   *service.AuditService
+	configSvc      *service.ConfigService
+	encryptSvc     *service.EncryptionService
+	notifySvc      *service.NotificationService
+	auditSvc       *service.AuditService
+	grayReleaseSvc *service.GrayReleaseService
+	experimentSvc  *service.ExperimentService
+	envSvc         *service.EnvironmentService
+	hotCache       *service.HotConfigCache
+	localeSvc      *service.LocaleService
+	resolveStats   *service.ResolveStats
 }
 
 // NewPublicConfigHandler 创建公开配置处理器
-func NewPublicConfigHandler(configSvc *service.ConfigService, encryptSvc *service.EncryptionService, notifySvc *service.NotificationService, auditSvc *service.AuditService) *PublicConfigHandler {
+func NewPublicConfigHandler(configSvc *service.ConfigService, encryptSvc *service.EncryptionService, notifySvc *service.NotificationService, auditSvc *service.AuditService, grayReleaseSvc *service.GrayReleaseService, experimentSvc *service.ExperimentService, envSvc *service.EnvironmentService, hotCache *service.HotConfigCache, localeSvc *service.LocaleService, resolveStats *service.ResolveStats) *PublicConfigHandler {
 	return &PublicConfigHandler{
-		configSvc:  configSvc,
-		encryptSvc: encryptSvc,
-		notifySvc:  notifySvc,
-		auditSvc:   auditSvc,
+		configSvc:      configSvc,
+		encryptSvc:     encryptSvc,
+		notifySvc:      notifySvc,
+		auditSvc:       auditSvc,
+		grayReleaseSvc: grayReleaseSvc,
+		experimentSvc:  experimentSvc,
+		envSvc:         envSvc,
+		hotCache:       hotCache,
+		localeSvc:      localeSvc,
+		resolveStats:   resolveStats,
 	}
 }
 
 // Get 获取配置
-// GET /api/v1/config?name=xxx&namespace=xxx&env=xxx
+// GET /api/v1/config?name=xxx&namespace=xxx&env=xxx&locale=xxx&path=xxx&format=xxx
+// 指定 locale 时多语言值按回退链解析为对应的语言版本; 指定 path 时只返回路径指向的子树
+// format 或 Accept 请求 YAML / properties 时直接返回转换后的内容, 版本和内容哈希放在响应头中
 func (h *PublicConfigHandler) Get(c *gin.Context) {
 	projectID := getProjectID(c)
 	if projectID == 0 {
@@ -53,10 +87,42 @@ func (h *PublicConfigHandler) Get(c *gin.Context) {
 		return
 	}
 
-	namespace := c.Query("namespace")
-	env := c.Query("env")
+	if !requireConfigAllowed(c, configName) {
+		return
+	}
+
+	locales, ok := h.localeChain(c, projectID)
+	if !ok {
+		return
+	}
+	path, ok := contentPath(c)
+	if !ok {
+		return
+	}
+	format, ok := responseFormat(c)
+	if !ok {
+		return
+	}
 
-	config, version, err := h.configSvc.GetByAccessKey(c.Request.Context(), projectID, configName, namespace, env)
+	// 非 JSON 格式在转换时按路径取子树, 解析时保留完整内容
+	resolvePath := path
+	if format != service.FormatJSON {
+		resolvePath = nil
+	}
+	timing := startResolveTiming(c)
+	response, config, err := h.resolve(c, projectID, configName, c.Query("namespace"), c.Query("env"), locales, resolvePath)
+	h.finishResolveTiming(c, timing)
+	if errors.Is(err, service.ErrInvalidPath) {
+		handleServiceError(c, err)
+		return
+	}
+	if errors.Is(err, service.ErrPathNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "PATH_NOT_FOUND",
+			"message": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"code":    "NOT_FOUND",
@@ -65,6 +131,170 @@ func (h *PublicConfigHandler) Get(c *gin.Context) {
 		return
 	}
 
+	if format != service.FormatJSON {
+		writeRenderedContent(c, response, config.FileType, path, format)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Bootstrap 一次返回调用方可读取的全部配置, 用于客户端冷启动
+// GET /api/v1/bootstrap?namespace=xxx&env=xxx&locale=xxx
+// 每项内容与 GET /api/v1/config 一致 (含灰度和发布元数据), 客户端支持时以 gzip 压缩
+func (h *PublicConfigHandler) Bootstrap(c *gin.Context) {
+	projectID := getProjectID(c)
+	if projectID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    "UNAUTHORIZED",
+			"message": "未授权访问",
+		})
+		return
+	}
+
+	namespace, env := service.ResolveNamespaceEnv(c.Query("namespace"), c.Query("env"))
+	locales, ok := h.localeChain(c, projectID)
+	if !ok {
+		return
+	}
+
+	names, err := h.configNames(c, projectID, namespace, env)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	items := make([]gin.H, 0, len(names))
+	timing := startResolveTiming(c)
+	for _, name := range names {
+		if !configAllowed(c, name) {
+			continue
+		}
+		item, _, err := h.resolve(c, projectID, name, namespace, env, locales, nil)
+		if err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+	h.finishResolveTiming(c, timing)
+
+	writeCompressedJSON(c, http.StatusOK, gin.H{
+		"namespace":    namespace,
+		"environment":  env,
+		"configs":      items,
+		"generated_at": time.Now(),
+	})
+}
+
+// List 按命名空间和环境批量获取配置, 每项内容与 GET /api/v1/config 一致
+// GET /api/v1/configs?namespace=xxx&env=xxx&names=a,b&content=false&locale=xxx&path=xxx
+// names 为空时返回调用方可读取的全部配置, 指定的配置不存在或无权读取时列在 missing 中;
+// content=false 时只列出名称、版本和内容哈希; 指定 path 时每项只返回路径指向的子树, 无法按路径读取的项附带 path_error
+func (h *PublicConfigHandler) List(c *gin.Context) {
+	projectID := getProjectID(c)
+	if projectID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    "UNAUTHORIZED",
+			"message": "未授权访问",
+		})
+		return
+	}
+
+	namespace, env := service.ResolveNamespaceEnv(c.Query("namespace"), c.Query("env"))
+	locales, ok := h.localeChain(c, projectID)
+	if !ok {
+		return
+	}
+
+	path, ok := contentPath(c)
+	if !ok {
+		return
+	}
+
+	requested := splitNames(c.Query("names"))
+	if len(requested) > maxBulkNames {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": fmt.Sprintf("一次最多获取 %d 个配置", maxBulkNames),
+		})
+		return
+	}
+
+	names := requested
+	if len(names) == 0 {
+		var err error
+		if names, err = h.configNames(c, projectID, namespace, env); err != nil {
+			handleServiceError(c, err)
+			return
+		}
+	}
+
+	withContent := c.Query("content") != "false"
+	items := make([]gin.H, 0, len(names))
+	missing := []string{}
+	timing := startResolveTiming(c)
+	for _, name := range names {
+		if !configAllowed(c, name) {
+			if len(requested) > 0 {
+				missing = append(missing, name)
+			}
+			continue
+		}
+		// 无法按路径读取的配置仍返回配置项, 附带 path_error
+		item, _, err := h.resolve(c, projectID, name, namespace, env, locales, path)
+		if err != nil && item == nil {
+			if len(requested) > 0 {
+				missing = append(missing, name)
+			}
+			continue
+		}
+		if !withContent {
+			delete(item, "content")
+		}
+		items = append(items, item)
+	}
+	h.finishResolveTiming(c, timing)
+
+	writeCompressedJSON(c, http.StatusOK, gin.H{
+		"namespace":   namespace,
+		"environment": env,
+		"configs":     items,
+		"missing":     missing,
+	})
+}
+
+// configNames 列出项目在命名空间和环境下的配置名称
+func (h *PublicConfigHandler) configNames(c *gin.Context, projectID int64, namespace, env string) ([]string, error) {
+	var names []string
+	if middleware.IsDegraded(c) {
+		// 数据库不可用时只能返回热点缓存中的配置
+		for _, entry := range h.hotCache.Cached(projectID, namespace, env) {
+			names = append(names, entry.Config.Name)
+		}
+		return names, nil
+	}
+
+	configs, err := h.configSvc.List(c.Request.Context(), projectID)
+	if err != nil {
+		return nil, err
+	}
+	for _, config := range configs {
+		if config.Namespace == namespace && config.Environment == env {
+			names = append(names, config.Name)
+		}
+	}
+	return names, nil
+}
+
+// resolve 解析下发给调用方的配置: 热点缓存、灰度分流、发布元数据、按权限解密、多语言解析和路径投影
+// path 不为空时内容替换为路径指向的子树; 投影失败时仍返回配置项 (不含内容, 附带 path_error) 和错误
+func (h *PublicConfigHandler) resolve(c *gin.Context, projectID int64, configName, namespace, env string, locales []string, path []string) (gin.H, *model.Config, error) {
+	// 热点缓存命中且没有活跃灰度时, 无需访问数据库
+	entry, err := h.hotCache.Get(c.Request.Context(), projectID, configName, namespace, env)
+	if err != nil {
+		return nil, nil, err
+	}
+	config, version, content := entry.Config, entry.Version, entry.Content
+
 	response := gin.H{
 		"name":        config.Name,
 		"namespace":   config.Namespace,
@@ -72,22 +302,215 @@ func (h *PublicConfigHandler) Get(c *gin.Context) {
 		"version":     config.CurrentVersion,
 	}
 
+	// 灰度发布: 按请求环境和客户端标识决定是否下发灰度版本; 降级期间无法判定, 下发缓存的内容
+	release := entry.Release
+	if entry.Gray != nil && !middleware.IsDegraded(c) {
+		start := time.Now()
+		grayVersion, grayRelease := h.resolveGrayVersion(c, config, version)
+		if grayVersion != nil {
+			grayVersion = h.configSvc.Inherited(c.Request.Context(), config, grayVersion)
+			version, release = grayVersion, grayRelease
+			content = h.envSvc.ResolveVariables(c.Request.Context(), config, version.Content)
+			response["version"] = grayVersion.Version
+		}
+		service.ResolveTimingFrom(c.Request.Context()).Since(service.ResolveStageGray, start)
+	}
+	writeReleaseMeta(response, version, release)
+
 	if version != nil {
-		content := version.Content
 		authCtx := middleware.GetAuthContext(c)
 		if authCtx != nil && authCtx.Permissions.Decrypt {
-			content = h.decryptSensitiveFields(content)
+			start := time.Now()
+			content = h.decryptSensitiveFields(c, config.ProjectID, content)
+			service.ResolveTimingFrom(c.Request.Context()).Since(service.ResolveStageDecrypt, start)
+		}
+		if len(locales) > 0 {
+			content = service.LocalizeContent(config.FileType, content, locales)
+			response["locale"] = locales[0]
 		}
 		response["content"] = content
+		if path != nil {
+			if err := projectItem(response, config.FileType, path, c.Query("path")); err != nil {
+				return response, config, err
+			}
+		}
 	}
 
-	h.logAccess(c, projectID, config.ID, "read")
-	c.JSON(http.StatusOK, response)
+	return response, config, nil
+}
+
+// resolveTimingHeaders 各解析阶段耗时的响应头
+var resolveTimingHeaders = map[int]string{
+	service.ResolveStageDB:      "X-Resolve-DB",
+	service.ResolveStageCache:   "X-Resolve-Cache",
+	service.ResolveStageGray:    "X-Gray-Eval",
+	service.ResolveStageDecrypt: "X-Resolve-Decrypt",
+}
+
+// startResolveTiming 开始记录本次请求配置解析各阶段的耗时, 记录随请求上下文传递给热点缓存
+func startResolveTiming(c *gin.Context) *service.ResolveTiming {
+	timing := &service.ResolveTiming{}
+	c.Request = c.Request.WithContext(service.WithResolveTiming(c.Request.Context(), timing))
+	return timing
+}
+
+// finishResolveTiming 将解析耗时计入指标; Access Key 开启了 resolve_timing 时以响应头返回各阶段耗时 (毫秒)
+func (h *PublicConfigHandler) finishResolveTiming(c *gin.Context, timing *service.ResolveTiming) {
+	h.resolveStats.Record(timing)
+
+	authCtx := middleware.GetAuthContext(c)
+	if authCtx == nil || !authCtx.ResolveTiming {
+		return
+	}
+	for stage, header := range resolveTimingHeaders {
+		ms := float64(timing.Duration(stage)) / float64(time.Millisecond)
+		c.Header(header, strconv.FormatFloat(ms, 'f', 3, 64))
+	}
+}
+
+// contentPath 解析请求的 path 参数, 未指定时返回空, 参数无效时写入 400 响应
+func contentPath(c *gin.Context) ([]string, bool) {
+	raw := c.Query("path")
+	if raw == "" {
+		return nil, true
+	}
+	path, err := service.ParseContentPath(raw)
+	if err != nil {
+		handleServiceError(c, err)
+		return nil, false
+	}
+	if path == nil {
+		path = []string{}
+	}
+	return path, true
+}
+
+// projectItem 将配置项的内容替换为路径指向的子树并记录 path; 失败时移除内容并记录 path_error
+func projectItem(item gin.H, fileType string, path []string, raw string) error {
+	content, ok := item["content"].(string)
+	if !ok {
+		return nil
+	}
+	item["path"] = raw
+	projected, err := service.ProjectContent(fileType, content, path)
+	if err != nil {
+		delete(item, "content")
+		item["path_error"] = err.Error()
+		return err
+	}
+	item["content"] = projected
+	return nil
+}
+
+// responseFormat 协商 GET /api/v1/config 的响应格式: format 参数优先, 其次按 Accept 的 q 值选择
+// Accept 中没有可识别的类型时返回 JSON; format 参数无效时写入 400 响应
+func responseFormat(c *gin.Context) (string, bool) {
+	c.Writer.Header().Add("Vary", "Accept")
+	if format := c.Query("format"); format != "" {
+		switch format {
+		case service.FormatJSON, service.FormatYAML, service.FormatProperties:
+			return format, true
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "format 仅支持 json、yaml 和 properties",
+		})
+		return "", false
+	}
+
+	best, bestQ := service.FormatJSON, 0.0
+	for _, part := range strings.Split(c.GetHeader("Accept"), ",") {
+		params := strings.Split(part, ";")
+		var format string
+		switch strings.ToLower(strings.TrimSpace(params[0])) {
+		case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+			format = service.FormatYAML
+		case "application/x-properties", "text/x-java-properties":
+			format = service.FormatProperties
+		case "application/json", "application/*", "*/*":
+			format = service.FormatJSON
+		default:
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if found && strings.TrimSpace(name) == "q" {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	return best, true
+}
+
+// writeRenderedContent 以 YAML 或 properties 格式输出配置项的内容, 版本和内容哈希写入响应头
+// 配置尚未发布时返回 204; 路径不存在时返回 404, 配置类型无法转换时返回 406
+func writeRenderedContent(c *gin.Context, item gin.H, fileType string, path []string, format string) {
+	content, ok := item["content"].(string)
+	if !ok {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	rendered, err := service.RenderContent(fileType, content, path, format)
+	if errors.Is(err, service.ErrPathNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "PATH_NOT_FOUND",
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotAcceptable, gin.H{
+			"code":    "NOT_ACCEPTABLE",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.Header("X-Config-Version", fmt.Sprint(item["version"]))
+	if hash, ok := item["content_hash"].(string); ok {
+		c.Header("X-Content-Hash", hash)
+	}
+	contentType := "application/yaml; charset=utf-8"
+	if format == service.FormatProperties {
+		contentType = "application/x-properties; charset=utf-8"
+	}
+	c.Data(http.StatusOK, contentType, []byte(rendered))
 }
 
+// localeChain 解析请求的 locale 参数, 返回语言回退链; 未指定时返回空, 参数无效时写入 400 响应
+func (h *PublicConfigHandler) localeChain(c *gin.Context, projectID int64) ([]string, bool) {
+	locale := c.Query("locale")
+	if locale == "" {
+		return nil, true
+	}
+	locales, err := h.localeSvc.Chain(c.Request.Context(), projectID, locale, !middleware.IsDegraded(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": err.Error(),
+		})
+		return nil, false
+	}
+	return locales, true
+}
+
+
+// Ping 连通性测试伪配置, 与读取配置经过相同的认证和权限校验
+// GET /api/v1/config/_ping
+func (h *PublicConfigHandler) Ping(c *gin.Context) {
+	writePing(c)
+}
 
 // Update 更新配置
 // PUT /api/v1/config
+// 请求体带 base_version 或请求头带 If-Match (提交哈希) 时, 配置已有更新的版本则返回 409 和三方差异
 func (h *PublicConfigHandler) Update(c *gin.Context) {
 	projectID := getProjectID(c)
 	if projectID == 0 {
@@ -99,11 +522,12 @@ func (h *PublicConfigHandler) Update(c *gin.Context) {
 	}
 
 	var req struct {
-		Name      string `json:"name" binding:"required"`
-		Namespace string `json:"namespace"`
-		Env       string `json:"env"`
-		Content   string `json:"content" binding:"required"`
-		Message   string `json:"message"`
+		Name        string `json:"name" binding:"required"`
+		Namespace   string `json:"namespace"`
+		Env         string `json:"env"`
+		Content     string `json:"content" binding:"required"`
+		Message     string `json:"message"`
+		BaseVersion int    `json:"base_version"` // 修改所基于的版本, 配置已有更新的版本时返回 409
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -114,6 +538,10 @@ func (h *PublicConfigHandler) Update(c *gin.Context) {
 		return
 	}
 
+	if !requireConfigAllowed(c, req.Name) {
+		return
+	}
+
 	config, _, err := h.configSvc.GetByAccessKey(c.Request.Context(), projectID, req.Name, req.Namespace, req.Env)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -123,6 +551,12 @@ func (h *PublicConfigHandler) Update(c *gin.Context) {
 		return
 	}
 
+	origin, err := changeOrigin(c)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
 	author := "api"
 	authCtx := middleware.GetAuthContext(c)
 	if authCtx != nil && authCtx.AccessKeyID > 0 {
@@ -134,29 +568,148 @@ func (h *PublicConfigHandler) Update(c *gin.Context) {
 		message = "通过 API 更新"
 	}
 
-	version, err := h.configSvc.Update(c.Request.Context(), config.ID, req.Content, message, author)
+	var version *model.ConfigVersion
+	if ifMatch := ifMatchHash(c); req.BaseVersion != 0 || ifMatch != "" {
+		version, err = h.configSvc.UpdateIf(c.Request.Context(), config.ID, req.Content, message, author, origin, &service.UpdatePrecondition{
+			BaseVersion: req.BaseVersion,
+			CommitHash:  ifMatch,
+		})
+	} else {
+		version, err = h.configSvc.Update(c.Request.Context(), config.ID, req.Content, message, author, origin)
+	}
 	if err != nil {
 		handleServiceError(c, err)
 		return
 	}
 
-	h.logAccess(c, projectID, config.ID, "update")
+	h.logAccess(c, projectID, config.ID, "update", origin)
 
-	h.notifySvc.NotifyChange(c.Request.Context(), &service.ConfigChange{
-		ConfigID:   config.ID,
-		ConfigName: config.Name,
-		Namespace:  config.Namespace,
-		Env:        config.Environment,
-		Version:    version.Version,
-		ChangeType: "update",
+	c.Header("ETag", strconv.Quote(version.CommitHash))
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "更新成功",
+		"version":     version.Version,
+		"commit_hash": version.CommitHash,
 	})
+}
+
+// ifMatchHash 解析 If-Match 请求头中的提交哈希, 去掉引号和弱校验前缀
+func ifMatchHash(c *gin.Context) string {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	value = strings.TrimPrefix(value, "W/")
+	return strings.Trim(value, `"`)
+}
+
+// Patch 以 JSON Patch (RFC 6902) 或 JSON Merge Patch (RFC 7386) 修改配置的部分字段
+// PATCH /api/v1/config?name=xxx&namespace=xxx&env=xxx&base_version=12&message=xxx
+// 补丁格式由 Content-Type 决定: application/json-patch+json 或 application/merge-patch+json;
+// application/json 时按请求体推断, 数组为 JSON Patch, 其他为 Merge Patch
+func (h *PublicConfigHandler) Patch(c *gin.Context) {
+	projectID := getProjectID(c)
+	if projectID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    "UNAUTHORIZED",
+			"message": "未授权访问",
+		})
+		return
+	}
+
+	name := c.Query("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "配置名称不能为空",
+		})
+		return
+	}
+	if !requireConfigAllowed(c, name) {
+		return
+	}
+
+	baseVersion := 0
+	if value := c.Query("base_version"); value != "" {
+		var err error
+		if baseVersion, err = strconv.Atoi(value); err != nil || baseVersion < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "无效的版本号",
+			})
+			return
+		}
+	}
+
+	body, err := c.GetRawData()
+	if err != nil || len(strings.TrimSpace(string(body))) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "补丁内容不能为空",
+		})
+		return
+	}
+	patchType := patchTypeOf(c.ContentType(), body)
+	if patchType == "" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"code":    "UNSUPPORTED_MEDIA_TYPE",
+			"message": "Content-Type 须为 application/json-patch+json、application/merge-patch+json 或 application/json",
+		})
+		return
+	}
+
+	config, _, err := h.configSvc.GetByAccessKey(c.Request.Context(), projectID, name, c.Query("namespace"), c.Query("env"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "NOT_FOUND",
+			"message": "配置不存在",
+		})
+		return
+	}
+
+	origin, err := changeOrigin(c)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	message := c.Query("message")
+	if message == "" {
+		message = "通过 API 补丁更新"
+	}
+	version, err := h.configSvc.Patch(c.Request.Context(), config.ID, &service.PatchRequest{
+		Type:        patchType,
+		Patch:       body,
+		BaseVersion: baseVersion,
+		Message:     message,
+		Origin:      origin,
+	}, publicAuthor(c))
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	h.logAccess(c, projectID, config.ID, "update", origin)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "更新成功",
 		"version": version.Version,
+		"content": version.Content,
 	})
 }
 
+// patchTypeOf 根据 Content-Type 和请求体确定补丁格式, 不支持时返回空
+func patchTypeOf(contentType string, body []byte) string {
+	switch contentType {
+	case "application/json-patch+json":
+		return service.PatchTypeJSON
+	case "application/merge-patch+json":
+		return service.PatchTypeMerge
+	case "application/json", "":
+		if strings.HasPrefix(strings.TrimSpace(string(body)), "[") {
+			return service.PatchTypeJSON
+		}
+		return service.PatchTypeMerge
+	}
+	return ""
+}
+
 // Create 创建配置
 // POST /api/v1/config
 func (h *PublicConfigHandler) Create(c *gin.Context) {
@@ -179,23 +732,34 @@ func (h *PublicConfigHandler) Create(c *gin.Context) {
 		return
 	}
 
+	if !requireConfigAllowed(c, req.Name) {
+		return
+	}
+
 	if req.FileType == "" {
 		req.FileType = "json"
 	}
 
+	origin, err := changeOrigin(c)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
 	author := "api"
 	authCtx := middleware.GetAuthContext(c)
 	if authCtx != nil && authCtx.AccessKeyID > 0 {
 		author = "key:" + strconv.FormatInt(authCtx.AccessKeyID, 10)
 	}
 
+	req.Origin = origin
 	config, err := h.configSvc.Upload(c.Request.Context(), projectID, &req, author)
 	if err != nil {
 		handleServiceError(c, err)
 		return
 	}
 
-	h.logAccess(c, projectID, config.ID, "create")
+	h.logAccess(c, projectID, config.ID, "create", origin)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "创建成功",
@@ -210,8 +774,21 @@ func (h *PublicConfigHandler) Create(c *gin.Context) {
 }
 
 
+// supportedTransports 服务端支持的客户端传输方式, 按优先级排列
+// sse 为单个配置的变更流 (WatchStream), long-polling 为监听接口 (Watch)
+var supportedTransports = []string{"sse", "long-polling"}
+
+// Transports 返回服务端支持的传输方式, 供 SDK 启动时协商
+// GET /api/v1/config/transports
+func (h *PublicConfigHandler) Transports(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"transports": supportedTransports,
+	})
+}
+
 // Watch 监听配置变更 (Long-Polling)
-// GET /api/v1/config/watch?name=xxx&namespace=xxx&env=xxx&version=xxx&timeout=xxx
+// GET /api/v1/config/watch?name=xxx&namespace=xxx&env=xxx&version=xxx&timeout=xxx&mode=notify&locale=xxx
+// mode=notify 时变更响应只包含版本和 content_hash, 不包含内容; 监听期间配置被删除时返回 410 CONFIG_DELETED
 func (h *PublicConfigHandler) Watch(c *gin.Context) {
 	projectID := getProjectID(c)
 	if projectID == 0 {
@@ -231,8 +808,17 @@ func (h *PublicConfigHandler) Watch(c *gin.Context) {
 		return
 	}
 
-	namespace := c.Query("namespace")
-	env := c.Query("env")
+	if !requireConfigAllowed(c, configName) {
+		return
+	}
+
+	// 与读取接口使用相同的默认命名空间和环境, 重新获取时也使用同一组值
+	namespace, env := service.ResolveNamespaceEnv(c.Query("namespace"), c.Query("env"))
+	notifyOnly := c.Query("mode") == "notify"
+	locales, ok := h.localeChain(c, projectID)
+	if !ok {
+		return
+	}
 
 	currentVersion := 0
 	if v := c.Query("version"); v != "" {
@@ -250,7 +836,8 @@ func (h *PublicConfigHandler) Watch(c *gin.Context) {
 		}
 	}
 
-	config, version, err := h.configSvc.GetByAccessKey(c.Request.Context(), projectID, configName, namespace, env)
+	// 与读取接口共用热点缓存, 降级期间以最后已知版本判断
+	entry, err := h.hotCache.Get(c.Request.Context(), projectID, configName, namespace, env)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"code":    "NOT_FOUND",
@@ -258,21 +845,19 @@ func (h *PublicConfigHandler) Watch(c *gin.Context) {
 		})
 		return
 	}
+	config := entry.Config
 
-	if version != nil && version.Version > currentVersion {
-		c.JSON(http.StatusOK, gin.H{
-			"changed":     true,
-			"name":        config.Name,
-			"namespace":   config.Namespace,
-			"environment": config.Environment,
-			"version":     version.Version,
-			"content":     version.Content,
-		})
+	// 父配置变更时子配置版本号不变, 客户端携带持有内容的哈希时据此发现两次监听之间的变化
+	hash := c.Query("content_hash")
+	if entry.Version != nil && (entry.Version.Version > currentVersion || inheritedChanged(entry, hash)) {
+		c.JSON(http.StatusOK, h.changed(c, entry, notifyOnly, locales))
 		return
 	}
 
+	// 只订阅本配置的变更, 热点配置的通知不会分发给其他配置的监听
 	clientID := uuid.New().String()
-	changeCh, err := h.notifySvc.Subscribe(c.Request.Context(), clientID, []int64{config.ID})
+	subscriber := watchSubscriber(c, projectID)
+	sub, err := h.notifySvc.Subscribe(c.Request.Context(), clientID, subscriber, []int64{config.ID})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    "INTERNAL_ERROR",
@@ -282,73 +867,164 @@ func (h *PublicConfigHandler) Watch(c *gin.Context) {
 	}
 	defer h.notifySvc.Unsubscribe(c.Request.Context(), clientID)
 
-	select {
-	case change := <-changeCh:
-		if change != nil && change.ConfigID == config.ID {
-			_, newVersion, _ := h.configSvc.GetByAccessKey(c.Request.Context(), projectID, configName, namespace, env)
-			if newVersion != nil {
-				c.JSON(http.StatusOK, gin.H{
-					"changed":     true,
-					"name":        config.Name,
-					"namespace":   config.Namespace,
-					"environment": config.Environment,
-					"version":     newVersion.Version,
-					"content":     newVersion.Content,
-				})
+	// 检查与订阅之间可能已有变更, 订阅后再检查一次
+	if latest, err := h.hotCache.Get(c.Request.Context(), projectID, configName, namespace, env); err == nil && latest.Version != nil && (latest.Version.Version > currentVersion || inheritedChanged(latest, hash)) {
+		c.JSON(http.StatusOK, h.changed(c, latest, notifyOnly, locales))
+		return
+	}
+
+	deadline := time.NewTimer(time.Duration(timeout) * time.Second)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-sub.Revoked:
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":    "ACCESS_REVOKED",
+				"message": "访问权限已被撤销",
+			})
+			return
+		case change, ok := <-sub.Changes:
+			if !ok {
+				c.Status(http.StatusNotModified)
 				return
 			}
+			// 其他配置的变更不结束本次监听
+			if change == nil || change.ConfigID != config.ID {
+				continue
+			}
+			if change.ChangeType == "delete" {
+				configDeleted(c, config)
+				return
+			}
+			latest, err := h.hotCache.Get(c.Request.Context(), projectID, configName, namespace, env)
+			if errors.Is(err, service.ErrConfigNotFound) {
+				configDeleted(c, config)
+				return
+			}
+			if err != nil {
+				c.Status(http.StatusNotModified)
+				return
+			}
+			// 父配置变更时版本号不变, 合并后的内容已变化
+			if latest.Version != nil && (latest.Version.Version > currentVersion || change.ChangeType == "inherit") {
+				c.JSON(http.StatusOK, h.changed(c, latest, notifyOnly, locales))
+				return
+			}
+		case <-deadline.C:
+			c.Status(http.StatusNotModified)
+			return
+		case <-c.Request.Context().Done():
+			return
 		}
-	case <-time.After(time.Duration(timeout) * time.Second):
-		c.Status(http.StatusNotModified)
-		return
-	case <-c.Request.Context().Done():
-		return
 	}
+}
 
-	c.Status(http.StatusNotModified)
+// configDeleted 监听期间配置被删除, 返回 410 通知客户端移除缓存并停止按版本监听
+func configDeleted(c *gin.Context, config *model.Config) {
+	c.JSON(http.StatusGone, deletedPayload(config))
 }
 
-// decryptSensitiveFields 解密敏感字段
-func (h *PublicConfigHandler) decryptSensitiveFields(content string) string {
-	var data map[string]interface{}
-	if err := json.Unmarshal([]byte(content), &data); err != nil {
-		return content
+// deletedPayload 配置被删除时返回给监听方的内容
+func deletedPayload(config *model.Config) gin.H {
+	return gin.H{
+		"code":        "CONFIG_DELETED",
+		"message":     "配置已删除",
+		"name":        config.Name,
+		"namespace":   config.Namespace,
+		"environment": config.Environment,
+		"version":     config.CurrentVersion,
 	}
+}
 
-	h.decryptMap(data)
+// inheritedChanged 继承了父配置的配置版本号未变时, 下发内容的哈希是否与客户端持有的不同
+func inheritedChanged(entry *service.HotConfigEntry, hash string) bool {
+	if hash == "" || entry.Config.ParentID == nil {
+		return false
+	}
+	return service.ServedContentHash(entry.Version, entry.Release) != hash
+}
 
-	result, err := json.Marshal(data)
-	if err != nil {
-		return content
+// changed 以热点缓存的读取结果构造监听接口的变更响应, 发布信息取自缓存条目, 不再查询数据库
+// notifyOnly 时省略内容, 客户端按 content_hash 判断是否需要重新获取
+func (h *PublicConfigHandler) changed(c *gin.Context, entry *service.HotConfigEntry, notifyOnly bool, locales []string) gin.H {
+	config, version, release := entry.Config, entry.Version, entry.Release
+	response := gin.H{
+		"changed":     true,
+		"name":        config.Name,
+		"namespace":   config.Namespace,
+		"environment": config.Environment,
+		"version":     version.Version,
+	}
+	if notifyOnly {
+		response["notify_only"] = true
+	} else {
+		content := entry.Content
+		if len(locales) > 0 {
+			content = service.LocalizeContent(config.FileType, content, locales)
+			response["locale"] = locales[0]
+		}
+		response["content"] = content
 	}
-	return string(result)
+	writeReleaseMeta(response, version, release)
+	return response
 }
 
-// decryptMap 递归解密 map 中的加密字段
-func (h *PublicConfigHandler) decryptMap(data map[string]interface{}) {
-	for key, value := range data {
-		switch v := value.(type) {
-		case string:
-			if len(v) > 4 && v[:4] == "ENC:" {
-				decrypted, err := h.encryptSvc.Decrypt(v[4:])
-				if err == nil {
-					data[key] = decrypted
-				}
-			}
-		case map[string]interface{}:
-			h.decryptMap(v)
-		case []interface{}:
-			for _, item := range v {
-				if m, ok := item.(map[string]interface{}); ok {
-					h.decryptMap(m)
-				}
-			}
+// resolveGrayVersion 判断客户端是否命中灰度发布, 命中时返回灰度版本及对应的灰度发布
+// 灰度期间同时记录客户端所在分组, 用于实验效果分析
+func (h *PublicConfigHandler) resolveGrayVersion(c *gin.Context, config *model.Config, stable *model.ConfigVersion) (*model.ConfigVersion, *model.Release) {
+	clientID := c.Query("client_id")
+	if clientID == "" {
+		clientID = c.GetHeader("X-Client-ID")
+	}
+
+	ctx := c.Request.Context()
+	release, matched := h.grayReleaseSvc.Evaluate(ctx, config, clientID, c.ClientIP())
+	if release == nil {
+		return nil, nil
+	}
+
+	if !matched {
+		if stable != nil {
+			h.experimentSvc.RecordExposure(release, config.Environment, clientID, model.VariantStable, stable.Version, c.ClientIP())
 		}
+		return nil, nil
+	}
+
+	version, err := h.grayReleaseSvc.GetGrayReleaseVersion(ctx, release, config)
+	if err != nil {
+		return nil, nil
+	}
+	h.experimentSvc.RecordExposure(release, config.Environment, clientID, model.VariantGray, version.Version, c.ClientIP())
+	return version, release
+}
+
+// writeReleaseMeta 写入下发版本的内容哈希, 发布与下发版本一致时附加发布信息
+func writeReleaseMeta(response gin.H, version *model.ConfigVersion, release *model.Release) {
+	if version == nil {
+		return
+	}
+	response["content_hash"] = service.ServedContentHash(version, release)
+
+	if release == nil || release.Version != version.Version {
+		return
+	}
+	response["release_id"] = release.ID
+	response["release_type"] = release.ReleaseType
+	response["released_at"] = release.ReleasedAt
+}
+
+// decryptSensitiveFields 解密敏感字段, 内容不是 JSON 对象时原样返回
+func (h *PublicConfigHandler) decryptSensitiveFields(c *gin.Context, projectID int64, content string) string {
+	decrypted, err := h.encryptSvc.DecryptFields(c.Request.Context(), projectID, content)
+	if err != nil {
+		return content
 	}
+	return decrypted
 }
 
-// logAccess 记录访问日志
-func (h *PublicConfigHandler) logAccess(c *gin.Context, projectID, configID int64, action string) {
+// logAccess 记录审计日志 (仅用于变更操作, 读取由访问日志中间件记录)
+func (h *PublicConfigHandler) logAccess(c *gin.Context, projectID, configID int64, action, origin string) {
 	authCtx := middleware.GetAuthContext(c)
 	var keyID *int64
 	if authCtx != nil && authCtx.AccessKeyID > 0 {
@@ -363,5 +1039,6 @@ func (h *PublicConfigHandler) logAccess(c *gin.Context, projectID, configID int6
 		ResourceID:   configID,
 		IPAddress:    c.ClientIP(),
 		UserAgent:    c.Request.UserAgent(),
+		Origin:       origin,
 	})
 }
@@ -0,0 +1,390 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"confighub/internal/middleware"
+	"confighub/internal/model"
+	"confighub/internal/repository"
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// memoryDB 测试用的内存数据库, 只支持读取配置和版本时的等值查询, 其他表始终为空
+type memoryDB struct {
+	mu       sync.Mutex
+	configs  []*model.Config
+	versions []*model.ConfigVersion
+}
+
+var (
+	memoryDBsMu sync.Mutex
+	memoryDBs   = map[string]*memoryDB{}
+)
+
+func init() {
+	sql.Register("confighub-memory", memoryDriver{})
+}
+
+// openMemoryDB 以内存数据库打开 GORM 连接
+func openMemoryDB(t *testing.T, store *memoryDB) *gorm.DB {
+	t.Helper()
+	memoryDBsMu.Lock()
+	memoryDBs[t.Name()] = store
+	memoryDBsMu.Unlock()
+
+	sqlDB, err := sql.Open("confighub-memory", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// commit 为配置写入新版本并推进当前版本
+func (m *memoryDB) commit(configID int64, content string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, config := range m.configs {
+		if config.ID == configID {
+			config.CurrentVersion++
+			m.versions = append(m.versions, &model.ConfigVersion{
+				ID:         int64(len(m.versions) + 1),
+				ConfigID:   configID,
+				Version:    config.CurrentVersion,
+				Content:    content,
+				CommitHash: service.GenerateHash(content),
+			})
+		}
+	}
+}
+
+// conditionPattern 按顺序匹配 WHERE 中的等值条件, 与查询参数一一对应
+var conditionPattern = regexp.MustCompile("`?(\\w+)`? = \\?")
+
+// query 返回查询结果的列和行
+func (m *memoryDB) query(query string, args []driver.Value) ([]string, [][]driver.Value) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	conditions := map[string]driver.Value{}
+	for i, match := range conditionPattern.FindAllStringSubmatch(query, -1) {
+		if i < len(args) {
+			conditions[match[1]] = args[i]
+		}
+	}
+	matches := func(values map[string]driver.Value) bool {
+		for column, want := range conditions {
+			got, ok := values[column]
+			if !ok || fmt.Sprint(got) != fmt.Sprint(want) {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch {
+	case strings.Contains(query, "FROM `configs`"):
+		columns := []string{"id", "project_id", "name", "namespace", "environment", "file_type", "current_version"}
+		var rows [][]driver.Value
+		for _, c := range m.configs {
+			values := map[string]driver.Value{"id": c.ID, "project_id": c.ProjectID, "name": c.Name, "namespace": c.Namespace, "environment": c.Environment}
+			if matches(values) {
+				rows = append(rows, []driver.Value{c.ID, c.ProjectID, c.Name, c.Namespace, c.Environment, c.FileType, int64(c.CurrentVersion)})
+			}
+		}
+		return columns, rows
+	case strings.Contains(query, "FROM `config_versions`"):
+		columns := []string{"id", "config_id", "version", "content", "commit_hash"}
+		var matched []*model.ConfigVersion
+		for _, v := range m.versions {
+			if matches(map[string]driver.Value{"config_id": v.ConfigID, "version": int64(v.Version)}) {
+				matched = append(matched, v)
+			}
+		}
+		sort.Slice(matched, func(i, j int) bool { return matched[i].Version > matched[j].Version })
+		var rows [][]driver.Value
+		for _, v := range matched {
+			rows = append(rows, []driver.Value{v.ID, v.ConfigID, int64(v.Version), v.Content, v.CommitHash})
+		}
+		return columns, rows
+	}
+	return []string{"id"}, nil
+}
+
+type memoryDriver struct{}
+
+func (memoryDriver) Open(name string) (driver.Conn, error) {
+	memoryDBsMu.Lock()
+	defer memoryDBsMu.Unlock()
+	store, ok := memoryDBs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown memory database %q", name)
+	}
+	return &memoryConn{store: store}, nil
+}
+
+type memoryConn struct {
+	store *memoryDB
+}
+
+func (c *memoryConn) Prepare(query string) (driver.Stmt, error) {
+	return &memoryStmt{conn: c, query: query}, nil
+}
+
+func (c *memoryConn) Close() error { return nil }
+
+func (c *memoryConn) Begin() (driver.Tx, error) { return memoryTx{}, nil }
+
+func (c *memoryConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	columns, rows := c.store.query(query, values)
+	return &memoryRows{columns: columns, rows: rows}, nil
+}
+
+func (c *memoryConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+type memoryTx struct{}
+
+func (memoryTx) Commit() error   { return nil }
+func (memoryTx) Rollback() error { return nil }
+
+type memoryStmt struct {
+	conn  *memoryConn
+	query string
+}
+
+func (s *memoryStmt) Close() error  { return nil }
+func (s *memoryStmt) NumInput() int { return -1 }
+
+func (s *memoryStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (s *memoryStmt) Query(args []driver.Value) (driver.Rows, error) {
+	columns, rows := s.conn.store.query(s.query, args)
+	return &memoryRows{columns: columns, rows: rows}, nil
+}
+
+type memoryRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *memoryRows) Columns() []string { return r.columns }
+func (r *memoryRows) Close() error      { return nil }
+
+func (r *memoryRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// watchFixture 由内存数据库支撑的公开配置处理器
+type watchFixture struct {
+	store     *memoryDB
+	notifySvc *service.NotificationService
+	handler   *PublicConfigHandler
+}
+
+func newWatchFixture(t *testing.T) *watchFixture {
+	gin.SetMode(gin.TestMode)
+
+	// 项目 1 中同名的 app 配置分别位于默认命名空间和 billing 命名空间
+	store := &memoryDB{
+		configs: []*model.Config{
+			{ID: 1, ProjectID: 1, Name: "app", Namespace: service.DefaultNamespace, Environment: service.DefaultEnvironment, FileType: "json"},
+			{ID: 2, ProjectID: 1, Name: "app", Namespace: "billing", Environment: service.DefaultEnvironment, FileType: "json"},
+		},
+	}
+	store.commit(1, `{"ns":"application"}`)
+	store.commit(2, `{"ns":"billing"}`)
+
+	db := openMemoryDB(t, store)
+	configRepo := repository.NewConfigRepository(db)
+	versionRepo := repository.NewVersionRepository(db)
+	projectRepo := repository.NewProjectRepository(db)
+	releaseRepo := repository.NewReleaseRepository(db)
+
+	notifySvc := service.NewNotificationService(nil)
+	encryptSvc := service.NewEncryptionService("test")
+	contractSvc := service.NewContractService(repository.NewContractRepository(db), configRepo, versionRepo)
+	schemaSvc := service.NewSchemaService(configRepo, versionRepo, projectRepo, repository.NewProtoDescriptorRepository(db))
+	configSvc := service.NewConfigService(configRepo, versionRepo, projectRepo, notifySvc, contractSvc, schemaSvc)
+	pipelineSvc := service.NewReleasePipelineService(projectRepo, configRepo, versionRepo, encryptSvc)
+	releaseSvc := service.NewReleaseService(releaseRepo, configRepo, versionRepo, projectRepo, notifySvc, contractSvc, schemaSvc, pipelineSvc)
+	grayReleaseSvc := service.NewGrayReleaseService(releaseRepo, configRepo, versionRepo, notifySvc, pipelineSvc)
+	envSvc := service.NewEnvironmentService(projectRepo, configRepo, versionRepo, repository.NewEnvironmentRepository(db), notifySvc)
+	resilienceSvc := service.NewResilienceService(repository.NewMigrationRepository(db), false, 0)
+	hotCache := service.NewHotConfigCache(configSvc, releaseSvc, grayReleaseSvc, envSvc, notifySvc, resilienceSvc, 0)
+
+	handler := NewPublicConfigHandler(configSvc, encryptSvc, notifySvc, service.NewAuditService(repository.NewAuditRepository(db)),
+		grayReleaseSvc, service.NewExperimentService(repository.NewExperimentRepository(db)), envSvc, hotCache,
+		service.NewLocaleService(projectRepo), service.NewResolveStats())
+	return &watchFixture{store: store, notifySvc: notifySvc, handler: handler}
+}
+
+// watch 以项目 1 的 Access Key 身份发起监听请求
+func (f *watchFixture) watch(query string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/config/watch?"+query, nil)
+	c.Set(middleware.AuthContextKey, &middleware.AuthContext{ProjectID: 1, AccessKeyID: 1})
+	f.handler.Watch(c)
+	c.Writer.WriteHeaderNow()
+	return recorder
+}
+
+// watchAsync 在后台发起监听, 订阅生效后返回
+func (f *watchFixture) watchAsync(t *testing.T, query string) <-chan *httptest.ResponseRecorder {
+	t.Helper()
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() { done <- f.watch(query) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for f.notifySvc.SubscribersByProject()[1] == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("watch did not subscribe")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return done
+}
+
+// update 提交配置的新版本并通知监听方
+func (f *watchFixture) update(configID int64, content string) {
+	f.store.commit(configID, content)
+	for _, config := range f.store.configs {
+		if config.ID == configID {
+			f.notifySvc.NotifyChange(context.Background(), &service.ConfigChange{
+				ProjectID:  config.ProjectID,
+				ConfigID:   config.ID,
+				ConfigName: config.Name,
+				Namespace:  config.Namespace,
+				Env:        config.Environment,
+				Version:    config.CurrentVersion,
+				ChangeType: "update",
+			})
+		}
+	}
+}
+
+func decodeWatch(t *testing.T, recorder *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", recorder.Code, recorder.Body.String())
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestWatchDefaultNamespaceReturnsNewerVersion(t *testing.T) {
+	f := newWatchFixture(t)
+	f.store.commit(1, `{"ns":"application","v":2}`)
+
+	body := decodeWatch(t, f.watch("name=app&version=1&timeout=1"))
+	if body["namespace"] != service.DefaultNamespace || body["environment"] != service.DefaultEnvironment {
+		t.Fatalf("watched %v/%v, want the default namespace and environment", body["namespace"], body["environment"])
+	}
+	if body["version"] != float64(2) || body["content"] != `{"ns":"application","v":2}` {
+		t.Fatalf("version = %v, content = %v", body["version"], body["content"])
+	}
+}
+
+func TestWatchExplicitNamespaceReturnsNewerVersion(t *testing.T) {
+	f := newWatchFixture(t)
+	f.store.commit(2, `{"ns":"billing","v":2}`)
+
+	body := decodeWatch(t, f.watch("name=app&namespace=billing&version=1&timeout=1"))
+	if body["namespace"] != "billing" || body["version"] != float64(2) {
+		t.Fatalf("watched %v at version %v, want billing at version 2", body["namespace"], body["version"])
+	}
+}
+
+func TestWatchDefaultNamespaceWakesOnChange(t *testing.T) {
+	f := newWatchFixture(t)
+	done := f.watchAsync(t, "name=app&version=1&timeout=5")
+
+	// 其他命名空间的同名配置变更不结束监听
+	f.update(2, `{"ns":"billing","v":2}`)
+	select {
+	case recorder := <-done:
+		t.Fatalf("watch ended on another namespace's change: %d %s", recorder.Code, recorder.Body.String())
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	f.update(1, `{"ns":"application","v":2}`)
+	select {
+	case recorder := <-done:
+		body := decodeWatch(t, recorder)
+		if body["namespace"] != service.DefaultNamespace || body["version"] != float64(2) {
+			t.Fatalf("watched %v at version %v, want application at version 2", body["namespace"], body["version"])
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("watch did not return after the change")
+	}
+}
+
+func TestWatchExplicitNamespaceWakesOnChange(t *testing.T) {
+	f := newWatchFixture(t)
+	done := f.watchAsync(t, "name=app&namespace=billing&env=default&version=1&timeout=5")
+
+	// 默认命名空间的同名配置变更不结束监听
+	f.update(1, `{"ns":"application","v":2}`)
+	select {
+	case recorder := <-done:
+		t.Fatalf("watch ended on the default namespace's change: %d %s", recorder.Code, recorder.Body.String())
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	f.update(2, `{"ns":"billing","v":2}`)
+	select {
+	case recorder := <-done:
+		body := decodeWatch(t, recorder)
+		if body["namespace"] != "billing" || body["version"] != float64(2) || body["content"] != `{"ns":"billing","v":2}` {
+			t.Fatalf("watched %v at version %v with %v, want billing at version 2", body["namespace"], body["version"], body["content"])
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("watch did not return after the change")
+	}
+}
+
+func TestWatchTimesOutWithoutChange(t *testing.T) {
+	f := newWatchFixture(t)
+	if recorder := f.watch("name=app&namespace=billing&version=1&timeout=1"); recorder.Code != http.StatusNotModified {
+		t.Fatalf("status = %d, want 304", recorder.Code)
+	}
+}
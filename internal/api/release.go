@@ -1,9 +1,13 @@
 package api
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"confighub/internal/middleware"
 	"confighub/internal/model"
 	"confighub/internal/service"
 
@@ -15,14 +19,20 @@ type ReleaseHandler struct {
 	releaseSvc     *service.ReleaseService
 	grayReleaseSvc *service.GrayReleaseService
 	auditSvc       *service.AuditService
+	contractSvc    *service.ContractService
+	schemaSvc      *service.SchemaService
+	pipelineSvc    *service.ReleasePipelineService
 }
 
 // NewReleaseHandler 创建发布处理器
-func NewReleaseHandler(releaseSvc *service.ReleaseService, grayReleaseSvc *service.GrayReleaseService, auditSvc *service.AuditService) *ReleaseHandler {
+func NewReleaseHandler(releaseSvc *service.ReleaseService, grayReleaseSvc *service.GrayReleaseService, auditSvc *service.AuditService, contractSvc *service.ContractService, schemaSvc *service.SchemaService, pipelineSvc *service.ReleasePipelineService) *ReleaseHandler {
 	return &ReleaseHandler{
 		releaseSvc:     releaseSvc,
 		grayReleaseSvc: grayReleaseSvc,
 		auditSvc:       auditSvc,
+		contractSvc:    contractSvc,
+		schemaSvc:      schemaSvc,
+		pipelineSvc:    pipelineSvc,
 	}
 }
 
@@ -39,8 +49,10 @@ func (h *ReleaseHandler) Create(c *gin.Context) {
 	}
 
 	var req struct {
-		Environment string `json:"environment" binding:"required"`
-		Version     int    `json:"version"`
+		Environment    string `json:"environment" binding:"required"`
+		Version        int    `json:"version"`
+		Override       bool   `json:"override"`        // 覆盖发布护栏, 需要管理权限
+		OverrideReason string `json:"override_reason"` // 覆盖原因, 记录在审计日志中
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -51,20 +63,38 @@ func (h *ReleaseHandler) Create(c *gin.Context) {
 		return
 	}
 
+	if req.Override {
+		authCtx := middleware.GetAuthContext(c)
+		if authCtx == nil || !authCtx.Permissions.Admin {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":    "FORBIDDEN",
+				"message": "无权限覆盖发布护栏",
+			})
+			return
+		}
+		if strings.TrimSpace(req.OverrideReason) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "覆盖发布护栏需要填写原因",
+			})
+			return
+		}
+	}
+
 	userID := getUserID(c)
 	author := "user"
 	if userID > 0 {
 		author = strconv.FormatInt(userID, 10)
 	}
 
-	release, err := h.releaseSvc.Create(c.Request.Context(), configID, req.Environment, req.Version, author)
+	release, overridden, err := h.releaseSvc.Create(c.Request.Context(), configID, req.Environment, req.Version, author, req.Override)
 	if err != nil {
 		handleServiceError(c, err)
 		return
 	}
 
-	// 记录审计日志
-	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+	// 记录审计日志, 覆盖护栏时附带原因和被覆盖的规则
+	auditLog := &model.AuditLog{
 		ProjectID:    release.ProjectID,
 		UserID:       &userID,
 		Action:       model.AuditActionRelease,
@@ -72,11 +102,28 @@ func (h *ReleaseHandler) Create(c *gin.Context) {
 		ResourceID:   release.ID,
 		IPAddress:    c.ClientIP(),
 		UserAgent:    c.Request.UserAgent(),
-	})
+	}
+	if len(overridden) > 0 {
+		annotation, _ := json.Marshal(gin.H{
+			"guardrail_override": gin.H{
+				"reason":     req.OverrideReason,
+				"violations": overridden,
+			},
+		})
+		auditLog.RequestBody = string(annotation)
+	}
+	h.auditSvc.Log(c.Request.Context(), auditLog)
 
-	c.JSON(http.StatusCreated, gin.H{
+	resp := gin.H{
 		"release": release,
-	})
+	}
+	if warnings := h.contractSvc.Warnings(c.Request.Context(), configID, release.Version); len(warnings) > 0 {
+		resp["contract_warnings"] = warnings
+	}
+	if warnings := h.schemaSvc.Warnings(c.Request.Context(), configID, release.Version); len(warnings) > 0 {
+		resp["schema_warnings"] = warnings
+	}
+	c.JSON(http.StatusCreated, resp)
 }
 
 // List 获取发布历史
@@ -103,6 +150,60 @@ func (h *ReleaseHandler) List(c *gin.Context) {
 }
 
 
+// ReleaseNotes 生成发布说明
+// GET /api/projects/:id/release-notes?env=prod&from=2024-01-01T00:00:00Z&to=2024-01-08T00:00:00Z&format=markdown
+func (h *ReleaseHandler) ReleaseNotes(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	// 默认统计最近 7 天
+	to := time.Now()
+	if toStr := c.Query("to"); toStr != "" {
+		if to, err = time.Parse(time.RFC3339, toStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "无效的结束时间, 请使用 RFC3339 格式",
+			})
+			return
+		}
+	}
+	from := to.AddDate(0, 0, -7)
+	if fromStr := c.Query("from"); fromStr != "" {
+		if from, err = time.Parse(time.RFC3339, fromStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "无效的开始时间, 请使用 RFC3339 格式",
+			})
+			return
+		}
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "开始时间必须早于结束时间",
+		})
+		return
+	}
+
+	notes, err := h.releaseSvc.ReleaseNotes(c.Request.Context(), projectID, c.Query("env"), from, to)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	if c.Query("format") == "markdown" {
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(notes.Markdown()))
+		return
+	}
+	c.JSON(http.StatusOK, notes)
+}
+
 // Rollback 回滚发布
 // POST /api/releases/:id/rollback
 func (h *ReleaseHandler) Rollback(c *gin.Context) {
@@ -145,12 +246,13 @@ func (h *ReleaseHandler) CreateGray(c *gin.Context) {
 	}
 
 	var req struct {
-		Environment string   `json:"environment" binding:"required"`
-		Version     int      `json:"version"`
-		RuleType    string   `json:"rule_type" binding:"required"` // percentage, client_id, ip_range
-		Percentage  int      `json:"percentage,omitempty"`
-		ClientIDs   []string `json:"client_ids,omitempty"`
-		IPRanges    []string `json:"ip_ranges,omitempty"`
+		Environment    string         `json:"environment"`
+		Version        int            `json:"version"`
+		RuleType       string         `json:"rule_type" binding:"required"` // percentage, client_id, ip_range
+		Percentage     int            `json:"percentage,omitempty"`
+		EnvPercentages map[string]int `json:"env_percentages,omitempty"`
+		ClientIDs      []string       `json:"client_ids,omitempty"`
+		IPRanges       []string       `json:"ip_ranges,omitempty"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -168,13 +270,14 @@ func (h *ReleaseHandler) CreateGray(c *gin.Context) {
 	}
 
 	grayReq := &service.GrayReleaseRequest{
-		ConfigID:    configID,
-		Environment: req.Environment,
-		Version:     req.Version,
-		RuleType:    req.RuleType,
-		Percentage:  req.Percentage,
-		ClientIDs:   req.ClientIDs,
-		IPRanges:    req.IPRanges,
+		ConfigID:       configID,
+		Environment:    req.Environment,
+		Version:        req.Version,
+		RuleType:       req.RuleType,
+		Percentage:     req.Percentage,
+		EnvPercentages: req.EnvPercentages,
+		ClientIDs:      req.ClientIDs,
+		IPRanges:       req.IPRanges,
 	}
 
 	release, err := h.grayReleaseSvc.Create(c.Request.Context(), grayReq, author)
@@ -264,7 +367,8 @@ func (h *ReleaseHandler) UpdateGrayPercentage(c *gin.Context) {
 	}
 
 	var req struct {
-		Percentage int `json:"percentage" binding:"required,min=0,max=100"`
+		Percentage  int    `json:"percentage" binding:"required,min=0,max=100"`
+		Environment string `json:"environment"` // 分阶段灰度时指定要调整的环境
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -275,7 +379,7 @@ func (h *ReleaseHandler) UpdateGrayPercentage(c *gin.Context) {
 		return
 	}
 
-	if err := h.grayReleaseSvc.UpdatePercentage(c.Request.Context(), releaseID, req.Percentage); err != nil {
+	if err := h.grayReleaseSvc.UpdatePercentage(c.Request.Context(), releaseID, req.Environment, req.Percentage); err != nil {
 		handleServiceError(c, err)
 		return
 	}
@@ -342,3 +446,333 @@ func (h *ReleaseHandler) CreateEnvironment(c *gin.Context) {
 		"message": "环境创建成功",
 	})
 }
+
+// GetGuardrails 获取项目的发布护栏
+// GET /api/projects/:id/release-guardrails
+func (h *ReleaseHandler) GetGuardrails(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	guardrails, err := h.releaseSvc.GetGuardrails(c.Request.Context(), projectID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"guardrails": guardrails,
+	})
+}
+
+// UpdateGuardrails 更新项目的发布护栏
+// PUT /api/projects/:id/release-guardrails
+func (h *ReleaseHandler) UpdateGuardrails(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	var guardrails service.ReleaseGuardrails
+	if err := c.ShouldBindJSON(&guardrails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.releaseSvc.UpdateGuardrails(c.Request.Context(), projectID, &guardrails); err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	body, _ := json.Marshal(guardrails)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    projectID,
+		UserID:       &userID,
+		Action:       model.AuditActionUpdate,
+		ResourceType: model.AuditResourceProject,
+		ResourceID:   projectID,
+		ResourceName: "release_guardrails",
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		RequestBody:  string(body),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"guardrails": guardrails,
+	})
+}
+
+// AssessRisk 预估发布风险
+// GET /api/configs/:id/release-risk?env=prod&version=3
+func (h *ReleaseHandler) AssessRisk(c *gin.Context) {
+	configID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return
+	}
+
+	env := c.Query("env")
+	if env == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "环境不能为空",
+		})
+		return
+	}
+	version, _ := strconv.Atoi(c.Query("version"))
+
+	assessment, err := h.releaseSvc.AssessRisk(c.Request.Context(), configID, env, version)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"risk": assessment,
+	})
+}
+
+// GetRiskPolicy 获取项目的风险审批策略
+// GET /api/projects/:id/risk-policy
+func (h *ReleaseHandler) GetRiskPolicy(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	policy, err := h.releaseSvc.GetRiskPolicy(c.Request.Context(), projectID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policy": policy,
+	})
+}
+
+// UpdateRiskPolicy 更新项目的风险审批策略
+// PUT /api/projects/:id/risk-policy
+func (h *ReleaseHandler) UpdateRiskPolicy(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	var policy service.RiskPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.releaseSvc.UpdateRiskPolicy(c.Request.Context(), projectID, &policy); err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	body, _ := json.Marshal(policy)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    projectID,
+		UserID:       &userID,
+		Action:       model.AuditActionUpdate,
+		ResourceType: model.AuditResourceProject,
+		ResourceID:   projectID,
+		ResourceName: "risk_policy",
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		RequestBody:  string(body),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"policy": policy,
+	})
+}
+
+// Approve 审批待审批的发布
+// POST /api/releases/:id/approve
+func (h *ReleaseHandler) Approve(c *gin.Context) {
+	releaseID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的发布 ID",
+		})
+		return
+	}
+
+	var req struct {
+		Comment string `json:"comment"`
+	}
+	c.ShouldBindJSON(&req)
+
+	userID := getUserID(c)
+	release, err := h.releaseSvc.Approve(c.Request.Context(), releaseID, userID, req.Comment)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    release.ProjectID,
+		UserID:       &userID,
+		Action:       model.AuditActionApprove,
+		ResourceType: model.AuditResourceRelease,
+		ResourceID:   release.ID,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"release": release,
+	})
+}
+
+// Reject 驳回待审批的发布
+// POST /api/releases/:id/reject
+func (h *ReleaseHandler) Reject(c *gin.Context) {
+	releaseID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的发布 ID",
+		})
+		return
+	}
+
+	release, err := h.releaseSvc.Reject(c.Request.Context(), releaseID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    release.ProjectID,
+		UserID:       &userID,
+		Action:       model.AuditActionReject,
+		ResourceType: model.AuditResourceRelease,
+		ResourceID:   release.ID,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"release": release,
+	})
+}
+
+// ListApprovals 获取发布的审批记录
+// GET /api/releases/:id/approvals
+func (h *ReleaseHandler) ListApprovals(c *gin.Context) {
+	releaseID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的发布 ID",
+		})
+		return
+	}
+
+	approvals, err := h.releaseSvc.ListApprovals(c.Request.Context(), releaseID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"approvals": approvals,
+	})
+}
+
+// ConfigAt 获取配置在某一时刻的版本和生效的发布, 用于事故复盘
+// GET /api/configs/:id/at?time=2024-05-01T00:00:00Z
+func (h *ReleaseHandler) ConfigAt(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return
+	}
+	at, ok := parseAtTime(c)
+	if !ok {
+		return
+	}
+
+	snapshot, err := h.releaseSvc.ConfigAt(c.Request.Context(), id, at)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// EnvironmentAt 获取环境下所有配置在某一时刻的版本和生效的发布
+// GET /api/projects/:id/environments/:env/at?time=2024-05-01T00:00:00Z&namespace=application
+func (h *ReleaseHandler) EnvironmentAt(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+	at, ok := parseAtTime(c)
+	if !ok {
+		return
+	}
+
+	snapshot, err := h.releaseSvc.EnvironmentAt(c.Request.Context(), projectID, c.Param("env"), c.Query("namespace"), at)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// parseAtTime 解析查询参数 time (RFC3339), 缺少或无效时返回 400
+func parseAtTime(c *gin.Context) (time.Time, bool) {
+	at, err := time.Parse(time.RFC3339, c.Query("time"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的时间, 请使用 RFC3339 格式",
+		})
+		return time.Time{}, false
+	}
+	return at, true
+}
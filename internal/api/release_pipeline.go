@@ -0,0 +1,160 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"confighub/internal/model"
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPipeline 获取项目的发布流水线
+// GET /api/projects/:id/release-pipeline
+func (h *ReleaseHandler) GetPipeline(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	pipeline, err := h.pipelineSvc.Get(c.Request.Context(), projectID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pipeline": pipeline,
+	})
+}
+
+// UpdatePipeline 更新项目的发布流水线
+// PUT /api/projects/:id/release-pipeline
+func (h *ReleaseHandler) UpdatePipeline(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	var pipeline service.ReleasePipeline
+	if err := c.ShouldBindJSON(&pipeline); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.pipelineSvc.Update(c.Request.Context(), projectID, &pipeline); err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	body, _ := json.Marshal(pipeline)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    projectID,
+		UserID:       &userID,
+		Action:       model.AuditActionUpdate,
+		ResourceType: model.AuditResourceProject,
+		ResourceID:   projectID,
+		ResourceName: "release_pipeline",
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		RequestBody:  string(body),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"pipeline": pipeline,
+	})
+}
+
+// PreviewPipelineRequest 流水线试运行请求
+type PreviewPipelineRequest struct {
+	Environment string `json:"environment" binding:"required"`
+	Version     int    `json:"version"` // 为空时使用当前版本
+}
+
+// PreviewPipeline 按项目流水线试运行配置版本, 不创建发布
+// POST /api/configs/:id/release-pipeline/preview
+func (h *ReleaseHandler) PreviewPipeline(c *gin.Context) {
+	configID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return
+	}
+
+	var req PreviewPipelineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	userID := getUserID(c)
+	author := "user"
+	if userID > 0 {
+		author = strconv.FormatInt(userID, 10)
+	}
+
+	result, err := h.pipelineSvc.Preview(c.Request.Context(), configID, req.Environment, req.Version, author)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"result": result,
+	})
+}
+
+// GetArtifact 获取发布产物及流水线执行记录
+// GET /api/releases/:id/artifact
+func (h *ReleaseHandler) GetArtifact(c *gin.Context) {
+	releaseID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的发布 ID",
+		})
+		return
+	}
+
+	release, err := h.releaseSvc.GetByID(c.Request.Context(), releaseID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	log := []service.TransformLogEntry{}
+	if release.PipelineLog != "" {
+		_ = json.Unmarshal([]byte(release.PipelineLog), &log)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"release_id":    release.ID,
+		"version":       release.Version,
+		"environment":   release.Environment,
+		"transformed":   release.ArtifactHash != "",
+		"artifact":      release.Artifact,
+		"artifact_hash": release.ArtifactHash,
+		"pipeline_log":  log,
+	})
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+
+	"confighub/internal/model"
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReplicationHandler 跨实例复制处理器
+type ReplicationHandler struct {
+	replicationSvc *service.ReplicationService
+}
+
+// NewReplicationHandler 创建复制处理器
+func NewReplicationHandler(replicationSvc *service.ReplicationService) *ReplicationHandler {
+	return &ReplicationHandler{
+		replicationSvc: replicationSvc,
+	}
+}
+
+// Snapshot 导出项目快照 (供副本拉取)
+// GET /api/replication/projects/:name/snapshot
+func (h *ReplicationHandler) Snapshot(c *gin.Context) {
+	snapshot, err := h.replicationSvc.Snapshot(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// Apply 应用主实例推送的快照, 仅 receive 模式的副本接收
+// POST /api/replication/apply
+func (h *ReplicationHandler) Apply(c *gin.Context) {
+	var snapshot model.ReplicationSnapshot
+	if err := c.ShouldBindJSON(&snapshot); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数错误",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.replicationSvc.Receive(c.Request.Context(), &snapshot)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Status 获取复制状态
+// GET /api/admin/replication/status
+func (h *ReplicationHandler) Status(c *gin.Context) {
+	c.JSON(http.StatusOK, h.replicationSvc.Status())
+}
+
+// Sync 立即执行一次同步
+// POST /api/admin/replication/sync
+func (h *ReplicationHandler) Sync(c *gin.Context) {
+	status, err := h.replicationSvc.SyncOnce(c.Request.Context())
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"confighub/internal/model"
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RetentionHandler 版本保留策略处理器
+type RetentionHandler struct {
+	retentionSvc *service.RetentionService
+	auditSvc     *service.AuditService
+}
+
+// NewRetentionHandler 创建版本保留策略处理器
+func NewRetentionHandler(retentionSvc *service.RetentionService, auditSvc *service.AuditService) *RetentionHandler {
+	return &RetentionHandler{
+		retentionSvc: retentionSvc,
+		auditSvc:     auditSvc,
+	}
+}
+
+// Get 获取项目的版本保留策略
+// GET /api/projects/:id/version-retention
+func (h *RetentionHandler) Get(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	retention, err := h.retentionSvc.Get(c.Request.Context(), projectID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"retention": retention,
+	})
+}
+
+// Update 更新项目的版本保留策略
+// PUT /api/projects/:id/version-retention
+func (h *RetentionHandler) Update(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	var retention service.VersionRetention
+	if err := c.ShouldBindJSON(&retention); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.retentionSvc.Update(c.Request.Context(), projectID, &retention); err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	body, _ := json.Marshal(retention)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    projectID,
+		UserID:       &userID,
+		Action:       model.AuditActionUpdate,
+		ResourceType: model.AuditResourceProject,
+		ResourceID:   projectID,
+		ResourceName: "version_retention",
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		RequestBody:  string(body),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"retention": retention,
+	})
+}
+
+// Prune 立即按保留策略清理版本, 未指定项目时清理所有设置了策略的未归档项目
+// POST /api/admin/versions/prune?project_id=1&dry_run=true
+func (h *RetentionHandler) Prune(c *gin.Context) {
+	var projectID int64
+	if idStr := c.Query("project_id"); idStr != "" {
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "无效的项目 ID",
+			})
+			return
+		}
+		projectID = id
+	}
+
+	result, err := h.retentionSvc.Prune(c.Request.Context(), projectID, isDryRun(c))
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Stats 获取版本清理统计
+// GET /api/admin/versions/prune
+func (h *RetentionHandler) Stats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.retentionSvc.Stats())
+}
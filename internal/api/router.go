@@ -1,7 +1,11 @@
 package api
 
 import (
+	"context"
+	"time"
+
 	"confighub/internal/config"
+	"confighub/internal/database"
 	"confighub/internal/middleware"
 	"confighub/internal/repository"
 	"confighub/internal/service"
@@ -13,130 +17,492 @@ import (
 )
 
 // RegisterRoutes 注册所有路由
-func RegisterRoutes(router *gin.Engine, db *gorm.DB, rdb *redis.Client, logger *zap.Logger, cfg *config.Config) {
+// 公开配置接口 (/api/v1, 数据面) 注册到 data, 管理接口 (/api) 和指标注册到 management;
+// 未配置独立的管理面监听地址时两者为同一个 Engine
+func RegisterRoutes(data, management *gin.Engine, db *gorm.DB, rdb *redis.Client, logger *zap.Logger, cfg *config.Config) {
 	// 初始化 Repository
 	projectRepo := repository.NewProjectRepository(db)
 	configRepo := repository.NewConfigRepository(db)
-	versionRepo := repository.NewVersionRepository(db)
+	var versionRepo repository.VersionStore = repository.NewVersionRepository(db)
 	keyRepo := repository.NewKeyRepository(db)
 	auditRepo := repository.NewAuditRepository(db)
 	releaseRepo := repository.NewReleaseRepository(db)
+	experimentRepo := repository.NewExperimentRepository(db)
+	orphanRepo := repository.NewOrphanRepository(db)
+	envRepo := repository.NewEnvironmentRepository(db)
+	replicationRepo := repository.NewReplicationRepository(db)
+	migrationRepo := repository.NewMigrationRepository(db)
+	contractRepo := repository.NewContractRepository(db)
+	integrationRepo := repository.NewIntegrationRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	usageRepo := repository.NewUsageRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	signatureRepo := repository.NewSignatureRepository(db)
+	protoRepo := repository.NewProtoDescriptorRepository(db)
+	transferRepo := repository.NewTransferRepository(db)
+	configKeyRepo := repository.NewConfigKeyRepository(db)
+	retentionRepo := repository.NewRetentionRepository(db)
+	statsRepo := repository.NewStatsRepository(db)
+	accessReviewRepo := repository.NewAccessReviewRepository(db)
+	dataKeyRepo := repository.NewDataKeyRepository(db)
+
+	// 版本存储: 使用 Git 时, 直接读取版本表的复制和环境克隆也从仓库读取内容
+	if cfg.Storage.Versions == config.VersionStorageGit {
+		store, err := repository.NewGitVersionStore(db, repository.GitVersionOptions{
+			Dir:         cfg.Storage.Git.Dir,
+			Remote:      cfg.Storage.Git.Remote,
+			Branch:      cfg.Storage.Git.Branch,
+			AuthorName:  cfg.Git.AuthorName,
+			AuthorEmail: cfg.Git.AuthorEmail,
+			OnError: func(err error) {
+				logger.Warn("Failed to push version storage", zap.Error(err))
+			},
+		})
+		if err != nil {
+			logger.Fatal("Failed to open git version storage", zap.String("dir", cfg.Storage.Git.Dir), zap.Error(err))
+		}
+		versionRepo = store
+		envRepo.SetContentLoader(store.Fill)
+		replicationRepo.SetContentLoader(store.Fill)
+		logger.Info("Storing versions in git", zap.String("dir", cfg.Storage.Git.Dir))
+	}
 
 	// 初始化 Service
-	projectSvc := service.NewProjectService(projectRepo, keyRepo)
-	configSvc := service.NewConfigService(configRepo, versionRepo, projectRepo)
-	versionSvc := service.NewVersionService(versionRepo, configRepo)
-	schemaSvc := service.NewSchemaService(configRepo, versionRepo)
-	keySvc := service.NewKeyService(keyRepo)
-	auditSvc := service.NewAuditService(auditRepo)
-	encryptSvc := service.NewEncryptionService(cfg.Encrypt.Key)
-	releaseSvc := service.NewReleaseService(releaseRepo, configRepo, versionRepo)
 	notifySvc := service.NewNotificationService(rdb)
-	grayReleaseSvc := service.NewGrayReleaseService(releaseRepo, configRepo, versionRepo)
-	envSvc := service.NewEnvironmentService(projectRepo, configRepo, versionRepo)
-	envDiffSvc := service.NewEnvDiffService(configRepo, versionRepo)
+	notifySvc.SetShedding(watchShedding(cfg))
+	encryptSvc := service.NewEncryptionService(cfg.Encrypt.Key)
+	encryptSvc.SetDataKeyStore(dataKeyRepo)
+	projectSvc := service.NewProjectService(projectRepo, keyRepo, notifySvc, encryptSvc, time.Duration(cfg.Project.DeleteGraceHours)*time.Hour)
+	contractSvc := service.NewContractService(contractRepo, configRepo, versionRepo)
+	schemaSvc := service.NewSchemaService(configRepo, versionRepo, projectRepo, protoRepo)
+	configSvc := service.NewConfigService(configRepo, versionRepo, projectRepo, notifySvc, contractSvc, schemaSvc)
+	readCache := service.NewConfigReadCache(rdb, notifySvc, time.Duration(cfg.Cache.RedisTTLSeconds)*time.Second)
+	configSvc.SetReadCache(readCache)
+	kvSvc := service.NewKVService(configKeyRepo, configRepo, versionRepo, configSvc, notifySvc)
+	chaosSvc := service.NewChaosService(notifySvc, configRepo, projectRepo)
+	retentionSvc := service.NewRetentionService(retentionRepo, projectRepo, configRepo)
+	versionSvc := service.NewVersionService(versionRepo, configRepo, notifySvc, schemaSvc)
+	signatureSvc := service.NewSignatureService(signatureRepo, configRepo, versionRepo, projectRepo)
+	keySvc := service.NewKeyService(keyRepo, notifySvc, encryptSvc)
+	nonceStore := service.NewNonceStore(rdb, middleware.NonceWindow)
+	auditSvc := service.NewAuditService(auditRepo)
+	localeSvc := service.NewLocaleService(projectRepo)
+	pipelineSvc := service.NewReleasePipelineService(projectRepo, configRepo, versionRepo, encryptSvc)
+	releaseSvc := service.NewReleaseService(releaseRepo, configRepo, versionRepo, projectRepo, notifySvc, contractSvc, schemaSvc, pipelineSvc)
+	grayReleaseSvc := service.NewGrayReleaseService(releaseRepo, configRepo, versionRepo, notifySvc, pipelineSvc)
+	envSvc := service.NewEnvironmentService(projectRepo, configRepo, versionRepo, envRepo, notifySvc)
+	envDiffSvc := service.NewEnvDiffService(configRepo, versionRepo, envSvc, contractSvc)
+	transferSvc := service.NewTransferService(transferRepo, projectRepo, configRepo, notifySvc)
+	preflightSvc := service.NewPreflightService(configRepo, projectRepo, schemaSvc, contractSvc, envSvc, envDiffSvc, releaseSvc)
+	resilienceSvc := service.NewResilienceService(migrationRepo, cfg.Resilience.Enabled, cfg.Resilience.FailureThreshold)
+	resolveStats := service.NewResolveStats()
+	hotCache := service.NewHotConfigCache(configSvc, releaseSvc, grayReleaseSvc, envSvc, notifySvc, resilienceSvc, cfg.Cache.HotSize)
+	experimentSvc := service.NewExperimentService(experimentRepo)
+	metricsSvc := service.NewMetricsService(configRepo, versionRepo)
+	orphanSvc := service.NewOrphanService(orphanRepo)
+	migrationSvc := service.NewMigrationService(migrationRepo, database.SchemaVersion, cfg.Database.MigrationGate)
+	watchTokenSvc := service.NewWatchTokenService(keyRepo, cfg.JWT.Secret)
+	usageSvc := service.NewUsageService(usageRepo, projectRepo)
+	selfCheckSvc := service.NewSelfCheckService(migrationRepo, rdb, cfg.Env, cfg.CheckSettings())
+	eventSvc := service.NewEventService(notificationRepo, configRepo, notifySvc)
+	statsSvc := service.NewStatsService(statsRepo, projectRepo, notifySvc)
+	accessReviewSvc := service.NewAccessReviewService(accessReviewRepo)
+	integrationSvc := service.NewIntegrationService(integrationRepo, configRepo, configSvc, schemaSvc, contractSvc)
+	onboardingSvc, err := service.NewOnboardingService(projectRepo, projectSvc, configSvc, schemaSvc, keySvc, integrationSvc, cfg.Project.TemplateDir)
+	if err != nil {
+		logger.Warn("Failed to load project templates", zap.String("dir", cfg.Project.TemplateDir), zap.Error(err))
+	}
+	webhookSvc := service.NewWebhookService(webhookRepo, projectRepo, configRepo, versionRepo, releaseRepo, notifySvc)
+	gitSyncSvc := service.NewGitSyncService(projectRepo, configRepo, versionRepo, configSvc, notifySvc, service.GitSyncOptions{
+		WorkDir:     cfg.Git.WorkDir,
+		AuthorName:  cfg.Git.AuthorName,
+		AuthorEmail: cfg.Git.AuthorEmail,
+	})
+	replicationSvc := service.NewReplicationService(replicationRepo, notifySvc, service.ReplicationOptions{
+		Enabled:        cfg.Replication.Enabled,
+		Mode:           cfg.Replication.Mode,
+		PeerURL:        cfg.Replication.PeerURL,
+		Token:          cfg.Replication.Token,
+		Projects:       cfg.Replication.Projects,
+		Interval:       time.Duration(cfg.Replication.IntervalSeconds) * time.Second,
+		ConflictPolicy: cfg.Replication.ConflictPolicy,
+	})
+	bundleSvc := service.NewProjectBundleService(replicationRepo, projectRepo, notifySvc)
+	offlineSvc := service.NewOfflineBundleService(configRepo, versionRepo, releaseSvc, envSvc, encryptSvc, service.OfflineBundleOptions{
+		SigningKey: cfg.Offline.SigningKey,
+		JWTSecret:  cfg.JWT.Secret,
+		DefaultTTL: time.Duration(cfg.Offline.DefaultTTLHours) * time.Hour,
+		MaxTTL:     time.Duration(cfg.Offline.MaxTTLHours) * time.Hour,
+	})
+	accessLogSvc, err := service.NewAccessLogService(cfg.AccessLog.Enabled, cfg.AccessLog.Output, cfg.AccessLog.SampleRate)
+	if err != nil {
+		logger.Warn("Failed to init access log output, falling back to app logger", zap.Error(err))
+		accessLogSvc = service.NewAccessLogServiceWithLogger(logger.Named("access"), cfg.AccessLog.SampleRate)
+	}
 
 	// 初始化 Handler
 	projectHandler := NewProjectHandler(projectSvc, auditSvc)
-	configHandler := NewConfigHandler(configSvc, auditSvc)
-	versionHandler := NewVersionHandler(versionSvc)
+	configHandler := NewConfigHandler(configSvc, metricsSvc, auditSvc, contractSvc, schemaSvc)
+	versionHandler := NewVersionHandler(versionSvc, signatureSvc)
 	schemaHandler := NewSchemaHandler(schemaSvc)
 	keyHandler := NewKeyHandler(keySvc, auditSvc)
 	auditHandler := NewAuditHandler(auditSvc)
-	releaseHandler := NewReleaseHandler(releaseSvc, grayReleaseSvc, auditSvc)
-	publicConfigHandler := NewPublicConfigHandler(configSvc, encryptSvc, notifySvc, auditSvc)
+	releaseHandler := NewReleaseHandler(releaseSvc, grayReleaseSvc, auditSvc, contractSvc, schemaSvc, pipelineSvc)
+	publicConfigHandler := NewPublicConfigHandler(configSvc, encryptSvc, notifySvc, auditSvc, grayReleaseSvc, experimentSvc, envSvc, hotCache, localeSvc, resolveStats)
 	envHandler := NewEnvironmentHandler(envSvc, envDiffSvc)
-	authHandler := NewAuthHandler(db, cfg.JWT.Secret)
-
-	// 根路径 - API 信息
-	router.GET("/", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"service": "ConfigHub",
-			"version": "1.0.0",
-			"status":  "running",
-			"endpoints": gin.H{
-				"health":   "/health",
-				"api":      "/api/v1",
-				"docs":     "https://github.com/gzhangrencai/config-hub",
-			},
-		})
+	var ldapSvc *service.LDAPService
+	if cfg.Auth.LDAP.Enabled {
+		ldapSvc = service.NewLDAPService(cfg.Auth.LDAP, repository.NewUserRepository(db))
+	}
+	authHandler := NewAuthHandler(db, cfg.JWT.Secret, ldapSvc)
+	experimentHandler := NewExperimentHandler(experimentSvc)
+	metricsHandler := NewMetricsHandler(metricsSvc, hotCache, readCache, notifySvc, retentionSvc, resolveStats)
+	adminHandler := NewAdminHandler(orphanSvc, migrationSvc, usageSvc, selfCheckSvc, accessReviewSvc)
+	watchTokenHandler := NewWatchTokenHandler(watchTokenSvc)
+	replicationHandler := NewReplicationHandler(replicationSvc)
+	contractHandler := NewContractHandler(contractSvc, configSvc)
+	integrationHandler := NewIntegrationHandler(integrationSvc, auditSvc)
+	eventHandler := NewEventHandler(eventSvc, notifySvc)
+	onboardingHandler := NewOnboardingHandler(onboardingSvc, auditSvc)
+	gitHandler := NewGitHandler(gitSyncSvc, auditSvc)
+	webhookHandler := NewWebhookHandler(webhookSvc, auditSvc)
+	offlineHandler := NewOfflineBundleHandler(offlineSvc)
+	localeHandler := NewLocaleHandler(localeSvc, auditSvc)
+	bundleHandler := NewProjectBundleHandler(bundleSvc, auditSvc)
+	signatureHandler := NewSignatureHandler(signatureSvc, configSvc, auditSvc)
+	preflightHandler := NewPreflightHandler(preflightSvc)
+	transferHandler := NewTransferHandler(transferSvc, auditSvc)
+	kvHandler := NewKVHandler(kvSvc, configSvc, auditSvc)
+	chaosHandler := NewChaosHandler(chaosSvc)
+	retentionHandler := NewRetentionHandler(retentionSvc, auditSvc)
+	statsHandler := NewStatsHandler(statsSvc)
+
+	// 迁移版本门控: 启动时检查一次, 之后定期复查, 版本不一致期间拒绝写请求
+	migration := migrationSvc.Refresh(context.Background())
+	if migration.Writable {
+		logger.Info("Database migration version checked", zap.Uint("expected", migration.Expected), zap.Bool("managed", migration.Managed))
+	} else {
+		logger.Warn("Database migration version mismatch, writes are rejected", zap.Uint("expected", migration.Expected), zap.String("reason", migration.Reason))
+	}
+	go migrationSvc.Run(context.Background(), 30*time.Second)
+	for _, router := range planes(data, management) {
+		router.Use(middleware.MigrationGate(migrationSvc))
+	}
+
+	// 数据库故障降级: 探测连续失败后拒绝写请求, 公开读取以缓存中最近一次下发的内容响应
+	go resilienceSvc.Run(context.Background(), time.Duration(cfg.Resilience.CheckIntervalSeconds)*time.Second, func(status *service.ResilienceStatus) {
+		if status.Degraded {
+			logger.Error("Database unavailable, serving cached configs in degraded mode", zap.String("reason", status.Reason))
+			return
+		}
+		logger.Info("Database recovered, leaving degraded mode")
 	})
+	for _, router := range planes(data, management) {
+		router.Use(middleware.Resilience(resilienceSvc))
+	}
+
+	// 启动自检: 配置项结果已在启动时输出, 此处补充外部依赖探测
+	go func() {
+		if report := selfCheckSvc.Refresh(context.Background()); report.Status != config.SeverityOK {
+			logger.Warn("Startup self-check reported problems, see GET /api/admin/selfcheck", zap.String("status", report.Status))
+		}
+	}()
+
+	// 预热热点缓存, 不阻塞启动
+	go func() {
+		loaded, err := hotCache.Warmup(context.Background(), cfg.Cache.WarmupSize)
+		if err != nil {
+			logger.Warn("Failed to warm up hot config cache", zap.Error(err))
+			return
+		}
+		logger.Info("Hot config cache warmed up", zap.Int("configs", loaded))
+	}()
 
-	// 健康检查
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
+	// 定期清理过期的沙箱环境
+	go envSvc.RunSandboxCleanup(context.Background(), 10*time.Minute, func(err error) {
+		logger.Warn("Failed to clean up expired sandbox environments", zap.Error(err))
 	})
-	router.GET("/api/v1/health", func(c *gin.Context) {
+
+	// 定期清理过期的变更事件日志
+	go eventSvc.RunPrune(context.Background(), time.Hour, func(err error) {
+		logger.Warn("Failed to prune config change events", zap.Error(err))
+	})
+
+	// 项目用量计量: 每分钟写入调用次数和监听时长, 每天记录存储快照
+	go usageSvc.Run(context.Background(), time.Minute, func(err error) {
+		logger.Warn("Failed to record project usage", zap.Error(err))
+	})
+
+	// 灰度实验曝光: 公开读取时在内存中合并, 每 10 秒批量写入
+	go experimentSvc.Run(context.Background(), 10*time.Second, func(err error) {
+		logger.Warn("Failed to record gray exposures", zap.Error(err))
+	})
+
+	// 出站 Webhook: 配置变更时立即投递, 每 10 秒重试到期的失败投递
+	go webhookSvc.Run(context.Background(), 10*time.Second, func(err error) {
+		logger.Warn("Failed to deliver webhooks", zap.Error(err))
+	})
+
+	// Git 同步: 配置变更时立即导出, 并定期导出所有关联仓库的项目
+	go gitSyncSvc.Run(context.Background(), time.Duration(cfg.Git.IntervalSeconds)*time.Second, func(err error) {
+		logger.Warn("Failed to export configs to git", zap.Error(err))
+	})
+
+	// 统计概览: 每分钟重新聚合计数
+	go statsSvc.Run(context.Background(), time.Minute, func(err error) {
+		logger.Warn("Failed to refresh stats", zap.Error(err))
+	})
+	metricsInterval := time.Duration(cfg.Metrics.RefreshSeconds) * time.Second
+	if metricsInterval <= 0 {
+		metricsInterval = time.Minute
+	}
+	go metricsSvc.Run(context.Background(), metricsInterval, func(err error) {
+		logger.Warn("Failed to refresh config metrics", zap.Error(err))
+	})
+
+	// 按项目的保留策略定期清理旧版本
+	if cfg.Retention.IntervalMinutes > 0 {
+		go retentionSvc.RunPrune(context.Background(), time.Duration(cfg.Retention.IntervalMinutes)*time.Minute, func(err error) {
+			logger.Warn("Failed to prune config versions", zap.Error(err))
+		})
+	}
+
+	// 跨实例复制: 按配置定时推送或拉取项目快照
+	if cfg.Replication.Enabled {
+		go replicationSvc.Run(context.Background())
+	}
+
+	for _, router := range planes(data, management) {
+		// 根路径 - API 信息
+		router.GET("/", func(c *gin.Context) {
+			c.JSON(200, gin.H{
+				"service": "ConfigHub",
+				"version": "1.0.0",
+				"status":  "running",
+				"endpoints": gin.H{
+					"health": "/health",
+					"api":    "/api/v1",
+					"docs":   "https://github.com/gzhangrencai/config-hub",
+				},
+			})
+		})
+
+		// 健康检查
+		// 降级期间仍返回 200, 实例可以继续承接读取流量
+		router.GET("/health", func(c *gin.Context) {
+			if middleware.IsDegraded(c) {
+				c.JSON(200, gin.H{"status": "degraded", "resilience": resilienceSvc.Status()})
+				return
+			}
+			c.JSON(200, gin.H{"status": "ok"})
+		})
+	}
+	data.GET("/api/v1/health", func(c *gin.Context) {
+		if middleware.IsDegraded(c) {
+			c.JSON(200, gin.H{"status": "degraded", "service": "confighub", "resilience": resilienceSvc.Status()})
+			return
+		}
 		c.JSON(200, gin.H{"status": "ok", "service": "confighub"})
 	})
 
+	// Prometheus 指标, 配置指标按 metrics.refresh_seconds 定期计算
+	metricsAuth, err := middleware.MetricsAuth(cfg.Metrics.Token, cfg.Metrics.AllowedCIDRs)
+	if err != nil {
+		logger.Fatal("Invalid metrics.allowed_cidrs", zap.Error(err))
+	}
+	management.GET("/metrics", metricsAuth, metricsHandler.Prometheus)
+
+	// 归档项目写保护
+	archivedByProject := middleware.RejectArchivedProject(db, middleware.ProjectFromParam)
+	archivedByConfig := middleware.RejectArchivedProject(db, middleware.ProjectFromConfigParam)
+	archivedByRelease := middleware.RejectArchivedProject(db, middleware.ProjectFromReleaseParam)
+	archivedByAuth := middleware.RejectArchivedProject(db, middleware.ProjectFromAuthContext)
+	archivedByIntegration := middleware.RejectArchivedProject(db, middleware.ProjectFromIntegrationParam)
+	archivedByWebhook := middleware.RejectArchivedProject(db, middleware.ProjectFromWebhookParam)
+
 	// API v1 - 公开配置接口 (客户端使用)
-	v1 := router.Group("/api/v1")
+	v1 := data.Group("/api/v1")
 	{
-		v1.Use(middleware.OptionalAuth(db, cfg.JWT.Secret))
-		v1.GET("/config", publicConfigHandler.Get)
-		v1.PUT("/config", middleware.RequirePermission("write"), publicConfigHandler.Update)
-		v1.POST("/config", middleware.RequirePermission("write"), publicConfigHandler.Create)
-		v1.GET("/config/watch", publicConfigHandler.Watch)
+		v1.Use(middleware.QueryAccessKey(cfg.Auth.AllowQueryAccessKey, projectSvc.RejectsQueryAccessKey), middleware.DegradedAuth(notifySvc, time.Duration(cfg.Resilience.AuthTTLSeconds)*time.Second, cfg.Resilience.AuthMaxEntries), middleware.OptionalAuth(db, cfg.JWT.Secret), middleware.SignatureAuth(db, keySvc, nonceStore, projectSvc.RequiresSignature, cfg.Auth.SignedBodyMaxBytes), middleware.Usage(usageSvc), middleware.RequestErrors(statsSvc))
+		accessMode := middleware.EnforceAccessMode(db, projectSvc.GetCached)
+		watchDeadline := middleware.StreamDeadline(time.Duration(cfg.Server.Watch.Timeout) * time.Second)
+		watchAdmission := middleware.WatchAdmission(notifySvc)
+		v1.GET("/config", middleware.AccessLog(accessLogSvc), accessMode, middleware.RequirePermission("read"), publicConfigHandler.Get)
+		v1.PUT("/config", accessMode, middleware.RequirePermission("write"), archivedByAuth, publicConfigHandler.Update)
+		v1.POST("/config", accessMode, middleware.RequirePermission("write"), archivedByAuth, publicConfigHandler.Create)
+		v1.PATCH("/config", accessMode, middleware.RequirePermission("write"), archivedByAuth, publicConfigHandler.Patch)
+		v1.GET("/config/key", accessMode, middleware.RequirePermission("read"), kvHandler.PublicGet)
+		v1.PATCH("/config/key", accessMode, middleware.RequirePermission("write"), archivedByAuth, kvHandler.PublicSet)
+		v1.DELETE("/config/key", accessMode, middleware.RequirePermission("write"), archivedByAuth, kvHandler.PublicDelete)
+		v1.GET("/config/watch", watchDeadline, middleware.AccessLog(accessLogSvc), middleware.WatcherUsage(usageSvc), middleware.WatchTokenAuth(watchTokenSvc), accessMode, middleware.RequirePermission("read"), watchAdmission, publicConfigHandler.Watch)
+		v1.GET("/config/watch/stream", middleware.StreamDeadline(0), middleware.AccessLog(accessLogSvc), middleware.WatcherUsage(usageSvc), middleware.WatchTokenAuth(watchTokenSvc), accessMode, middleware.RequirePermission("read"), watchAdmission, publicConfigHandler.WatchStream)
+		v1.GET("/config/events", middleware.StreamDeadline(0), middleware.AccessLog(accessLogSvc), middleware.WatcherUsage(usageSvc), middleware.WatchTokenAuth(watchTokenSvc), accessMode, middleware.RequirePermission("read"), watchAdmission, eventHandler.Stream)
+		v1.POST("/config/watch-token", accessMode, middleware.RequirePermission("read"), watchTokenHandler.Issue)
+		v1.POST("/config/signature", accessMode, middleware.RequirePermission("write"), archivedByAuth, signatureHandler.AttachByAccessKey)
+		v1.PUT("/config/contract", accessMode, middleware.RequirePermission("read"), contractHandler.Register)
+		v1.GET("/config/transports", publicConfigHandler.Transports)
+		v1.GET("/config/_ping", accessMode, middleware.RequirePermission("read"), publicConfigHandler.Ping)
+		v1.GET("/bootstrap", middleware.AccessLog(accessLogSvc), accessMode, middleware.RequirePermission("read"), publicConfigHandler.Bootstrap)
+		v1.GET("/configs", middleware.AccessLog(accessLogSvc), accessMode, middleware.RequirePermission("read"), publicConfigHandler.List)
+		v1.GET("/offline-bundle", middleware.AccessLog(accessLogSvc), accessMode, middleware.RequirePermission("read"), offlineHandler.Export)
+		v1.GET("/offline-bundle/public-key", offlineHandler.PublicKey)
+
+		// 入站集成: 外部系统使用集成令牌推送数据
+		v1.POST("/inbound/:id", archivedByIntegration, integrationHandler.Receive)
 	}
 
 	// API - 管理接口
-	api := router.Group("/api")
+	api := management.Group("/api")
 	{
 		// 项目管理
 		projects := api.Group("/projects")
 		projects.Use(middleware.JWTAuth(cfg.JWT.Secret))
 		{
 			projects.POST("", projectHandler.Create)
+			projects.GET("/templates", onboardingHandler.Templates)
+			projects.POST("/from-template", onboardingHandler.CreateFromTemplate)
 			projects.GET("", projectHandler.List)
 			projects.GET("/:id", projectHandler.Get)
-			projects.PUT("/:id", projectHandler.Update)
+			projects.PUT("/:id", archivedByProject, projectHandler.Update)
 			projects.DELETE("/:id", projectHandler.Delete)
+			projects.POST("/:id/archive", projectHandler.Archive)
+			projects.POST("/:id/unarchive", projectHandler.Unarchive)
+			projects.POST("/:id/export", bundleHandler.Export)
+			projects.POST("/:id/import", archivedByProject, bundleHandler.Import)
+
+			// 项目数据加密密钥
+			projects.GET("/:id/data-keys", projectHandler.ListDataKeys)
+			projects.POST("/:id/data-keys/rotate", archivedByProject, projectHandler.RotateDataKey)
+			projects.POST("/:id/encrypt", archivedByProject, projectHandler.EncryptValue)
+			projects.POST("/:id/decrypt", projectHandler.DecryptValue)
+
+			// 项目所有权转移及配置迁移
+			projects.POST("/:id/transfers", archivedByProject, transferHandler.Request)
+			projects.GET("/:id/transfers", transferHandler.ListByProject)
 
 			// 项目下的配置
-			projects.POST("/:id/configs", configHandler.Upload)
+			projects.POST("/:id/configs", archivedByProject, configHandler.Upload)
 			projects.GET("/:id/configs", configHandler.List)
 
 			// 项目下的密钥
 			projects.POST("/:id/keys", keyHandler.Create)
 			projects.GET("/:id/keys", keyHandler.List)
 
+			// 项目登记的签名公钥
+			projects.GET("/:id/signing-keys", signatureHandler.ListKeys)
+			projects.POST("/:id/signing-keys", archivedByProject, signatureHandler.CreateKey)
+
+			// 项目下的入站集成
+			projects.POST("/:id/integrations", archivedByProject, integrationHandler.Create)
+			projects.GET("/:id/integrations", integrationHandler.List)
+
+			// 项目下的出站 Webhook
+			projects.POST("/:id/webhooks", archivedByProject, webhookHandler.Create)
+			projects.GET("/:id/webhooks", webhookHandler.List)
+			projects.POST("/:id/webhooks/rotate-secret", archivedByProject, webhookHandler.RotateSecret)
+
+			// 项目 Git 仓库同步
+			projects.POST("/:id/git/sync", archivedByProject, gitHandler.Sync)
+
 			// 项目下的审计日志
 			projects.GET("/:id/audit-logs", auditHandler.List)
 
+			// 项目发布说明
+			projects.GET("/:id/release-notes", releaseHandler.ReleaseNotes)
+			projects.GET("/:id/release-guardrails", releaseHandler.GetGuardrails)
+			projects.PUT("/:id/release-guardrails", archivedByProject, releaseHandler.UpdateGuardrails)
+			projects.GET("/:id/risk-policy", releaseHandler.GetRiskPolicy)
+			projects.PUT("/:id/risk-policy", archivedByProject, releaseHandler.UpdateRiskPolicy)
+			projects.GET("/:id/version-retention", retentionHandler.Get)
+			projects.GET("/:id/stats", statsHandler.Project)
+			projects.PUT("/:id/version-retention", archivedByProject, retentionHandler.Update)
+			projects.GET("/:id/release-pipeline", releaseHandler.GetPipeline)
+			projects.PUT("/:id/release-pipeline", archivedByProject, releaseHandler.UpdatePipeline)
+			projects.GET("/:id/preflight", preflightHandler.Run)
+
+			// 项目默认 Schema
+			projects.GET("/:id/schema-defaults", schemaHandler.GetDefaults)
+			projects.PUT("/:id/schema-defaults", archivedByProject, schemaHandler.UpdateDefaults)
+
+			// 项目内容规范化策略
+			projects.GET("/:id/normalization", configHandler.GetNormalization)
+			projects.PUT("/:id/normalization", archivedByProject, configHandler.UpdateNormalization)
+
+			// 项目多语言设置
+			projects.GET("/:id/locales", localeHandler.Get)
+			projects.PUT("/:id/locales", archivedByProject, localeHandler.Update)
+
 			// 项目下的环境
 			projects.GET("/:id/environments", envHandler.List)
-			projects.POST("/:id/environments", envHandler.Create)
+			projects.POST("/:id/environments", archivedByProject, envHandler.Create)
+			projects.GET("/:id/environments/:env/impact", envHandler.Impact)
+			projects.GET("/:id/environments/:env/at", releaseHandler.EnvironmentAt)
+			projects.POST("/:id/environments/:env/clone-to-sandbox", archivedByProject, envHandler.CloneToSandbox)
+			projects.PUT("/:id/environments/:env", archivedByProject, envHandler.Update)
+			projects.DELETE("/:id/environments/:env", archivedByProject, envHandler.Delete)
+			projects.GET("/:id/drift", envHandler.Drift)
+			projects.GET("/:id/environments/:env/variables", envHandler.GetVariables)
+			projects.PUT("/:id/environments/:env/variables", archivedByProject, envHandler.SetVariables)
 		}
 
 		// 配置管理
 		configs := api.Group("/configs")
 		configs.Use(middleware.JWTAuth(cfg.JWT.Secret))
 		{
+			configs.GET("/compare", versionHandler.Compare)
 			configs.GET("/:id", configHandler.Get)
-			configs.PUT("/:id", configHandler.Update)
-			configs.DELETE("/:id", configHandler.Delete)
+			configs.PUT("/:id", archivedByConfig, configHandler.Update)
+			configs.DELETE("/:id", archivedByConfig, configHandler.Delete)
 
 			// 版本管理
 			configs.GET("/:id/versions", versionHandler.List)
 			configs.GET("/:id/versions/:version", versionHandler.Get)
+			configs.GET("/:id/versions/:version/signatures", signatureHandler.List)
+			configs.POST("/:id/versions/:version/signatures", archivedByConfig, signatureHandler.Attach)
 			configs.GET("/:id/diff", versionHandler.Diff)
-			configs.POST("/:id/rollback/:version", versionHandler.Rollback)
+			configs.GET("/:id/diff-rules", configHandler.GetDiffRules)
+			configs.PUT("/:id/diff-rules", archivedByConfig, configHandler.UpdateDiffRules)
+			configs.GET("/:id/inheritance", configHandler.GetInheritance)
+			configs.PUT("/:id/parent", archivedByConfig, configHandler.SetParent)
+			configs.POST("/:id/rollback/:version", archivedByConfig, versionHandler.Rollback)
+
+			// kv 配置的单键读写
+			configs.GET("/:id/keys", kvHandler.List)
+			configs.GET("/:id/keys/:key", kvHandler.Get)
+			configs.GET("/:id/keys/:key/history", kvHandler.History)
+			configs.PUT("/:id/keys/:key", archivedByConfig, kvHandler.Set)
+			configs.DELETE("/:id/keys/:key", archivedByConfig, kvHandler.Delete)
 
 			// Schema 管理
 			configs.GET("/:id/schema", schemaHandler.Get)
-			configs.PUT("/:id/schema", schemaHandler.Update)
-			configs.POST("/:id/schema/generate", schemaHandler.Generate)
+			configs.GET("/:id/schema/effective", schemaHandler.Effective)
+			configs.PUT("/:id/schema", archivedByConfig, schemaHandler.Update)
+			configs.POST("/:id/schema/generate", archivedByConfig, schemaHandler.Generate)
+			configs.GET("/:id/schema-policy", schemaHandler.GetPolicy)
+			configs.PUT("/:id/schema-policy", archivedByConfig, schemaHandler.UpdatePolicy)
+			configs.GET("/:id/proto-descriptor", schemaHandler.GetProtoDescriptor)
+			configs.PUT("/:id/proto-descriptor", archivedByConfig, schemaHandler.UpdateProtoDescriptor)
+			configs.DELETE("/:id/proto-descriptor", archivedByConfig, schemaHandler.DeleteProtoDescriptor)
+			configs.GET("/:id/proto/json", schemaHandler.RenderProto)
 
 			// 发布管理
-			configs.POST("/:id/release", releaseHandler.Create)
+			configs.POST("/:id/release", archivedByConfig, releaseHandler.Create)
 			configs.GET("/:id/releases", releaseHandler.List)
-			configs.POST("/:id/gray-release", releaseHandler.CreateGray)
+			configs.GET("/:id/at", releaseHandler.ConfigAt)
+			configs.GET("/:id/release-risk", releaseHandler.AssessRisk)
+			configs.POST("/:id/release-pipeline/preview", releaseHandler.PreviewPipeline)
+			configs.POST("/:id/gray-release", archivedByConfig, releaseHandler.CreateGray)
+
+			// 消费契约
+			configs.GET("/:id/contracts", contractHandler.List)
+			configs.PUT("/:id/contracts", archivedByConfig, contractHandler.Upsert)
+			configs.GET("/:id/contracts/check", contractHandler.Check)
+			configs.DELETE("/:id/contracts/:consumer", archivedByConfig, contractHandler.Delete)
 
 			// 环境对比
 			configs.GET("/:id/compare", envHandler.Compare)
-			configs.POST("/:id/sync", envHandler.Sync)
-			configs.POST("/:id/merge", envHandler.MergeConfig)
+			configs.POST("/:id/sync", archivedByConfig, envHandler.Sync)
+			configs.POST("/:id/merge", archivedByConfig, envHandler.MergeConfig)
 		}
 
 		// 密钥管理
@@ -148,14 +514,98 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB, rdb *redis.Client, logger *
 			keys.POST("/:id/regenerate", keyHandler.Regenerate)
 		}
 
+		// 入站集成管理
+		integrations := api.Group("/integrations")
+		integrations.Use(middleware.JWTAuth(cfg.JWT.Secret))
+		{
+			integrations.PUT("/:id", archivedByIntegration, integrationHandler.Update)
+			integrations.DELETE("/:id", integrationHandler.Delete)
+			integrations.POST("/:id/regenerate", integrationHandler.RegenerateToken)
+		}
+
+		// 出站 Webhook 管理
+		webhooks := api.Group("/webhooks")
+		webhooks.Use(middleware.JWTAuth(cfg.JWT.Secret))
+		{
+			webhooks.PUT("/:id", archivedByWebhook, webhookHandler.Update)
+			webhooks.DELETE("/:id", webhookHandler.Delete)
+			webhooks.GET("/:id/deliveries", webhookHandler.Deliveries)
+			webhooks.GET("/:id/deliveries/:delivery", webhookHandler.GetDelivery)
+			webhooks.POST("/:id/test", webhookHandler.Test)
+			webhooks.POST("/:id/deliveries/:delivery/replay", archivedByWebhook, webhookHandler.Replay)
+			webhooks.POST("/:id/deliveries/replay-failed", archivedByWebhook, webhookHandler.ReplayFailed)
+		}
+
+		// 所有权转移申请: 接收方确认或拒绝, 发起方撤回
+		transfers := api.Group("/transfers")
+		transfers.Use(middleware.JWTAuth(cfg.JWT.Secret))
+		{
+			transfers.GET("", transferHandler.List)
+			transfers.GET("/:id", transferHandler.Get)
+			transfers.POST("/:id/accept", transferHandler.Accept)
+			transfers.POST("/:id/reject", transferHandler.Reject)
+			transfers.POST("/:id/cancel", transferHandler.Cancel)
+		}
+
+		// 签名公钥管理
+		signingKeys := api.Group("/signing-keys")
+		signingKeys.Use(middleware.JWTAuth(cfg.JWT.Secret))
+		{
+			signingKeys.POST("/:id/revoke", signatureHandler.RevokeKey)
+		}
+
 		// 发布管理
 		releases := api.Group("/releases")
 		releases.Use(middleware.JWTAuth(cfg.JWT.Secret))
 		{
-			releases.POST("/:id/rollback", releaseHandler.Rollback)
-			releases.POST("/:id/promote", releaseHandler.Promote)
-			releases.POST("/:id/cancel", releaseHandler.Cancel)
-			releases.PUT("/:id/percentage", releaseHandler.UpdateGrayPercentage)
+			releases.POST("/:id/rollback", archivedByRelease, releaseHandler.Rollback)
+			releases.POST("/:id/promote", archivedByRelease, releaseHandler.Promote)
+			releases.POST("/:id/cancel", archivedByRelease, releaseHandler.Cancel)
+			releases.PUT("/:id/percentage", archivedByRelease, releaseHandler.UpdateGrayPercentage)
+			releases.GET("/:id/exposures", experimentHandler.ExportExposures)
+			releases.POST("/:id/approve", archivedByRelease, releaseHandler.Approve)
+			releases.POST("/:id/reject", archivedByRelease, releaseHandler.Reject)
+			releases.GET("/:id/approvals", releaseHandler.ListApprovals)
+			releases.GET("/:id/artifact", releaseHandler.GetArtifact)
+		}
+
+		// 统计概览
+		stats := api.Group("/stats")
+		stats.Use(middleware.JWTAuth(cfg.JWT.Secret))
+		{
+			stats.GET("", statsHandler.Global)
+		}
+
+		// 运维管理
+		admin := api.Group("/admin")
+		admin.Use(middleware.JWTAuth(cfg.JWT.Secret), middleware.AdminOnly(cfg.Auth.AdminUserIDs))
+		{
+			admin.POST("/orphans/cleanup", adminHandler.CleanupOrphans)
+			admin.GET("/migrations", adminHandler.MigrationStatus)
+			admin.GET("/selfcheck", adminHandler.SelfCheck)
+			admin.GET("/usage", adminHandler.ExportUsage)
+			admin.GET("/access-review", adminHandler.ExportAccessReview)
+			admin.GET("/replication/status", replicationHandler.Status)
+			admin.POST("/replication/sync", replicationHandler.Sync)
+			admin.GET("/versions/prune", retentionHandler.Stats)
+			admin.POST("/versions/prune", retentionHandler.Prune)
+
+			// 监听链路故障注入, 仅用于测试环境
+			if cfg.Chaos.Enabled {
+				admin.GET("/chaos", chaosHandler.List)
+				admin.POST("/chaos/projects/:id/events", chaosHandler.InjectEvents)
+				admin.PUT("/chaos/projects/:id/delay", chaosHandler.SetDelay)
+				admin.DELETE("/chaos/projects/:id/delay", chaosHandler.ClearDelay)
+				admin.POST("/chaos/projects/:id/disconnect", chaosHandler.Disconnect)
+			}
+		}
+
+		// 跨实例复制 (实例间使用共享令牌认证)
+		replication := api.Group("/replication")
+		replication.Use(middleware.ReplicationAuth(replicationSvc.Token()))
+		{
+			replication.GET("/projects/:name/snapshot", replicationHandler.Snapshot)
+			replication.POST("/apply", replicationHandler.Apply)
 		}
 
 		// 用户认证
@@ -167,3 +617,62 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB, rdb *redis.Client, logger *
 		}
 	}
 }
+
+// planes 返回需要注册公共中间件和路由的 Engine, 数据面与管理面共用时只返回一个
+func planes(data, management *gin.Engine) []*gin.Engine {
+	if data == management {
+		return []*gin.Engine{data}
+	}
+	return []*gin.Engine{data, management}
+}
+
+// RegisterFollowerRoutes 注册只读跟随节点路由
+// 跟随节点不连接数据库, 配置和密钥来自主实例快照, 仅提供公开读取和监听接口
+func RegisterFollowerRoutes(router *gin.Engine, logger *zap.Logger, cfg *config.Config) {
+	notifySvc := service.NewNotificationService(nil)
+	notifySvc.SetShedding(watchShedding(cfg))
+	encryptSvc := service.NewEncryptionService(cfg.Encrypt.Key)
+	client := service.NewReplicationClient(cfg.Replication.PeerURL, cfg.Replication.Token)
+	followerSvc := service.NewFollowerService(client, notifySvc, cfg.Replication.Projects, time.Duration(cfg.Replication.IntervalSeconds)*time.Second)
+	encryptSvc.SetDataKeyStore(followerSvc)
+	accessLogSvc, err := service.NewAccessLogService(cfg.AccessLog.Enabled, cfg.AccessLog.Output, cfg.AccessLog.SampleRate)
+	if err != nil {
+		logger.Warn("Failed to init access log output, falling back to app logger", zap.Error(err))
+		accessLogSvc = service.NewAccessLogServiceWithLogger(logger.Named("access"), cfg.AccessLog.SampleRate)
+	}
+
+	followerHandler := NewFollowerHandler(followerSvc, encryptSvc, notifySvc)
+
+	go followerSvc.Run(context.Background())
+
+	router.GET("/health", followerHandler.Health)
+	router.GET("/api/v1/health", followerHandler.Health)
+
+	// API v1 - 公开配置接口 (只读)
+	v1 := router.Group("/api/v1")
+	{
+		v1.Use(middleware.QueryAccessKey(cfg.Auth.AllowQueryAccessKey, followerSvc.RejectsQueryAccessKey))
+		auth := middleware.FollowerAuth(followerSvc)
+		v1.GET("/config", middleware.AccessLog(accessLogSvc), auth, middleware.RequirePermission("read"), followerHandler.Get)
+		v1.GET("/config/watch", middleware.StreamDeadline(time.Duration(cfg.Server.Watch.Timeout)*time.Second), middleware.AccessLog(accessLogSvc), auth, middleware.RequirePermission("read"), middleware.WatchAdmission(notifySvc), followerHandler.Watch)
+		v1.GET("/config/transports", followerHandler.Transports)
+		v1.GET("/config/_ping", auth, middleware.RequirePermission("read"), followerHandler.Ping)
+		v1.GET("/bootstrap", middleware.AccessLog(accessLogSvc), auth, middleware.RequirePermission("read"), followerHandler.Bootstrap)
+		v1.GET("/configs", middleware.AccessLog(accessLogSvc), auth, middleware.RequirePermission("read"), followerHandler.List)
+		v1.PUT("/config", followerHandler.ReadOnly)
+		v1.POST("/config", followerHandler.ReadOnly)
+		v1.PATCH("/config", followerHandler.ReadOnly)
+		v1.POST("/inbound/:id", followerHandler.ReadOnly)
+	}
+}
+
+// watchShedding 监听负载保护设置
+func watchShedding(cfg *config.Config) service.WatchShedding {
+	shedding := cfg.Server.Watch.Shedding
+	return service.WatchShedding{
+		MaxWatchers: shedding.MaxWatchers,
+		NormalRate:  shedding.NormalRate,
+		BatchRate:   shedding.BatchRate,
+		BatchDelay:  time.Duration(shedding.BatchDelayMs) * time.Millisecond,
+	}
+}
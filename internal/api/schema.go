@@ -100,3 +100,242 @@ func (h *SchemaHandler) Generate(c *gin.Context) {
 		"schema": schema,
 	})
 }
+
+// Effective 获取配置生效的 Schema 及其来源 (配置自身、命名空间或项目默认)
+// GET /api/configs/:id/schema/effective
+func (h *SchemaHandler) Effective(c *gin.Context) {
+	configID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return
+	}
+
+	effective, err := h.schemaSvc.Effective(c.Request.Context(), configID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, effective)
+}
+
+// GetPolicy 获取配置的 Schema 校验策略
+// GET /api/configs/:id/schema-policy
+func (h *SchemaHandler) GetPolicy(c *gin.Context) {
+	configID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return
+	}
+
+	policy, err := h.schemaSvc.GetPolicy(c.Request.Context(), configID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdatePolicy 设置配置的 Schema 校验策略 (off/warn/block), 为空表示继承项目设置
+// PUT /api/configs/:id/schema-policy
+func (h *SchemaHandler) UpdatePolicy(c *gin.Context) {
+	configID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return
+	}
+
+	var req struct {
+		Policy string `json:"policy"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	policy, err := h.schemaSvc.UpdatePolicy(c.Request.Context(), configID, req.Policy)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// GetDefaults 获取项目的默认 Schema
+// GET /api/projects/:id/schema-defaults
+func (h *SchemaHandler) GetDefaults(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	defaults, err := h.schemaSvc.GetDefaults(c.Request.Context(), projectID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"schema_defaults": defaults,
+	})
+}
+
+// UpdateDefaults 更新项目的默认 Schema
+// PUT /api/projects/:id/schema-defaults
+func (h *SchemaHandler) UpdateDefaults(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	var defaults service.SchemaDefaults
+	if err := c.ShouldBindJSON(&defaults); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.schemaSvc.UpdateDefaults(c.Request.Context(), projectID, &defaults); err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"schema_defaults": defaults,
+	})
+}
+
+// GetProtoDescriptor 获取 protobuf 配置的描述符
+// GET /api/configs/:id/proto-descriptor
+func (h *SchemaHandler) GetProtoDescriptor(c *gin.Context) {
+	configID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return
+	}
+
+	info, err := h.schemaSvc.GetProtoDescriptor(c.Request.Context(), configID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// UpdateProtoDescriptor 上传 protobuf 配置的描述符
+// PUT /api/configs/:id/proto-descriptor
+func (h *SchemaHandler) UpdateProtoDescriptor(c *gin.Context) {
+	configID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return
+	}
+
+	var req struct {
+		DescriptorSet string `json:"descriptor_set" binding:"required"` // base64 编码的 FileDescriptorSet
+		MessageType   string `json:"message_type" binding:"required,max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	info, err := h.schemaSvc.UpdateProtoDescriptor(c.Request.Context(), configID, req.DescriptorSet, req.MessageType, getUserID(c))
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// DeleteProtoDescriptor 删除 protobuf 配置的描述符
+// DELETE /api/configs/:id/proto-descriptor
+func (h *SchemaHandler) DeleteProtoDescriptor(c *gin.Context) {
+	configID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return
+	}
+
+	if err := h.schemaSvc.DeleteProtoDescriptor(c.Request.Context(), configID); err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "描述符已删除",
+	})
+}
+
+// RenderProto 将 protobuf 配置版本渲染为 JSON, 供表单编辑器使用
+// GET /api/configs/:id/proto/json?version=3
+func (h *SchemaHandler) RenderProto(c *gin.Context) {
+	configID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return
+	}
+
+	version := 0
+	if v := c.Query("version"); v != "" {
+		if version, err = strconv.Atoi(v); err != nil || version < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "无效的版本号",
+			})
+			return
+		}
+	}
+
+	rendering, err := h.schemaSvc.RenderProto(c.Request.Context(), configID, version)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rendering)
+}
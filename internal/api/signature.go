@@ -0,0 +1,285 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"confighub/internal/middleware"
+	"confighub/internal/model"
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SignatureHandler 版本签名处理器
+type SignatureHandler struct {
+	signatureSvc *service.SignatureService
+	configSvc    *service.ConfigService
+	auditSvc     *service.AuditService
+}
+
+// NewSignatureHandler 创建版本签名处理器
+func NewSignatureHandler(signatureSvc *service.SignatureService, configSvc *service.ConfigService, auditSvc *service.AuditService) *SignatureHandler {
+	return &SignatureHandler{
+		signatureSvc: signatureSvc,
+		configSvc:    configSvc,
+		auditSvc:     auditSvc,
+	}
+}
+
+// CreateSigningKeyRequest 登记签名公钥请求
+type CreateSigningKeyRequest struct {
+	Name      string `json:"name" binding:"required,max=100"`
+	Type      string `json:"type" binding:"required,oneof=gpg sigstore"`
+	PublicKey string `json:"public_key" binding:"required"`
+	Identity  string `json:"identity" binding:"max=100"`
+}
+
+// AttachSignatureRequest 附加版本签名请求
+type AttachSignatureRequest struct {
+	Signature string `json:"signature" binding:"required"` // ASCII armor 或 base64 编码的分离签名
+}
+
+// ListKeys 获取项目登记的签名公钥
+// GET /api/projects/:id/signing-keys
+func (h *SignatureHandler) ListKeys(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	keys, err := h.signatureSvc.ListKeys(c.Request.Context(), projectID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"keys": keys,
+	})
+}
+
+// CreateKey 登记签名公钥
+// POST /api/projects/:id/signing-keys
+func (h *SignatureHandler) CreateKey(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	var req CreateSigningKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	userID := getUserID(c)
+	key := &model.SigningKey{
+		ProjectID: projectID,
+		Name:      req.Name,
+		Type:      req.Type,
+		PublicKey: req.PublicKey,
+		Identity:  req.Identity,
+		CreatedBy: userID,
+	}
+	if err := h.signatureSvc.CreateKey(c.Request.Context(), key); err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    projectID,
+		UserID:       &userID,
+		Action:       model.AuditActionCreate,
+		ResourceType: model.AuditResourceSigningKey,
+		ResourceID:   key.ID,
+		ResourceName: key.Name,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// RevokeKey 吊销签名公钥
+// POST /api/signing-keys/:id/revoke
+func (h *SignatureHandler) RevokeKey(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的签名密钥 ID",
+		})
+		return
+	}
+
+	key, err := h.signatureSvc.RevokeKey(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    key.ProjectID,
+		UserID:       &userID,
+		Action:       model.AuditActionDelete,
+		ResourceType: model.AuditResourceSigningKey,
+		ResourceID:   key.ID,
+		ResourceName: key.Name,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+
+	c.JSON(http.StatusOK, key)
+}
+
+// List 获取配置版本的签名
+// GET /api/configs/:id/versions/:version/signatures
+func (h *SignatureHandler) List(c *gin.Context) {
+	configID, versionNum, ok := parseConfigVersion(c)
+	if !ok {
+		return
+	}
+
+	signatures, err := h.signatureSvc.List(c.Request.Context(), configID, versionNum)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"signatures": signatures,
+	})
+}
+
+// Attach 为配置版本附加分离签名
+// POST /api/configs/:id/versions/:version/signatures
+func (h *SignatureHandler) Attach(c *gin.Context) {
+	configID, versionNum, ok := parseConfigVersion(c)
+	if !ok {
+		return
+	}
+
+	var req AttachSignatureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	signedBy := ""
+	if authCtx := middleware.GetAuthContext(c); authCtx != nil {
+		signedBy = authCtx.Username
+	}
+	info, err := h.signatureSvc.Attach(c.Request.Context(), configID, versionNum, req.Signature, signedBy)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    info.ProjectID,
+		UserID:       &userID,
+		Action:       model.AuditActionCreate,
+		ResourceType: model.AuditResourceConfig,
+		ResourceID:   configID,
+		ResourceName: "signature",
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+
+	c.JSON(http.StatusOK, info)
+}
+
+// AttachByAccessKey 使用 Access Key 为配置版本附加分离签名, 供 CI 在发布流程中签名
+// POST /api/v1/config/signature
+func (h *SignatureHandler) AttachByAccessKey(c *gin.Context) {
+	projectID := getProjectID(c)
+	if projectID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    "UNAUTHORIZED",
+			"message": "未授权访问",
+		})
+		return
+	}
+
+	var req struct {
+		Name      string `json:"name" binding:"required"`
+		Namespace string `json:"namespace"`
+		Env       string `json:"env"`
+		Version   int    `json:"version" binding:"required"`
+		Signature string `json:"signature" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !requireConfigAllowed(c, req.Name) {
+		return
+	}
+
+	config, _, err := h.configSvc.GetByAccessKey(c.Request.Context(), projectID, req.Name, req.Namespace, req.Env)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "NOT_FOUND",
+			"message": "配置不存在",
+		})
+		return
+	}
+
+	signedBy := "api"
+	if accessKeyID := getAccessKeyID(c); accessKeyID > 0 {
+		signedBy = "key:" + strconv.FormatInt(accessKeyID, 10)
+	}
+	info, err := h.signatureSvc.Attach(c.Request.Context(), config.ID, req.Version, req.Signature, signedBy)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// parseConfigVersion 解析路径中的配置 ID 和版本号, 无效时写入 400 响应
+func parseConfigVersion(c *gin.Context) (int64, int, bool) {
+	configID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID",
+		})
+		return 0, 0, false
+	}
+
+	versionNum, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的版本号",
+		})
+		return 0, 0, false
+	}
+	return configID, versionNum, true
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatsHandler 统计概览处理器
+type StatsHandler struct {
+	statsSvc *service.StatsService
+}
+
+// NewStatsHandler 创建统计概览处理器
+func NewStatsHandler(statsSvc *service.StatsService) *StatsHandler {
+	return &StatsHandler{statsSvc: statsSvc}
+}
+
+// Global 获取全局统计概览
+// GET /api/stats
+func (h *StatsHandler) Global(c *gin.Context) {
+	stats, err := h.statsSvc.Global(c.Request.Context())
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// Project 获取项目统计概览
+// GET /api/projects/:id/stats
+func (h *StatsHandler) Project(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	stats, err := h.statsSvc.Project(c.Request.Context(), projectID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"confighub/internal/model"
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TransferHandler 所有权转移处理器
+type TransferHandler struct {
+	transferSvc *service.TransferService
+	auditSvc    *service.AuditService
+}
+
+// NewTransferHandler 创建所有权转移处理器
+func NewTransferHandler(transferSvc *service.TransferService, auditSvc *service.AuditService) *TransferHandler {
+	return &TransferHandler{
+		transferSvc: transferSvc,
+		auditSvc:    auditSvc,
+	}
+}
+
+// Request 发起项目所有权转移或配置迁移
+// POST /api/projects/:id/transfers
+func (h *TransferHandler) Request(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	var req service.TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	transfer, err := h.transferSvc.Request(c.Request.Context(), projectID, getUserID(c), &req)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	h.audit(c, transfer, model.AuditActionTransfer)
+	c.JSON(http.StatusCreated, transfer)
+}
+
+// ListByProject 获取项目发起或接收的转移申请
+// GET /api/projects/:id/transfers
+func (h *TransferHandler) ListByProject(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	transfers, err := h.transferSvc.ListByProject(c.Request.Context(), projectID, getUserID(c))
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transfers": transfers,
+	})
+}
+
+// List 获取当前用户发起或等待当前用户确认的转移申请
+// GET /api/transfers?status=pending
+func (h *TransferHandler) List(c *gin.Context) {
+	transfers, err := h.transferSvc.ListByUser(c.Request.Context(), getUserID(c), c.Query("status"))
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transfers": transfers,
+	})
+}
+
+// Get 获取转移申请详情
+// GET /api/transfers/:id
+func (h *TransferHandler) Get(c *gin.Context) {
+	id, ok := transferID(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := h.transferSvc.Get(c.Request.Context(), id, getUserID(c))
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+// Accept 接收方确认并执行转移
+// POST /api/transfers/:id/accept
+func (h *TransferHandler) Accept(c *gin.Context) {
+	h.decide(c, h.transferSvc.Accept, model.AuditActionAccept)
+}
+
+// Reject 接收方拒绝转移
+// POST /api/transfers/:id/reject
+func (h *TransferHandler) Reject(c *gin.Context) {
+	h.decide(c, h.transferSvc.Reject, model.AuditActionReject)
+}
+
+// Cancel 发起方撤回转移申请
+// POST /api/transfers/:id/cancel
+func (h *TransferHandler) Cancel(c *gin.Context) {
+	h.decide(c, h.transferSvc.Cancel, model.AuditActionCancel)
+}
+
+// decide 处理转移申请并记录审计日志
+func (h *TransferHandler) decide(c *gin.Context, fn func(ctx context.Context, id, userID int64) (*model.OwnershipTransfer, error), action string) {
+	id, ok := transferID(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := fn(c.Request.Context(), id, getUserID(c))
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	h.audit(c, transfer, action)
+	c.JSON(http.StatusOK, transfer)
+}
+
+// audit 在原项目中记录转移的审计日志, 配置迁移同时记录到目标项目
+func (h *TransferHandler) audit(c *gin.Context, transfer *model.OwnershipTransfer, action string) {
+	userID := getUserID(c)
+	body, _ := json.Marshal(transfer)
+	projects := []int64{transfer.ProjectID}
+	if transfer.TargetProjectID != nil {
+		projects = append(projects, *transfer.TargetProjectID)
+	}
+	for _, projectID := range projects {
+		h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+			ProjectID:    projectID,
+			UserID:       &userID,
+			Action:       action,
+			ResourceType: model.AuditResourceTransfer,
+			ResourceID:   transfer.ID,
+			IPAddress:    c.ClientIP(),
+			UserAgent:    c.Request.UserAgent(),
+			RequestBody:  string(body),
+		})
+	}
+}
+
+// transferID 解析路径中的转移申请 ID, 无效时返回 400
+func transferID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的转移申请 ID",
+		})
+		return 0, false
+	}
+	return id, true
+}
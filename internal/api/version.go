@@ -11,18 +11,21 @@ import (
 
 // VersionHandler 版本处理器
 type VersionHandler struct {
-	versionSvc *service.VersionService
+	versionSvc   *service.VersionService
+	signatureSvc *service.SignatureService
 }
 
 // NewVersionHandler 创建版本处理器
-func NewVersionHandler(versionSvc *service.VersionService) *VersionHandler {
+func NewVersionHandler(versionSvc *service.VersionService, signatureSvc *service.SignatureService) *VersionHandler {
 	return &VersionHandler{
-		versionSvc: versionSvc,
+		versionSvc:   versionSvc,
+		signatureSvc: signatureSvc,
 	}
 }
 
-// List 获取版本列表
-// GET /api/configs/:id/versions
+// List 获取版本列表, 每个版本附带签名验证状态
+// GET /api/configs/:id/versions?origin=ci
+// origin 为 automation 时匹配所有自动化工具
 func (h *VersionHandler) List(c *gin.Context) {
 	configID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -33,12 +36,22 @@ func (h *VersionHandler) List(c *gin.Context) {
 		return
 	}
 
-	versions, err := h.versionSvc.List(c.Request.Context(), configID)
+	origin, err := service.ParseOriginFilter(c.Query("origin"))
 	if err != nil {
 		handleServiceError(c, err)
 		return
 	}
 
+	versions, err := h.versionSvc.List(c.Request.Context(), configID, origin)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+	if err := h.signatureSvc.Annotate(c.Request.Context(), configID, versions); err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"versions": versions,
 	})
@@ -118,6 +131,56 @@ func (h *VersionHandler) Diff(c *gin.Context) {
 	})
 }
 
+// Compare 对比任意两个配置版本 (可跨配置、跨环境)
+// GET /api/configs/compare?from_config=1&from_version=3&to_config=2&to_version=5&raw=false
+// 版本号省略时使用配置当前版本; 默认应用源配置的对比忽略规则, raw=true 时返回原始差异
+func (h *VersionHandler) Compare(c *gin.Context) {
+	from, ok := parseVersionRef(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := parseVersionRef(c, "to")
+	if !ok {
+		return
+	}
+
+	result, err := h.versionSvc.Compare(c.Request.Context(), from, to, c.Query("raw") != "true")
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// parseVersionRef 解析 <prefix>_config 和 <prefix>_version 查询参数
+func parseVersionRef(c *gin.Context, prefix string) (service.VersionRef, bool) {
+	var ref service.VersionRef
+
+	configID, err := strconv.ParseInt(c.Query(prefix+"_config"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的配置 ID: " + prefix + "_config",
+		})
+		return ref, false
+	}
+	ref.ConfigID = configID
+
+	if v := c.Query(prefix + "_version"); v != "" {
+		version, err := strconv.Atoi(v)
+		if err != nil || version < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "无效的版本号: " + prefix + "_version",
+			})
+			return ref, false
+		}
+		ref.Version = version
+	}
+	return ref, true
+}
+
 // Rollback 回滚到指定版本
 // POST /api/configs/:id/rollback/:version
 func (h *VersionHandler) Rollback(c *gin.Context) {
@@ -139,13 +202,19 @@ func (h *VersionHandler) Rollback(c *gin.Context) {
 		return
 	}
 
+	origin, err := changeOrigin(c)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
 	userID := getUserID(c)
 	author := "user"
 	if userID > 0 {
 		author = strconv.FormatInt(userID, 10)
 	}
 
-	version, err := h.versionSvc.Rollback(c.Request.Context(), configID, toVersion, author)
+	version, err := h.versionSvc.Rollback(c.Request.Context(), configID, toVersion, author, origin)
 	if err != nil {
 		handleServiceError(c, err)
 		return
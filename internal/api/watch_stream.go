@@ -0,0 +1,138 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WatchStream 以 Server-Sent Events 推送单个配置的变更, 即 SDK 协商的 sse 传输方式
+// GET /api/v1/config/watch/stream?name=xxx&namespace=xxx&env=xxx&version=xxx&content_hash=xxx&mode=notify&locale=xxx
+// 与监听接口不同, 一个连接持续推送多次变更: 配置越过 version (或继承内容与 content_hash 不同) 时推送 change 事件,
+// 内容与监听接口的变更响应相同; 配置被删除时推送 deleted 事件, 访问权限被撤销时推送 revoked 事件, 之后结束
+func (h *PublicConfigHandler) WatchStream(c *gin.Context) {
+	projectID := getProjectID(c)
+	if projectID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    "UNAUTHORIZED",
+			"message": "未授权访问",
+		})
+		return
+	}
+
+	configName := c.Query("name")
+	if configName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "配置名称不能为空",
+		})
+		return
+	}
+
+	if !requireConfigAllowed(c, configName) {
+		return
+	}
+
+	namespace, env := service.ResolveNamespaceEnv(c.Query("namespace"), c.Query("env"))
+	notifyOnly := c.Query("mode") == "notify"
+	locales, ok := h.localeChain(c, projectID)
+	if !ok {
+		return
+	}
+
+	currentVersion := 0
+	if v := c.Query("version"); v != "" {
+		currentVersion, _ = strconv.Atoi(v)
+	}
+	hash := c.Query("content_hash")
+
+	entry, err := h.hotCache.Get(c.Request.Context(), projectID, configName, namespace, env)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "NOT_FOUND",
+			"message": "配置不存在",
+		})
+		return
+	}
+	config := entry.Config
+
+	clientID := uuid.New().String()
+	subscriber := watchSubscriber(c, projectID)
+	sub, err := h.notifySvc.Subscribe(c.Request.Context(), clientID, subscriber, []int64{config.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    "INTERNAL_ERROR",
+			"message": "订阅失败",
+		})
+		return
+	}
+	defer h.notifySvc.Unsubscribe(c.Request.Context(), clientID)
+
+	// 变更流为长连接, 不受服务端写超时限制
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+
+	// 订阅后再检查一次, 连接前已有的变更立即推送
+	if latest, err := h.hotCache.Get(c.Request.Context(), projectID, configName, namespace, env); err == nil && latest.Version != nil && (latest.Version.Version > currentVersion || inheritedChanged(latest, hash)) {
+		writeSSE(c, 0, "change", h.changed(c, latest, notifyOnly, locales))
+		currentVersion = latest.Version.Version
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-sub.Revoked:
+			writeSSE(c, 0, "revoked", gin.H{"code": "ACCESS_REVOKED", "message": "访问权限已被撤销"})
+			c.Writer.Flush()
+			return
+		case change, ok := <-sub.Changes:
+			if !ok {
+				return
+			}
+			if change == nil || change.ConfigID != config.ID {
+				continue
+			}
+			if change.ChangeType == "delete" {
+				writeSSE(c, 0, "deleted", deletedPayload(config))
+				c.Writer.Flush()
+				return
+			}
+			latest, err := h.hotCache.Get(c.Request.Context(), projectID, configName, namespace, env)
+			if errors.Is(err, service.ErrConfigNotFound) {
+				writeSSE(c, 0, "deleted", deletedPayload(config))
+				c.Writer.Flush()
+				return
+			}
+			if err != nil || latest.Version == nil {
+				continue
+			}
+			// 父配置变更时版本号不变, 合并后的内容已变化
+			if latest.Version.Version > currentVersion || change.ChangeType == "inherit" {
+				writeSSE(c, 0, "change", h.changed(c, latest, notifyOnly, locales))
+				currentVersion = latest.Version.Version
+				c.Writer.Flush()
+			}
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WatchTokenHandler 监听令牌处理器
+type WatchTokenHandler struct {
+	watchTokenSvc *service.WatchTokenService
+}
+
+// NewWatchTokenHandler 创建监听令牌处理器
+func NewWatchTokenHandler(watchTokenSvc *service.WatchTokenService) *WatchTokenHandler {
+	return &WatchTokenHandler{
+		watchTokenSvc: watchTokenSvc,
+	}
+}
+
+// Issue 签发监听令牌 (需使用 Access Key 认证)
+// POST /api/v1/config/watch-token
+func (h *WatchTokenHandler) Issue(c *gin.Context) {
+	accessKeyID := getAccessKeyID(c)
+	if accessKeyID == 0 {
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":    "FORBIDDEN",
+			"message": "监听令牌仅支持 Access Key 签发",
+		})
+		return
+	}
+
+	token, expiresAt, err := h.watchTokenSvc.Issue(accessKeyID, getProjectID(c))
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":      token,
+		"expires_at": expiresAt,
+	})
+}
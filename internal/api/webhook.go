@@ -0,0 +1,381 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"confighub/internal/model"
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler 出站 Webhook 处理器
+type WebhookHandler struct {
+	webhookSvc *service.WebhookService
+	auditSvc   *service.AuditService
+}
+
+// NewWebhookHandler 创建 Webhook 处理器
+func NewWebhookHandler(webhookSvc *service.WebhookService, auditSvc *service.AuditService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookSvc: webhookSvc,
+		auditSvc:   auditSvc,
+	}
+}
+
+// Create 创建 Webhook
+// POST /api/projects/:id/webhooks
+func (h *WebhookHandler) Create(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	var req service.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	userID := getUserID(c)
+	webhook, secret, err := h.webhookSvc.Create(c.Request.Context(), projectID, &req, userID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    projectID,
+		UserID:       &userID,
+		Action:       model.AuditActionCreate,
+		ResourceType: model.AuditResourceWebhook,
+		ResourceID:   webhook.ID,
+		ResourceName: webhook.Name,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+
+	response := gin.H{
+		"webhook": webhook,
+	}
+	if secret != "" {
+		response["project_secret"] = secret // 新生成的项目签名密钥, 仅此一次返回
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// List 获取项目下的 Webhook
+// GET /api/projects/:id/webhooks
+func (h *WebhookHandler) List(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	webhooks, err := h.webhookSvc.List(c.Request.Context(), projectID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": webhooks,
+		"events":   service.WebhookEvents,
+	})
+}
+
+// RotateSecret 重新生成项目的 Webhook 签名密钥
+// POST /api/projects/:id/webhooks/rotate-secret
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的项目 ID",
+		})
+		return
+	}
+
+	secret, err := h.webhookSvc.RotateSecret(c.Request.Context(), projectID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    projectID,
+		UserID:       &userID,
+		Action:       model.AuditActionUpdate,
+		ResourceType: model.AuditResourceWebhook,
+		ResourceName: "project_secret",
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"project_secret": secret,
+	})
+}
+
+// Update 更新 Webhook
+// PUT /api/webhooks/:id
+func (h *WebhookHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的 Webhook ID",
+		})
+		return
+	}
+
+	var req service.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "请求参数无效",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	webhook, err := h.webhookSvc.Update(c.Request.Context(), id, &req)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    webhook.ProjectID,
+		UserID:       &userID,
+		Action:       model.AuditActionUpdate,
+		ResourceType: model.AuditResourceWebhook,
+		ResourceID:   webhook.ID,
+		ResourceName: webhook.Name,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhook": webhook,
+	})
+}
+
+// Delete 删除 Webhook
+// DELETE /api/webhooks/:id
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的 Webhook ID",
+		})
+		return
+	}
+
+	webhook, err := h.webhookSvc.Get(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+	if err := h.webhookSvc.Delete(c.Request.Context(), id); err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    webhook.ProjectID,
+		UserID:       &userID,
+		Action:       model.AuditActionDelete,
+		ResourceType: model.AuditResourceWebhook,
+		ResourceID:   id,
+		ResourceName: webhook.Name,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "删除成功",
+	})
+}
+
+// Deliveries 获取 Webhook 最近的投递记录, 包含请求体、请求头和响应体
+// GET /api/webhooks/:id/deliveries?status=failed&limit=50
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的 Webhook ID",
+		})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	deliveries, err := h.webhookSvc.Deliveries(c.Request.Context(), id, c.Query("status"), limit)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+	})
+}
+
+// GetDelivery 获取单条投递记录
+// GET /api/webhooks/:id/deliveries/:delivery
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	id, deliveryID, ok := parseDeliveryParams(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookSvc.GetDelivery(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"delivery": delivery,
+	})
+}
+
+// Test 立即发送一次测试事件 (ping), 返回请求和响应详情
+// POST /api/webhooks/:id/test
+func (h *WebhookHandler) Test(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的 Webhook ID",
+		})
+		return
+	}
+
+	delivery, err := h.webhookSvc.Test(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"delivery": delivery,
+		"success":  delivery.Status == model.WebhookDeliverySuccess,
+	})
+}
+
+// Replay 重新投递一条已结束的投递记录
+// POST /api/webhooks/:id/deliveries/:delivery/replay
+func (h *WebhookHandler) Replay(c *gin.Context) {
+	id, deliveryID, ok := parseDeliveryParams(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookSvc.Replay(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	userID := getUserID(c)
+	h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+		ProjectID:    delivery.ProjectID,
+		UserID:       &userID,
+		Action:       model.AuditActionReplay,
+		ResourceType: model.AuditResourceWebhook,
+		ResourceID:   id,
+		ResourceName: "delivery:" + strconv.FormatInt(deliveryID, 10),
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"delivery": delivery,
+		"success":  delivery.Status == model.WebhookDeliverySuccess,
+	})
+}
+
+// ReplayFailed 将尚未重放过的失败投递重新加入投递队列
+// POST /api/webhooks/:id/deliveries/replay-failed?dry_run=true
+func (h *WebhookHandler) ReplayFailed(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的 Webhook ID",
+		})
+		return
+	}
+
+	webhook, err := h.webhookSvc.Get(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+	dryRun := isDryRun(c)
+	replayed, err := h.webhookSvc.ReplayFailed(c.Request.Context(), id, dryRun)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	if len(replayed) > 0 && !dryRun {
+		userID := getUserID(c)
+		h.auditSvc.Log(c.Request.Context(), &model.AuditLog{
+			ProjectID:    webhook.ProjectID,
+			UserID:       &userID,
+			Action:       model.AuditActionReplay,
+			ResourceType: model.AuditResourceWebhook,
+			ResourceID:   id,
+			ResourceName: webhook.Name,
+			IPAddress:    c.ClientIP(),
+			UserAgent:    c.Request.UserAgent(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dry_run":      dryRun,
+		"queued":       len(replayed),
+		"delivery_ids": replayed,
+	})
+}
+
+// parseDeliveryParams 解析路由参数 :id (Webhook ID) 和 :delivery, 无效时直接写入响应
+func parseDeliveryParams(c *gin.Context) (int64, int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的 Webhook ID",
+		})
+		return 0, 0, false
+	}
+	deliveryID, err := strconv.ParseInt(c.Param("delivery"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": "无效的投递记录 ID",
+		})
+		return 0, 0, false
+	}
+	return id, deliveryID, true
+}
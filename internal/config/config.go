@@ -10,22 +10,88 @@ import (
 
 // Config 应用配置
 type Config struct {
-	Env      string         `mapstructure:"env"`
-	LogLevel string         `mapstructure:"log_level"`
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	Encrypt  EncryptConfig  `mapstructure:"encrypt"`
+	Env         string            `mapstructure:"env"`
+	LogLevel    string            `mapstructure:"log_level"`
+	Server      ServerConfig      `mapstructure:"server"`
+	Database    DatabaseConfig    `mapstructure:"database"`
+	Redis       RedisConfig       `mapstructure:"redis"`
+	JWT         JWTConfig         `mapstructure:"jwt"`
+	Auth        AuthConfig        `mapstructure:"auth"`
+	Encrypt     EncryptConfig     `mapstructure:"encrypt"`
+	AccessLog   AccessLogConfig   `mapstructure:"access_log"`
+	Project     ProjectConfig     `mapstructure:"project"`
+	Replication ReplicationConfig `mapstructure:"replication"`
+	Cache       CacheConfig       `mapstructure:"cache"`
+	Metrics     MetricsConfig     `mapstructure:"metrics"`
+	Git         GitConfig         `mapstructure:"git"`
+	Storage     StorageConfig     `mapstructure:"storage"`
+	Resilience  ResilienceConfig  `mapstructure:"resilience"`
+	SelfCheck   SelfCheckConfig   `mapstructure:"selfcheck"`
+	Tracing     TracingConfig     `mapstructure:"tracing"`
+	Offline     OfflineConfig     `mapstructure:"offline_bundle"`
+	Chaos       ChaosConfig       `mapstructure:"chaos"`
+	Retention   RetentionConfig   `mapstructure:"retention"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Addr         string `mapstructure:"addr"`
-	ReadTimeout  int    `mapstructure:"read_timeout"`
-	WriteTimeout int    `mapstructure:"write_timeout"`
+	Addr              string            `mapstructure:"addr"`
+	ReadTimeout       int               `mapstructure:"read_timeout"`
+	WriteTimeout      int               `mapstructure:"write_timeout"`
+	ReadHeaderTimeout int               `mapstructure:"read_header_timeout"`
+	IdleTimeout       int               `mapstructure:"idle_timeout"`  // keep-alive 空闲连接保持时间 (秒)
+	MaxConns          int               `mapstructure:"max_conns"`     // 最大并发连接数, 0 表示不限制
+	HTTP2             bool              `mapstructure:"http2"`         // 明文 HTTP/2 (h2c), TLS 由前置代理终止时使用
+	GzipMinSize       int               `mapstructure:"gzip_min_size"` // 响应体达到该字节数时以 gzip 压缩, 0 表示不压缩
+	Role              string            `mapstructure:"role"`          // standalone, follower
+	TLS               TLSConfig         `mapstructure:"tls"`           // 主监听地址 (及独立的监听接口地址) 的 TLS
+	Watch             WatchServerConfig `mapstructure:"watch"`
+	Management        ManagementConfig  `mapstructure:"management"`
 }
 
+// TLSConfig 监听地址的 TLS 设置, 未设置证书时使用明文 HTTP
+type TLSConfig struct {
+	CertFile     string `mapstructure:"cert_file"`
+	KeyFile      string `mapstructure:"key_file"`
+	ClientCAFile string `mapstructure:"client_ca_file"` // 设置后要求客户端证书 (mTLS)
+}
+
+// Enabled 是否启用 TLS
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+// ManagementConfig 管理面 (/api 管理接口、/metrics) 的独立监听设置
+// 设置 addr 后主监听地址只提供公开配置接口 (/api/v1, 数据面), 管理面可单独限制在内网
+type ManagementConfig struct {
+	Addr         string    `mapstructure:"addr"`          // 管理面监听地址, 为空时与数据面共用 server.addr
+	TLS          TLSConfig `mapstructure:"tls"`           // 管理面的 TLS, 与数据面相互独立
+	AllowedCIDRs []string  `mapstructure:"allowed_cidrs"` // 允许访问管理面的来源网段, 为空表示不限制
+}
+
+// WatchServerConfig 监听接口 (长轮询、SSE) 的连接设置
+// 全局读写超时会在长轮询返回前断开连接, 监听接口按请求放宽超时, 也可使用独立的监听地址
+type WatchServerConfig struct {
+	Addr        string `mapstructure:"addr"`         // 独立监听地址, 仅提供监听接口; 为空时与主服务共用
+	Timeout     int    `mapstructure:"timeout"`      // 长轮询请求的读写超时 (秒), 需大于长轮询最长时间 60 秒
+	IdleTimeout int    `mapstructure:"idle_timeout"` // 独立监听地址的空闲连接保持时间 (秒)
+	MaxConns    int    `mapstructure:"max_conns"`    // 独立监听地址的最大并发连接数, 0 表示不限制
+
+	Shedding WatchSheddingConfig `mapstructure:"shedding"`
+}
+
+// WatchSheddingConfig 监听负载保护: 监听连接数达到阈值后按密钥的监听优先级限流新连接并延后投递 batch 密钥的变更
+// high 密钥不受限流和延后影响, 保障关键服务的变更延迟
+type WatchSheddingConfig struct {
+	MaxWatchers  int     `mapstructure:"max_watchers"`   // 触发负载保护的监听连接数, 0 表示不启用
+	NormalRate   float64 `mapstructure:"normal_rate"`    // 负载保护期间 normal 密钥每秒允许的新监听请求数
+	BatchRate    float64 `mapstructure:"batch_rate"`     // 负载保护期间 batch 密钥每秒允许的新监听请求数
+	BatchDelayMs int     `mapstructure:"batch_delay_ms"` // 负载保护期间 batch 密钥的变更延后合并投递的间隔 (毫秒)
+}
+
+// ServerRoleFollower 只读跟随节点: 不连接数据库, 从 replication.peer_url 拉取快照并仅提供公开读取和监听接口
+const ServerRoleFollower = "follower"
+
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
 	Driver          string `mapstructure:"driver"` // mysql or postgres
@@ -33,6 +99,7 @@ type DatabaseConfig struct {
 	MaxIdleConns    int    `mapstructure:"max_idle_conns"`
 	MaxOpenConns    int    `mapstructure:"max_open_conns"`
 	ConnMaxLifetime int    `mapstructure:"conn_max_lifetime"`
+	MigrationGate   bool   `mapstructure:"migration_gate"` // 迁移版本与服务不一致时拒绝写入
 }
 
 // RedisConfig Redis 配置
@@ -49,11 +116,153 @@ type JWTConfig struct {
 	ExpireHour int    `mapstructure:"expire_hour"`
 }
 
+// AuthConfig 客户端及登录认证配置
+type AuthConfig struct {
+	AllowQueryAccessKey bool       `mapstructure:"allow_query_access_key"` // 兼容旧客户端: 允许在查询参数中传递 Access Key (已弃用)
+	AdminUserIDs        []int64    `mapstructure:"admin_user_ids"`         // 可调用 /api/admin 运维接口的用户 ID, 为空时任何用户都不能调用
+	SignedBodyMaxBytes  int64      `mapstructure:"signed_body_max_bytes"`  // v2 签名校验时读取的请求体上限, 超出返回 413
+	LDAP                LDAPConfig `mapstructure:"ldap"`
+}
+
+// LDAPConfig LDAP 登录配置
+// 启用后登录先在目录中查找用户并以其 DN 绑定校验密码, 首次登录时创建本地用户, 用户名和邮箱按属性映射同步
+type LDAPConfig struct {
+	Enabled            bool            `mapstructure:"enabled"`
+	URL                string          `mapstructure:"url"`                  // ldap://host:389 或 ldaps://host:636
+	StartTLS           bool            `mapstructure:"start_tls"`            // ldap:// 连接后升级为 TLS
+	InsecureSkipVerify bool            `mapstructure:"insecure_skip_verify"` // 不校验服务端证书, 仅用于测试
+	TimeoutSeconds     int             `mapstructure:"timeout_seconds"`
+	BindDN             string          `mapstructure:"bind_dn"`       // 查找用户的服务账号, 为空时匿名查找
+	BindPassword       string          `mapstructure:"bind_password"` // 服务账号密码
+	BaseDN             string          `mapstructure:"base_dn"`       // 用户搜索起点
+	UserFilter         string          `mapstructure:"user_filter"`   // 用户过滤器, %s 替换为转义后的登录名
+	UsernameAttr       string          `mapstructure:"username_attr"` // 映射为本地用户名的属性
+	EmailAttr          string          `mapstructure:"email_attr"`    // 映射为邮箱的属性
+	GroupAttr          string          `mapstructure:"group_attr"`    // 用户条目上列出所属组 DN 的属性, 如 memberOf
+	GroupBaseDN        string          `mapstructure:"group_base_dn"` // 设置后另外搜索用户所属的组
+	GroupFilter        string          `mapstructure:"group_filter"`  // 组过滤器, %s 替换为转义后的用户 DN
+	GroupRoles         []LDAPGroupRole `mapstructure:"group_roles"`   // 组到项目角色的映射, 每次登录时同步
+	AllowLocal         bool            `mapstructure:"allow_local"`   // 目录中没有该用户或 LDAP 不可用时允许本地账号登录
+}
+
+// LDAPGroupRole LDAP 组到项目成员角色的映射
+type LDAPGroupRole struct {
+	Group   string `mapstructure:"group"`   // 组 DN
+	Project string `mapstructure:"project"` // 项目名称
+	Role    string `mapstructure:"role"`    // viewer, developer, releaser, admin
+}
+
 // EncryptConfig 加密配置
 type EncryptConfig struct {
 	Key string `mapstructure:"key"` // AES-256 密钥 (32 bytes)
 }
 
+// AccessLogConfig 访问日志配置 (公开读取/监听接口, 不写入数据库)
+type AccessLogConfig struct {
+	Enabled    bool    `mapstructure:"enabled"`
+	Output     string  `mapstructure:"output"`      // stdout, stderr 或文件路径
+	SampleRate float64 `mapstructure:"sample_rate"` // 成功请求采样率 (0-1), 失败请求始终记录
+}
+
+// ProjectConfig 项目生命周期配置
+type ProjectConfig struct {
+	DeleteGraceHours int    `mapstructure:"delete_grace_hours"` // 归档后需等待的小时数才允许彻底删除
+	TemplateDir      string `mapstructure:"template_dir"`       // 项目初始化模板目录 (*.yaml, *.yml, *.json), 为空时不启用
+}
+
+// ReplicationConfig 跨实例复制配置 (多区域只读副本 / 容灾)
+type ReplicationConfig struct {
+	Enabled         bool     `mapstructure:"enabled"`
+	Mode            string   `mapstructure:"mode"`             // push: 推送到 peer_url; pull: 从 peer_url 拉取; receive: 只接收推送
+	PeerURL         string   `mapstructure:"peer_url"`         // 对端 ConfigHub 地址
+	Token           string   `mapstructure:"token"`            // 两端共享的复制令牌, 为空时复制接口不可用
+	Projects        []string `mapstructure:"projects"`         // 复制的项目名称
+	IntervalSeconds int      `mapstructure:"interval_seconds"` // 同步间隔
+	ConflictPolicy  string   `mapstructure:"conflict_policy"`  // source_wins, target_wins, newer_wins
+}
+
+// MetricsConfig Prometheus 指标接口 (/metrics) 的访问控制和配置指标的刷新间隔
+// 令牌和来源网段都未配置时指标接口拒绝访问
+type MetricsConfig struct {
+	Token          string   `mapstructure:"token"`           // 抓取时携带 Authorization: Bearer <token>
+	AllowedCIDRs   []string `mapstructure:"allowed_cidrs"`   // 允许抓取的来源网段, 与令牌同时配置时两者都需满足
+	RefreshSeconds int      `mapstructure:"refresh_seconds"` // 配置大小与复杂度指标的重新计算间隔
+}
+
+// CacheConfig 公开读取路径的缓存配置: 进程内热点缓存和多实例共享的 Redis 读取缓存
+type CacheConfig struct {
+	HotSize         int `mapstructure:"hot_size"`          // 缓存的配置数量上限, 0 表示禁用
+	WarmupSize      int `mapstructure:"warmup_size"`       // 启动时预热的最近发布配置数量
+	RedisTTLSeconds int `mapstructure:"redis_ttl_seconds"` // Redis 读取缓存的条目有效期, 0 表示禁用
+}
+
+// GitConfig 项目 Git 仓库同步配置
+type GitConfig struct {
+	WorkDir         string `mapstructure:"work_dir"`         // 本地工作副本目录
+	AuthorName      string `mapstructure:"author_name"`      // 提交者名称
+	AuthorEmail     string `mapstructure:"author_email"`     // 提交者邮箱
+	IntervalSeconds int    `mapstructure:"interval_seconds"` // 定期导出间隔
+}
+
+// 版本存储方式
+const (
+	VersionStorageDB  = "db"  // 版本内容保存在数据库 (默认)
+	VersionStorageGit = "git" // 版本内容保存在 Git 仓库, 每个配置一个文件, 每个版本一次提交
+)
+
+// StorageConfig 存储配置
+type StorageConfig struct {
+	Versions string           `mapstructure:"versions"` // db, git
+	Git      GitStorageConfig `mapstructure:"git"`
+}
+
+// GitStorageConfig Git 版本存储配置, 提交者使用 git.author_name / git.author_email
+type GitStorageConfig struct {
+	Dir    string `mapstructure:"dir"`    // 仓库工作目录
+	Remote string `mapstructure:"remote"` // 远端地址 (可选), 每次提交后推送
+	Branch string `mapstructure:"branch"`
+}
+
+// ResilienceConfig 数据库故障降级配置
+type ResilienceConfig struct {
+	Enabled              bool `mapstructure:"enabled"`
+	CheckIntervalSeconds int  `mapstructure:"check_interval_seconds"` // 数据库探测间隔
+	FailureThreshold     int  `mapstructure:"failure_threshold"`      // 连续探测失败多少次后进入降级
+	AuthTTLSeconds       int  `mapstructure:"auth_ttl_seconds"`       // 降级认证记录的有效期, 从调用方最近一次正常认证起算
+	AuthMaxEntries       int  `mapstructure:"auth_max_entries"`       // 降级认证记录的最大条数
+}
+
+// TracingConfig OpenTelemetry 链路追踪配置
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	ServiceName string  `mapstructure:"service_name"`
+	Endpoint    string  `mapstructure:"endpoint"`     // OTLP/HTTP Collector 地址, 如 otel-collector:4318
+	Insecure    bool    `mapstructure:"insecure"`     // 以 HTTP 连接 Collector
+	SampleRatio float64 `mapstructure:"sample_ratio"` // 客户端未携带采样决定时的采样比例
+}
+
+// OfflineConfig 离线配置包配置 (边缘设备 / 隔离网络)
+type OfflineConfig struct {
+	SigningKey      string `mapstructure:"signing_key"`       // Ed25519 私钥种子 (32 字节, base64), 为空时由 JWT 密钥派生
+	DefaultTTLHours int    `mapstructure:"default_ttl_hours"` // 未指定有效期时的默认值
+	MaxTTLHours     int    `mapstructure:"max_ttl_hours"`     // 允许的最长有效期
+}
+
+// SelfCheckConfig 启动自检配置
+type SelfCheckConfig struct {
+	RefuseInsecure bool `mapstructure:"refuse_insecure"` // 生产环境存在不安全配置 (如默认密钥) 时拒绝启动
+}
+
+// ChaosConfig 监听链路故障注入配置, 仅用于测试环境
+type ChaosConfig struct {
+	Enabled bool `mapstructure:"enabled"` // 开启后注册 /api/admin/chaos 接口
+}
+
+// RetentionConfig 配置版本清理任务配置, 保留策略按项目设置
+type RetentionConfig struct {
+	IntervalMinutes int `mapstructure:"interval_minutes"` // 后台清理间隔, 0 表示只通过管理接口手动清理
+}
+
 // Load 加载配置
 func Load() (*Config, error) {
 	viper.SetConfigName("config")
@@ -175,6 +384,11 @@ func overrideFromEnv() {
 		viper.Set("encrypt.key", encryptKey)
 	}
 
+	// LDAP 服务账号密码
+	if ldapPassword := os.Getenv("LDAP_BIND_PASSWORD"); ldapPassword != "" {
+		viper.Set("auth.ldap.bind_password", ldapPassword)
+	}
+
 	// 服务器端口 (支持 PORT 环境变量 - 云平台标准)
 	if port := os.Getenv("PORT"); port != "" {
 		viper.Set("server.addr", ":"+port)
@@ -198,12 +412,26 @@ func setDefaults() {
 	viper.SetDefault("server.addr", ":8080")
 	viper.SetDefault("server.read_timeout", 30)
 	viper.SetDefault("server.write_timeout", 30)
+	viper.SetDefault("server.read_header_timeout", 10)
+	viper.SetDefault("server.idle_timeout", 120)
+	viper.SetDefault("server.max_conns", 0)
+	viper.SetDefault("server.http2", false)
+	viper.SetDefault("server.gzip_min_size", 1024)
+	viper.SetDefault("server.watch.timeout", 90)
+	viper.SetDefault("server.watch.idle_timeout", 300)
+	viper.SetDefault("server.watch.max_conns", 0)
+	viper.SetDefault("server.watch.shedding.max_watchers", 0)
+	viper.SetDefault("server.watch.shedding.normal_rate", 100)
+	viper.SetDefault("server.watch.shedding.batch_rate", 10)
+	viper.SetDefault("server.watch.shedding.batch_delay_ms", 2000)
+	viper.SetDefault("server.role", "standalone")
 
 	viper.SetDefault("database.driver", "mysql")
 	viper.SetDefault("database.dsn", "root:password@tcp(localhost:3306)/confighub?charset=utf8mb4&parseTime=True&loc=Local")
 	viper.SetDefault("database.max_idle_conns", 10)
 	viper.SetDefault("database.max_open_conns", 100)
 	viper.SetDefault("database.conn_max_lifetime", 3600)
+	viper.SetDefault("database.migration_gate", true)
 
 	viper.SetDefault("redis.addr", "localhost:6379")
 	viper.SetDefault("redis.password", "")
@@ -212,5 +440,61 @@ func setDefaults() {
 	viper.SetDefault("jwt.secret", "confighub-jwt-secret-key-change-in-production")
 	viper.SetDefault("jwt.expire_hour", 24)
 
+	viper.SetDefault("auth.allow_query_access_key", true)
+	viper.SetDefault("auth.signed_body_max_bytes", 10<<20)
+	viper.SetDefault("auth.ldap.enabled", false)
+	viper.SetDefault("auth.ldap.timeout_seconds", 10)
+	viper.SetDefault("auth.ldap.user_filter", "(uid=%s)")
+	viper.SetDefault("auth.ldap.username_attr", "uid")
+	viper.SetDefault("auth.ldap.email_attr", "mail")
+	viper.SetDefault("auth.ldap.group_attr", "memberOf")
+	viper.SetDefault("auth.ldap.group_filter", "(member=%s)")
+	viper.SetDefault("auth.ldap.allow_local", true)
+
 	viper.SetDefault("encrypt.key", "confighub-encrypt-key-32bytes!")
+
+	viper.SetDefault("access_log.enabled", true)
+	viper.SetDefault("access_log.output", "stdout")
+	viper.SetDefault("access_log.sample_rate", 0.1)
+
+	viper.SetDefault("project.delete_grace_hours", 168)
+
+	viper.SetDefault("cache.hot_size", 1000)
+	viper.SetDefault("cache.warmup_size", 200)
+	viper.SetDefault("cache.redis_ttl_seconds", 300)
+
+	viper.SetDefault("metrics.refresh_seconds", 60)
+
+	viper.SetDefault("replication.enabled", false)
+	viper.SetDefault("replication.mode", "pull")
+	viper.SetDefault("replication.interval_seconds", 60)
+	viper.SetDefault("replication.conflict_policy", "source_wins")
+
+	viper.SetDefault("git.work_dir", "./data/git")
+	viper.SetDefault("git.author_name", "ConfigHub")
+	viper.SetDefault("git.author_email", "confighub@localhost")
+	viper.SetDefault("git.interval_seconds", 60)
+
+	viper.SetDefault("storage.versions", "db")
+	viper.SetDefault("storage.git.dir", "./data/versions")
+	viper.SetDefault("storage.git.branch", "main")
+
+	viper.SetDefault("resilience.enabled", true)
+	viper.SetDefault("resilience.check_interval_seconds", 5)
+	viper.SetDefault("resilience.failure_threshold", 2)
+	viper.SetDefault("resilience.auth_ttl_seconds", 3600)
+	viper.SetDefault("resilience.auth_max_entries", 10000)
+
+	viper.SetDefault("selfcheck.refuse_insecure", true)
+
+	viper.SetDefault("tracing.enabled", false)
+	viper.SetDefault("tracing.service_name", "confighub")
+	viper.SetDefault("tracing.sample_ratio", 0.1)
+
+	viper.SetDefault("offline_bundle.default_ttl_hours", 168)
+	viper.SetDefault("offline_bundle.max_ttl_hours", 720)
+
+	viper.SetDefault("chaos.enabled", false)
+
+	viper.SetDefault("retention.interval_minutes", 60)
 }
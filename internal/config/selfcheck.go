@@ -0,0 +1,193 @@
+package config
+
+import (
+	"encoding/base64"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// 自检结果级别
+const (
+	SeverityOK    = "ok"
+	SeverityWarn  = "warn"
+	SeverityError = "error" // 不安全的默认值等, 生产环境下拒绝启动
+)
+
+// 内置默认值及部署示例 (docker-compose.yml, deploy/k8s) 中的占位值, 生产环境必须替换
+var (
+	defaultJWTSecrets       = []string{"confighub-jwt-secret-key-change-in-production", "your-jwt-secret-change-in-production"}
+	defaultEncryptKeys      = []string{"confighub-encrypt-key-32bytes!", "your-32-byte-encryption-key-here"}
+	defaultReplicationToken = "change-me-shared-secret"
+)
+
+// minEncryptKeyEntropy 加密密钥的最低估算熵 (比特)
+const minEncryptKeyEntropy = 96
+
+// maxLongPollSeconds 监听接口长轮询的最长时间 (秒)
+const maxLongPollSeconds = 60
+
+// Finding 一项自检结果
+type Finding struct {
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Hint     string `json:"hint,omitempty"`
+}
+
+// CheckSettings 检查关键配置项, 不访问外部依赖
+func (c *Config) CheckSettings() []Finding {
+	var findings []Finding
+	add := func(check, severity, message, hint string) {
+		findings = append(findings, Finding{Check: check, Severity: severity, Message: message, Hint: hint})
+	}
+
+	switch {
+	case c.JWT.Secret == "" || contains(defaultJWTSecrets, c.JWT.Secret):
+		add("jwt_secret", SeverityError, "JWT 密钥为空或使用默认值, 任何人都可以签发登录令牌", "通过 jwt.secret 或 JWT_SECRET 设置至少 32 个字符的随机值")
+	case len(c.JWT.Secret) < 32:
+		add("jwt_secret", SeverityWarn, "JWT 密钥短于 32 个字符", "使用至少 32 个字符的随机值")
+	default:
+		add("jwt_secret", SeverityOK, "JWT 密钥已设置", "")
+	}
+
+	entropy := estimateEntropy(c.Encrypt.Key)
+	switch {
+	case c.Encrypt.Key == "" || contains(defaultEncryptKeys, c.Encrypt.Key):
+		add("encrypt_key", SeverityError, "加密密钥为空或使用默认值, 加密字段可被直接解密", "通过 encrypt.key 或 ENCRYPT_KEY 设置 32 字节的随机值")
+	case entropy < minEncryptKeyEntropy:
+		add("encrypt_key", SeverityError, "加密密钥熵过低 (约 "+strconv.Itoa(int(entropy))+" 比特), 容易被猜测", "使用 32 字节的随机值, 如 openssl rand -base64 24")
+	case len(c.Encrypt.Key) != 32:
+		add("encrypt_key", SeverityWarn, "加密密钥长度不是 32 字节, 将被补齐或截断", "使用恰好 32 字节的密钥")
+	default:
+		add("encrypt_key", SeverityOK, "加密密钥已设置 (估算熵约 "+strconv.Itoa(int(entropy))+" 比特)", "")
+	}
+
+	if strings.Contains(c.Database.DSN, "root:password@") {
+		add("database_dsn", SeverityWarn, "数据库连接使用默认的 root/password 账号", "为 ConfigHub 创建专用账号并设置强密码")
+	}
+
+	remoteRedis := c.Redis.Addr != "" && !isLocalAddr(c.Redis.Addr)
+	switch {
+	case c.Redis.Addr == "":
+		add("redis", SeverityWarn, "未配置 Redis, 多实例部署时变更通知无法跨实例传播", "设置 redis.addr")
+	case remoteRedis && c.Redis.Password == "" && !strings.Contains(c.Redis.Addr, "@"):
+		add("redis", SeverityWarn, "远程 Redis 未设置密码", "设置 redis.password 或 REDIS_PASSWORD")
+	case remoteRedis && !c.Redis.TLS && !strings.HasPrefix(c.Redis.Addr, "rediss://"):
+		add("redis", SeverityWarn, "远程 Redis 未启用 TLS", "设置 redis.tls: true 或 REDIS_TLS=true")
+	default:
+		add("redis", SeverityOK, "Redis 配置为 "+redisMode(c.Redis), "")
+	}
+
+	if c.Auth.AllowQueryAccessKey {
+		add("query_access_key", SeverityWarn, "允许在查询参数中传递 Access Key, 凭据可能出现在代理和访问日志中", "客户端改用 X-Access-Key 请求头后设置 auth.allow_query_access_key: false")
+	}
+
+	if ldap := c.Auth.LDAP; ldap.Enabled {
+		switch {
+		case ldap.URL == "" || ldap.BaseDN == "":
+			add("ldap", SeverityError, "已开启 LDAP 登录但未设置 url 或 base_dn", "设置 auth.ldap.url 和 auth.ldap.base_dn")
+		case strings.HasPrefix(ldap.URL, "ldap://") && !ldap.StartTLS && !isLocalAddr(ldap.URL):
+			add("ldap", SeverityWarn, "LDAP 连接未加密, 用户密码以明文传输", "使用 ldaps:// 地址或设置 auth.ldap.start_tls: true")
+		case ldap.InsecureSkipVerify:
+			add("ldap", SeverityWarn, "LDAP 连接不校验服务端证书", "仅在测试环境设置 auth.ldap.insecure_skip_verify: true")
+		default:
+			add("ldap", SeverityOK, "LDAP 登录已开启", "")
+		}
+	}
+
+	if c.Offline.SigningKey == "" {
+		add("offline_signing_key", SeverityWarn, "离线配置包签名密钥由 JWT 密钥派生, 更换 JWT 密钥后设备需重新下发公钥", "通过 offline_bundle.signing_key 设置独立的 Ed25519 私钥种子")
+	} else if seed, err := base64.StdEncoding.DecodeString(c.Offline.SigningKey); err != nil || len(seed) != 32 {
+		add("offline_signing_key", SeverityError, "离线配置包签名密钥无效, 应为 base64 编码的 32 字节 Ed25519 私钥种子", "使用 openssl rand -base64 32 生成")
+	}
+
+	if c.Server.Watch.Timeout > 0 && c.Server.Watch.Timeout <= maxLongPollSeconds {
+		add("watch_timeout", SeverityWarn, "监听接口超时不超过长轮询最长时间 "+strconv.Itoa(maxLongPollSeconds)+" 秒, 长轮询可能在返回前被断开", "将 server.watch.timeout 设置为 90 或更大")
+	}
+
+	if c.Replication.Enabled && (c.Replication.Token == "" || c.Replication.Token == defaultReplicationToken) {
+		add("replication_token", SeverityError, "已开启跨实例复制但复制令牌为空或为示例值", "为两端设置相同的随机 replication.token")
+	}
+
+	if c.Chaos.Enabled {
+		severity := SeverityWarn
+		if c.Env == "production" {
+			severity = SeverityError
+		}
+		add("chaos", severity, "已开启监听链路故障注入接口, 管理员可向客户端推送伪造的变更并断开监听连接", "仅在测试环境设置 chaos.enabled: true")
+	}
+
+	return findings
+}
+
+// InsecureFindings 返回级别为 error 的结果
+func InsecureFindings(findings []Finding) []Finding {
+	var insecure []Finding
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			insecure = append(insecure, f)
+		}
+	}
+	return insecure
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// estimateEntropy 按字符频率估算字符串的熵 (比特), 重复或字符集单一的密钥得分较低
+func estimateEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	perChar := 0.0
+	for _, n := range counts {
+		p := float64(n) / float64(total)
+		perChar -= p * math.Log2(p)
+	}
+	return perChar * float64(total)
+}
+
+// isLocalAddr 地址是否指向本机或容器内部网络
+func isLocalAddr(addr string) bool {
+	host := addr
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		host = host[i+1:]
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsPrivate())
+}
+
+// redisMode Redis 连接方式的描述
+func redisMode(r RedisConfig) string {
+	mode := "明文连接"
+	if r.TLS || strings.HasPrefix(r.Addr, "rediss://") {
+		mode = "TLS 连接"
+	}
+	if isLocalAddr(r.Addr) {
+		mode = "本地" + mode
+	}
+	return mode
+}
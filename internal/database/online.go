@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 大表 (如 config_versions) 的在线迁移辅助函数
+// 滚动升级期间新旧版本的服务同时运行, 迁移需满足: 不长时间锁表、可重复执行、只做向后兼容的变更
+// (先加可空列/索引, 新版本全量上线后再回填和清理旧字段)
+
+// AddColumnOnline 在线添加列, 列已存在时跳过
+// MySQL 优先使用 ALGORITHM=INSTANT, 不支持时退回 ALGORITHM=INPLACE, LOCK=NONE;
+// PostgreSQL 添加可空且无易变默认值的列仅修改元数据, 无需特殊处理
+func AddColumnOnline(ctx context.Context, db *gorm.DB, table, column, definition string) error {
+	db = db.WithContext(ctx)
+	if db.Migrator().HasColumn(table, column) {
+		return nil
+	}
+
+	stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", quote(db, table), quote(db, column), definition)
+	if db.Dialector.Name() != "mysql" {
+		return db.Exec(stmt).Error
+	}
+	if err := db.Exec(stmt + ", ALGORITHM=INSTANT").Error; err == nil {
+		return nil
+	}
+	return db.Exec(stmt + ", ALGORITHM=INPLACE, LOCK=NONE").Error
+}
+
+// CreateIndexOnline 在线创建索引, 索引已存在时跳过
+// MySQL 使用 ALGORITHM=INPLACE, LOCK=NONE; PostgreSQL 使用 CONCURRENTLY (不能在事务中执行)
+func CreateIndexOnline(ctx context.Context, db *gorm.DB, table, index string, columns ...string) error {
+	db = db.WithContext(ctx)
+	if db.Migrator().HasIndex(table, index) {
+		return nil
+	}
+
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = quote(db, column)
+	}
+	cols := strings.Join(quoted, ", ")
+
+	if db.Dialector.Name() == "mysql" {
+		return db.Exec(fmt.Sprintf("CREATE INDEX %s ON %s (%s) ALGORITHM=INPLACE LOCK=NONE", quote(db, index), quote(db, table), cols)).Error
+	}
+	return db.Exec(fmt.Sprintf("CREATE INDEX CONCURRENTLY IF NOT EXISTS %s ON %s (%s)", quote(db, index), quote(db, table), cols)).Error
+}
+
+// BatchUpdate 按主键区间分批处理大表, 每批在独立的短事务中执行, 批次间暂停以降低锁竞争和主从延迟
+// apply 处理 [fromID, toID] 区间内的行; 返回处理的批次数
+func BatchUpdate(ctx context.Context, db *gorm.DB, table string, batchSize int64, pause time.Duration, apply func(tx *gorm.DB, fromID, toID int64) error) (int, error) {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	db = db.WithContext(ctx)
+
+	var bounds struct {
+		MinID *int64
+		MaxID *int64
+	}
+	if err := db.Table(table).Select("MIN(id) AS min_id, MAX(id) AS max_id").Scan(&bounds).Error; err != nil {
+		return 0, err
+	}
+	if bounds.MinID == nil || bounds.MaxID == nil {
+		return 0, nil
+	}
+
+	batches := 0
+	for from := *bounds.MinID; from <= *bounds.MaxID; from += batchSize {
+		to := from + batchSize - 1
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return apply(tx, from, to)
+		}); err != nil {
+			return batches, fmt.Errorf("batch [%d, %d]: %w", from, to, err)
+		}
+		batches++
+
+		if pause > 0 && to < *bounds.MaxID {
+			select {
+			case <-ctx.Done():
+				return batches, ctx.Err()
+			case <-time.After(pause):
+			}
+		}
+	}
+	return batches, nil
+}
+
+// quote 按数据库方言引用标识符
+func quote(db *gorm.DB, name string) string {
+	return db.Statement.Quote(name)
+}
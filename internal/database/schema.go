@@ -0,0 +1,5 @@
+package database
+
+// SchemaVersion 当前代码要求的数据库 schema 版本, 即 migrations 目录中最新迁移的序号
+// 新增迁移脚本时需同步更新
+const SchemaVersion = 27
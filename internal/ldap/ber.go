@@ -0,0 +1,162 @@
+package ldap
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// BER 标签 (RFC 4511 使用的子集)
+const (
+	tagBoolean     = 0x01
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagEnumerated  = 0x0a
+	tagSequence    = 0x30
+	tagSet         = 0x31
+)
+
+// maxPacketSize 单个响应的最大长度, 防止异常的长度字段耗尽内存
+const maxPacketSize = 16 << 20
+
+var errMalformed = errors.New("ldap: 响应格式错误")
+
+// element 解码后的 BER 元素
+type element struct {
+	tag     byte
+	content []byte
+}
+
+// encode 编码 TLV
+func encode(tag byte, content []byte) []byte {
+	out := []byte{tag}
+	out = append(out, encodeLength(len(content))...)
+	return append(out, content...)
+}
+
+// encodeLength 编码长度, 超过 127 时使用长格式
+func encodeLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
+
+// encodeInt 编码整数 (二进制补码, 最短形式)
+func encodeInt(tag byte, v int64) []byte {
+	var b []byte
+	for {
+		b = append([]byte{byte(v)}, b...)
+		v >>= 8
+		if (v == 0 && b[0]&0x80 == 0) || (v == -1 && b[0]&0x80 != 0) {
+			break
+		}
+	}
+	return encode(tag, b)
+}
+
+// encodeBool 编码布尔值
+func encodeBool(v bool) []byte {
+	if v {
+		return encode(tagBoolean, []byte{0xff})
+	}
+	return encode(tagBoolean, []byte{0x00})
+}
+
+// encodeString 编码字符串
+func encodeString(tag byte, s string) []byte {
+	return encode(tag, []byte(s))
+}
+
+// concat 拼接已编码的元素, 作为构造类型的内容
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+// readPacket 读取一个完整的 BER 元素 (LDAP 不使用不定长格式)
+func readPacket(r *bufio.Reader) (*element, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	first, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	length := int(first)
+	if first&0x80 != 0 {
+		n := int(first & 0x7f)
+		if n == 0 || n > 4 {
+			return nil, errMalformed
+		}
+		length = 0
+		for i := 0; i < n; i++ {
+			b, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			length = length<<8 | int(b)
+		}
+	}
+	if length > maxPacketSize {
+		return nil, errMalformed
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, err
+	}
+	return &element{tag: tag, content: content}, nil
+}
+
+// children 解析构造类型内容中的各个元素
+func (e *element) children() ([]*element, error) {
+	var out []*element
+	b := e.content
+	for len(b) > 0 {
+		if len(b) < 2 {
+			return nil, errMalformed
+		}
+		tag := b[0]
+		length := int(b[1])
+		offset := 2
+		if b[1]&0x80 != 0 {
+			n := int(b[1] & 0x7f)
+			if n == 0 || n > 4 || len(b) < 2+n {
+				return nil, errMalformed
+			}
+			length = 0
+			for i := 0; i < n; i++ {
+				length = length<<8 | int(b[2+i])
+			}
+			offset += n
+		}
+		if length < 0 || len(b) < offset+length {
+			return nil, errMalformed
+		}
+		out = append(out, &element{tag: tag, content: b[offset : offset+length]})
+		b = b[offset+length:]
+	}
+	return out, nil
+}
+
+// int 解析整数或枚举值
+func (e *element) int() int64 {
+	var v int64
+	for i, b := range e.content {
+		if i == 0 && b&0x80 != 0 {
+			v = -1
+		}
+		v = v<<8 | int64(b)
+	}
+	return v
+}
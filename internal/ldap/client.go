@@ -0,0 +1,313 @@
+// Package ldap 实现登录所需的最小 LDAP v3 客户端: 简单绑定、搜索和 StartTLS
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 协议操作标签 (RFC 4511)
+const (
+	opBindRequest       = 0x60
+	opBindResponse      = 0x61
+	opUnbindRequest     = 0x42
+	opSearchRequest     = 0x63
+	opSearchEntry       = 0x64
+	opSearchDone        = 0x65
+	opSearchReference   = 0x73
+	opExtendedRequest   = 0x77
+	opExtendedResponse  = 0x78
+	extendedRequestName = 0x80
+	simpleAuth          = 0x80
+)
+
+// startTLSOID StartTLS 扩展操作
+const startTLSOID = "1.3.6.1.4.1.1466.20037"
+
+// 结果码
+const (
+	ResultSuccess            = 0
+	ResultNoSuchObject       = 32
+	ResultInvalidCredentials = 49
+)
+
+// 搜索范围
+const (
+	ScopeBaseObject   = 0
+	ScopeSingleLevel  = 1
+	ScopeWholeSubtree = 2
+)
+
+// ResultError 服务端返回的非成功结果
+type ResultError struct {
+	Code    int64
+	Message string
+}
+
+func (e *ResultError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("ldap: 结果码 %d", e.Code)
+	}
+	return fmt.Sprintf("ldap: 结果码 %d: %s", e.Code, e.Message)
+}
+
+// IsResult 判断 err 是否为指定结果码
+func IsResult(err error, code int64) bool {
+	var resultErr *ResultError
+	return errors.As(err, &resultErr) && resultErr.Code == code
+}
+
+// Conn LDAP 连接, 请求按顺序执行, 不可并发使用
+type Conn struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	host    string
+	timeout time.Duration
+	msgID   int64
+}
+
+// Dial 连接 ldap:// 或 ldaps:// 地址, tlsConfig 用于 ldaps 及之后的 StartTLS
+func Dial(ctx context.Context, rawURL string, timeout time.Duration, tlsConfig *tls.Config) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: 无效的地址 %q", rawURL)
+	}
+	host := u.Hostname()
+	port := u.Port()
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	switch u.Scheme {
+	case "ldap":
+		if port == "" {
+			port = "389"
+		}
+		conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	case "ldaps":
+		if port == "" {
+			port = "636"
+		}
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: withServerName(tlsConfig, host)}).DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	default:
+		return nil, fmt.Errorf("ldap: 不支持的协议 %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{conn: conn, r: bufio.NewReader(conn), host: host, timeout: timeout}, nil
+}
+
+// StartTLS 将明文连接升级为 TLS
+func (c *Conn) StartTLS(tlsConfig *tls.Config) error {
+	op := encode(opExtendedRequest, encodeString(extendedRequestName, startTLSOID))
+	if _, err := c.request(op, opExtendedResponse, nil); err != nil {
+		return err
+	}
+
+	tlsConn := tls.Client(c.conn, withServerName(tlsConfig, c.host))
+	if c.timeout > 0 {
+		tlsConn.SetDeadline(time.Now().Add(c.timeout))
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+	c.conn = tlsConn
+	c.r = bufio.NewReader(tlsConn)
+	return nil
+}
+
+// Bind 简单绑定; 密码为空时服务端会视为匿名绑定而返回成功, 因此直接拒绝
+func (c *Conn) Bind(dn, password string) error {
+	if password == "" && dn != "" {
+		return &ResultError{Code: ResultInvalidCredentials, Message: "密码为空"}
+	}
+	op := encode(opBindRequest, concat(
+		encodeInt(tagInteger, 3),
+		encodeString(tagOctetString, dn),
+		encodeString(simpleAuth, password),
+	))
+	_, err := c.request(op, opBindResponse, nil)
+	return err
+}
+
+// SearchRequest 搜索请求
+type SearchRequest struct {
+	BaseDN     string
+	Scope      int
+	Filter     string
+	Attributes []string
+	SizeLimit  int
+}
+
+// Entry 搜索结果条目
+type Entry struct {
+	DN         string
+	Attributes map[string][]string // 属性名统一为小写
+}
+
+// Get 返回属性的第一个值, 属性名不区分大小写
+func (e *Entry) Get(attr string) string {
+	if values := e.Attributes[strings.ToLower(attr)]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Values 返回属性的全部值, 属性名不区分大小写
+func (e *Entry) Values(attr string) []string {
+	return e.Attributes[strings.ToLower(attr)]
+}
+
+// Search 执行搜索, 返回全部结果条目 (忽略引用)
+func (c *Conn) Search(req *SearchRequest) ([]*Entry, error) {
+	filter, err := compileFilter(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	var attrs []byte
+	for _, attr := range req.Attributes {
+		attrs = append(attrs, encodeString(tagOctetString, attr)...)
+	}
+	timeLimit := int64(c.timeout / time.Second)
+
+	op := encode(opSearchRequest, concat(
+		encodeString(tagOctetString, req.BaseDN),
+		encodeInt(tagEnumerated, int64(req.Scope)),
+		encodeInt(tagEnumerated, 0), // neverDerefAliases
+		encodeInt(tagInteger, int64(req.SizeLimit)),
+		encodeInt(tagInteger, timeLimit),
+		encodeBool(false),
+		filter,
+		encode(tagSequence, attrs),
+	))
+
+	var entries []*Entry
+	_, err = c.request(op, opSearchDone, func(op *element) error {
+		if op.tag != opSearchEntry {
+			return nil
+		}
+		entry, err := parseEntry(op)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, err
+}
+
+// Close 发送解绑请求并关闭连接
+func (c *Conn) Close() error {
+	c.msgID++
+	msg := encode(tagSequence, concat(encodeInt(tagInteger, c.msgID), encode(opUnbindRequest, nil)))
+	c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	c.conn.Write(msg)
+	return c.conn.Close()
+}
+
+// request 发送请求并读取响应直到 final 类型的操作; 之前的中间响应 (如搜索条目) 交给 onOp
+func (c *Conn) request(op []byte, final byte, onOp func(op *element) error) (*element, error) {
+	c.msgID++
+	msgID := c.msgID
+	if c.timeout > 0 {
+		c.conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+	if _, err := c.conn.Write(encode(tagSequence, concat(encodeInt(tagInteger, msgID), op))); err != nil {
+		return nil, err
+	}
+
+	for {
+		packet, err := readPacket(c.r)
+		if err != nil {
+			return nil, err
+		}
+		if packet.tag != tagSequence {
+			return nil, errMalformed
+		}
+		parts, err := packet.children()
+		if err != nil || len(parts) < 2 || parts[0].tag != tagInteger {
+			return nil, errMalformed
+		}
+		if parts[0].int() != msgID {
+			// 消息 ID 为 0 的是服务端主动通知 (如断开连接), 其他不属于本请求的消息忽略
+			if parts[0].int() == 0 && parts[1].tag == opExtendedResponse {
+				return nil, resultError(parts[1])
+			}
+			continue
+		}
+
+		response := parts[1]
+		if response.tag == final {
+			return response, resultError(response)
+		}
+		if onOp == nil {
+			if response.tag == opSearchReference {
+				continue
+			}
+			return nil, errMalformed
+		}
+		if err := onOp(response); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// resultError 解析 LDAPResult, 结果码非 0 时返回 ResultError
+func resultError(op *element) error {
+	parts, err := op.children()
+	if err != nil || len(parts) < 3 || parts[0].tag != tagEnumerated {
+		return errMalformed
+	}
+	if code := parts[0].int(); code != ResultSuccess {
+		return &ResultError{Code: code, Message: string(parts[2].content)}
+	}
+	return nil
+}
+
+// parseEntry 解析 SearchResultEntry
+func parseEntry(op *element) (*Entry, error) {
+	parts, err := op.children()
+	if err != nil || len(parts) < 2 {
+		return nil, errMalformed
+	}
+	entry := &Entry{DN: string(parts[0].content), Attributes: make(map[string][]string)}
+
+	attrs, err := parts[1].children()
+	if err != nil {
+		return nil, err
+	}
+	for _, attr := range attrs {
+		fields, err := attr.children()
+		if err != nil || len(fields) < 2 {
+			return nil, errMalformed
+		}
+		values, err := fields[1].children()
+		if err != nil {
+			return nil, err
+		}
+		name := strings.ToLower(string(fields[0].content))
+		for _, v := range values {
+			entry.Attributes[name] = append(entry.Attributes[name], string(v.content))
+		}
+	}
+	return entry, nil
+}
+
+// withServerName 复制 TLS 配置并在未指定时设置 ServerName
+func withServerName(tlsConfig *tls.Config, host string) *tls.Config {
+	if tlsConfig == nil {
+		return &tls.Config{ServerName: host}
+	}
+	cfg := tlsConfig.Clone()
+	if cfg.ServerName == "" {
+		cfg.ServerName = host
+	}
+	return cfg
+}
@@ -0,0 +1,182 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// 过滤器的上下文标签 (RFC 4511 4.5.1)
+const (
+	filterAnd            = 0xa0
+	filterOr             = 0xa1
+	filterNot            = 0xa2
+	filterEquality       = 0xa3
+	filterSubstrings     = 0xa4
+	filterGreaterOrEqual = 0xa5
+	filterLessOrEqual    = 0xa6
+	filterPresent        = 0x87
+	filterApprox         = 0xa8
+)
+
+// EscapeFilter 转义过滤器中的值 (RFC 4515), 用于将用户输入代入过滤器
+func EscapeFilter(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '*', '(', ')', '\\', 0:
+			fmt.Fprintf(&b, "\\%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// compileFilter 将字符串形式的过滤器 (如 (&(objectClass=person)(uid=alice))) 编码为 BER
+func compileFilter(filter string) ([]byte, error) {
+	filter = strings.TrimSpace(filter)
+	if !strings.HasPrefix(filter, "(") {
+		filter = "(" + filter + ")"
+	}
+	encoded, rest, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("ldap: 过滤器 %q 末尾有多余内容", filter)
+	}
+	return encoded, nil
+}
+
+// parseFilter 解析一个带括号的过滤器, 返回编码结果和剩余内容
+func parseFilter(s string) ([]byte, string, error) {
+	if len(s) < 2 || s[0] != '(' {
+		return nil, "", fmt.Errorf("ldap: 无效的过滤器 %q", s)
+	}
+	s = s[1:]
+
+	switch s[0] {
+	case '&', '|':
+		tag := byte(filterAnd)
+		if s[0] == '|' {
+			tag = filterOr
+		}
+		s = s[1:]
+		var parts []byte
+		for len(s) > 0 && s[0] == '(' {
+			part, rest, err := parseFilter(s)
+			if err != nil {
+				return nil, "", err
+			}
+			parts = append(parts, part...)
+			s = rest
+		}
+		if len(s) == 0 || s[0] != ')' {
+			return nil, "", fmt.Errorf("ldap: 过滤器缺少右括号")
+		}
+		return encode(tag, parts), s[1:], nil
+	case '!':
+		part, rest, err := parseFilter(s[1:])
+		if err != nil {
+			return nil, "", err
+		}
+		if len(rest) == 0 || rest[0] != ')' {
+			return nil, "", fmt.Errorf("ldap: 过滤器缺少右括号")
+		}
+		return encode(filterNot, part), rest[1:], nil
+	}
+
+	end := strings.IndexByte(s, ')')
+	if end < 0 {
+		return nil, "", fmt.Errorf("ldap: 过滤器缺少右括号")
+	}
+	encoded, err := parseItem(s[:end])
+	if err != nil {
+		return nil, "", err
+	}
+	return encoded, s[end+1:], nil
+}
+
+// parseItem 解析单个比较项, 如 uid=alice、mail=*、cn=ali*
+func parseItem(item string) ([]byte, error) {
+	eq := strings.IndexByte(item, '=')
+	if eq <= 0 {
+		return nil, fmt.Errorf("ldap: 无效的过滤条件 %q", item)
+	}
+	attr, value := item[:eq], item[eq+1:]
+
+	tag := byte(filterEquality)
+	switch attr[len(attr)-1] {
+	case '>':
+		tag, attr = filterGreaterOrEqual, attr[:len(attr)-1]
+	case '<':
+		tag, attr = filterLessOrEqual, attr[:len(attr)-1]
+	case '~':
+		tag, attr = filterApprox, attr[:len(attr)-1]
+	}
+	if attr == "" {
+		return nil, fmt.Errorf("ldap: 无效的过滤条件 %q", item)
+	}
+
+	if tag == filterEquality && value == "*" {
+		return encodeString(filterPresent, attr), nil
+	}
+	if tag == filterEquality && strings.Contains(value, "*") {
+		return encodeSubstrings(attr, value)
+	}
+
+	v, err := unescapeValue(value)
+	if err != nil {
+		return nil, err
+	}
+	return encode(tag, concat(encodeString(tagOctetString, attr), encodeString(tagOctetString, v))), nil
+}
+
+// encodeSubstrings 编码子串匹配, 如 cn=ali*ce*
+func encodeSubstrings(attr, value string) ([]byte, error) {
+	parts := strings.Split(value, "*")
+	var subs []byte
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		v, err := unescapeValue(part)
+		if err != nil {
+			return nil, err
+		}
+		tag := byte(0x81) // any
+		switch i {
+		case 0:
+			tag = 0x80 // initial
+		case len(parts) - 1:
+			tag = 0x82 // final
+		}
+		subs = append(subs, encodeString(tag, v)...)
+	}
+	return encode(filterSubstrings, concat(encodeString(tagOctetString, attr), encode(tagSequence, subs))), nil
+}
+
+// unescapeValue 还原过滤器值中的 \XX 转义
+func unescapeValue(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+3 > len(s) {
+			return "", fmt.Errorf("ldap: 无效的转义 %q", s)
+		}
+		decoded, err := hex.DecodeString(s[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("ldap: 无效的转义 %q", s)
+		}
+		b.Write(decoded)
+		i += 2
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"time"
+
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLog 访问日志中间件
+// 用于公开读取/监听接口, 采样输出结构化日志, 不写入审计表
+func AccessLog(accessLogSvc *service.AccessLogService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		entry := &service.AccessLogEntry{
+			ConfigName:  c.Query("name"),
+			Namespace:   c.Query("namespace"),
+			Environment: c.Query("env"),
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			Status:      c.Writer.Status(),
+			Latency:     time.Since(start),
+			IPAddress:   c.ClientIP(),
+			UserAgent:   c.Request.UserAgent(),
+			ClientID:    c.Query("client_id"),
+			Labels:      c.GetHeader("X-Client-Labels"),
+		}
+		if entry.ClientID == "" {
+			entry.ClientID = c.GetHeader("X-Client-ID")
+		}
+
+		if authCtx := GetAuthContext(c); authCtx != nil {
+			entry.ProjectID = authCtx.ProjectID
+			entry.AccessKeyID = authCtx.AccessKeyID
+		}
+
+		accessLogSvc.Log(entry)
+	}
+}
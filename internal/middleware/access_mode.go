@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"confighub/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// EnforceAccessMode 按项目访问模式校验公开接口的调用方 (需在 OptionalAuth 之后使用)
+//   - public: 允许匿名读取, 匿名权限取自项目 PublicPermissions; 登录用户是项目成员时按角色授权, 否则同匿名
+//   - key:    必须使用 Access Key
+//   - auth:   必须是登录用户且为项目成员, 权限由成员角色决定
+//
+// Access Key 自带所属项目; JWT 用户和匿名调用方通过 project_id 参数或 X-Project-ID 头指定项目.
+// 项目通过 loadProject 读取 (项目缓存), 与签名校验读取的项目设置为同一条记录
+func EnforceAccessMode(db *gorm.DB, loadProject func(ctx context.Context, projectID int64) (*model.Project, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 降级期间认证结果来自 DegradedAuth 的记录, 已包含访问模式校验后的项目和权限
+		if IsDegraded(c) && GetAuthContext(c) != nil {
+			c.Next()
+			return
+		}
+
+		authCtx := GetAuthContext(c)
+		if authCtx == nil {
+			authCtx = &AuthContext{}
+		}
+
+		projectID := authCtx.ProjectID
+		if requested := requestedProjectID(c); requested != 0 {
+			if projectID != 0 && projectID != requested {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"code":    "FORBIDDEN",
+					"message": "Access Key 不属于该项目",
+				})
+				return
+			}
+			projectID = requested
+		}
+		if projectID == 0 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "未指定项目",
+			})
+			return
+		}
+
+		project, err := loadProject(c.Request.Context(), projectID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+				"code":    "NOT_FOUND",
+				"message": "项目不存在",
+			})
+			return
+		}
+
+		isKey := authCtx.AccessKeyID != 0
+		isUser := authCtx.UserID != 0
+
+		switch project.AccessMode {
+		case model.AccessModePublic:
+			// 登录用户按成员角色授权, 不是成员时与匿名访问相同; JWT 自带的权限不代表对该项目的授权
+			switch {
+			case isUser:
+				permissions, ok := memberPermissions(c, db, project, authCtx.UserID)
+				if !ok {
+					permissions = publicPermissions(project)
+				}
+				authCtx.Permissions = permissions
+			case !isKey:
+				authCtx.Permissions = publicPermissions(project)
+			}
+		case model.AccessModeAuth:
+			if !isUser {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					"code":    "UNAUTHORIZED",
+					"message": "该项目需要登录访问",
+				})
+				return
+			}
+			permissions, ok := memberPermissions(c, db, project, authCtx.UserID)
+			if !ok {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"code":    "FORBIDDEN",
+					"message": "不是该项目成员",
+				})
+				return
+			}
+			authCtx.Permissions = permissions
+		default: // key
+			if !isKey {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					"code":    "UNAUTHORIZED",
+					"message": "该项目需要 Access Key 访问",
+				})
+				return
+			}
+		}
+
+		authCtx.ProjectID = project.ID
+		c.Set(AuthContextKey, authCtx)
+		c.Next()
+	}
+}
+
+// requestedProjectID 从 project_id 参数或 X-Project-ID 头获取请求的项目 ID
+func requestedProjectID(c *gin.Context) int64 {
+	value := c.Query("project_id")
+	if value == "" {
+		value = c.GetHeader("X-Project-ID")
+	}
+	id, _ := strconv.ParseInt(value, 10, 64)
+	return id
+}
+
+// publicPermissions 解析项目的匿名访问权限, 未配置时仅允许读取
+func publicPermissions(project *model.Project) model.Permissions {
+	permissions := model.Permissions{Read: true}
+	if project.PublicPermissions != "" {
+		json.Unmarshal([]byte(project.PublicPermissions), &permissions)
+	}
+	return permissions
+}
+
+// memberPermissions 获取用户在项目中的权限, 项目创建者视为管理员
+func memberPermissions(c *gin.Context, db *gorm.DB, project *model.Project, userID int64) (model.Permissions, bool) {
+	if project.CreatedBy == userID {
+		return model.RolePermissions("admin"), true
+	}
+
+	var member model.ProjectMember
+	if err := db.WithContext(c.Request.Context()).Where("project_id = ? AND user_id = ?", project.ID, userID).First(&member).Error; err != nil {
+		return model.Permissions{}, false
+	}
+	return model.RolePermissions(member.Role), true
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AllowCIDRs 只允许来源地址在指定网段内的请求, 用于将管理面限制在内网
+// 按 TCP 连接的对端地址判断, 不信任 X-Forwarded-For 等可伪造的请求头
+func AllowCIDRs(cidrs []string) (gin.HandlerFunc, error) {
+	networks, err := parseCIDRs(cidrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *gin.Context) {
+		if len(networks) == 0 || remoteIn(c, networks) {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"code":    "FORBIDDEN",
+			"message": "来源地址不允许访问管理接口",
+		})
+	}, nil
+}
+
+// parseCIDRs 解析网段列表, 单个 IP 视为只包含该地址的网段
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if ip := net.ParseIP(cidr); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// remoteIn 请求的 TCP 对端地址是否在任一网段内
+func remoteIn(c *gin.Context, networks []*net.IPNet) bool {
+	ip := net.ParseIP(c.RemoteIP())
+	for _, network := range networks {
+		if ip != nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
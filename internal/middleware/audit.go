@@ -55,6 +55,7 @@ func AuditMiddleware(auditSvc *service.AuditService) gin.HandlerFunc {
 			IPAddress:    c.ClientIP(),
 			UserAgent:    c.Request.UserAgent(),
 			RequestBody:  truncateString(requestBody, 2000),
+			StatusCode:   c.Writer.Status(),
 		}
 
 		if authCtx.UserID > 0 {
@@ -16,15 +16,20 @@ import (
 
 // AuthContext 认证上下文
 type AuthContext struct {
-	UserID      int64
-	Username    string
-	AccessKeyID int64
-	ProjectID   int64
-	Permissions model.Permissions
+	UserID        int64
+	Username      string
+	AccessKeyID   int64
+	ProjectID     int64
+	Permissions   model.Permissions
+	Priority      string // Access Key 的监听优先级, 用户和匿名访问为空 (按 normal 处理)
+	ResolveTiming bool   // 公开读取响应是否携带配置解析的耗时分解, 由 Access Key 开启
 }
 
 const AuthContextKey = "auth_context"
 
+// projectKeyContextKey 认证通过的 Access Key 记录, 供签名校验使用而无需再次查询
+const projectKeyContextKey = "project_key"
+
 // JWTAuth JWT 认证中间件
 func JWTAuth(secret string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -110,57 +115,79 @@ func AccessKeyAuth(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// 检查过期时间
-		if key.ExpiresAt != nil && key.ExpiresAt.Before(time.Now()) {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"code":    "UNAUTHORIZED",
-				"message": "Access Key 已过期",
-			})
+		if !authorizeKey(c, &key) {
 			return
 		}
+		c.Next()
+	}
+}
 
-		// 检查 IP 白名单
-		if key.IPWhitelist != "" && key.IPWhitelist != "[]" {
-			var whitelist []string
-			if err := json.Unmarshal([]byte(key.IPWhitelist), &whitelist); err == nil && len(whitelist) > 0 {
-				clientIP := c.ClientIP()
-				allowed := false
-				for _, ip := range whitelist {
-					if ip == clientIP || matchIPRange(clientIP, ip) {
-						allowed = true
-						break
-					}
-				}
-				if !allowed {
-					c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-						"code":    "FORBIDDEN",
-						"message": "IP 地址不在白名单中",
-					})
-					return
+// authorizeKey 校验 Access Key 的有效期、IP 白名单和所属项目的查询参数策略, 通过后设置认证上下文
+func authorizeKey(c *gin.Context, key *model.ProjectKey) bool {
+	if queryKeyRejected(c, key.ProjectID) {
+		rejectQueryAccessKey(c)
+		return false
+	}
+
+	// 检查过期时间
+	if key.ExpiresAt != nil && key.ExpiresAt.Before(time.Now()) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"code":    "UNAUTHORIZED",
+			"message": "Access Key 已过期",
+		})
+		return false
+	}
+
+	// 检查 IP 白名单
+	if key.IPWhitelist != "" && key.IPWhitelist != "[]" {
+		var whitelist []string
+		if err := json.Unmarshal([]byte(key.IPWhitelist), &whitelist); err == nil && len(whitelist) > 0 {
+			clientIP := c.ClientIP()
+			allowed := false
+			for _, ip := range whitelist {
+				if ip == clientIP || matchIPRange(clientIP, ip) {
+					allowed = true
+					break
 				}
 			}
+			if !allowed {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"code":    "FORBIDDEN",
+					"message": "IP 地址不在白名单中",
+				})
+				return false
+			}
 		}
+	}
 
-		// 解析权限
-		var permissions model.Permissions
-		if key.Permissions != "" {
-			json.Unmarshal([]byte(key.Permissions), &permissions)
-		}
-
-		authCtx := &AuthContext{
-			AccessKeyID: key.ID,
-			ProjectID:   key.ProjectID,
-			Permissions: permissions,
-		}
+	// 解析权限
+	var permissions model.Permissions
+	if key.Permissions != "" {
+		json.Unmarshal([]byte(key.Permissions), &permissions)
+	}
 
-		c.Set(AuthContextKey, authCtx)
-		c.Next()
+	authCtx := &AuthContext{
+		AccessKeyID:   key.ID,
+		ProjectID:     key.ProjectID,
+		Permissions:   permissions,
+		Priority:      key.Priority,
+		ResolveTiming: key.ResolveTiming,
 	}
+
+	c.Set(AuthContextKey, authCtx)
+	c.Set(projectKeyContextKey, key)
+	return true
 }
 
 // OptionalAuth 可选认证中间件 (用于公开模式)
 func OptionalAuth(db *gorm.DB, jwtSecret string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// 降级期间已由 DegradedAuth 恢复认证结果
+		if IsDegraded(c) && GetAuthContext(c) != nil {
+			c.Next()
+			return
+		}
+
 		// 尝试 JWT 认证
 		authHeader := c.GetHeader("Authorization")
 		if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
@@ -239,6 +266,27 @@ func RequirePermission(permission string) gin.HandlerFunc {
 	}
 }
 
+// AdminOnly 运维接口中间件, 需放在 JWTAuth 之后, 只允许配置中列出的用户调用
+// 注册无需审批, 登录用户本身不代表有实例级的管理权限
+func AdminOnly(adminUserIDs []int64) gin.HandlerFunc {
+	admins := make(map[int64]bool, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		admins[id] = true
+	}
+
+	return func(c *gin.Context) {
+		authCtx := GetAuthContext(c)
+		if authCtx == nil || authCtx.UserID == 0 || !admins[authCtx.UserID] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code":    "FORBIDDEN",
+				"message": "仅实例管理员可执行此操作",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
 // GetAuthContext 获取认证上下文
 func GetAuthContext(c *gin.Context) *AuthContext {
 	if authCtx, exists := c.Get(AuthContextKey); exists {
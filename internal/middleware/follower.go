@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FollowerAuth 跟随节点 Access Key 认证中间件
+// 密钥来自主实例复制的快照, 校验规则与 AccessKeyAuth 一致
+func FollowerAuth(followerSvc *service.FollowerService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		accessKey := c.GetHeader("X-Access-Key")
+		if accessKey == "" {
+			accessKey = c.Query("access_key")
+		}
+
+		if accessKey == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "缺少 Access Key",
+			})
+			return
+		}
+
+		key, err := followerSvc.Authenticate(accessKey)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "无效的 Access Key",
+			})
+			return
+		}
+
+		if !authorizeKey(c, key) {
+			return
+		}
+		c.Next()
+	}
+}
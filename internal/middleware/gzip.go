@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// Gzip 客户端接受 gzip 时压缩响应体, 响应体不足 minSize 字节时原样输出
+// 已自行压缩 (设置了 Content-Encoding) 的响应、SSE 和图片等已压缩的类型不再压缩;
+// 响应在达到 minSize 之前刷新 (长轮询、SSE) 时不压缩
+func Gzip(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead || !acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+
+		w := &gzipWriter{ResponseWriter: c.Writer, minSize: minSize}
+		c.Writer = w
+		// panic 时同样输出已缓冲的内容并还原 Writer, 由外层的 Recovery 写入错误响应
+		defer func() {
+			w.finish()
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// acceptsGzip Accept-Encoding 是否接受 gzip (q=0 表示拒绝)
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(params[0]))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if found && strings.TrimSpace(name) == "q" {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		return q > 0
+	}
+	return false
+}
+
+// gzipWriter 缓冲响应体直到足以判断是否压缩
+type gzipWriter struct {
+	gin.ResponseWriter
+	minSize int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, data...)
+		if len(w.buf) < w.minSize {
+			return len(data), nil
+		}
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if w.gz != nil {
+		return w.gz.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow 立即写出响应头时不再压缩
+func (w *gzipWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.start(false)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Written 缓冲中的内容视为已写入, 避免后续中间件覆盖响应
+func (w *gzipWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+func (w *gzipWriter) Flush() {
+	if !w.decided {
+		w.start(false)
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Unwrap 供 http.ResponseController 设置读写截止时间
+func (w *gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// start 决定是否压缩并写出已缓冲的内容
+func (w *gzipWriter) start(compress bool) error {
+	w.decided = true
+	buf := w.buf
+	w.buf = nil
+
+	if compress && w.compressible() {
+		header := w.Header()
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", http.DetectContentType(buf))
+		}
+		header.Set("Content-Encoding", "gzip")
+		header.Add("Vary", "Accept-Encoding")
+		header.Del("Content-Length")
+		// 压缩后的字节与原内容不同, 强 ETag 改为弱 ETag
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+
+		w.gz = gzipWriters.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+		if len(buf) == 0 {
+			return nil
+		}
+		_, err := w.gz.Write(buf)
+		return err
+	}
+
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// compressible 响应是否适合压缩
+func (w *gzipWriter) compressible() bool {
+	status := w.Status()
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	for _, prefix := range []string{"text/event-stream", "image/", "video/", "audio/", "application/gzip", "application/zip", "application/octet-stream"} {
+		if strings.HasPrefix(contentType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// finish 请求结束时输出不足 minSize 的缓冲内容, 或结束 gzip 流
+func (w *gzipWriter) finish() {
+	if !w.decided {
+		w.start(false)
+	}
+	if w.gz != nil {
+		w.gz.Close()
+		w.gz.Reset(nil)
+		gzipWriters.Put(w.gz)
+		w.gz = nil
+	}
+}
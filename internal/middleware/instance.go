@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InstanceHeader 服务实例标识头, 每次进程启动生成新值, 客户端据此发现服务重启并重新同步
+const InstanceHeader = "X-Instance-ID"
+
+// NewInstanceID 生成实例标识: 启动时间 (毫秒) 加随机后缀
+func NewInstanceID() string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return strconv.FormatInt(time.Now().UnixMilli(), 36) + "-" + hex.EncodeToString(suffix)
+}
+
+// Instance 在所有响应中附带实例标识
+func Instance(id string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(InstanceHeader, id)
+		c.Next()
+	}
+}
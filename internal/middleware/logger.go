@@ -3,6 +3,9 @@ package middleware
 import (
 	"time"
 
+	"confighub/internal/service"
+	"confighub/internal/tracing"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -23,12 +26,16 @@ func Logger(logger *zap.Logger) gin.HandlerFunc {
 			zap.Int("status", status),
 			zap.String("method", c.Request.Method),
 			zap.String("path", path),
-			zap.String("query", query),
+			zap.String("query", service.RedactQuery(query)),
 			zap.String("ip", c.ClientIP()),
 			zap.Duration("latency", latency),
 			zap.String("user_agent", c.Request.UserAgent()),
 		}
 
+		if traceID := tracing.TraceID(c.Request.Context()); traceID != "" {
+			fields = append(fields, zap.String("trace_id", traceID))
+		}
+
 		if len(c.Errors) > 0 {
 			fields = append(fields, zap.String("errors", c.Errors.String()))
 		}
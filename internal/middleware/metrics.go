@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsAuth 指标接口认证中间件
+// 配置了令牌时需携带 Authorization: Bearer <token>, 配置了来源网段时 TCP 对端地址须在网段内;
+// 两者都未配置时拒绝访问, 避免配置和项目信息对任意来源公开
+func MetricsAuth(token string, cidrs []string) (gin.HandlerFunc, error) {
+	networks, err := parseCIDRs(cidrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *gin.Context) {
+		if token == "" && len(networks) == 0 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code":    "FORBIDDEN",
+				"message": "未配置指标接口的访问令牌或来源网段",
+			})
+			return
+		}
+		if len(networks) > 0 && !remoteIn(c, networks) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code":    "FORBIDDEN",
+				"message": "来源地址不允许访问指标接口",
+			})
+			return
+		}
+		if token != "" {
+			provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					"code":    "UNAUTHORIZED",
+					"message": "无效的指标访问令牌",
+				})
+				return
+			}
+		}
+		c.Next()
+	}, nil
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MigrationGate 迁移版本门控中间件
+// 数据库版本与服务不一致时拒绝写请求 (读请求和登录不受影响), 客户端可稍后重试
+func MigrationGate(migrationSvc *service.MigrationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if strings.HasPrefix(c.Request.URL.Path, "/api/auth/") || migrationSvc.Writable() {
+			c.Next()
+			return
+		}
+
+		status := migrationSvc.Status()
+		c.Header("Retry-After", "30")
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"code":      "SCHEMA_MISMATCH",
+			"message":   "数据库升级中, 暂不允许写入",
+			"details":   status.Reason,
+			"migration": status,
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// queryKeyPolicyKey 上下文中的项目级查询参数密钥策略, 仅在密钥来自查询参数时设置
+const queryKeyPolicyKey = "query_key_policy"
+
+// QueryAccessKey 查询参数中的 Access Key 处理策略
+// 查询参数中的凭据会出现在代理和访问日志中, 已弃用: allow 为 false 时一律拒绝;
+// 否则响应带上 Deprecation 头, 并由 authorizeKey 按密钥所属项目的设置 (projectRejects) 决定是否拒绝
+func QueryAccessKey(allow bool, projectRejects func(ctx context.Context, projectID int64) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("X-Access-Key") != "" || c.Query("access_key") == "" {
+			c.Next()
+			return
+		}
+
+		if !allow {
+			rejectQueryAccessKey(c)
+			return
+		}
+
+		c.Header("Deprecation", "true")
+		c.Header("Warning", `299 - "access_key query parameter is deprecated, use the X-Access-Key header"`)
+		c.Set(queryKeyPolicyKey, projectRejects)
+		c.Next()
+	}
+}
+
+// queryKeyRejected 密钥来自查询参数且所属项目拒绝此方式时返回 true
+func queryKeyRejected(c *gin.Context, projectID int64) bool {
+	value, ok := c.Get(queryKeyPolicyKey)
+	if !ok {
+		return false
+	}
+	projectRejects, ok := value.(func(ctx context.Context, projectID int64) bool)
+	return ok && projectRejects != nil && projectRejects(c.Request.Context(), projectID)
+}
+
+// rejectQueryAccessKey 拒绝查询参数中的 Access Key
+func rejectQueryAccessKey(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"code":    "QUERY_ACCESS_KEY_REJECTED",
+		"message": "不支持在查询参数中传递 Access Key, 请使用 X-Access-Key 请求头",
+	})
+}
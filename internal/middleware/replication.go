@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReplicationAuth 复制接口认证中间件
+// 对端需携带与本实例一致的共享令牌; 未配置令牌时复制接口整体不可用
+func ReplicationAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader(service.ReplicationTokenHeader)
+		if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "无效的复制令牌",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,224 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"confighub/internal/model"
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DegradedHeader 降级响应头, 值为 db-unavailable 表示内容来自缓存, 可能不是最新版本
+const DegradedHeader = "X-Degraded"
+
+// degradedContextKey 请求处于降级模式的上下文键
+const degradedContextKey = "degraded"
+
+// Resilience 数据库故障降级中间件
+// 降级期间拒绝写请求; 读请求标记降级响应头, 由 DegradedAuth 和热点缓存以最后已知结果响应
+func Resilience(resilienceSvc *service.ResilienceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !resilienceSvc.Degraded() {
+			c.Next()
+			return
+		}
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Header(DegradedHeader, "db-unavailable")
+			c.Set(degradedContextKey, true)
+			c.Next()
+			return
+		}
+
+		status := resilienceSvc.Status()
+		c.Header("Retry-After", "30")
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"code":       "DB_UNAVAILABLE",
+			"message":    "数据库不可用, 暂不允许写入",
+			"resilience": status,
+		})
+	}
+}
+
+// IsDegraded 当前请求是否处于降级模式
+func IsDegraded(c *gin.Context) bool {
+	return c.GetBool(degradedContextKey)
+}
+
+// degradedEntry 一个调用方最近一次正常认证的结果
+type degradedEntry struct {
+	auth             AuthContext
+	key              *model.ProjectKey // 认证通过的密钥记录, 降级期间用于校验过期时间和请求签名
+	requireSignature bool              // 项目是否要求请求签名
+	seenAt           time.Time
+}
+
+// degradedAuthCache 降级认证记录, 条目在 ttl 后失效, 超出 maxEntries 时淘汰最早的记录
+type degradedAuthCache struct {
+	mu         sync.RWMutex
+	entries    map[string]*degradedEntry
+	byKey      map[int64]map[string]bool // 密钥 ID -> 记录键, 密钥撤销时据此删除
+	ttl        time.Duration
+	maxEntries int
+}
+
+func (d *degradedAuthCache) put(credential string, entry *degradedEntry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.entries[credential]; !ok && len(d.entries) >= d.maxEntries {
+		d.evictLocked(entry.seenAt)
+	}
+	d.entries[credential] = entry
+	if keyID := entry.auth.AccessKeyID; keyID != 0 {
+		if d.byKey[keyID] == nil {
+			d.byKey[keyID] = make(map[string]bool)
+		}
+		d.byKey[keyID][credential] = true
+	}
+}
+
+// get 获取仍然有效的记录, 超过有效期或密钥已过期的记录随之删除
+func (d *degradedAuthCache) get(credential string, now time.Time) (*degradedEntry, bool) {
+	d.mu.RLock()
+	entry, ok := d.entries[credential]
+	d.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	if now.Sub(entry.seenAt) > d.ttl || (entry.key != nil && entry.key.ExpiresAt != nil && now.After(*entry.key.ExpiresAt)) {
+		d.remove(credential)
+		return nil, false
+	}
+	return entry, true
+}
+
+func (d *degradedAuthCache) remove(credential string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.removeLocked(credential)
+}
+
+// revokeKey 删除指定密钥的全部记录
+func (d *degradedAuthCache) revokeKey(keyID int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for credential := range d.byKey[keyID] {
+		d.removeLocked(credential)
+	}
+}
+
+func (d *degradedAuthCache) removeLocked(credential string) {
+	entry, ok := d.entries[credential]
+	if !ok {
+		return
+	}
+	delete(d.entries, credential)
+	if keyID := entry.auth.AccessKeyID; keyID != 0 {
+		delete(d.byKey[keyID], credential)
+		if len(d.byKey[keyID]) == 0 {
+			delete(d.byKey, keyID)
+		}
+	}
+}
+
+// evictLocked 清理已失效的记录, 仍然已满时淘汰最早的一条
+func (d *degradedAuthCache) evictLocked(now time.Time) {
+	var oldest string
+	var oldestAt time.Time
+	for credential, entry := range d.entries {
+		if now.Sub(entry.seenAt) > d.ttl {
+			d.removeLocked(credential)
+			continue
+		}
+		if oldest == "" || entry.seenAt.Before(oldestAt) {
+			oldest, oldestAt = credential, entry.seenAt
+		}
+	}
+	if len(d.entries) >= d.maxEntries {
+		d.removeLocked(oldest)
+	}
+}
+
+// DegradedAuth 公开读取接口的降级认证 (需在 OptionalAuth 之前使用)
+// 正常时记录读取成功的 Access Key (按客户端 IP) 和匿名调用方的认证结果, 认证失败时删除记录;
+// 降级时按记录恢复认证上下文、密钥记录和项目的签名要求, OptionalAuth 和 EnforceAccessMode 不再查询数据库,
+// SignatureAuth 据此照常校验签名; 没有记录的调用方返回 503
+// 记录自最近一次正常认证起 ttl 内有效, 且不超过密钥的过期时间; 密钥被禁用、删除或重新生成时立即删除
+func DegradedAuth(notifySvc *service.NotificationService, ttl time.Duration, maxEntries int) gin.HandlerFunc {
+	known := &degradedAuthCache{
+		entries:    make(map[string]*degradedEntry),
+		byKey:      make(map[int64]map[string]bool),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+	}
+	notifySvc.OnAccessKeyRevoked(known.revokeKey)
+
+	return func(c *gin.Context) {
+		credential := degradedCredential(c)
+
+		if !IsDegraded(c) {
+			c.Next()
+			if credential == "" || c.Request.Method != http.MethodGet {
+				return
+			}
+			switch status := c.Writer.Status(); {
+			case status == http.StatusUnauthorized || status == http.StatusForbidden:
+				known.remove(credential)
+				return
+			case status >= http.StatusBadRequest:
+				return
+			}
+			if authCtx := GetAuthContext(c); authCtx != nil && authCtx.ProjectID != 0 {
+				entry := &degradedEntry{auth: *authCtx, requireSignature: c.GetBool(signatureRequiredContextKey), seenAt: time.Now()}
+				if v, ok := c.Get(projectKeyContextKey); ok {
+					if key, ok := v.(*model.ProjectKey); ok && key.ID == authCtx.AccessKeyID {
+						entry.key = key
+					}
+				}
+				known.put(credential, entry)
+			}
+			return
+		}
+
+		entry, ok := known.get(credential, time.Now())
+		if credential == "" || !ok {
+			c.Header("Retry-After", "30")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"code":    "DB_UNAVAILABLE",
+				"message": "数据库不可用, 无法校验调用方身份",
+			})
+			return
+		}
+		authCtx := entry.auth
+		c.Set(AuthContextKey, &authCtx)
+		if entry.key != nil {
+			c.Set(projectKeyContextKey, entry.key)
+		}
+		c.Set(signatureRequiredContextKey, entry.requireSignature)
+		c.Next()
+	}
+}
+
+// degradedCredential 调用方凭据的记录键; 登录用户和监听令牌不参与降级认证, 返回空
+func degradedCredential(c *gin.Context) string {
+	if c.GetHeader("Authorization") != "" || c.GetHeader(WatchTokenHeader) != "" || c.Query("watch_token") != "" {
+		return ""
+	}
+	accessKey := c.GetHeader("X-Access-Key")
+	if accessKey == "" {
+		accessKey = c.Query("access_key")
+	}
+	if accessKey != "" {
+		return "key:" + accessKey + "@" + c.ClientIP()
+	}
+	if projectID := requestedProjectID(c); projectID != 0 {
+		return "anonymous:" + strconv.FormatInt(projectID, 10)
+	}
+	return ""
+}
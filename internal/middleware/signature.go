@@ -1,16 +1,23 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"confighub/internal/model"
+	"confighub/internal/service"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -23,71 +30,146 @@ const (
 	TimestampHeader = "X-Timestamp"
 	// NonceHeader 随机数头
 	NonceHeader = "X-Nonce"
+	// SignatureVersionHeader 签名版本头, 缺省为 v1
+	SignatureVersionHeader = "X-Signature-Version"
+	// ContentSHA256Header 请求体 SHA-256 摘要头 (v2)
+	ContentSHA256Header = "X-Content-SHA256"
 	// MaxTimeDiff 最大时间差 (5分钟)
 	MaxTimeDiff = 5 * 60
+	// SignatureAlgorithmV2 v2 签名算法标识, 作为待签名字符串首行
+	SignatureAlgorithmV2 = "CONFIGHUB-HMAC-SHA256-V2"
 )
 
-// SignatureAuth 签名认证中间件
-func SignatureAuth(db *gorm.DB) gin.HandlerFunc {
+// signedHeadersV2 v2 签名覆盖的请求头 (小写, 按字母排序)
+var signedHeadersV2 = []string{"host", "x-access-key", "x-content-sha256", "x-nonce", "x-timestamp"}
+
+// signatureRequiredContextKey 请求所属项目是否要求签名, 由 SignatureAuth 写入, 供 DegradedAuth 记录
+const signatureRequiredContextKey = "signature_required"
+
+// NonceWindow 随机数的保留时间: 覆盖时间戳前后各 MaxTimeDiff 的有效窗口
+const NonceWindow = 2 * MaxTimeDiff * time.Second
+
+// SignatureAuth 签名校验中间件 (需在 OptionalAuth 之后使用), 校验使用 Access Key 的请求所带的签名:
+// 开启了签名校验 (projectRequires) 的项目要求 v2 签名, 时间戳在有效窗口内, 且随机数 (X-Nonce) 在窗口内未被使用过;
+// 其他项目的请求带有签名 (v1 或 v2) 时同样校验, 不带签名或密钥早于签名校验创建 (无法还原 Secret Key) 时放行;
+// 登录用户、监听令牌和匿名调用方不校验签名; v2 签名读取的请求体不超过 maxBody 字节
+func SignatureAuth(db *gorm.DB, keySvc *service.KeyService, nonces *service.NonceStore, projectRequires func(ctx context.Context, projectID int64) bool, maxBody int64) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		accessKey := c.GetHeader("X-Access-Key")
-		if accessKey == "" {
-			accessKey = c.Query("access_key")
+		authCtx := GetAuthContext(c)
+		if authCtx == nil || authCtx.AccessKeyID == 0 {
+			c.Next()
+			return
+		}
+		// 降级期间无法读取项目设置, 使用 DegradedAuth 记录的故障前设置; 密钥记录同样来自 DegradedAuth, 签名照常校验
+		var required bool
+		if IsDegraded(c) {
+			required = c.GetBool(signatureRequiredContextKey)
+		} else {
+			required = projectRequires(c.Request.Context(), authCtx.ProjectID)
+			c.Set(signatureRequiredContextKey, required)
 		}
 
-		if accessKey == "" {
+		signature := c.GetHeader(SignatureHeader)
+		if signature == "" {
+			if !required {
+				c.Next()
+				return
+			}
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"code":    "UNAUTHORIZED",
-				"message": "缺少 Access Key",
+				"code":    "SIGNATURE_REQUIRED",
+				"message": "该项目要求请求签名",
 			})
 			return
 		}
-
-		signature := c.GetHeader(SignatureHeader)
-		timestamp := c.GetHeader(TimestampHeader)
-		nonce := c.GetHeader(NonceHeader)
-
-		// 如果没有签名头，跳过签名验证 (向后兼容)
-		if signature == "" {
-			c.Next()
+		v2 := c.GetHeader(SignatureVersionHeader) == "2"
+		if required && !v2 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    "INVALID_SIGNATURE",
+				"message": "该项目要求 v2 签名 (X-Signature-Version: 2)",
+			})
 			return
 		}
 
-		// 验证时间戳
-		ts, err := strconv.ParseInt(timestamp, 10, 64)
+		// 获取密钥, 优先使用认证时已读取的记录
+		key, err := projectKey(c, db, authCtx.AccessKeyID)
 		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "无效的 Access Key",
+			})
+			return
+		}
+		secret, ok := keySvc.SigningSecret(key)
+		if !ok {
+			if !required {
+				c.Next()
+				return
+			}
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"code":    "INVALID_SIGNATURE",
-				"message": "无效的时间戳",
+				"message": "该 Access Key 不支持签名校验, 请重新生成 Secret Key",
 			})
 			return
 		}
 
-		now := time.Now().Unix()
-		if abs(now-ts) > MaxTimeDiff {
+		// 验证时间戳
+		ts, err := strconv.ParseInt(c.GetHeader(TimestampHeader), 10, 64)
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"code":    "INVALID_SIGNATURE",
-				"message": "请求已过期",
+				"message": "无效的时间戳",
 			})
 			return
 		}
 
-		// 获取密钥
-		var key model.ProjectKey
-		if err := db.Where("access_key = ? AND is_active = ?", accessKey, true).First(&key).Error; err != nil {
+		// 返回服务端时间和偏差, 便于客户端校正时钟
+		now := time.Now().Unix()
+		if abs(now-ts) > MaxTimeDiff {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"code":    "UNAUTHORIZED",
-				"message": "无效的 Access Key",
+				"code":         "INVALID_SIGNATURE",
+				"message":      "请求已过期",
+				"server_time":  now,
+				"skew_seconds": ts - now,
+				"max_skew":     MaxTimeDiff,
 			})
 			return
 		}
 
-		// 构建签名字符串
-		stringToSign := buildStringToSign(c, accessKey, timestamp, nonce)
+		var nonce, stringToSign string
+		if v2 {
+			nonce = c.GetHeader(NonceHeader)
+			if nonce == "" {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					"code":    "INVALID_SIGNATURE",
+					"message": "缺少随机数 (X-Nonce)",
+				})
+				return
+			}
+
+			digest, err := bodyDigest(c, maxBody)
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+					"code":    "PAYLOAD_TOO_LARGE",
+					"message": fmt.Sprintf("签名请求的请求体超过 %d 字节", maxBody),
+				})
+				return
+			}
+			if err != nil || !hmac.Equal([]byte(digest), []byte(strings.ToLower(c.GetHeader(ContentSHA256Header)))) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					"code":    "INVALID_SIGNATURE",
+					"message": "请求体摘要不匹配",
+				})
+				return
+			}
+			stringToSign = buildStringToSignV2(c, digest)
+		} else {
+			stringToSign = buildStringToSignV1(c)
+		}
 
 		// 验证签名
-		expectedSignature := calculateSignature(stringToSign, key.SecretKeyHash)
-		if !hmac.Equal([]byte(signature), []byte(expectedSignature)) {
+		expectedSignature := calculateSignature(stringToSign, secret)
+		if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(expectedSignature)) {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"code":    "INVALID_SIGNATURE",
 				"message": "签名验证失败",
@@ -95,48 +177,98 @@ func SignatureAuth(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		// 签名通过后才记录随机数, 避免伪造的请求占用合法客户端的随机数; v1 签名不含随机数, 无法拒绝重放
+		if v2 && !nonces.Claim(c.Request.Context(), key.AccessKey, nonce) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    "NONCE_REUSED",
+				"message": "随机数已被使用, 请求可能被重放",
+			})
+			return
+		}
+
 		c.Next()
 	}
 }
 
-// buildStringToSign 构建待签名字符串
-func buildStringToSign(c *gin.Context, accessKey, timestamp, nonce string) string {
-	var parts []string
-
-	// HTTP 方法
-	parts = append(parts, c.Request.Method)
-
-	// 请求路径
-	parts = append(parts, c.Request.URL.Path)
-
-	// 查询参数 (按字母排序)
-	queryParams := c.Request.URL.Query()
-	var sortedKeys []string
-	for k := range queryParams {
-		if k != "signature" {
-			sortedKeys = append(sortedKeys, k)
+// projectKey 返回认证通过的 Access Key 记录, 上下文中没有时从数据库读取
+func projectKey(c *gin.Context, db *gorm.DB, id int64) (*model.ProjectKey, error) {
+	if v, ok := c.Get(projectKeyContextKey); ok {
+		if key, ok := v.(*model.ProjectKey); ok && key.ID == id {
+			return key, nil
 		}
 	}
-	sort.Strings(sortedKeys)
+	var key model.ProjectKey
+	if err := db.WithContext(c.Request.Context()).First(&key, id).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
 
-	var queryParts []string
-	for _, k := range sortedKeys {
-		for _, v := range queryParams[k] {
-			queryParts = append(queryParts, k+"="+v)
+// buildStringToSignV1 构建 v1 待签名字符串: 时间戳 + 方法 + 路径 (+ "?" + 原始查询串), 与 SDK 的默认签名一致
+func buildStringToSignV1(c *gin.Context) string {
+	message := c.GetHeader(TimestampHeader) + c.Request.Method + c.Request.URL.Path
+	if c.Request.URL.RawQuery != "" {
+		message += "?" + c.Request.URL.RawQuery
+	}
+	return message
+}
+
+// buildStringToSignV2 构建 v2 待签名字符串
+// 格式: 算法 \n 方法 \n 路径 \n 规范化查询串 \n 规范化请求头 \n 签名头列表 \n 请求体摘要
+func buildStringToSignV2(c *gin.Context, digest string) string {
+	var headers []string
+	for _, name := range signedHeadersV2 {
+		value := c.GetHeader(name)
+		if name == "host" {
+			value = c.Request.Host
 		}
+		headers = append(headers, name+":"+strings.TrimSpace(value))
 	}
-	parts = append(parts, strings.Join(queryParts, "&"))
 
-	// Access Key
-	parts = append(parts, accessKey)
+	return strings.Join([]string{
+		SignatureAlgorithmV2,
+		c.Request.Method,
+		c.Request.URL.EscapedPath(),
+		canonicalQuery(c.Request.URL.Query()),
+		strings.Join(headers, "\n"),
+		strings.Join(signedHeadersV2, ";"),
+		digest,
+	}, "\n")
+}
 
-	// 时间戳
-	parts = append(parts, timestamp)
+// canonicalQuery 规范化查询串: 键值按 RFC 3986 编码, 先按键再按值排序
+func canonicalQuery(query url.Values) string {
+	var pairs []string
+	for k, values := range query {
+		if k == "signature" {
+			continue
+		}
+		for _, v := range values {
+			pairs = append(pairs, rfc3986Escape(k)+"="+rfc3986Escape(v))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
 
-	// 随机数
-	parts = append(parts, nonce)
+// rfc3986Escape 按 RFC 3986 编码 (空格编码为 %20, 保留 ~)
+func rfc3986Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
 
-	return strings.Join(parts, "\n")
+// bodyDigest 计算请求体 SHA-256 摘要 (十六进制小写), 并恢复请求体供后续读取; 请求体超过 maxBody 字节时返回 *http.MaxBytesError
+func bodyDigest(c *gin.Context, maxBody int64) (string, error) {
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		body, err = io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBody))
+		if err != nil {
+			return "", err
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
 }
 
 // calculateSignature 计算签名
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestErrors 记录公开接口的服务端错误 (5xx), 计入统计概览的近期错误数
+func RequestErrors(statsSvc *service.StatsService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Writer.Status() < http.StatusInternalServerError {
+			return
+		}
+		var projectID int64
+		if authCtx := GetAuthContext(c); authCtx != nil {
+			projectID = authCtx.ProjectID
+		}
+		statsSvc.RecordError(projectID)
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StreamDeadline 为长连接请求覆盖服务器的全局读写超时
+// 全局 ReadTimeout/WriteTimeout 从连接读取请求时开始计时, 会在长轮询返回前断开连接或取消请求;
+// timeout 大于 0 时将读写截止时间延长到当前时间之后 timeout, 否则取消截止时间 (用于 SSE)
+func StreamDeadline(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var deadline time.Time
+		if timeout > 0 {
+			deadline = time.Now().Add(timeout)
+		}
+		rc := http.NewResponseController(c.Writer)
+		rc.SetReadDeadline(deadline)
+		rc.SetWriteDeadline(deadline)
+		c.Next()
+	}
+}
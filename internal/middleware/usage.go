@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"time"
+
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Usage 用量计量中间件
+// 按认证上下文中的项目统计公开接口调用次数, 匿名访问的公开项目同样计入; 使用密钥时记录密钥的最近使用时间
+func Usage(usageSvc *service.UsageService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if authCtx := GetAuthContext(c); authCtx != nil {
+			usageSvc.RecordCall(authCtx.ProjectID)
+			usageSvc.RecordKeyUse(authCtx.AccessKeyID)
+		}
+	}
+}
+
+// WatcherUsage 监听时长计量中间件, 用于长轮询等保持连接的接口
+func WatcherUsage(usageSvc *service.UsageService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		if authCtx := GetAuthContext(c); authCtx != nil {
+			usageSvc.RecordWatch(authCtx.ProjectID, time.Since(start))
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WatchAdmission 监听准入中间件, 需放在认证之后
+// 负载保护期间按调用方 Access Key 的监听优先级限流新的监听请求, high 密钥不受限制
+func WatchAdmission(notifySvc *service.NotificationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var priority string
+		if authCtx := GetAuthContext(c); authCtx != nil {
+			priority = authCtx.Priority
+		}
+
+		if ok, wait := notifySvc.AdmitWatch(priority); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"code":    "WATCH_THROTTLED",
+				"message": "监听连接过多, 请稍后重试",
+			})
+			return
+		}
+		c.Next()
+	}
+}
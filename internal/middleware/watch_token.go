@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+
+	"confighub/internal/model"
+	"confighub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WatchTokenHeader 监听令牌头
+const WatchTokenHeader = "X-Watch-Token"
+
+// WatchTokenAuth 监听令牌认证中间件
+// 请求携带监听令牌时以令牌身份 (只读, 配置范围与签发密钥一致) 替换认证上下文; 未携带时保持原有认证结果
+func WatchTokenAuth(watchTokenSvc *service.WatchTokenService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetHeader(WatchTokenHeader)
+		if token == "" {
+			token = c.Query("watch_token")
+		}
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		claims, err := watchTokenSvc.Verify(c.Request.Context(), token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    "INVALID_WATCH_TOKEN",
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.Set(AuthContextKey, &AuthContext{
+			AccessKeyID: claims.AccessKeyID,
+			ProjectID:   claims.ProjectID,
+			Permissions: model.Permissions{Read: true, Configs: claims.Configs},
+			Priority:    claims.Priority,
+		})
+		c.Next()
+	}
+}
@@ -8,15 +8,17 @@ import (
 type AuditLog struct {
 	ID           int64     `json:"id" gorm:"primaryKey;autoIncrement"`
 	ProjectID    int64     `json:"project_id" gorm:"index"`
-	UserID       *int64    `json:"user_id,omitempty"`
-	AccessKeyID  *int64    `json:"access_key_id,omitempty"`
+	UserID       *int64    `json:"user_id,omitempty" gorm:"index:idx_audit_user"`
+	AccessKeyID  *int64    `json:"access_key_id,omitempty" gorm:"index:idx_audit_key"`
 	Action       string    `json:"action" gorm:"type:varchar(50);index;not null"` // create, read, update, delete, release, login
 	ResourceType string    `json:"resource_type" gorm:"type:varchar(50);not null"` // project, config, key, release
 	ResourceID   int64     `json:"resource_id"`
-	ResourceName string    `json:"resource_name" gorm:"type:varchar(200)"`
+	ResourceName string    `json:"resource_name" gorm:"type:varchar(200);index:idx_audit_resource_name"`
 	IPAddress    string    `json:"ip_address" gorm:"type:varchar(45)"`
 	UserAgent    string    `json:"user_agent" gorm:"type:varchar(500)"`
 	RequestBody  string    `json:"request_body,omitempty" gorm:"type:text"`
+	StatusCode   int       `json:"status_code" gorm:"index:idx_audit_status;default:0"` // 操作的 HTTP 响应状态码
+	Origin       string    `json:"origin,omitempty" gorm:"type:varchar(100);index:idx_audit_origin"` // 配置变更来源, 见 OriginHuman 等
 	CreatedAt    time.Time `json:"created_at" gorm:"index;autoCreateTime"`
 }
 
@@ -27,19 +29,35 @@ func (AuditLog) TableName() string {
 
 // AuditAction 审计动作常量
 const (
-	AuditActionCreate  = "create"
-	AuditActionRead    = "read"
-	AuditActionUpdate  = "update"
-	AuditActionDelete  = "delete"
-	AuditActionRelease = "release"
-	AuditActionLogin   = "login"
+	AuditActionCreate    = "create"
+	AuditActionRead      = "read"
+	AuditActionUpdate    = "update"
+	AuditActionDelete    = "delete"
+	AuditActionRelease   = "release"
+	AuditActionLogin     = "login"
+	AuditActionArchive   = "archive"
+	AuditActionUnarchive = "unarchive"
+	AuditActionApprove   = "approve"
+	AuditActionReject    = "reject"
+	AuditActionSync      = "sync"
+	AuditActionExport    = "export"
+	AuditActionImport    = "import"
+	AuditActionReplay    = "replay"
+	AuditActionTransfer  = "transfer"
+	AuditActionAccept    = "accept"
+	AuditActionCancel    = "cancel"
+	AuditActionDecrypt   = "decrypt"
 )
 
 // AuditResourceType 审计资源类型常量
 const (
-	AuditResourceProject = "project"
-	AuditResourceConfig  = "config"
-	AuditResourceKey     = "key"
-	AuditResourceRelease = "release"
-	AuditResourceUser    = "user"
+	AuditResourceProject     = "project"
+	AuditResourceConfig      = "config"
+	AuditResourceKey         = "key"
+	AuditResourceRelease     = "release"
+	AuditResourceUser        = "user"
+	AuditResourceIntegration = "integration"
+	AuditResourceWebhook     = "webhook"
+	AuditResourceSigningKey  = "signing_key"
+	AuditResourceTransfer    = "transfer"
 )
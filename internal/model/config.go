@@ -13,7 +13,10 @@ type Config struct {
 	Environment     string    `json:"environment" gorm:"type:varchar(50);default:default"`
 	FileType        string    `json:"file_type" gorm:"type:varchar(20);not null"` // json, protobuf, yaml
 	SchemaJSON      string    `json:"schema_json,omitempty" gorm:"type:json"`
+	SchemaPolicy    string    `json:"schema_policy,omitempty" gorm:"type:varchar(10)"`        // off, warn, block; 为空时继承项目的 schema_defaults.enforce
 	DefaultEditMode string    `json:"default_edit_mode" gorm:"type:varchar(10);default:code"` // code, form
+	DiffRules       string    `json:"diff_rules,omitempty" gorm:"type:json"`                  // 对比忽略规则
+	ParentID        *int64    `json:"parent_id,omitempty" gorm:"index"`                       // 继承的父配置, 下发内容为父配置链与自身内容的深度合并
 	CurrentVersion  int       `json:"current_version" gorm:"default:1"`
 	CreatedAt       time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt       time.Time `json:"updated_at" gorm:"autoUpdateTime"`
@@ -24,6 +27,13 @@ func (Config) TableName() string {
 	return "configs"
 }
 
+// Schema 校验策略: 修改和发布配置时按生效的 Schema 校验内容
+const (
+	SchemaPolicyOff   = "off"   // 不校验
+	SchemaPolicyWarn  = "warn"  // 允许写入和发布, 在响应中标记不符合项
+	SchemaPolicyBlock = "block" // 不符合 Schema 的写入和发布被拒绝
+)
+
 // ConfigVersion 配置版本
 type ConfigVersion struct {
 	ID            int64     `json:"id" gorm:"primaryKey;autoIncrement"`
@@ -33,7 +43,11 @@ type ConfigVersion struct {
 	CommitHash    string    `json:"commit_hash" gorm:"type:varchar(64);index"`
 	CommitMessage string    `json:"commit_message" gorm:"type:varchar(500)"`
 	Author        string    `json:"author" gorm:"type:varchar(100)"`
+	Origin        string    `json:"origin" gorm:"type:varchar(100);index;not null;default:''"` // 变更来源: human, api, ci, sync, automation:<name>
+	GitRef        string    `json:"git_ref,omitempty" gorm:"type:varchar(300)"`                // 内容保存在 Git 仓库时为 <提交>:<路径>, content 为空
 	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	Signature *SignatureStatus `json:"signature,omitempty" gorm:"-"` // 签名验证状态, 仅版本列表返回
 }
 
 // TableName 表名
@@ -41,6 +55,15 @@ func (ConfigVersion) TableName() string {
 	return "config_versions"
 }
 
+// 配置变更来源, 历史版本为空表示未知
+const (
+	OriginHuman            = "human"       // 登录用户通过控制台或管理接口修改
+	OriginAPI              = "api"         // 使用 Access Key 调用公开接口
+	OriginCI               = "ci"          // CI/CD 流水线
+	OriginSync             = "sync"        // 环境克隆、跨实例复制等同步
+	OriginAutomationPrefix = "automation:" // 具名自动化工具, 如 automation:renovate、入站集成
+)
+
 // ConfigNotification 配置变更通知
 type ConfigNotification struct {
 	ID         int64     `json:"id" gorm:"primaryKey;autoIncrement"`
@@ -48,6 +71,12 @@ type ConfigNotification struct {
 	Version    int       `json:"version" gorm:"not null"`
 	ChangeType string    `json:"change_type" gorm:"type:varchar(20);not null"` // create, update, delete, release
 	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	// 事件发生时配置的归属, 配置删除后仍可按命名空间和环境补发删除事件; 早期事件为空, 查询时取自 configs 表
+	ProjectID   int64  `json:"project_id" gorm:"index"`
+	ConfigName  string `json:"config_name" gorm:"type:varchar(200)"`
+	Namespace   string `json:"namespace" gorm:"type:varchar(100)"`
+	Environment string `json:"environment" gorm:"type:varchar(50)"`
 }
 
 // TableName 表名
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+)
+
+// ConfigContract 配置消费契约: 消费方声明其依赖的键及类型, 写入或发布破坏契约时被拦截或标记
+type ConfigContract struct {
+	ID          int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	ConfigID    int64     `json:"config_id" gorm:"uniqueIndex:idx_contract_config_consumer;not null"`
+	Consumer    string    `json:"consumer" gorm:"type:varchar(100);uniqueIndex:idx_contract_config_consumer;not null"` // 消费方服务名
+	Keys        string    `json:"keys" gorm:"column:required_keys;type:json"`                                          // [{"path": "db.port", "type": "integer"}]
+	Enforcement string    `json:"enforcement" gorm:"type:varchar(10);default:block"`                                   // block, warn
+	AccessKeyID *int64    `json:"access_key_id,omitempty"`                                                             // 通过 Access Key 注册时记录
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 表名
+func (ConfigContract) TableName() string {
+	return "config_contracts"
+}
+
+// 契约执行方式
+const (
+	ContractEnforcementBlock = "block" // 破坏契约的写入和发布被拒绝
+	ContractEnforcementWarn  = "warn"  // 允许写入和发布, 在响应中标记
+)
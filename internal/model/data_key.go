@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// ProjectDataKey 项目的数据加密密钥 (DEK), 以主密钥 (encrypt.key) 包装后保存
+// 项目的 ENC: 值使用最新创建的数据密钥加密, 轮换后旧密钥保留, 用于解密尚未重新加密的值
+type ProjectDataKey struct {
+	ID         int64     `json:"-" gorm:"primaryKey;autoIncrement"`
+	ProjectID  int64     `json:"project_id" gorm:"uniqueIndex:idx_project_data_key;not null"`
+	KeyID      string    `json:"key_id" gorm:"type:varchar(32);uniqueIndex:idx_project_data_key;not null"` // 写入加密值的密钥标识
+	WrappedKey string    `json:"-" gorm:"type:varchar(255);not null"`
+	Primary    bool      `json:"primary" gorm:"-"` // 当前用于加密的密钥
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName 表名
+func (ProjectDataKey) TableName() string {
+	return "project_data_keys"
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+)
+
+// GrayExposure 灰度实验曝光记录 (记录客户端在灰度期间收到的版本)
+type GrayExposure struct {
+	ID          int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	ProjectID   int64     `json:"project_id" gorm:"index;not null"`
+	ConfigID    int64     `json:"config_id" gorm:"index;not null"`
+	ReleaseID   int64     `json:"release_id" gorm:"uniqueIndex:idx_exposure_release_client_variant;not null"`
+	ClientID    string    `json:"client_id" gorm:"type:varchar(100);uniqueIndex:idx_exposure_release_client_variant;index;not null"`
+	Variant     string    `json:"variant" gorm:"type:varchar(20);uniqueIndex:idx_exposure_release_client_variant;not null"` // gray, stable
+	Environment string    `json:"environment" gorm:"type:varchar(50)"`
+	Version     int       `json:"version"`
+	IPAddress   string    `json:"ip_address" gorm:"type:varchar(45)"`
+	HitCount    int64     `json:"hit_count" gorm:"default:1"`
+	FirstSeenAt time.Time `json:"first_seen_at" gorm:"index;autoCreateTime"`
+	LastSeenAt  time.Time `json:"last_seen_at" gorm:"index"`
+}
+
+// TableName 表名
+func (GrayExposure) TableName() string {
+	return "gray_exposures"
+}
+
+// 灰度实验分组
+const (
+	VariantGray   = "gray"
+	VariantStable = "stable"
+)
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+)
+
+// InboundIntegration 入站集成: 外部系统 (如 CMDB、定价服务) 推送数据, 经映射模板生成指定配置的新版本
+type InboundIntegration struct {
+	ID             int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	ProjectID      int64      `json:"project_id" gorm:"index;not null"`
+	ConfigID       int64      `json:"config_id" gorm:"index;not null"`
+	Name           string     `json:"name" gorm:"type:varchar(100);not null"`
+	TokenPrefix    string     `json:"token_prefix" gorm:"type:varchar(16)"` // 令牌前缀, 便于识别
+	TokenHash      string     `json:"-" gorm:"type:varchar(128);not null"`
+	Template       string     `json:"template" gorm:"type:text"` // Go text/template, 为空时直接使用推送内容
+	IsActive       bool       `json:"is_active" gorm:"default:true"`
+	CreatedBy      int64      `json:"created_by"`
+	LastReceivedAt *time.Time `json:"last_received_at,omitempty"`
+	LastVersion    int        `json:"last_version"` // 最近一次推送生成的版本号
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 表名
+func (InboundIntegration) TableName() string {
+	return "inbound_integrations"
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"strings"
 	"time"
 )
 
@@ -11,12 +12,18 @@ type ProjectKey struct {
 	Name          string     `json:"name" gorm:"type:varchar(100)"`
 	AccessKey     string     `json:"access_key" gorm:"type:varchar(64);uniqueIndex;not null"`
 	SecretKeyHash string     `json:"-" gorm:"type:varchar(128);not null"`
+	SecretKeyEnc  string     `json:"-" gorm:"type:varchar(255)"`   // 加密保存的 Secret Key, 用于校验请求签名; 早于签名校验创建的密钥为空
 	Permissions   string     `json:"permissions" gorm:"type:json"` // {"read": true, "write": false, ...}
 	IPWhitelist   string     `json:"ip_whitelist,omitempty" gorm:"type:json"`
 	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
 	IsActive      bool       `json:"is_active" gorm:"default:true"`
+	Priority      string     `json:"priority" gorm:"type:varchar(20);not null;default:'normal'"` // 监听优先级: high, normal, batch
+	ResolveTiming bool       `json:"resolve_timing" gorm:"default:false"`                        // 公开读取响应是否携带 X-Resolve-* 耗时分解
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty"`                                     // 最近一次调用公开接口的时间, 每分钟写入一次
 	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt     time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	SignatureReady bool `json:"signature_ready" gorm:"-"` // 能否校验该密钥的请求签名, 为 false 时需重新生成 Secret Key
 }
 
 // TableName 表名
@@ -24,6 +31,22 @@ func (ProjectKey) TableName() string {
 	return "project_keys"
 }
 
+// 密钥的监听优先级, 负载过高时决定监听连接的准入和变更投递顺序
+const (
+	KeyPriorityHigh   = "high"   // 不限流, 变更最先投递, 用于支付等关键服务
+	KeyPriorityNormal = "normal" // 默认
+	KeyPriorityBatch  = "batch"  // 负载过高时最先限流, 变更延后批量投递
+)
+
+// ValidKeyPriority 是否为有效的监听优先级
+func ValidKeyPriority(priority string) bool {
+	switch priority {
+	case KeyPriorityHigh, KeyPriorityNormal, KeyPriorityBatch:
+		return true
+	}
+	return false
+}
+
 // Permissions 权限结构
 type Permissions struct {
 	Read    bool `json:"read"`
@@ -32,6 +55,25 @@ type Permissions struct {
 	Release bool `json:"release"`
 	Admin   bool `json:"admin"`
 	Decrypt bool `json:"decrypt"`
+	// Configs 可访问的配置名称, 以 * 结尾表示前缀匹配 (如 payments/*); 为空表示项目内全部配置
+	Configs []string `json:"configs,omitempty"`
+}
+
+// AllowsConfig 是否允许访问指定名称的配置
+func (p Permissions) AllowsConfig(name string) bool {
+	if len(p.Configs) == 0 {
+		return true
+	}
+	for _, pattern := range p.Configs {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if pattern == name {
+			return true
+		}
+	}
+	return false
 }
 
 // DefaultPermissions 默认权限
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+)
+
+// ConfigKey kv 配置的单个键: 每个键一行, 与配置最新版本的内容保持一致
+// kv 配置的版本内容是值均为字符串的 JSON 对象, 发布、回滚、监听等仍以整个配置的版本为单位
+type ConfigKey struct {
+	ID            int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	ConfigID      int64     `json:"config_id" gorm:"uniqueIndex:idx_config_key;not null"`
+	Key           string    `json:"key" gorm:"column:key_name;type:varchar(200);uniqueIndex:idx_config_key;not null"`
+	Value         string    `json:"value" gorm:"type:text"`
+	Revision      int       `json:"revision" gorm:"not null"`       // 键自身的修订号, 每次修改加 1, 删除后重新创建时继续递增
+	ConfigVersion int       `json:"config_version" gorm:"not null"` // 最近一次修改该键的配置版本
+	UpdatedBy     string    `json:"updated_by" gorm:"type:varchar(100)"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 表名
+func (ConfigKey) TableName() string {
+	return "config_keys"
+}
+
+// ConfigKeyHistory kv 配置单个键的修改历史
+type ConfigKeyHistory struct {
+	ID            int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	ConfigID      int64     `json:"config_id" gorm:"index:idx_config_key_history;not null"`
+	Key           string    `json:"key" gorm:"column:key_name;type:varchar(200);index:idx_config_key_history;not null"`
+	Revision      int       `json:"revision" gorm:"not null"`
+	Value         string    `json:"value,omitempty" gorm:"type:text"`
+	Deleted       bool      `json:"deleted" gorm:"default:false"`
+	ConfigVersion int       `json:"config_version" gorm:"not null"`
+	Author        string    `json:"author" gorm:"type:varchar(100)"`
+	Message       string    `json:"message,omitempty" gorm:"type:varchar(500)"`
+	Origin        string    `json:"origin,omitempty" gorm:"type:varchar(100)"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName 表名
+func (ConfigKeyHistory) TableName() string {
+	return "config_key_history"
+}
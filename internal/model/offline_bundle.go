@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+)
+
+// 离线配置包格式与签名算法
+const (
+	OfflineBundleFormat    = "confighub-offline-bundle/v1"
+	OfflineBundleAlgorithm = "ed25519"
+)
+
+// OfflineBundle 签名的离线配置包, 签名覆盖 Payload 解码后的原始字节
+type OfflineBundle struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id"`
+	Payload   string `json:"payload"`   // OfflineBundlePayload 的 JSON, base64 编码
+	Signature string `json:"signature"` // base64 编码
+}
+
+// OfflineBundlePayload 离线配置包内容, 超过 ExpiresAt 后 SDK 拒绝使用
+type OfflineBundlePayload struct {
+	Format      string                `json:"format"`
+	ProjectID   int64                 `json:"project_id"`
+	Namespace   string                `json:"namespace"`
+	Environment string                `json:"environment"`
+	IssuedAt    time.Time             `json:"issued_at"`
+	ExpiresAt   time.Time             `json:"expires_at"`
+	Configs     []OfflineBundleConfig `json:"configs"`
+}
+
+// OfflineBundleConfig 离线配置包中的配置, 内容为所在环境最新正式发布的版本
+type OfflineBundleConfig struct {
+	Name        string    `json:"name"`
+	Namespace   string    `json:"namespace"`
+	Environment string    `json:"environment"`
+	Version     int       `json:"version"`
+	Content     string    `json:"content"`
+	ContentHash string    `json:"content_hash,omitempty"`
+	ReleaseID   int64     `json:"release_id"`
+	ReleaseType string    `json:"release_type"`
+	ReleasedAt  time.Time `json:"released_at"`
+}
@@ -6,18 +6,19 @@ import (
 
 // Project 项目
 type Project struct {
-	ID                int64     `json:"id" gorm:"primaryKey;autoIncrement"`
-	Name              string    `json:"name" gorm:"type:varchar(100);uniqueIndex;not null"`
-	Description       string    `json:"description" gorm:"type:text"`
-	AccessMode        string    `json:"access_mode" gorm:"type:varchar(20);default:key"` // public, key, auth
-	PublicPermissions string    `json:"public_permissions" gorm:"type:json"`
-	Settings          string    `json:"settings,omitempty" gorm:"type:json"`
-	GitRepoURL        string    `json:"git_repo_url,omitempty" gorm:"type:varchar(500)"`
-	GitBranch         string    `json:"git_branch,omitempty" gorm:"type:varchar(100);default:main"`
-	WebhookSecret     string    `json:"-" gorm:"type:varchar(128)"`
-	CreatedBy         int64     `json:"created_by" gorm:"index"`
-	CreatedAt         time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt         time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID                int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name              string     `json:"name" gorm:"type:varchar(100);uniqueIndex;not null"`
+	Description       string     `json:"description" gorm:"type:text"`
+	AccessMode        string     `json:"access_mode" gorm:"type:varchar(20);default:key"` // public, key, auth
+	PublicPermissions string     `json:"public_permissions" gorm:"type:json"`
+	Settings          string     `json:"settings,omitempty" gorm:"type:json"`
+	GitRepoURL        string     `json:"git_repo_url,omitempty" gorm:"type:varchar(500)"`
+	GitBranch         string     `json:"git_branch,omitempty" gorm:"type:varchar(100);default:main"`
+	WebhookSecret     string     `json:"-" gorm:"type:varchar(128)"`
+	CreatedBy         int64      `json:"created_by" gorm:"index"`
+	ArchivedAt        *time.Time `json:"archived_at,omitempty" gorm:"index"` // 归档时间, 归档后只读且默认不在列表中显示
+	CreatedAt         time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt         time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
 // TableName 表名
@@ -25,6 +26,18 @@ func (Project) TableName() string {
 	return "projects"
 }
 
+// 项目访问模式
+const (
+	AccessModePublic = "public" // 允许匿名读取, 权限由 PublicPermissions 决定
+	AccessModeKey    = "key"    // 需要 Access Key
+	AccessModeAuth   = "auth"   // 需要登录用户且为项目成员
+)
+
+// IsArchived 项目是否已归档
+func (p *Project) IsArchived() bool {
+	return p.ArchivedAt != nil
+}
+
 // ProjectEnvironment 项目环境
 type ProjectEnvironment struct {
 	ID          int64     `json:"id" gorm:"primaryKey;autoIncrement"`
@@ -32,6 +45,7 @@ type ProjectEnvironment struct {
 	Name        string    `json:"name" gorm:"type:varchar(50);not null"`
 	Description string    `json:"description" gorm:"type:varchar(200)"`
 	SortOrder   int       `json:"sort_order" gorm:"default:0"`
+	Variables   string    `json:"variables,omitempty" gorm:"type:json"` // 环境变量 {"REGION": "cn-north-1"}, 配置中以 ${env:REGION} 引用
 	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
 }
 
@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+)
+
+// ProjectBundleFormat 项目导入导出包格式
+const ProjectBundleFormat = "confighub-project-bundle/v1"
+
+// ProjectBundle 项目导入导出包, 用于在实例之间迁移项目及备份
+// 内容与复制快照一致, 以名称而非 ID 关联数据; 默认不包含访问密钥
+type ProjectBundle struct {
+	Format     string    `json:"format"`
+	ExportedAt time.Time `json:"exported_at"`
+	ExportedBy string    `json:"exported_by,omitempty"`
+	ReplicationSnapshot
+}
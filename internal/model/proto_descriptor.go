@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+)
+
+// ProtoDescriptor protobuf 配置的描述符, 用于校验配置内容并渲染为 JSON
+// 描述符集为 protoc --include_imports --descriptor_set_out 生成的 FileDescriptorSet
+type ProtoDescriptor struct {
+	ID            int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	ProjectID     int64     `json:"project_id" gorm:"index;not null"`
+	ConfigID      int64     `json:"config_id" gorm:"uniqueIndex;not null"`
+	MessageType   string    `json:"message_type" gorm:"type:varchar(255);not null"` // 配置内容对应的消息全名, 如 acme.app.v1.AppConfig
+	DescriptorSet string    `json:"descriptor_set" gorm:"type:longtext;not null"`   // base64 编码的 FileDescriptorSet
+	UpdatedBy     int64     `json:"updated_by"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 表名
+func (ProtoDescriptor) TableName() string {
+	return "proto_descriptors"
+}
@@ -11,12 +11,21 @@ type Release struct {
 	ConfigID       int64     `json:"config_id" gorm:"index;not null"`
 	Version        int       `json:"version" gorm:"not null"`
 	Environment    string    `json:"environment" gorm:"type:varchar(50);not null"`
-	Status         string    `json:"status" gorm:"type:varchar(20);default:released"` // pending, released, rollback, gray
+	Status         string    `json:"status" gorm:"type:varchar(20);default:released"` // pending, released, rollback, gray, rejected
 	ReleaseType    string    `json:"release_type" gorm:"type:varchar(10);default:full"` // full, gray
 	GrayRules      string    `json:"gray_rules,omitempty" gorm:"type:json"`
 	GrayPercentage int       `json:"gray_percentage,omitempty" gorm:"default:0"`
 	ReleasedBy     string    `json:"released_by" gorm:"type:varchar(100)"`
 	ReleasedAt     time.Time `json:"released_at" gorm:"autoCreateTime"`
+
+	RiskScore         int    `json:"risk_score" gorm:"default:0"`                   // 变更风险分 0-100
+	RiskLevel         string `json:"risk_level,omitempty" gorm:"type:varchar(10)"`  // low, medium, high
+	RiskFactors       string `json:"risk_factors,omitempty" gorm:"type:json"`       // 风险因素明细
+	RequiredApprovals int    `json:"required_approvals,omitempty" gorm:"default:0"` // 按风险策略需要的审批人数, 审批通过前状态为 pending
+
+	Artifact     string `json:"-" gorm:"type:longtext"`                          // 发布流水线生成的产物, 为空表示下发版本原始内容
+	ArtifactHash string `json:"artifact_hash,omitempty" gorm:"type:varchar(64)"` // 产物内容哈希, 下发时作为 content_hash
+	PipelineLog  string `json:"pipeline_log,omitempty" gorm:"type:text"`         // 流水线各步骤的执行记录 (JSON)
 }
 
 // TableName 表名
@@ -24,10 +33,35 @@ func (Release) TableName() string {
 	return "releases"
 }
 
+// ReleaseApproval 发布审批记录
+type ReleaseApproval struct {
+	ID        int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	ReleaseID int64     `json:"release_id" gorm:"uniqueIndex:idx_approval_release_user;not null"`
+	UserID    int64     `json:"user_id" gorm:"uniqueIndex:idx_approval_release_user;not null"`
+	Comment   string    `json:"comment,omitempty" gorm:"type:varchar(500)"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName 表名
+func (ReleaseApproval) TableName() string {
+	return "release_approvals"
+}
+
+// ReleaseEnvAll 跨环境灰度发布的环境标识 (按 GrayRules.EnvPercentages 分阶段生效)
+const ReleaseEnvAll = "*"
+
+// GrayTarget 分阶段灰度在一个环境中灰度的配置及版本
+type GrayTarget struct {
+	ConfigID int64 `json:"config_id"`
+	Version  int   `json:"version"`
+}
+
 // GrayRules 灰度发布规则
 type GrayRules struct {
-	Type       string   `json:"type"`                  // percentage, client_id, ip_range
-	Percentage int      `json:"percentage,omitempty"`
-	ClientIDs  []string `json:"client_ids,omitempty"`
-	IPRanges   []string `json:"ip_ranges,omitempty"`
+	Type           string                `json:"type"` // percentage, client_id, ip_range
+	Percentage     int                   `json:"percentage,omitempty"`
+	EnvPercentages map[string]int        `json:"env_percentages,omitempty"` // 按环境设置百分比, 如 {"staging": 50, "prod": 5}
+	EnvTargets     map[string]GrayTarget `json:"env_targets,omitempty"`     // 各环境灰度的配置及版本 (配置按环境分行保存)
+	ClientIDs      []string              `json:"client_ids,omitempty"`
+	IPRanges       []string              `json:"ip_ranges,omitempty"`
 }
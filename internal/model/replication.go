@@ -0,0 +1,127 @@
+package model
+
+import (
+	"time"
+)
+
+// 复制冲突策略: 同一版本号在两个实例上内容不同时的处理方式
+const (
+	ReplicationSourceWins = "source_wins" // 以复制源为准, 覆盖本地
+	ReplicationTargetWins = "target_wins" // 保留本地, 仅记录冲突
+	ReplicationNewerWins  = "newer_wins"  // 以创建时间较新的一方为准
+)
+
+// 复制模式
+const (
+	ReplicationModePush    = "push"    // 主实例定时推送快照到副本
+	ReplicationModePull    = "pull"    // 副本定时从主实例拉取快照
+	ReplicationModeReceive = "receive" // 副本接收主实例的推送, 自身不主动同步
+)
+
+// ReplicationSnapshot 项目复制快照, 跨实例以名称而非 ID 关联数据
+type ReplicationSnapshot struct {
+	GeneratedAt  time.Time               `json:"generated_at"`
+	Project      ReplicatedProject       `json:"project"`
+	Environments []ReplicatedEnvironment `json:"environments"`
+	Keys         []ReplicatedKey         `json:"keys"`
+	DataKeys     []ReplicatedDataKey     `json:"data_keys,omitempty"`
+	Configs      []ReplicatedConfig      `json:"configs"`
+}
+
+// ReplicatedProject 复制的项目信息
+type ReplicatedProject struct {
+	Name              string     `json:"name"`
+	Description       string     `json:"description"`
+	AccessMode        string     `json:"access_mode"`
+	PublicPermissions string     `json:"public_permissions"`
+	Settings          string     `json:"settings"`
+	ArchivedAt        *time.Time `json:"archived_at,omitempty"`
+}
+
+// ReplicatedEnvironment 复制的环境
+type ReplicatedEnvironment struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	SortOrder   int    `json:"sort_order"`
+	Variables   string `json:"variables,omitempty"`
+}
+
+// ReplicatedKey 复制的访问密钥, 使副本可在本地完成客户端鉴权
+type ReplicatedKey struct {
+	Name          string     `json:"name"`
+	AccessKey     string     `json:"access_key"`
+	SecretKeyHash string     `json:"secret_key_hash"`
+	SecretKeyEnc  string     `json:"secret_key_enc,omitempty"` // 加密的 Secret Key, 两端使用相同的 encrypt.key 时副本也能校验签名
+	Permissions   string     `json:"permissions"`
+	IPWhitelist   string     `json:"ip_whitelist,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	IsActive      bool       `json:"is_active"`
+	Priority      string     `json:"priority,omitempty"` // 早于监听优先级的主实例不发送, 视为 normal
+	ResolveTiming bool       `json:"resolve_timing,omitempty"`
+}
+
+// ReplicatedDataKey 复制的项目数据密钥 (以主密钥包装), 两端使用相同的 encrypt.key 时副本也能解密配置中的 ENC: 值
+type ReplicatedDataKey struct {
+	KeyID      string    `json:"key_id"`
+	WrappedKey string    `json:"wrapped_key"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ReplicatedConfig 复制的配置及其版本和发布记录
+type ReplicatedConfig struct {
+	Name            string              `json:"name"`
+	Namespace       string              `json:"namespace"`
+	Environment     string              `json:"environment"`
+	FileType        string              `json:"file_type"`
+	SchemaJSON      string              `json:"schema_json,omitempty"`
+	DefaultEditMode string              `json:"default_edit_mode"`
+	DiffRules       string              `json:"diff_rules,omitempty"`
+	CurrentVersion  int                 `json:"current_version"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+	Versions        []ReplicatedVersion `json:"versions"`
+	Releases        []ReplicatedRelease `json:"releases"`
+}
+
+// ReplicatedVersion 复制的配置版本
+type ReplicatedVersion struct {
+	Version       int       `json:"version"`
+	Content       string    `json:"content"`
+	CommitHash    string    `json:"commit_hash"`
+	CommitMessage string    `json:"commit_message"`
+	Author        string    `json:"author"`
+	Origin        string    `json:"origin,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ReplicatedRelease 复制的发布记录
+type ReplicatedRelease struct {
+	Version        int       `json:"version"`
+	Environment    string    `json:"environment"`
+	Status         string    `json:"status"`
+	ReleaseType    string    `json:"release_type"`
+	GrayRules      string    `json:"gray_rules,omitempty"`
+	GrayPercentage int       `json:"gray_percentage,omitempty"`
+	ReleasedBy     string    `json:"released_by"`
+	ReleasedAt     time.Time `json:"released_at"`
+	Artifact       string    `json:"artifact,omitempty"`
+	ArtifactHash   string    `json:"artifact_hash,omitempty"`
+	PipelineLog    string    `json:"pipeline_log,omitempty"`
+}
+
+// ReplicationConflict 复制冲突记录
+type ReplicationConflict struct {
+	Config   string `json:"config"` // namespace/name@environment
+	Version  int    `json:"version"`
+	Resolved string `json:"resolved"` // source, target
+	Reason   string `json:"reason"`
+}
+
+// ReplicationResult 快照应用结果
+type ReplicationResult struct {
+	Project         string                `json:"project"`
+	ConfigsCreated  int                   `json:"configs_created"`
+	VersionsApplied int                   `json:"versions_applied"`
+	ReleasesApplied int                   `json:"releases_applied"`
+	ChangedConfigs  []int64               `json:"changed_configs"` // 本地配置 ID, 用于通知监听方
+	Conflicts       []ReplicationConflict `json:"conflicts"`
+}
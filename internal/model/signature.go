@@ -0,0 +1,64 @@
+package model
+
+import (
+	"time"
+)
+
+// 签名密钥类型
+const (
+	SigningKeyGPG      = "gpg"      // ASCII armor 格式的 OpenPGP 公钥, 签名为 gpg --detach-sign 的输出
+	SigningKeySigstore = "sigstore" // PEM 格式的 ECDSA/Ed25519 公钥, 签名为 cosign sign-blob 的 base64 输出
+)
+
+// 版本签名验证状态
+const (
+	SignatureUnsigned = "unsigned" // 没有签名
+	SignatureVerified = "verified" // 至少一个有效签名
+	SignatureRevoked  = "revoked"  // 签名密钥均已吊销
+	SignatureInvalid  = "invalid"  // 签名后版本内容发生变化 (如复制冲突覆盖)
+)
+
+// SigningKey 项目登记的签名公钥, 用于验证作者或 CI 为配置版本附加的分离签名
+type SigningKey struct {
+	ID          int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	ProjectID   int64      `json:"project_id" gorm:"index;not null"`
+	Name        string     `json:"name" gorm:"type:varchar(100);not null"`
+	Type        string     `json:"type" gorm:"type:varchar(20);not null"` // gpg, sigstore
+	PublicKey   string     `json:"public_key" gorm:"type:text;not null"`
+	Fingerprint string     `json:"fingerprint" gorm:"type:varchar(64);index"`
+	Identity    string     `json:"identity,omitempty" gorm:"type:varchar(100)"` // 密钥所属作者, 与版本的 author 一致时视为作者本人签名
+	CreatedBy   int64      `json:"created_by"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName 表名
+func (SigningKey) TableName() string {
+	return "signing_keys"
+}
+
+// VersionSignature 配置版本的分离签名, 附加时已通过验证
+type VersionSignature struct {
+	ID         int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	ProjectID  int64     `json:"project_id" gorm:"index;not null"`
+	ConfigID   int64     `json:"config_id" gorm:"index;not null"`
+	VersionID  int64     `json:"version_id" gorm:"uniqueIndex:idx_version_signature;not null"`
+	Version    int       `json:"version" gorm:"not null"`
+	KeyID      int64     `json:"key_id" gorm:"uniqueIndex:idx_version_signature;not null"`
+	CommitHash string    `json:"commit_hash" gorm:"type:varchar(64)"` // 签名时的版本内容哈希
+	Signature  string    `json:"signature" gorm:"type:text;not null"`
+	SignedBy   string    `json:"signed_by" gorm:"type:varchar(100)"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName 表名
+func (VersionSignature) TableName() string {
+	return "version_signatures"
+}
+
+// SignatureStatus 版本列表中附带的签名验证状态
+type SignatureStatus struct {
+	Status         string   `json:"status"`            // unsigned, verified, revoked, invalid
+	Signers        []string `json:"signers,omitempty"` // 有效签名的密钥名称
+	AuthorVerified bool     `json:"author_verified"`   // 有效签名中有版本作者本人的密钥
+}
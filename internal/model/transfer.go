@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+)
+
+// 所有权转移状态
+const (
+	TransferPending   = "pending"   // 等待接收方确认
+	TransferAccepted  = "accepted"  // 接收方已确认, 转移已执行
+	TransferRejected  = "rejected"  // 接收方拒绝
+	TransferCancelled = "cancelled" // 发起方撤回
+	TransferExpired   = "expired"   // 超过有效期未确认
+)
+
+// OwnershipTransfer 所有权转移申请: 由项目所有者或管理员发起, 接收方确认后执行
+// ConfigIDs 为空时转移整个项目的所有权; 否则将这些配置迁入接收方管理的 TargetProjectID 项目
+type OwnershipTransfer struct {
+	ID              int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	ProjectID       int64      `json:"project_id" gorm:"index;not null"`
+	TargetProjectID *int64     `json:"target_project_id,omitempty" gorm:"index"`
+	ConfigIDs       string     `json:"config_ids,omitempty" gorm:"type:json"` // 迁移的配置 ID 列表, 如 [12, 15]
+	FromUserID      int64      `json:"from_user_id" gorm:"index;not null"`
+	ToUserID        int64      `json:"to_user_id" gorm:"index;not null"`
+	Status          string     `json:"status" gorm:"type:varchar(20);index;not null"` // pending, accepted, rejected, cancelled, expired
+	Message         string     `json:"message,omitempty" gorm:"type:varchar(500)"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	DecidedAt       *time.Time `json:"decided_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName 表名
+func (OwnershipTransfer) TableName() string {
+	return "ownership_transfers"
+}
+
+// IsConfigTransfer 是否为配置迁移 (而非整个项目的所有权转移)
+func (t *OwnershipTransfer) IsConfigTransfer() bool {
+	return t.TargetProjectID != nil
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// ProjectUsage 项目每日用量, 用于共享实例的成本分摊
+type ProjectUsage struct {
+	ID             int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	ProjectID      int64     `json:"project_id" gorm:"uniqueIndex:idx_usage_project_day;not null"`
+	Day            string    `json:"day" gorm:"type:varchar(10);uniqueIndex:idx_usage_project_day;index;not null"` // 2006-01-02 (UTC)
+	APICalls       int64     `json:"api_calls" gorm:"column:api_calls;default:0"`
+	WatcherSeconds int64     `json:"watcher_seconds" gorm:"default:0"` // 监听连接累计保持时长
+	StorageBytes   int64     `json:"storage_bytes" gorm:"default:0"`   // 当日配置版本内容总字节数快照
+	UpdatedAt      time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 表名
+func (ProjectUsage) TableName() string {
+	return "project_usage"
+}
@@ -6,13 +6,14 @@ import (
 
 // User 用户
 type User struct {
-	ID           int64     `json:"id" gorm:"primaryKey;autoIncrement"`
-	Username     string    `json:"username" gorm:"type:varchar(100);uniqueIndex;not null"`
-	Email        string    `json:"email" gorm:"type:varchar(200);uniqueIndex;not null"`
-	PasswordHash string    `json:"-" gorm:"type:varchar(128);not null"`
-	IsActive     bool      `json:"is_active" gorm:"default:true"`
-	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID           int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Username     string     `json:"username" gorm:"type:varchar(100);uniqueIndex;not null"`
+	Email        string     `json:"email" gorm:"type:varchar(200);uniqueIndex;not null"`
+	PasswordHash string     `json:"-" gorm:"type:varchar(128);not null"`
+	IsActive     bool       `json:"is_active" gorm:"default:true"`
+	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
 // TableName 表名
@@ -20,6 +21,9 @@ func (User) TableName() string {
 	return "users"
 }
 
+// ExternalPasswordHash 由外部目录 (LDAP) 认证的用户的密码哈希占位值, 不匹配任何本地密码
+const ExternalPasswordHash = "!external"
+
 // ProjectMember 项目成员
 type ProjectMember struct {
 	ID        int64     `json:"id" gorm:"primaryKey;autoIncrement"`
@@ -34,6 +38,20 @@ func (ProjectMember) TableName() string {
 	return "project_members"
 }
 
+// RolePermissions 成员角色对应的权限
+func RolePermissions(role string) Permissions {
+	switch role {
+	case "admin":
+		return Permissions{Read: true, Write: true, Delete: true, Release: true, Admin: true, Decrypt: true}
+	case "releaser":
+		return Permissions{Read: true, Write: true, Release: true}
+	case "developer":
+		return Permissions{Read: true, Write: true}
+	default:
+		return Permissions{Read: true}
+	}
+}
+
 // ClientConnection 客户端连接 (用于实时推送)
 type ClientConnection struct {
 	ID            int64     `json:"id" gorm:"primaryKey;autoIncrement"`
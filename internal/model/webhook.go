@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+)
+
+// Webhook 项目级出站 Webhook: 配置变更时向外部地址推送签名事件
+type Webhook struct {
+	ID           int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	ProjectID    int64     `json:"project_id" gorm:"index;not null"`
+	Name         string    `json:"name" gorm:"type:varchar(100);not null"`
+	URL          string    `json:"url" gorm:"type:varchar(500);not null"`
+	Secret       string    `json:"-" gorm:"type:varchar(128)"`              // 签名密钥, 为空时使用项目的 WebhookSecret
+	Events       string    `json:"events,omitempty" gorm:"type:json"`       // 订阅的变更类型, 如 ["update", "release"], 为空表示全部
+	Environments string    `json:"environments,omitempty" gorm:"type:json"` // 订阅的环境, 为空表示全部
+	IsActive     bool      `json:"is_active" gorm:"default:true"`
+	CreatedBy    int64     `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 表名
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// WebhookDelivery Webhook 投递记录, 失败后按退避间隔重试
+type WebhookDelivery struct {
+	ID             int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	WebhookID      int64      `json:"webhook_id" gorm:"index;not null"`
+	ProjectID      int64      `json:"project_id" gorm:"index;not null"`
+	Event          string     `json:"event" gorm:"type:varchar(50);not null"`
+	Payload        string     `json:"payload" gorm:"type:text"`
+	Status         string     `json:"status" gorm:"type:varchar(20);index;not null"` // pending, success, failed
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty" gorm:"index"`
+	RequestHeaders string     `json:"request_headers,omitempty" gorm:"type:text"` // 最近一次发送的请求头 (JSON)
+	ResponseStatus int        `json:"response_status"`
+	ResponseBody   string     `json:"response_body,omitempty" gorm:"type:text"` // 最近一次响应的响应体, 超长时截断
+	DurationMS     int64      `json:"duration_ms"`                              // 最近一次请求耗时
+	LastError      string     `json:"last_error,omitempty" gorm:"type:varchar(500)"`
+	ReplayOf       *int64     `json:"replay_of,omitempty" gorm:"index"` // 重放时为原投递记录 ID
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 表名
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// Webhook 投递状态
+const (
+	WebhookDeliveryPending = "pending"
+	WebhookDeliverySuccess = "success"
+	WebhookDeliveryFailed  = "failed"
+)
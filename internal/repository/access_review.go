@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"confighub/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// AccessMember 项目成员及其用户信息
+type AccessMember struct {
+	ProjectID   int64
+	UserID      int64
+	Username    string
+	Email       string
+	IsActive    bool
+	LastLoginAt *time.Time
+	Role        string
+	CreatedAt   time.Time // 加入项目的时间
+}
+
+// AccessReviewRepository 访问审查的数据访问
+type AccessReviewRepository struct {
+	db *gorm.DB
+}
+
+// NewAccessReviewRepository 创建访问审查仓库
+func NewAccessReviewRepository(db *gorm.DB) *AccessReviewRepository {
+	return &AccessReviewRepository{db: db}
+}
+
+// Projects 获取用户作为所有者或管理员的项目 (含已归档), projectID 为 0 时返回全部这些项目
+func (r *AccessReviewRepository) Projects(ctx context.Context, projectID, userID int64) ([]*model.Project, error) {
+	var projects []*model.Project
+	managed := r.db.Model(&model.ProjectMember{}).Select("project_id").Where("user_id = ? AND role = ?", userID, "admin")
+	query := r.db.WithContext(ctx).Where("created_by = ? OR id IN (?)", userID, managed)
+	if projectID > 0 {
+		query = query.Where("id = ?", projectID)
+	}
+	err := query.Order("id ASC").Find(&projects).Error
+	return projects, err
+}
+
+// Users 按 ID 获取用户
+func (r *AccessReviewRepository) Users(ctx context.Context, ids []int64) ([]*model.User, error) {
+	var users []*model.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
+// Members 获取项目成员, 成员对应的用户已删除时不返回
+func (r *AccessReviewRepository) Members(ctx context.Context, projectIDs []int64) ([]*AccessMember, error) {
+	var members []*AccessMember
+	if len(projectIDs) == 0 {
+		return members, nil
+	}
+	err := r.db.WithContext(ctx).Table("project_members").
+		Select("project_members.project_id, project_members.user_id, users.username, users.email, users.is_active, users.last_login_at, project_members.role, project_members.created_at").
+		Joins("JOIN users ON users.id = project_members.user_id").
+		Where("project_members.project_id IN ?", projectIDs).
+		Order("project_members.project_id ASC, users.username ASC").
+		Scan(&members).Error
+	return members, err
+}
+
+// Keys 获取项目的访问密钥
+func (r *AccessReviewRepository) Keys(ctx context.Context, projectIDs []int64) ([]*model.ProjectKey, error) {
+	var keys []*model.ProjectKey
+	if len(projectIDs) == 0 {
+		return keys, nil
+	}
+	err := r.db.WithContext(ctx).Where("project_id IN ?", projectIDs).Order("project_id ASC, id ASC").Find(&keys).Error
+	return keys, err
+}
+
+// LastActivity 各项目中每个用户最近一条审计日志的时间, 键为 [项目 ID, 用户 ID]
+func (r *AccessReviewRepository) LastActivity(ctx context.Context, projectIDs []int64) (map[[2]int64]time.Time, error) {
+	result := make(map[[2]int64]time.Time)
+	if len(projectIDs) == 0 {
+		return result, nil
+	}
+	var rows []struct {
+		ProjectID int64
+		UserID    int64
+		LastAt    time.Time
+	}
+	err := r.db.WithContext(ctx).Table("audit_logs").
+		Select("project_id, user_id, MAX(created_at) AS last_at").
+		Where("project_id IN ? AND user_id IS NOT NULL", projectIDs).
+		Group("project_id, user_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[[2]int64{row.ProjectID, row.UserID}] = row.LastAt
+	}
+	return result, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"confighub/internal/model"
@@ -29,6 +30,13 @@ type AuditFilter struct {
 	ProjectID    int64
 	Action       string
 	ResourceType string
+	ResourceName string // 资源名称模糊匹配
+	UserID       *int64
+	AccessKeyID  *int64
+	StatusMin    int // HTTP 状态码范围, 0 表示不限制
+	StatusMax    int
+	Query        string // 在请求体和资源名称中全文匹配
+	Origin       string // 配置变更来源, automation 匹配所有自动化工具
 	StartTime    *time.Time
 	EndTime      *time.Time
 	Limit        int
@@ -38,23 +46,7 @@ type AuditFilter struct {
 // List 获取审计日志列表
 func (r *AuditRepository) List(ctx context.Context, filter *AuditFilter) ([]*model.AuditLog, error) {
 	var logs []*model.AuditLog
-	query := r.db.WithContext(ctx).Model(&model.AuditLog{})
-
-	if filter.ProjectID > 0 {
-		query = query.Where("project_id = ?", filter.ProjectID)
-	}
-	if filter.Action != "" {
-		query = query.Where("action = ?", filter.Action)
-	}
-	if filter.ResourceType != "" {
-		query = query.Where("resource_type = ?", filter.ResourceType)
-	}
-	if filter.StartTime != nil {
-		query = query.Where("created_at >= ?", filter.StartTime)
-	}
-	if filter.EndTime != nil {
-		query = query.Where("created_at <= ?", filter.EndTime)
-	}
+	query := r.applyFilter(r.db.WithContext(ctx).Model(&model.AuditLog{}), filter)
 
 	if filter.Limit > 0 {
 		query = query.Limit(filter.Limit)
@@ -72,8 +64,14 @@ func (r *AuditRepository) List(ctx context.Context, filter *AuditFilter) ([]*mod
 // Count 统计审计日志数量
 func (r *AuditRepository) Count(ctx context.Context, filter *AuditFilter) (int64, error) {
 	var count int64
-	query := r.db.WithContext(ctx).Model(&model.AuditLog{})
+	query := r.applyFilter(r.db.WithContext(ctx).Model(&model.AuditLog{}), filter)
 
+	err := query.Count(&count).Error
+	return count, err
+}
+
+// applyFilter 追加过滤条件
+func (r *AuditRepository) applyFilter(query *gorm.DB, filter *AuditFilter) *gorm.DB {
 	if filter.ProjectID > 0 {
 		query = query.Where("project_id = ?", filter.ProjectID)
 	}
@@ -83,13 +81,48 @@ func (r *AuditRepository) Count(ctx context.Context, filter *AuditFilter) (int64
 	if filter.ResourceType != "" {
 		query = query.Where("resource_type = ?", filter.ResourceType)
 	}
+	if filter.ResourceName != "" {
+		query = query.Where("resource_name LIKE ?", "%"+escapeLike(filter.ResourceName)+"%")
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.AccessKeyID != nil {
+		query = query.Where("access_key_id = ?", *filter.AccessKeyID)
+	}
+	if filter.StatusMin > 0 {
+		query = query.Where("status_code >= ?", filter.StatusMin)
+	}
+	if filter.StatusMax > 0 {
+		query = query.Where("status_code <= ?", filter.StatusMax)
+	}
+	if filter.Query != "" {
+		query = r.matchText(query, filter.Query)
+	}
+	if filter.Origin != "" {
+		query = whereOrigin(query, filter.Origin)
+	}
 	if filter.StartTime != nil {
 		query = query.Where("created_at >= ?", filter.StartTime)
 	}
 	if filter.EndTime != nil {
 		query = query.Where("created_at <= ?", filter.EndTime)
 	}
+	return query
+}
 
-	err := query.Count(&count).Error
-	return count, err
+// matchText 全文匹配请求体和资源名称
+// MySQL 使用 request_body 上的 FULLTEXT 索引 (短语匹配); PostgreSQL 使用 pg_trgm 索引加速 ILIKE
+func (r *AuditRepository) matchText(query *gorm.DB, text string) *gorm.DB {
+	pattern := "%" + escapeLike(text) + "%"
+	if r.db.Dialector.Name() == "mysql" {
+		phrase := `"` + strings.ReplaceAll(text, `"`, " ") + `"`
+		return query.Where("(MATCH(request_body) AGAINST (? IN BOOLEAN MODE) OR resource_name LIKE ?)", phrase, pattern)
+	}
+	return query.Where("(request_body ILIKE ? OR resource_name ILIKE ?)", pattern, pattern)
+}
+
+// escapeLike 转义 LIKE 通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
@@ -6,6 +6,7 @@ import (
 	"confighub/internal/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
 // ConfigRepository 配置数据访问
@@ -62,6 +63,13 @@ func (r *ConfigRepository) List(ctx context.Context, projectID int64) ([]*model.
 	return configs, err
 }
 
+// ListAll 获取所有项目的配置列表
+func (r *ConfigRepository) ListAll(ctx context.Context) ([]*model.Config, error) {
+	var configs []*model.Config
+	err := r.db.WithContext(ctx).Order("project_id ASC, name ASC").Find(&configs).Error
+	return configs, err
+}
+
 // ListByNamespace 根据命名空间获取配置列表
 func (r *ConfigRepository) ListByNamespace(ctx context.Context, projectID int64, namespace string) ([]*model.Config, error) {
 	var configs []*model.Config
@@ -72,14 +80,92 @@ func (r *ConfigRepository) ListByNamespace(ctx context.Context, projectID int64,
 	return configs, err
 }
 
+// ListChildren 获取直接继承指定配置的子配置
+func (r *ConfigRepository) ListChildren(ctx context.Context, parentID int64) ([]*model.Config, error) {
+	var configs []*model.Config
+	err := r.db.WithContext(ctx).Where("parent_id = ?", parentID).Order("id ASC").Find(&configs).Error
+	return configs, err
+}
+
 // Update 更新配置
 func (r *ConfigRepository) Update(ctx context.Context, config *model.Config) error {
 	return r.db.WithContext(ctx).Save(config).Error
 }
 
-// Delete 删除配置
+// Delete 删除配置, 在同一事务中级联删除版本、发布、变更通知、灰度曝光记录和消费契约
 func (r *ConfigRepository) Delete(ctx context.Context, id int64) error {
-	return r.db.WithContext(ctx).Delete(&model.Config{}, id).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteConfigChildren(tx, []int64{id}); err != nil {
+			return err
+		}
+		return tx.Delete(&model.Config{}, id).Error
+	})
+}
+
+// DeleteImpact 删除操作将影响的数据, 用于 dry_run 预演
+type DeleteImpact struct {
+	Rows      map[string]int64 `json:"rows"`       // 各表将被删除的行数
+	ConfigIDs []int64          `json:"config_ids"` // 将被删除的配置
+}
+
+// DeleteImpact 统计删除配置将级联删除的数据, 不做修改
+func (r *ConfigRepository) DeleteImpact(ctx context.Context, id int64) (*DeleteImpact, error) {
+	impact := &DeleteImpact{
+		Rows:      map[string]int64{"configs": 1},
+		ConfigIDs: []int64{id},
+	}
+	if err := countConfigChildren(r.db.WithContext(ctx), []int64{id}, impact.Rows); err != nil {
+		return nil, err
+	}
+	return impact, nil
+}
+
+// configChildren 配置的从属数据, 删除配置时一并删除
+var configChildren = []schema.Tabler{
+	&model.GrayExposure{},
+	&model.Release{},
+	&model.ConfigNotification{},
+	&model.VersionSignature{},
+	&model.ConfigVersion{},
+	&model.ConfigContract{},
+	&model.InboundIntegration{},
+	&model.ProtoDescriptor{},
+	&model.ConfigKey{},
+	&model.ConfigKeyHistory{},
+}
+
+// deleteConfigChildren 删除配置的从属数据, configIDs 可以是 ID 列表或子查询
+func deleteConfigChildren(tx *gorm.DB, configIDs interface{}) error {
+	releaseIDs := tx.Model(&model.Release{}).Select("id").Where("config_id IN (?)", configIDs)
+	if err := tx.Where("release_id IN (?)", releaseIDs).Delete(&model.ReleaseApproval{}).Error; err != nil {
+		return err
+	}
+
+	for _, child := range configChildren {
+		if err := tx.Where("config_id IN (?)", configIDs).Delete(child).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countConfigChildren 按表统计 deleteConfigChildren 将删除的行数, 累加到 rows
+func countConfigChildren(db *gorm.DB, configIDs interface{}, rows map[string]int64) error {
+	releaseIDs := db.Model(&model.Release{}).Select("id").Where("config_id IN (?)", configIDs)
+	var count int64
+	if err := db.Model(&model.ReleaseApproval{}).Where("release_id IN (?)", releaseIDs).Count(&count).Error; err != nil {
+		return err
+	}
+	rows[model.ReleaseApproval{}.TableName()] += count
+
+	for _, child := range configChildren {
+		var count int64
+		if err := db.Model(child).Where("config_id IN (?)", configIDs).Count(&count).Error; err != nil {
+			return err
+		}
+		rows[child.TableName()] += count
+	}
+	return nil
 }
 
 // IncrementVersion 增加版本号
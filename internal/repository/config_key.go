@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"confighub/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ConfigKeyRepository kv 配置的键及其修改历史数据访问
+type ConfigKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewConfigKeyRepository 创建 kv 键仓库
+func NewConfigKeyRepository(db *gorm.DB) *ConfigKeyRepository {
+	return &ConfigKeyRepository{db: db}
+}
+
+// List 获取配置的所有键, 按键名排序
+func (r *ConfigKeyRepository) List(ctx context.Context, configID int64) ([]*model.ConfigKey, error) {
+	var keys []*model.ConfigKey
+	err := r.db.WithContext(ctx).Where("config_id = ?", configID).Order("key_name ASC").Find(&keys).Error
+	return keys, err
+}
+
+// Get 获取配置的单个键
+func (r *ConfigKeyRepository) Get(ctx context.Context, configID int64, key string) (*model.ConfigKey, error) {
+	var row model.ConfigKey
+	err := r.db.WithContext(ctx).Where("config_id = ? AND key_name = ?", configID, key).First(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// History 获取单个键的修改历史, 最新的在前
+func (r *ConfigKeyRepository) History(ctx context.Context, configID int64, key string, limit int) ([]*model.ConfigKeyHistory, error) {
+	var history []*model.ConfigKeyHistory
+	query := r.db.WithContext(ctx).Where("config_id = ? AND key_name = ?", configID, key).Order("revision DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	err := query.Find(&history).Error
+	return history, err
+}
+
+// Apply 在同一事务中写入键的修改: 每项修改记录一条历史并更新或删除对应的键, 修订号在键的历史上递增
+func (r *ConfigKeyRepository) Apply(ctx context.Context, configID int64, changes []*model.ConfigKeyHistory) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, change := range changes {
+			var last model.ConfigKeyHistory
+			err := tx.Where("config_id = ? AND key_name = ?", configID, change.Key).Order("revision DESC").First(&last).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			change.ConfigID = configID
+			change.Revision = last.Revision + 1
+			if err := tx.Create(change).Error; err != nil {
+				return err
+			}
+
+			if change.Deleted {
+				if err := tx.Where("config_id = ? AND key_name = ?", configID, change.Key).Delete(&model.ConfigKey{}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			var row model.ConfigKey
+			err = tx.Where("config_id = ? AND key_name = ?", configID, change.Key).First(&row).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			row.ConfigID = configID
+			row.Key = change.Key
+			row.Value = change.Value
+			row.Revision = change.Revision
+			row.ConfigVersion = change.ConfigVersion
+			row.UpdatedBy = change.Author
+			if err := tx.Save(&row).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+
+	"confighub/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ContractRepository 配置消费契约数据访问
+type ContractRepository struct {
+	db *gorm.DB
+}
+
+// NewContractRepository 创建契约仓库
+func NewContractRepository(db *gorm.DB) *ContractRepository {
+	return &ContractRepository{db: db}
+}
+
+// Upsert 创建或更新消费方在配置上的契约
+func (r *ContractRepository) Upsert(ctx context.Context, contract *model.ConfigContract) error {
+	var existing model.ConfigContract
+	err := r.db.WithContext(ctx).
+		Where("config_id = ? AND consumer = ?", contract.ConfigID, contract.Consumer).
+		First(&existing).Error
+	if err != nil {
+		return r.db.WithContext(ctx).Create(contract).Error
+	}
+
+	contract.ID = existing.ID
+	contract.CreatedAt = existing.CreatedAt
+	return r.db.WithContext(ctx).Save(contract).Error
+}
+
+// ListByConfig 获取配置的所有契约
+func (r *ContractRepository) ListByConfig(ctx context.Context, configID int64) ([]*model.ConfigContract, error) {
+	var contracts []*model.ConfigContract
+	err := r.db.WithContext(ctx).Where("config_id = ?", configID).Order("consumer ASC").Find(&contracts).Error
+	return contracts, err
+}
+
+// Delete 删除消费方在配置上的契约, 返回是否存在
+func (r *ContractRepository) Delete(ctx context.Context, configID int64, consumer string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("config_id = ? AND consumer = ?", configID, consumer).Delete(&model.ConfigContract{})
+	return result.RowsAffected > 0, result.Error
+}
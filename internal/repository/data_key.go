@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+
+	"confighub/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// DataKeyRepository 项目数据加密密钥数据访问
+type DataKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewDataKeyRepository 创建数据密钥仓库
+func NewDataKeyRepository(db *gorm.DB) *DataKeyRepository {
+	return &DataKeyRepository{db: db}
+}
+
+// ListDataKeys 获取项目的数据密钥, 按创建顺序排列
+func (r *DataKeyRepository) ListDataKeys(ctx context.Context, projectID int64) ([]*model.ProjectDataKey, error) {
+	var keys []*model.ProjectDataKey
+	err := r.db.WithContext(ctx).Where("project_id = ?", projectID).Order("id ASC").Find(&keys).Error
+	return keys, err
+}
+
+// CreateDataKey 保存数据密钥
+func (r *DataKeyRepository) CreateDataKey(ctx context.Context, key *model.ProjectDataKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// UpdateDataKey 更新数据密钥的包装结果
+func (r *DataKeyRepository) UpdateDataKey(ctx context.Context, key *model.ProjectDataKey) error {
+	return r.db.WithContext(ctx).Model(&model.ProjectDataKey{}).Where("id = ?", key.ID).Update("wrapped_key", key.WrappedKey).Error
+}
@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"confighub/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// EnvironmentRepository 环境数据访问, 负责环境改名/删除时迁移绑定的数据
+type EnvironmentRepository struct {
+	db          *gorm.DB
+	loadContent ContentLoader
+}
+
+// NewEnvironmentRepository 创建环境仓库
+func NewEnvironmentRepository(db *gorm.DB) *EnvironmentRepository {
+	return &EnvironmentRepository{db: db}
+}
+
+// SetContentLoader 设置版本内容不在数据库中时的读取方式 (Git 版本存储)
+func (r *EnvironmentRepository) SetContentLoader(load ContentLoader) {
+	r.loadContent = load
+}
+
+// EnvironmentUsage 环境被引用情况
+type EnvironmentUsage struct {
+	Configs            int64 `json:"configs"`
+	Releases           int64 `json:"releases"`
+	ActiveGrayReleases int64 `json:"active_gray_releases"`
+}
+
+// InUse 环境是否仍被配置或发布引用
+func (u *EnvironmentUsage) InUse() bool {
+	return u.Configs > 0 || u.Releases > 0
+}
+
+// CountUsage 统计项目中绑定到指定环境的配置和发布
+func (r *EnvironmentRepository) CountUsage(ctx context.Context, projectID int64, env string) (*EnvironmentUsage, error) {
+	usage := &EnvironmentUsage{}
+	db := r.db.WithContext(ctx)
+
+	if err := db.Model(&model.Config{}).
+		Where("project_id = ? AND environment = ?", projectID, env).
+		Count(&usage.Configs).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&model.Release{}).
+		Where("project_id = ? AND environment = ?", projectID, env).
+		Count(&usage.Releases).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&model.Release{}).
+		Where("project_id = ? AND environment = ? AND status = ?", projectID, env, "gray").
+		Count(&usage.ActiveGrayReleases).Error; err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// ListConflicts 返回迁移时会冲突的配置名: 源环境与目标环境中存在同名同命名空间的配置
+func (r *EnvironmentRepository) ListConflicts(ctx context.Context, projectID int64, from, to string) ([]string, error) {
+	var names []string
+	err := r.db.WithContext(ctx).Model(&model.Config{}).
+		Where("project_id = ? AND environment = ?", projectID, from).
+		Where("(name, namespace) IN (?)", r.db.Model(&model.Config{}).
+			Select("name, namespace").
+			Where("project_id = ? AND environment = ?", projectID, to)).
+		Pluck("name", &names).Error
+	return names, err
+}
+
+// Migrate 在同一事务中将绑定到 from 环境的配置、发布和曝光记录迁移到 to 环境,
+// 同步改写跨环境灰度规则中的环境百分比, 并保存项目设置 (project.Settings 由调用方更新).
+// rename 为 true 时改名环境记录, 否则删除 from 环境记录.
+func (r *EnvironmentRepository) Migrate(ctx context.Context, project *model.Project, from, to string, rename bool) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, table := range []interface{}{&model.Config{}, &model.Release{}, &model.GrayExposure{}} {
+			if err := tx.Model(table).
+				Where("project_id = ? AND environment = ?", project.ID, from).
+				Update("environment", to).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := migrateGrayEnvPercentages(tx, project.ID, from, to); err != nil {
+			return err
+		}
+
+		envs := tx.Where("project_id = ? AND name = ?", project.ID, from)
+		if rename {
+			if err := envs.Model(&model.ProjectEnvironment{}).Update("name", to).Error; err != nil {
+				return err
+			}
+		} else if err := envs.Delete(&model.ProjectEnvironment{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Save(project).Error
+	})
+}
+
+// migrateGrayEnvPercentages 改写跨环境灰度发布规则中以环境名为键的百分比和灰度目标
+// 目标环境已有百分比时保留目标环境的设置
+func migrateGrayEnvPercentages(tx *gorm.DB, projectID int64, from, to string) error {
+	var releases []*model.Release
+	if err := tx.Where("project_id = ? AND environment = ? AND gray_rules IS NOT NULL", projectID, model.ReleaseEnvAll).
+		Find(&releases).Error; err != nil {
+		return err
+	}
+
+	for _, release := range releases {
+		var rules model.GrayRules
+		if err := json.Unmarshal([]byte(release.GrayRules), &rules); err != nil {
+			continue
+		}
+		percentage, ok := rules.EnvPercentages[from]
+		if !ok {
+			continue
+		}
+		delete(rules.EnvPercentages, from)
+		if _, exists := rules.EnvPercentages[to]; !exists {
+			rules.EnvPercentages[to] = percentage
+		}
+		if target, ok := rules.EnvTargets[from]; ok {
+			delete(rules.EnvTargets, from)
+			if _, exists := rules.EnvTargets[to]; !exists {
+				rules.EnvTargets[to] = target
+			}
+		}
+
+		rulesJSON, err := json.Marshal(rules)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(release).Update("gray_rules", string(rulesJSON)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CloneReleased 在同一事务中将 from 环境各配置当前生效的正式发布复制到 to 环境:
+// 每个配置创建同名副本, 以生效内容作为版本 1 并直接发布; 同时复制环境变量并保存项目设置 (project.Settings 由调用方更新).
+// 没有正式发布的配置不复制, 返回复制的配置数
+func (r *EnvironmentRepository) CloneReleased(ctx context.Context, project *model.Project, from, to, author string) (int, error) {
+	cloned := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var configs []*model.Config
+		if err := tx.Where("project_id = ? AND environment = ?", project.ID, from).Find(&configs).Error; err != nil {
+			return err
+		}
+
+		for _, config := range configs {
+			var release model.Release
+			err := tx.Where("config_id = ? AND environment = ? AND status = 'released'", config.ID, from).
+				Order("released_at DESC").
+				First(&release).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			var version model.ConfigVersion
+			if err := tx.Where("config_id = ? AND version = ?", config.ID, release.Version).First(&version).Error; err != nil {
+				return err
+			}
+			if r.loadContent != nil {
+				if err := r.loadContent(ctx, &version); err != nil {
+					return err
+				}
+			}
+
+			clone := *config
+			clone.ID = 0
+			clone.Environment = to
+			clone.CurrentVersion = 1
+			if err := tx.Create(&clone).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&model.ConfigVersion{
+				ConfigID:      clone.ID,
+				Version:       1,
+				Content:       version.Content,
+				CommitHash:    version.CommitHash,
+				CommitMessage: fmt.Sprintf("克隆自 %s 环境 v%d", from, version.Version),
+				Author:        author,
+				Origin:        model.OriginSync,
+			}).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&model.Release{
+				ProjectID:   project.ID,
+				ConfigID:    clone.ID,
+				Version:     1,
+				Environment: to,
+				Status:      "released",
+				ReleaseType: "full",
+				ReleasedBy:  author,
+			}).Error; err != nil {
+				return err
+			}
+			cloned++
+		}
+
+		var source model.ProjectEnvironment
+		if err := tx.Where("project_id = ? AND name = ?", project.ID, from).First(&source).Error; err == nil {
+			if err := tx.Create(&model.ProjectEnvironment{
+				ProjectID:   project.ID,
+				Name:        to,
+				Description: source.Description,
+				SortOrder:   source.SortOrder,
+				Variables:   source.Variables,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Save(project).Error
+	})
+	return cloned, err
+}
+
+// Purge 在同一事务中删除环境下的配置及其版本、发布等从属数据和环境记录, 并保存项目设置 (project.Settings 由调用方更新).
+// 返回被删除的配置
+func (r *EnvironmentRepository) Purge(ctx context.Context, project *model.Project, env string) ([]*model.Config, error) {
+	var configs []*model.Config
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("project_id = ? AND environment = ?", project.ID, env).Find(&configs).Error; err != nil {
+			return err
+		}
+
+		if len(configs) > 0 {
+			configIDs := make([]int64, len(configs))
+			for i, config := range configs {
+				configIDs[i] = config.ID
+			}
+			if err := deleteConfigChildren(tx, configIDs); err != nil {
+				return err
+			}
+			if err := tx.Delete(&model.Config{}, configIDs).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("project_id = ? AND name = ?", project.ID, env).Delete(&model.ProjectEnvironment{}).Error; err != nil {
+			return err
+		}
+		return tx.Save(project).Error
+	})
+	return configs, err
+}
+
+// ListConfigIDs 获取项目中绑定到指定环境的配置 ID
+func (r *EnvironmentRepository) ListConfigIDs(ctx context.Context, projectID int64, env string) ([]int64, error) {
+	configIDs := []int64{}
+	err := r.db.WithContext(ctx).Model(&model.Config{}).
+		Where("project_id = ? AND environment = ?", projectID, env).
+		Order("id ASC").
+		Pluck("id", &configIDs).Error
+	return configIDs, err
+}
+
+// PurgeImpact 统计 Purge 将删除的配置、从属数据和环境记录, 不做修改
+func (r *EnvironmentRepository) PurgeImpact(ctx context.Context, projectID int64, env string) (*DeleteImpact, error) {
+	configIDs, err := r.ListConfigIDs(ctx, projectID, env)
+	if err != nil {
+		return nil, err
+	}
+	impact := &DeleteImpact{
+		Rows:      map[string]int64{"configs": int64(len(configIDs))},
+		ConfigIDs: configIDs,
+	}
+	db := r.db.WithContext(ctx)
+	if len(configIDs) > 0 {
+		if err := countConfigChildren(db, configIDs, impact.Rows); err != nil {
+			return nil, err
+		}
+	}
+
+	var count int64
+	if err := db.Model(&model.ProjectEnvironment{}).Where("project_id = ? AND name = ?", projectID, env).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	impact.Rows[model.ProjectEnvironment{}.TableName()] = count
+	return impact, nil
+}
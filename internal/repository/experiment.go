@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"confighub/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ExperimentRepository 灰度实验曝光数据访问
+type ExperimentRepository struct {
+	db *gorm.DB
+}
+
+// NewExperimentRepository 创建灰度实验仓库
+func NewExperimentRepository(db *gorm.DB) *ExperimentRepository {
+	return &ExperimentRepository{db: db}
+}
+
+// UpsertExposure 写入曝光 (同一发布/客户端/分组只保留一条): 已存在时累加命中次数, 更新最后时间、版本和地址
+// exposure.HitCount 为本次累加的命中次数
+func (r *ExperimentRepository) UpsertExposure(ctx context.Context, exposure *model.GrayExposure) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "release_id"}, {Name: "client_id"}, {Name: "variant"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"hit_count":    gorm.Expr("gray_exposures.hit_count + ?", exposure.HitCount),
+			"last_seen_at": exposure.LastSeenAt,
+			"version":      exposure.Version,
+			"ip_address":   exposure.IPAddress,
+		}),
+	}).Create(exposure).Error
+}
+
+// ExposureFilter 曝光记录过滤条件
+type ExposureFilter struct {
+	ReleaseID int64
+	ConfigID  int64
+	ClientID  string
+	Variant   string
+	StartTime *time.Time
+	EndTime   *time.Time
+	Limit     int
+	Offset    int
+}
+
+// ListExposures 获取曝光记录
+func (r *ExperimentRepository) ListExposures(ctx context.Context, filter *ExposureFilter) ([]*model.GrayExposure, error) {
+	var exposures []*model.GrayExposure
+	query := r.db.WithContext(ctx).Model(&model.GrayExposure{})
+
+	if filter.ReleaseID > 0 {
+		query = query.Where("release_id = ?", filter.ReleaseID)
+	}
+	if filter.ConfigID > 0 {
+		query = query.Where("config_id = ?", filter.ConfigID)
+	}
+	if filter.ClientID != "" {
+		query = query.Where("client_id = ?", filter.ClientID)
+	}
+	if filter.Variant != "" {
+		query = query.Where("variant = ?", filter.Variant)
+	}
+	// 时间范围与曝光区间有交集即返回
+	if filter.StartTime != nil {
+		query = query.Where("last_seen_at >= ?", filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		query = query.Where("first_seen_at <= ?", filter.EndTime)
+	}
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	err := query.Order("first_seen_at ASC").Find(&exposures).Error
+	return exposures, err
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"confighub/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// IntegrationRepository 入站集成数据访问
+type IntegrationRepository struct {
+	db *gorm.DB
+}
+
+// NewIntegrationRepository 创建入站集成仓库
+func NewIntegrationRepository(db *gorm.DB) *IntegrationRepository {
+	return &IntegrationRepository{db: db}
+}
+
+// Create 创建入站集成
+func (r *IntegrationRepository) Create(ctx context.Context, integration *model.InboundIntegration) error {
+	return r.db.WithContext(ctx).Create(integration).Error
+}
+
+// GetByID 根据 ID 获取入站集成
+func (r *IntegrationRepository) GetByID(ctx context.Context, id int64) (*model.InboundIntegration, error) {
+	var integration model.InboundIntegration
+	err := r.db.WithContext(ctx).First(&integration, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &integration, nil
+}
+
+// ListByProject 获取项目下的入站集成
+func (r *IntegrationRepository) ListByProject(ctx context.Context, projectID int64) ([]*model.InboundIntegration, error) {
+	var integrations []*model.InboundIntegration
+	err := r.db.WithContext(ctx).Where("project_id = ?", projectID).Order("id ASC").Find(&integrations).Error
+	return integrations, err
+}
+
+// Update 更新入站集成
+func (r *IntegrationRepository) Update(ctx context.Context, integration *model.InboundIntegration) error {
+	return r.db.WithContext(ctx).Save(integration).Error
+}
+
+// MarkReceived 记录最近一次推送的时间和生成的版本
+func (r *IntegrationRepository) MarkReceived(ctx context.Context, id int64, version int) error {
+	return r.db.WithContext(ctx).Model(&model.InboundIntegration{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_received_at": time.Now(),
+			"last_version":     version,
+		}).Error
+}
+
+// Delete 删除入站集成
+func (r *IntegrationRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Delete(&model.InboundIntegration{}, id).Error
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// schemaMigrationsTable golang-migrate 记录迁移版本的表
+const schemaMigrationsTable = "schema_migrations"
+
+// MigrationRepository 数据库迁移版本仓库
+type MigrationRepository struct {
+	db *gorm.DB
+}
+
+// NewMigrationRepository 创建迁移版本仓库
+func NewMigrationRepository(db *gorm.DB) *MigrationRepository {
+	return &MigrationRepository{db: db}
+}
+
+// SchemaMigration golang-migrate 的迁移记录
+type SchemaMigration struct {
+	Version uint
+	Dirty   bool
+}
+
+// Current 获取当前迁移版本; 未使用 golang-migrate 管理 (表不存在或为空) 时返回 nil
+func (r *MigrationRepository) Current(ctx context.Context) (*SchemaMigration, error) {
+	db := r.db.WithContext(ctx)
+	if !db.Migrator().HasTable(schemaMigrationsTable) {
+		return nil, nil
+	}
+
+	var migrations []SchemaMigration
+	if err := db.Table(schemaMigrationsTable).Select("version, dirty").Limit(1).Find(&migrations).Error; err != nil {
+		return nil, err
+	}
+	if len(migrations) == 0 {
+		return nil, nil
+	}
+	return &migrations[0], nil
+}
+
+// Ping 检查数据库连接是否可用
+func (r *MigrationRepository) Ping(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
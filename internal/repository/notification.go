@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"confighub/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// NotificationRepository 配置变更事件日志数据访问
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository 创建事件日志仓库
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// Create 记录变更事件
+func (r *NotificationRepository) Create(ctx context.Context, notification *model.ConfigNotification) error {
+	return r.db.WithContext(ctx).Create(notification).Error
+}
+
+// NotificationEvent 变更事件及其所属配置
+type NotificationEvent struct {
+	ID          int64
+	ConfigID    int64
+	Version     int
+	ChangeType  string
+	Name        string
+	Namespace   string
+	Environment string
+}
+
+// ListAfter 获取 afterID 之后项目指定命名空间和环境的变更事件, 按 ID 升序
+func (r *NotificationRepository) ListAfter(ctx context.Context, projectID int64, namespace, env string, afterID int64, limit int) ([]*NotificationEvent, error) {
+	var events []*NotificationEvent
+	// 已删除配置的事件 (如删除事件) 取事件自身记录的归属, 早期事件只能取自 configs 表
+	err := r.db.WithContext(ctx).Table("config_notifications").
+		Select("config_notifications.id, config_notifications.config_id, config_notifications.version, config_notifications.change_type, "+
+			"COALESCE(configs.name, config_notifications.config_name) AS name, "+
+			"COALESCE(configs.namespace, config_notifications.namespace) AS namespace, "+
+			"COALESCE(configs.environment, config_notifications.environment) AS environment").
+		Joins("LEFT JOIN configs ON configs.id = config_notifications.config_id").
+		Where("config_notifications.id > ? AND COALESCE(configs.project_id, config_notifications.project_id) = ? "+
+			"AND COALESCE(configs.namespace, config_notifications.namespace) = ? "+
+			"AND COALESCE(configs.environment, config_notifications.environment) = ?", afterID, projectID, namespace, env).
+		Order("config_notifications.id ASC").
+		Limit(limit).
+		Scan(&events).Error
+	return events, err
+}
+
+// MinID 获取保留的最早事件 ID, 没有事件时返回 0
+func (r *NotificationRepository) MinID(ctx context.Context) (int64, error) {
+	var id *int64
+	err := r.db.WithContext(ctx).Model(&model.ConfigNotification{}).Select("MIN(id)").Scan(&id).Error
+	if err != nil || id == nil {
+		return 0, err
+	}
+	return *id, nil
+}
+
+// DeleteBefore 删除指定时间之前的事件, 返回删除数量
+func (r *NotificationRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", before).Delete(&model.ConfigNotification{})
+	return result.RowsAffected, result.Error
+}
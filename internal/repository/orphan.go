@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// OrphanRepository 孤儿数据清理
+type OrphanRepository struct {
+	db *gorm.DB
+}
+
+// NewOrphanRepository 创建孤儿数据仓库
+func NewOrphanRepository(db *gorm.DB) *OrphanRepository {
+	return &OrphanRepository{db: db}
+}
+
+// orphanRule 孤儿数据判定规则: 表中 where 条件命中的行即为孤儿
+// 按依赖顺序排列, 先清理上层数据, 使其从属数据在同一轮中被识别
+type orphanRule struct {
+	table string
+	where string
+}
+
+var orphanRules = []orphanRule{
+	{"configs", "project_id NOT IN (SELECT id FROM projects)"},
+	{"project_keys", "project_id NOT IN (SELECT id FROM projects)"},
+	{"project_environments", "project_id NOT IN (SELECT id FROM projects)"},
+	{"project_members", "project_id NOT IN (SELECT id FROM projects)"},
+	{"client_connections", "project_id NOT IN (SELECT id FROM projects)"},
+	// 保留项目删除记录作为墓碑
+	{"audit_logs", "project_id <> 0 AND project_id NOT IN (SELECT id FROM projects) AND NOT (action = 'delete' AND resource_type = 'project')"},
+	{"config_versions", "config_id NOT IN (SELECT id FROM configs)"},
+	{"config_notifications", "config_id NOT IN (SELECT id FROM configs)"},
+	{"releases", "config_id NOT IN (SELECT id FROM configs)"},
+	{"config_contracts", "config_id NOT IN (SELECT id FROM configs)"},
+	{"inbound_integrations", "config_id NOT IN (SELECT id FROM configs)"},
+	{"gray_exposures", "release_id NOT IN (SELECT id FROM releases)"},
+	{"release_approvals", "release_id NOT IN (SELECT id FROM releases)"},
+}
+
+// Count 统计各表孤儿数据行数
+// 上层孤儿 (如 configs) 尚未删除时, 其从属数据不计入
+func (r *OrphanRepository) Count(ctx context.Context) (map[string]int64, error) {
+	counts := make(map[string]int64, len(orphanRules))
+	for _, rule := range orphanRules {
+		var count int64
+		if err := r.db.WithContext(ctx).Table(rule.table).Where(rule.where).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		counts[rule.table] = count
+	}
+	return counts, nil
+}
+
+// Delete 在同一事务中删除所有孤儿数据, 返回各表删除行数
+func (r *OrphanRepository) Delete(ctx context.Context) (map[string]int64, error) {
+	deleted := make(map[string]int64, len(orphanRules))
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, rule := range orphanRules {
+			result := tx.Exec("DELETE FROM " + rule.table + " WHERE " + rule.where)
+			if result.Error != nil {
+				return result.Error
+			}
+			deleted[rule.table] = result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
@@ -2,15 +2,18 @@ package repository
 
 import (
 	"context"
+	"errors"
 
 	"confighub/internal/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
 // ProjectRepository 项目数据访问
 type ProjectRepository struct {
-	db *gorm.DB
+	db       *gorm.DB
+	onChange []func(projectID int64)
 }
 
 // NewProjectRepository 创建项目仓库
@@ -18,6 +21,18 @@ func NewProjectRepository(db *gorm.DB) *ProjectRepository {
 	return &ProjectRepository{db: db}
 }
 
+// OnChange 注册项目更新或删除后的回调, 用于失效项目缓存; 需在处理请求前注册
+func (r *ProjectRepository) OnChange(fn func(projectID int64)) {
+	r.onChange = append(r.onChange, fn)
+}
+
+// changed 通知项目已更新或删除
+func (r *ProjectRepository) changed(projectID int64) {
+	for _, fn := range r.onChange {
+		fn(projectID)
+	}
+}
+
 // Create 创建项目
 func (r *ProjectRepository) Create(ctx context.Context, project *model.Project) error {
 	return r.db.WithContext(ctx).Create(project).Error
@@ -43,25 +58,108 @@ func (r *ProjectRepository) GetByName(ctx context.Context, name string) (*model.
 	return &project, nil
 }
 
-// List 获取项目列表
-func (r *ProjectRepository) List(ctx context.Context, userID int64) ([]*model.Project, error) {
+// List 获取项目列表, includeArchived 为 false 时不包含已归档项目
+func (r *ProjectRepository) List(ctx context.Context, userID int64, includeArchived bool) ([]*model.Project, error) {
 	var projects []*model.Project
 	query := r.db.WithContext(ctx)
 	if userID > 0 {
 		query = query.Where("created_by = ?", userID)
 	}
+	if !includeArchived {
+		query = query.Where("archived_at IS NULL")
+	}
 	err := query.Order("created_at DESC").Find(&projects).Error
 	return projects, err
 }
 
+// ListGitLinked 获取已关联 Git 仓库且未归档的项目
+func (r *ProjectRepository) ListGitLinked(ctx context.Context) ([]*model.Project, error) {
+	var projects []*model.Project
+	err := r.db.WithContext(ctx).
+		Where("git_repo_url <> '' AND archived_at IS NULL").
+		Order("id").
+		Find(&projects).Error
+	return projects, err
+}
+
+// MemberRole 获取用户在项目中的成员角色, 不是成员时返回空字符串
+func (r *ProjectRepository) MemberRole(ctx context.Context, projectID, userID int64) (string, error) {
+	var member model.ProjectMember
+	err := r.db.WithContext(ctx).Where("project_id = ? AND user_id = ?", projectID, userID).First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
 // Update 更新项目
 func (r *ProjectRepository) Update(ctx context.Context, project *model.Project) error {
-	return r.db.WithContext(ctx).Save(project).Error
+	if err := r.db.WithContext(ctx).Save(project).Error; err != nil {
+		return err
+	}
+	r.changed(project.ID)
+	return nil
 }
 
-// Delete 删除项目
+// Delete 删除项目, 在同一事务中级联删除配置、版本、发布、密钥、成员、Webhook、签名密钥及审计日志
 func (r *ProjectRepository) Delete(ctx context.Context, id int64) error {
-	return r.db.WithContext(ctx).Delete(&model.Project{}, id).Error
+	defer r.changed(id)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		configIDs := tx.Model(&model.Config{}).Select("id").Where("project_id = ?", id)
+		if err := deleteConfigChildren(tx, configIDs); err != nil {
+			return err
+		}
+
+		for _, child := range projectChildren {
+			if err := tx.Where("project_id = ?", id).Delete(child).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&model.Project{}, id).Error
+	})
+}
+
+// projectChildren 项目的从属数据, 删除项目时在配置的从属数据之后删除
+var projectChildren = []schema.Tabler{
+	&model.Config{},
+	&model.ProjectKey{},
+	&model.ProjectEnvironment{},
+	&model.ProjectMember{},
+	&model.ClientConnection{},
+	&model.AuditLog{},
+	&model.WebhookDelivery{},
+	&model.Webhook{},
+	&model.SigningKey{},
+	&model.ProjectDataKey{},
+}
+
+// DeleteImpact 统计删除项目将级联删除的数据, 不做修改
+func (r *ProjectRepository) DeleteImpact(ctx context.Context, id int64) (*DeleteImpact, error) {
+	db := r.db.WithContext(ctx)
+	impact := &DeleteImpact{
+		Rows:      map[string]int64{"projects": 1},
+		ConfigIDs: []int64{},
+	}
+	if err := db.Model(&model.Config{}).Where("project_id = ?", id).Order("id ASC").Pluck("id", &impact.ConfigIDs).Error; err != nil {
+		return nil, err
+	}
+	configIDs := db.Model(&model.Config{}).Select("id").Where("project_id = ?", id)
+	if err := countConfigChildren(db, configIDs, impact.Rows); err != nil {
+		return nil, err
+	}
+
+	for _, child := range projectChildren {
+		var count int64
+		if err := db.Model(child).Where("project_id = ?", id).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		impact.Rows[child.TableName()] += count
+	}
+	return impact, nil
 }
 
 // ExistsByName 检查项目名是否存在
@@ -76,6 +174,21 @@ func (r *ProjectRepository) CreateEnvironment(ctx context.Context, env *model.Pr
 	return r.db.WithContext(ctx).Create(env).Error
 }
 
+// GetEnvironment 获取项目下指定名称的环境
+func (r *ProjectRepository) GetEnvironment(ctx context.Context, projectID int64, name string) (*model.ProjectEnvironment, error) {
+	var env model.ProjectEnvironment
+	err := r.db.WithContext(ctx).Where("project_id = ? AND name = ?", projectID, name).First(&env).Error
+	if err != nil {
+		return nil, err
+	}
+	return &env, nil
+}
+
+// SaveEnvironment 保存环境 (不存在时创建)
+func (r *ProjectRepository) SaveEnvironment(ctx context.Context, env *model.ProjectEnvironment) error {
+	return r.db.WithContext(ctx).Save(env).Error
+}
+
 // ListEnvironments 获取项目环境列表
 func (r *ProjectRepository) ListEnvironments(ctx context.Context, projectID int64) ([]*model.ProjectEnvironment, error) {
 	var envs []*model.ProjectEnvironment
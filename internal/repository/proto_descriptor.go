@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+
+	"confighub/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ProtoDescriptorRepository protobuf 描述符数据访问
+type ProtoDescriptorRepository struct {
+	db *gorm.DB
+}
+
+// NewProtoDescriptorRepository 创建描述符仓库
+func NewProtoDescriptorRepository(db *gorm.DB) *ProtoDescriptorRepository {
+	return &ProtoDescriptorRepository{db: db}
+}
+
+// GetByConfig 获取配置的描述符
+func (r *ProtoDescriptorRepository) GetByConfig(ctx context.Context, configID int64) (*model.ProtoDescriptor, error) {
+	var descriptor model.ProtoDescriptor
+	if err := r.db.WithContext(ctx).Where("config_id = ?", configID).First(&descriptor).Error; err != nil {
+		return nil, err
+	}
+	return &descriptor, nil
+}
+
+// Upsert 创建或替换配置的描述符
+func (r *ProtoDescriptorRepository) Upsert(ctx context.Context, descriptor *model.ProtoDescriptor) error {
+	var existing model.ProtoDescriptor
+	err := r.db.WithContext(ctx).Where("config_id = ?", descriptor.ConfigID).First(&existing).Error
+	if err != nil {
+		return r.db.WithContext(ctx).Create(descriptor).Error
+	}
+
+	descriptor.ID = existing.ID
+	descriptor.CreatedAt = existing.CreatedAt
+	return r.db.WithContext(ctx).Save(descriptor).Error
+}
+
+// Delete 删除配置的描述符, 返回是否存在
+func (r *ProtoDescriptorRepository) Delete(ctx context.Context, configID int64) (bool, error) {
+	result := r.db.WithContext(ctx).Where("config_id = ?", configID).Delete(&model.ProtoDescriptor{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"confighub/internal/model"
 
@@ -46,6 +47,70 @@ func (r *ReleaseRepository) GetByConfigAndEnv(ctx context.Context, configID int6
 	return &release, nil
 }
 
+// GetLatestReleased 获取配置在指定环境的最新正式发布
+func (r *ReleaseRepository) GetLatestReleased(ctx context.Context, configID int64, env string) (*model.Release, error) {
+	var release model.Release
+	err := r.db.WithContext(ctx).
+		Where("config_id = ? AND environment = ? AND status = 'released'", configID, env).
+		Order("released_at DESC").
+		First(&release).Error
+	if err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// ListInWindow 获取项目在时间窗口内的发布记录 (不含已取消的灰度及未生效的待审批/已驳回发布), env 为空时不限环境
+func (r *ReleaseRepository) ListInWindow(ctx context.Context, projectID int64, env string, from, to time.Time) ([]*model.Release, error) {
+	var releases []*model.Release
+	query := r.db.WithContext(ctx).
+		Where("project_id = ? AND released_at >= ? AND released_at < ? AND status NOT IN ('cancelled', 'pending', 'rejected')", projectID, from, to)
+	if env != "" {
+		query = query.Where("environment = ?", env)
+	}
+	err := query.Order("released_at ASC").Find(&releases).Error
+	return releases, err
+}
+
+// GetLatestReleasedBefore 获取配置在指定环境某时间点之前最后一次生效的发布
+func (r *ReleaseRepository) GetLatestReleasedBefore(ctx context.Context, configID int64, env string, before time.Time) (*model.Release, error) {
+	var release model.Release
+	err := r.db.WithContext(ctx).
+		Where("config_id = ? AND environment = ? AND released_at < ? AND status IN ('released', 'promoted', 'rollback')", configID, env, before).
+		Order("released_at DESC").
+		First(&release).Error
+	if err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// GetReleasedAsOf 获取配置在指定环境某一时刻生效的正式发布: 发布时间不晚于 at 的最后一次正式发布
+// 之后被回滚的发布 (rollback) 在回滚前仍然生效, 因此同样计入
+func (r *ReleaseRepository) GetReleasedAsOf(ctx context.Context, configID int64, env string, at time.Time) (*model.Release, error) {
+	var release model.Release
+	err := r.db.WithContext(ctx).
+		Where("config_id = ? AND environment = ? AND released_at <= ? AND status IN ('released', 'promoted', 'rollback')", configID, env, at).
+		Order("released_at DESC, id DESC").
+		First(&release).Error
+	if err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// ListRecentlyReleasedConfigIDs 按最近一次正式发布时间倒序返回配置 ID, 用于缓存预热
+func (r *ReleaseRepository) ListRecentlyReleasedConfigIDs(ctx context.Context, limit int) ([]int64, error) {
+	var ids []int64
+	err := r.db.WithContext(ctx).Model(&model.Release{}).
+		Where("status = ?", "released").
+		Group("config_id").
+		Order("MAX(released_at) DESC").
+		Limit(limit).
+		Pluck("config_id", &ids).Error
+	return ids, err
+}
+
 // List 获取配置的发布历史
 func (r *ReleaseRepository) List(ctx context.Context, configID int64) ([]*model.Release, error) {
 	var releases []*model.Release
@@ -76,3 +141,33 @@ func (r *ReleaseRepository) GetActiveGrayRelease(ctx context.Context, configID i
 	}
 	return &release, nil
 }
+
+// ListActiveStagedGray 获取项目中活跃的分阶段 (跨环境) 灰度发布
+func (r *ReleaseRepository) ListActiveStagedGray(ctx context.Context, projectID int64) ([]*model.Release, error) {
+	var releases []*model.Release
+	err := r.db.WithContext(ctx).
+		Where("project_id = ? AND environment = ? AND status = 'gray'", projectID, model.ReleaseEnvAll).
+		Find(&releases).Error
+	return releases, err
+}
+
+// CountRolledBackSince 统计配置在指定环境某时间点之后发布且已被回滚的次数
+func (r *ReleaseRepository) CountRolledBackSince(ctx context.Context, configID int64, env string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Release{}).
+		Where("config_id = ? AND environment = ? AND status = 'rollback' AND released_at >= ?", configID, env, since).
+		Count(&count).Error
+	return count, err
+}
+
+// CreateApproval 创建审批记录
+func (r *ReleaseRepository) CreateApproval(ctx context.Context, approval *model.ReleaseApproval) error {
+	return r.db.WithContext(ctx).Create(approval).Error
+}
+
+// ListApprovals 获取发布的审批记录
+func (r *ReleaseRepository) ListApprovals(ctx context.Context, releaseID int64) ([]*model.ReleaseApproval, error) {
+	var approvals []*model.ReleaseApproval
+	err := r.db.WithContext(ctx).Where("release_id = ?", releaseID).Order("created_at ASC").Find(&approvals).Error
+	return approvals, err
+}
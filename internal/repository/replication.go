@@ -0,0 +1,513 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"confighub/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ReplicationRepository 跨实例复制的数据访问: 导出项目快照并在本地应用快照
+type ReplicationRepository struct {
+	db          *gorm.DB
+	loadContent ContentLoader
+}
+
+// NewReplicationRepository 创建复制仓库
+func NewReplicationRepository(db *gorm.DB) *ReplicationRepository {
+	return &ReplicationRepository{db: db}
+}
+
+// SetContentLoader 设置版本内容不在数据库中时的读取方式 (Git 版本存储)
+func (r *ReplicationRepository) SetContentLoader(load ContentLoader) {
+	r.loadContent = load
+}
+
+// Snapshot 导出项目的完整快照 (环境、密钥、配置、版本、发布记录)
+func (r *ReplicationRepository) Snapshot(ctx context.Context, projectName string) (*model.ReplicationSnapshot, error) {
+	db := r.db.WithContext(ctx)
+
+	var project model.Project
+	if err := db.Where("name = ?", projectName).First(&project).Error; err != nil {
+		return nil, err
+	}
+
+	snapshot := &model.ReplicationSnapshot{
+		GeneratedAt: time.Now(),
+		Project: model.ReplicatedProject{
+			Name:              project.Name,
+			Description:       project.Description,
+			AccessMode:        project.AccessMode,
+			PublicPermissions: project.PublicPermissions,
+			Settings:          project.Settings,
+			ArchivedAt:        project.ArchivedAt,
+		},
+		Environments: []model.ReplicatedEnvironment{},
+		Keys:         []model.ReplicatedKey{},
+		Configs:      []model.ReplicatedConfig{},
+	}
+
+	var envs []*model.ProjectEnvironment
+	if err := db.Where("project_id = ?", project.ID).Order("sort_order ASC").Find(&envs).Error; err != nil {
+		return nil, err
+	}
+	for _, env := range envs {
+		snapshot.Environments = append(snapshot.Environments, model.ReplicatedEnvironment{
+			Name:        env.Name,
+			Description: env.Description,
+			SortOrder:   env.SortOrder,
+			Variables:   env.Variables,
+		})
+	}
+
+	var keys []*model.ProjectKey
+	if err := db.Where("project_id = ?", project.ID).Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		snapshot.Keys = append(snapshot.Keys, model.ReplicatedKey{
+			Name:          key.Name,
+			AccessKey:     key.AccessKey,
+			SecretKeyHash: key.SecretKeyHash,
+			SecretKeyEnc:  key.SecretKeyEnc,
+			Permissions:   key.Permissions,
+			IPWhitelist:   key.IPWhitelist,
+			ExpiresAt:     key.ExpiresAt,
+			IsActive:      key.IsActive,
+			Priority:      key.Priority,
+			ResolveTiming: key.ResolveTiming,
+		})
+	}
+
+	var dataKeys []*model.ProjectDataKey
+	if err := db.Where("project_id = ?", project.ID).Order("id ASC").Find(&dataKeys).Error; err != nil {
+		return nil, err
+	}
+	for _, key := range dataKeys {
+		snapshot.DataKeys = append(snapshot.DataKeys, model.ReplicatedDataKey{
+			KeyID:      key.KeyID,
+			WrappedKey: key.WrappedKey,
+			CreatedAt:  key.CreatedAt,
+		})
+	}
+
+	var configs []*model.Config
+	if err := db.Where("project_id = ?", project.ID).Order("id ASC").Find(&configs).Error; err != nil {
+		return nil, err
+	}
+	for _, config := range configs {
+		rc := model.ReplicatedConfig{
+			Name:            config.Name,
+			Namespace:       config.Namespace,
+			Environment:     config.Environment,
+			FileType:        config.FileType,
+			SchemaJSON:      config.SchemaJSON,
+			DefaultEditMode: config.DefaultEditMode,
+			DiffRules:       config.DiffRules,
+			CurrentVersion:  config.CurrentVersion,
+			UpdatedAt:       config.UpdatedAt,
+			Versions:        []model.ReplicatedVersion{},
+			Releases:        []model.ReplicatedRelease{},
+		}
+
+		var versions []*model.ConfigVersion
+		if err := db.Where("config_id = ?", config.ID).Order("version ASC").Find(&versions).Error; err != nil {
+			return nil, err
+		}
+		if r.loadContent != nil {
+			if err := r.loadContent(ctx, versions...); err != nil {
+				return nil, err
+			}
+		}
+		for _, v := range versions {
+			rc.Versions = append(rc.Versions, model.ReplicatedVersion{
+				Version:       v.Version,
+				Content:       v.Content,
+				CommitHash:    v.CommitHash,
+				CommitMessage: v.CommitMessage,
+				Author:        v.Author,
+				Origin:        v.Origin,
+				CreatedAt:     v.CreatedAt,
+			})
+		}
+
+		var releases []*model.Release
+		if err := db.Where("config_id = ?", config.ID).Order("released_at ASC").Find(&releases).Error; err != nil {
+			return nil, err
+		}
+		for _, rel := range releases {
+			rc.Releases = append(rc.Releases, model.ReplicatedRelease{
+				Version:        rel.Version,
+				Environment:    rel.Environment,
+				Status:         rel.Status,
+				ReleaseType:    rel.ReleaseType,
+				GrayRules:      rel.GrayRules,
+				GrayPercentage: rel.GrayPercentage,
+				ReleasedBy:     rel.ReleasedBy,
+				ReleasedAt:     rel.ReleasedAt,
+				Artifact:       rel.Artifact,
+				ArtifactHash:   rel.ArtifactHash,
+				PipelineLog:    rel.PipelineLog,
+			})
+		}
+
+		snapshot.Configs = append(snapshot.Configs, rc)
+	}
+
+	return snapshot, nil
+}
+
+// Apply 在同一事务中将快照应用到本地实例
+// 项目元数据、环境和密钥以复制源为准 (源端删除的密钥在本地同步删除);
+// 同一版本号内容不一致时按 policy 处理并记录冲突
+func (r *ReplicationRepository) Apply(ctx context.Context, snapshot *model.ReplicationSnapshot, policy string) (*model.ReplicationResult, error) {
+	result := &model.ReplicationResult{
+		Project:        snapshot.Project.Name,
+		ChangedConfigs: []int64{},
+		Conflicts:      []model.ReplicationConflict{},
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		project, err := applyProject(tx, &snapshot.Project)
+		if err != nil {
+			return err
+		}
+		if err := applyEnvironments(tx, project.ID, snapshot.Environments); err != nil {
+			return err
+		}
+		if err := applyKeys(tx, project.ID, snapshot.Keys); err != nil {
+			return err
+		}
+		if err := saveDataKeys(tx, project.ID, snapshot.DataKeys); err != nil {
+			return err
+		}
+		for i := range snapshot.Configs {
+			if err := applyConfig(tx, project.ID, &snapshot.Configs[i], policy, result); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Import 在同一事务中将导入包应用到已有项目, 项目名称、描述和访问模式保持不变
+// 与 Apply 不同, 导入只新增或更新数据: 包中没有的密钥不会被删除;
+// mergeSettings 为 true 时包中的项目设置按顶层键覆盖本地设置
+func (r *ReplicationRepository) Import(ctx context.Context, projectID int64, snapshot *model.ReplicationSnapshot, policy string, mergeSettings bool) (*model.ReplicationResult, error) {
+	result := &model.ReplicationResult{
+		ChangedConfigs: []int64{},
+		Conflicts:      []model.ReplicationConflict{},
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var project model.Project
+		if err := tx.First(&project, projectID).Error; err != nil {
+			return err
+		}
+		result.Project = project.Name
+
+		if mergeSettings && snapshot.Project.Settings != "" {
+			settings, err := mergeSettingsJSON(project.Settings, snapshot.Project.Settings)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&project).Update("settings", settings).Error; err != nil {
+				return err
+			}
+		}
+		if err := applyEnvironments(tx, project.ID, snapshot.Environments); err != nil {
+			return err
+		}
+		if _, err := saveKeys(tx, project.ID, snapshot.Keys); err != nil {
+			return err
+		}
+		if err := saveDataKeys(tx, project.ID, snapshot.DataKeys); err != nil {
+			return err
+		}
+		for i := range snapshot.Configs {
+			if err := applyConfig(tx, project.ID, &snapshot.Configs[i], policy, result); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// mergeSettingsJSON 按顶层键合并项目设置, incoming 中的键覆盖 current
+func mergeSettingsJSON(current, incoming string) (string, error) {
+	merged := map[string]json.RawMessage{}
+	if current != "" {
+		if err := json.Unmarshal([]byte(current), &merged); err != nil {
+			return "", err
+		}
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(incoming), &fields); err != nil {
+		return "", fmt.Errorf("项目设置不是 JSON 对象: %w", err)
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// applyProject 按名称创建或更新项目
+func applyProject(tx *gorm.DB, rp *model.ReplicatedProject) (*model.Project, error) {
+	var project model.Project
+	err := tx.Where("name = ?", rp.Name).First(&project).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	project.Name = rp.Name
+	project.Description = rp.Description
+	project.AccessMode = rp.AccessMode
+	project.PublicPermissions = rp.PublicPermissions
+	project.Settings = rp.Settings
+	project.ArchivedAt = rp.ArchivedAt
+	if err := tx.Save(&project).Error; err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// applyEnvironments 按名称创建或更新环境
+func applyEnvironments(tx *gorm.DB, projectID int64, envs []model.ReplicatedEnvironment) error {
+	for _, re := range envs {
+		var env model.ProjectEnvironment
+		err := tx.Where("project_id = ? AND name = ?", projectID, re.Name).First(&env).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		env.ProjectID = projectID
+		env.Name = re.Name
+		env.Description = re.Description
+		env.SortOrder = re.SortOrder
+		env.Variables = re.Variables
+		if err := tx.Save(&env).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyKeys 同步访问密钥, 复制源已删除的密钥在本地删除, 使吊销在副本上同样生效
+func applyKeys(tx *gorm.DB, projectID int64, keys []model.ReplicatedKey) error {
+	accessKeys, err := saveKeys(tx, projectID, keys)
+	if err != nil {
+		return err
+	}
+
+	stale := tx.Where("project_id = ?", projectID)
+	if len(accessKeys) > 0 {
+		stale = stale.Where("access_key NOT IN ?", accessKeys)
+	}
+	return stale.Delete(&model.ProjectKey{}).Error
+}
+
+// saveKeys 按 access key 创建或更新访问密钥, 返回已保存的 access key
+func saveKeys(tx *gorm.DB, projectID int64, keys []model.ReplicatedKey) ([]string, error) {
+	accessKeys := make([]string, 0, len(keys))
+	for _, rk := range keys {
+		var key model.ProjectKey
+		err := tx.Where("access_key = ?", rk.AccessKey).First(&key).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if err == nil && key.ProjectID != projectID {
+			return nil, fmt.Errorf("access key %s 已属于本地其他项目", rk.AccessKey)
+		}
+
+		key.ProjectID = projectID
+		key.Name = rk.Name
+		key.AccessKey = rk.AccessKey
+		// 导出包不含加密的 Secret Key, Secret Key 未变时保留本地已保存的一份
+		if rk.SecretKeyEnc != "" || key.SecretKeyHash != rk.SecretKeyHash {
+			key.SecretKeyEnc = rk.SecretKeyEnc
+		}
+		key.SecretKeyHash = rk.SecretKeyHash
+		key.Permissions = rk.Permissions
+		key.IPWhitelist = rk.IPWhitelist
+		key.ExpiresAt = rk.ExpiresAt
+		key.IsActive = rk.IsActive
+		key.Priority = rk.Priority
+		key.ResolveTiming = rk.ResolveTiming
+		if key.Priority == "" {
+			key.Priority = model.KeyPriorityNormal
+		}
+		if err := tx.Save(&key).Error; err != nil {
+			return nil, err
+		}
+		accessKeys = append(accessKeys, rk.AccessKey)
+	}
+	return accessKeys, nil
+}
+
+// saveDataKeys 保存本地还没有的数据密钥; 数据密钥不会被删除, 以免已复制的加密值无法解密
+func saveDataKeys(tx *gorm.DB, projectID int64, keys []model.ReplicatedDataKey) error {
+	for _, rk := range keys {
+		var count int64
+		if err := tx.Model(&model.ProjectDataKey{}).Where("project_id = ? AND key_id = ?", projectID, rk.KeyID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		key := &model.ProjectDataKey{
+			ProjectID:  projectID,
+			KeyID:      rk.KeyID,
+			WrappedKey: rk.WrappedKey,
+			CreatedAt:  rk.CreatedAt,
+		}
+		if err := tx.Create(key).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyConfig 应用单个配置的元数据、版本和发布记录
+func applyConfig(tx *gorm.DB, projectID int64, rc *model.ReplicatedConfig, policy string, result *model.ReplicationResult) error {
+	label := fmt.Sprintf("%s/%s@%s", rc.Namespace, rc.Name, rc.Environment)
+
+	var config model.Config
+	err := tx.Where("project_id = ? AND name = ? AND namespace = ? AND environment = ?", projectID, rc.Name, rc.Namespace, rc.Environment).
+		First(&config).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	isNew := err != nil
+	if isNew {
+		result.ConfigsCreated++
+	}
+
+	previousVersion := config.CurrentVersion
+	config.ProjectID = projectID
+	config.Name = rc.Name
+	config.Namespace = rc.Namespace
+	config.Environment = rc.Environment
+	config.FileType = rc.FileType
+	config.SchemaJSON = rc.SchemaJSON
+	config.DefaultEditMode = rc.DefaultEditMode
+	config.DiffRules = rc.DiffRules
+
+	// 本地有复制源没有的新版本, 说明副本上发生过写入
+	if !isNew && config.CurrentVersion > rc.CurrentVersion {
+		if resolveConflict(policy, rc.UpdatedAt, config.UpdatedAt) {
+			config.CurrentVersion = rc.CurrentVersion
+			result.Conflicts = append(result.Conflicts, model.ReplicationConflict{
+				Config: label, Version: previousVersion, Resolved: "source", Reason: "本地存在复制源之后的版本",
+			})
+		} else {
+			result.Conflicts = append(result.Conflicts, model.ReplicationConflict{
+				Config: label, Version: previousVersion, Resolved: "target", Reason: "本地存在复制源之后的版本",
+			})
+		}
+	} else {
+		config.CurrentVersion = rc.CurrentVersion
+	}
+	if err := tx.Save(&config).Error; err != nil {
+		return err
+	}
+	changed := isNew || config.CurrentVersion != previousVersion
+
+	for _, rv := range rc.Versions {
+		var version model.ConfigVersion
+		err := tx.Where("config_id = ? AND version = ?", config.ID, rv.Version).First(&version).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			if version.CommitHash == rv.CommitHash {
+				continue
+			}
+			conflict := model.ReplicationConflict{Config: label, Version: rv.Version, Reason: "同一版本号内容不一致"}
+			if !resolveConflict(policy, rv.CreatedAt, version.CreatedAt) {
+				conflict.Resolved = "target"
+				result.Conflicts = append(result.Conflicts, conflict)
+				continue
+			}
+			conflict.Resolved = "source"
+			result.Conflicts = append(result.Conflicts, conflict)
+		}
+
+		version.ConfigID = config.ID
+		version.Version = rv.Version
+		version.Content = rv.Content
+		version.CommitHash = rv.CommitHash
+		version.CommitMessage = rv.CommitMessage
+		version.Author = rv.Author
+		version.Origin = rv.Origin
+		version.CreatedAt = rv.CreatedAt
+		if err := tx.Save(&version).Error; err != nil {
+			return err
+		}
+		result.VersionsApplied++
+		changed = true
+	}
+
+	for _, rr := range rc.Releases {
+		var release model.Release
+		err := tx.Where("config_id = ? AND environment = ? AND version = ? AND released_at = ?", config.ID, rr.Environment, rr.Version, rr.ReleasedAt).
+			First(&release).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil && release.Status == rr.Status && release.GrayPercentage == rr.GrayPercentage {
+			continue
+		}
+
+		release.ProjectID = projectID
+		release.ConfigID = config.ID
+		release.Version = rr.Version
+		release.Environment = rr.Environment
+		release.Status = rr.Status
+		release.ReleaseType = rr.ReleaseType
+		release.GrayRules = rr.GrayRules
+		release.GrayPercentage = rr.GrayPercentage
+		release.ReleasedBy = rr.ReleasedBy
+		release.ReleasedAt = rr.ReleasedAt
+		release.Artifact = rr.Artifact
+		release.ArtifactHash = rr.ArtifactHash
+		release.PipelineLog = rr.PipelineLog
+		if err := tx.Save(&release).Error; err != nil {
+			return err
+		}
+		result.ReleasesApplied++
+		changed = true
+	}
+
+	if changed {
+		result.ChangedConfigs = append(result.ChangedConfigs, config.ID)
+	}
+	return nil
+}
+
+// resolveConflict 按冲突策略判断是否采用复制源的数据
+func resolveConflict(policy string, sourceTime, targetTime time.Time) bool {
+	switch policy {
+	case model.ReplicationTargetWins:
+		return false
+	case model.ReplicationNewerWins:
+		return sourceTime.After(targetTime)
+	default:
+		return true
+	}
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"confighub/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// pruneBatchSize 单个配置一次最多清理的版本数, 剩余的在下一轮清理
+const pruneBatchSize = 1000
+
+// RetentionRepository 版本保留策略的数据访问
+type RetentionRepository struct {
+	db *gorm.DB
+}
+
+// NewRetentionRepository 创建版本保留仓库
+func NewRetentionRepository(db *gorm.DB) *RetentionRepository {
+	return &RetentionRepository{db: db}
+}
+
+// PruneCandidates 超出保留策略的版本 ID: 不在最近 keep 个版本内, before 不为零时创建时间早于 before,
+// 且不是最新版本, 也未被发布记录引用 (被拒绝或取消的发布除外), 未结束的分阶段灰度在各环境指向的版本同样保留
+func (r *RetentionRepository) PruneCandidates(ctx context.Context, configID int64, keep int, before time.Time) ([]int64, error) {
+	db := r.db.WithContext(ctx)
+
+	// 保留最近 keep 个版本 (至少保留最新版本)
+	if keep < 1 {
+		keep = 1
+	}
+	var boundary []int
+	if err := db.Model(&model.ConfigVersion{}).Where("config_id = ?", configID).
+		Order("version DESC").Offset(keep-1).Limit(1).Pluck("version", &boundary).Error; err != nil {
+		return nil, err
+	}
+	if len(boundary) == 0 {
+		return nil, nil
+	}
+
+	released := db.Model(&model.Release{}).Select("version").
+		Where("config_id = ? AND status NOT IN ?", configID, []string{"rejected", "cancelled"})
+	query := db.Model(&model.ConfigVersion{}).
+		Where("config_id = ? AND version < ?", configID, boundary[0]).
+		Where("version NOT IN (?)", released)
+	staged, err := r.stagedGrayVersions(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+	if len(staged) > 0 {
+		query = query.Where("version NOT IN ?", staged)
+	}
+	if !before.IsZero() {
+		query = query.Where("created_at < ?", before)
+	}
+
+	var ids []int64
+	err = query.Order("version").Limit(pruneBatchSize).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// stagedGrayVersions 配置所在项目中未结束的分阶段灰度通过 GrayRules.EnvTargets 指向该配置的版本
+// 分阶段灰度的发布记录只关联发起灰度的配置, 其他环境的配置及版本只保存在灰度规则中
+func (r *RetentionRepository) stagedGrayVersions(ctx context.Context, configID int64) ([]int, error) {
+	db := r.db.WithContext(ctx)
+	var releases []*model.Release
+	err := db.Where("project_id = (?) AND environment = ? AND status NOT IN ?",
+		db.Model(&model.Config{}).Select("project_id").Where("id = ?", configID),
+		model.ReleaseEnvAll, []string{"rejected", "cancelled", "promoted"}).
+		Find(&releases).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []int
+	for _, release := range releases {
+		var rules model.GrayRules
+		if err := json.Unmarshal([]byte(release.GrayRules), &rules); err != nil {
+			continue
+		}
+		for _, target := range rules.EnvTargets {
+			if target.ConfigID == configID {
+				versions = append(versions, target.Version)
+			}
+		}
+	}
+	return versions, nil
+}
+
+// DeleteVersions 在同一事务中删除版本及其签名, 返回删除的版本数
+func (r *RetentionRepository) DeleteVersions(ctx context.Context, ids []int64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("version_id IN ?", ids).Delete(&model.VersionSignature{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id IN ?", ids).Delete(&model.ConfigVersion{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+
+	"confighub/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// SignatureRepository 签名密钥及版本签名数据访问
+type SignatureRepository struct {
+	db *gorm.DB
+}
+
+// NewSignatureRepository 创建签名仓库
+func NewSignatureRepository(db *gorm.DB) *SignatureRepository {
+	return &SignatureRepository{db: db}
+}
+
+// CreateKey 登记签名密钥
+func (r *SignatureRepository) CreateKey(ctx context.Context, key *model.SigningKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// GetKey 根据 ID 获取签名密钥
+func (r *SignatureRepository) GetKey(ctx context.Context, id int64) (*model.SigningKey, error) {
+	var key model.SigningKey
+	err := r.db.WithContext(ctx).First(&key, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetKeyByFingerprint 根据指纹获取项目下的签名密钥
+func (r *SignatureRepository) GetKeyByFingerprint(ctx context.Context, projectID int64, fingerprint string) (*model.SigningKey, error) {
+	var key model.SigningKey
+	err := r.db.WithContext(ctx).Where("project_id = ? AND fingerprint = ?", projectID, fingerprint).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListKeys 获取项目下的签名密钥 (包括已吊销的)
+func (r *SignatureRepository) ListKeys(ctx context.Context, projectID int64) ([]*model.SigningKey, error) {
+	var keys []*model.SigningKey
+	err := r.db.WithContext(ctx).Where("project_id = ?", projectID).Order("id ASC").Find(&keys).Error
+	return keys, err
+}
+
+// UpdateKey 更新签名密钥
+func (r *SignatureRepository) UpdateKey(ctx context.Context, key *model.SigningKey) error {
+	return r.db.WithContext(ctx).Save(key).Error
+}
+
+// CreateSignature 保存版本签名
+func (r *SignatureRepository) CreateSignature(ctx context.Context, signature *model.VersionSignature) error {
+	return r.db.WithContext(ctx).Create(signature).Error
+}
+
+// GetSignature 获取密钥对版本的签名
+func (r *SignatureRepository) GetSignature(ctx context.Context, versionID, keyID int64) (*model.VersionSignature, error) {
+	var signature model.VersionSignature
+	err := r.db.WithContext(ctx).Where("version_id = ? AND key_id = ?", versionID, keyID).First(&signature).Error
+	if err != nil {
+		return nil, err
+	}
+	return &signature, nil
+}
+
+// ListByVersions 获取多个版本的签名
+func (r *SignatureRepository) ListByVersions(ctx context.Context, versionIDs []int64) ([]*model.VersionSignature, error) {
+	var signatures []*model.VersionSignature
+	if len(versionIDs) == 0 {
+		return signatures, nil
+	}
+	err := r.db.WithContext(ctx).Where("version_id IN ?", versionIDs).Order("id ASC").Find(&signatures).Error
+	return signatures, err
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"confighub/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// StatsRepository 统计概览的聚合查询, 每次查询按项目分组返回全部项目的计数
+type StatsRepository struct {
+	db *gorm.DB
+}
+
+// NewStatsRepository 创建统计仓库
+func NewStatsRepository(db *gorm.DB) *StatsRepository {
+	return &StatsRepository{db: db}
+}
+
+// CountProjects 统计项目总数和其中已归档的数量
+func (r *StatsRepository) CountProjects(ctx context.Context) (total, archived int64, err error) {
+	db := r.db.WithContext(ctx)
+	if err = db.Model(&model.Project{}).Count(&total).Error; err != nil {
+		return 0, 0, err
+	}
+	err = db.Model(&model.Project{}).Where("archived_at IS NOT NULL").Count(&archived).Error
+	return total, archived, err
+}
+
+// ConfigsByProject 统计各项目的配置数
+func (r *StatsRepository) ConfigsByProject(ctx context.Context) (map[int64]int64, error) {
+	return r.countByProject(r.db.WithContext(ctx).Table("configs").
+		Select("project_id, COUNT(*) AS count").
+		Group("project_id"))
+}
+
+// VersionsByProject 统计各项目的配置版本数
+func (r *StatsRepository) VersionsByProject(ctx context.Context) (map[int64]int64, error) {
+	return r.countByProject(r.db.WithContext(ctx).Table("config_versions").
+		Select("configs.project_id AS project_id, COUNT(*) AS count").
+		Joins("JOIN configs ON configs.id = config_versions.config_id").
+		Group("configs.project_id"))
+}
+
+// ReleasesByProject 统计各项目自 since 起生效的发布数 (待审批、被拒绝和已取消的发布除外)
+func (r *StatsRepository) ReleasesByProject(ctx context.Context, since time.Time) (map[int64]int64, error) {
+	return r.countByProject(r.db.WithContext(ctx).Table("releases").
+		Select("project_id, COUNT(*) AS count").
+		Where("released_at >= ? AND status NOT IN ?", since, []string{"pending", "rejected", "cancelled"}).
+		Group("project_id"))
+}
+
+// countByProject 执行按项目分组的计数查询
+func (r *StatsRepository) countByProject(query *gorm.DB) (map[int64]int64, error) {
+	var rows []struct {
+		ProjectID int64
+		Count     int64
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		result[row.ProjectID] = row.Count
+	}
+	return result, nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"confighub/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// TransferRepository 所有权转移数据访问
+type TransferRepository struct {
+	db *gorm.DB
+}
+
+// NewTransferRepository 创建所有权转移仓库
+func NewTransferRepository(db *gorm.DB) *TransferRepository {
+	return &TransferRepository{db: db}
+}
+
+// Create 创建转移申请
+func (r *TransferRepository) Create(ctx context.Context, transfer *model.OwnershipTransfer) error {
+	return r.db.WithContext(ctx).Create(transfer).Error
+}
+
+// GetByID 根据 ID 获取转移申请
+func (r *TransferRepository) GetByID(ctx context.Context, id int64) (*model.OwnershipTransfer, error) {
+	var transfer model.OwnershipTransfer
+	err := r.db.WithContext(ctx).First(&transfer, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// Update 更新转移申请
+func (r *TransferRepository) Update(ctx context.Context, transfer *model.OwnershipTransfer) error {
+	return r.db.WithContext(ctx).Save(transfer).Error
+}
+
+// ListByProject 获取项目发起或接收的转移申请, 最新的在前
+func (r *TransferRepository) ListByProject(ctx context.Context, projectID int64) ([]*model.OwnershipTransfer, error) {
+	var transfers []*model.OwnershipTransfer
+	err := r.db.WithContext(ctx).
+		Where("project_id = ? OR target_project_id = ?", projectID, projectID).
+		Order("id DESC").
+		Find(&transfers).Error
+	return transfers, err
+}
+
+// ListByUser 获取用户发起或需要用户确认的转移申请, status 为空时返回全部状态
+func (r *TransferRepository) ListByUser(ctx context.Context, userID int64, status string) ([]*model.OwnershipTransfer, error) {
+	var transfers []*model.OwnershipTransfer
+	query := r.db.WithContext(ctx).Where("from_user_id = ? OR to_user_id = ?", userID, userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("id DESC").Find(&transfers).Error
+	return transfers, err
+}
+
+// ListPending 获取项目中等待确认的转移申请
+func (r *TransferRepository) ListPending(ctx context.Context, projectID int64) ([]*model.OwnershipTransfer, error) {
+	var transfers []*model.OwnershipTransfer
+	err := r.db.WithContext(ctx).
+		Where("project_id = ? AND status = ?", projectID, model.TransferPending).
+		Find(&transfers).Error
+	return transfers, err
+}
+
+// GetUser 根据 ID 获取用户
+func (r *TransferRepository) GetUser(ctx context.Context, id int64) (*model.User, error) {
+	var user model.User
+	err := r.db.WithContext(ctx).First(&user, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// MemberRole 获取用户在项目中的成员角色, 不是成员时返回空字符串
+func (r *TransferRepository) MemberRole(ctx context.Context, projectID, userID int64) (string, error) {
+	var member model.ProjectMember
+	err := r.db.WithContext(ctx).Where("project_id = ? AND user_id = ?", projectID, userID).First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// TransferProject 在同一事务中将项目所有者改为接收方并保存转移申请:
+// 原所有者保留为项目管理员, 接收方原有的成员记录删除 (所有者即管理员)
+func (r *TransferRepository) TransferProject(ctx context.Context, transfer *model.OwnershipTransfer, previousOwner int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Project{}).Where("id = ?", transfer.ProjectID).
+			Update("created_by", transfer.ToUserID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ? AND user_id IN (?)", transfer.ProjectID, []int64{previousOwner, transfer.ToUserID}).
+			Delete(&model.ProjectMember{}).Error; err != nil {
+			return err
+		}
+		if previousOwner != 0 && previousOwner != transfer.ToUserID {
+			if err := tx.Create(&model.ProjectMember{
+				ProjectID: transfer.ProjectID,
+				UserID:    previousOwner,
+				Role:      "admin",
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(transfer).Error
+	})
+}
+
+// MoveConfigs 在同一事务中将配置及其发布、灰度曝光、签名、描述符和入站集成迁入目标项目, 并保存转移申请.
+// 目标项目缺少的环境随之创建; 版本、契约等只按配置 ID 关联的数据无需改动
+func (r *TransferRepository) MoveConfigs(ctx context.Context, transfer *model.OwnershipTransfer, configs []*model.Config) error {
+	target := *transfer.TargetProjectID
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		configIDs := make([]int64, len(configs))
+		envs := map[string]bool{}
+		for i, config := range configs {
+			configIDs[i] = config.ID
+			envs[config.Environment] = true
+		}
+
+		for env := range envs {
+			var count int64
+			if err := tx.Model(&model.ProjectEnvironment{}).
+				Where("project_id = ? AND name = ?", target, env).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+			var source model.ProjectEnvironment
+			err := tx.Where("project_id = ? AND name = ?", transfer.ProjectID, env).First(&source).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if err := tx.Create(&model.ProjectEnvironment{
+				ProjectID:   target,
+				Name:        env,
+				Description: source.Description,
+				SortOrder:   source.SortOrder,
+				Variables:   source.Variables,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&model.Config{}).Where("id IN (?)", configIDs).
+			Update("project_id", target).Error; err != nil {
+			return err
+		}
+		for _, table := range []interface{}{
+			&model.Release{},
+			&model.GrayExposure{},
+			&model.VersionSignature{},
+			&model.ProtoDescriptor{},
+			&model.InboundIntegration{},
+		} {
+			if err := tx.Model(table).Where("config_id IN (?)", configIDs).
+				Update("project_id", target).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(transfer).Error
+	})
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"confighub/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// UsageRepository 项目用量数据访问
+type UsageRepository struct {
+	db *gorm.DB
+}
+
+// NewUsageRepository 创建用量仓库
+func NewUsageRepository(db *gorm.DB) *UsageRepository {
+	return &UsageRepository{db: db}
+}
+
+// Add 累加项目当日的调用次数和监听时长
+func (r *UsageRepository) Add(ctx context.Context, projectID int64, day string, calls, watcherSeconds int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.ProjectUsage{}).
+			Where("project_id = ? AND day = ?", projectID, day).
+			Updates(map[string]interface{}{
+				"api_calls":       gorm.Expr("api_calls + ?", calls),
+				"watcher_seconds": gorm.Expr("watcher_seconds + ?", watcherSeconds),
+			})
+		if result.Error != nil || result.RowsAffected > 0 {
+			return result.Error
+		}
+		return tx.Create(&model.ProjectUsage{
+			ProjectID:      projectID,
+			Day:            day,
+			APICalls:       calls,
+			WatcherSeconds: watcherSeconds,
+		}).Error
+	})
+}
+
+// TouchKey 更新密钥的最近使用时间, 不修改 updated_at
+func (r *UsageRepository) TouchKey(ctx context.Context, keyID int64, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.ProjectKey{}).Where("id = ?", keyID).UpdateColumn("last_used_at", at).Error
+}
+
+// SetStorage 记录项目当日的存储快照
+func (r *UsageRepository) SetStorage(ctx context.Context, projectID int64, day string, bytes int64) error {
+	var usage model.ProjectUsage
+	err := r.db.WithContext(ctx).Where("project_id = ? AND day = ?", projectID, day).First(&usage).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return r.db.WithContext(ctx).Create(&model.ProjectUsage{
+			ProjectID:    projectID,
+			Day:          day,
+			StorageBytes: bytes,
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Model(&usage).Update("storage_bytes", bytes).Error
+}
+
+// StorageByProject 统计各项目配置版本内容的总字节数
+func (r *UsageRepository) StorageByProject(ctx context.Context) (map[int64]int64, error) {
+	var rows []struct {
+		ProjectID int64
+		Bytes     int64
+	}
+	err := r.db.WithContext(ctx).Table("config_versions").
+		Select("configs.project_id AS project_id, COALESCE(SUM(OCTET_LENGTH(config_versions.content)), 0) AS bytes").
+		Joins("JOIN configs ON configs.id = config_versions.config_id").
+		Group("configs.project_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		result[row.ProjectID] = row.Bytes
+	}
+	return result, nil
+}
+
+// ListRange 获取日期区间 [from, to] 内的用量记录, projectID 为 0 时不限项目
+func (r *UsageRepository) ListRange(ctx context.Context, projectID int64, from, to string) ([]*model.ProjectUsage, error) {
+	var usages []*model.ProjectUsage
+	query := r.db.WithContext(ctx).Where("day >= ? AND day <= ?", from, to)
+	if projectID > 0 {
+		query = query.Where("project_id = ?", projectID)
+	}
+	err := query.Order("project_id ASC, day ASC").Find(&usages).Error
+	return usages, err
+}
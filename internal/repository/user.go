@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"confighub/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// UserRepository 用户数据访问
+type UserRepository struct {
+	db *gorm.DB
+}
+
+// NewUserRepository 创建用户仓库
+func NewUserRepository(db *gorm.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// GetByUsername 按用户名获取用户
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	var user model.User
+	if err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SaveExternalUser 按用户名获取外部目录认证的用户, 不存在时创建 (不能以本地密码登录);
+// 已存在时同步邮箱, 邮箱已被其他用户使用时保留原邮箱. 只匹配外部用户, 同名的本地账号不会被关联
+func (r *UserRepository) SaveExternalUser(ctx context.Context, username, email string) (*model.User, error) {
+	db := r.db.WithContext(ctx)
+
+	var user model.User
+	err := db.Where("username = ? AND password_hash = ?", username, model.ExternalPasswordHash).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user = model.User{
+			Username:     username,
+			Email:        email,
+			PasswordHash: model.ExternalPasswordHash,
+			IsActive:     true,
+		}
+		if err := db.Create(&user).Error; err != nil {
+			return nil, err
+		}
+		return &user, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if email != "" && user.Email != email {
+		if err := db.Model(&user).Update("email", email).Error; err == nil {
+			user.Email = email
+		}
+	}
+	return &user, nil
+}
+
+// SyncMemberRoles 在同一事务中按 roles (项目名称 -> 角色) 同步用户在 managed 项目中的成员角色:
+// 有角色的创建或更新成员记录, 没有角色的删除成员记录; 项目所有者不受影响, 不存在的项目跳过
+func (r *UserRepository) SyncMemberRoles(ctx context.Context, userID int64, roles map[string]string, managed []string) error {
+	if len(managed) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var projects []*model.Project
+		if err := tx.Where("name IN ?", managed).Find(&projects).Error; err != nil {
+			return err
+		}
+		for _, project := range projects {
+			if project.CreatedBy == userID {
+				continue
+			}
+			role := roles[project.Name]
+
+			var member model.ProjectMember
+			err := tx.Where("project_id = ? AND user_id = ?", project.ID, userID).First(&member).Error
+			found := err == nil
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			switch {
+			case role == "" && found:
+				err = tx.Delete(&member).Error
+			case role != "" && !found:
+				err = tx.Create(&model.ProjectMember{ProjectID: project.ID, UserID: userID, Role: role}).Error
+			case role != "" && member.Role != role:
+				err = tx.Model(&member).Update("role", role).Error
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -2,12 +2,35 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"confighub/internal/model"
 
 	"gorm.io/gorm"
 )
 
+// ErrVersionConflict 提交新版本时配置的当前版本已不是期望的版本, 或版本号已被占用
+var ErrVersionConflict = errors.New("配置版本已变化")
+
+// VersionStore 版本存储, 默认为数据库 (VersionRepository), 也可以将内容保存在 Git 仓库 (GitVersionStore)
+type VersionStore interface {
+	Create(ctx context.Context, version *model.ConfigVersion) error
+	Commit(ctx context.Context, version *model.ConfigVersion, expected int) error
+	GetByConfigAndVersion(ctx context.Context, configID int64, version int) (*model.ConfigVersion, error)
+	GetByCommitHash(ctx context.Context, configID int64, hash string) (*model.ConfigVersion, error)
+	List(ctx context.Context, configID int64, origin string) ([]*model.ConfigVersion, error)
+	GetLatest(ctx context.Context, configID int64) (*model.ConfigVersion, error)
+	GetAsOf(ctx context.Context, configID int64, at time.Time) (*model.ConfigVersion, error)
+	GetVersionsSince(ctx context.Context, configID int64, sinceVersion int) ([]*model.ConfigVersion, error)
+	ListRange(ctx context.Context, configID int64, after, upTo int) ([]*model.ConfigVersion, error)
+	DeleteByConfigID(ctx context.Context, configID int64) error
+	Update(ctx context.Context, version *model.ConfigVersion) error
+}
+
+// ContentLoader 补全内容不在数据库中的版本 (git_ref 不为空), 供直接读取版本表的仓库使用
+type ContentLoader func(ctx context.Context, versions ...*model.ConfigVersion) error
+
 // VersionRepository 版本数据访问
 type VersionRepository struct {
 	db *gorm.DB
@@ -23,6 +46,37 @@ func (r *VersionRepository) Create(ctx context.Context, version *model.ConfigVer
 	return r.db.WithContext(ctx).Create(version).Error
 }
 
+// Commit 写入配置的新版本并将配置的当前版本从 expected 推进到该版本, 在同一事务中完成
+// 当前版本已不是 expected (其他写入或其他实例已提交) 或版本号已存在时返回 ErrVersionConflict, 不写入任何数据
+func (r *VersionRepository) Commit(ctx context.Context, version *model.ConfigVersion, expected int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Config{}).
+			Where("id = ? AND current_version = ?", version.ConfigID, expected).
+			Updates(map[string]interface{}{"current_version": version.Version, "updated_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVersionConflict
+		}
+		if err := tx.Create(version).Error; err != nil {
+			if isDuplicateKey(tx, err) {
+				return ErrVersionConflict
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// isDuplicateKey 错误是否为唯一约束冲突
+func isDuplicateKey(db *gorm.DB, err error) bool {
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
 // GetByConfigAndVersion 根据配置 ID 和版本号获取版本
 func (r *VersionRepository) GetByConfigAndVersion(ctx context.Context, configID int64, version int) (*model.ConfigVersion, error) {
 	var v model.ConfigVersion
@@ -33,13 +87,37 @@ func (r *VersionRepository) GetByConfigAndVersion(ctx context.Context, configID
 	return &v, nil
 }
 
-// List 获取配置的所有版本
-func (r *VersionRepository) List(ctx context.Context, configID int64) ([]*model.ConfigVersion, error) {
+// GetByCommitHash 获取提交哈希为 hash 的最近一个版本 (内容相同的版本哈希相同)
+func (r *VersionRepository) GetByCommitHash(ctx context.Context, configID int64, hash string) (*model.ConfigVersion, error) {
+	var v model.ConfigVersion
+	err := r.db.WithContext(ctx).Where("config_id = ? AND commit_hash = ?", configID, hash).Order("version DESC").First(&v).Error
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// List 获取配置的所有版本, origin 不为空时按来源过滤, automation 匹配所有 automation:<名称>
+func (r *VersionRepository) List(ctx context.Context, configID int64, origin string) ([]*model.ConfigVersion, error) {
 	var versions []*model.ConfigVersion
-	err := r.db.WithContext(ctx).Where("config_id = ?", configID).Order("version DESC").Find(&versions).Error
+	query := r.db.WithContext(ctx).Where("config_id = ?", configID)
+	query = whereOrigin(query, origin)
+	err := query.Order("version DESC").Find(&versions).Error
 	return versions, err
 }
 
+// whereOrigin 按变更来源过滤
+func whereOrigin(query *gorm.DB, origin string) *gorm.DB {
+	switch origin {
+	case "":
+		return query
+	case "automation":
+		return query.Where("origin LIKE ?", model.OriginAutomationPrefix+"%")
+	default:
+		return query.Where("origin = ?", origin)
+	}
+}
+
 // GetLatest 获取最新版本
 func (r *VersionRepository) GetLatest(ctx context.Context, configID int64) (*model.ConfigVersion, error) {
 	var version model.ConfigVersion
@@ -50,6 +128,16 @@ func (r *VersionRepository) GetLatest(ctx context.Context, configID int64) (*mod
 	return &version, nil
 }
 
+// GetAsOf 获取某一时刻的最新版本: 创建时间不晚于 at 的最后一个版本
+func (r *VersionRepository) GetAsOf(ctx context.Context, configID int64, at time.Time) (*model.ConfigVersion, error) {
+	var version model.ConfigVersion
+	err := r.db.WithContext(ctx).Where("config_id = ? AND created_at <= ?", configID, at).Order("version DESC").First(&version).Error
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
 // GetVersionsSince 获取指定版本之后的所有版本
 func (r *VersionRepository) GetVersionsSince(ctx context.Context, configID int64, sinceVersion int) ([]*model.ConfigVersion, error) {
 	var versions []*model.ConfigVersion
@@ -60,6 +148,16 @@ func (r *VersionRepository) GetVersionsSince(ctx context.Context, configID int64
 	return versions, err
 }
 
+// ListRange 获取版本号在 (after, upTo] 区间内的版本, 按版本号升序
+func (r *VersionRepository) ListRange(ctx context.Context, configID int64, after, upTo int) ([]*model.ConfigVersion, error) {
+	var versions []*model.ConfigVersion
+	err := r.db.WithContext(ctx).
+		Where("config_id = ? AND version > ? AND version <= ?", configID, after, upTo).
+		Order("version ASC").
+		Find(&versions).Error
+	return versions, err
+}
+
 // DeleteByConfigID 删除配置的所有版本
 func (r *VersionRepository) DeleteByConfigID(ctx context.Context, configID int64) error {
 	return r.db.WithContext(ctx).Where("config_id = ?", configID).Delete(&model.ConfigVersion{}).Error
@@ -0,0 +1,359 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"confighub/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// gitVersionCacheSize 缓存的版本内容条数, 提交后内容不再变化, 超出时整体清空
+const gitVersionCacheSize = 1024
+
+// gitRemoteCredential 匹配远端地址中的用户名和密码
+var gitRemoteCredential = regexp.MustCompile(`://[^/@\s]+@`)
+
+// GitVersionOptions Git 版本存储配置
+type GitVersionOptions struct {
+	Dir         string // 仓库工作目录
+	Remote      string // 远端地址, 为空时只保存在本地
+	Branch      string
+	AuthorName  string // 版本没有作者时使用
+	AuthorEmail string
+	OnError     func(err error) // 推送失败等不影响写入的错误
+}
+
+// GitVersionStore 将版本内容保存在 Git 仓库: 每个配置是一个文件, 每个版本是一次提交
+// 版本号、作者、来源等元数据仍保存在数据库, 以便按版本号查询和在事务中引用; 数据库中的 content 为空, git_ref 指向提交中的文件.
+// 没有 git_ref 的版本 (切换前的历史版本, 以及环境克隆、复制导入等在数据库事务中批量写入的版本) 从数据库读取内容
+type GitVersionStore struct {
+	*VersionRepository
+	opts GitVersionOptions
+
+	mu sync.Mutex // 串行化工作目录的写入和提交
+
+	cacheMu sync.Mutex
+	cache   map[string]string // git_ref -> 内容
+}
+
+// NewGitVersionStore 创建 Git 版本存储, 工作目录不存在时初始化仓库, 设置了远端时从远端分支开始
+func NewGitVersionStore(db *gorm.DB, opts GitVersionOptions) (*GitVersionStore, error) {
+	if opts.Branch == "" {
+		opts.Branch = "main"
+	}
+	s := &GitVersionStore{
+		VersionRepository: NewVersionRepository(db),
+		opts:              opts,
+		cache:             make(map[string]string),
+	}
+	if err := s.open(context.Background()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// open 准备工作目录
+func (s *GitVersionStore) open(ctx context.Context) error {
+	if _, err := os.Stat(filepath.Join(s.opts.Dir, ".git")); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(s.opts.Dir, 0o700); err != nil {
+		return err
+	}
+	if _, err := s.git(ctx, "init", "-q"); err != nil {
+		return err
+	}
+	if _, err := s.git(ctx, "checkout", "-q", "-B", s.opts.Branch); err != nil {
+		return err
+	}
+	if s.opts.Remote == "" {
+		return nil
+	}
+	if _, err := s.git(ctx, "remote", "add", "origin", s.opts.Remote); err != nil {
+		return err
+	}
+	heads, err := s.git(ctx, "ls-remote", "--heads", "origin", s.opts.Branch)
+	if err != nil || len(bytes.TrimSpace(heads)) == 0 {
+		// 远端分支不存在 (如空仓库) 时首次推送创建
+		return err
+	}
+	if _, err := s.git(ctx, "fetch", "-q", "origin", s.opts.Branch); err != nil {
+		return err
+	}
+	_, err = s.git(ctx, "reset", "-q", "--hard", "FETCH_HEAD")
+	return err
+}
+
+// Create 将版本内容提交到仓库, 再在数据库中记录版本元数据
+// 数据库写入失败时提交已存在, 仓库中会多出一次未被引用的提交
+func (s *GitVersionStore) Create(ctx context.Context, version *model.ConfigVersion) error {
+	ref, err := s.commit(ctx, version, "")
+	if err != nil {
+		return err
+	}
+	stored := *version
+	stored.Content = ""
+	stored.GitRef = ref
+	if err := s.VersionRepository.Create(ctx, &stored); err != nil {
+		return err
+	}
+	version.ID = stored.ID
+	version.GitRef = ref
+	version.CreatedAt = stored.CreatedAt
+	s.remember(ref, version.Content)
+	return nil
+}
+
+// Commit 将版本内容提交到仓库, 再在数据库事务中记录版本元数据并推进配置的当前版本
+// 版本冲突时仓库中会多出一次未被引用的提交, 与 Create 写入失败时相同
+func (s *GitVersionStore) Commit(ctx context.Context, version *model.ConfigVersion, expected int) error {
+	ref, err := s.commit(ctx, version, "")
+	if err != nil {
+		return err
+	}
+	stored := *version
+	stored.Content = ""
+	stored.GitRef = ref
+	if err := s.VersionRepository.Commit(ctx, &stored, expected); err != nil {
+		return err
+	}
+	version.ID = stored.ID
+	version.GitRef = ref
+	version.CreatedAt = stored.CreatedAt
+	s.remember(ref, version.Content)
+	return nil
+}
+
+// Update 修改已有版本的内容: 以新的提交保存, 版本记录指向新的提交
+func (s *GitVersionStore) Update(ctx context.Context, version *model.ConfigVersion) error {
+	ref, err := s.commit(ctx, version, "Amended: true")
+	if err != nil {
+		return err
+	}
+	stored := *version
+	stored.Content = ""
+	stored.GitRef = ref
+	if err := s.VersionRepository.Update(ctx, &stored); err != nil {
+		return err
+	}
+	version.GitRef = ref
+	s.remember(ref, version.Content)
+	return nil
+}
+
+// GetByConfigAndVersion 根据配置 ID 和版本号获取版本
+func (s *GitVersionStore) GetByConfigAndVersion(ctx context.Context, configID int64, version int) (*model.ConfigVersion, error) {
+	v, err := s.VersionRepository.GetByConfigAndVersion(ctx, configID, version)
+	if err != nil {
+		return nil, err
+	}
+	return v, s.Fill(ctx, v)
+}
+
+// GetByCommitHash 获取提交哈希为 hash 的最近一个版本
+func (s *GitVersionStore) GetByCommitHash(ctx context.Context, configID int64, hash string) (*model.ConfigVersion, error) {
+	v, err := s.VersionRepository.GetByCommitHash(ctx, configID, hash)
+	if err != nil {
+		return nil, err
+	}
+	return v, s.Fill(ctx, v)
+}
+
+// List 获取配置的所有版本
+func (s *GitVersionStore) List(ctx context.Context, configID int64, origin string) ([]*model.ConfigVersion, error) {
+	versions, err := s.VersionRepository.List(ctx, configID, origin)
+	if err != nil {
+		return nil, err
+	}
+	return versions, s.Fill(ctx, versions...)
+}
+
+// GetLatest 获取最新版本
+func (s *GitVersionStore) GetLatest(ctx context.Context, configID int64) (*model.ConfigVersion, error) {
+	v, err := s.VersionRepository.GetLatest(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+	return v, s.Fill(ctx, v)
+}
+
+// GetAsOf 获取某一时刻的最新版本
+func (s *GitVersionStore) GetAsOf(ctx context.Context, configID int64, at time.Time) (*model.ConfigVersion, error) {
+	v, err := s.VersionRepository.GetAsOf(ctx, configID, at)
+	if err != nil {
+		return nil, err
+	}
+	return v, s.Fill(ctx, v)
+}
+
+// GetVersionsSince 获取指定版本之后的所有版本
+func (s *GitVersionStore) GetVersionsSince(ctx context.Context, configID int64, sinceVersion int) ([]*model.ConfigVersion, error) {
+	versions, err := s.VersionRepository.GetVersionsSince(ctx, configID, sinceVersion)
+	if err != nil {
+		return nil, err
+	}
+	return versions, s.Fill(ctx, versions...)
+}
+
+// ListRange 获取版本号在 (after, upTo] 区间内的版本
+func (s *GitVersionStore) ListRange(ctx context.Context, configID int64, after, upTo int) ([]*model.ConfigVersion, error) {
+	versions, err := s.VersionRepository.ListRange(ctx, configID, after, upTo)
+	if err != nil {
+		return nil, err
+	}
+	return versions, s.Fill(ctx, versions...)
+}
+
+// commit 将版本内容写入配置对应的文件并提交, 返回 <提交>:<路径>
+// 内容未变化 (如只升级版本号的保存) 时同样生成提交, 保证每个版本对应一次提交
+func (s *GitVersionStore) commit(ctx context.Context, version *model.ConfigVersion, trailer string) (string, error) {
+	var config model.Config
+	if err := s.db.WithContext(ctx).First(&config, version.ConfigID).Error; err != nil {
+		return "", err
+	}
+	file := gitVersionPath(&config)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	full := filepath.Join(s.opts.Dir, filepath.FromSlash(file))
+	if err := os.MkdirAll(filepath.Dir(full), 0o700); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(full, []byte(version.Content), 0o600); err != nil {
+		return "", err
+	}
+	if _, err := s.git(ctx, "add", "--", file); err != nil {
+		return "", err
+	}
+
+	subject := version.CommitMessage
+	if subject == "" {
+		subject = fmt.Sprintf("%s v%d", config.Name, version.Version)
+	}
+	trailers := fmt.Sprintf("Config-ID: %d\nConfig-Version: %d", config.ID, version.Version)
+	if version.Origin != "" {
+		trailers += "\nOrigin: " + version.Origin
+	}
+	if trailer != "" {
+		trailers += "\n" + trailer
+	}
+	author := version.Author
+	if author == "" {
+		author = s.opts.AuthorName
+	}
+	if _, err := s.git(ctx,
+		"-c", "user.name="+s.opts.AuthorName,
+		"-c", "user.email="+s.opts.AuthorEmail,
+		"commit", "-q", "--allow-empty",
+		"--author", fmt.Sprintf("%s <%s>", author, s.opts.AuthorEmail),
+		"-m", subject, "-m", trailers); err != nil {
+		return "", err
+	}
+	sha, err := s.git(ctx, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	if s.opts.Remote != "" {
+		// 推送失败不影响写入, 下次提交时一并推送
+		if _, err := s.git(ctx, "push", "-q", "origin", "HEAD:"+s.opts.Branch); err != nil && s.opts.OnError != nil {
+			s.opts.OnError(err)
+		}
+	}
+	return string(bytes.TrimSpace(sha)) + ":" + file, nil
+}
+
+// Fill 从仓库读取保存在 Git 中的版本内容, 满足 ContentLoader
+func (s *GitVersionStore) Fill(ctx context.Context, versions ...*model.ConfigVersion) error {
+	for _, v := range versions {
+		if v.GitRef == "" {
+			continue
+		}
+		s.cacheMu.Lock()
+		content, ok := s.cache[v.GitRef]
+		s.cacheMu.Unlock()
+		if !ok {
+			out, err := s.git(ctx, "cat-file", "blob", v.GitRef)
+			if err != nil {
+				return err
+			}
+			content = string(out)
+			s.remember(v.GitRef, content)
+		}
+		v.Content = content
+	}
+	return nil
+}
+
+// remember 缓存已提交的内容
+func (s *GitVersionStore) remember(ref, content string) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	if len(s.cache) >= gitVersionCacheSize {
+		s.cache = make(map[string]string)
+	}
+	s.cache[ref] = content
+}
+
+// git 在工作目录中执行 git 命令, 返回标准输出原文
+func (s *GitVersionStore) git(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = s.opts.Dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		op := args[0]
+		if op == "-c" {
+			op = "commit"
+		}
+		output := strings.TrimSpace(stderr.String())
+		if output == "" {
+			output = err.Error()
+		}
+		return nil, fmt.Errorf("git %s 失败: %s", op, gitRemoteCredential.ReplaceAllString(output, "://***@"))
+	}
+	return stdout.Bytes(), nil
+}
+
+// gitVersionPath 配置在仓库中的文件: <项目 ID>/<命名空间>/<环境>/<名称><扩展名>
+func gitVersionPath(config *model.Config) string {
+	ext := "." + config.FileType
+	switch config.FileType {
+	case "hcl", "kv":
+		// HCL 内容转换为 JSON 保存, kv 内容本身为 JSON 对象
+		ext = ".json"
+	case "protobuf":
+		ext = ".proto"
+	}
+	return path.Join(
+		strconv.FormatInt(config.ProjectID, 10),
+		gitPathSegment(config.Namespace),
+		gitPathSegment(config.Environment),
+		gitPathSegment(config.Name)+ext,
+	)
+}
+
+// gitPathSegment 转义路径中的一段, 避免分隔符和 . / .. 越出目录
+func gitPathSegment(s string) string {
+	escaped := url.PathEscape(s)
+	if strings.HasPrefix(escaped, ".") {
+		escaped = "%2E" + escaped[1:]
+	}
+	return escaped
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"confighub/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// WebhookRepository Webhook 及投递记录数据访问
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository 创建 Webhook 仓库
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// Create 创建 Webhook
+func (r *WebhookRepository) Create(ctx context.Context, webhook *model.Webhook) error {
+	return r.db.WithContext(ctx).Create(webhook).Error
+}
+
+// GetByID 根据 ID 获取 Webhook
+func (r *WebhookRepository) GetByID(ctx context.Context, id int64) (*model.Webhook, error) {
+	var webhook model.Webhook
+	err := r.db.WithContext(ctx).First(&webhook, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListByProject 获取项目下的 Webhook
+func (r *WebhookRepository) ListByProject(ctx context.Context, projectID int64) ([]*model.Webhook, error) {
+	var webhooks []*model.Webhook
+	err := r.db.WithContext(ctx).Where("project_id = ?", projectID).Order("id ASC").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// ListActiveByProject 获取项目下启用的 Webhook
+func (r *WebhookRepository) ListActiveByProject(ctx context.Context, projectID int64) ([]*model.Webhook, error) {
+	var webhooks []*model.Webhook
+	err := r.db.WithContext(ctx).Where("project_id = ? AND is_active = ?", projectID, true).Order("id ASC").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// Update 更新 Webhook
+func (r *WebhookRepository) Update(ctx context.Context, webhook *model.Webhook) error {
+	return r.db.WithContext(ctx).Save(webhook).Error
+}
+
+// Delete 删除 Webhook 及其投递记录
+func (r *WebhookRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Webhook{}, id).Error
+	})
+}
+
+// CreateDelivery 创建投递记录
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Create(delivery).Error
+}
+
+// UpdateDelivery 更新投递记录
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Save(delivery).Error
+}
+
+// ListDueDeliveries 获取到达重试时间的待投递记录
+func (r *WebhookRepository) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryPending, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ListDeliveries 获取 Webhook 最近的投递记录, status 为空表示全部
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID int64, status string, limit int) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	query := r.db.WithContext(ctx).Where("webhook_id = ?", webhookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// GetDelivery 根据 ID 获取投递记录
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id int64) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := r.db.WithContext(ctx).First(&delivery, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListUnreplayedFailed 获取尚未重放过的失败投递记录, 按时间正序
+func (r *WebhookRepository) ListUnreplayedFailed(ctx context.Context, webhookID int64, limit int) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	replayed := r.db.Model(&model.WebhookDelivery{}).Select("replay_of").Where("webhook_id = ? AND replay_of IS NOT NULL", webhookID)
+	err := r.db.WithContext(ctx).
+		Where("webhook_id = ? AND status = ? AND id NOT IN (?)", webhookID, model.WebhookDeliveryFailed, replayed).
+		Order("id ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ClaimDelivery 认领待投递记录并将下次重试时间推迟到 lease, 多实例部署时避免重复投递
+func (r *WebhookRepository) ClaimDelivery(ctx context.Context, delivery *model.WebhookDelivery, lease time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, model.WebhookDeliveryPending, delivery.NextAttemptAt).
+		Update("next_attempt_at", lease)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	delivery.NextAttemptAt = &lease
+	return true, nil
+}
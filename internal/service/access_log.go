@@ -0,0 +1,118 @@
+package service
+
+import (
+	"math/rand"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// AccessLogService 访问日志服务
+// 用于公开读取/监听等高频接口, 以结构化 JSON 输出到 stdout 或文件并按比例采样,
+// 不写入数据库; 变更类和管理类操作仍由 AuditService 记录
+type AccessLogService struct {
+	logger     *zap.Logger
+	sampleRate float64
+}
+
+// AccessLogEntry 访问日志条目
+type AccessLogEntry struct {
+	ProjectID   int64
+	AccessKeyID int64
+	ConfigName  string
+	Namespace   string
+	Environment string
+	Method      string
+	Path        string
+	Status      int
+	Latency     time.Duration
+	IPAddress   string
+	UserAgent   string
+	ClientID    string // SDK 实例标识 (X-Client-ID), 与灰度分流使用的标识一致
+	Labels      string // SDK 实例标签 (X-Client-Labels), 如 region=eu-west,zone=a
+}
+
+// NewAccessLogService 创建访问日志服务
+// output 为 stdout、stderr 或文件路径; enabled 为 false 时不输出任何日志
+func NewAccessLogService(enabled bool, output string, sampleRate float64) (*AccessLogService, error) {
+	if !enabled {
+		return &AccessLogService{logger: zap.NewNop()}, nil
+	}
+
+	if output == "" {
+		output = "stdout"
+	}
+
+	cfg := zap.NewProductionConfig()
+	cfg.OutputPaths = []string{output}
+	cfg.Sampling = nil // 采样由 sampleRate 控制
+	cfg.DisableCaller = true
+	cfg.DisableStacktrace = true
+	cfg.EncoderConfig.MessageKey = "msg"
+	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	logger, err := cfg.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAccessLogServiceWithLogger(logger.Named("access"), sampleRate), nil
+}
+
+// NewAccessLogServiceWithLogger 使用已有 logger 创建访问日志服务
+func NewAccessLogServiceWithLogger(logger *zap.Logger, sampleRate float64) *AccessLogService {
+	if sampleRate < 0 {
+		sampleRate = 0
+	}
+	if sampleRate > 1 {
+		sampleRate = 1
+	}
+	return &AccessLogService{
+		logger:     logger,
+		sampleRate: sampleRate,
+	}
+}
+
+// Log 记录访问日志 (成功请求按采样率记录, 失败请求始终记录)
+func (s *AccessLogService) Log(entry *AccessLogEntry) {
+	if !s.shouldSample(entry.Status) {
+		return
+	}
+
+	s.logger.Info("access",
+		zap.Int64("project_id", entry.ProjectID),
+		zap.Int64("access_key_id", entry.AccessKeyID),
+		zap.String("config_name", entry.ConfigName),
+		zap.String("namespace", entry.Namespace),
+		zap.String("environment", entry.Environment),
+		zap.String("method", entry.Method),
+		zap.String("path", entry.Path),
+		zap.Int("status", entry.Status),
+		zap.Duration("latency", entry.Latency),
+		zap.String("ip", entry.IPAddress),
+		zap.String("user_agent", entry.UserAgent),
+		zap.String("client_id", entry.ClientID),
+		zap.String("client_labels", entry.Labels),
+		zap.Float64("sample_rate", s.sampleRate),
+	)
+}
+
+// Sync 刷新缓冲区
+func (s *AccessLogService) Sync() error {
+	return s.logger.Sync()
+}
+
+// shouldSample 判断是否记录本次访问
+func (s *AccessLogService) shouldSample(status int) bool {
+	if status >= 400 {
+		return true
+	}
+	if s.sampleRate >= 1 {
+		return true
+	}
+	if s.sampleRate <= 0 {
+		return false
+	}
+	return rand.Float64() < s.sampleRate
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+)
+
+// 访问审查中的主体类型
+const (
+	PrincipalUser      = "user"      // 项目所有者或成员
+	PrincipalKey       = "key"       // 访问密钥
+	PrincipalAnonymous = "anonymous" // 公开项目的匿名访问
+)
+
+// AccessReviewEntry 项目中一个用户或密钥的访问权限
+type AccessReviewEntry struct {
+	ProjectID       int64      `json:"project_id"`
+	ProjectName     string     `json:"project_name"`
+	ProjectArchived bool       `json:"project_archived"`
+	PrincipalType   string     `json:"principal_type"` // user, key, anonymous
+	PrincipalID     int64      `json:"principal_id,omitempty"`
+	Name            string     `json:"name"`                        // 用户名或密钥名称
+	Email           string     `json:"email,omitempty"`             // 仅用户
+	AccessKeyPrefix string     `json:"access_key_prefix,omitempty"` // 仅密钥, 只包含前缀以便辨认, 不能用于调用
+	Role            string     `json:"role,omitempty"`              // 用户在项目中的角色, 项目创建者为 owner
+	Permissions     []string   `json:"permissions"`
+	Configs         []string   `json:"configs,omitempty"` // 密钥可访问的配置范围, 为空表示项目内全部配置
+	Status          string     `json:"status"`            // active, disabled, expired
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt      *time.Time `json:"last_used_at,omitempty"`     // 密钥最近调用公开接口的时间, 用户最近登录的时间
+	LastActivityAt  *time.Time `json:"last_activity_at,omitempty"` // 用户在该项目最近一条审计日志的时间
+	GrantedAt       *time.Time `json:"granted_at,omitempty"`       // 成员加入、密钥创建或项目创建的时间
+}
+
+// AccessReviewService 访问审查: 按项目列出所有用户和密钥的权限、最近使用时间和有效期
+type AccessReviewService struct {
+	reviewRepo *repository.AccessReviewRepository
+}
+
+// NewAccessReviewService 创建访问审查服务
+func NewAccessReviewService(reviewRepo *repository.AccessReviewRepository) *AccessReviewService {
+	return &AccessReviewService{reviewRepo: reviewRepo}
+}
+
+// Review 生成 userID 作为所有者或管理员的项目的访问审查清单, projectID 为 0 时包含全部这些项目 (含已归档)
+// 每个项目依次列出所有者、成员、密钥, 公开项目最后附带匿名访问
+func (s *AccessReviewService) Review(ctx context.Context, projectID, userID int64) ([]*AccessReviewEntry, error) {
+	projects, err := s.reviewRepo.Projects(ctx, projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if projectID > 0 && len(projects) == 0 {
+		return nil, ErrProjectNotFound
+	}
+
+	projectIDs := make([]int64, 0, len(projects))
+	ownerIDs := make([]int64, 0, len(projects))
+	for _, project := range projects {
+		projectIDs = append(projectIDs, project.ID)
+		ownerIDs = append(ownerIDs, project.CreatedBy)
+	}
+	owners, err := s.reviewRepo.Users(ctx, ownerIDs)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.reviewRepo.Members(ctx, projectIDs)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := s.reviewRepo.Keys(ctx, projectIDs)
+	if err != nil {
+		return nil, err
+	}
+	activity, err := s.reviewRepo.LastActivity(ctx, projectIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	usersByID := make(map[int64]*model.User, len(owners))
+	for _, user := range owners {
+		usersByID[user.ID] = user
+	}
+	membersByProject := make(map[int64][]*repository.AccessMember)
+	for _, member := range members {
+		membersByProject[member.ProjectID] = append(membersByProject[member.ProjectID], member)
+	}
+	keysByProject := make(map[int64][]*model.ProjectKey)
+	for _, key := range keys {
+		keysByProject[key.ProjectID] = append(keysByProject[key.ProjectID], key)
+	}
+	lastActivity := func(projectID, userID int64) *time.Time {
+		if at, ok := activity[[2]int64{projectID, userID}]; ok {
+			return &at
+		}
+		return nil
+	}
+
+	now := time.Now()
+	entries := []*AccessReviewEntry{}
+	for _, project := range projects {
+		newEntry := func(principalType string) *AccessReviewEntry {
+			return &AccessReviewEntry{
+				ProjectID:       project.ID,
+				ProjectName:     project.Name,
+				ProjectArchived: project.ArchivedAt != nil,
+				PrincipalType:   principalType,
+				Status:          "active",
+			}
+		}
+
+		// 项目创建者视为管理员, 与成员记录重复时以创建者为准
+		if owner, ok := usersByID[project.CreatedBy]; ok {
+			entry := newEntry(PrincipalUser)
+			entry.PrincipalID = owner.ID
+			entry.Name = owner.Username
+			entry.Email = owner.Email
+			entry.Role = "owner"
+			entry.Permissions = permissionNames(model.RolePermissions("admin"))
+			entry.LastUsedAt = owner.LastLoginAt
+			entry.LastActivityAt = lastActivity(project.ID, owner.ID)
+			entry.GrantedAt = &project.CreatedAt
+			if !owner.IsActive {
+				entry.Status = "disabled"
+			}
+			entries = append(entries, entry)
+		}
+		for _, member := range membersByProject[project.ID] {
+			if member.UserID == project.CreatedBy {
+				continue
+			}
+			entry := newEntry(PrincipalUser)
+			entry.PrincipalID = member.UserID
+			entry.Name = member.Username
+			entry.Email = member.Email
+			entry.Role = member.Role
+			entry.Permissions = permissionNames(model.RolePermissions(member.Role))
+			entry.LastUsedAt = member.LastLoginAt
+			entry.LastActivityAt = lastActivity(project.ID, member.UserID)
+			entry.GrantedAt = &member.CreatedAt
+			if !member.IsActive {
+				entry.Status = "disabled"
+			}
+			entries = append(entries, entry)
+		}
+
+		for _, key := range keysByProject[project.ID] {
+			var permissions model.Permissions
+			if key.Permissions != "" {
+				json.Unmarshal([]byte(key.Permissions), &permissions)
+			}
+			entry := newEntry(PrincipalKey)
+			entry.PrincipalID = key.ID
+			entry.Name = key.Name
+			entry.AccessKeyPrefix = maskAccessKey(key.AccessKey)
+			entry.Permissions = permissionNames(permissions)
+			entry.Configs = permissions.Configs
+			entry.ExpiresAt = key.ExpiresAt
+			entry.LastUsedAt = key.LastUsedAt
+			entry.GrantedAt = &key.CreatedAt
+			switch {
+			case !key.IsActive:
+				entry.Status = "disabled"
+			case key.ExpiresAt != nil && key.ExpiresAt.Before(now):
+				entry.Status = "expired"
+			}
+			entries = append(entries, entry)
+		}
+
+		if project.AccessMode == "public" {
+			permissions := model.Permissions{Read: true}
+			if project.PublicPermissions != "" {
+				json.Unmarshal([]byte(project.PublicPermissions), &permissions)
+			}
+			entry := newEntry(PrincipalAnonymous)
+			entry.Name = "anonymous"
+			entry.Permissions = permissionNames(permissions)
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// ExportCSV 导出访问审查清单为 CSV, 多个权限和配置范围以空格分隔
+func (s *AccessReviewService) ExportCSV(ctx context.Context, projectID, userID int64, w io.Writer) error {
+	entries, err := s.Review(ctx, projectID, userID)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+
+	header := []string{"project_id", "project_name", "project_archived", "principal_type", "principal_id", "name", "email", "access_key_prefix", "role", "permissions", "configs", "status", "expires_at", "last_used_at", "last_activity_at", "granted_at"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		principalID := ""
+		if e.PrincipalID > 0 {
+			principalID = strconv.FormatInt(e.PrincipalID, 10)
+		}
+		row := []string{
+			strconv.FormatInt(e.ProjectID, 10),
+			e.ProjectName,
+			strconv.FormatBool(e.ProjectArchived),
+			e.PrincipalType,
+			principalID,
+			e.Name,
+			e.Email,
+			e.AccessKeyPrefix,
+			e.Role,
+			strings.Join(e.Permissions, " "),
+			strings.Join(e.Configs, " "),
+			e.Status,
+			formatReviewTime(e.ExpiresAt),
+			formatReviewTime(e.LastUsedAt),
+			formatReviewTime(e.LastActivityAt),
+			formatReviewTime(e.GrantedAt),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExportJSON 导出访问审查清单为 JSON
+func (s *AccessReviewService) ExportJSON(ctx context.Context, projectID, userID int64, w io.Writer) error {
+	entries, err := s.Review(ctx, projectID, userID)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
+
+// accessKeyPrefixLen 访问审查中保留的 Access Key 长度 (含 ak_ 前缀)
+const accessKeyPrefixLen = 8
+
+// maskAccessKey 只保留 Access Key 的前缀, 导出的清单不能用于调用公开接口
+func maskAccessKey(accessKey string) string {
+	if len(accessKey) <= accessKeyPrefixLen {
+		return "****"
+	}
+	return accessKey[:accessKeyPrefixLen] + "****"
+}
+
+// permissionNames 已授予的权限名称
+func permissionNames(p model.Permissions) []string {
+	names := []string{}
+	for _, perm := range []struct {
+		name    string
+		granted bool
+	}{
+		{"read", p.Read},
+		{"write", p.Write},
+		{"delete", p.Delete},
+		{"release", p.Release},
+		{"admin", p.Admin},
+		{"decrypt", p.Decrypt},
+	} {
+		if perm.granted {
+			names = append(names, perm.name)
+		}
+	}
+	return names
+}
+
+// formatReviewTime 以 RFC 3339 (UTC) 格式化时间, 为空时返回空字符串
+func formatReviewTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
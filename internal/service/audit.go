@@ -25,7 +25,12 @@ func NewAuditService(auditRepo *repository.AuditRepository) *AuditService {
 }
 
 // Log 记录审计日志
+// 处理器在操作成功后写入审计日志, 未指定状态码时记为 200; 请求体中的凭据写入前隐去
 func (s *AuditService) Log(ctx context.Context, entry *model.AuditLog) error {
+	if entry.StatusCode == 0 {
+		entry.StatusCode = 200
+	}
+	entry.RequestBody = RedactCredentials(entry.RequestBody)
 	return s.auditRepo.Create(ctx, entry)
 }
 
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"confighub/internal/repository"
+)
+
+var ErrInvalidChaos = errors.New("无效的故障注入参数")
+
+// 故障注入的上限, 避免测试接口本身压垮服务
+const (
+	chaosMaxEvents          = 1000             // 每个配置最多注入的事件数
+	chaosMaxInterval        = time.Minute      // 事件间隔上限
+	chaosMaxDelay           = 5 * time.Minute  // 投递延迟 (含抖动) 上限
+	chaosDefaultFaultWindow = 10 * time.Minute // 延迟未指定持续时间时的默认值
+	chaosMaxFaultWindow     = 24 * time.Hour
+)
+
+// WatchFault 项目注入的投递延迟: 到期前该项目的变更推迟分发给监听连接
+type WatchFault struct {
+	ProjectID int64     `json:"project_id"`
+	DelayMs   int       `json:"delay_ms"`
+	JitterMs  int       `json:"jitter_ms"` // 在延迟基础上随机增加 0 ~ jitter_ms, 同一连接收到的变更可能乱序
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// InjectEventsRequest 注入伪造变更事件的请求
+type InjectEventsRequest struct {
+	ConfigID    int64  `json:"config_id"`   // 为空时注入到项目下所有配置
+	Environment string `json:"environment"` // 为空时不限环境
+	Count       int    `json:"count"`       // 每个配置的事件数, 默认 1
+	IntervalMs  int    `json:"interval_ms"` // 相邻两轮事件的间隔, 0 表示一次性注入
+	ChangeType  string `json:"change_type"` // inherit (默认), update 或 delete
+}
+
+// InjectEventsResult 注入结果, 有间隔时事件在后台继续注入
+type InjectEventsResult struct {
+	Configs    int  `json:"configs"`
+	Events     int  `json:"events"`
+	Background bool `json:"background"`
+}
+
+// SetDelayRequest 设置投递延迟的请求
+type SetDelayRequest struct {
+	DelayMs         int `json:"delay_ms"`
+	JitterMs        int `json:"jitter_ms"`
+	DurationSeconds int `json:"duration_seconds"` // 延迟持续时间, 默认 10 分钟
+}
+
+// ChaosService 监听链路故障注入, 用于在测试环境验证客户端对频繁变更和重连风暴的处理
+// 故障只作用于本实例的监听连接; 伪造的事件不经过进程内监听器, 不会触发缓存失效、Webhook 或事件日志
+type ChaosService struct {
+	notifySvc   *NotificationService
+	configRepo  *repository.ConfigRepository
+	projectRepo *repository.ProjectRepository
+}
+
+// NewChaosService 创建故障注入服务
+func NewChaosService(notifySvc *NotificationService, configRepo *repository.ConfigRepository, projectRepo *repository.ProjectRepository) *ChaosService {
+	return &ChaosService{
+		notifySvc:   notifySvc,
+		configRepo:  configRepo,
+		projectRepo: projectRepo,
+	}
+}
+
+// InjectEvents 向项目的监听连接推送伪造的变更事件, 版本号为配置的当前版本
+// inherit 事件会使长轮询返回当前内容, update 事件只推送给 SSE 事件流, delete 事件使长轮询返回 410
+func (s *ChaosService) InjectEvents(ctx context.Context, projectID int64, req *InjectEventsRequest) (*InjectEventsResult, error) {
+	if _, err := s.projectRepo.GetByID(ctx, projectID); err != nil {
+		return nil, ErrProjectNotFound
+	}
+	if req.Count == 0 {
+		req.Count = 1
+	}
+	if req.ChangeType == "" {
+		req.ChangeType = "inherit"
+	}
+	interval := time.Duration(req.IntervalMs) * time.Millisecond
+	switch {
+	case req.Count < 0 || req.Count > chaosMaxEvents:
+		return nil, fmt.Errorf("%w: count 须在 1 ~ %d 之间", ErrInvalidChaos, chaosMaxEvents)
+	case req.IntervalMs < 0 || interval > chaosMaxInterval:
+		return nil, fmt.Errorf("%w: interval_ms 须在 0 ~ %d 之间", ErrInvalidChaos, chaosMaxInterval.Milliseconds())
+	case req.ChangeType != "inherit" && req.ChangeType != "update" && req.ChangeType != "delete":
+		return nil, fmt.Errorf("%w: 不支持的 change_type %q", ErrInvalidChaos, req.ChangeType)
+	}
+
+	configs, err := s.configRepo.List(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	var changes []*ConfigChange
+	for _, config := range configs {
+		if (req.ConfigID != 0 && config.ID != req.ConfigID) || (req.Environment != "" && config.Environment != req.Environment) {
+			continue
+		}
+		changes = append(changes, &ConfigChange{
+			ProjectID:  projectID,
+			ConfigID:   config.ID,
+			ConfigName: config.Name,
+			Namespace:  config.Namespace,
+			Env:        config.Environment,
+			Version:    config.CurrentVersion,
+			ChangeType: req.ChangeType,
+		})
+	}
+	if req.ConfigID != 0 && len(changes) == 0 {
+		return nil, ErrConfigNotFound
+	}
+
+	result := &InjectEventsResult{
+		Configs:    len(changes),
+		Events:     len(changes) * req.Count,
+		Background: interval > 0 && req.Count > 1,
+	}
+	if !result.Background {
+		for i := 0; i < req.Count; i++ {
+			s.publish(changes)
+		}
+		return result, nil
+	}
+
+	// 按轮注入, 不随请求结束而停止
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		s.publish(changes)
+		for i := 1; i < req.Count; i++ {
+			<-ticker.C
+			s.publish(changes)
+		}
+	}()
+	return result, nil
+}
+
+// publish 分发一轮伪造事件, 每次分发使用新的副本, 避免监听方持有同一对象
+func (s *ChaosService) publish(changes []*ConfigChange) {
+	for _, change := range changes {
+		event := *change
+		s.notifySvc.publish(&event)
+	}
+}
+
+// SetDelay 为项目设置投递延迟, 覆盖已有的设置
+func (s *ChaosService) SetDelay(ctx context.Context, projectID int64, req *SetDelayRequest) (*WatchFault, error) {
+	if _, err := s.projectRepo.GetByID(ctx, projectID); err != nil {
+		return nil, ErrProjectNotFound
+	}
+	window := time.Duration(req.DurationSeconds) * time.Second
+	if req.DurationSeconds == 0 {
+		window = chaosDefaultFaultWindow
+	}
+	switch {
+	case req.DelayMs <= 0 || req.JitterMs < 0 || time.Duration(req.DelayMs+req.JitterMs)*time.Millisecond > chaosMaxDelay:
+		return nil, fmt.Errorf("%w: delay_ms 须大于 0, 且与 jitter_ms 之和不超过 %d", ErrInvalidChaos, chaosMaxDelay.Milliseconds())
+	case window <= 0 || window > chaosMaxFaultWindow:
+		return nil, fmt.Errorf("%w: duration_seconds 须在 1 ~ %d 之间", ErrInvalidChaos, int(chaosMaxFaultWindow.Seconds()))
+	}
+
+	fault := &WatchFault{
+		ProjectID: projectID,
+		DelayMs:   req.DelayMs,
+		JitterMs:  req.JitterMs,
+		ExpiresAt: time.Now().Add(window),
+	}
+	s.notifySvc.faultMu.Lock()
+	defer s.notifySvc.faultMu.Unlock()
+	s.notifySvc.faults[projectID] = fault
+	return fault, nil
+}
+
+// ClearDelay 清除项目的投递延迟, 已推迟的变更仍按原定时间分发; 返回是否存在未到期的延迟
+func (s *ChaosService) ClearDelay(projectID int64) bool {
+	s.notifySvc.faultMu.Lock()
+	defer s.notifySvc.faultMu.Unlock()
+
+	fault, ok := s.notifySvc.faults[projectID]
+	delete(s.notifySvc.faults, projectID)
+	return ok && time.Now().Before(fault.ExpiresAt)
+}
+
+// Faults 列出未到期的投递延迟, 同时清理已到期的设置
+func (s *ChaosService) Faults() []*WatchFault {
+	s.notifySvc.faultMu.Lock()
+	defer s.notifySvc.faultMu.Unlock()
+
+	now := time.Now()
+	faults := []*WatchFault{}
+	for projectID, fault := range s.notifySvc.faults {
+		if !now.Before(fault.ExpiresAt) {
+			delete(s.notifySvc.faults, projectID)
+			continue
+		}
+		faults = append(faults, fault)
+	}
+	sort.Slice(faults, func(i, j int) bool { return faults[i].ProjectID < faults[j].ProjectID })
+	return faults
+}
+
+// Disconnect 断开项目的监听连接, percent 为断开的比例 (1-100); 返回断开数量
+// 与吊销不同, 连接按正常结束处理 (长轮询返回 304, 事件流关闭), 客户端会立即重新连接
+func (s *ChaosService) Disconnect(ctx context.Context, projectID int64, percent int) (int, error) {
+	if _, err := s.projectRepo.GetByID(ctx, projectID); err != nil {
+		return 0, ErrProjectNotFound
+	}
+	if percent == 0 {
+		percent = 100
+	}
+	if percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("%w: percent 须在 1 ~ 100 之间", ErrInvalidChaos)
+	}
+	return s.notifySvc.disconnect(func(sub *Subscription) bool {
+		return sub.ProjectID == projectID && rand.Intn(100) < percent
+	}), nil
+}
+
+// faultDelay 项目当前注入的投递延迟, 未设置或已到期时为 0
+func (s *NotificationService) faultDelay(projectID int64) time.Duration {
+	s.faultMu.RLock()
+	fault, ok := s.faults[projectID]
+	s.faultMu.RUnlock()
+	if !ok || !time.Now().Before(fault.ExpiresAt) {
+		return 0
+	}
+	delay := time.Duration(fault.DelayMs) * time.Millisecond
+	if fault.JitterMs > 0 {
+		delay += time.Duration(rand.Intn(fault.JitterMs+1)) * time.Millisecond
+	}
+	return delay
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+
+	"confighub/internal/model"
+)
+
+// VersionRef 指向某个配置的某个版本, Version 为 0 表示当前版本
+type VersionRef struct {
+	ConfigID int64 `json:"config_id"`
+	Version  int   `json:"version"`
+}
+
+// CompareSide 对比的一侧
+type CompareSide struct {
+	ConfigID    int64  `json:"config_id"`
+	Name        string `json:"name"`
+	Namespace   string `json:"namespace"`
+	Environment string `json:"environment"`
+	FileType    string `json:"file_type"`
+	Version     int    `json:"version"`
+	CommitHash  string `json:"commit_hash"`
+}
+
+// CompareResult 任意两个版本的对比结果
+type CompareResult struct {
+	From       CompareSide    `json:"from"`
+	To         CompareSide    `json:"to"`
+	Identical  bool           `json:"identical"`
+	Lines      []LineDiff     `json:"lines"`
+	Summary    map[string]int `json:"summary"`
+	Structural []JSONDiff     `json:"structural"`
+	// RulesApplied 是否应用了源配置的对比忽略规则
+	RulesApplied bool `json:"rules_applied"`
+	// StructuralError 任一侧内容无法解析为结构化数据时的原因, 此时仅返回行级差异
+	StructuralError string `json:"structural_error,omitempty"`
+}
+
+// Compare 对比任意两个 (配置, 版本), 支持跨环境及跨配置 (如改名后的配置) 对比
+// 同时返回行级差异和结构化差异; YAML 内容先转换为 JSON 再做结构化对比
+// 设置了描述符的 protobuf 配置按描述符渲染为 JSON 后对比, 二进制内容也可逐行对比
+// applyRules 为 true 时应用源配置上的对比忽略规则
+func (s *VersionService) Compare(ctx context.Context, from, to VersionRef, applyRules bool) (*CompareResult, error) {
+	fromSide, fromContent, err := s.resolveVersionRef(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	toSide, toContent, err := s.resolveVersionRef(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	fromContent = s.renderProto(ctx, fromSide, fromContent)
+	toContent = s.renderProto(ctx, toSide, toContent)
+
+	diffSvc := NewDiffService()
+	var rules *DiffRules
+	if applyRules {
+		if rules, err = s.diffRules(ctx, from.ConfigID); err != nil {
+			return nil, err
+		}
+		diffSvc = diffSvc.WithRules(rules)
+	}
+
+	lines := diffSvc.DiffLines(fromContent, toContent)
+	result := &CompareResult{
+		From:         *fromSide,
+		To:           *toSide,
+		Identical:    fromContent == toContent,
+		Lines:        lines,
+		Summary:      diffSvc.GetDiffSummary(lines),
+		Structural:   []JSONDiff{},
+		RulesApplied: rules != nil,
+	}
+
+	fromJSON, err := structuredContent(fromSide.FileType, fromContent)
+	if err != nil {
+		result.StructuralError = "源版本: " + err.Error()
+		return result, nil
+	}
+	toJSON, err := structuredContent(toSide.FileType, toContent)
+	if err != nil {
+		result.StructuralError = "目标版本: " + err.Error()
+		return result, nil
+	}
+
+	structural, err := diffSvc.DiffJSON(fromJSON, toJSON)
+	if err != nil {
+		result.StructuralError = err.Error()
+		return result, nil
+	}
+	if structural != nil {
+		result.Structural = structural
+	}
+	return result, nil
+}
+
+// renderProto 将 protobuf 配置的内容按描述符渲染为 JSON, 并将该侧标记为 json; 无法渲染时原样返回
+func (s *VersionService) renderProto(ctx context.Context, side *CompareSide, content string) string {
+	if side.FileType != "protobuf" {
+		return content
+	}
+	rendered, ok := s.schemaSvc.protoJSON(ctx, side.ConfigID, content)
+	if !ok {
+		return content
+	}
+	side.FileType = "json"
+	return rendered
+}
+
+// diffRules 读取配置的对比忽略规则
+func (s *VersionService) diffRules(ctx context.Context, configID int64) (*DiffRules, error) {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+	return ParseDiffRules(config.DiffRules)
+}
+
+// resolveVersionRef 加载版本引用对应的配置和内容
+func (s *VersionService) resolveVersionRef(ctx context.Context, ref VersionRef) (*CompareSide, string, error) {
+	config, err := s.configRepo.GetByID(ctx, ref.ConfigID)
+	if err != nil {
+		return nil, "", ErrConfigNotFound
+	}
+
+	versionNum := ref.Version
+	if versionNum == 0 {
+		versionNum = config.CurrentVersion
+	}
+	version, err := s.versionRepo.GetByConfigAndVersion(ctx, config.ID, versionNum)
+	if err != nil {
+		return nil, "", ErrVersionNotFound
+	}
+
+	return compareSide(config, version), version.Content, nil
+}
+
+// compareSide 构造对比一侧的描述
+func compareSide(config *model.Config, version *model.ConfigVersion) *CompareSide {
+	return &CompareSide{
+		ConfigID:    config.ID,
+		Name:        config.Name,
+		Namespace:   config.Namespace,
+		Environment: config.Environment,
+		FileType:    config.FileType,
+		Version:     version.Version,
+		CommitHash:  version.CommitHash,
+	}
+}
+
+// structuredContent 将配置内容规范化为 JSON, 用于结构化对比
+func structuredContent(fileType, content string) (string, error) {
+	parser := NewParser()
+	var result *ParseResult
+	var err error
+	switch fileType {
+	case "yaml":
+		result, err = parser.ParseYAML(content)
+	default:
+		result, err = parser.ParseJSON(content)
+	}
+	if err != nil {
+		return "", err
+	}
+	return result.Content, nil
+}
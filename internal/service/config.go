@@ -6,10 +6,14 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"regexp"
+	"strings"
 
 	"confighub/internal/model"
 	"confighub/internal/repository"
+	"confighub/internal/tracing"
 
+	"go.opentelemetry.io/otel/attribute"
 	"gopkg.in/yaml.v3"
 )
 
@@ -18,23 +22,79 @@ var (
 	ErrConfigNameExists   = errors.New("配置名称已存在")
 	ErrInvalidJSON        = errors.New("无效的 JSON 格式")
 	ErrInvalidYAML        = errors.New("无效的 YAML 格式")
+	ErrInvalidHCL         = errors.New("无效的 HCL 格式")
 	ErrInvalidFileType    = errors.New("不支持的文件类型")
+	ErrInvalidOrigin      = errors.New("无效的变更来源, 可选 human、api、ci、sync 或 automation:<名称>")
 )
 
+// 未指定命名空间和环境时使用的默认值
+const (
+	DefaultNamespace   = "application"
+	DefaultEnvironment = "default"
+)
+
+// ResolveNamespaceEnv 补全默认命名空间和环境, 读取、监听和写入配置时统一使用
+func ResolveNamespaceEnv(namespace, env string) (string, string) {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	if env == "" {
+		env = DefaultEnvironment
+	}
+	return namespace, env
+}
+
+// originNamePattern 自动化工具名称
+var originNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]{0,79}$`)
+
+// ParseOrigin 校验变更来源, 仅包含名称时视为 automation:<名称>
+func ParseOrigin(origin string) (string, error) {
+	origin = strings.TrimSpace(origin)
+	switch origin {
+	case model.OriginHuman, model.OriginAPI, model.OriginCI, model.OriginSync:
+		return origin, nil
+	}
+	name := strings.TrimPrefix(origin, model.OriginAutomationPrefix)
+	if !originNamePattern.MatchString(name) {
+		return "", ErrInvalidOrigin
+	}
+	return model.OriginAutomationPrefix + name, nil
+}
+
+// ParseOriginFilter 校验按来源过滤的条件, 为空表示不过滤, automation 匹配所有自动化工具
+func ParseOriginFilter(origin string) (string, error) {
+	if origin == "" || origin == "automation" {
+		return origin, nil
+	}
+	return ParseOrigin(origin)
+}
+
 // ConfigService 配置服务
 type ConfigService struct {
 	configRepo  *repository.ConfigRepository
-	versionRepo *repository.VersionRepository
+	versionRepo repository.VersionStore
 	projectRepo *repository.ProjectRepository
+	notifySvc   *NotificationService
+	contractSvc *ContractService
+	schemaSvc   *SchemaService
+	parser      *Parser
+	readCache   *ConfigReadCache // 公开读取的 Redis 缓存, 为空时直接读取数据库
 }
 
 // NewConfigService 创建配置服务
-func NewConfigService(configRepo *repository.ConfigRepository, versionRepo *repository.VersionRepository, projectRepo *repository.ProjectRepository) *ConfigService {
-	return &ConfigService{
+func NewConfigService(configRepo *repository.ConfigRepository, versionRepo repository.VersionStore, projectRepo *repository.ProjectRepository, notifySvc *NotificationService, contractSvc *ContractService, schemaSvc *SchemaService) *ConfigService {
+	s := &ConfigService{
 		configRepo:  configRepo,
 		versionRepo: versionRepo,
 		projectRepo: projectRepo,
+		notifySvc:   notifySvc,
+		contractSvc: contractSvc,
+		schemaSvc:   schemaSvc,
+		parser:      NewParser(),
 	}
+	// 父配置变更时通知继承它的子配置
+	notifySvc.OnChange(s.notifyChildren)
+	return s
 }
 
 // UploadRequest 上传配置请求
@@ -45,12 +105,16 @@ type UploadRequest struct {
 	FileType    string `json:"file_type" binding:"required"`
 	Content     string `json:"content" binding:"required"`
 	Message     string `json:"message"`
+	Origin      string `json:"-"` // 变更来源, 由调用方根据认证方式设置
 }
 
 // Upload 上传配置
 func (s *ConfigService) Upload(ctx context.Context, projectID int64, req *UploadRequest, author string) (*model.Config, error) {
+	ctx, span := tracing.Start(ctx, "ConfigService.Upload", attribute.Int64("project.id", projectID), attribute.String("config.name", req.Name))
+	defer span.End()
+
 	// 验证文件类型
-	if req.FileType != "json" && req.FileType != "yaml" && req.FileType != "protobuf" {
+	if req.FileType != "json" && req.FileType != "yaml" && req.FileType != "hcl" && req.FileType != "protobuf" && req.FileType != "kv" {
 		return nil, ErrInvalidFileType
 	}
 
@@ -61,6 +125,10 @@ func (s *ConfigService) Upload(ctx context.Context, projectID int64, req *Upload
 			return nil, ErrInvalidJSON
 		}
 	} else if req.FileType == "yaml" {
+		if issues := s.parser.LintYAML(content); hasLintErrors(issues) {
+			return nil, &YAMLLintError{Issues: issues}
+		}
+
 		// YAML 转 JSON
 		var data interface{}
 		if err := yaml.Unmarshal([]byte(content), &data); err != nil {
@@ -71,18 +139,31 @@ func (s *ConfigService) Upload(ctx context.Context, projectID int64, req *Upload
 			return nil, ErrInvalidYAML
 		}
 		content = string(jsonBytes)
+	} else if req.FileType == "hcl" {
+		// HCL 转 JSON
+		converted, err := normalizeHCL(content)
+		if err != nil {
+			return nil, err
+		}
+		content = converted
+	} else if req.FileType == "kv" {
+		// kv 内容按键排序保存
+		normalized, err := normalizeKV(content)
+		if err != nil {
+			return nil, err
+		}
+		content = normalized
 	}
 
-	// 默认值
-	namespace := req.Namespace
-	if namespace == "" {
-		namespace = "application"
-	}
-	environment := req.Environment
-	if environment == "" {
-		environment = "default"
+	// 按项目的规范化策略统一格式
+	content, err := s.normalizeForProject(ctx, projectID, req.FileType, content)
+	if err != nil {
+		return nil, err
 	}
 
+	// 默认值
+	namespace, environment := ResolveNamespaceEnv(req.Namespace, req.Environment)
+
 	// 检查是否已存在
 	existing, _ := s.configRepo.GetByProjectNamespaceEnv(ctx, projectID, namespace, environment, req.Name)
 	if existing != nil {
@@ -100,6 +181,11 @@ func (s *ConfigService) Upload(ctx context.Context, projectID int64, req *Upload
 		CurrentVersion:  1,
 	}
 
+	// 项目开启强制校验时按继承的默认 Schema 校验
+	if err := s.schemaSvc.Enforce(ctx, config, content); err != nil {
+		return nil, err
+	}
+
 	if err := s.configRepo.Create(ctx, config); err != nil {
 		return nil, err
 	}
@@ -118,12 +204,22 @@ func (s *ConfigService) Upload(ctx context.Context, projectID int64, req *Upload
 		CommitHash:    commitHash,
 		CommitMessage: message,
 		Author:        author,
+		Origin:        req.Origin,
 	}
 
 	if err := s.versionRepo.Create(ctx, version); err != nil {
 		return nil, err
 	}
 
+	s.notifySvc.NotifyChange(ctx, &ConfigChange{
+		ConfigID:   config.ID,
+		ConfigName: config.Name,
+		Namespace:  config.Namespace,
+		Env:        config.Environment,
+		Version:    version.Version,
+		ChangeType: "create",
+	})
+
 	return config, nil
 }
 
@@ -152,18 +248,86 @@ func (s *ConfigService) List(ctx context.Context, projectID int64) ([]*model.Con
 	return s.configRepo.List(ctx, projectID)
 }
 
-// Update 更新配置内容
-func (s *ConfigService) Update(ctx context.Context, id int64, content, message, author string) (*model.ConfigVersion, error) {
+// Update 更新配置内容, origin 为变更来源
+// 内容不依赖更新前的版本, 提交时遇到并发写入则基于最新版本重试
+func (s *ConfigService) Update(ctx context.Context, id int64, content, message, author, origin string) (*model.ConfigVersion, error) {
+	ctx, span := tracing.Start(ctx, "ConfigService.Update", attribute.Int64("config.id", id), attribute.String("change.origin", origin))
+	defer span.End()
+
+	var version *model.ConfigVersion
+	err := retryOnConflict(func() error {
+		var err error
+		version, err = s.update(ctx, id, content, message, author, origin, anyVersion)
+		return err
+	})
+	return version, err
+}
+
+// update 基于配置的当前版本生成新版本, base 不为 anyVersion 时当前版本须为 base
+// 版本写入和当前版本的推进在同一事务中以比较并交换完成, 期间配置已产生新版本时返回 ErrConfigVersionConflict
+func (s *ConfigService) update(ctx context.Context, id int64, content, message, author, origin string, base int) (*model.ConfigVersion, error) {
 	config, err := s.configRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, ErrConfigNotFound
 	}
+	if base != anyVersion && base != config.CurrentVersion {
+		return nil, ErrConfigVersionConflict
+	}
 
 	// 验证 JSON
 	if config.FileType == "json" && !json.Valid([]byte(content)) {
 		return nil, ErrInvalidJSON
 	}
 
+	// 校验 YAML 重复键和锚点
+	if config.FileType == "yaml" {
+		if issues := s.parser.LintYAML(content); hasLintErrors(issues) {
+			return nil, &YAMLLintError{Issues: issues}
+		}
+	}
+
+	// HCL 转换为 JSON 保存, 与上传时一致
+	if config.FileType == "hcl" {
+		converted, err := normalizeHCL(content)
+		if err != nil {
+			return nil, err
+		}
+		content = converted
+	}
+
+	// kv 内容须为值均为字符串的 JSON 对象, 按键排序保存
+	if config.FileType == "kv" {
+		normalized, err := normalizeKV(content)
+		if err != nil {
+			return nil, err
+		}
+		content = normalized
+	}
+
+	// protobuf 按描述符校验, 编辑器提交的 JSON 转换为当前格式
+	if config.FileType == "protobuf" {
+		normalized, err := s.schemaSvc.NormalizeProto(ctx, config, content)
+		if err != nil {
+			return nil, err
+		}
+		content = normalized
+	}
+
+	// 按项目的规范化策略统一格式, 使版本差异只反映语义变化
+	if content, err = s.normalizeForProject(ctx, config.ProjectID, config.FileType, content); err != nil {
+		return nil, err
+	}
+
+	// 按配置的 Schema 校验策略校验, block 策略下不符合时拒绝写入
+	if err := s.schemaSvc.Enforce(ctx, config, content); err != nil {
+		return nil, err
+	}
+
+	// 校验消费方契约, 仅标记的违反项不阻止写入
+	if _, err := s.contractSvc.Enforce(ctx, config, content); err != nil {
+		return nil, err
+	}
+
 	// 增加版本号
 	newVersion := config.CurrentVersion + 1
 	commitHash := generateHash(content)
@@ -179,52 +343,135 @@ func (s *ConfigService) Update(ctx context.Context, id int64, content, message,
 		CommitHash:    commitHash,
 		CommitMessage: message,
 		Author:        author,
+		Origin:        origin,
 	}
 
-	if err := s.versionRepo.Create(ctx, version); err != nil {
+	// 写入版本并推进配置的当前版本
+	if err := s.versionRepo.Commit(ctx, version, config.CurrentVersion); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return nil, ErrConfigVersionConflict
+		}
 		return nil, err
 	}
-
-	// 更新配置的当前版本
 	config.CurrentVersion = newVersion
-	if err := s.configRepo.Update(ctx, config); err != nil {
+
+	s.notifySvc.NotifyChange(ctx, &ConfigChange{
+		ConfigID:   config.ID,
+		ConfigName: config.Name,
+		Namespace:  config.Namespace,
+		Env:        config.Environment,
+		Version:    version.Version,
+		ChangeType: "update",
+	})
+
+	return version, nil
+}
+
+// Delete 删除配置, 存在继承自它的子配置时拒绝删除
+// dryRun 为 true 时只做同样的检查并返回将被删除的数据, 不删除
+func (s *ConfigService) Delete(ctx context.Context, id int64, dryRun bool) (*repository.DeleteImpact, error) {
+	config, err := s.configRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+	if children, err := s.configRepo.ListChildren(ctx, id); err != nil {
+		return nil, err
+	} else if len(children) > 0 {
+		return nil, ErrConfigHasChildren
+	}
+	if dryRun {
+		return s.configRepo.DeleteImpact(ctx, id)
+	}
+	if err := s.configRepo.Delete(ctx, id); err != nil {
 		return nil, err
 	}
+	// 配置已删除, 删除事件自身携带归属, 监听方据此识别被删除的配置
+	s.notifySvc.NotifyChange(ctx, deleteChange(config))
+	return nil, nil
+}
 
-	return version, nil
+// deleteChange 构造配置的删除事件, 携带配置的归属和最后版本
+func deleteChange(config *model.Config) *ConfigChange {
+	return &ConfigChange{
+		ProjectID:  config.ProjectID,
+		ConfigID:   config.ID,
+		ConfigName: config.Name,
+		Namespace:  config.Namespace,
+		Env:        config.Environment,
+		Version:    config.CurrentVersion,
+		ChangeType: "delete",
+	}
 }
 
-// Delete 删除配置
-func (s *ConfigService) Delete(ctx context.Context, id int64) error {
-	_, err := s.configRepo.GetByID(ctx, id)
+// GetDiffRules 获取配置的对比忽略规则, 未设置时返回空规则
+func (s *ConfigService) GetDiffRules(ctx context.Context, id int64) (*DiffRules, error) {
+	config, err := s.configRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+	rules, err := ParseDiffRules(config.DiffRules)
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		rules = &DiffRules{IgnorePaths: []string{}}
+	}
+	return rules, nil
+}
+
+// UpdateDiffRules 更新配置的对比忽略规则, 在环境对比和版本对比中生效
+func (s *ConfigService) UpdateDiffRules(ctx context.Context, id int64, rules *DiffRules) error {
+	config, err := s.configRepo.GetByID(ctx, id)
 	if err != nil {
 		return ErrConfigNotFound
 	}
-	return s.configRepo.Delete(ctx, id)
+	if err := rules.Validate(); err != nil {
+		return err
+	}
+
+	rulesJSON, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+	config.DiffRules = string(rulesJSON)
+	return s.configRepo.Update(ctx, config)
 }
 
 // GetByAccessKey 通过 Access Key 获取配置
 func (s *ConfigService) GetByAccessKey(ctx context.Context, projectID int64, configName, namespace, env string) (*model.Config, *model.ConfigVersion, error) {
-	if namespace == "" {
-		namespace = "application"
-	}
-	if env == "" {
-		env = "default"
+	namespace, env = ResolveNamespaceEnv(namespace, env)
+
+	ctx, span := tracing.Start(ctx, "ConfigService.GetByAccessKey", attribute.Int64("project.id", projectID), attribute.String("config.name", configName))
+	defer span.End()
+
+	if config, version, ok := s.readCache.Get(ctx, projectID, namespace, env, configName); ok {
+		span.SetAttributes(attribute.Bool("cache.hit", true))
+		return config, version, nil
 	}
 
 	config, err := s.configRepo.GetByProjectNamespaceEnv(ctx, projectID, namespace, env, configName)
 	if err != nil {
 		return nil, nil, ErrConfigNotFound
 	}
+	gen := s.readCache.Generation(config.ID)
 
 	version, err := s.versionRepo.GetLatest(ctx, config.ID)
 	if err != nil {
+		s.readCache.Set(ctx, config, nil, gen)
 		return config, nil, nil
 	}
 
+	// 设置了父配置时下发与父配置链合并后的内容
+	version = s.Inherited(ctx, config, version)
+	s.readCache.Set(ctx, config, version, gen)
 	return config, version, nil
 }
 
+// SetReadCache 设置公开读取的 Redis 缓存
+func (s *ConfigService) SetReadCache(cache *ConfigReadCache) {
+	s.readCache = cache
+}
+
 // generateHash 生成内容哈希
 func generateHash(content string) string {
 	hash := sha256.Sum256([]byte(content))
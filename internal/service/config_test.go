@@ -0,0 +1,27 @@
+package service
+
+import "testing"
+
+func TestResolveNamespaceEnv(t *testing.T) {
+	tests := []struct {
+		name          string
+		namespace     string
+		env           string
+		wantNamespace string
+		wantEnv       string
+	}{
+		{"both empty", "", "", DefaultNamespace, DefaultEnvironment},
+		{"namespace only", "billing", "", "billing", DefaultEnvironment},
+		{"env only", "", "prod", DefaultNamespace, "prod"},
+		{"both explicit", "billing", "prod", "billing", "prod"},
+		{"explicit defaults", DefaultNamespace, DefaultEnvironment, DefaultNamespace, DefaultEnvironment},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespace, env := ResolveNamespaceEnv(tt.namespace, tt.env)
+			if namespace != tt.wantNamespace || env != tt.wantEnv {
+				t.Fatalf("ResolveNamespaceEnv(%q, %q) = %q, %q; want %q, %q", tt.namespace, tt.env, namespace, env, tt.wantNamespace, tt.wantEnv)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"confighub/internal/model"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrInvalidBaseVersion    = errors.New("无效的基础版本")
+	ErrConfigVersionConflict = errors.New("配置已被并发修改, 请基于最新版本重试")
+)
+
+// anyVersion 写入不要求配置的当前版本
+const anyVersion = -1
+
+// maxCommitAttempts 基于最新版本的写入因并发修改失败时的最多尝试次数
+const maxCommitAttempts = 3
+
+// retryOnConflict 执行基于最新版本的读-改-写, 提交时配置已产生新版本则重新读取后重试
+func retryOnConflict(fn func() error) error {
+	var err error
+	for attempt := 0; attempt < maxCommitAttempts; attempt++ {
+		if err = fn(); !errors.Is(err, ErrConfigVersionConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// UpdatePrecondition 乐观并发控制: 调用方修改所基于的版本, 版本号和提交哈希至少指定一个, 都指定时须同时满足
+type UpdatePrecondition struct {
+	BaseVersion int    // 基础版本号
+	CommitHash  string // 基础版本的提交哈希 (If-Match), * 表示任意版本
+}
+
+// UpdateConflictError 配置在调用方读取之后已被修改, 附带三方差异
+type UpdateConflictError struct {
+	BaseVersion       int           `json:"base_version"` // 0 表示找不到基础版本, 此时差异以当前版本为基础
+	CurrentVersion    int           `json:"current_version"`
+	CurrentCommitHash string        `json:"current_commit_hash"`
+	Diff              *ThreeWayDiff `json:"diff"`
+}
+
+func (e *UpdateConflictError) Error() string {
+	return fmt.Sprintf("配置已被修改 (当前版本: %d), 请基于最新版本重新提交", e.CurrentVersion)
+}
+
+// ThreeWayDiff 基础版本、当前版本和本次提交内容的三方差异
+// JSON、kv、HCL 和 YAML 按字段路径对比, 其他格式按行对比
+type ThreeWayDiff struct {
+	Format      string     `json:"format"`                 // fields 或 lines
+	Theirs      []JSONDiff `json:"theirs,omitempty"`       // 基础版本之后已生效的修改 (基础 → 当前)
+	Ours        []JSONDiff `json:"ours,omitempty"`         // 本次提交的修改 (基础 → 提交)
+	Conflicts   []string   `json:"conflicts"`              // 双方都修改且结果不一致的路径
+	TheirsLines []LineDiff `json:"theirs_lines,omitempty"` // 按行对比时的修改, 只包含增删的行
+	OursLines   []LineDiff `json:"ours_lines,omitempty"`
+}
+
+// UpdateIf 在满足前置条件时更新配置, 否则返回 UpdateConflictError 而不生成版本
+// 新版本以检查时的当前版本为基础提交, 检查之后其他写入 (包括其他实例) 先提交时同样返回 UpdateConflictError
+func (s *ConfigService) UpdateIf(ctx context.Context, id int64, content, message, author, origin string, pre *UpdatePrecondition) (*model.ConfigVersion, error) {
+	config, err := s.configRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+	if pre.BaseVersion < 0 || pre.BaseVersion > config.CurrentVersion {
+		return nil, fmt.Errorf("%w: 版本 %d 不存在", ErrInvalidBaseVersion, pre.BaseVersion)
+	}
+
+	latest, err := s.versionRepo.GetLatest(ctx, id)
+	if err != nil {
+		latest = &model.ConfigVersion{}
+	}
+	matched := pre.BaseVersion == 0 || pre.BaseVersion == config.CurrentVersion
+	if pre.CommitHash != "" && pre.CommitHash != "*" && pre.CommitHash != latest.CommitHash {
+		matched = false
+	}
+	if !matched {
+		return nil, s.updateConflict(ctx, config, latest, content, pre)
+	}
+
+	// 不限定版本 (If-Match: *) 时与 Update 相同
+	if pre.BaseVersion == 0 && (pre.CommitHash == "" || pre.CommitHash == "*") {
+		return s.Update(ctx, id, content, message, author, origin)
+	}
+
+	version, err := s.update(ctx, id, content, message, author, origin, config.CurrentVersion)
+	if errors.Is(err, ErrConfigVersionConflict) {
+		if config, err = s.configRepo.GetByID(ctx, id); err != nil {
+			return nil, ErrConfigNotFound
+		}
+		if latest, err = s.versionRepo.GetLatest(ctx, id); err != nil {
+			latest = &model.ConfigVersion{}
+		}
+		return nil, s.updateConflict(ctx, config, latest, content, pre)
+	}
+	return version, err
+}
+
+// updateConflict 构造冲突错误: 找到调用方的基础版本并计算三方差异
+func (s *ConfigService) updateConflict(ctx context.Context, config *model.Config, latest *model.ConfigVersion, content string, pre *UpdatePrecondition) error {
+	var base *model.ConfigVersion
+	var err error
+	if pre.BaseVersion > 0 {
+		base, err = s.versionRepo.GetByConfigAndVersion(ctx, config.ID, pre.BaseVersion)
+	} else {
+		base, err = s.versionRepo.GetByCommitHash(ctx, config.ID, pre.CommitHash)
+	}
+	conflict := &UpdateConflictError{
+		CurrentVersion:    latest.Version,
+		CurrentCommitHash: latest.CommitHash,
+	}
+	if err != nil {
+		base = latest
+	} else {
+		conflict.BaseVersion = base.Version
+	}
+	conflict.Diff = s.threeWayDiff(config.FileType, base.Content, latest.Content, content)
+	return conflict
+}
+
+// threeWayDiff 计算基础 → 当前和基础 → 提交两组修改, 并找出冲突的路径
+func (s *ConfigService) threeWayDiff(fileType, base, current, submitted string) *ThreeWayDiff {
+	differ := NewDiffService()
+	baseData, okBase := s.structured(fileType, base)
+	currentData, okCurrent := s.structured(fileType, current)
+	submittedData, okSubmitted := s.structured(fileType, submitted)
+	if !okBase || !okCurrent || !okSubmitted {
+		return &ThreeWayDiff{
+			Format:      "lines",
+			Conflicts:   []string{},
+			TheirsLines: changedLines(differ.DiffLines(base, current)),
+			OursLines:   changedLines(differ.DiffLines(base, submitted)),
+		}
+	}
+
+	diff := &ThreeWayDiff{Format: "fields", Conflicts: []string{}}
+	differ.compareJSON("", baseData, currentData, &diff.Theirs)
+	differ.compareJSON("", baseData, submittedData, &diff.Ours)
+	for _, ours := range diff.Ours {
+		for _, theirs := range diff.Theirs {
+			if !pathOverlaps(ours.Path, theirs.Path) {
+				continue
+			}
+			if ours.Path == theirs.Path && ours.Type == theirs.Type && reflect.DeepEqual(ours.NewValue, theirs.NewValue) {
+				continue
+			}
+			diff.Conflicts = append(diff.Conflicts, ours.Path)
+			break
+		}
+	}
+	return diff
+}
+
+// structured 将配置内容解析为 JSON 结构, 不支持按字段对比的格式或解析失败时返回 false
+func (s *ConfigService) structured(fileType, content string) (interface{}, bool) {
+	var data interface{}
+	switch fileType {
+	case "json", "kv":
+	case "hcl":
+		// 已保存的版本为 JSON, 提交的内容可能为 HCL
+		if !json.Valid([]byte(content)) {
+			converted, err := normalizeHCL(content)
+			if err != nil {
+				return nil, false
+			}
+			content = converted
+		}
+	case "yaml":
+		if err := yaml.Unmarshal([]byte(content), &data); err != nil {
+			return nil, false
+		}
+		return convertYAMLToJSON(data), true
+	default:
+		return nil, false
+	}
+	if err := json.Unmarshal([]byte(content), &data); err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// pathOverlaps 两个字段路径相同或一方是另一方的祖先
+func pathOverlaps(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return a == "" || a == b || strings.HasPrefix(b, a+".") || strings.HasPrefix(b, a+"[")
+}
+
+// changedLines 只保留增删的行
+func changedLines(diffs []LineDiff) []LineDiff {
+	changed := []LineDiff{}
+	for _, d := range diffs {
+		if d.Type != "unchanged" {
+			changed = append(changed, d)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"container/list"
+	"sync"
+)
+
+// 内容派生结果缓存的容量 (字节)
+const (
+	decryptCacheBytes   = 16 << 20
+	mergeCacheBytes     = 16 << 20
+	grayRulesCacheBytes = 1 << 20
+)
+
+// contentLRU 按字节数限制容量的 LRU, 缓存由内容派生的解析结果, 避免热点路径重复反序列化
+// 键通常为内容哈希 (与版本的 commit hash 算法一致), 缓存的值必须视为只读
+type contentLRU struct {
+	mu       sync.Mutex
+	maxBytes int
+	used     int
+	order    *list.List // 最近使用的在前
+	items    map[string]*list.Element
+}
+
+type contentLRUItem struct {
+	key   string
+	value interface{}
+	size  int
+}
+
+// newContentLRU 创建内容缓存
+func newContentLRU(maxBytes int) *contentLRU {
+	return &contentLRU{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get 获取缓存值
+func (c *contentLRU) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*contentLRUItem).value, true
+}
+
+// Add 放入缓存, 超过容量时淘汰最久未使用的条目; 单个值超过总容量时不缓存
+func (c *contentLRU) Add(key string, value interface{}, size int) {
+	size += len(key)
+	if size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		item := elem.Value.(*contentLRUItem)
+		c.used += size - item.size
+		item.value, item.size = value, size
+		c.order.MoveToFront(elem)
+	} else {
+		c.items[key] = c.order.PushFront(&contentLRUItem{key: key, value: value, size: size})
+		c.used += size
+	}
+
+	for c.used > c.maxBytes {
+		oldest := c.order.Back()
+		item := oldest.Value.(*contentLRUItem)
+		c.order.Remove(oldest)
+		delete(c.items, item.key)
+		c.used -= item.size
+	}
+}
@@ -0,0 +1,401 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrInvalidContract  = errors.New("无效的消费契约")
+	ErrContractNotFound = errors.New("消费契约不存在")
+)
+
+// 契约支持的键类型
+const (
+	ContractTypeAny     = "any"
+	ContractTypeString  = "string"
+	ContractTypeNumber  = "number"
+	ContractTypeInteger = "integer"
+	ContractTypeBoolean = "boolean"
+	ContractTypeObject  = "object"
+	ContractTypeArray   = "array"
+)
+
+// ContractKey 消费方依赖的键, Path 与对比结果的路径格式一致, 如 db.hosts[0].port
+type ContractKey struct {
+	Path string `json:"path"`
+	Type string `json:"type"` // any, string, number, integer, boolean, object, array
+}
+
+// Contract 消费契约
+type Contract struct {
+	ID          int64         `json:"id"`
+	ConfigID    int64         `json:"config_id"`
+	Consumer    string        `json:"consumer"`
+	Keys        []ContractKey `json:"keys"`
+	Enforcement string        `json:"enforcement"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// ContractViolation 契约违反项
+type ContractViolation struct {
+	Consumer    string `json:"consumer"`
+	Path        string `json:"path"`
+	Expected    string `json:"expected"`
+	Actual      string `json:"actual"` // 键不存在时为 missing
+	Enforcement string `json:"enforcement"`
+}
+
+// ContractViolationError 写入或发布会破坏消费方契约
+type ContractViolationError struct {
+	Violations []ContractViolation
+}
+
+func (e *ContractViolationError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		if v.Actual == "missing" {
+			parts[i] = fmt.Sprintf("%s 依赖的 %s 被移除", v.Consumer, v.Path)
+		} else {
+			parts[i] = fmt.Sprintf("%s 依赖的 %s 类型由 %s 变为 %s", v.Consumer, v.Path, v.Expected, v.Actual)
+		}
+	}
+	return "变更破坏消费契约: " + strings.Join(parts, "; ")
+}
+
+// ContractService 配置消费契约服务
+type ContractService struct {
+	contractRepo *repository.ContractRepository
+	configRepo   *repository.ConfigRepository
+	versionRepo  repository.VersionStore
+}
+
+// NewContractService 创建契约服务
+func NewContractService(contractRepo *repository.ContractRepository, configRepo *repository.ConfigRepository, versionRepo repository.VersionStore) *ContractService {
+	return &ContractService{
+		contractRepo: contractRepo,
+		configRepo:   configRepo,
+		versionRepo:  versionRepo,
+	}
+}
+
+// Register 注册或更新消费方在配置上的契约
+func (s *ContractService) Register(ctx context.Context, configID int64, consumer string, keys []ContractKey, enforcement string, accessKeyID *int64) (*Contract, error) {
+	if _, err := s.configRepo.GetByID(ctx, configID); err != nil {
+		return nil, ErrConfigNotFound
+	}
+
+	consumer = strings.TrimSpace(consumer)
+	if consumer == "" || len(consumer) > 100 || len(keys) == 0 {
+		return nil, ErrInvalidContract
+	}
+	if enforcement == "" {
+		enforcement = model.ContractEnforcementBlock
+	}
+	if enforcement != model.ContractEnforcementBlock && enforcement != model.ContractEnforcementWarn {
+		return nil, ErrInvalidContract
+	}
+	for i := range keys {
+		keys[i].Path = strings.TrimSpace(keys[i].Path)
+		if keys[i].Type == "" {
+			keys[i].Type = ContractTypeAny
+		}
+		if keys[i].Path == "" || !validContractType(keys[i].Type) {
+			return nil, ErrInvalidContract
+		}
+		if _, ok := parseContractPath(keys[i].Path); !ok {
+			return nil, ErrInvalidContract
+		}
+	}
+
+	keysJSON, err := json.Marshal(keys)
+	if err != nil {
+		return nil, err
+	}
+	contract := &model.ConfigContract{
+		ConfigID:    configID,
+		Consumer:    consumer,
+		Keys:        string(keysJSON),
+		Enforcement: enforcement,
+		AccessKeyID: accessKeyID,
+	}
+	if err := s.contractRepo.Upsert(ctx, contract); err != nil {
+		return nil, err
+	}
+	return toContract(contract), nil
+}
+
+// List 获取配置的所有契约
+func (s *ContractService) List(ctx context.Context, configID int64) ([]*Contract, error) {
+	if _, err := s.configRepo.GetByID(ctx, configID); err != nil {
+		return nil, ErrConfigNotFound
+	}
+	contracts, err := s.contractRepo.ListByConfig(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Contract, len(contracts))
+	for i, c := range contracts {
+		result[i] = toContract(c)
+	}
+	return result, nil
+}
+
+// Delete 删除消费方的契约
+func (s *ContractService) Delete(ctx context.Context, configID int64, consumer string) error {
+	deleted, err := s.contractRepo.Delete(ctx, configID, consumer)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrContractNotFound
+	}
+	return nil
+}
+
+// CheckVersion 检查配置的指定版本是否满足所有契约, version 为 0 时检查当前版本
+func (s *ContractService) CheckVersion(ctx context.Context, configID int64, version int) ([]ContractViolation, error) {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+	if version == 0 {
+		version = config.CurrentVersion
+	}
+	v, err := s.versionRepo.GetByConfigAndVersion(ctx, configID, version)
+	if err != nil {
+		return nil, ErrVersionNotFound
+	}
+	return s.Check(ctx, config, v.Content)
+}
+
+// Check 检查内容是否满足配置上的所有契约, 返回全部违反项 (含仅标记的)
+// 无法解析的内容或非 JSON/YAML 配置不做检查
+func (s *ContractService) Check(ctx context.Context, config *model.Config, content string) ([]ContractViolation, error) {
+	contracts, err := s.contractRepo.ListByConfig(ctx, config.ID)
+	if err != nil || len(contracts) == 0 {
+		return nil, err
+	}
+
+	data, ok := parseContractContent(config.FileType, content)
+	if !ok {
+		return nil, nil
+	}
+
+	var violations []ContractViolation
+	for _, c := range contracts {
+		contract := toContract(c)
+		for _, key := range contract.Keys {
+			actual := contractValueType(data, key.Path)
+			if contractTypeMatches(key.Type, actual) {
+				continue
+			}
+			violations = append(violations, ContractViolation{
+				Consumer:    contract.Consumer,
+				Path:        key.Path,
+				Expected:    key.Type,
+				Actual:      actual,
+				Enforcement: contract.Enforcement,
+			})
+		}
+	}
+	return violations, nil
+}
+
+// Enforce 检查内容, 存在 block 级别的违反项时返回 ContractViolationError; 否则返回仅需标记的违反项
+func (s *ContractService) Enforce(ctx context.Context, config *model.Config, content string) ([]ContractViolation, error) {
+	violations, err := s.Check(ctx, config, content)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocked, flagged []ContractViolation
+	for _, v := range violations {
+		if v.Enforcement == model.ContractEnforcementBlock {
+			blocked = append(blocked, v)
+		} else {
+			flagged = append(flagged, v)
+		}
+	}
+	if len(blocked) > 0 {
+		return nil, &ContractViolationError{Violations: blocked}
+	}
+	return flagged, nil
+}
+
+// Warnings 返回配置指定版本上仅标记 (warn 级别) 的违反项, 供写入或发布成功后提示
+func (s *ContractService) Warnings(ctx context.Context, configID int64, version int) []ContractViolation {
+	violations, err := s.CheckVersion(ctx, configID, version)
+	if err != nil {
+		return nil
+	}
+	var warnings []ContractViolation
+	for _, v := range violations {
+		if v.Enforcement == model.ContractEnforcementWarn {
+			warnings = append(warnings, v)
+		}
+	}
+	return warnings
+}
+
+// toContract 转换契约记录
+func toContract(c *model.ConfigContract) *Contract {
+	contract := &Contract{
+		ID:          c.ID,
+		ConfigID:    c.ConfigID,
+		Consumer:    c.Consumer,
+		Keys:        []ContractKey{},
+		Enforcement: c.Enforcement,
+		UpdatedAt:   c.UpdatedAt,
+	}
+	json.Unmarshal([]byte(c.Keys), &contract.Keys)
+	return contract
+}
+
+// validContractType 是否为支持的类型
+func validContractType(t string) bool {
+	switch t {
+	case ContractTypeAny, ContractTypeString, ContractTypeNumber, ContractTypeInteger,
+		ContractTypeBoolean, ContractTypeObject, ContractTypeArray:
+		return true
+	}
+	return false
+}
+
+// parseContractContent 将 JSON/YAML 内容解析为通用结构
+func parseContractContent(fileType, content string) (interface{}, bool) {
+	var data interface{}
+	switch fileType {
+	case "json", "hcl", "kv":
+		if err := json.Unmarshal([]byte(content), &data); err != nil {
+			return nil, false
+		}
+	case "yaml":
+		var raw interface{}
+		if err := yaml.Unmarshal([]byte(content), &raw); err != nil {
+			return nil, false
+		}
+		// 经 JSON 往返, 使数值类型与 JSON 配置一致
+		encoded, err := json.Marshal(convertYAMLToJSON(raw))
+		if err != nil || json.Unmarshal(encoded, &data) != nil {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	return data, true
+}
+
+// contractSegment 路径段: 对象键或数组下标
+type contractSegment struct {
+	key   string
+	index int // 小于 0 表示对象键
+}
+
+// parseContractPath 解析 a.b[0].c 形式的路径
+func parseContractPath(path string) ([]contractSegment, bool) {
+	var segments []contractSegment
+	for _, part := range strings.Split(path, ".") {
+		name := part
+		var indexes []int
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			name = part[:i]
+			rest := part[i:]
+			for rest != "" {
+				end := strings.IndexByte(rest, ']')
+				if rest[0] != '[' || end < 0 {
+					return nil, false
+				}
+				n, err := strconv.Atoi(rest[1:end])
+				if err != nil || n < 0 {
+					return nil, false
+				}
+				indexes = append(indexes, n)
+				rest = rest[end+1:]
+			}
+		}
+		if name == "" && (len(segments) > 0 || len(indexes) == 0) {
+			return nil, false
+		}
+		if name != "" {
+			segments = append(segments, contractSegment{key: name, index: -1})
+		}
+		for _, n := range indexes {
+			segments = append(segments, contractSegment{index: n})
+		}
+	}
+	return segments, len(segments) > 0
+}
+
+// contractValueType 返回路径处值的类型, 不存在时返回 missing
+func contractValueType(data interface{}, path string) string {
+	segments, _ := parseContractPath(path)
+	current := data
+	for _, seg := range segments {
+		if seg.index < 0 {
+			obj, ok := current.(map[string]interface{})
+			if !ok {
+				return "missing"
+			}
+			if current, ok = obj[seg.key]; !ok {
+				return "missing"
+			}
+		} else {
+			arr, ok := current.([]interface{})
+			if !ok || seg.index >= len(arr) {
+				return "missing"
+			}
+			current = arr[seg.index]
+		}
+	}
+
+	switch v := current.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return ContractTypeBoolean
+	case float64:
+		if v == math.Trunc(v) {
+			return ContractTypeInteger
+		}
+		return ContractTypeNumber
+	case string:
+		// 加密字段的明文类型未知, 视为满足任意标量类型
+		if strings.HasPrefix(v, EncryptedPrefix) {
+			return "encrypted"
+		}
+		return ContractTypeString
+	case map[string]interface{}:
+		return ContractTypeObject
+	case []interface{}:
+		return ContractTypeArray
+	}
+	return ContractTypeAny
+}
+
+// contractTypeMatches 实际类型是否满足声明的类型
+func contractTypeMatches(expected, actual string) bool {
+	switch {
+	case actual == "missing":
+		return false
+	case expected == ContractTypeAny || expected == actual:
+		return true
+	case expected == ContractTypeNumber && actual == ContractTypeInteger:
+		return true
+	case actual == "encrypted":
+		return expected != ContractTypeObject && expected != ContractTypeArray
+	}
+	return false
+}
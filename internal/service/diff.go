@@ -4,17 +4,25 @@ import (
 	"encoding/json"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 )
 
 // DiffService 差异对比服务
-type DiffService struct{}
+type DiffService struct {
+	rules *DiffRules
+}
 
 // NewDiffService 创建差异对比服务
 func NewDiffService() *DiffService {
 	return &DiffService{}
 }
 
+// WithRules 返回应用指定忽略规则的对比服务, rules 为 nil 时不忽略任何差异
+func (s *DiffService) WithRules(rules *DiffRules) *DiffService {
+	return &DiffService{rules: rules}
+}
+
 // LineDiff 行级差异
 type LineDiff struct {
 	Type       string `json:"type"`        // add, remove, unchanged
@@ -34,6 +42,8 @@ type JSONDiff struct {
 
 // DiffLines 行级对比
 func (s *DiffService) DiffLines(oldContent, newContent string) []LineDiff {
+	oldContent = s.rules.Normalize(oldContent)
+	newContent = s.rules.Normalize(newContent)
 	oldLines := strings.Split(oldContent, "\n")
 	newLines := strings.Split(newContent, "\n")
 
@@ -127,7 +137,7 @@ func (s *DiffService) DiffJSON(oldJSON, newJSON string) ([]JSONDiff, error) {
 
 // compareJSON 递归比较 JSON
 func (s *DiffService) compareJSON(path string, oldVal, newVal interface{}, diffs *[]JSONDiff) {
-	if reflect.DeepEqual(oldVal, newVal) {
+	if reflect.DeepEqual(oldVal, newVal) || (path != "" && s.rules.Ignored(path)) {
 		return
 	}
 
@@ -170,6 +180,9 @@ func (s *DiffService) compareJSON(path string, oldVal, newVal interface{}, diffs
 			if path != "" {
 				childPath = path + "." + k
 			}
+			if s.rules.Ignored(childPath) {
+				continue
+			}
 
 			oldChild, oldExists := old[k]
 			newChild, newExists := newMap[k]
@@ -193,13 +206,20 @@ func (s *DiffService) compareJSON(path string, oldVal, newVal interface{}, diffs
 
 	case []interface{}:
 		newArr := newVal.([]interface{})
+		if s.rules != nil && s.rules.IgnoreArrayOrder {
+			old = sortArrays(old).([]interface{})
+			newArr = sortArrays(newArr).([]interface{})
+		}
 		maxLen := len(old)
 		if len(newArr) > maxLen {
 			maxLen = len(newArr)
 		}
 
 		for i := 0; i < maxLen; i++ {
-			childPath := path + "[" + string(rune('0'+i)) + "]"
+			childPath := path + "[" + strconv.Itoa(i) + "]"
+			if s.rules.Ignored(childPath) {
+				continue
+			}
 			if i >= len(old) {
 				*diffs = append(*diffs, JSONDiff{
 					Path:     childPath,
@@ -218,6 +238,9 @@ func (s *DiffService) compareJSON(path string, oldVal, newVal interface{}, diffs
 		}
 
 	default:
+		if s.rules.ValuesEqual(path, oldVal, newVal) {
+			return
+		}
 		*diffs = append(*diffs, JSONDiff{
 			Path:     path,
 			Type:     "modify",
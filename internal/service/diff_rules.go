@@ -0,0 +1,201 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidDiffRules = errors.New("无效的对比忽略规则")
+)
+
+// DiffRules 配置级对比忽略规则, 使对比聚焦于有意义的变更
+type DiffRules struct {
+	IgnoreKeyOrder   bool     `json:"ignore_key_order"`   // 行级对比前按键名排序规范化内容
+	IgnoreArrayOrder bool     `json:"ignore_array_order"` // 数组按元素集合比较, 忽略顺序
+	IgnorePaths      []string `json:"ignore_paths"`       // 忽略的路径, 如 metadata.updated_at; * 匹配单级, ** 匹配多级
+	NumericTolerance float64  `json:"numeric_tolerance"`  // 数值差的绝对值不超过该值时视为相同
+
+	patterns []*regexp.Regexp
+}
+
+// ParseDiffRules 解析配置上保存的对比规则, 空内容返回 nil (不应用规则)
+func ParseDiffRules(raw string) (*DiffRules, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var rules DiffRules
+	if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+		return nil, ErrInvalidDiffRules
+	}
+	if err := rules.compile(); err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+// Validate 校验规则并编译路径模式
+func (r *DiffRules) Validate() error {
+	if r.NumericTolerance < 0 {
+		return ErrInvalidDiffRules
+	}
+	return r.compile()
+}
+
+// compile 将路径模式编译为正则: 模式命中的路径及其子路径均被忽略
+func (r *DiffRules) compile() error {
+	r.patterns = r.patterns[:0]
+	for _, p := range r.IgnorePaths {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			return ErrInvalidDiffRules
+		}
+		var b strings.Builder
+		b.WriteString("^")
+		for i := 0; i < len(p); i++ {
+			switch {
+			case strings.HasPrefix(p[i:], "**"):
+				b.WriteString(".*")
+				i++
+			case p[i] == '*':
+				b.WriteString(`[^.\[]*`)
+			default:
+				b.WriteString(regexp.QuoteMeta(p[i : i+1]))
+			}
+		}
+		b.WriteString(`(\..*|\[.*)?$`)
+		re, err := regexp.Compile(b.String())
+		if err != nil {
+			return ErrInvalidDiffRules
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return nil
+}
+
+// Ignored 路径是否被忽略
+func (r *DiffRules) Ignored(path string) bool {
+	if r == nil {
+		return false
+	}
+	for _, re := range r.patterns {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValuesEqual 按规则比较 path 处的两个值 (忽略子路径、数值容差、数组顺序)
+func (r *DiffRules) ValuesEqual(path string, a, b interface{}) bool {
+	if r != nil && len(r.patterns) > 0 {
+		a, b = r.prune(path, a), r.prune(path, b)
+	}
+	if r != nil && r.NumericTolerance > 0 {
+		if af, ok := a.(float64); ok {
+			if bf, ok := b.(float64); ok {
+				return math.Abs(af-bf) <= r.NumericTolerance
+			}
+		}
+	}
+	if r != nil && r.IgnoreArrayOrder {
+		a, b = sortArrays(a), sortArrays(b)
+	}
+	aJSON, _ := json.Marshal(a)
+	bJSON, _ := json.Marshal(b)
+	return string(aJSON) == string(bJSON)
+}
+
+// Normalize 按规则规范化 JSON 内容 (移除忽略路径、排序数组、按键名排序), 用于行级对比
+// 内容不是 JSON 或规则不要求规范化时原样返回
+func (r *DiffRules) Normalize(content string) string {
+	if r == nil || (!r.IgnoreKeyOrder && !r.IgnoreArrayOrder && len(r.patterns) == 0) {
+		return content
+	}
+	var data interface{}
+	if err := json.Unmarshal([]byte(content), &data); err != nil {
+		return content
+	}
+	data = r.prune("", data)
+	if r.IgnoreArrayOrder {
+		data = sortArrays(data)
+	}
+	normalized, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return content
+	}
+	return string(normalized)
+}
+
+// prune 递归移除被忽略的路径
+func (r *DiffRules) prune(path string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			if r.Ignored(childPath) {
+				continue
+			}
+			result[k] = r.prune(childPath, child)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for i, child := range v {
+			childPath := path + "[" + strconv.Itoa(i) + "]"
+			if r.Ignored(childPath) {
+				continue
+			}
+			result = append(result, r.prune(childPath, child))
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// sortArrays 递归地按元素 JSON 编码排序数组
+func sortArrays(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			result[k] = sortArrays(child)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		keys := make([]string, len(v))
+		for i, child := range v {
+			result[i] = sortArrays(child)
+			encoded, _ := json.Marshal(result[i])
+			keys[i] = string(encoded)
+		}
+		sort.Sort(byKey{items: result, keys: keys})
+		return result
+	default:
+		return value
+	}
+}
+
+// byKey 按预先计算的键排序
+type byKey struct {
+	items []interface{}
+	keys  []string
+}
+
+func (s byKey) Len() int           { return len(s.items) }
+func (s byKey) Less(i, j int) bool { return s.keys[i] < s.keys[j] }
+func (s byKey) Swap(i, j int) {
+	s.items[i], s.items[j] = s.items[j], s.items[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
@@ -1,19 +1,31 @@
 package service
 
 import (
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"io"
+	"strconv"
 	"strings"
+	"sync"
+
+	"confighub/internal/model"
 )
 
 var (
 	ErrEncryptionFailed = errors.New("加密失败")
 	ErrDecryptionFailed = errors.New("解密失败")
+	ErrDataKeyNotFound  = errors.New("数据密钥不存在")
+	ErrDataKeysDisabled = errors.New("未启用项目数据密钥")
+
+	ErrInvalidEncryptedValue = errors.New("无效的加密值")
 )
 
 const (
@@ -21,9 +33,36 @@ const (
 	EncryptedPrefix = "ENC:"
 )
 
+// 由主密钥派生的各用途密钥的 HKDF info, 一种用途的密文不能用另一种用途的密钥解开
+const (
+	keyPurposeDataKeyWrap = "confighub/data-key-wrap"
+	keyPurposeSecretKey   = "confighub/secret-key"
+	keyPurposeValue       = "confighub/value"
+)
+
+// DataKeyStore 项目数据密钥的存储, 主实例为数据库, 跟随节点为主实例快照
+type DataKeyStore interface {
+	ListDataKeys(ctx context.Context, projectID int64) ([]*model.ProjectDataKey, error)
+	CreateDataKey(ctx context.Context, key *model.ProjectDataKey) error
+	UpdateDataKey(ctx context.Context, key *model.ProjectDataKey) error
+}
+
 // EncryptionService 加密服务
+// 主密钥 (encrypt.key) 不直接加密数据, 而是以 HKDF 为各用途派生独立的密钥: 包装各项目的数据密钥、
+// 加密保存 Secret Key、加密没有密钥标识的旧格式 ENC: 值, 解密接口因此无法解开包装的数据密钥或 Secret Key;
+// 配置中的加密值写为 ENC:<密钥标识>:<密文>, 使用所属项目的数据密钥, 一个项目的数据密钥泄露不影响其他项目
+// 派生密钥启用前直接以主密钥加密的数据仍可读取: 数据密钥在加载时重新包装, 旧格式 ENC: 值只在解密已保存的配置时兼容
 type EncryptionService struct {
-	key []byte
+	key       []byte // 主密钥, 仅用于读取启用派生密钥前的数据
+	wrapKey   []byte
+	secretKey []byte
+	valueKey  []byte
+	decrypted *contentLRU // 项目及内容哈希 -> 解密后的内容
+
+	store    DataKeyStore
+	mu       sync.Mutex
+	dataKeys map[int64]map[string][]byte // 项目 ID -> 密钥标识 -> 解包后的数据密钥
+	createMu sync.Mutex
 }
 
 // NewEncryptionService 创建加密服务
@@ -38,13 +77,54 @@ func NewEncryptionService(key string) *EncryptionService {
 	}
 
 	return &EncryptionService{
-		key: keyBytes,
+		key:       keyBytes,
+		wrapKey:   hkdfSHA256(keyBytes, keyPurposeDataKeyWrap, 32),
+		secretKey: hkdfSHA256(keyBytes, keyPurposeSecretKey, 32),
+		valueKey:  hkdfSHA256(keyBytes, keyPurposeValue, 32),
+		decrypted: newContentLRU(decryptCacheBytes),
+		dataKeys:  make(map[int64]map[string][]byte),
+	}
+}
+
+// hkdfSHA256 以 HKDF-SHA256 (RFC 5869, 盐为空) 从主密钥派生指定用途的密钥
+func hkdfSHA256(secret []byte, info string, length int) []byte {
+	extract := hmac.New(sha256.New, make([]byte, sha256.Size))
+	extract.Write(secret)
+	prk := extract.Sum(nil)
+
+	var okm, block []byte
+	for counter := byte(1); len(okm) < length; counter++ {
+		expand := hmac.New(sha256.New, prk)
+		expand.Write(block)
+		expand.Write([]byte(info))
+		expand.Write([]byte{counter})
+		block = expand.Sum(nil)
+		okm = append(okm, block...)
+	}
+	return okm[:length]
+}
+
+// SetDataKeyStore 启用项目数据密钥, 未设置时加密值沿用主密钥
+func (s *EncryptionService) SetDataKeyStore(store DataKeyStore) {
+	s.store = store
+}
+
+// EncryptSecret 加密保存 Secret Key
+func (s *EncryptionService) EncryptSecret(plaintext string) (string, error) {
+	return sealGCM(s.secretKey, plaintext)
+}
+
+// DecryptSecret 解密保存的 Secret Key, 兼容启用派生密钥前以主密钥加密的值
+func (s *EncryptionService) DecryptSecret(ciphertext string) (string, error) {
+	if plaintext, err := openGCM(s.secretKey, ciphertext); err == nil {
+		return plaintext, nil
 	}
+	return openGCM(s.key, ciphertext)
 }
 
-// Encrypt 加密
-func (s *EncryptionService) Encrypt(plaintext string) (string, error) {
-	block, err := aes.NewCipher(s.key)
+// sealGCM 以 AES-GCM 加密, 结果为 base64 编码的随机数和密文
+func sealGCM(key []byte, plaintext string) (string, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", ErrEncryptionFailed
 	}
@@ -63,14 +143,14 @@ func (s *EncryptionService) Encrypt(plaintext string) (string, error) {
 	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
-// Decrypt 解密
-func (s *EncryptionService) Decrypt(ciphertext string) (string, error) {
+// openGCM 解密 sealGCM 的结果
+func openGCM(key []byte, ciphertext string) (string, error) {
 	data, err := base64.StdEncoding.DecodeString(ciphertext)
 	if err != nil {
 		return "", ErrDecryptionFailed
 	}
 
-	block, err := aes.NewCipher(s.key)
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", ErrDecryptionFailed
 	}
@@ -94,21 +174,193 @@ func (s *EncryptionService) Decrypt(ciphertext string) (string, error) {
 	return string(plaintext), nil
 }
 
-// EncryptWithPrefix 加密并添加前缀
-func (s *EncryptionService) EncryptWithPrefix(plaintext string) (string, error) {
-	encrypted, err := s.Encrypt(plaintext)
+// EncryptForProject 使用项目当前的数据密钥加密并添加前缀, 项目还没有数据密钥时先生成
+func (s *EncryptionService) EncryptForProject(ctx context.Context, projectID int64, plaintext string) (string, error) {
+	if s.store == nil {
+		return s.EncryptWithPrefix(plaintext)
+	}
+	key, err := s.primaryDataKey(ctx, projectID)
 	if err != nil {
 		return "", err
 	}
-	return EncryptedPrefix + encrypted, nil
+	dek, err := s.dataKey(ctx, projectID, key.KeyID)
+	if err != nil {
+		return "", err
+	}
+	encrypted, err := sealGCM(dek, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return EncryptedPrefix + key.KeyID + ":" + encrypted, nil
+}
+
+// DecryptValue 解密项目中的加密值, 不是加密值时原样返回
+// 旧格式 (没有密钥标识) 只接受派生密钥加密的值; 值可能由调用方提交, 不以主密钥解密
+func (s *EncryptionService) DecryptValue(ctx context.Context, projectID int64, value string) (string, error) {
+	return s.decryptValue(ctx, projectID, value, false)
+}
+
+// DecryptStoredValue 解密已保存的配置中的加密值, 旧格式兼容启用派生密钥前以主密钥加密的值
+func (s *EncryptionService) DecryptStoredValue(ctx context.Context, projectID int64, value string) (string, error) {
+	return s.decryptValue(ctx, projectID, value, true)
+}
+
+func (s *EncryptionService) decryptValue(ctx context.Context, projectID int64, value string, stored bool) (string, error) {
+	if !s.IsEncrypted(value) {
+		return value, nil
+	}
+	keyID, ciphertext, keyed := strings.Cut(value[len(EncryptedPrefix):], ":")
+	if !keyed {
+		if plaintext, err := openGCM(s.valueKey, keyID); err == nil || !stored {
+			return plaintext, err
+		}
+		return openGCM(s.key, keyID)
+	}
+	dek, err := s.dataKey(ctx, projectID, keyID)
+	if err != nil {
+		return "", ErrDecryptionFailed
+	}
+	return openGCM(dek, ciphertext)
+}
+
+// ListDataKeys 获取项目的数据密钥, 最新的为当前用于加密的密钥
+func (s *EncryptionService) ListDataKeys(ctx context.Context, projectID int64) ([]*model.ProjectDataKey, error) {
+	if s.store == nil {
+		return nil, ErrDataKeysDisabled
+	}
+	return s.loadDataKeys(ctx, projectID)
+}
+
+// RotateDataKey 为项目生成新的数据密钥, 之后的加密使用新密钥; 旧密钥保留, 已有的加密值仍可解密,
+// 在发布流水线的 re_encrypt 步骤中重新加密
+func (s *EncryptionService) RotateDataKey(ctx context.Context, projectID int64) (*model.ProjectDataKey, error) {
+	if s.store == nil {
+		return nil, ErrDataKeysDisabled
+	}
+	s.createMu.Lock()
+	defer s.createMu.Unlock()
+	return s.createDataKey(ctx, projectID)
 }
 
-// DecryptWithPrefix 解密带前缀的值
-func (s *EncryptionService) DecryptWithPrefix(value string) (string, error) {
-	if !strings.HasPrefix(value, EncryptedPrefix) {
-		return value, nil // 不是加密值，直接返回
+// primaryDataKey 返回项目当前用于加密的数据密钥, 每次从存储读取, 以便看到其他实例轮换的密钥
+func (s *EncryptionService) primaryDataKey(ctx context.Context, projectID int64) (*model.ProjectDataKey, error) {
+	keys, err := s.loadDataKeys(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) > 0 {
+		return keys[len(keys)-1], nil
+	}
+
+	s.createMu.Lock()
+	defer s.createMu.Unlock()
+	if keys, err = s.loadDataKeys(ctx, projectID); err != nil {
+		return nil, err
+	}
+	if len(keys) > 0 {
+		return keys[len(keys)-1], nil
+	}
+	return s.createDataKey(ctx, projectID)
+}
+
+// createDataKey 生成数据密钥, 以派生的包装密钥包装后保存
+func (s *EncryptionService) createDataKey(ctx context.Context, projectID int64) (*model.ProjectDataKey, error) {
+	dek := make([]byte, 32)
+	id := make([]byte, 6)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return nil, ErrEncryptionFailed
+	}
+	if _, err := io.ReadFull(rand.Reader, id); err != nil {
+		return nil, ErrEncryptionFailed
+	}
+	wrapped, err := sealGCM(s.wrapKey, string(dek))
+	if err != nil {
+		return nil, err
+	}
+
+	key := &model.ProjectDataKey{
+		ProjectID:  projectID,
+		KeyID:      "k" + hex.EncodeToString(id),
+		WrappedKey: wrapped,
+	}
+	if err := s.store.CreateDataKey(ctx, key); err != nil {
+		return nil, err
+	}
+	key.Primary = true
+
+	s.mu.Lock()
+	if s.dataKeys[projectID] == nil {
+		s.dataKeys[projectID] = make(map[string][]byte)
 	}
-	return s.Decrypt(value[len(EncryptedPrefix):])
+	s.dataKeys[projectID][key.KeyID] = dek
+	s.mu.Unlock()
+	return key, nil
+}
+
+// dataKey 返回项目中指定标识的数据密钥, 缓存中没有时重新读取 (可能由其他实例新生成)
+func (s *EncryptionService) dataKey(ctx context.Context, projectID int64, keyID string) ([]byte, error) {
+	s.mu.Lock()
+	dek, ok := s.dataKeys[projectID][keyID]
+	s.mu.Unlock()
+	if ok {
+		return dek, nil
+	}
+	if s.store == nil {
+		return nil, ErrDataKeyNotFound
+	}
+
+	if _, err := s.loadDataKeys(ctx, projectID); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	dek, ok = s.dataKeys[projectID][keyID]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrDataKeyNotFound
+	}
+	return dek, nil
+}
+
+// loadDataKeys 读取项目的数据密钥并解包到缓存, 无法解包的密钥跳过
+// 启用派生密钥前以主密钥包装的数据密钥改用包装密钥重新包装保存, 跟随节点无法保存时仍可使用
+func (s *EncryptionService) loadDataKeys(ctx context.Context, projectID int64) ([]*model.ProjectDataKey, error) {
+	keys, err := s.store.ListDataKeys(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	deks := make(map[string][]byte, len(keys))
+	usable := make([]*model.ProjectDataKey, 0, len(keys))
+	for _, key := range keys {
+		dek, err := openGCM(s.wrapKey, key.WrappedKey)
+		if err != nil {
+			if dek, err = openGCM(s.key, key.WrappedKey); err != nil {
+				continue
+			}
+			if wrapped, err := sealGCM(s.wrapKey, dek); err == nil {
+				key.WrappedKey = wrapped
+				_ = s.store.UpdateDataKey(ctx, key)
+			}
+		}
+		deks[key.KeyID] = []byte(dek)
+		usable = append(usable, key)
+	}
+	if len(usable) > 0 {
+		usable[len(usable)-1].Primary = true
+	}
+
+	s.mu.Lock()
+	s.dataKeys[projectID] = deks
+	s.mu.Unlock()
+	return usable, nil
+}
+
+// EncryptWithPrefix 以没有密钥标识的旧格式加密并添加前缀, 用于未启用项目数据密钥时
+func (s *EncryptionService) EncryptWithPrefix(plaintext string) (string, error) {
+	encrypted, err := sealGCM(s.valueKey, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return EncryptedPrefix + encrypted, nil
 }
 
 // IsEncrypted 检查值是否已加密
@@ -137,19 +389,25 @@ func (s *EncryptionService) EncryptFields(content string, fields []string) (stri
 	return string(result), nil
 }
 
-// DecryptFields 解密 JSON 中的所有加密字段
-func (s *EncryptionService) DecryptFields(content string) (string, error) {
+// DecryptFields 解密项目 JSON 配置中的所有加密字段, 结果按项目和内容哈希缓存
+func (s *EncryptionService) DecryptFields(ctx context.Context, projectID int64, content string) (string, error) {
+	hash := generateHash(strconv.FormatInt(projectID, 10) + ":" + content)
+	if cached, ok := s.decrypted.Get(hash); ok {
+		return cached.(string), nil
+	}
+
 	var data map[string]interface{}
 	if err := json.Unmarshal([]byte(content), &data); err != nil {
 		return "", err
 	}
 
-	s.decryptMapFields(data)
+	s.decryptMapFields(ctx, projectID, data)
 
 	result, err := json.Marshal(data)
 	if err != nil {
 		return "", err
 	}
+	s.decrypted.Add(hash, string(result), len(result))
 	return string(result), nil
 }
 
@@ -184,22 +442,22 @@ func (s *EncryptionService) encryptMapFields(data map[string]interface{}, fields
 }
 
 // decryptMapFields 递归解密 map 中的加密字段
-func (s *EncryptionService) decryptMapFields(data map[string]interface{}) {
+func (s *EncryptionService) decryptMapFields(ctx context.Context, projectID int64, data map[string]interface{}) {
 	for key, value := range data {
 		switch v := value.(type) {
 		case string:
 			if s.IsEncrypted(v) {
-				decrypted, err := s.DecryptWithPrefix(v)
+				decrypted, err := s.DecryptStoredValue(ctx, projectID, v)
 				if err == nil {
 					data[key] = decrypted
 				}
 			}
 		case map[string]interface{}:
-			s.decryptMapFields(v)
+			s.decryptMapFields(ctx, projectID, v)
 		case []interface{}:
 			for _, item := range v {
 				if m, ok := item.(map[string]interface{}); ok {
-					s.decryptMapFields(m)
+					s.decryptMapFields(ctx, projectID, m)
 				}
 			}
 		}
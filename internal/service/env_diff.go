@@ -5,30 +5,36 @@ import (
 	"encoding/json"
 	"sort"
 
+	"confighub/internal/model"
 	"confighub/internal/repository"
 )
 
 // EnvDiffService 环境对比服务
 type EnvDiffService struct {
 	configRepo  *repository.ConfigRepository
-	versionRepo *repository.VersionRepository
+	versionRepo repository.VersionStore
+	envSvc      *EnvironmentService
+	contractSvc *ContractService
 }
 
 // NewEnvDiffService 创建环境对比服务
-func NewEnvDiffService(configRepo *repository.ConfigRepository, versionRepo *repository.VersionRepository) *EnvDiffService {
+func NewEnvDiffService(configRepo *repository.ConfigRepository, versionRepo repository.VersionStore, envSvc *EnvironmentService, contractSvc *ContractService) *EnvDiffService {
 	return &EnvDiffService{
 		configRepo:  configRepo,
 		versionRepo: versionRepo,
+		envSvc:      envSvc,
+		contractSvc: contractSvc,
 	}
 }
 
 // EnvComparison 环境对比结果
 type EnvComparison struct {
-	SourceEnv    string           `json:"source_env"`
-	TargetEnv    string           `json:"target_env"`
-	Differences  []EnvDifference  `json:"differences"`
-	OnlyInSource []string         `json:"only_in_source"`
-	OnlyInTarget []string         `json:"only_in_target"`
+	SourceEnv    string            `json:"source_env"`
+	TargetEnv    string            `json:"target_env"`
+	Effective    bool              `json:"effective"` // 是否对比合并及变量解析后的生效内容
+	Differences  []EnvDifference   `json:"differences"`
+	OnlyInSource []string          `json:"only_in_source"`
+	OnlyInTarget []string          `json:"only_in_target"`
 	Summary      ComparisonSummary `json:"summary"`
 }
 
@@ -49,37 +55,56 @@ type ComparisonSummary struct {
 }
 
 // Compare 对比两个环境的配置
-func (s *EnvDiffService) Compare(ctx context.Context, configID int64, sourceEnv, targetEnv string) (*EnvComparison, error) {
+// effective 为 true 时对比生效内容 (继承合并、变量解析后), 否则对比存储的原始内容
+func (s *EnvDiffService) Compare(ctx context.Context, configID int64, sourceEnv, targetEnv string, effective bool) (*EnvComparison, error) {
 	config, err := s.configRepo.GetByID(ctx, configID)
 	if err != nil {
 		return nil, ErrConfigNotFound
 	}
 
 	// 获取源环境配置
-	sourceConfig, err := s.configRepo.GetByNameAndEnv(ctx, config.ProjectID, config.Name, config.Namespace, sourceEnv)
+	sourceContent, err := s.envContent(ctx, config, sourceEnv, effective)
 	if err != nil {
-		return nil, ErrConfigNotFound
+		return nil, err
 	}
-	sourceVersion, err := s.versionRepo.GetLatest(ctx, sourceConfig.ID)
+
+	// 获取目标环境配置
+	targetContent, err := s.envContent(ctx, config, targetEnv, effective)
 	if err != nil {
 		return nil, err
 	}
 
-	// 获取目标环境配置
-	targetConfig, err := s.configRepo.GetByNameAndEnv(ctx, config.ProjectID, config.Name, config.Namespace, targetEnv)
+	rules, err := ParseDiffRules(config.DiffRules)
 	if err != nil {
-		return nil, ErrConfigNotFound
+		return nil, err
 	}
-	targetVersion, err := s.versionRepo.GetLatest(ctx, targetConfig.ID)
+
+	comparison, err := s.compareContent(sourceEnv, targetEnv, sourceContent, targetContent, rules)
 	if err != nil {
 		return nil, err
 	}
+	comparison.Effective = effective
+	return comparison, nil
+}
 
-	return s.compareContent(sourceEnv, targetEnv, sourceVersion.Content, targetVersion.Content)
+// envContent 获取同名配置在指定环境的最新内容
+func (s *EnvDiffService) envContent(ctx context.Context, config *model.Config, env string, effective bool) (string, error) {
+	envConfig, err := s.configRepo.GetByNameAndEnv(ctx, config.ProjectID, config.Name, config.Namespace, env)
+	if err != nil {
+		return "", ErrConfigNotFound
+	}
+	if effective {
+		return s.envSvc.EffectiveContent(ctx, envConfig)
+	}
+	version, err := s.versionRepo.GetLatest(ctx, envConfig.ID)
+	if err != nil {
+		return "", err
+	}
+	return version.Content, nil
 }
 
-// compareContent 对比两个配置内容
-func (s *EnvDiffService) compareContent(sourceEnv, targetEnv, sourceContent, targetContent string) (*EnvComparison, error) {
+// compareContent 对比两个配置内容, rules 中忽略的路径不计入差异
+func (s *EnvDiffService) compareContent(sourceEnv, targetEnv, sourceContent, targetContent string, rules *DiffRules) (*EnvComparison, error) {
 	var sourceData, targetData map[string]interface{}
 
 	if err := json.Unmarshal([]byte(sourceContent), &sourceData); err != nil {
@@ -97,7 +122,7 @@ func (s *EnvDiffService) compareContent(sourceEnv, targetEnv, sourceContent, tar
 		OnlyInTarget: []string{},
 	}
 
-	s.compareMap("", sourceData, targetData, comparison)
+	s.compareMap("", sourceData, targetData, comparison, rules)
 
 	// 计算摘要
 	comparison.Summary = ComparisonSummary{
@@ -116,7 +141,7 @@ func (s *EnvDiffService) compareContent(sourceEnv, targetEnv, sourceContent, tar
 }
 
 // compareMap 递归对比 map
-func (s *EnvDiffService) compareMap(prefix string, source, target map[string]interface{}, result *EnvComparison) {
+func (s *EnvDiffService) compareMap(prefix string, source, target map[string]interface{}, result *EnvComparison, rules *DiffRules) {
 	allKeys := make(map[string]bool)
 	for k := range source {
 		allKeys[k] = true
@@ -136,6 +161,9 @@ func (s *EnvDiffService) compareMap(prefix string, source, target map[string]int
 		if prefix != "" {
 			path = prefix + "." + key
 		}
+		if rules.Ignored(path) {
+			continue
+		}
 
 		sourceVal, sourceExists := source[key]
 		targetVal, targetExists := target[key]
@@ -153,8 +181,8 @@ func (s *EnvDiffService) compareMap(prefix string, source, target map[string]int
 		targetMap, targetIsMap := targetVal.(map[string]interface{})
 
 		if sourceIsMap && targetIsMap {
-			s.compareMap(path, sourceMap, targetMap, result)
-		} else if !s.valuesEqual(sourceVal, targetVal) {
+			s.compareMap(path, sourceMap, targetMap, result, rules)
+		} else if !rules.ValuesEqual(path, sourceVal, targetVal) {
 			result.Differences = append(result.Differences, EnvDifference{
 				Path:        path,
 				SourceValue: sourceVal,
@@ -165,43 +193,46 @@ func (s *EnvDiffService) compareMap(prefix string, source, target map[string]int
 	}
 }
 
-// valuesEqual 比较两个值是否相等
-func (s *EnvDiffService) valuesEqual(a, b interface{}) bool {
-	aJSON, _ := json.Marshal(a)
-	bJSON, _ := json.Marshal(b)
-	return string(aJSON) == string(bJSON)
+// SyncResult 环境同步结果
+type SyncResult struct {
+	ConfigID int64          `json:"config_id"` // 目标环境的配置
+	Version  int            `json:"version"`   // 被改写的目标环境版本
+	Changes  *EnvComparison `json:"changes"`   // 同步带入的值 (source_value) 与目标环境原有值 (target_value) 的差异
+	Content  string         `json:"content,omitempty"`
 }
 
-// Sync 同步配置到目标环境
-func (s *EnvDiffService) Sync(ctx context.Context, configID int64, sourceEnv, targetEnv string, keys []string) error {
+// Sync 同步配置到目标环境, keys 为空时以源环境内容整体覆盖
+// dryRun 为 true 时返回同步后的内容和差异, 不写入
+func (s *EnvDiffService) Sync(ctx context.Context, configID int64, sourceEnv, targetEnv string, keys []string, dryRun bool) (*SyncResult, error) {
 	config, err := s.configRepo.GetByID(ctx, configID)
 	if err != nil {
-		return ErrConfigNotFound
+		return nil, ErrConfigNotFound
 	}
 
 	// 获取源环境配置
 	sourceConfig, err := s.configRepo.GetByNameAndEnv(ctx, config.ProjectID, config.Name, config.Namespace, sourceEnv)
 	if err != nil {
-		return ErrConfigNotFound
+		return nil, ErrConfigNotFound
 	}
 	sourceVersion, err := s.versionRepo.GetLatest(ctx, sourceConfig.ID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// 获取目标环境配置
 	targetConfig, err := s.configRepo.GetByNameAndEnv(ctx, config.ProjectID, config.Name, config.Namespace, targetEnv)
 	if err != nil {
-		return ErrConfigNotFound
+		return nil, ErrConfigNotFound
 	}
 	targetVersion, err := s.versionRepo.GetLatest(ctx, targetConfig.ID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var sourceData, targetData map[string]interface{}
 	json.Unmarshal([]byte(sourceVersion.Content), &sourceData)
 	json.Unmarshal([]byte(targetVersion.Content), &targetData)
+	currentContent, _ := json.Marshal(targetData)
 
 	// 同步指定的 keys
 	if len(keys) == 0 {
@@ -215,8 +246,157 @@ func (s *EnvDiffService) Sync(ctx context.Context, configID int64, sourceEnv, ta
 	}
 
 	newContent, _ := json.MarshalIndent(targetData, "", "  ")
+	if _, err := s.contractSvc.Enforce(ctx, targetConfig, string(newContent)); err != nil {
+		return nil, err
+	}
+	changes, err := s.compareContent(sourceEnv, targetEnv, string(newContent), string(currentContent), nil)
+	if err != nil {
+		return nil, err
+	}
+	result := &SyncResult{
+		ConfigID: targetConfig.ID,
+		Version:  targetVersion.Version,
+		Changes:  changes,
+	}
+	if dryRun {
+		result.Content = string(newContent)
+		return result, nil
+	}
+
 	targetVersion.Content = string(newContent)
+	if err := s.versionRepo.Update(ctx, targetVersion); err != nil {
+		return nil, err
+	}
+	s.envSvc.notifySvc.NotifyChange(ctx, &ConfigChange{
+		ConfigID:   targetConfig.ID,
+		ConfigName: targetConfig.Name,
+		Namespace:  targetConfig.Namespace,
+		Env:        targetConfig.Environment,
+		Version:    targetVersion.Version,
+		ChangeType: "sync",
+	})
+	return result, nil
+}
+
+// DriftReport 项目级环境漂移报告
+type DriftReport struct {
+	ProjectID    int64          `json:"project_id"`
+	SourceEnv    string         `json:"source_env"`
+	TargetEnv    string         `json:"target_env"`
+	Effective    bool           `json:"effective"`
+	Configs      []*ConfigDrift `json:"configs"` // 按差异数降序
+	TotalDrift   int            `json:"total_drift"`
+	DriftedCount int            `json:"drifted_count"`
+}
 
-	return s.versionRepo.Update(ctx, targetVersion)
+// ConfigDrift 单个配置在两个环境间的漂移
+type ConfigDrift struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Status    string            `json:"status"` // in_sync, drifted, missing_in_source, missing_in_target, error
+	Drift     int               `json:"drift"`  // 差异键数量
+	Summary   ComparisonSummary `json:"summary"`
+	Error     string            `json:"error,omitempty"`
 }
 
+// 漂移状态
+const (
+	DriftStatusInSync          = "in_sync"
+	DriftStatusDrifted         = "drifted"
+	DriftStatusMissingInSource = "missing_in_source"
+	DriftStatusMissingInTarget = "missing_in_target"
+	DriftStatusError           = "error"
+)
+
+// ProjectDrift 对项目内所有配置执行环境对比, 返回按差异数排序的漂移报告
+// 仅存在于一侧环境的配置视为漂移, 排在有差异的配置之后
+func (s *EnvDiffService) ProjectDrift(ctx context.Context, projectID int64, sourceEnv, targetEnv string, effective bool) (*DriftReport, error) {
+	configs, err := s.configRepo.List(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按 命名空间/名称 归组, 记录两侧环境中的配置
+	type pair struct {
+		source, target *model.Config
+	}
+	pairs := make(map[string]*pair)
+	var keys []string
+	for _, config := range configs {
+		if config.Environment != sourceEnv && config.Environment != targetEnv {
+			continue
+		}
+		key := config.Namespace + "/" + config.Name
+		p, ok := pairs[key]
+		if !ok {
+			p = &pair{}
+			pairs[key] = p
+			keys = append(keys, key)
+		}
+		if config.Environment == sourceEnv {
+			p.source = config
+		} else {
+			p.target = config
+		}
+	}
+
+	report := &DriftReport{
+		ProjectID: projectID,
+		SourceEnv: sourceEnv,
+		TargetEnv: targetEnv,
+		Effective: effective,
+		Configs:   []*ConfigDrift{},
+	}
+
+	for _, key := range keys {
+		p := pairs[key]
+		base := p.source
+		if base == nil {
+			base = p.target
+		}
+		drift := &ConfigDrift{Name: base.Name, Namespace: base.Namespace}
+
+		switch {
+		case p.source == nil:
+			drift.Status = DriftStatusMissingInSource
+		case p.target == nil:
+			drift.Status = DriftStatusMissingInTarget
+		default:
+			comparison, err := s.Compare(ctx, p.source.ID, sourceEnv, targetEnv, effective)
+			if err != nil {
+				drift.Status = DriftStatusError
+				drift.Error = err.Error()
+				break
+			}
+			drift.Summary = comparison.Summary
+			drift.Drift = comparison.Summary.TotalKeys
+			drift.Status = DriftStatusInSync
+			if drift.Drift > 0 {
+				drift.Status = DriftStatusDrifted
+			}
+		}
+
+		if drift.Status != DriftStatusInSync {
+			report.DriftedCount++
+		}
+		report.TotalDrift += drift.Drift
+		report.Configs = append(report.Configs, drift)
+	}
+
+	sort.SliceStable(report.Configs, func(i, j int) bool {
+		return driftRank(report.Configs[i]) > driftRank(report.Configs[j])
+	})
+	return report, nil
+}
+
+// driftRank 排序权重: 差异数优先, 缺失和错误排在有差异的配置之后, 同步的配置最后
+func driftRank(d *ConfigDrift) int {
+	switch d.Status {
+	case DriftStatusDrifted:
+		return d.Drift + 2
+	case DriftStatusMissingInSource, DriftStatusMissingInTarget, DriftStatusError:
+		return 1
+	default:
+		return 0
+	}
+}
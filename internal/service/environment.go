@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"confighub/internal/model"
 	"confighub/internal/repository"
@@ -12,14 +15,40 @@ import (
 var (
 	ErrEnvironmentNotFound = errors.New("环境不存在")
 	ErrEnvironmentExists   = errors.New("环境已存在")
+	ErrEnvironmentLast     = errors.New("项目至少需要保留一个环境")
+	ErrEnvironmentSameName = errors.New("目标环境不能与当前环境相同")
 )
 
+// EnvironmentInUseError 环境仍被配置或发布引用, 需指定迁移目标后才能删除
+type EnvironmentInUseError struct {
+	Usage *repository.EnvironmentUsage
+}
+
+func (e *EnvironmentInUseError) Error() string {
+	if e.Usage.ActiveGrayReleases > 0 {
+		return fmt.Sprintf("环境存在 %d 个进行中的灰度发布, 请先全量发布或取消", e.Usage.ActiveGrayReleases)
+	}
+	return fmt.Sprintf("环境仍被 %d 个配置和 %d 条发布记录引用, 请指定迁移目标环境", e.Usage.Configs, e.Usage.Releases)
+}
+
+// EnvironmentConflictError 迁移目标环境中已存在同名配置
+type EnvironmentConflictError struct {
+	Configs []string
+}
+
+func (e *EnvironmentConflictError) Error() string {
+	return "目标环境中已存在同名配置: " + strings.Join(e.Configs, ", ")
+}
+
 // Environment 环境定义
 type Environment struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Order       int    `json:"order"`
-	IsDefault   bool   `json:"is_default"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Order       int        `json:"order"`
+	IsDefault   bool       `json:"is_default"`
+	Sandbox     bool       `json:"sandbox,omitempty"`    // 沙箱环境, 删除时直接丢弃数据
+	Source      string     `json:"source,omitempty"`     // 沙箱的来源环境
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // 沙箱过期时间, 过期后自动清理
 }
 
 // DefaultEnvironments 默认环境列表
@@ -34,15 +63,21 @@ var DefaultEnvironments = []Environment{
 type EnvironmentService struct {
 	projectRepo *repository.ProjectRepository
 	configRepo  *repository.ConfigRepository
-	versionRepo *repository.VersionRepository
+	versionRepo repository.VersionStore
+	envRepo     *repository.EnvironmentRepository
+	notifySvc   *NotificationService
+	merged      *contentLRU // 基础配置哈希 + 环境配置哈希 -> 合并后的内容
 }
 
 // NewEnvironmentService 创建环境服务
-func NewEnvironmentService(projectRepo *repository.ProjectRepository, configRepo *repository.ConfigRepository, versionRepo *repository.VersionRepository) *EnvironmentService {
+func NewEnvironmentService(projectRepo *repository.ProjectRepository, configRepo *repository.ConfigRepository, versionRepo repository.VersionStore, envRepo *repository.EnvironmentRepository, notifySvc *NotificationService) *EnvironmentService {
 	return &EnvironmentService{
 		projectRepo: projectRepo,
 		configRepo:  configRepo,
 		versionRepo: versionRepo,
+		envRepo:     envRepo,
+		notifySvc:   notifySvc,
+		merged:      newContentLRU(mergeCacheBytes),
 	}
 }
 
@@ -52,18 +87,60 @@ func (s *EnvironmentService) List(ctx context.Context, projectID int64) ([]Envir
 	if err != nil {
 		return nil, ErrProjectNotFound
 	}
+	return projectEnvironments(project), nil
+}
 
+// projectEnvironments 解析项目设置中的环境列表, 未自定义时返回默认环境
+func projectEnvironments(project *model.Project) []Environment {
 	// 如果项目有自定义环境配置
 	if project.Settings != "" {
 		var settings struct {
 			Environments []Environment `json:"environments"`
 		}
 		if err := json.Unmarshal([]byte(project.Settings), &settings); err == nil && len(settings.Environments) > 0 {
-			return settings.Environments, nil
+			return settings.Environments
 		}
 	}
 
-	return DefaultEnvironments, nil
+	envs := make([]Environment, len(DefaultEnvironments))
+	copy(envs, DefaultEnvironments)
+	return envs
+}
+
+// setProjectEnvironments 写回项目设置中的环境列表, 保留其他设置项
+func setProjectEnvironments(project *model.Project, envs []Environment) error {
+	settings := map[string]json.RawMessage{}
+	if project.Settings != "" {
+		if err := json.Unmarshal([]byte(project.Settings), &settings); err != nil {
+			settings = map[string]json.RawMessage{}
+		}
+	}
+
+	for i := range envs {
+		envs[i].Order = i + 1
+	}
+	envsJSON, err := json.Marshal(envs)
+	if err != nil {
+		return err
+	}
+	settings["environments"] = envsJSON
+
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	project.Settings = string(settingsJSON)
+	return nil
+}
+
+// findEnvironment 查找环境下标, 不存在返回 -1
+func findEnvironment(envs []Environment, name string) int {
+	for i, e := range envs {
+		if e.Name == name {
+			return i
+		}
+	}
+	return -1
 }
 
 // Create 创建自定义环境
@@ -73,27 +150,290 @@ func (s *EnvironmentService) Create(ctx context.Context, projectID int64, env En
 		return ErrProjectNotFound
 	}
 
-	envs, _ := s.List(ctx, projectID)
-	for _, e := range envs {
-		if e.Name == env.Name {
+	envs := projectEnvironments(project)
+	if findEnvironment(envs, env.Name) >= 0 {
+		return ErrEnvironmentExists
+	}
+
+	// 沙箱只能通过 CloneToSandbox 创建
+	env.Sandbox, env.Source, env.ExpiresAt = false, "", nil
+	envs = append(envs, env)
+	if err := setProjectEnvironments(project, envs); err != nil {
+		return err
+	}
+
+	return s.projectRepo.Update(ctx, project)
+}
+
+// EnvironmentImpact 环境变更影响评估
+type EnvironmentImpact struct {
+	Environment string                       `json:"environment"`
+	Usage       *repository.EnvironmentUsage `json:"usage"`
+	CanDelete   bool                         `json:"can_delete"` // 无引用时可直接删除
+}
+
+// Impact 评估修改或删除环境的影响范围
+func (s *EnvironmentService) Impact(ctx context.Context, projectID int64, name string) (*EnvironmentImpact, error) {
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	envs := projectEnvironments(project)
+	if findEnvironment(envs, name) < 0 {
+		return nil, ErrEnvironmentNotFound
+	}
+
+	usage, err := s.envRepo.CountUsage(ctx, projectID, name)
+	if err != nil {
+		return nil, err
+	}
+	return &EnvironmentImpact{
+		Environment: name,
+		Usage:       usage,
+		CanDelete:   !usage.InUse() && len(envs) > 1,
+	}, nil
+}
+
+// UpdateEnvironmentRequest 更新环境请求, Name 非空且不同于当前名称时执行改名
+type UpdateEnvironmentRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+}
+
+// Update 更新环境, 改名时同步迁移绑定到该环境的配置和发布
+func (s *EnvironmentService) Update(ctx context.Context, projectID int64, name string, req *UpdateEnvironmentRequest) error {
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return ErrProjectNotFound
+	}
+
+	envs := projectEnvironments(project)
+	idx := findEnvironment(envs, name)
+	if idx < 0 {
+		return ErrEnvironmentNotFound
+	}
+
+	if req.Description != nil {
+		envs[idx].Description = *req.Description
+	}
+
+	rename := req.Name != "" && req.Name != name
+	if rename {
+		if findEnvironment(envs, req.Name) >= 0 {
 			return ErrEnvironmentExists
 		}
+		// 环境列表之外也可能存在绑定到新名称的配置
+		conflicts, err := s.envRepo.ListConflicts(ctx, projectID, name, req.Name)
+		if err != nil {
+			return err
+		}
+		if len(conflicts) > 0 {
+			return &EnvironmentConflictError{Configs: conflicts}
+		}
+		envs[idx].Name = req.Name
 	}
 
-	env.Order = len(envs) + 1
-	envs = append(envs, env)
+	if err := setProjectEnvironments(project, envs); err != nil {
+		return err
+	}
+	if !rename {
+		return s.projectRepo.Update(ctx, project)
+	}
+	if err := s.envRepo.Migrate(ctx, project, name, req.Name, true); err != nil {
+		return err
+	}
+	s.notifyEnvironment(ctx, projectID, req.Name)
+	return nil
+}
+
+// EnvironmentDeletion 删除环境的预演结果
+type EnvironmentDeletion struct {
+	Environment string                       `json:"environment"`
+	Sandbox     bool                         `json:"sandbox"`
+	Usage       *repository.EnvironmentUsage `json:"usage,omitempty"`
+	MigrateTo   string                       `json:"migrate_to,omitempty"` // 绑定的配置和发布迁移到的环境
+	ConfigIDs   []int64                      `json:"config_ids,omitempty"` // 将迁移的配置
+	Purged      *repository.DeleteImpact     `json:"purged,omitempty"`     // 沙箱环境将丢弃的数据
+}
 
-	settings := map[string]interface{}{
-		"environments": envs,
+// Delete 删除环境
+// 环境仍被引用时必须指定 migrateTo, 绑定的配置和发布将迁移到该环境
+// dryRun 为 true 时只做同样的检查并返回将迁移或丢弃的数据, 不修改
+func (s *EnvironmentService) Delete(ctx context.Context, projectID int64, name, migrateTo string, dryRun bool) (*EnvironmentDeletion, error) {
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
 	}
-	settingsJSON, _ := json.Marshal(settings)
-	project.Settings = string(settingsJSON)
 
-	return s.projectRepo.Update(ctx, project)
+	envs := projectEnvironments(project)
+	idx := findEnvironment(envs, name)
+	if idx < 0 {
+		return nil, ErrEnvironmentNotFound
+	}
+	if len(envs) == 1 {
+		return nil, ErrEnvironmentLast
+	}
+	if envs[idx].Sandbox {
+		if dryRun {
+			purged, err := s.envRepo.PurgeImpact(ctx, projectID, name)
+			if err != nil {
+				return nil, err
+			}
+			return &EnvironmentDeletion{Environment: name, Sandbox: true, Purged: purged}, nil
+		}
+		return nil, s.purgeSandbox(ctx, project, envs, idx)
+	}
+
+	usage, err := s.envRepo.CountUsage(ctx, projectID, name)
+	if err != nil {
+		return nil, err
+	}
+	if usage.ActiveGrayReleases > 0 || (usage.InUse() && migrateTo == "") {
+		return nil, &EnvironmentInUseError{Usage: usage}
+	}
+
+	if migrateTo != "" {
+		if migrateTo == name {
+			return nil, ErrEnvironmentSameName
+		}
+		if findEnvironment(envs, migrateTo) < 0 {
+			return nil, ErrEnvironmentNotFound
+		}
+		conflicts, err := s.envRepo.ListConflicts(ctx, projectID, name, migrateTo)
+		if err != nil {
+			return nil, err
+		}
+		if len(conflicts) > 0 {
+			return nil, &EnvironmentConflictError{Configs: conflicts}
+		}
+	}
+
+	if dryRun {
+		deletion := &EnvironmentDeletion{Environment: name, Usage: usage, MigrateTo: migrateTo}
+		if migrateTo != "" {
+			if deletion.ConfigIDs, err = s.envRepo.ListConfigIDs(ctx, projectID, name); err != nil {
+				return nil, err
+			}
+		}
+		return deletion, nil
+	}
+	if migrateTo == "" {
+		// 无引用数据, 迁移仅删除环境记录
+		migrateTo = name
+	}
+
+	envs = append(envs[:idx], envs[idx+1:]...)
+	if err := setProjectEnvironments(project, envs); err != nil {
+		return nil, err
+	}
+	if err := s.envRepo.Migrate(ctx, project, name, migrateTo, false); err != nil {
+		return nil, err
+	}
+	if migrateTo != name {
+		s.notifyEnvironment(ctx, projectID, migrateTo)
+	}
+	return nil, nil
+}
+
+// GetVariables 获取环境变量
+func (s *EnvironmentService) GetVariables(ctx context.Context, projectID int64, name string) (map[string]string, error) {
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	if findEnvironment(projectEnvironments(project), name) < 0 {
+		return nil, ErrEnvironmentNotFound
+	}
+	return s.variables(ctx, projectID, name), nil
+}
+
+// SetVariables 整体替换环境变量, 并通知该环境下的配置监听方重新拉取
+func (s *EnvironmentService) SetVariables(ctx context.Context, projectID int64, name string, vars map[string]string) error {
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return ErrProjectNotFound
+	}
+	envs := projectEnvironments(project)
+	idx := findEnvironment(envs, name)
+	if idx < 0 {
+		return ErrEnvironmentNotFound
+	}
+	if err := ValidateVariables(vars); err != nil {
+		return err
+	}
+
+	// 自定义环境仅记录在项目设置中, 首次设置变量时补建环境记录
+	env, err := s.projectRepo.GetEnvironment(ctx, projectID, name)
+	if err != nil {
+		env = &model.ProjectEnvironment{
+			ProjectID:   projectID,
+			Name:        name,
+			Description: envs[idx].Description,
+			SortOrder:   envs[idx].Order,
+		}
+	}
+
+	varsJSON, err := json.Marshal(vars)
+	if err != nil {
+		return err
+	}
+	env.Variables = string(varsJSON)
+	if err := s.projectRepo.SaveEnvironment(ctx, env); err != nil {
+		return err
+	}
+
+	s.notifyEnvironment(ctx, projectID, name)
+	return nil
+}
+
+// ResolveVariables 将配置内容中的 ${env:VAR} 替换为配置所在环境的变量值
+func (s *EnvironmentService) ResolveVariables(ctx context.Context, config *model.Config, content string) string {
+	vars := s.variables(ctx, config.ProjectID, config.Environment)
+	return InterpolateVariables(content, config.FileType, vars)
+}
+
+// variables 读取环境变量, 环境记录不存在或未设置时返回空
+func (s *EnvironmentService) variables(ctx context.Context, projectID int64, name string) map[string]string {
+	env, err := s.projectRepo.GetEnvironment(ctx, projectID, name)
+	if err != nil || env.Variables == "" {
+		return map[string]string{}
+	}
+	vars := map[string]string{}
+	if err := json.Unmarshal([]byte(env.Variables), &vars); err != nil {
+		return map[string]string{}
+	}
+	return vars
+}
+
+// notifyEnvironment 变量变化会影响环境下所有配置的解析结果, 逐个发送变更通知
+func (s *EnvironmentService) notifyEnvironment(ctx context.Context, projectID int64, name string) {
+	configs, err := s.configRepo.List(ctx, projectID)
+	if err != nil {
+		return
+	}
+	for _, config := range configs {
+		if config.Environment != name {
+			continue
+		}
+		s.notifySvc.NotifyChange(ctx, &ConfigChange{
+			ConfigID:   config.ID,
+			ConfigName: config.Name,
+			Namespace:  config.Namespace,
+			Env:        config.Environment,
+			Version:    config.CurrentVersion,
+			ChangeType: "variables",
+		})
+	}
 }
 
 // MergeConfig 合并配置 (基础配置 + 环境覆盖)
 func (s *EnvironmentService) MergeConfig(ctx context.Context, baseContent, envContent string) (string, error) {
+	// 合并结果只取决于两侧内容, 按内容哈希缓存
+	key := generateHash(baseContent) + generateHash(envContent)
+	if cached, ok := s.merged.Get(key); ok {
+		return cached.(string), nil
+	}
+
 	var base, env map[string]interface{}
 
 	if err := json.Unmarshal([]byte(baseContent), &base); err != nil {
@@ -108,17 +448,18 @@ func (s *EnvironmentService) MergeConfig(ctx context.Context, baseContent, envCo
 		return "", errors.New("环境配置格式无效")
 	}
 
-	merged := s.deepMerge(base, env)
+	merged := deepMerge(base, env)
 	result, err := json.MarshalIndent(merged, "", "  ")
 	if err != nil {
 		return "", err
 	}
 
+	s.merged.Add(key, string(result), len(result))
 	return string(result), nil
 }
 
-// deepMerge 深度合并两个 map
-func (s *EnvironmentService) deepMerge(base, override map[string]interface{}) map[string]interface{} {
+// deepMerge 深度合并两个 map, 同名的对象递归合并, 其他值以 override 为准
+func deepMerge(base, override map[string]interface{}) map[string]interface{} {
 	result := make(map[string]interface{})
 
 	// 复制 base
@@ -132,7 +473,7 @@ func (s *EnvironmentService) deepMerge(base, override map[string]interface{}) ma
 			baseMap, baseIsMap := baseVal.(map[string]interface{})
 			overrideMap, overrideIsMap := v.(map[string]interface{})
 			if baseIsMap && overrideIsMap {
-				result[k] = s.deepMerge(baseMap, overrideMap)
+				result[k] = deepMerge(baseMap, overrideMap)
 				continue
 			}
 		}
@@ -142,6 +483,16 @@ func (s *EnvironmentService) deepMerge(base, override map[string]interface{}) ma
 	return result
 }
 
+// EffectiveContent 获取配置的生效内容: 基础配置与环境覆盖合并后, 再解析 ${env:VAR} 引用
+// 即客户端实际观察到的内容
+func (s *EnvironmentService) EffectiveContent(ctx context.Context, config *model.Config) (string, error) {
+	version, err := s.GetConfigForEnv(ctx, config.ID, config.Environment)
+	if err != nil {
+		return "", err
+	}
+	return s.ResolveVariables(ctx, config, version.Content), nil
+}
+
 // GetConfigForEnv 获取指定环境的配置
 func (s *EnvironmentService) GetConfigForEnv(ctx context.Context, configID int64, env string) (*model.ConfigVersion, error) {
 	config, err := s.configRepo.GetByID(ctx, configID)
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+)
+
+// EventRetention 变更事件日志保留时长, 超过后断点续传的客户端需要全量重新加载
+const EventRetention = 24 * time.Hour
+
+// maxEventBacklog 断点续传时单次补发的最大事件数
+const maxEventBacklog = 1000
+
+// EventService 配置变更事件日志
+// 将每次变更记录到 config_notifications, 事件 ID 即日志 ID, 供 SSE 客户端通过 Last-Event-ID 续传
+type EventService struct {
+	notificationRepo *repository.NotificationRepository
+	configRepo       *repository.ConfigRepository
+}
+
+// NewEventService 创建事件日志服务, 并注册为通知服务的变更监听器
+func NewEventService(notificationRepo *repository.NotificationRepository, configRepo *repository.ConfigRepository, notifySvc *NotificationService) *EventService {
+	s := &EventService{
+		notificationRepo: notificationRepo,
+		configRepo:       configRepo,
+	}
+	notifySvc.OnChange(s.record)
+	return s
+}
+
+// record 补全变更所属的配置信息并写入事件日志
+// 监听器先于订阅方执行, 订阅方收到的变更已带有事件 ID; 配置已删除时只记录携带归属的删除事件
+func (s *EventService) record(change *ConfigChange) {
+	ctx := context.Background()
+	// 删除事件在配置删除后发出, 由变更自身携带配置的归属
+	config, err := s.configRepo.GetByID(ctx, change.ConfigID)
+	if err != nil {
+		if change.ChangeType != "delete" || change.ProjectID == 0 {
+			return
+		}
+	} else {
+		change.ProjectID = config.ProjectID
+		if change.ConfigName == "" {
+			change.ConfigName = config.Name
+		}
+		if change.Namespace == "" {
+			change.Namespace = config.Namespace
+		}
+		if change.Env == "" {
+			change.Env = config.Environment
+		}
+		if change.Version == 0 {
+			change.Version = config.CurrentVersion
+		}
+	}
+
+	notification := &model.ConfigNotification{
+		ConfigID:    change.ConfigID,
+		Version:     change.Version,
+		ChangeType:  change.ChangeType,
+		ProjectID:   change.ProjectID,
+		ConfigName:  change.ConfigName,
+		Namespace:   change.Namespace,
+		Environment: change.Env,
+	}
+	if err := s.notificationRepo.Create(ctx, notification); err == nil {
+		change.ID = notification.ID
+	}
+}
+
+// Since 获取 afterID 之后项目指定命名空间和环境的变更事件
+// 返回的 complete 为 false 表示 afterID 之后的部分事件已过期或超出补发上限, 客户端应全量重新加载
+func (s *EventService) Since(ctx context.Context, projectID int64, namespace, env string, afterID int64) ([]*ConfigChange, bool, error) {
+	minID, err := s.notificationRepo.MinID(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	complete := minID == 0 || minID <= afterID+1
+
+	events, err := s.notificationRepo.ListAfter(ctx, projectID, namespace, env, afterID, maxEventBacklog+1)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(events) > maxEventBacklog {
+		events = events[:maxEventBacklog]
+		complete = false
+	}
+
+	changes := make([]*ConfigChange, len(events))
+	for i, e := range events {
+		changes[i] = &ConfigChange{
+			ID:         e.ID,
+			ProjectID:  projectID,
+			ConfigID:   e.ConfigID,
+			ConfigName: e.Name,
+			Namespace:  e.Namespace,
+			Env:        e.Environment,
+			Version:    e.Version,
+			ChangeType: e.ChangeType,
+		}
+	}
+	return changes, complete, nil
+}
+
+// RunPrune 定期清理超过保留时长的事件
+func (s *EventService) RunPrune(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.notificationRepo.DeleteBefore(ctx, time.Now().Add(-EventRetention)); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+)
+
+// maxPendingExposures 内存中尚未写入的曝光记录上限, 达到后新客户端的曝光被丢弃
+const maxPendingExposures = 100000
+
+// exposureFlushAttempts 一条曝光写入失败后的最多尝试次数, 如发布已被删除
+const exposureFlushAttempts = 3
+
+// exposureKey 同一发布/客户端/分组的曝光
+type exposureKey struct {
+	releaseID int64
+	clientID  string
+	variant   string
+}
+
+// pendingExposure 尚未写入数据库的曝光
+type pendingExposure struct {
+	exposure *model.GrayExposure // HitCount 为累计的命中次数
+	attempts int
+}
+
+// ExperimentService 灰度实验追踪服务
+// 记录灰度期间每个客户端收到的分组 (gray/stable), 供产品团队按 client_id 关联业务指标
+// 曝光先在内存中按发布/客户端/分组合并, 定期批量写入, 公开读取路径上不访问数据库
+type ExperimentService struct {
+	experimentRepo *repository.ExperimentRepository
+
+	mu      sync.Mutex
+	pending map[exposureKey]*pendingExposure
+	dropped uint64
+}
+
+// NewExperimentService 创建灰度实验追踪服务
+func NewExperimentService(experimentRepo *repository.ExperimentRepository) *ExperimentService {
+	return &ExperimentService{
+		experimentRepo: experimentRepo,
+		pending:        make(map[exposureKey]*pendingExposure),
+	}
+}
+
+// RecordExposure 记录客户端曝光, 在下次写入时落库
+func (s *ExperimentService) RecordExposure(release *model.Release, env, clientID, variant string, version int, clientIP string) {
+	if release == nil || clientID == "" {
+		return
+	}
+	key := exposureKey{releaseID: release.ID, clientID: clientID, variant: variant}
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.pending[key]; ok {
+		p.exposure.HitCount++
+		p.exposure.LastSeenAt = now
+		p.exposure.Version = version
+		p.exposure.IPAddress = clientIP
+		return
+	}
+	if len(s.pending) >= maxPendingExposures {
+		s.dropped++
+		return
+	}
+	s.pending[key] = &pendingExposure{exposure: &model.GrayExposure{
+		ProjectID:   release.ProjectID,
+		ConfigID:    release.ConfigID,
+		ReleaseID:   release.ID,
+		ClientID:    clientID,
+		Variant:     variant,
+		Environment: env,
+		Version:     version,
+		IPAddress:   clientIP,
+		HitCount:    1,
+		FirstSeenAt: now,
+		LastSeenAt:  now,
+	}}
+}
+
+// Flush 将内存中的曝光写入数据库, 写入失败的记录留待下次重试 (最多 exposureFlushAttempts 次)
+func (s *ExperimentService) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[exposureKey]*pendingExposure)
+	dropped := s.dropped
+	s.dropped = 0
+	s.mu.Unlock()
+
+	var errs []error
+	if dropped > 0 {
+		errs = append(errs, fmt.Errorf("曝光记录积压过多, 丢弃了 %d 条", dropped))
+	}
+	for key, p := range pending {
+		if err := s.experimentRepo.UpsertExposure(ctx, p.exposure); err != nil {
+			errs = append(errs, err)
+			if p.attempts+1 < exposureFlushAttempts {
+				s.restore(key, &pendingExposure{exposure: p.exposure, attempts: p.attempts + 1})
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// restore 将未写入的曝光放回, 期间已有新的曝光时合并命中次数
+func (s *ExperimentService) restore(key exposureKey, p *pendingExposure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.pending[key]
+	if !ok {
+		s.pending[key] = p
+		return
+	}
+	existing.exposure.HitCount += p.exposure.HitCount
+	existing.exposure.FirstSeenAt = p.exposure.FirstSeenAt
+	existing.attempts = p.attempts
+}
+
+// Run 定期写入曝光, ctx 结束时写入剩余的曝光后返回
+func (s *ExperimentService) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.Flush(context.Background())
+			return
+		case <-ticker.C:
+			if err := s.Flush(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// List 获取曝光记录
+func (s *ExperimentService) List(ctx context.Context, filter *repository.ExposureFilter) ([]*model.GrayExposure, error) {
+	return s.experimentRepo.ListExposures(ctx, filter)
+}
+
+// ExportCSV 导出曝光记录为 CSV
+func (s *ExperimentService) ExportCSV(ctx context.Context, filter *repository.ExposureFilter, w io.Writer) error {
+	exposures, err := s.experimentRepo.ListExposures(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+
+	header := []string{"client_id", "variant", "release_id", "config_id", "environment", "version", "hit_count", "first_seen_at", "last_seen_at"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, e := range exposures {
+		row := []string{
+			e.ClientID,
+			e.Variant,
+			fmt.Sprintf("%d", e.ReleaseID),
+			fmt.Sprintf("%d", e.ConfigID),
+			e.Environment,
+			fmt.Sprintf("%d", e.Version),
+			fmt.Sprintf("%d", e.HitCount),
+			e.FirstSeenAt.Format(time.RFC3339),
+			e.LastSeenAt.Format(time.RFC3339),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExportJSON 导出曝光记录为 JSON
+func (s *ExperimentService) ExportJSON(ctx context.Context, filter *repository.ExposureFilter, w io.Writer) error {
+	exposures, err := s.experimentRepo.ListExposures(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(exposures)
+}
@@ -0,0 +1,380 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"confighub/internal/model"
+)
+
+// FollowerService 只读跟随节点服务
+// 定时从主实例拉取项目快照并缓存在内存中, 仅供公开读取和监听接口使用, 不依赖数据库
+type FollowerService struct {
+	client    *ReplicationClient
+	notifySvc *NotificationService
+	projects  []string
+	interval  time.Duration
+
+	mu             sync.RWMutex
+	ids            map[string]int64 // 快照以名称关联数据, 本地分配稳定的 ID
+	nextID         int64
+	keys           map[string]*model.ProjectKey
+	rejectQueryKey map[int64]bool // 拒绝查询参数中 Access Key 的项目
+	locales        map[int64]*LocaleSettings
+	dataKeys       map[int64][]*model.ProjectDataKey
+	configs        map[string]*FollowerConfig
+	synced         map[string]*ProjectReplicationStatus
+}
+
+// FollowerConfig 跟随节点缓存的配置, Content 已按环境变量解析
+type FollowerConfig struct {
+	ID          int64
+	ProjectID   int64
+	Name        string
+	Namespace   string
+	Environment string
+	FileType    string
+	Version     int
+	CommitHash  string
+	Content     string
+	Release     *model.ReplicatedRelease // 当前版本对应的最新正式发布, 可能为空
+}
+
+// FollowerStatus 跟随节点状态
+type FollowerStatus struct {
+	Ready    bool                                 `json:"ready"`
+	Configs  int                                  `json:"configs"`
+	Projects map[string]*ProjectReplicationStatus `json:"projects"`
+}
+
+// NewFollowerService 创建跟随节点服务
+func NewFollowerService(client *ReplicationClient, notifySvc *NotificationService, projects []string, interval time.Duration) *FollowerService {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &FollowerService{
+		client:         client,
+		notifySvc:      notifySvc,
+		projects:       projects,
+		interval:       interval,
+		ids:            make(map[string]int64),
+		keys:           make(map[string]*model.ProjectKey),
+		rejectQueryKey: make(map[int64]bool),
+		locales:        make(map[int64]*LocaleSettings),
+		dataKeys:       make(map[int64][]*model.ProjectDataKey),
+		configs:        make(map[string]*FollowerConfig),
+		synced:         make(map[string]*ProjectReplicationStatus),
+	}
+}
+
+// Run 按间隔刷新快照, 直到 ctx 结束
+func (s *FollowerService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.Refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh 拉取所有项目的快照并替换缓存, 拉取失败的项目保留上一次的数据
+func (s *FollowerService) Refresh(ctx context.Context) {
+	for _, name := range s.projects {
+		snapshot, err := s.client.FetchSnapshot(ctx, name)
+		if err != nil {
+			s.mu.Lock()
+			status := s.status(name)
+			status.LastError = err.Error()
+			s.mu.Unlock()
+			continue
+		}
+
+		changed, revoked := s.load(snapshot)
+		for _, keyID := range revoked {
+			s.notifySvc.RevokeAccessKey(keyID)
+		}
+		for _, configID := range changed {
+			s.notifySvc.NotifyChange(ctx, &ConfigChange{
+				ConfigID:   configID,
+				ChangeType: "replication",
+			})
+		}
+	}
+}
+
+// Authenticate 按 Access Key 查找缓存的密钥
+func (s *FollowerService) Authenticate(accessKey string) (*model.ProjectKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.keys[accessKey]
+	if !ok || !key.IsActive {
+		return nil, ErrKeyNotFound
+	}
+	copied := *key
+	return &copied, nil
+}
+
+// RejectsQueryAccessKey 项目是否拒绝在查询参数中传递 Access Key, 取自主实例的项目设置
+func (s *FollowerService) RejectsQueryAccessKey(ctx context.Context, projectID int64) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rejectQueryKey[projectID]
+}
+
+// LocaleChain 按复制的项目多语言设置计算语言回退链, locale 为空时返回空
+func (s *FollowerService) LocaleChain(projectID int64, locale string) ([]string, error) {
+	if locale == "" {
+		return nil, nil
+	}
+	locale, err := NormalizeLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	settings := s.locales[projectID]
+	s.mu.RUnlock()
+	return LocaleChain(locale, settings), nil
+}
+
+// GetConfig 获取缓存的配置, 命名空间和环境的默认值与主实例一致
+func (s *FollowerService) GetConfig(projectID int64, name, namespace, env string) (*FollowerConfig, error) {
+	namespace, env = ResolveNamespaceEnv(namespace, env)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	config, ok := s.configs[followerConfigKey(projectID, namespace, name, env)]
+	if !ok {
+		return nil, ErrConfigNotFound
+	}
+	copied := *config
+	return &copied, nil
+}
+
+// ListConfigs 获取项目在命名空间和环境下缓存的全部配置, 按名称排序
+func (s *FollowerService) ListConfigs(projectID int64, namespace, env string) []*FollowerConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var configs []*FollowerConfig
+	for _, config := range s.configs {
+		if config.ProjectID == projectID && config.Namespace == namespace && config.Environment == env {
+			copied := *config
+			configs = append(configs, &copied)
+		}
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Name < configs[j].Name })
+	return configs
+}
+
+// ListDataKeys 返回快照中项目的数据密钥, 供加密服务解密配置中的 ENC: 值
+func (s *FollowerService) ListDataKeys(ctx context.Context, projectID int64) ([]*model.ProjectDataKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]*model.ProjectDataKey, 0, len(s.dataKeys[projectID]))
+	for _, key := range s.dataKeys[projectID] {
+		copied := *key
+		keys = append(keys, &copied)
+	}
+	return keys, nil
+}
+
+// CreateDataKey 跟随节点只读, 不生成数据密钥
+func (s *FollowerService) CreateDataKey(ctx context.Context, key *model.ProjectDataKey) error {
+	return ErrFollowerReadOnly
+}
+
+// UpdateDataKey 跟随节点只读, 数据密钥随主实例快照更新
+func (s *FollowerService) UpdateDataKey(ctx context.Context, key *model.ProjectDataKey) error {
+	return ErrFollowerReadOnly
+}
+
+// Status 获取跟随节点状态, 所有项目至少成功同步一次后才视为就绪
+func (s *FollowerService) Status() *FollowerStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := &FollowerStatus{
+		Ready:    true,
+		Configs:  len(s.configs),
+		Projects: make(map[string]*ProjectReplicationStatus, len(s.projects)),
+	}
+	for _, name := range s.projects {
+		synced, ok := s.synced[name]
+		if !ok || synced.LastSyncAt.IsZero() {
+			status.Ready = false
+		}
+		if ok {
+			copied := *synced
+			status.Projects[name] = &copied
+		}
+	}
+	return status
+}
+
+// load 用快照替换项目的缓存, 返回内容发生变化的配置 ID 和已失效的密钥 ID
+func (s *FollowerService) load(snapshot *model.ReplicationSnapshot) (changed, revoked []int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	projectID := s.id("project:" + snapshot.Project.Name)
+
+	var reject bool
+	project := &model.Project{Settings: snapshot.Project.Settings}
+	s.rejectQueryKey[projectID] = projectSetting(project, projectSettingRejectQueryKey, &reject) && reject
+	s.locales[projectID] = projectLocales(project)
+
+	dataKeys := make([]*model.ProjectDataKey, 0, len(snapshot.DataKeys))
+	for _, rk := range snapshot.DataKeys {
+		dataKeys = append(dataKeys, &model.ProjectDataKey{
+			ProjectID:  projectID,
+			KeyID:      rk.KeyID,
+			WrappedKey: rk.WrappedKey,
+			CreatedAt:  rk.CreatedAt,
+		})
+	}
+	s.dataKeys[projectID] = dataKeys
+
+	previous := make(map[string]*model.ProjectKey)
+	for accessKey, key := range s.keys {
+		if key.ProjectID == projectID {
+			previous[accessKey] = key
+			delete(s.keys, accessKey)
+		}
+	}
+	for _, rk := range snapshot.Keys {
+		key := &model.ProjectKey{
+			ID:            s.id("key:" + rk.AccessKey),
+			ProjectID:     projectID,
+			Name:          rk.Name,
+			AccessKey:     rk.AccessKey,
+			Permissions:   rk.Permissions,
+			IPWhitelist:   rk.IPWhitelist,
+			ExpiresAt:     rk.ExpiresAt,
+			IsActive:      rk.IsActive && snapshot.Project.ArchivedAt == nil,
+			Priority:      rk.Priority,
+			ResolveTiming: rk.ResolveTiming,
+		}
+		if key.Priority == "" {
+			key.Priority = model.KeyPriorityNormal
+		}
+		// 与主实例一致: 密钥禁用或权限、白名单、有效期、监听优先级变化时断开其监听
+		if old, ok := previous[rk.AccessKey]; ok && old.IsActive &&
+			(!key.IsActive || old.Permissions != key.Permissions || old.IPWhitelist != key.IPWhitelist || !sameExpiry(old.ExpiresAt, key.ExpiresAt) || old.Priority != key.Priority) {
+			revoked = append(revoked, key.ID)
+		}
+		delete(previous, rk.AccessKey)
+		s.keys[rk.AccessKey] = key
+	}
+	for _, old := range previous {
+		revoked = append(revoked, old.ID)
+	}
+
+	variables := make(map[string]map[string]string, len(snapshot.Environments))
+	for _, env := range snapshot.Environments {
+		vars := map[string]string{}
+		if env.Variables != "" {
+			json.Unmarshal([]byte(env.Variables), &vars)
+		}
+		variables[env.Name] = vars
+	}
+
+	seen := make(map[string]bool, len(snapshot.Configs))
+	for i := range snapshot.Configs {
+		rc := &snapshot.Configs[i]
+		cacheKey := followerConfigKey(projectID, rc.Namespace, rc.Name, rc.Environment)
+		seen[cacheKey] = true
+
+		config := &FollowerConfig{
+			ID:          s.id("config:" + cacheKey),
+			ProjectID:   projectID,
+			Name:        rc.Name,
+			Namespace:   rc.Namespace,
+			Environment: rc.Environment,
+			FileType:    rc.FileType,
+		}
+		// 与主实例一致, 下发最新版本
+		if n := len(rc.Versions); n > 0 {
+			latest := rc.Versions[n-1]
+			config.Version = latest.Version
+			config.CommitHash = latest.CommitHash
+			config.Content = InterpolateVariables(latest.Content, rc.FileType, variables[rc.Environment])
+		}
+		// 发布记录按时间升序, 仅当最新正式发布与下发版本一致时附加发布信息
+		var latestRelease *model.ReplicatedRelease
+		for j := range rc.Releases {
+			release := &rc.Releases[j]
+			if release.ReleaseType == "full" && release.Status == "released" && release.Environment == rc.Environment {
+				latestRelease = release
+			}
+		}
+		if latestRelease != nil && latestRelease.Version == config.Version {
+			config.Release = latestRelease
+			// 与主实例一致, 下发发布流水线生成的产物
+			if latestRelease.ArtifactHash != "" {
+				config.CommitHash = latestRelease.ArtifactHash
+				config.Content = InterpolateVariables(latestRelease.Artifact, rc.FileType, variables[rc.Environment])
+			}
+		}
+
+		if old, ok := s.configs[cacheKey]; !ok || old.Version != config.Version || old.Content != config.Content {
+			changed = append(changed, config.ID)
+		}
+		s.configs[cacheKey] = config
+	}
+
+	for cacheKey, config := range s.configs {
+		if config.ProjectID == projectID && !seen[cacheKey] {
+			delete(s.configs, cacheKey)
+		}
+	}
+
+	status := s.status(snapshot.Project.Name)
+	status.LastSyncAt = time.Now()
+	status.LastError = ""
+	return changed, revoked
+}
+
+// status 获取项目同步状态, 调用方需持有写锁
+func (s *FollowerService) status(name string) *ProjectReplicationStatus {
+	status, ok := s.synced[name]
+	if !ok {
+		status = &ProjectReplicationStatus{}
+		s.synced[name] = status
+	}
+	return status
+}
+
+// id 返回名称对应的本地 ID, 首次出现时分配, 调用方需持有写锁
+func (s *FollowerService) id(name string) int64 {
+	if id, ok := s.ids[name]; ok {
+		return id
+	}
+	s.nextID++
+	s.ids[name] = s.nextID
+	return s.nextID
+}
+
+// sameExpiry 两个有效期是否相同
+func sameExpiry(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+// followerConfigKey 配置缓存键
+func followerConfigKey(projectID int64, namespace, name, env string) string {
+	return strconv.FormatInt(projectID, 10) + "/" + namespace + "/" + name + "@" + env
+}
@@ -0,0 +1,659 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrGitNotLinked      = errors.New("项目未关联 Git 仓库")
+	ErrInvalidGitResolve = errors.New("无效的冲突处理方式, 可选 confighub 或 git")
+	ErrInvalidGitRepoURL = errors.New("无效的 Git 仓库地址, 仅支持 https:// 和 ssh://")
+	ErrInvalidGitBranch  = errors.New("无效的 Git 分支名称")
+)
+
+// GitError Git 命令执行失败, 输出中的仓库凭据已隐藏
+type GitError struct {
+	Op     string
+	Output string
+}
+
+func (e *GitError) Error() string {
+	return fmt.Sprintf("git %s 失败: %s", e.Op, e.Output)
+}
+
+// gitStateFile 仓库中记录每个文件最近一次导出版本号的文件
+const gitStateFile = ".confighub/state.json"
+
+// gitCredentialPattern 匹配 URL 中的用户名和密码
+var gitCredentialPattern = regexp.MustCompile(`://[^/@\s]+@`)
+
+// gitFileTypes 配置文件类型与仓库中文件扩展名的对应关系
+var gitFileTypes = map[string]string{
+	"json":     ".json",
+	"yaml":     ".yaml",
+	"protobuf": ".proto",
+}
+
+// 冲突处理方式
+const (
+	GitResolveConfigHub = "confighub" // 以 ConfigHub 当前版本覆盖仓库文件
+	GitResolveGit       = "git"       // 以仓库文件生成新版本
+)
+
+// GitSyncOptions Git 同步配置
+type GitSyncOptions struct {
+	WorkDir     string // 本地工作副本目录, 每个项目一个子目录
+	AuthorName  string
+	AuthorEmail string
+}
+
+// GitConflict 无法自动同步的文件
+type GitConflict struct {
+	Path          string `json:"path"`
+	ConfigID      int64  `json:"config_id,omitempty"`
+	LocalVersion  int    `json:"local_version,omitempty"`  // ConfigHub 当前版本
+	SyncedVersion int    `json:"synced_version,omitempty"` // 最近一次导出到仓库的版本
+	Reason        string `json:"reason"`
+}
+
+// GitSyncResult 同步结果
+type GitSyncResult struct {
+	Commit    string         `json:"commit,omitempty"` // 同步后仓库的最新提交
+	Imported  []string       `json:"imported"`         // 从仓库导入的文件
+	Exported  int            `json:"exported"`         // 提交到仓库的版本数
+	Conflicts []*GitConflict `json:"conflicts"`
+}
+
+// gitState 仓库中记录的同步状态
+type gitState struct {
+	Configs map[string]int `json:"configs"` // 文件路径 -> 最近一次导出的版本号
+}
+
+// GitSyncService 项目与 Git 仓库的双向同步
+// 每个配置版本都作为一次提交写入关联仓库的 <命名空间>/<环境>/<名称>.<扩展名>;
+// 仓库中的修改通过 Sync 按需导入, 双方都修改过的文件作为冲突报告, 不会被自动覆盖
+type GitSyncService struct {
+	projectRepo *repository.ProjectRepository
+	configRepo  *repository.ConfigRepository
+	versionRepo repository.VersionStore
+	configSvc   *ConfigService
+	opts        GitSyncOptions
+
+	trigger chan int64 // 有变更的配置 ID
+	mu      sync.Mutex
+	locks   map[int64]*sync.Mutex // 每个项目的工作副本同一时间只允许一个同步
+}
+
+// NewGitSyncService 创建 Git 同步服务, 并注册为通知服务的变更监听器
+func NewGitSyncService(projectRepo *repository.ProjectRepository, configRepo *repository.ConfigRepository, versionRepo repository.VersionStore, configSvc *ConfigService, notifySvc *NotificationService, opts GitSyncOptions) *GitSyncService {
+	s := &GitSyncService{
+		projectRepo: projectRepo,
+		configRepo:  configRepo,
+		versionRepo: versionRepo,
+		configSvc:   configSvc,
+		opts:        opts,
+		trigger:     make(chan int64, 100),
+		locks:       make(map[int64]*sync.Mutex),
+	}
+	notifySvc.OnChange(func(change *ConfigChange) {
+		select {
+		case s.trigger <- change.ConfigID:
+		default:
+			// 队列已满时由定时导出补上
+		}
+	})
+	return s
+}
+
+// Run 配置变更时立即导出所属项目, 并定期导出所有关联仓库的项目 (补上队列已满或推送失败时遗漏的版本)
+func (s *GitSyncService) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	report := func(err error) {
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case configID := <-s.trigger:
+			config, err := s.configRepo.GetByID(ctx, configID)
+			if err != nil {
+				continue
+			}
+			project, err := s.projectRepo.GetByID(ctx, config.ProjectID)
+			if err != nil || project.GitRepoURL == "" || project.IsArchived() {
+				continue
+			}
+			_, err = s.export(ctx, project)
+			report(err)
+		case <-ticker.C:
+			projects, err := s.projectRepo.ListGitLinked(ctx)
+			if err != nil {
+				report(err)
+				continue
+			}
+			for _, project := range projects {
+				_, err := s.export(ctx, project)
+				report(err)
+			}
+		}
+	}
+}
+
+// Sync 从仓库导入修改并导出尚未提交的版本
+// resolve 为空时冲突文件保持不变; 为 confighub 或 git 时按指定一方处理冲突
+func (s *GitSyncService) Sync(ctx context.Context, projectID int64, resolve string) (*GitSyncResult, error) {
+	if resolve != "" && resolve != GitResolveConfigHub && resolve != GitResolveGit {
+		return nil, ErrInvalidGitResolve
+	}
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	if project.GitRepoURL == "" {
+		return nil, ErrGitNotLinked
+	}
+
+	lock := s.lock(project.ID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	dir, err := s.checkout(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	state, err := readGitState(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GitSyncResult{Imported: []string{}, Conflicts: []*GitConflict{}}
+	head, _ := s.git(ctx, dir, "rev-parse", "--short", "HEAD")
+
+	configs, err := s.configRepo.List(ctx, project.ID)
+	if err != nil {
+		return nil, err
+	}
+	byPath := make(map[string]*model.Config, len(configs))
+	for _, config := range configs {
+		if path, ok := gitConfigPath(config); ok {
+			byPath[path] = config
+		}
+	}
+
+	files, err := listGitFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	overwrite := make(map[string]bool) // 冲突时以 ConfigHub 为准的文件
+	for _, path := range files {
+		data, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			return nil, err
+		}
+		content := string(data)
+		config, exists := byPath[path]
+		synced, tracked := state.Configs[path]
+
+		if !exists {
+			if tracked {
+				result.Conflicts = append(result.Conflicts, &GitConflict{Path: path, SyncedVersion: synced, Reason: "配置已在 ConfigHub 中删除"})
+				continue
+			}
+			namespace, env, name, fileType := parseGitPath(path)
+			config, err := s.configSvc.Upload(ctx, project.ID, &UploadRequest{
+				Name:        name,
+				Namespace:   namespace,
+				Environment: env,
+				FileType:    fileType,
+				Content:     content,
+				Message:     "从 Git 导入 " + head,
+				Origin:      model.OriginSync,
+			}, "git")
+			if err != nil {
+				result.Conflicts = append(result.Conflicts, &GitConflict{Path: path, Reason: err.Error()})
+				continue
+			}
+			// 导入的内容即仓库文件, 无需再导出
+			state.Configs[path] = config.CurrentVersion
+			result.Imported = append(result.Imported, path)
+			continue
+		}
+
+		current, err := s.versionRepo.GetByConfigAndVersion(ctx, config.ID, config.CurrentVersion)
+		if err != nil {
+			return nil, err
+		}
+		if sameGitContent(config.FileType, content, current.Content) {
+			state.Configs[path] = config.CurrentVersion
+			continue
+		}
+		if tracked && !s.modifiedInGit(ctx, config, synced, content) {
+			// 仓库未修改, ConfigHub 中的新版本稍后导出
+			continue
+		}
+
+		conflict := &GitConflict{Path: path, ConfigID: config.ID, LocalVersion: config.CurrentVersion, SyncedVersion: synced}
+		switch {
+		case tracked && synced == config.CurrentVersion, resolve == GitResolveGit:
+			// 上次导出后 ConfigHub 没有新版本, 或指定以仓库为准: 导入为新版本
+		case resolve == GitResolveConfigHub:
+			overwrite[path] = true
+			continue
+		case tracked:
+			conflict.Reason = "仓库和 ConfigHub 在上次同步后均有修改"
+			result.Conflicts = append(result.Conflicts, conflict)
+			continue
+		default:
+			conflict.Reason = "ConfigHub 中已存在内容不同的同名配置"
+			result.Conflicts = append(result.Conflicts, conflict)
+			continue
+		}
+
+		version, err := s.configSvc.Update(ctx, config.ID, content, "从 Git 导入 "+head, "git", model.OriginSync)
+		if err != nil {
+			conflict.Reason = err.Error()
+			result.Conflicts = append(result.Conflicts, conflict)
+			continue
+		}
+		state.Configs[path] = version.Version
+		result.Imported = append(result.Imported, path)
+	}
+
+	// 仓库中删除的文件不会删除配置, 需人工处理
+	inRepo := make(map[string]bool, len(files))
+	for _, path := range files {
+		inRepo[path] = true
+	}
+	for path, synced := range state.Configs {
+		config, ok := byPath[path]
+		if !ok || inRepo[path] {
+			continue
+		}
+		if resolve == GitResolveConfigHub {
+			overwrite[path] = true
+			continue
+		}
+		result.Conflicts = append(result.Conflicts, &GitConflict{Path: path, ConfigID: config.ID, LocalVersion: config.CurrentVersion, SyncedVersion: synced, Reason: "文件已在仓库中删除"})
+	}
+	sort.Slice(result.Conflicts, func(i, j int) bool { return result.Conflicts[i].Path < result.Conflicts[j].Path })
+
+	if len(result.Imported) > 0 {
+		if err := writeGitState(dir, state); err != nil {
+			return nil, err
+		}
+		if _, err := s.commit(ctx, dir, fmt.Sprintf("从 Git 导入 %d 个文件", len(result.Imported)), gitStateFile); err != nil {
+			return nil, err
+		}
+	}
+
+	exported, err := s.exportLocked(ctx, project, dir, overwrite)
+	if err != nil {
+		return nil, err
+	}
+	result.Exported = exported
+
+	if len(result.Imported) > 0 || exported > 0 {
+		if _, err := s.git(ctx, dir, "push", "--end-of-options", "origin", "HEAD:refs/heads/"+gitBranch(project)); err != nil {
+			return nil, err
+		}
+	}
+	result.Commit, _ = s.git(ctx, dir, "rev-parse", "--short", "HEAD")
+	return result, nil
+}
+
+// export 将项目中尚未提交的版本逐个提交并推送到仓库
+func (s *GitSyncService) export(ctx context.Context, project *model.Project) (int, error) {
+	lock := s.lock(project.ID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	dir, err := s.checkout(ctx, project)
+	if err != nil {
+		return 0, err
+	}
+	exported, err := s.exportLocked(ctx, project, dir, nil)
+	if err != nil || exported == 0 {
+		return exported, err
+	}
+	if _, err := s.git(ctx, dir, "push", "--end-of-options", "origin", "HEAD:refs/heads/"+gitBranch(project)); err != nil {
+		return 0, err
+	}
+	return exported, nil
+}
+
+// exportLocked 为每个新版本生成一次提交, 返回提交数
+// 仓库中被修改过的文件不会被覆盖, 留待 Sync 导入或报告冲突; overwrite 中的文件除外
+func (s *GitSyncService) exportLocked(ctx context.Context, project *model.Project, dir string, overwrite map[string]bool) (int, error) {
+	state, err := readGitState(dir)
+	if err != nil {
+		return 0, err
+	}
+	configs, err := s.configRepo.List(ctx, project.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	exported := 0
+	for _, config := range configs {
+		path, ok := gitConfigPath(config)
+		if !ok {
+			continue
+		}
+		synced := state.Configs[path]
+		if synced >= config.CurrentVersion && !overwrite[path] {
+			continue
+		}
+
+		file := filepath.Join(dir, path)
+		if data, err := os.ReadFile(file); err == nil && !overwrite[path] {
+			if synced == 0 || s.modifiedInGit(ctx, config, synced, string(data)) {
+				continue
+			}
+		}
+
+		versions, err := s.versionRepo.ListRange(ctx, config.ID, synced, config.CurrentVersion)
+		if err != nil {
+			return exported, err
+		}
+		if len(versions) == 0 && overwrite[path] {
+			// 冲突以 ConfigHub 为准时重新写入当前版本
+			current, err := s.versionRepo.GetByConfigAndVersion(ctx, config.ID, config.CurrentVersion)
+			if err != nil {
+				return exported, err
+			}
+			versions = []*model.ConfigVersion{current}
+		}
+
+		for _, version := range versions {
+			if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+				return exported, err
+			}
+			if err := os.WriteFile(file, []byte(version.Content), 0o644); err != nil {
+				return exported, err
+			}
+			state.Configs[path] = version.Version
+			if err := writeGitState(dir, state); err != nil {
+				return exported, err
+			}
+
+			message := fmt.Sprintf("%s v%d: %s\n\nConfig-Version: %d\nAuthor: %s\nOrigin: %s",
+				path, version.Version, version.CommitMessage, version.Version, version.Author, version.Origin)
+			committed, err := s.commit(ctx, dir, message, path, gitStateFile)
+			if err != nil {
+				return exported, err
+			}
+			if committed {
+				exported++
+			}
+		}
+	}
+	return exported, nil
+}
+
+// modifiedInGit 仓库文件是否与上次导出的版本不同
+func (s *GitSyncService) modifiedInGit(ctx context.Context, config *model.Config, synced int, content string) bool {
+	version, err := s.versionRepo.GetByConfigAndVersion(ctx, config.ID, synced)
+	if err != nil {
+		return true
+	}
+	return !sameGitContent(config.FileType, content, version.Content)
+}
+
+// checkout 准备项目的工作副本并与远端分支对齐, 本地未推送的提交会被丢弃并按同步状态重新生成
+func (s *GitSyncService) checkout(ctx context.Context, project *model.Project) (string, error) {
+	dir := filepath.Join(s.opts.WorkDir, strconv.FormatInt(project.ID, 10))
+	branch := gitBranch(project)
+	// 校验在保存项目时已做过, 这里再次检查以防历史数据中的值被 git 当作选项解析
+	if err := ValidateGitRepoURL(project.GitRepoURL); err != nil {
+		return "", err
+	}
+	if err := ValidateGitBranch(ctx, branch); err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return "", err
+		}
+		if _, err := s.git(ctx, dir, "init", "-q"); err != nil {
+			return "", err
+		}
+		if _, err := s.git(ctx, dir, "remote", "add", "--end-of-options", "origin", project.GitRepoURL); err != nil {
+			return "", err
+		}
+	} else if _, err := s.git(ctx, dir, "remote", "set-url", "--end-of-options", "origin", project.GitRepoURL); err != nil {
+		return "", err
+	}
+
+	heads, err := s.git(ctx, dir, "ls-remote", "--heads", "--end-of-options", "origin", branch)
+	if err != nil {
+		return "", err
+	}
+	if heads == "" {
+		// 远端分支不存在 (如空仓库), 首次推送时创建
+		_, err = s.git(ctx, dir, "checkout", "-q", "-B", branch)
+		return dir, err
+	}
+	if _, err := s.git(ctx, dir, "fetch", "-q", "--end-of-options", "origin", branch); err != nil {
+		return "", err
+	}
+	if _, err := s.git(ctx, dir, "checkout", "-q", "-f", "-B", branch, "FETCH_HEAD"); err != nil {
+		return "", err
+	}
+	if _, err := s.git(ctx, dir, "clean", "-q", "-fd"); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// commit 暂存指定文件并提交, 没有变化时返回 false
+func (s *GitSyncService) commit(ctx context.Context, dir, message string, paths ...string) (bool, error) {
+	if _, err := s.git(ctx, dir, append([]string{"add", "--"}, paths...)...); err != nil {
+		return false, err
+	}
+	if _, err := s.git(ctx, dir, "diff", "--cached", "--quiet"); err == nil {
+		return false, nil
+	}
+	_, err := s.git(ctx, dir,
+		"-c", "user.name="+s.opts.AuthorName,
+		"-c", "user.email="+s.opts.AuthorEmail,
+		"commit", "-q", "-m", message)
+	return err == nil, err
+}
+
+// git 在工作副本中执行 git 命令, 返回去除首尾空白的输出
+func (s *GitSyncService) git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ALLOW_PROTOCOL=https:ssh")
+	out, err := cmd.CombinedOutput()
+	output := strings.TrimSpace(string(out))
+	if err != nil {
+		op := args[0]
+		if op == "-c" {
+			op = "commit"
+		}
+		if output == "" {
+			output = err.Error()
+		}
+		return "", &GitError{Op: op, Output: gitCredentialPattern.ReplaceAllString(output, "://***@")}
+	}
+	return output, nil
+}
+
+// lock 获取项目工作副本的锁
+func (s *GitSyncService) lock(projectID int64) *sync.Mutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lock, ok := s.locks[projectID]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.locks[projectID] = lock
+	}
+	return lock
+}
+
+// gitBranch 项目关联的分支, 默认 main
+func gitBranch(project *model.Project) string {
+	if project.GitBranch == "" {
+		return "main"
+	}
+	return project.GitBranch
+}
+
+// ValidateGitRepoURL 校验项目关联的仓库地址, 只允许 https:// 和 ssh://
+// file://、http:// 等地址会让项目所有者读取服务器本地路径或内网服务, 一律拒绝
+func ValidateGitRepoURL(repoURL string) error {
+	u, err := url.Parse(repoURL)
+	if err != nil || u.Host == "" || strings.HasPrefix(u.Host, "-") {
+		return ErrInvalidGitRepoURL
+	}
+	if u.Scheme != "https" && u.Scheme != "ssh" {
+		return ErrInvalidGitRepoURL
+	}
+	return nil
+}
+
+// ValidateGitBranch 校验分支名称, 以 - 开头的名称会被 git 当作选项, 其余规则交给 git check-ref-format
+func ValidateGitBranch(ctx context.Context, branch string) error {
+	if branch == "" || strings.HasPrefix(branch, "-") {
+		return ErrInvalidGitBranch
+	}
+	if err := exec.CommandContext(ctx, "git", "check-ref-format", "--branch", branch).Run(); err != nil {
+		return ErrInvalidGitBranch
+	}
+	return nil
+}
+
+// gitConfigPath 配置在仓库中的路径, 名称中含有路径分隔符等无法映射为文件的配置不参与同步
+func gitConfigPath(config *model.Config) (string, bool) {
+	ext, ok := gitFileTypes[config.FileType]
+	if !ok {
+		return "", false
+	}
+	for _, part := range []string{config.Namespace, config.Environment, config.Name} {
+		if part == "" || strings.ContainsAny(part, `/\`) || strings.HasPrefix(part, ".") {
+			return "", false
+		}
+	}
+	return config.Namespace + "/" + config.Environment + "/" + config.Name + ext, true
+}
+
+// parseGitPath 解析仓库文件路径, 调用方需保证路径来自 listGitFiles
+func parseGitPath(path string) (namespace, env, name, fileType string) {
+	parts := strings.Split(path, "/")
+	ext := filepath.Ext(parts[2])
+	for t, e := range gitFileTypes {
+		if e == ext {
+			fileType = t
+		}
+	}
+	return parts[0], parts[1], strings.TrimSuffix(parts[2], ext), fileType
+}
+
+// listGitFiles 列出仓库中符合 <命名空间>/<环境>/<名称>.<扩展名> 的文件, 其他文件忽略
+func listGitFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && strings.HasPrefix(d.Name(), ".") && path != dir {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if strings.Count(rel, "/") != 2 {
+			return nil
+		}
+		ext := filepath.Ext(rel)
+		for _, e := range gitFileTypes {
+			if e == ext && strings.TrimSuffix(filepath.Base(rel), ext) != "" {
+				files = append(files, rel)
+				break
+			}
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// sameGitContent 比较仓库文件与版本内容, JSON/YAML 按解析后的结构比较
+// YAML 配置上传时会转换为 JSON 保存, 逐字比较会误报修改
+func sameGitContent(fileType, a, b string) bool {
+	if strings.TrimSpace(a) == strings.TrimSpace(b) {
+		return true
+	}
+	if fileType != "json" && fileType != "yaml" {
+		return false
+	}
+	var va, vb interface{}
+	if yaml.Unmarshal([]byte(a), &va) != nil || yaml.Unmarshal([]byte(b), &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// readGitState 读取仓库中的同步状态, 不存在时返回空状态
+func readGitState(dir string) (*gitState, error) {
+	state := &gitState{Configs: map[string]int{}}
+	data, err := os.ReadFile(filepath.Join(dir, gitStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", gitStateFile, err)
+	}
+	if state.Configs == nil {
+		state.Configs = map[string]int{}
+	}
+	return state, nil
+}
+
+// writeGitState 写入同步状态
+func writeGitState(dir string, state *gitState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(dir, gitStateFile)
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0o644)
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"hash/fnv"
 	"net"
+	"sort"
 	"strings"
 
 	"confighub/internal/model"
@@ -13,23 +15,35 @@ import (
 )
 
 var (
-	ErrGrayReleaseNotFound = errors.New("灰度发布不存在")
-	ErrGrayReleaseActive   = errors.New("已有活跃的灰度发布")
+	ErrGrayReleaseNotFound    = errors.New("灰度发布不存在")
+	ErrGrayReleaseActive      = errors.New("已有活跃的灰度发布")
+	ErrInvalidGrayPercentage  = errors.New("灰度百分比必须在 0-100 之间")
+	ErrGrayEnvironmentMissing = errors.New("灰度发布缺少环境")
+	ErrInvalidGrayRule        = errors.New("无效的灰度规则")
 )
 
+// grayRuleTypes 支持的灰度规则类型
+var grayRuleTypes = map[string]bool{"percentage": true, "client_id": true, "ip_range": true}
+
 // GrayReleaseService 灰度发布服务
 type GrayReleaseService struct {
 	releaseRepo *repository.ReleaseRepository
 	configRepo  *repository.ConfigRepository
-	versionRepo *repository.VersionRepository
+	versionRepo repository.VersionStore
+	notifySvc   *NotificationService
+	pipelineSvc *ReleasePipelineService
+	rules       *contentLRU // 灰度规则原文 -> *model.GrayRules
 }
 
 // NewGrayReleaseService 创建灰度发布服务
-func NewGrayReleaseService(releaseRepo *repository.ReleaseRepository, configRepo *repository.ConfigRepository, versionRepo *repository.VersionRepository) *GrayReleaseService {
+func NewGrayReleaseService(releaseRepo *repository.ReleaseRepository, configRepo *repository.ConfigRepository, versionRepo repository.VersionStore, notifySvc *NotificationService, pipelineSvc *ReleasePipelineService) *GrayReleaseService {
 	return &GrayReleaseService{
 		releaseRepo: releaseRepo,
 		configRepo:  configRepo,
 		versionRepo: versionRepo,
+		notifySvc:   notifySvc,
+		pipelineSvc: pipelineSvc,
+		rules:       newContentLRU(grayRulesCacheBytes),
 	}
 }
 
@@ -42,6 +56,10 @@ type GrayReleaseRequest struct {
 	Percentage  int      `json:"percentage,omitempty"`
 	ClientIDs   []string `json:"client_ids,omitempty"`
 	IPRanges    []string `json:"ip_ranges,omitempty"`
+
+	// EnvPercentages 按环境阶段设置百分比 (如 staging 50%, prod 5%), 只支持 percentage 规则, 不能同时设置 Environment 和 Percentage
+	// 每个环境灰度同名配置在该环境中的行: 请求的配置使用 Version, 其他环境使用其当前版本
+	EnvPercentages map[string]int `json:"env_percentages,omitempty"`
 }
 
 // Create 创建灰度发布
@@ -51,27 +69,64 @@ func (s *GrayReleaseService) Create(ctx context.Context, req *GrayReleaseRequest
 		return nil, ErrConfigNotFound
 	}
 
-	// 检查是否已有活跃的灰度发布
-	existing, _ := s.releaseRepo.GetActiveGrayRelease(ctx, req.ConfigID, req.Environment)
-	if existing != nil {
-		return nil, ErrGrayReleaseActive
+	if !grayRuleTypes[req.RuleType] {
+		return nil, fmt.Errorf("%w: 不支持的规则类型 %q", ErrInvalidGrayRule, req.RuleType)
+	}
+	if req.Percentage < 0 || req.Percentage > 100 {
+		return nil, ErrInvalidGrayPercentage
 	}
-
-	// 验证版本存在
 	if req.Version == 0 {
 		req.Version = config.CurrentVersion
 	}
-	_, err = s.versionRepo.GetByConfigAndVersion(ctx, req.ConfigID, req.Version)
-	if err != nil {
+
+	// 分阶段灰度: 一个发布对象覆盖多个环境, 每个环境指向该环境中的同名配置
+	environment := req.Environment
+	var targets map[string]model.GrayTarget
+	if len(req.EnvPercentages) > 0 {
+		if req.RuleType != "percentage" {
+			return nil, fmt.Errorf("%w: 分阶段灰度只支持 percentage 规则", ErrInvalidGrayRule)
+		}
+		if req.Environment != "" || req.Percentage != 0 {
+			return nil, fmt.Errorf("%w: 分阶段灰度按 env_percentages 设置环境和百分比, 不能同时设置 environment 或 percentage", ErrInvalidGrayRule)
+		}
+		for _, p := range req.EnvPercentages {
+			if p < 0 || p > 100 {
+				return nil, ErrInvalidGrayPercentage
+			}
+		}
+		targets, err = s.resolveTargets(ctx, config, req.Version, req.EnvPercentages)
+		if err != nil {
+			return nil, err
+		}
+		environment = model.ReleaseEnvAll
+	} else if environment == "" {
+		return nil, ErrGrayEnvironmentMissing
+	} else if environment != config.Environment {
+		// 配置按环境分行保存, 灰度只对配置所在的环境生效
+		return nil, fmt.Errorf("%w: 配置属于环境 %s, 不能在环境 %s 灰度", ErrInvalidGrayRule, config.Environment, environment)
+	} else if _, err := s.versionRepo.GetByConfigAndVersion(ctx, req.ConfigID, req.Version); err != nil {
 		return nil, ErrVersionNotFound
 	}
 
+	// 检查是否已有活跃的灰度发布
+	if len(targets) > 0 {
+		for env, target := range targets {
+			if err := s.checkNoActiveGray(ctx, config.ProjectID, target.ConfigID, env); err != nil {
+				return nil, err
+			}
+		}
+	} else if err := s.checkNoActiveGray(ctx, config.ProjectID, config.ID, environment); err != nil {
+		return nil, err
+	}
+
 	// 构建灰度规则
 	rules := model.GrayRules{
-		Type:       req.RuleType,
-		Percentage: req.Percentage,
-		ClientIDs:  req.ClientIDs,
-		IPRanges:   req.IPRanges,
+		Type:           req.RuleType,
+		Percentage:     req.Percentage,
+		EnvPercentages: req.EnvPercentages,
+		EnvTargets:     targets,
+		ClientIDs:      req.ClientIDs,
+		IPRanges:       req.IPRanges,
 	}
 	rulesJSON, _ := json.Marshal(rules)
 
@@ -79,7 +134,7 @@ func (s *GrayReleaseService) Create(ctx context.Context, req *GrayReleaseRequest
 		ProjectID:      config.ProjectID,
 		ConfigID:       req.ConfigID,
 		Version:        req.Version,
-		Environment:    req.Environment,
+		Environment:    environment,
 		Status:         "gray",
 		ReleaseType:    "gray",
 		GrayRules:      string(rulesJSON),
@@ -91,35 +146,179 @@ func (s *GrayReleaseService) Create(ctx context.Context, req *GrayReleaseRequest
 		return nil, err
 	}
 
+	s.notifyTargets(ctx, release, "gray_release")
 	return release, nil
 }
 
-// ShouldUseGrayRelease 判断客户端是否应该使用灰度版本
-func (s *GrayReleaseService) ShouldUseGrayRelease(ctx context.Context, configID int64, env, clientID, clientIP string) (bool, *model.Release, error) {
-	grayRelease, err := s.releaseRepo.GetActiveGrayRelease(ctx, configID, env)
+// resolveTargets 查找分阶段灰度各环境中的同名配置及其灰度版本
+// 请求的配置使用 version, 其他环境的配置使用其当前版本
+func (s *GrayReleaseService) resolveTargets(ctx context.Context, config *model.Config, version int, envPercentages map[string]int) (map[string]model.GrayTarget, error) {
+	targets := make(map[string]model.GrayTarget, len(envPercentages))
+	for env := range envPercentages {
+		target := config
+		if env != config.Environment {
+			var err error
+			target, err = s.configRepo.GetByNameAndEnv(ctx, config.ProjectID, config.Name, config.Namespace, env)
+			if err != nil {
+				return nil, fmt.Errorf("%w: 环境 %s 中没有配置 %s", ErrInvalidGrayRule, env, config.Name)
+			}
+		}
+
+		targetVersion := target.CurrentVersion
+		if target.ID == config.ID {
+			targetVersion = version
+		}
+		if _, err := s.versionRepo.GetByConfigAndVersion(ctx, target.ID, targetVersion); err != nil {
+			return nil, ErrVersionNotFound
+		}
+		targets[env] = model.GrayTarget{ConfigID: target.ID, Version: targetVersion}
+	}
+	return targets, nil
+}
+
+// stagedTargets 分阶段灰度覆盖的环境及各环境灰度的配置和版本
+// 早于按环境指向配置的分阶段灰度只对发布所属配置的环境生效
+func (s *GrayReleaseService) stagedTargets(ctx context.Context, release *model.Release, rules *model.GrayRules) map[string]model.GrayTarget {
+	if len(rules.EnvTargets) > 0 {
+		return rules.EnvTargets
+	}
+	config, err := s.configRepo.GetByID(ctx, release.ConfigID)
 	if err != nil {
-		return false, nil, nil // 没有灰度发布
+		return nil
+	}
+	if _, ok := rules.EnvPercentages[config.Environment]; !ok {
+		return nil
+	}
+	return map[string]model.GrayTarget{config.Environment: {ConfigID: release.ConfigID, Version: release.Version}}
+}
+
+// notifyTargets 通知灰度发布涉及的每个配置, 分阶段灰度逐个通知各环境的配置
+func (s *GrayReleaseService) notifyTargets(ctx context.Context, release *model.Release, changeType string) {
+	if release.Environment != model.ReleaseEnvAll {
+		notifyRelease(ctx, s.notifySvc, release, changeType)
+		return
+	}
+	rules, err := s.parseRules(release.GrayRules)
+	if err != nil {
+		return
+	}
+	for env, target := range s.stagedTargets(ctx, release, rules) {
+		change := &ConfigChange{
+			ConfigID:   target.ConfigID,
+			Env:        env,
+			Version:    target.Version,
+			ChangeType: changeType,
+		}
+		// 发布记录的版本号属于发布所属的配置, 其他配置不关联发布以免按错误的版本生成变更摘要
+		if target.ConfigID == release.ConfigID {
+			change.ReleaseID = release.ID
+		}
+		s.notifySvc.NotifyChange(ctx, change)
+	}
+}
+
+// checkNoActiveGray 检查配置在目标环境是否已有活跃的灰度发布 (包括覆盖该配置的分阶段灰度)
+func (s *GrayReleaseService) checkNoActiveGray(ctx context.Context, projectID, configID int64, env string) error {
+	if existing, _ := s.releaseRepo.GetActiveGrayRelease(ctx, configID, env); existing != nil {
+		return ErrGrayReleaseActive
+	}
+	if release, _ := s.activeStagedGray(ctx, projectID, configID, env); release != nil {
+		return ErrGrayReleaseActive
+	}
+	return nil
+}
+
+// activeStagedGray 获取覆盖指定配置和环境的活跃分阶段灰度
+func (s *GrayReleaseService) activeStagedGray(ctx context.Context, projectID, configID int64, env string) (*model.Release, *model.GrayRules) {
+	releases, err := s.releaseRepo.ListActiveStagedGray(ctx, projectID)
+	if err != nil {
+		return nil, nil
+	}
+	for _, release := range releases {
+		rules, err := s.parseRules(release.GrayRules)
+		if err != nil {
+			continue
+		}
+		if len(rules.EnvTargets) == 0 {
+			// 早于按环境指向配置的分阶段灰度只对发布所属的配置生效
+			if _, ok := rules.EnvPercentages[env]; ok && release.ConfigID == configID {
+				return release, rules
+			}
+			continue
+		}
+		if target, ok := rules.EnvTargets[env]; ok && target.ConfigID == configID {
+			return release, rules
+		}
+	}
+	return nil, nil
+}
+
+// getActiveGrayRelease 获取对配置生效的灰度发布 (环境专属发布优先于分阶段发布)
+func (s *GrayReleaseService) getActiveGrayRelease(ctx context.Context, config *model.Config) (*model.Release, *model.GrayRules) {
+	if release, err := s.releaseRepo.GetActiveGrayRelease(ctx, config.ID, config.Environment); err == nil {
+		if rules, err := s.parseRules(release.GrayRules); err == nil {
+			return release, rules
+		}
+	}
+	return s.activeStagedGray(ctx, config.ProjectID, config.ID, config.Environment)
+}
+
+// parseRules 解析灰度规则, 结果按规则原文缓存, 调用方不得修改返回值
+func (s *GrayReleaseService) parseRules(raw string) (*model.GrayRules, error) {
+	if cached, ok := s.rules.Get(raw); ok {
+		return cached.(*model.GrayRules), nil
 	}
 
 	var rules model.GrayRules
-	if err := json.Unmarshal([]byte(grayRelease.GrayRules), &rules); err != nil {
-		return false, nil, nil
+	if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+		return nil, err
+	}
+	s.rules.Add(raw, &rules, len(raw))
+	return &rules, nil
+}
+
+// ActiveGrayRelease 获取对配置所在环境生效的灰度发布, 没有时返回 nil
+func (s *GrayReleaseService) ActiveGrayRelease(ctx context.Context, config *model.Config) *model.Release {
+	release, _ := s.getActiveGrayRelease(ctx, config)
+	return release
+}
+
+// percentageFor 获取灰度规则在指定环境下的百分比
+func (s *GrayReleaseService) percentageFor(rules *model.GrayRules, env string) int {
+	if len(rules.EnvPercentages) > 0 {
+		return rules.EnvPercentages[env]
+	}
+	return rules.Percentage
+}
+
+// ShouldUseGrayRelease 判断客户端是否应该使用灰度版本
+func (s *GrayReleaseService) ShouldUseGrayRelease(ctx context.Context, config *model.Config, clientID, clientIP string) (bool, *model.Release, error) {
+	grayRelease, matched := s.Evaluate(ctx, config, clientID, clientIP)
+	if matched {
+		return true, grayRelease, nil
+	}
+	return false, nil, nil
+}
+
+// Evaluate 评估客户端在灰度发布中的分组
+// 返回对配置所在环境生效的灰度发布 (没有时为 nil) 以及客户端是否命中灰度
+func (s *GrayReleaseService) Evaluate(ctx context.Context, config *model.Config, clientID, clientIP string) (*model.Release, bool) {
+	grayRelease, rules := s.getActiveGrayRelease(ctx, config)
+	if grayRelease == nil {
+		return nil, false // 没有灰度发布
 	}
 
 	shouldUse := false
 	switch rules.Type {
 	case "percentage":
-		shouldUse = s.matchPercentage(clientID, rules.Percentage)
+		shouldUse = s.matchPercentage(clientID, s.percentageFor(rules, config.Environment))
 	case "client_id":
 		shouldUse = s.matchClientID(clientID, rules.ClientIDs)
 	case "ip_range":
 		shouldUse = s.matchIPRange(clientIP, rules.IPRanges)
 	}
 
-	if shouldUse {
-		return true, grayRelease, nil
-	}
-	return false, nil, nil
+	return grayRelease, shouldUse
 }
 
 // matchPercentage 百分比匹配
@@ -185,28 +384,55 @@ func (s *GrayReleaseService) Promote(ctx context.Context, releaseID int64, autho
 		return nil, errors.New("只能提升灰度发布")
 	}
 
+	// 分阶段灰度按各环境对应的配置分别创建正式发布
+	targets := map[string]model.GrayTarget{release.Environment: {ConfigID: release.ConfigID, Version: release.Version}}
+	if release.Environment == model.ReleaseEnvAll {
+		rules, err := s.parseRules(release.GrayRules)
+		if err != nil {
+			return nil, err
+		}
+		targets = s.stagedTargets(ctx, release, rules)
+	}
+	envs := make([]string, 0, len(targets))
+	for env := range targets {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+
+	// 先完成全部流水线, 任一环境失败时灰度保持不变
+	fullReleases := make([]*model.Release, 0, len(envs))
+	for _, env := range envs {
+		r := &model.Release{
+			ProjectID:   release.ProjectID,
+			ConfigID:    targets[env].ConfigID,
+			Version:     targets[env].Version,
+			Environment: env,
+			Status:      "released",
+			ReleaseType: "full",
+			ReleasedBy:  author,
+		}
+		if err := s.pipelineSvc.Apply(ctx, r); err != nil {
+			return nil, err
+		}
+		fullReleases = append(fullReleases, r)
+	}
+
 	// 更新灰度发布状态
 	release.Status = "promoted"
 	if err := s.releaseRepo.Update(ctx, release); err != nil {
 		return nil, err
 	}
-
-	// 创建正式发布
-	fullRelease := &model.Release{
-		ProjectID:   release.ProjectID,
-		ConfigID:    release.ConfigID,
-		Version:     release.Version,
-		Environment: release.Environment,
-		Status:      "released",
-		ReleaseType: "full",
-		ReleasedBy:  author,
+	for _, r := range fullReleases {
+		if err := s.releaseRepo.Create(ctx, r); err != nil {
+			return nil, err
+		}
+		notifyRelease(ctx, s.notifySvc, r, "promote")
 	}
 
-	if err := s.releaseRepo.Create(ctx, fullRelease); err != nil {
-		return nil, err
+	if len(fullReleases) == 0 {
+		return nil, nil
 	}
-
-	return fullRelease, nil
+	return fullReleases[0], nil
 }
 
 // Cancel 取消灰度发布
@@ -221,11 +447,16 @@ func (s *GrayReleaseService) Cancel(ctx context.Context, releaseID int64) error
 	}
 
 	release.Status = "cancelled"
-	return s.releaseRepo.Update(ctx, release)
+	if err := s.releaseRepo.Update(ctx, release); err != nil {
+		return err
+	}
+	s.notifyTargets(ctx, release, "gray_cancel")
+	return nil
 }
 
 // UpdatePercentage 更新灰度百分比
-func (s *GrayReleaseService) UpdatePercentage(ctx context.Context, releaseID int64, percentage int) error {
+// 分阶段灰度须指定 env, 且只能调整已覆盖的环境; 其他灰度只能是 percentage 规则, env 为空或与发布环境一致
+func (s *GrayReleaseService) UpdatePercentage(ctx context.Context, releaseID int64, env string, percentage int) error {
 	release, err := s.releaseRepo.GetByID(ctx, releaseID)
 	if err != nil {
 		return ErrGrayReleaseNotFound
@@ -235,19 +466,52 @@ func (s *GrayReleaseService) UpdatePercentage(ctx context.Context, releaseID int
 		return errors.New("只能更新灰度发布")
 	}
 
+	if percentage < 0 || percentage > 100 {
+		return ErrInvalidGrayPercentage
+	}
+
 	var rules model.GrayRules
-	json.Unmarshal([]byte(release.GrayRules), &rules)
-	rules.Percentage = percentage
+	if err := json.Unmarshal([]byte(release.GrayRules), &rules); err != nil {
+		return err
+	}
+	if rules.Type != "percentage" {
+		return fmt.Errorf("%w: %s 规则不按百分比灰度", ErrInvalidGrayRule, rules.Type)
+	}
+	if release.Environment == model.ReleaseEnvAll {
+		if env == "" {
+			return ErrGrayEnvironmentMissing
+		}
+		// 新增环境需经过冲突检查, 只能通过新建灰度发布
+		if _, ok := rules.EnvPercentages[env]; !ok {
+			return fmt.Errorf("%w: 分阶段灰度未覆盖环境 %s", ErrInvalidGrayRule, env)
+		}
+		rules.EnvPercentages[env] = percentage
+	} else {
+		if env != "" && env != release.Environment {
+			return fmt.Errorf("%w: 灰度发布属于环境 %s", ErrInvalidGrayRule, release.Environment)
+		}
+		rules.Percentage = percentage
+		release.GrayPercentage = percentage
+	}
 	rulesJSON, _ := json.Marshal(rules)
 
 	release.GrayRules = string(rulesJSON)
-	release.GrayPercentage = percentage
 
-	return s.releaseRepo.Update(ctx, release)
+	if err := s.releaseRepo.Update(ctx, release); err != nil {
+		return err
+	}
+	s.notifyTargets(ctx, release, "gray_release")
+	return nil
 }
 
-// GetGrayReleaseVersion 获取灰度发布版本内容
-func (s *GrayReleaseService) GetGrayReleaseVersion(ctx context.Context, release *model.Release) (*model.ConfigVersion, error) {
+// GetGrayReleaseVersion 获取灰度发布对指定配置下发的版本内容, 分阶段灰度按配置所在环境取对应的版本
+func (s *GrayReleaseService) GetGrayReleaseVersion(ctx context.Context, release *model.Release, config *model.Config) (*model.ConfigVersion, error) {
+	if release.Environment == model.ReleaseEnvAll {
+		if rules, err := s.parseRules(release.GrayRules); err == nil {
+			if target, ok := rules.EnvTargets[config.Environment]; ok && target.ConfigID == config.ID {
+				return s.versionRepo.GetByConfigAndVersion(ctx, target.ConfigID, target.Version)
+			}
+		}
+	}
 	return s.versionRepo.GetByConfigAndVersion(ctx, release.ConfigID, release.Version)
 }
-
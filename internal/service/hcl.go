@@ -0,0 +1,150 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/parser"
+)
+
+// HCLSyntaxError HCL 解析失败, 携带带位置的问题
+type HCLSyntaxError struct {
+	Issues []ParseIssue
+}
+
+func (e *HCLSyntaxError) Error() string {
+	if len(e.Issues) == 0 {
+		return ErrInvalidHCL.Error()
+	}
+	first := e.Issues[0]
+	if first.Line == 0 {
+		return fmt.Sprintf("%s: %s", ErrInvalidHCL.Error(), first.Message)
+	}
+	return fmt.Sprintf("%s: 第 %d 行第 %d 列: %s", ErrInvalidHCL.Error(), first.Line, first.Column, first.Message)
+}
+
+// Unwrap 使 errors.Is(err, ErrInvalidHCL) 成立
+func (e *HCLSyntaxError) Unwrap() error {
+	return ErrInvalidHCL
+}
+
+// hclRepeated 同名键或块重复出现时收集的值, 编码为 JSON 数组
+type hclRepeated []interface{}
+
+// jsonContent 配置内容是否以 JSON 保存: json 配置, 上传时转换为 JSON 的 hcl 配置, 以及 kv 配置
+func jsonContent(fileType string) bool {
+	return fileType == "json" || fileType == "hcl" || fileType == "kv"
+}
+
+// normalizeHCL 将 HCL 内容转换为 JSON 保存; 内容已是 JSON (如编辑器提交的内部表示) 时原样返回
+func normalizeHCL(content string) (string, error) {
+	if json.Valid([]byte(content)) {
+		return content, nil
+	}
+	data, err := hclToJSON(content)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// hclToJSON 解析 HCL (terraform.tfvars 风格) 文档并转换为 JSON
+// 带标签的块按标签嵌套为对象, 如 variable "image" { default = "nginx" } 转换为 {"variable": {"image": {"default": "nginx"}}};
+// 同名键或无标签的块重复出现时转换为数组
+func hclToJSON(content string) (data []byte, err error) {
+	file, err := hcl.ParseString(content)
+	if err != nil {
+		var posErr *parser.PosError
+		if errors.As(err, &posErr) {
+			return nil, &HCLSyntaxError{Issues: []ParseIssue{{
+				Line:     posErr.Pos.Line,
+				Column:   posErr.Pos.Column,
+				Severity: IssueSeverityError,
+				Message:  posErr.Err.Error(),
+			}}}
+		}
+		return nil, &HCLSyntaxError{Issues: []ParseIssue{{Severity: IssueSeverityError, Message: err.Error()}}}
+	}
+
+	// 超出范围的数字等字面量在取值时 panic, 按解析失败处理
+	defer func() {
+		if r := recover(); r != nil {
+			data, err = nil, &HCLSyntaxError{Issues: []ParseIssue{{Severity: IssueSeverityError, Message: fmt.Sprint(r)}}}
+		}
+	}()
+
+	list, ok := file.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, ErrInvalidHCL
+	}
+	return json.Marshal(hclObject(list))
+}
+
+// hclObject 将 HCL 对象列表转换为 map
+func hclObject(list *ast.ObjectList) map[string]interface{} {
+	result := map[string]interface{}{}
+	for _, item := range list.Items {
+		keys := make([]string, 0, len(item.Keys))
+		for _, key := range item.Keys {
+			keys = append(keys, fmt.Sprint(key.Token.Value()))
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		setHCLValue(result, keys, hclValue(item.Val))
+	}
+	return result
+}
+
+// hclValue 将 HCL 节点转换为 JSON 兼容的值
+func hclValue(node ast.Node) interface{} {
+	switch n := node.(type) {
+	case *ast.LiteralType:
+		return n.Token.Value()
+	case *ast.ListType:
+		values := make([]interface{}, 0, len(n.List))
+		for _, elem := range n.List {
+			values = append(values, hclValue(elem))
+		}
+		return values
+	case *ast.ObjectType:
+		return hclObject(n.List)
+	case *ast.ObjectList:
+		return hclObject(n)
+	}
+	return nil
+}
+
+// setHCLValue 按键路径写入值, 块标签作为嵌套对象的键; 键已存在时追加为数组
+func setHCLValue(obj map[string]interface{}, keys []string, value interface{}) {
+	key := keys[0]
+	existing, exists := obj[key]
+	if len(keys) > 1 {
+		child, ok := existing.(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			if exists {
+				obj[key] = appendHCLValue(existing, child)
+			} else {
+				obj[key] = child
+			}
+		}
+		setHCLValue(child, keys[1:], value)
+		return
+	}
+	if exists {
+		obj[key] = appendHCLValue(existing, value)
+		return
+	}
+	obj[key] = value
+}
+
+func appendHCLValue(existing, value interface{}) interface{} {
+	if repeated, ok := existing.(hclRepeated); ok {
+		return append(repeated, value)
+	}
+	return hclRepeated{existing, value}
+}
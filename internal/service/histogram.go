@@ -0,0 +1,44 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// latencyHistogram 延迟直方图
+type latencyHistogram struct {
+	bounds  []float64 // 各桶上界 (秒)
+	mu      sync.Mutex
+	buckets []uint64 // 最后一个桶为 +Inf
+	sum     float64
+	count   uint64
+}
+
+func newLatencyHistogram(bounds []float64) *latencyHistogram {
+	return &latencyHistogram{bounds: bounds, buckets: make([]uint64, len(bounds)+1)}
+}
+
+// observe 记录一次延迟
+func (h *latencyHistogram) observe(d time.Duration) {
+	seconds := d.Seconds()
+	bucket := len(h.bounds)
+	for i, upper := range h.bounds {
+		if seconds <= upper {
+			bucket = i
+			break
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.buckets[bucket]++
+	h.sum += seconds
+	h.count++
+}
+
+// snapshot 返回各桶计数、延迟总和和次数的副本
+func (h *latencyHistogram) snapshot() ([]uint64, float64, uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]uint64(nil), h.buckets...), h.sum, h.count
+}
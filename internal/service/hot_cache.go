@@ -0,0 +1,276 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"confighub/internal/model"
+	"confighub/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// HotConfigCache 公开读取路径的进程内热点缓存 (LRU)
+// 以 (项目, 命名空间, 环境, 名称) 为键缓存下发版本、最新正式发布、活跃灰度发布和变量解析后的内容,
+// 命中且无活跃灰度时读取无需访问数据库或 Redis; 条目由通知总线按配置 ID 失效
+// 失效或淘汰的条目保留为最后已知内容, 数据库不可用 (降级) 时未命中的读取以其兜底
+type HotConfigCache struct {
+	configSvc      *ConfigService
+	releaseSvc     *ReleaseService
+	grayReleaseSvc *GrayReleaseService
+	envSvc         *EnvironmentService
+	resilienceSvc  *ResilienceService
+	capacity       int
+
+	mu       sync.Mutex
+	order    *list.List                 // 最近使用的在前
+	items    map[string]*list.Element   // 缓存键 -> *hotCacheItem
+	byConfig map[int64]string           // 配置 ID -> 缓存键
+	stale    map[string]*HotConfigEntry // 已失效或淘汰的条目, 数量不超过 capacity
+	gen      uint64                     // 每次失效递增, 避免加载期间发生的变更被旧数据覆盖
+	hits     uint64
+	misses   uint64
+}
+
+// HotConfigEntry 缓存的配置读取结果
+type HotConfigEntry struct {
+	Config  *model.Config
+	Version *model.ConfigVersion // 没有任何版本时为空
+	Content string               // 已按环境变量解析的内容, 版本已发布且有流水线产物时为产物
+	Release *model.Release       // 所在环境的最新正式发布, 可能为空
+	Gray    *model.Release       // 活跃的灰度发布, 存在时需按客户端实时判定
+}
+
+// HotCacheStats 缓存统计
+type HotCacheStats struct {
+	Size     int    `json:"size"`
+	Capacity int    `json:"capacity"`
+	Hits     uint64 `json:"hits"`
+	Misses   uint64 `json:"misses"`
+}
+
+type hotCacheItem struct {
+	key   string
+	entry *HotConfigEntry
+}
+
+// NewHotConfigCache 创建热点缓存并订阅变更通知, capacity 不大于 0 时不缓存
+func NewHotConfigCache(configSvc *ConfigService, releaseSvc *ReleaseService, grayReleaseSvc *GrayReleaseService, envSvc *EnvironmentService, notifySvc *NotificationService, resilienceSvc *ResilienceService, capacity int) *HotConfigCache {
+	c := &HotConfigCache{
+		configSvc:      configSvc,
+		releaseSvc:     releaseSvc,
+		grayReleaseSvc: grayReleaseSvc,
+		envSvc:         envSvc,
+		resilienceSvc:  resilienceSvc,
+		capacity:       capacity,
+		order:          list.New(),
+		items:          make(map[string]*list.Element),
+		byConfig:       make(map[int64]string),
+		stale:          make(map[string]*HotConfigEntry),
+	}
+	notifySvc.OnChange(func(change *ConfigChange) {
+		c.Invalidate(change.ConfigID)
+	})
+	return c
+}
+
+// Get 获取配置的读取结果, 未命中时从数据库加载并放入缓存
+// 降级期间加载失败时返回最后已知内容
+func (c *HotConfigCache) Get(ctx context.Context, projectID int64, name, namespace, env string) (*HotConfigEntry, error) {
+	namespace, env = ResolveNamespaceEnv(namespace, env)
+	key := hotCacheKey(projectID, namespace, env, name)
+	timing := ResolveTimingFrom(ctx)
+	start := time.Now()
+
+	ctx, span := tracing.Start(ctx, "HotConfigCache.Get", attribute.String("config.name", name), attribute.String("config.namespace", namespace), attribute.String("config.env", env))
+	defer span.End()
+
+	c.mu.Lock()
+	if elem, ok := c.items[key]; ok {
+		c.order.MoveToFront(elem)
+		c.hits++
+		entry := elem.Value.(*hotCacheItem).entry
+		c.mu.Unlock()
+		span.SetAttributes(attribute.Bool("cache.hit", true))
+		timing.Since(ResolveStageCache, start)
+		return entry, nil
+	}
+	c.misses++
+	span.SetAttributes(attribute.Bool("cache.hit", false))
+	gen := c.gen
+	c.mu.Unlock()
+	timing.Since(ResolveStageCache, start)
+
+	start = time.Now()
+	defer timing.Since(ResolveStageDB, start)
+	config, version, err := c.configSvc.GetByAccessKey(ctx, projectID, name, namespace, env)
+	if err != nil {
+		if c.resilienceSvc.Degraded() {
+			c.mu.Lock()
+			entry, ok := c.stale[key]
+			c.mu.Unlock()
+			if ok {
+				return entry, nil
+			}
+		}
+		return nil, err
+	}
+	entry := c.load(ctx, config, version)
+	c.store(key, entry, gen)
+	return entry, nil
+}
+
+// Warmup 预热最近发布过的配置, 返回加载的条目数
+func (c *HotConfigCache) Warmup(ctx context.Context, limit int) (int, error) {
+	if c.capacity <= 0 || limit <= 0 {
+		return 0, nil
+	}
+	if limit > c.capacity {
+		limit = c.capacity
+	}
+
+	ids, err := c.releaseSvc.ListRecentlyReleasedConfigIDs(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	loaded := 0
+	for _, id := range ids {
+		c.mu.Lock()
+		gen := c.gen
+		c.mu.Unlock()
+
+		config, err := c.configSvc.GetConfigByID(ctx, id)
+		if err != nil {
+			continue
+		}
+		// 与公开读取走同一加载路径, 保证预热结果与未命中时加载的一致
+		config, version, err := c.configSvc.GetByAccessKey(ctx, config.ProjectID, config.Name, config.Namespace, config.Environment)
+		if err != nil {
+			continue
+		}
+		c.store(hotCacheKey(config.ProjectID, config.Namespace, config.Environment, config.Name), c.load(ctx, config, version), gen)
+		loaded++
+	}
+	return loaded, nil
+}
+
+// Invalidate 移除配置对应的缓存条目
+func (c *HotConfigCache) Invalidate(configID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	if key, ok := c.byConfig[configID]; ok {
+		c.remove(key)
+	}
+}
+
+// Cached 获取项目指定命名空间和环境下缓存的全部条目 (含最后已知内容), 供降级期间的批量读取
+func (c *HotConfigCache) Cached(projectID int64, namespace, env string) []*HotConfigEntry {
+	namespace, env = ResolveNamespaceEnv(namespace, env)
+	prefix := hotCacheKey(projectID, namespace, env, "")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var entries []*HotConfigEntry
+	for key, elem := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			entries = append(entries, elem.Value.(*hotCacheItem).entry)
+		}
+	}
+	for key, entry := range c.stale {
+		if strings.HasPrefix(key, prefix) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Config.Name < entries[j].Config.Name })
+	return entries
+}
+
+// Stats 获取缓存统计
+func (c *HotConfigCache) Stats() HotCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return HotCacheStats{
+		Size:     len(c.items),
+		Capacity: c.capacity,
+		Hits:     c.hits,
+		Misses:   c.misses,
+	}
+}
+
+// load 加载读取结果中除配置和版本外的部分
+func (c *HotConfigCache) load(ctx context.Context, config *model.Config, version *model.ConfigVersion) *HotConfigEntry {
+	entry := &HotConfigEntry{
+		Config:  config,
+		Version: version,
+		Gray:    c.grayReleaseSvc.ActiveGrayRelease(ctx, config),
+	}
+	if release, err := c.releaseSvc.GetLatestReleased(ctx, config.ID, config.Environment); err == nil {
+		entry.Release = release
+	}
+	if version != nil {
+		entry.Content = c.envSvc.ResolveVariables(ctx, config, ServedContent(version, entry.Release))
+	}
+	return entry
+}
+
+// store 放入缓存; 加载期间发生过失效时丢弃, 下次读取重新加载
+func (c *HotConfigCache) store(key string, entry *HotConfigEntry, gen uint64) {
+	if c.capacity <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.gen != gen {
+		return
+	}
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*hotCacheItem).entry = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	delete(c.stale, key)
+	c.items[key] = c.order.PushFront(&hotCacheItem{key: key, entry: entry})
+	c.byConfig[entry.Config.ID] = key
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back().Value.(*hotCacheItem).key)
+	}
+}
+
+// remove 移除缓存条目并保留为最后已知内容, 调用方需持有锁
+func (c *HotConfigCache) remove(key string) {
+	elem, ok := c.items[key]
+	if !ok {
+		return
+	}
+	entry := elem.Value.(*hotCacheItem).entry
+	c.order.Remove(elem)
+	delete(c.items, key)
+	delete(c.byConfig, entry.Config.ID)
+
+	if len(c.stale) >= c.capacity {
+		// 超出上限时任意丢弃一条
+		for k := range c.stale {
+			delete(c.stale, k)
+			break
+		}
+	}
+	c.stale[key] = entry
+}
+
+// hotCacheKey 缓存键
+func hotCacheKey(projectID int64, namespace, env, name string) string {
+	return strconv.FormatInt(projectID, 10) + "/" + namespace + "/" + env + "/" + name
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"confighub/internal/model"
+)
+
+var (
+	ErrInvalidParent     = errors.New("无效的父配置")
+	ErrConfigHasChildren = errors.New("配置被其他配置继承, 请先解除继承关系")
+)
+
+// maxInheritanceDepth 继承链的最大层数 (含配置自身), 如 基础配置 → 命名空间 → 环境覆盖
+const maxInheritanceDepth = 5
+
+// 合并结果中键的来源
+const (
+	KeyOwn        = "own"        // 只在配置自身定义
+	KeyInherited  = "inherited"  // 继承自父配置链
+	KeyOverridden = "overridden" // 配置自身覆盖了父配置链中的值
+)
+
+// InheritanceNode 继承链中的一个配置
+type InheritanceNode struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Namespace   string `json:"namespace"`
+	Environment string `json:"environment"`
+	Version     int    `json:"version"`
+}
+
+// InheritedKey 合并结果中的叶子键及其生效值的来源
+type InheritedKey struct {
+	Path     string `json:"path"`
+	Status   string `json:"status"`    // own, inherited, overridden
+	SourceID int64  `json:"source_id"` // 生效值所在的配置
+}
+
+// InheritanceInfo 配置的继承链、合并后的内容和各键的来源
+type InheritanceInfo struct {
+	ParentID *int64            `json:"parent_id"`
+	Chain    []InheritanceNode `json:"chain"` // 从根配置到配置自身
+	Content  string            `json:"content"`
+	Keys     []InheritedKey    `json:"keys"`
+}
+
+// Inheritance 获取配置的继承链, 以及各配置最新版本合并后的内容和各键的来源
+func (s *ConfigService) Inheritance(ctx context.Context, id int64) (*InheritanceInfo, error) {
+	config, err := s.configRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+
+	chain := append(s.ancestors(ctx, config), config)
+	info := &InheritanceInfo{ParentID: config.ParentID, Chain: make([]InheritanceNode, 0, len(chain))}
+	var layers []map[string]interface{}
+	var merged map[string]interface{}
+	for _, node := range chain {
+		info.Chain = append(info.Chain, InheritanceNode{
+			ID:          node.ID,
+			Name:        node.Name,
+			Namespace:   node.Namespace,
+			Environment: node.Environment,
+			Version:     node.CurrentVersion,
+		})
+		layer := map[string]interface{}{}
+		if version, err := s.versionRepo.GetLatest(ctx, node.ID); err == nil {
+			if err := json.Unmarshal([]byte(version.Content), &layer); err != nil {
+				return nil, fmt.Errorf("%w: 配置 %s 的内容不是 JSON 对象", ErrInvalidParent, node.Name)
+			}
+		}
+		layers = append(layers, layer)
+		if merged == nil {
+			merged = layer
+		} else {
+			merged = deepMerge(merged, layer)
+		}
+	}
+
+	content, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	info.Content = string(content)
+	info.Keys = keySources(chain, layers, merged)
+	return info, nil
+}
+
+// SetParent 设置或解除 (parentID 为空) 配置继承的父配置
+// 父配置须为同一项目的 JSON 配置, 不能形成循环, 继承链不超过 maxInheritanceDepth 层
+func (s *ConfigService) SetParent(ctx context.Context, id int64, parentID *int64) (*InheritanceInfo, error) {
+	config, err := s.configRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+
+	if parentID != nil {
+		if config.FileType != "json" {
+			return nil, fmt.Errorf("%w: 只有 JSON 配置支持继承", ErrInvalidParent)
+		}
+		if *parentID == id {
+			return nil, fmt.Errorf("%w: 不能继承自身", ErrInvalidParent)
+		}
+		parent, err := s.configRepo.GetByID(ctx, *parentID)
+		if err != nil || parent.ProjectID != config.ProjectID {
+			return nil, fmt.Errorf("%w: 父配置不存在", ErrInvalidParent)
+		}
+		if parent.FileType != "json" {
+			return nil, fmt.Errorf("%w: 父配置不是 JSON 配置", ErrInvalidParent)
+		}
+		ancestors := s.ancestors(ctx, parent)
+		for _, ancestor := range ancestors {
+			if ancestor.ID == id {
+				return nil, fmt.Errorf("%w: 继承关系不能形成循环", ErrInvalidParent)
+			}
+		}
+		if depth := len(ancestors) + 1 + s.descendantDepth(ctx, id, maxInheritanceDepth); depth > maxInheritanceDepth {
+			return nil, fmt.Errorf("%w: 继承链不能超过 %d 层", ErrInvalidParent, maxInheritanceDepth)
+		}
+	}
+
+	config.ParentID = parentID
+	if err := s.configRepo.Update(ctx, config); err != nil {
+		return nil, err
+	}
+	s.notifySvc.NotifyChange(ctx, inheritChange(config))
+	return s.Inheritance(ctx, id)
+}
+
+// Inherited 返回配置版本与父配置链合并后的副本, 未设置父配置或无法合并时原样返回
+// 副本的 CommitHash 为合并后内容的哈希, 父配置变更时客户端可据此发现内容变化
+func (s *ConfigService) Inherited(ctx context.Context, config *model.Config, version *model.ConfigVersion) *model.ConfigVersion {
+	if config.ParentID == nil || version == nil {
+		return version
+	}
+
+	var merged map[string]interface{}
+	for _, ancestor := range s.ancestors(ctx, config) {
+		latest, err := s.versionRepo.GetLatest(ctx, ancestor.ID)
+		if err != nil {
+			continue
+		}
+		var layer map[string]interface{}
+		if err := json.Unmarshal([]byte(latest.Content), &layer); err != nil {
+			return version
+		}
+		if merged == nil {
+			merged = layer
+		} else {
+			merged = deepMerge(merged, layer)
+		}
+	}
+	if merged == nil {
+		return version
+	}
+
+	var own map[string]interface{}
+	if err := json.Unmarshal([]byte(version.Content), &own); err != nil {
+		return version
+	}
+	content, err := json.MarshalIndent(deepMerge(merged, own), "", "  ")
+	if err != nil {
+		return version
+	}
+
+	inherited := *version
+	inherited.Content = string(content)
+	inherited.CommitHash = generateHash(inherited.Content)
+	return &inherited
+}
+
+// ancestors 获取配置的父配置链, 从根配置开始; 父配置已删除时链在此中断
+func (s *ConfigService) ancestors(ctx context.Context, config *model.Config) []*model.Config {
+	var chain []*model.Config
+	seen := map[int64]bool{config.ID: true}
+	for parentID := config.ParentID; parentID != nil && len(chain) < maxInheritanceDepth; {
+		if seen[*parentID] {
+			break
+		}
+		parent, err := s.configRepo.GetByID(ctx, *parentID)
+		if err != nil {
+			break
+		}
+		seen[parent.ID] = true
+		chain = append([]*model.Config{parent}, chain...)
+		parentID = parent.ParentID
+	}
+	return chain
+}
+
+// descendantDepth 获取以配置为根的继承子树的层数 (含配置自身), 最多计算到 limit 层
+func (s *ConfigService) descendantDepth(ctx context.Context, id int64, limit int) int {
+	if limit <= 1 {
+		return 1
+	}
+	children, err := s.configRepo.ListChildren(ctx, id)
+	if err != nil {
+		return 1
+	}
+	depth := 1
+	for _, child := range children {
+		if d := 1 + s.descendantDepth(ctx, child.ID, limit-1); d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
+
+// notifyChildren 配置变更后通知继承它的子配置, 子配置的下发内容随之变化; 由通知总线的监听器调用
+func (s *ConfigService) notifyChildren(change *ConfigChange) {
+	if change.ChangeType == "delete" {
+		return
+	}
+	ctx := context.Background()
+	children, err := s.configRepo.ListChildren(ctx, change.ConfigID)
+	if err != nil {
+		return
+	}
+	for _, child := range children {
+		// 子配置的通知同样经过监听器, 孙配置依次被通知
+		s.notifySvc.NotifyChange(ctx, inheritChange(child))
+	}
+}
+
+// inheritChange 构造继承内容变化的事件: 版本号不变, 合并后的内容变化
+func inheritChange(config *model.Config) *ConfigChange {
+	return &ConfigChange{
+		ProjectID:  config.ProjectID,
+		ConfigID:   config.ID,
+		ConfigName: config.Name,
+		Namespace:  config.Namespace,
+		Env:        config.Environment,
+		Version:    config.CurrentVersion,
+		ChangeType: "inherit",
+	}
+}
+
+// keySources 计算合并结果中各叶子键的来源, layers 与 chain 一一对应, 最后一层为配置自身
+func keySources(chain []*model.Config, layers []map[string]interface{}, merged map[string]interface{}) []InheritedKey {
+	leaves := make([]map[string]bool, len(layers))
+	for i, layer := range layers {
+		leaves[i] = map[string]bool{}
+		collectLeaves("", layer, leaves[i])
+	}
+
+	paths := map[string]bool{}
+	collectLeaves("", merged, paths)
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	self := len(layers) - 1
+	keys := make([]InheritedKey, 0, len(sorted))
+	for _, path := range sorted {
+		key := InheritedKey{Path: path}
+		if definesPath(leaves[self], path) {
+			key.Status, key.SourceID = KeyOwn, chain[self].ID
+			for i := 0; i < self; i++ {
+				if definesPath(leaves[i], path) {
+					key.Status = KeyOverridden
+					break
+				}
+			}
+		} else {
+			key.Status = KeyInherited
+			for i := self - 1; i >= 0; i-- {
+				if definesPath(leaves[i], path) {
+					key.SourceID = chain[i].ID
+					break
+				}
+			}
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// collectLeaves 以点分路径收集对象的叶子键, 数组和标量视为叶子
+func collectLeaves(prefix string, value map[string]interface{}, leaves map[string]bool) {
+	for k, v := range value {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		if child, ok := v.(map[string]interface{}); ok && len(child) > 0 {
+			collectLeaves(path, child, leaves)
+			continue
+		}
+		leaves[path] = true
+	}
+}
+
+// definesPath 某一层是否定义了路径上的值: 定义了路径本身、路径下的键, 或以标量覆盖了路径的上级
+func definesPath(leaves map[string]bool, path string) bool {
+	if leaves[path] {
+		return true
+	}
+	for leaf := range leaves {
+		if strings.HasPrefix(leaf, path+".") || strings.HasPrefix(path, leaf+".") {
+			return true
+		}
+	}
+	return false
+}
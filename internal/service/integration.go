@@ -0,0 +1,315 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrIntegrationNotFound     = errors.New("入站集成不存在")
+	ErrInvalidIntegration      = errors.New("无效的入站集成")
+	ErrInvalidIntegrationToken = errors.New("无效的集成令牌")
+	ErrIntegrationDisabled     = errors.New("入站集成已停用")
+	ErrInvalidPayload          = errors.New("推送内容无法映射为配置")
+)
+
+// IntegrationValidationError 映射后的内容不符合配置的 Schema
+type IntegrationValidationError struct {
+	Errors []ValidationError
+}
+
+func (e *IntegrationValidationError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, v := range e.Errors {
+		parts[i] = v.Field + ": " + v.Message
+	}
+	return "推送内容不符合 Schema: " + strings.Join(parts, "; ")
+}
+
+// IntegrationRequest 创建或更新入站集成请求
+type IntegrationRequest struct {
+	Name     string  `json:"name"`
+	ConfigID int64   `json:"config_id"`
+	Template *string `json:"template"` // 更新时为空表示不修改
+	IsActive *bool   `json:"is_active"`
+}
+
+// InboundResult 推送处理结果
+type InboundResult struct {
+	Config    *model.Config       `json:"-"`
+	Version   int                 `json:"version"`
+	Unchanged bool                `json:"unchanged"` // 映射结果与当前版本相同, 未生成新版本
+	Warnings  []ContractViolation `json:"contract_warnings,omitempty"`
+}
+
+// IntegrationService 入站集成服务
+// 外部系统使用集成令牌推送数据, 数据经映射模板渲染后作为指定配置的新版本写入,
+// 写入前依次校验格式、Schema 和消费方契约
+type IntegrationService struct {
+	integrationRepo *repository.IntegrationRepository
+	configRepo      *repository.ConfigRepository
+	configSvc       *ConfigService
+	schemaSvc       *SchemaService
+	contractSvc     *ContractService
+}
+
+// NewIntegrationService 创建入站集成服务
+func NewIntegrationService(integrationRepo *repository.IntegrationRepository, configRepo *repository.ConfigRepository, configSvc *ConfigService, schemaSvc *SchemaService, contractSvc *ContractService) *IntegrationService {
+	return &IntegrationService{
+		integrationRepo: integrationRepo,
+		configRepo:      configRepo,
+		configSvc:       configSvc,
+		schemaSvc:       schemaSvc,
+		contractSvc:     contractSvc,
+	}
+}
+
+// Create 创建入站集成, 返回仅此一次可见的令牌
+func (s *IntegrationService) Create(ctx context.Context, projectID int64, req *IntegrationRequest, createdBy int64) (*model.InboundIntegration, string, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		return nil, "", ErrInvalidIntegration
+	}
+	config, err := s.configRepo.GetByID(ctx, req.ConfigID)
+	if err != nil || config.ProjectID != projectID {
+		return nil, "", ErrConfigNotFound
+	}
+	tmpl := ""
+	if req.Template != nil {
+		tmpl = *req.Template
+	}
+	if _, err := parseIntegrationTemplate(tmpl); err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidIntegration, err)
+	}
+
+	token, tokenHash, err := generateIntegrationToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	integration := &model.InboundIntegration{
+		ProjectID:   projectID,
+		ConfigID:    config.ID,
+		Name:        req.Name,
+		TokenPrefix: token[:11],
+		TokenHash:   tokenHash,
+		Template:    tmpl,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+		CreatedBy:   createdBy,
+	}
+	if err := s.integrationRepo.Create(ctx, integration); err != nil {
+		return nil, "", err
+	}
+	return integration, token, nil
+}
+
+// Get 获取入站集成
+func (s *IntegrationService) Get(ctx context.Context, id int64) (*model.InboundIntegration, error) {
+	integration, err := s.integrationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrIntegrationNotFound
+	}
+	return integration, nil
+}
+
+// List 获取项目下的入站集成
+func (s *IntegrationService) List(ctx context.Context, projectID int64) ([]*model.InboundIntegration, error) {
+	return s.integrationRepo.ListByProject(ctx, projectID)
+}
+
+// Update 更新入站集成的名称、模板或启用状态, 目标配置不可修改
+func (s *IntegrationService) Update(ctx context.Context, id int64, req *IntegrationRequest) (*model.InboundIntegration, error) {
+	integration, err := s.integrationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrIntegrationNotFound
+	}
+
+	if name := strings.TrimSpace(req.Name); name != "" {
+		if len(name) > 100 {
+			return nil, ErrInvalidIntegration
+		}
+		integration.Name = name
+	}
+	if req.ConfigID != 0 && req.ConfigID != integration.ConfigID {
+		return nil, ErrInvalidIntegration
+	}
+	if req.Template != nil {
+		if _, err := parseIntegrationTemplate(*req.Template); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidIntegration, err)
+		}
+		integration.Template = *req.Template
+	}
+	if req.IsActive != nil {
+		integration.IsActive = *req.IsActive
+	}
+
+	if err := s.integrationRepo.Update(ctx, integration); err != nil {
+		return nil, err
+	}
+	return integration, nil
+}
+
+// Delete 删除入站集成
+func (s *IntegrationService) Delete(ctx context.Context, id int64) error {
+	if _, err := s.integrationRepo.GetByID(ctx, id); err != nil {
+		return ErrIntegrationNotFound
+	}
+	return s.integrationRepo.Delete(ctx, id)
+}
+
+// RegenerateToken 重新生成集成令牌, 旧令牌立即失效
+func (s *IntegrationService) RegenerateToken(ctx context.Context, id int64) (*model.InboundIntegration, string, error) {
+	integration, err := s.integrationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, "", ErrIntegrationNotFound
+	}
+
+	token, tokenHash, err := generateIntegrationToken()
+	if err != nil {
+		return nil, "", err
+	}
+	integration.TokenPrefix = token[:11]
+	integration.TokenHash = tokenHash
+	if err := s.integrationRepo.Update(ctx, integration); err != nil {
+		return nil, "", err
+	}
+	return integration, token, nil
+}
+
+// Authenticate 校验集成令牌
+func (s *IntegrationService) Authenticate(ctx context.Context, id int64, token string) (*model.InboundIntegration, error) {
+	integration, err := s.integrationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrInvalidIntegrationToken
+	}
+	if token == "" || bcrypt.CompareHashAndPassword([]byte(integration.TokenHash), []byte(token)) != nil {
+		return nil, ErrInvalidIntegrationToken
+	}
+	if !integration.IsActive {
+		return nil, ErrIntegrationDisabled
+	}
+	return integration, nil
+}
+
+// Receive 处理外部系统的推送, 映射后写入配置的新版本
+// 映射结果与当前版本相同时不生成新版本
+func (s *IntegrationService) Receive(ctx context.Context, integration *model.InboundIntegration, payload []byte) (*InboundResult, error) {
+	config, current, err := s.configSvc.GetByID(ctx, integration.ConfigID)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+	currentContent := ""
+	if current != nil {
+		currentContent = current.Content
+	}
+
+	content, err := renderIntegrationTemplate(integration.Template, config.FileType, payload, currentContent)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	result := &InboundResult{Config: config, Version: config.CurrentVersion}
+	if current != nil && content == current.Content {
+		result.Unchanged = true
+		return result, nil
+	}
+
+	if jsonContent(config.FileType) && json.Valid([]byte(content)) {
+		validation, err := s.schemaSvc.ValidateConfig(ctx, config.ID, content)
+		if err != nil {
+			return nil, err
+		}
+		if !validation.Valid {
+			return nil, &IntegrationValidationError{Errors: validation.Errors}
+		}
+	}
+
+	version, err := s.configSvc.Update(ctx, config.ID, content, "同步自入站集成 "+integration.Name, "integration:"+integration.Name, model.OriginAutomationPrefix+"integration")
+	if err != nil {
+		return nil, err
+	}
+	s.integrationRepo.MarkReceived(ctx, integration.ID, version.Version)
+
+	result.Version = version.Version
+	result.Warnings = s.contractSvc.Warnings(ctx, config.ID, version.Version)
+	return result, nil
+}
+
+// generateIntegrationToken 生成集成令牌及其哈希
+func generateIntegrationToken() (string, string, error) {
+	token := "it_" + uuid.New().String()
+	hash, err := bcrypt.GenerateFromPassword([]byte(token), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", err
+	}
+	return token, string(hash), nil
+}
+
+// integrationFuncs 映射模板可用的函数
+var integrationFuncs = template.FuncMap{
+	"toJSON": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"toYAML": func(v interface{}) (string, error) {
+		data, err := yaml.Marshal(v)
+		return strings.TrimSuffix(string(data), "\n"), err
+	},
+	"default": func(def, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return def
+		}
+		return v
+	},
+}
+
+// parseIntegrationTemplate 解析映射模板, 为空时返回 nil
+func parseIntegrationTemplate(text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	return template.New("integration").Funcs(integrationFuncs).Option("missingkey=error").Parse(text)
+}
+
+// renderIntegrationTemplate 渲染映射模板
+// 模板数据: .payload 为解析后的推送 JSON, .raw 为原始推送内容, .current 为解析后的当前配置 (JSON/YAML)
+// 引用不存在的键时渲染失败, 可选字段使用 index 配合 default; 未设置模板时直接使用推送内容
+func renderIntegrationTemplate(text, fileType string, payload []byte, currentContent string) (string, error) {
+	tmpl, err := parseIntegrationTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	if tmpl == nil {
+		return string(payload), nil
+	}
+
+	var parsed interface{}
+	if len(bytes.TrimSpace(payload)) > 0 {
+		if err := json.Unmarshal(payload, &parsed); err != nil {
+			return "", errors.New("推送内容不是有效的 JSON")
+		}
+	}
+	current, _ := parseContractContent(fileType, currentContent)
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, map[string]interface{}{
+		"payload": parsed,
+		"raw":     string(payload),
+		"current": current,
+	}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
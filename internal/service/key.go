@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 	"time"
 
 	"confighub/internal/model"
@@ -14,31 +15,51 @@ import (
 )
 
 var (
-	ErrKeyNotFound = errors.New("密钥不存在")
+	ErrKeyNotFound        = errors.New("密钥不存在")
+	ErrInvalidKeyConfigs  = errors.New("无效的配置范围, * 只能出现在末尾")
+	ErrInvalidKeyPriority = errors.New("无效的监听优先级, 可选 high、normal、batch")
 )
 
 // KeyService 密钥服务
 type KeyService struct {
-	keyRepo *repository.KeyRepository
+	keyRepo    *repository.KeyRepository
+	notifySvc  *NotificationService
+	encryptSvc *EncryptionService
 }
 
 // NewKeyService 创建密钥服务
-func NewKeyService(keyRepo *repository.KeyRepository) *KeyService {
+func NewKeyService(keyRepo *repository.KeyRepository, notifySvc *NotificationService, encryptSvc *EncryptionService) *KeyService {
 	return &KeyService{
-		keyRepo: keyRepo,
+		keyRepo:    keyRepo,
+		notifySvc:  notifySvc,
+		encryptSvc: encryptSvc,
 	}
 }
 
 // CreateKeyRequest 创建密钥请求
 type CreateKeyRequest struct {
-	Name        string            `json:"name" binding:"required"`
-	Permissions map[string]bool   `json:"permissions"`
-	IPWhitelist []string          `json:"ip_whitelist"`
-	ExpiresAt   *time.Time        `json:"expires_at"`
+	Name          string          `json:"name" binding:"required"`
+	Permissions   map[string]bool `json:"permissions"`
+	Configs       []string        `json:"configs"` // 可访问的配置名称, 支持 payments/* 前缀匹配
+	IPWhitelist   []string        `json:"ip_whitelist"`
+	ExpiresAt     *time.Time      `json:"expires_at"`
+	Priority      string          `json:"priority"`       // 监听优先级, 为空时为 normal
+	ResolveTiming bool            `json:"resolve_timing"` // 公开读取响应是否携带 X-Resolve-* 耗时分解
 }
 
 // Create 创建密钥
 func (s *KeyService) Create(ctx context.Context, projectID int64, req *CreateKeyRequest) (*model.ProjectKey, string, error) {
+	if err := validateKeyConfigs(req.Configs); err != nil {
+		return nil, "", err
+	}
+	priority := req.Priority
+	if priority == "" {
+		priority = model.KeyPriorityNormal
+	}
+	if !model.ValidKeyPriority(priority) {
+		return nil, "", ErrInvalidKeyPriority
+	}
+
 	accessKey := "ak_" + uuid.New().String()[:24]
 	secretKey := "sk_" + uuid.New().String()
 
@@ -46,6 +67,10 @@ func (s *KeyService) Create(ctx context.Context, projectID int64, req *CreateKey
 	if err != nil {
 		return nil, "", err
 	}
+	secretEnc, err := s.encryptSvc.EncryptSecret(secretKey)
+	if err != nil {
+		return nil, "", err
+	}
 
 	// 默认权限
 	permissions := req.Permissions
@@ -58,7 +83,7 @@ func (s *KeyService) Create(ctx context.Context, projectID int64, req *CreateKey
 			"admin":   false,
 		}
 	}
-	permsJSON, _ := json.Marshal(permissions)
+	permsJSON := encodeKeyPermissions(permissions, req.Configs)
 
 	// IP 白名单
 	var ipWhitelistJSON string
@@ -72,32 +97,68 @@ func (s *KeyService) Create(ctx context.Context, projectID int64, req *CreateKey
 		Name:          req.Name,
 		AccessKey:     accessKey,
 		SecretKeyHash: string(secretHash),
-		Permissions:   string(permsJSON),
+		SecretKeyEnc:  secretEnc,
+		Permissions:   permsJSON,
 		IPWhitelist:   ipWhitelistJSON,
 		ExpiresAt:     req.ExpiresAt,
 		IsActive:      true,
+		Priority:      priority,
+		ResolveTiming: req.ResolveTiming,
 	}
 
 	if err := s.keyRepo.Create(ctx, key); err != nil {
 		return nil, "", err
 	}
+	key.SignatureReady = true
 
 	return key, secretKey, nil
 }
 
+// encodeKeyPermissions 序列化密钥权限, 配置范围保存在权限的 configs 字段中
+func encodeKeyPermissions(permissions map[string]bool, configs []string) string {
+	encoded := make(map[string]interface{}, len(permissions)+1)
+	for name, allowed := range permissions {
+		encoded[name] = allowed
+	}
+	if len(configs) > 0 {
+		encoded["configs"] = configs
+	}
+	permsJSON, _ := json.Marshal(encoded)
+	return string(permsJSON)
+}
+
+// validateKeyConfigs 校验配置范围, * 只能作为末尾的前缀通配符
+func validateKeyConfigs(configs []string) error {
+	for _, pattern := range configs {
+		if pattern == "" || strings.Contains(strings.TrimSuffix(pattern, "*"), "*") {
+			return ErrInvalidKeyConfigs
+		}
+	}
+	return nil
+}
+
 // List 获取密钥列表
 func (s *KeyService) List(ctx context.Context, projectID int64) ([]*model.ProjectKey, error) {
-	return s.keyRepo.List(ctx, projectID)
+	keys, err := s.keyRepo.List(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		key.SignatureReady = key.SecretKeyEnc != ""
+	}
+	return keys, nil
 }
 
-
 // UpdateKeyRequest 更新密钥请求
 type UpdateKeyRequest struct {
-	Name        string          `json:"name"`
-	Permissions map[string]bool `json:"permissions"`
-	IPWhitelist []string        `json:"ip_whitelist"`
-	ExpiresAt   *time.Time      `json:"expires_at"`
-	IsActive    *bool           `json:"is_active"`
+	Name          string          `json:"name"`
+	Permissions   map[string]bool `json:"permissions"`
+	Configs       []string        `json:"configs"` // 传入空数组表示取消限制
+	IPWhitelist   []string        `json:"ip_whitelist"`
+	ExpiresAt     *time.Time      `json:"expires_at"`
+	IsActive      *bool           `json:"is_active"`
+	Priority      string          `json:"priority"`
+	ResolveTiming *bool           `json:"resolve_timing"`
 }
 
 // Update 更新密钥
@@ -106,13 +167,42 @@ func (s *KeyService) Update(ctx context.Context, id int64, req *UpdateKeyRequest
 	if err != nil {
 		return ErrKeyNotFound
 	}
+	if err := validateKeyConfigs(req.Configs); err != nil {
+		return err
+	}
+	if req.Priority != "" && !model.ValidKeyPriority(req.Priority) {
+		return ErrInvalidKeyPriority
+	}
 
 	if req.Name != "" {
 		key.Name = req.Name
 	}
-	if req.Permissions != nil {
-		permsJSON, _ := json.Marshal(req.Permissions)
-		key.Permissions = string(permsJSON)
+	if req.Permissions != nil || req.Configs != nil {
+		// 只修改其中一项时保留另一项
+		var current map[string]interface{}
+		if key.Permissions != "" {
+			json.Unmarshal([]byte(key.Permissions), &current)
+		}
+		permissions, configs := req.Permissions, req.Configs
+		if permissions == nil {
+			permissions = make(map[string]bool)
+			for name, value := range current {
+				if allowed, ok := value.(bool); ok {
+					permissions[name] = allowed
+				}
+			}
+		}
+		if configs == nil {
+			configs = []string{}
+			if patterns, ok := current["configs"].([]interface{}); ok {
+				for _, pattern := range patterns {
+					if p, ok := pattern.(string); ok {
+						configs = append(configs, p)
+					}
+				}
+			}
+		}
+		key.Permissions = encodeKeyPermissions(permissions, configs)
 	}
 	if req.IPWhitelist != nil {
 		ipJSON, _ := json.Marshal(req.IPWhitelist)
@@ -124,8 +214,23 @@ func (s *KeyService) Update(ctx context.Context, id int64, req *UpdateKeyRequest
 	if req.IsActive != nil {
 		key.IsActive = *req.IsActive
 	}
+	priorityChanged := req.Priority != "" && req.Priority != key.Priority
+	if req.Priority != "" {
+		key.Priority = req.Priority
+	}
+	if req.ResolveTiming != nil {
+		key.ResolveTiming = *req.ResolveTiming
+	}
+
+	if err := s.keyRepo.Update(ctx, key); err != nil {
+		return err
+	}
 
-	return s.keyRepo.Update(ctx, key)
+	// 密钥被禁用、访问限制或监听优先级变化时断开现有监听, 重连时按新规则重新鉴权
+	if !key.IsActive || req.Permissions != nil || req.Configs != nil || req.IPWhitelist != nil || req.ExpiresAt != nil || priorityChanged {
+		s.notifySvc.RevokeAccessKey(key.ID)
+	}
+	return nil
 }
 
 // Delete 删除密钥
@@ -134,7 +239,11 @@ func (s *KeyService) Delete(ctx context.Context, id int64) error {
 	if err != nil {
 		return ErrKeyNotFound
 	}
-	return s.keyRepo.Delete(ctx, id)
+	if err := s.keyRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.notifySvc.RevokeAccessKey(id)
+	return nil
 }
 
 // Regenerate 重新生成密钥
@@ -152,13 +261,20 @@ func (s *KeyService) Regenerate(ctx context.Context, id int64) (*model.ProjectKe
 	if err != nil {
 		return nil, "", err
 	}
+	secretEnc, err := s.encryptSvc.EncryptSecret(newSecretKey)
+	if err != nil {
+		return nil, "", err
+	}
 
 	key.AccessKey = newAccessKey
 	key.SecretKeyHash = string(secretHash)
+	key.SecretKeyEnc = secretEnc
+	key.SignatureReady = true
 
 	if err := s.keyRepo.Update(ctx, key); err != nil {
 		return nil, "", err
 	}
+	s.notifySvc.RevokeAccessKey(key.ID)
 
 	return key, newSecretKey, nil
 }
@@ -172,6 +288,18 @@ func (s *KeyService) GetByAccessKey(ctx context.Context, accessKey string) (*mod
 	return key, nil
 }
 
+// SigningSecret 解密用于校验请求签名的 Secret Key, 密钥早于签名校验创建 (未加密保存) 时返回 false
+func (s *KeyService) SigningSecret(key *model.ProjectKey) (string, bool) {
+	if key.SecretKeyEnc == "" {
+		return "", false
+	}
+	secret, err := s.encryptSvc.DecryptSecret(key.SecretKeyEnc)
+	if err != nil {
+		return "", false
+	}
+	return secret, true
+}
+
 // ValidateSecretKey 验证 Secret Key
 func (s *KeyService) ValidateSecretKey(key *model.ProjectKey, secretKey string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(key.SecretKeyHash), []byte(secretKey))
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+)
+
+var (
+	ErrInvalidKV         = errors.New("无效的 kv 配置")
+	ErrConfigKeyNotFound = errors.New("键不存在")
+	ErrConfigKeyConflict = errors.New("键已被修改, 请基于最新修订号重试")
+)
+
+// kvKeyPattern kv 配置的键名: 可直接用作 URL 路径段
+var kvKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._:-]{0,199}$`)
+
+// kvHistoryLimit 键历史默认返回的条数
+const kvHistoryLimit = 100
+
+// normalizeKV 校验 kv 配置内容: 值均为字符串的 JSON 对象, 键名符合 kvKeyPattern; 返回按键排序的格式化内容
+func normalizeKV(content string) (string, error) {
+	values, err := parseKV(content)
+	if err != nil {
+		return "", err
+	}
+	return formatKV(values)
+}
+
+// parseKV 解析 kv 配置内容
+func parseKV(content string) (map[string]string, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &raw); err != nil || raw == nil {
+		return nil, fmt.Errorf("%w: 内容须为 JSON 对象", ErrInvalidKV)
+	}
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		if !kvKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("%w: 键名 %q 须以字母、数字或下划线开头, 只包含字母、数字和 . _ : -, 最长 200 个字符", ErrInvalidKV, key)
+		}
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: 键 %s 的值须为字符串", ErrInvalidKV, key)
+		}
+		values[key] = s
+	}
+	return values, nil
+}
+
+// formatKV 将键值编码为配置内容, 键按字母排序以保证内容稳定
+func formatKV(values map[string]string) (string, error) {
+	content, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// KVService kv 配置的单键读写: 修改单个键时生成新的配置版本, 键和键的历史随版本同步
+// 任何途径产生的新版本 (整体更新、回滚、环境同步等) 都通过变更通知同步到键, 因此键始终与最新版本一致
+type KVService struct {
+	keyRepo     *repository.ConfigKeyRepository
+	configRepo  *repository.ConfigRepository
+	versionRepo repository.VersionStore
+	configSvc   *ConfigService
+
+	syncMu sync.Mutex // 串行化键与版本的同步
+}
+
+// NewKVService 创建 kv 配置服务, 并监听配置变更以同步键
+func NewKVService(keyRepo *repository.ConfigKeyRepository, configRepo *repository.ConfigRepository, versionRepo repository.VersionStore, configSvc *ConfigService, notifySvc *NotificationService) *KVService {
+	s := &KVService{
+		keyRepo:     keyRepo,
+		configRepo:  configRepo,
+		versionRepo: versionRepo,
+		configSvc:   configSvc,
+	}
+	notifySvc.OnChange(s.syncKeys)
+	return s
+}
+
+// SetKeyRequest 设置单个键的请求
+type SetKeyRequest struct {
+	Value    *string `json:"value" binding:"required"`
+	Revision *int    `json:"revision"` // 期望的键修订号, 不一致时拒绝修改; 0 表示键须不存在; 为空时不检查
+	Message  string  `json:"message"`
+	Origin   string  `json:"-"`
+}
+
+// List 获取 kv 配置的所有键
+func (s *KVService) List(ctx context.Context, configID int64) ([]*model.ConfigKey, error) {
+	if _, err := s.kvConfig(ctx, configID); err != nil {
+		return nil, err
+	}
+	return s.keyRepo.List(ctx, configID)
+}
+
+// Get 获取单个键
+func (s *KVService) Get(ctx context.Context, configID int64, key string) (*model.ConfigKey, error) {
+	if _, err := s.kvConfig(ctx, configID); err != nil {
+		return nil, err
+	}
+	row, err := s.keyRepo.Get(ctx, configID, key)
+	if err != nil {
+		return nil, ErrConfigKeyNotFound
+	}
+	return row, nil
+}
+
+// History 获取单个键的修改历史 (包括删除), 最新的在前
+func (s *KVService) History(ctx context.Context, configID int64, key string, limit int) ([]*model.ConfigKeyHistory, error) {
+	if _, err := s.kvConfig(ctx, configID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = kvHistoryLimit
+	}
+	return s.keyRepo.History(ctx, configID, key, limit)
+}
+
+// Set 设置单个键并生成新的配置版本; 值未变化时不生成版本
+// 新版本以读取时的版本为基础提交, 期间其他写入先提交时基于新的最新版本重新检查和修改
+func (s *KVService) Set(ctx context.Context, configID int64, key string, req *SetKeyRequest, author string) (*model.ConfigKey, error) {
+	if !kvKeyPattern.MatchString(key) {
+		return nil, fmt.Errorf("%w: 无效的键名 %q", ErrInvalidKV, key)
+	}
+
+	var row *model.ConfigKey
+	err := retryOnConflict(func() error {
+		values, current, base, err := s.load(ctx, configID, key, req.Revision)
+		if err != nil {
+			return err
+		}
+		if current != nil && current.Value == *req.Value {
+			row = current
+			return nil
+		}
+
+		values[key] = *req.Value
+		message := req.Message
+		if message == "" {
+			message = "设置 " + key
+		}
+		if err := s.save(ctx, configID, values, message, author, req.Origin, base); err != nil {
+			return err
+		}
+		row, err = s.keyRepo.Get(ctx, configID, key)
+		return err
+	})
+	return row, err
+}
+
+// Delete 删除单个键并生成新的配置版本
+func (s *KVService) Delete(ctx context.Context, configID int64, key string, revision *int, message, author, origin string) error {
+	if message == "" {
+		message = "删除 " + key
+	}
+	return retryOnConflict(func() error {
+		values, current, base, err := s.load(ctx, configID, key, revision)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return ErrConfigKeyNotFound
+		}
+
+		delete(values, key)
+		return s.save(ctx, configID, values, message, author, origin, base)
+	})
+}
+
+// load 读取配置当前版本的键值和要修改的键, 并检查期望的修订号; 同时返回读取的版本号, 作为提交的基础
+func (s *KVService) load(ctx context.Context, configID int64, key string, revision *int) (map[string]string, *model.ConfigKey, int, error) {
+	config, err := s.kvConfig(ctx, configID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	values := map[string]string{}
+	if current, err := s.versionRepo.GetByConfigAndVersion(ctx, configID, config.CurrentVersion); err == nil {
+		if values, err = parseKV(current.Content); err != nil {
+			return nil, nil, 0, err
+		}
+	}
+
+	current, err := s.keyRepo.Get(ctx, configID, key)
+	if err != nil {
+		current = nil
+	}
+	if revision != nil {
+		actual := 0
+		if current != nil {
+			actual = current.Revision
+		}
+		if actual != *revision {
+			return nil, nil, 0, ErrConfigKeyConflict
+		}
+	}
+	return values, current, config.CurrentVersion, nil
+}
+
+// save 以新的键值生成配置版本, 键由变更通知同步; 配置的当前版本已不是 base 时返回 ErrConfigVersionConflict
+func (s *KVService) save(ctx context.Context, configID int64, values map[string]string, message, author, origin string, base int) error {
+	content, err := formatKV(values)
+	if err != nil {
+		return err
+	}
+	_, err = s.configSvc.update(ctx, configID, content, message, author, origin, base)
+	return err
+}
+
+// syncKeys 配置产生新版本后按最新内容同步键, 有变化的键记录历史; 由通知总线的监听器调用
+func (s *KVService) syncKeys(change *ConfigChange) {
+	if change.ChangeType == "delete" || change.ChangeType == "inherit" {
+		return
+	}
+	ctx := context.Background()
+	config, err := s.configRepo.GetByID(ctx, change.ConfigID)
+	if err != nil || config.FileType != "kv" {
+		return
+	}
+
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	latest, err := s.versionRepo.GetLatest(ctx, config.ID)
+	if err != nil {
+		return
+	}
+	values, err := parseKV(latest.Content)
+	if err != nil {
+		return
+	}
+	rows, err := s.keyRepo.List(ctx, config.ID)
+	if err != nil {
+		return
+	}
+
+	var changes []*model.ConfigKeyHistory
+	record := func(key, value string, deleted bool) {
+		changes = append(changes, &model.ConfigKeyHistory{
+			Key:           key,
+			Value:         value,
+			Deleted:       deleted,
+			ConfigVersion: latest.Version,
+			Author:        latest.Author,
+			Message:       latest.CommitMessage,
+			Origin:        latest.Origin,
+		})
+	}
+	existing := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		existing[row.Key] = true
+		value, ok := values[row.Key]
+		if !ok {
+			record(row.Key, "", true)
+		} else if value != row.Value {
+			record(row.Key, value, false)
+		}
+	}
+	added := make([]string, 0, len(values))
+	for key := range values {
+		if !existing[key] {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	for _, key := range added {
+		record(key, values[key], false)
+	}
+	if len(changes) > 0 {
+		s.keyRepo.Apply(ctx, config.ID, changes)
+	}
+}
+
+// kvConfig 获取配置并确认为 kv 类型
+func (s *KVService) kvConfig(ctx context.Context, configID int64) (*model.Config, error) {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+	if config.FileType != "kv" {
+		return nil, fmt.Errorf("%w: 配置 %s 不是 kv 类型", ErrInvalidKV, config.Name)
+	}
+	return config, nil
+}
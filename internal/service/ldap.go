@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"confighub/internal/config"
+	"confighub/internal/ldap"
+	"confighub/internal/model"
+	"confighub/internal/repository"
+)
+
+var (
+	ErrLDAPUserNotFound       = errors.New("LDAP 中没有该用户")
+	ErrLDAPInvalidCredentials = errors.New("用户名或密码错误")
+	ErrLDAPUnavailable        = errors.New("LDAP 服务不可用")
+	ErrLDAPLocalUserExists    = errors.New("已存在同名的本地账号, 不能以 LDAP 身份登录")
+)
+
+// ldapEmailDomain 目录条目没有邮箱时生成占位邮箱使用的域名 (RFC 2606 保留域名)
+const ldapEmailDomain = "ldap.invalid"
+
+// roleRank 成员角色的高低, 同一项目映射到多个角色时取最高的
+var roleRank = map[string]int{"viewer": 1, "developer": 2, "releaser": 3, "admin": 4}
+
+// LDAPIdentity 通过 LDAP 认证的用户
+type LDAPIdentity struct {
+	DN       string
+	Username string
+	Email    string
+	Groups   []string // 所属组的 DN
+}
+
+// LDAPService LDAP 登录: 以服务账号查找用户, 以用户 DN 绑定校验密码, 再同步本地用户和项目角色
+type LDAPService struct {
+	cfg      config.LDAPConfig
+	userRepo *repository.UserRepository
+}
+
+// NewLDAPService 创建 LDAP 登录服务
+func NewLDAPService(cfg config.LDAPConfig, userRepo *repository.UserRepository) *LDAPService {
+	return &LDAPService{cfg: cfg, userRepo: userRepo}
+}
+
+// FallbackToLocal 判断 LDAP 登录失败后是否继续尝试本地账号: 仅在允许本地账号且目录中没有该用户或 LDAP 不可用时
+// 目录中存在的用户密码错误时不回退, 避免以本地的旧密码绕过目录
+func (s *LDAPService) FallbackToLocal(err error) bool {
+	return s.cfg.AllowLocal && (errors.Is(err, ErrLDAPUserNotFound) || errors.Is(err, ErrLDAPUnavailable))
+}
+
+// AllowLocal 是否允许本地账号登录和注册
+func (s *LDAPService) AllowLocal() bool {
+	return s.cfg.AllowLocal
+}
+
+// Login 以 LDAP 认证用户, 返回对应的本地用户 (首次登录时创建), 并按组映射同步项目角色
+func (s *LDAPService) Login(ctx context.Context, username, password string) (*model.User, error) {
+	identity, err := s.Authenticate(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	// 同名的本地账号可能属于他人 (如自助注册的账号或应急管理员), 不自动关联到目录身份
+	if existing, err := s.userRepo.GetByUsername(ctx, identity.Username); err == nil && existing.PasswordHash != model.ExternalPasswordHash {
+		return nil, ErrLDAPLocalUserExists
+	}
+	user, err := s.userRepo.SaveExternalUser(ctx, identity.Username, identity.Email)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.cfg.GroupRoles) > 0 {
+		roles, managed := s.groupRoles(identity.Groups)
+		if err := s.userRepo.SyncMemberRoles(ctx, user.ID, roles, managed); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// Authenticate 在目录中查找登录名对应的唯一条目并以其 DN 绑定校验密码
+func (s *LDAPService) Authenticate(ctx context.Context, username, password string) (*LDAPIdentity, error) {
+	if username == "" || password == "" {
+		return nil, ErrLDAPInvalidCredentials
+	}
+
+	conn, err := s.connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLDAPUnavailable, err)
+	}
+	defer conn.Close()
+
+	attrs := []string{s.cfg.UsernameAttr, s.cfg.EmailAttr}
+	if s.cfg.GroupAttr != "" {
+		attrs = append(attrs, s.cfg.GroupAttr)
+	}
+	entries, err := conn.Search(&ldap.SearchRequest{
+		BaseDN:     s.cfg.BaseDN,
+		Scope:      ldap.ScopeWholeSubtree,
+		Filter:     strings.ReplaceAll(s.cfg.UserFilter, "%s", ldap.EscapeFilter(username)),
+		Attributes: attrs,
+		SizeLimit:  2,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLDAPUnavailable, err)
+	}
+	switch len(entries) {
+	case 0:
+		return nil, ErrLDAPUserNotFound
+	case 1:
+	default:
+		// 登录名匹配到多个条目时无法确定用户, 拒绝登录
+		return nil, ErrLDAPInvalidCredentials
+	}
+	entry := entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsResult(err, ldap.ResultInvalidCredentials) {
+			return nil, ErrLDAPInvalidCredentials
+		}
+		return nil, fmt.Errorf("%w: %v", ErrLDAPUnavailable, err)
+	}
+
+	identity := &LDAPIdentity{
+		DN:       entry.DN,
+		Username: entry.Get(s.cfg.UsernameAttr),
+		Email:    entry.Get(s.cfg.EmailAttr),
+	}
+	if identity.Username == "" {
+		identity.Username = username
+	}
+	if identity.Email == "" {
+		identity.Email = identity.Username + "@" + ldapEmailDomain
+	}
+	if s.cfg.GroupAttr != "" {
+		identity.Groups = append(identity.Groups, entry.Values(s.cfg.GroupAttr)...)
+	}
+
+	if s.cfg.GroupBaseDN != "" && len(s.cfg.GroupRoles) > 0 {
+		// 组搜索以服务账号 (或匿名) 身份执行, 用户本身可能没有读取组的权限
+		if err := conn.Bind(s.cfg.BindDN, s.cfg.BindPassword); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrLDAPUnavailable, err)
+		}
+		groups, err := conn.Search(&ldap.SearchRequest{
+			BaseDN:     s.cfg.GroupBaseDN,
+			Scope:      ldap.ScopeWholeSubtree,
+			Filter:     strings.ReplaceAll(s.cfg.GroupFilter, "%s", ldap.EscapeFilter(entry.DN)),
+			Attributes: []string{"1.1"}, // 只需要 DN
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrLDAPUnavailable, err)
+		}
+		for _, group := range groups {
+			identity.Groups = append(identity.Groups, group.DN)
+		}
+	}
+	return identity, nil
+}
+
+// connect 连接 LDAP, 按需升级 TLS, 并以服务账号 (未设置时匿名) 绑定
+func (s *LDAPService) connect(ctx context.Context) (*ldap.Conn, error) {
+	timeout := time.Duration(s.cfg.TimeoutSeconds) * time.Second
+	tlsConfig := &tls.Config{InsecureSkipVerify: s.cfg.InsecureSkipVerify}
+
+	conn, err := ldap.Dial(ctx, s.cfg.URL, timeout, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.StartTLS && strings.HasPrefix(s.cfg.URL, "ldap://") {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if err := conn.Bind(s.cfg.BindDN, s.cfg.BindPassword); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// groupRoles 按组映射计算用户在各项目的角色, 以及映射涉及的全部项目 (这些项目的成员角色由 LDAP 管理)
+func (s *LDAPService) groupRoles(groups []string) (map[string]string, []string) {
+	roles := make(map[string]string)
+	var managed []string
+	seen := make(map[string]bool)
+	for _, mapping := range s.cfg.GroupRoles {
+		if !seen[mapping.Project] {
+			seen[mapping.Project] = true
+			managed = append(managed, mapping.Project)
+		}
+		if !inGroups(groups, mapping.Group) {
+			continue
+		}
+		if roleRank[mapping.Role] > roleRank[roles[mapping.Project]] {
+			roles[mapping.Project] = mapping.Role
+		}
+	}
+	return roles, managed
+}
+
+// inGroups 判断组 DN 是否在列表中, DN 比较不区分大小写和逗号后的空格
+func inGroups(groups []string, group string) bool {
+	target := normalizeDN(group)
+	for _, g := range groups {
+		if normalizeDN(g) == target {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeDN 规范化 DN 用于比较
+func normalizeDN(dn string) string {
+	parts := strings.Split(dn, ",")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+	return strings.ToLower(strings.Join(parts, ","))
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrInvalidLocale = errors.New("无效的语言标识")
+)
+
+// LocalizedMarker 多语言值的标记键, 如 {"$i18n": {"zh": "欢迎", "en": "Welcome"}}
+const LocalizedMarker = "$i18n"
+
+// localePattern 语言标识 (BCP 47 的常用子集), 如 zh、zh-CN、zh-Hant-TW
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,8}(-[A-Za-z0-9]{1,8})*$`)
+
+// LocaleSettings 项目级多语言设置, 保存在项目设置的 locales 中
+type LocaleSettings struct {
+	DefaultLocale string              `json:"default_locale"` // 所有回退链的最后一项, 如 en
+	Fallbacks     map[string][]string `json:"fallbacks"`      // 额外的回退语言, 如 {"zh-HK": ["zh-TW"]}
+}
+
+// Validate 校验并规范化多语言设置
+func (s *LocaleSettings) Validate() error {
+	if s.DefaultLocale != "" {
+		locale, err := NormalizeLocale(s.DefaultLocale)
+		if err != nil {
+			return err
+		}
+		s.DefaultLocale = locale
+	}
+
+	fallbacks := make(map[string][]string, len(s.Fallbacks))
+	for from, targets := range s.Fallbacks {
+		locale, err := NormalizeLocale(from)
+		if err != nil {
+			return err
+		}
+		normalized := make([]string, 0, len(targets))
+		for _, target := range targets {
+			t, err := NormalizeLocale(target)
+			if err != nil {
+				return err
+			}
+			normalized = append(normalized, t)
+		}
+		fallbacks[locale] = normalized
+	}
+	s.Fallbacks = fallbacks
+	return nil
+}
+
+// NormalizeLocale 校验语言标识, 统一使用连字符 (zh_CN -> zh-CN)
+func NormalizeLocale(locale string) (string, error) {
+	locale = strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	if !localePattern.MatchString(locale) {
+		return "", ErrInvalidLocale
+	}
+	return locale, nil
+}
+
+// LocaleChain 计算语言的回退链: 请求的语言及其配置的回退语言, 逐级去掉子标签
+// (zh-Hant-TW -> zh-Hant -> zh), 最后是项目默认语言
+func LocaleChain(locale string, settings *LocaleSettings) []string {
+	var chain []string
+	seen := map[string]bool{}
+	var add func(tag string)
+	add = func(tag string) {
+		for ; tag != ""; tag = parentLocale(tag) {
+			key := strings.ToLower(tag)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			chain = append(chain, tag)
+			if settings == nil {
+				continue
+			}
+			for from, targets := range settings.Fallbacks {
+				if strings.EqualFold(from, tag) {
+					for _, target := range targets {
+						add(target)
+					}
+				}
+			}
+		}
+	}
+
+	add(locale)
+	if settings != nil && settings.DefaultLocale != "" {
+		add(settings.DefaultLocale)
+	}
+	return chain
+}
+
+// parentLocale 去掉最后一个子标签, 没有子标签时返回空
+func parentLocale(tag string) string {
+	if i := strings.LastIndex(tag, "-"); i > 0 {
+		return tag[:i]
+	}
+	return ""
+}
+
+// LocalizeContent 将内容中的多语言值替换为回退链上第一个存在的语言版本
+// 回退链上都不存在时使用按语言标识排序的第一个版本; 仅支持 JSON/YAML, 其他格式或解析失败时原样返回
+func LocalizeContent(fileType, content string, chain []string) string {
+	if !strings.Contains(content, LocalizedMarker) {
+		return content
+	}
+
+	switch fileType {
+	case "json", "hcl", "kv":
+		var data interface{}
+		decoder := json.NewDecoder(strings.NewReader(content))
+		decoder.UseNumber()
+		if err := decoder.Decode(&data); err != nil {
+			return content
+		}
+		data, changed := localizeJSONValue(data, chain)
+		if !changed {
+			return content
+		}
+		output, err := encodeJSONLike(content, data)
+		if err != nil {
+			return content
+		}
+		return output
+	case "yaml":
+		doc, err := parseYAMLNode(content)
+		if err != nil || doc == nil || !localizeYAMLNode(doc, chain) {
+			return content
+		}
+		output, err := encodeYAMLNode(doc, false)
+		if err != nil {
+			return content
+		}
+		return output
+	}
+	return content
+}
+
+// localizeJSONValue 递归替换多语言值, 返回替换后的值及是否有替换
+func localizeJSONValue(value interface{}, chain []string) (interface{}, bool) {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if variants, ok := v[LocalizedMarker].(map[string]interface{}); ok && len(v) == 1 {
+			keys := make([]string, 0, len(variants))
+			for key := range variants {
+				keys = append(keys, key)
+			}
+			if selected, ok := selectLocale(keys, chain); ok {
+				resolved, _ := localizeJSONValue(variants[selected], chain)
+				return resolved, true
+			}
+			return value, false
+		}
+		for key, item := range v {
+			resolved, ok := localizeJSONValue(item, chain)
+			if ok {
+				v[key] = resolved
+				changed = true
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			resolved, ok := localizeJSONValue(item, chain)
+			if ok {
+				v[i] = resolved
+				changed = true
+			}
+		}
+	}
+	return value, changed
+}
+
+// localizeYAMLNode 原地替换 YAML 中的多语言值, 返回是否有替换
+func localizeYAMLNode(node *yaml.Node, chain []string) bool {
+	if node.Kind == yaml.MappingNode && len(node.Content) == 2 && node.Content[0].Value == LocalizedMarker && node.Content[1].Kind == yaml.MappingNode {
+		variants := node.Content[1]
+		keys := make([]string, 0, len(variants.Content)/2)
+		for i := 0; i+1 < len(variants.Content); i += 2 {
+			keys = append(keys, variants.Content[i].Value)
+		}
+		if selected, ok := selectLocale(keys, chain); ok {
+			for i := 0; i+1 < len(variants.Content); i += 2 {
+				if variants.Content[i].Value == selected {
+					*node = *variants.Content[i+1]
+					localizeYAMLNode(node, chain)
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	changed := false
+	for _, child := range node.Content {
+		if localizeYAMLNode(child, chain) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// selectLocale 按回退链选择语言版本, 语言标识不区分大小写, 下划线视同连字符
+func selectLocale(keys []string, chain []string) (string, bool) {
+	if len(keys) == 0 {
+		return "", false
+	}
+	for _, locale := range chain {
+		for _, key := range keys {
+			if strings.EqualFold(strings.ReplaceAll(key, "_", "-"), locale) {
+				return key, true
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys[0], true
+}
+
+// LocaleService 多语言设置服务
+type LocaleService struct {
+	projectRepo *repository.ProjectRepository
+}
+
+// NewLocaleService 创建多语言设置服务
+func NewLocaleService(projectRepo *repository.ProjectRepository) *LocaleService {
+	return &LocaleService{projectRepo: projectRepo}
+}
+
+// projectLocales 解析项目设置中的多语言设置, 未设置时返回空设置
+func projectLocales(project *model.Project) *LocaleSettings {
+	settings := &LocaleSettings{}
+	if !projectSetting(project, "locales", settings) {
+		settings = &LocaleSettings{}
+	}
+	if settings.Fallbacks == nil {
+		settings.Fallbacks = map[string][]string{}
+	}
+	return settings
+}
+
+// Get 获取项目的多语言设置
+func (s *LocaleService) Get(ctx context.Context, projectID int64) (*LocaleSettings, error) {
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	return projectLocales(project), nil
+}
+
+// Update 更新项目的多语言设置
+func (s *LocaleService) Update(ctx context.Context, projectID int64, settings *LocaleSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return ErrProjectNotFound
+	}
+	if err := setProjectSetting(project, "locales", settings); err != nil {
+		return err
+	}
+	return s.projectRepo.Update(ctx, project)
+}
+
+// Chain 按项目设置计算请求语言的回退链; withSettings 为 false 时 (如数据库降级) 不读取项目设置
+func (s *LocaleService) Chain(ctx context.Context, projectID int64, locale string, withSettings bool) ([]string, error) {
+	locale, err := NormalizeLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	var settings *LocaleSettings
+	if withSettings {
+		if project, err := s.projectRepo.GetByID(ctx, projectID); err == nil {
+			settings = projectLocales(project)
+		}
+	}
+	return LocaleChain(locale, settings), nil
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigMetrics 配置文件大小与复杂度指标
+type ConfigMetrics struct {
+	ConfigID            int64  `json:"config_id"`
+	ProjectID           int64  `json:"project_id"`
+	Name                string `json:"name"`
+	Namespace           string `json:"namespace"`
+	Environment         string `json:"environment"`
+	ByteSize            int    `json:"byte_size"`
+	KeyCount            int    `json:"key_count"`
+	Depth               int    `json:"depth"`
+	EncryptedFieldCount int    `json:"encrypted_field_count"`
+}
+
+// MetricsService 配置指标服务
+// 帮助平台团队找出体积过大、层级过深的异常配置
+// 全部配置的指标由后台任务定期计算后缓存, 指标接口抓取时不逐个查询配置内容
+type MetricsService struct {
+	configRepo  *repository.ConfigRepository
+	versionRepo repository.VersionStore
+
+	refreshMu sync.Mutex // 串行化刷新, 避免首次抓取与后台任务重复计算
+	mu        sync.RWMutex
+	snapshot  []*ConfigMetrics
+}
+
+// NewMetricsService 创建配置指标服务
+func NewMetricsService(configRepo *repository.ConfigRepository, versionRepo repository.VersionStore) *MetricsService {
+	return &MetricsService{
+		configRepo:  configRepo,
+		versionRepo: versionRepo,
+	}
+}
+
+// ForConfigs 计算指定配置的指标, 按配置 ID 索引
+func (s *MetricsService) ForConfigs(ctx context.Context, configs []*model.Config) map[int64]*ConfigMetrics {
+	result := make(map[int64]*ConfigMetrics, len(configs))
+	for _, config := range configs {
+		result[config.ID] = s.compute(ctx, config)
+	}
+	return result
+}
+
+// All 计算所有项目配置的指标
+func (s *MetricsService) All(ctx context.Context) ([]*ConfigMetrics, error) {
+	configs, err := s.configRepo.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*ConfigMetrics, 0, len(configs))
+	for _, config := range configs {
+		result = append(result, s.compute(ctx, config))
+	}
+	return result, nil
+}
+
+// Snapshot 获取缓存的全部配置指标, 尚未计算过时同步计算一次
+func (s *MetricsService) Snapshot(ctx context.Context) ([]*ConfigMetrics, error) {
+	s.mu.RLock()
+	snapshot := s.snapshot
+	s.mu.RUnlock()
+	if snapshot != nil {
+		return snapshot, nil
+	}
+
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	s.mu.RLock()
+	snapshot = s.snapshot
+	s.mu.RUnlock()
+	if snapshot != nil {
+		return snapshot, nil
+	}
+	return s.refresh(ctx)
+}
+
+// Run 启动时计算一次, 之后每隔 interval 重新计算全部配置的指标, 直到 ctx 取消
+func (s *MetricsService) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.refreshMu.Lock()
+		_, err := s.refresh(ctx)
+		s.refreshMu.Unlock()
+		if err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh 重新计算并替换缓存, 调用方需持有 refreshMu; 失败时保留上一次的结果
+func (s *MetricsService) refresh(ctx context.Context) ([]*ConfigMetrics, error) {
+	snapshot, err := s.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.snapshot = snapshot
+	s.mu.Unlock()
+	return snapshot, nil
+}
+
+// compute 基于最新版本内容计算单个配置的指标
+func (s *MetricsService) compute(ctx context.Context, config *model.Config) *ConfigMetrics {
+	metrics := &ConfigMetrics{
+		ConfigID:    config.ID,
+		ProjectID:   config.ProjectID,
+		Name:        config.Name,
+		Namespace:   config.Namespace,
+		Environment: config.Environment,
+	}
+
+	version, err := s.versionRepo.GetLatest(ctx, config.ID)
+	if err != nil {
+		return metrics
+	}
+
+	metrics.ByteSize = len(version.Content)
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(version.Content), &data); err != nil {
+		if err := yaml.Unmarshal([]byte(version.Content), &data); err != nil {
+			return metrics
+		}
+	}
+	metrics.Depth = measureValue(data, metrics)
+	return metrics
+}
+
+// measureValue 递归统计键数量和加密字段数量, 返回值的嵌套深度
+func measureValue(value interface{}, metrics *ConfigMetrics) int {
+	depth := 0
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, child := range v {
+			metrics.KeyCount++
+			if d := measureValue(child, metrics); d > depth {
+				depth = d
+			}
+		}
+		return depth + 1
+	case []interface{}:
+		for _, child := range v {
+			if d := measureValue(child, metrics); d > depth {
+				depth = d
+			}
+		}
+		return depth + 1
+	case string:
+		if strings.HasPrefix(v, EncryptedPrefix) {
+			metrics.EncryptedFieldCount++
+		}
+	}
+	return 0
+}
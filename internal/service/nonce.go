@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// nonceKeyPrefix Redis 中已使用随机数的键前缀
+const nonceKeyPrefix = "confighub:nonce:"
+
+// NonceStore 签名请求的随机数 (X-Nonce) 记录, 用于拒绝时间窗口内的重放
+// 随机数以 SETNX 写入 Redis, 多个实例共享; Redis 未连接或出错时记录在本实例内存中, 只能拒绝打到同一实例的重放
+type NonceStore struct {
+	rdb *redis.Client
+	ttl time.Duration
+
+	mu        sync.Mutex
+	seen      map[string]time.Time // 键 -> 过期时间
+	lastSweep time.Time
+}
+
+// NewNonceStore 创建随机数记录, ttl 需覆盖签名时间戳的有效窗口 (前后各一个最大时间差)
+func NewNonceStore(rdb *redis.Client, ttl time.Duration) *NonceStore {
+	return &NonceStore{
+		rdb:  rdb,
+		ttl:  ttl,
+		seen: make(map[string]time.Time),
+	}
+}
+
+// Claim 记录 scope (如 Access Key) 下的随机数, 首次使用返回 true, 有效期内重复使用返回 false
+func (s *NonceStore) Claim(ctx context.Context, scope, nonce string) bool {
+	key := nonceKeyPrefix + scope + ":" + nonce
+	if s.rdb != nil {
+		ok, err := s.rdb.SetNX(ctx, key, 1, s.ttl).Result()
+		if err == nil {
+			return ok
+		}
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) > s.ttl {
+		for k, expiresAt := range s.seen {
+			if now.After(expiresAt) {
+				delete(s.seen, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	if expiresAt, ok := s.seen[key]; ok && now.Before(expiresAt) {
+		return false
+	}
+	s.seen[key] = now.Add(s.ttl)
+	return true
+}
@@ -0,0 +1,393 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"confighub/internal/model"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidNormalization = errors.New("无效的内容规范化设置")
+
+// projectSettingNormalization 项目设置: 内容规范化策略
+const projectSettingNormalization = "normalization"
+
+const (
+	defaultNormalizeIndent = 2
+	maxNormalizeIndent     = 8
+)
+
+// jsonNumberPattern 可按 JSON 数字规范化的字面量, YAML 中的十六进制、八进制和带下划线的数字保持原样
+var jsonNumberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// NormalizationPolicy 项目的内容规范化策略, 写入配置时应用, 使版本间的差异只反映语义变化而非不同编辑器的格式差异
+// 适用于 JSON、kv、HCL (以 JSON 保存) 和 YAML 配置, protobuf 配置已按描述符规范化
+type NormalizationPolicy struct {
+	SortKeys         bool `json:"sort_keys"`         // 对象的键按字典序排列
+	Indent           int  `json:"indent"`            // 按该缩进 (空格数, 1-8) 重新格式化, 0 表示仅在需要重新格式化时使用 2 个空格
+	NormalizeNumbers bool `json:"normalize_numbers"` // 数字写作最短的十进制形式, 如 1.50 → 1.5, 1e3 → 1000, 2.0 → 2
+	TrailingNewline  bool `json:"trailing_newline"`  // 内容以且仅以一个换行结尾
+}
+
+// Validate 校验规范化策略
+func (p *NormalizationPolicy) Validate() error {
+	if p.Indent < 0 || p.Indent > maxNormalizeIndent {
+		return fmt.Errorf("%w: indent 须在 0-%d 之间", ErrInvalidNormalization, maxNormalizeIndent)
+	}
+	return nil
+}
+
+// Enabled 策略是否会改写内容
+func (p *NormalizationPolicy) Enabled() bool {
+	return p.reformat() || p.TrailingNewline
+}
+
+// reformat 是否需要解析并重新格式化内容
+func (p *NormalizationPolicy) reformat() bool {
+	return p.SortKeys || p.NormalizeNumbers || p.Indent > 0
+}
+
+func (p *NormalizationPolicy) indent() int {
+	if p.Indent > 0 {
+		return p.Indent
+	}
+	return defaultNormalizeIndent
+}
+
+// projectNormalization 解析项目设置中的规范化策略, 未设置时返回空策略 (不改写)
+func projectNormalization(project *model.Project) *NormalizationPolicy {
+	policy := &NormalizationPolicy{}
+	if project == nil || !projectSetting(project, projectSettingNormalization, policy) {
+		policy = &NormalizationPolicy{}
+	}
+	return policy
+}
+
+// NormalizeContent 按策略规范化配置内容; YAML 配置保留注释, 内容为 JSON 的 YAML 配置 (如上传时转换的) 按 JSON 处理
+func NormalizeContent(fileType, content string, policy *NormalizationPolicy) (string, error) {
+	if policy == nil || !policy.Enabled() {
+		return content, nil
+	}
+
+	if policy.reformat() {
+		var err error
+		switch {
+		case fileType == "json" || fileType == "kv" || fileType == "hcl" || (fileType == "yaml" && json.Valid([]byte(content))):
+			content, err = normalizeJSON(content, policy)
+		case fileType == "yaml":
+			content, err = normalizeYAML(content, policy)
+		default:
+			return content, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if policy.TrailingNewline {
+		content = strings.TrimRight(content, "\r\n") + "\n"
+	}
+	return content, nil
+}
+
+// jsonMember 保留原有顺序的 JSON 对象成员
+type jsonMember struct {
+	Key   string
+	Value interface{}
+}
+
+// normalizeJSON 解析 JSON (保留键的顺序和数字的原始写法) 后按策略重新编码
+func normalizeJSON(content string, policy *NormalizationPolicy) (string, error) {
+	dec := json.NewDecoder(strings.NewReader(content))
+	dec.UseNumber()
+	value, err := decodeOrdered(dec)
+	if err != nil {
+		return "", ErrInvalidJSON
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return "", ErrInvalidJSON
+	}
+
+	var b bytes.Buffer
+	if err := encodeOrdered(&b, value, policy, strings.Repeat(" ", policy.indent()), ""); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// decodeOrdered 按 token 解析 JSON 值, 对象解析为 []jsonMember
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			members := []jsonMember{}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, ok := keyTok.(string)
+				if !ok {
+					return nil, fmt.Errorf("对象的键不是字符串: %v", keyTok)
+				}
+				value, err := decodeOrdered(dec)
+				if err != nil {
+					return nil, err
+				}
+				members = append(members, jsonMember{Key: key, Value: value})
+			}
+			_, err = dec.Token()
+			return members, err
+		case '[':
+			items := []interface{}{}
+			for dec.More() {
+				item, err := decodeOrdered(dec)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+			}
+			_, err = dec.Token()
+			return items, err
+		}
+		return nil, fmt.Errorf("意外的分隔符 %v", t)
+	default:
+		return tok, nil
+	}
+}
+
+// encodeOrdered 以固定缩进编码 JSON 值, 字符串中的 HTML 字符不转义
+func encodeOrdered(b *bytes.Buffer, value interface{}, policy *NormalizationPolicy, indent, prefix string) error {
+	switch v := value.(type) {
+	case []jsonMember:
+		if len(v) == 0 {
+			b.WriteString("{}")
+			return nil
+		}
+		if policy.SortKeys {
+			sort.SliceStable(v, func(i, j int) bool { return v[i].Key < v[j].Key })
+		}
+		inner := prefix + indent
+		b.WriteString("{\n")
+		for i, member := range v {
+			b.WriteString(inner)
+			if err := encodeJSONScalar(b, member.Key); err != nil {
+				return err
+			}
+			b.WriteString(": ")
+			if err := encodeOrdered(b, member.Value, policy, indent, inner); err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				b.WriteByte(',')
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteString(prefix + "}")
+	case []interface{}:
+		if len(v) == 0 {
+			b.WriteString("[]")
+			return nil
+		}
+		inner := prefix + indent
+		b.WriteString("[\n")
+		for i, item := range v {
+			b.WriteString(inner)
+			if err := encodeOrdered(b, item, policy, indent, inner); err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				b.WriteByte(',')
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteString(prefix + "]")
+	case json.Number:
+		if policy.NormalizeNumbers {
+			b.WriteString(normalizeNumber(v.String()))
+		} else {
+			b.WriteString(v.String())
+		}
+	default:
+		return encodeJSONScalar(b, v)
+	}
+	return nil
+}
+
+func encodeJSONScalar(b *bytes.Buffer, value interface{}) error {
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return err
+	}
+	// Encode 在末尾追加换行
+	b.Truncate(b.Len() - 1)
+	return nil
+}
+
+// normalizeNumber 将 JSON 数字字面量改写为等值的最短十进制形式, 不经过浮点数转换, 不损失精度
+// 有效数字前后的零过多 (小于 1e-6 或不小于 1e21) 时使用科学计数法, 与 JavaScript 的数字格式一致
+func normalizeNumber(s string) string {
+	if !jsonNumberPattern.MatchString(s) {
+		return s
+	}
+
+	negative := strings.HasPrefix(s, "-")
+	mantissa, exponent := strings.TrimPrefix(s, "-"), 0
+	if i := strings.IndexAny(mantissa, "eE"); i >= 0 {
+		exp, err := strconv.Atoi(mantissa[i+1:])
+		if err != nil {
+			return s
+		}
+		mantissa, exponent = mantissa[:i], exp
+	}
+	intPart, fracPart, _ := strings.Cut(mantissa, ".")
+
+	// 值为 digits × 10^exponent
+	digits := strings.TrimLeft(intPart+fracPart, "0")
+	exponent -= len(fracPart)
+	if digits == "" {
+		return "0"
+	}
+	trimmed := strings.TrimRight(digits, "0")
+	exponent += len(digits) - len(trimmed)
+	digits = trimmed
+
+	// point 为小数点在 digits 中的位置
+	var out string
+	point := len(digits) + exponent
+	switch {
+	case exponent >= 0 && point <= 21:
+		out = digits + strings.Repeat("0", exponent)
+	case exponent < 0 && point > 0:
+		out = digits[:point] + "." + digits[point:]
+	case point <= 0 && point > -6:
+		out = "0." + strings.Repeat("0", -point) + digits
+	default:
+		out = digits[:1]
+		if len(digits) > 1 {
+			out += "." + digits[1:]
+		}
+		sign := "+"
+		if point-1 < 0 {
+			sign = "-"
+		}
+		e := point - 1
+		if e < 0 {
+			e = -e
+		}
+		out += "e" + sign + strconv.Itoa(e)
+	}
+	if negative {
+		out = "-" + out
+	}
+	return out
+}
+
+// normalizeYAML 解析 YAML 为节点树 (保留注释) 后按策略重新编码, 支持多文档
+func normalizeYAML(content string, policy *NormalizationPolicy) (string, error) {
+	dec := yaml.NewDecoder(strings.NewReader(content))
+	var docs []*yaml.Node
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return "", ErrInvalidYAML
+		}
+		normalizeYAMLNode(&doc, policy)
+		docs = append(docs, &doc)
+	}
+	if len(docs) == 0 {
+		return content, nil
+	}
+
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(policy.indent())
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return "", err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// normalizeYAMLNode 排序映射的键、规范化数字标量; 锚点引用的节点随原节点一并处理
+func normalizeYAMLNode(node *yaml.Node, policy *NormalizationPolicy) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		if policy.SortKeys {
+			pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+			}
+			sort.SliceStable(pairs, func(i, j int) bool { return pairs[i][0].Value < pairs[j][0].Value })
+			for i, pair := range pairs {
+				node.Content[2*i], node.Content[2*i+1] = pair[0], pair[1]
+			}
+		}
+	case yaml.ScalarNode:
+		if policy.NormalizeNumbers && node.Style == 0 && (node.Tag == "!!int" || node.Tag == "!!float") {
+			node.Value = normalizeNumber(node.Value)
+			// 2.0 规范化为 2 后按值重新推断类型, 避免输出显式的 !!float 标签
+			node.Tag = ""
+		}
+		return
+	}
+	for _, child := range node.Content {
+		normalizeYAMLNode(child, policy)
+	}
+}
+
+// normalizeForProject 按配置所在项目的规范化策略规范化内容
+func (s *ConfigService) normalizeForProject(ctx context.Context, projectID int64, fileType, content string) (string, error) {
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return content, nil
+	}
+	return NormalizeContent(fileType, content, projectNormalization(project))
+}
+
+// GetNormalization 获取项目的内容规范化策略
+func (s *ConfigService) GetNormalization(ctx context.Context, projectID int64) (*NormalizationPolicy, error) {
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	return projectNormalization(project), nil
+}
+
+// UpdateNormalization 更新项目的内容规范化策略, 对之后写入的版本生效, 已有版本不改写
+func (s *ConfigService) UpdateNormalization(ctx context.Context, projectID int64, policy *NormalizationPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return ErrProjectNotFound
+	}
+	if err := setProjectSetting(project, projectSettingNormalization, policy); err != nil {
+		return err
+	}
+	return s.projectRepo.Update(ctx, project)
+}
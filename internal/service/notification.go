@@ -2,70 +2,482 @@ package service
 
 import (
 	"context"
+	"hash/fnv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
+// notifyShardCount 订阅注册表的分片数, 每个分片由独立的 goroutine 分发变更
+const notifyShardCount = 16
+
+// FanoutLatencyBuckets 变更分发延迟直方图的桶上界 (秒)
+var FanoutLatencyBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
 // NotificationService 通知服务
+// 同时作为客户端注册表, 记录每个监听连接所属的密钥和项目, 以便在密钥禁用或项目归档时主动断开
+// 订阅按客户端分片, 指定配置的订阅按配置建立索引; 一次变更由各分片并行分发,
+// 分片在一次唤醒中批量处理积压的变更, 避免数万个监听同一热点配置时单个 goroutine 逐个唤醒
+// 订阅按密钥的监听优先级分类索引, 变更按 high、normal、batch 的顺序投递, 负载保护期间 batch 订阅延后合并投递
 type NotificationService struct {
-	rdb         *redis.Client
-	subscribers map[string]chan *ConfigChange
-	mu          sync.RWMutex
+	rdb       *redis.Client
+	shards    [notifyShardCount]*notifyShard
+	listeners []func(change *ConfigChange)
+	revokers  []func(accessKeyID int64) // 密钥被禁用、删除或重新生成时调用
+	mu        sync.RWMutex
+
+	faultMu sync.RWMutex
+	faults  map[int64]*WatchFault // 项目 ID -> 注入的投递延迟, 仅用于测试
+
+	watchers    int64 // 全部分片的订阅数
+	sheddingCfg atomic.Pointer[sheddingState]
+
+	// 分发统计
+	delivered uint64
+	dropped   uint64
+	latency   *latencyHistogram
+	classes   [priorityClassCount]*classStats
+}
+
+// Subscriber 监听连接的调用方身份
+type Subscriber struct {
+	AccessKeyID int64
+	ProjectID   int64
+	Priority    string // 密钥的监听优先级, 为空时按 normal 处理
+}
+
+// Subscription 监听订阅
+type Subscription struct {
+	Subscriber
+	Changes   chan *ConfigChange
+	Revoked   chan struct{} // 访问权限被撤销时关闭
+	configIDs []int64       // 为空时接收所有配置的变更
+	class     int
+}
+
+// FanoutStats 变更分发统计
+type FanoutStats struct {
+	Subscribers  int                `json:"subscribers"`
+	Delivered    uint64             `json:"delivered"`     // 已投递到订阅通道的变更数
+	Dropped      uint64             `json:"dropped"`       // 订阅通道已满而丢弃的变更数
+	Latency      []uint64           `json:"latency"`       // 落入各桶的次数, 与 FanoutLatencyBuckets 对应, 最后一项为 +Inf
+	LatencySum   float64            `json:"latency_sum"`   // 分发延迟总和 (秒)
+	LatencyCount uint64             `json:"latency_count"` // 完成分发的变更数
+	Shedding     bool               `json:"shedding"`      // 是否处于负载保护
+	Classes      []ClassFanoutStats `json:"classes"`       // 按监听优先级分类的统计, 顺序为 high、normal、batch
+}
+
+// notifyShard 订阅注册表分片
+type notifyShard struct {
+	mu       sync.RWMutex
+	subs     map[string]*Subscription
+	index    [priorityClassCount]subscriptionIndex
+	watchers *int64 // 全部分片的订阅数, 增减时同步更新
+
+	pendingMu sync.Mutex
+	pending   []*fanout
+	wake      chan struct{}
+
+	// 负载保护期间延后投递给 batch 订阅的变更, 仅由分片的分发 goroutine 访问
+	deferred []*fanout
+	flushAt  time.Time
+}
+
+// subscriptionIndex 一个监听优先级分类的订阅索引
+type subscriptionIndex struct {
+	all      map[string]*Subscription           // 接收所有配置变更的订阅
+	byConfig map[int64]map[string]*Subscription // 配置 ID -> 指定该配置的订阅
+}
+
+// fanout 一次变更的分发进度, 各分类最后一个完成的分片记录该分类的延迟, 最后完成的分类记录总延迟
+type fanout struct {
+	change      *ConfigChange
+	start       time.Time
+	remaining   [priorityClassCount]int32
+	classesLeft int32
 }
 
 // ConfigChange 配置变更
 type ConfigChange struct {
+	ID         int64  `json:"id,omitempty"` // 事件日志 ID, 记录到 config_notifications 后填充
+	ProjectID  int64  `json:"-"`
 	ConfigID   int64  `json:"config_id"`
 	ConfigName string `json:"config_name"`
 	Namespace  string `json:"namespace"`
 	Env        string `json:"environment"`
 	Version    int    `json:"version"`
 	ChangeType string `json:"change_type"`
+	ReleaseID  int64  `json:"-"` // 发布类变更对应的发布记录
 }
 
 // NewNotificationService 创建通知服务
 func NewNotificationService(rdb *redis.Client) *NotificationService {
-	return &NotificationService{
-		rdb:         rdb,
-		subscribers: make(map[string]chan *ConfigChange),
+	s := &NotificationService{
+		rdb:     rdb,
+		faults:  make(map[int64]*WatchFault),
+		latency: newLatencyHistogram(FanoutLatencyBuckets),
+	}
+	for i := range s.classes {
+		s.classes[i] = &classStats{latency: newLatencyHistogram(FanoutLatencyBuckets)}
+	}
+	for i := range s.shards {
+		shard := &notifyShard{
+			subs:     make(map[string]*Subscription),
+			watchers: &s.watchers,
+			wake:     make(chan struct{}, 1),
+		}
+		for class := range shard.index {
+			shard.index[class] = subscriptionIndex{
+				all:      make(map[string]*Subscription),
+				byConfig: make(map[int64]map[string]*Subscription),
+			}
+		}
+		s.shards[i] = shard
+		go s.dispatch(shard)
 	}
+	return s
 }
 
-// Subscribe 订阅配置变更
-func (s *NotificationService) Subscribe(ctx context.Context, clientID string, configIDs []int64) (<-chan *ConfigChange, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// shard 客户端所在的分片
+func (s *NotificationService) shard(clientID string) *notifyShard {
+	h := fnv.New32a()
+	h.Write([]byte(clientID))
+	return s.shards[h.Sum32()%notifyShardCount]
+}
+
+// Subscribe 订阅配置变更, configIDs 为空时接收所有配置的变更
+func (s *NotificationService) Subscribe(ctx context.Context, clientID string, subscriber Subscriber, configIDs []int64) (*Subscription, error) {
+	sub := &Subscription{
+		Subscriber: subscriber,
+		Changes:    make(chan *ConfigChange, 10),
+		Revoked:    make(chan struct{}),
+		configIDs:  configIDs,
+		class:      priorityClass(subscriber.Priority),
+	}
+
+	shard := s.shard(clientID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
 
-	ch := make(chan *ConfigChange, 10)
-	s.subscribers[clientID] = ch
+	if old, ok := shard.subs[clientID]; ok {
+		shard.remove(clientID, old)
+	}
+	shard.subs[clientID] = sub
+	atomic.AddInt64(shard.watchers, 1)
+	index := shard.index[sub.class]
+	if len(configIDs) == 0 {
+		index.all[clientID] = sub
+	}
+	for _, id := range configIDs {
+		watchers, ok := index.byConfig[id]
+		if !ok {
+			watchers = make(map[string]*Subscription)
+			index.byConfig[id] = watchers
+		}
+		watchers[clientID] = sub
+	}
 
-	return ch, nil
+	return sub, nil
 }
 
 // Unsubscribe 取消订阅
 func (s *NotificationService) Unsubscribe(ctx context.Context, clientID string) {
+	shard := s.shard(clientID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if sub, ok := shard.subs[clientID]; ok {
+		close(sub.Changes)
+		shard.remove(clientID, sub)
+	}
+}
+
+// remove 从分片的注册表和索引中移除订阅, 调用方需持有写锁
+func (shard *notifyShard) remove(clientID string, sub *Subscription) {
+	delete(shard.subs, clientID)
+	atomic.AddInt64(shard.watchers, -1)
+	index := shard.index[sub.class]
+	delete(index.all, clientID)
+	for _, id := range sub.configIDs {
+		if watchers, ok := index.byConfig[id]; ok {
+			delete(watchers, clientID)
+			if len(watchers) == 0 {
+				delete(index.byConfig, id)
+			}
+		}
+	}
+}
+
+// RevokeAccessKey 断开使用指定密钥的所有监听连接, 返回断开数量
+func (s *NotificationService) RevokeAccessKey(accessKeyID int64) int {
+	if accessKeyID == 0 {
+		return 0
+	}
+
+	s.mu.RLock()
+	revokers := s.revokers
+	s.mu.RUnlock()
+	for _, revoker := range revokers {
+		revoker(accessKeyID)
+	}
+
+	return s.revoke(func(sub *Subscription) bool {
+		return sub.AccessKeyID == accessKeyID
+	})
+}
+
+// OnAccessKeyRevoked 注册密钥撤销监听器, 密钥被禁用、删除、重新生成或访问限制变化时调用,
+// 用于丢弃按密钥缓存的认证结果
+func (s *NotificationService) OnAccessKeyRevoked(listener func(accessKeyID int64)) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if ch, ok := s.subscribers[clientID]; ok {
-		close(ch)
-		delete(s.subscribers, clientID)
+	s.revokers = append(s.revokers, listener)
+}
+
+// RevokeProject 断开指定项目的所有监听连接, 返回断开数量
+func (s *NotificationService) RevokeProject(projectID int64) int {
+	return s.revoke(func(sub *Subscription) bool {
+		return sub.ProjectID == projectID
+	})
+}
+
+// disconnect 关闭匹配订阅的 Changes 通道并从注册表移除, 监听方按连接正常结束处理并重新连接
+func (s *NotificationService) disconnect(match func(sub *Subscription) bool) int {
+	count := 0
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+		for clientID, sub := range shard.subs {
+			if match(sub) {
+				close(sub.Changes)
+				shard.remove(clientID, sub)
+				count++
+			}
+		}
+		shard.mu.Unlock()
 	}
+	return count
+}
+
+// revoke 关闭匹配订阅的 Revoked 通道并从注册表移除
+func (s *NotificationService) revoke(match func(sub *Subscription) bool) int {
+	count := 0
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+		for clientID, sub := range shard.subs {
+			if match(sub) {
+				// 仅关闭 Revoked, 避免监听方从已关闭的 Changes 读到空变更
+				close(sub.Revoked)
+				shard.remove(clientID, sub)
+				count++
+			}
+		}
+		shard.mu.Unlock()
+	}
+	return count
+}
+
+// OnChange 注册进程内变更监听器 (如缓存失效), 在 NotifyChange 时同步调用
+// 监听器按注册顺序执行, 且先于订阅方收到变更
+func (s *NotificationService) OnChange(listener func(change *ConfigChange)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.listeners = append(s.listeners, listener)
 }
 
 // NotifyChange 通知配置变更
+// 监听器同步执行; 订阅方由各分片异步分发, 同一订阅收到的变更保持通知顺序
 func (s *NotificationService) NotifyChange(ctx context.Context, change *ConfigChange) error {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
+	listeners := s.listeners
+	s.mu.RUnlock()
+
+	for _, listener := range listeners {
+		listener(change)
+	}
+
+	s.publish(change)
+	return nil
+}
+
+// publish 将变更交给各分片分发给订阅方, 不调用监听器; 项目注入了延迟时推迟分发
+func (s *NotificationService) publish(change *ConfigChange) {
+	f := &fanout{change: change, start: time.Now(), classesLeft: priorityClassCount}
+	for class := range f.remaining {
+		f.remaining[class] = notifyShardCount
+	}
+	if delay := s.faultDelay(change.ProjectID); delay > 0 {
+		time.AfterFunc(delay, func() { s.enqueue(f) })
+		return
+	}
+	s.enqueue(f)
+}
+
+// enqueue 将变更加入各分片的待分发队列并唤醒分片
+func (s *NotificationService) enqueue(f *fanout) {
+	for _, shard := range s.shards {
+		shard.pendingMu.Lock()
+		shard.pending = append(shard.pending, f)
+		shard.pendingMu.Unlock()
 
-	for _, ch := range s.subscribers {
 		select {
-		case ch <- change:
+		case shard.wake <- struct{}{}:
+		default:
+			// 分片已有待处理的唤醒, 本次变更会在同一批中处理
+		}
+	}
+}
+
+// dispatch 分片的分发循环, 每次唤醒取出全部积压的变更批量投递
+// 负载保护期间 batch 订阅的变更先放入延后队列, 到达合并时间或负载解除后按原顺序投递
+func (s *NotificationService) dispatch(shard *notifyShard) {
+	var flush <-chan time.Time
+	for {
+		select {
+		case <-shard.wake:
+		case <-flush:
+			flush = nil
+		}
+
+		shard.pendingMu.Lock()
+		batch := shard.pending
+		shard.pending = nil
+		shard.pendingMu.Unlock()
+
+		delay := s.batchDelay()
+		now := time.Now()
+
+		// 先投递到期的延后变更, 使 batch 订阅收到的变更保持通知顺序
+		var flushed []*fanout
+		if len(shard.deferred) > 0 && (delay == 0 || !now.Before(shard.flushAt)) {
+			flushed = shard.deferred
+			shard.deferred = nil
+		}
+		deferring := delay > 0 || len(shard.deferred) > 0
+
+		shard.mu.RLock()
+		for _, f := range flushed {
+			s.deliverClass(shard, f.change, classBatch)
+		}
+		for _, f := range batch {
+			s.deliverClass(shard, f.change, classHigh)
+			s.deliverClass(shard, f.change, classNormal)
+			if !deferring {
+				s.deliverClass(shard, f.change, classBatch)
+			}
+		}
+		shard.mu.RUnlock()
+
+		for _, f := range flushed {
+			s.complete(f, classBatch)
+		}
+		for _, f := range batch {
+			s.complete(f, classHigh)
+			s.complete(f, classNormal)
+			if deferring {
+				if len(shard.deferred) == 0 {
+					shard.flushAt = now.Add(delay)
+				}
+				shard.deferred = append(shard.deferred, f)
+			} else {
+				s.complete(f, classBatch)
+			}
+		}
+
+		if len(shard.deferred) == 0 {
+			flush = nil
+		} else if flush == nil {
+			flush = time.After(time.Until(shard.flushAt))
+		}
+	}
+}
+
+// deliverClass 向分片中一个分类的订阅投递变更, 调用方需持有读锁
+func (s *NotificationService) deliverClass(shard *notifyShard, change *ConfigChange, class int) {
+	index := shard.index[class]
+	s.deliver(change, index.all, class)
+	s.deliver(change, index.byConfig[change.ConfigID], class)
+}
+
+// deliver 向订阅投递变更, 通道已满时跳过
+func (s *NotificationService) deliver(change *ConfigChange, subs map[string]*Subscription, class int) {
+	var delivered, dropped uint64
+	for _, sub := range subs {
+		select {
+		case sub.Changes <- change:
+			delivered++
 		default:
 			// 通道已满，跳过
+			dropped++
 		}
 	}
+	stats := s.classes[class]
+	if delivered > 0 {
+		atomic.AddUint64(&s.delivered, delivered)
+		atomic.AddUint64(&stats.delivered, delivered)
+	}
+	if dropped > 0 {
+		atomic.AddUint64(&s.dropped, dropped)
+		atomic.AddUint64(&stats.dropped, dropped)
+	}
+}
 
-	return nil
+// complete 记录分片完成一次变更在一个分类的投递
+func (s *NotificationService) complete(f *fanout, class int) {
+	if atomic.AddInt32(&f.remaining[class], -1) != 0 {
+		return
+	}
+	d := time.Since(f.start)
+	s.classes[class].latency.observe(d)
+	if atomic.AddInt32(&f.classesLeft, -1) == 0 {
+		s.latency.observe(d)
+	}
+}
+
+// SubscribersByProject 各项目当前的监听连接数
+func (s *NotificationService) SubscribersByProject() map[int64]int {
+	counts := make(map[int64]int)
+	for _, shard := range s.shards {
+		shard.mu.RLock()
+		for _, sub := range shard.subs {
+			counts[sub.ProjectID]++
+		}
+		shard.mu.RUnlock()
+	}
+	return counts
+}
+
+// FanoutStats 获取分发统计
+func (s *NotificationService) FanoutStats() FanoutStats {
+	stats := FanoutStats{
+		Delivered: atomic.LoadUint64(&s.delivered),
+		Dropped:   atomic.LoadUint64(&s.dropped),
+		Shedding:  s.Shedding(),
+	}
+	var subscribers [priorityClassCount]int
+	for _, shard := range s.shards {
+		shard.mu.RLock()
+		stats.Subscribers += len(shard.subs)
+		for _, sub := range shard.subs {
+			subscribers[sub.class]++
+		}
+		shard.mu.RUnlock()
+	}
+	stats.Latency, stats.LatencySum, stats.LatencyCount = s.latency.snapshot()
+
+	for class, cs := range s.classes {
+		classStats := ClassFanoutStats{
+			Class:       priorityClassNames[class],
+			Subscribers: subscribers[class],
+			Delivered:   atomic.LoadUint64(&cs.delivered),
+			Dropped:     atomic.LoadUint64(&cs.dropped),
+			Throttled:   atomic.LoadUint64(&cs.throttled),
+		}
+		classStats.Latency, classStats.LatencySum, classStats.LatencyCount = cs.latency.snapshot()
+		stats.Classes = append(stats.Classes, classStats)
+	}
+	return stats
 }
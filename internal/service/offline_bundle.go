@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+)
+
+var ErrInvalidBundleTTL = errors.New("离线配置包有效期超出允许范围")
+
+// OfflineBundleOptions 离线配置包选项
+type OfflineBundleOptions struct {
+	SigningKey string // Ed25519 私钥种子 (base64), 为空或无效时由 JWTSecret 派生
+	JWTSecret  string
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+}
+
+// OfflineBundleService 离线配置包服务
+// 导出项目已发布配置的签名快照, 边缘设备在无网络时加载, 过期后 SDK 拒绝使用;
+// 设备只需持有公钥即可校验, 无法伪造或延长配置包
+// 每个项目使用由主种子派生的独立签名密钥, 一个项目的配置包无法通过另一个项目公钥的校验
+type OfflineBundleService struct {
+	configRepo  *repository.ConfigRepository
+	versionRepo repository.VersionStore
+	releaseSvc  *ReleaseService
+	envSvc      *EnvironmentService
+	encryptSvc  *EncryptionService
+	seed        []byte // 主种子, 只用于派生各项目的签名密钥
+	defaultTTL  time.Duration
+	maxTTL      time.Duration
+}
+
+// NewOfflineBundleService 创建离线配置包服务
+func NewOfflineBundleService(configRepo *repository.ConfigRepository, versionRepo repository.VersionStore, releaseSvc *ReleaseService, envSvc *EnvironmentService, encryptSvc *EncryptionService, opts OfflineBundleOptions) *OfflineBundleService {
+	seed, err := base64.StdEncoding.DecodeString(opts.SigningKey)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		// 与监听令牌相同, 从 JWT 密钥派生独立的签名密钥
+		sum := sha256.Sum256([]byte(opts.JWTSecret + ":offline-bundle"))
+		seed = sum[:]
+	}
+	return &OfflineBundleService{
+		configRepo:  configRepo,
+		versionRepo: versionRepo,
+		releaseSvc:  releaseSvc,
+		envSvc:      envSvc,
+		encryptSvc:  encryptSvc,
+		seed:        seed,
+		defaultTTL:  opts.DefaultTTL,
+		maxTTL:      opts.MaxTTL,
+	}
+}
+
+// projectKey 项目的签名密钥及其标识, 以项目 ID 从主种子派生
+func (s *OfflineBundleService) projectKey(projectID int64) (ed25519.PrivateKey, string) {
+	mac := hmac.New(sha256.New, s.seed)
+	mac.Write([]byte("offline-bundle:project:" + strconv.FormatInt(projectID, 10)))
+	key := ed25519.NewKeyFromSeed(mac.Sum(nil))
+	keyHash := sha256.Sum256(key.Public().(ed25519.PublicKey))
+	return key, strconv.FormatInt(projectID, 10) + "-" + hex.EncodeToString(keyHash[:8])
+}
+
+// PublicKey 返回项目的校验公钥 (base64) 及其标识, 预置到设备上用于校验该项目的配置包
+func (s *OfflineBundleService) PublicKey(projectID int64) (string, string) {
+	key, keyID := s.projectKey(projectID)
+	return base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)), keyID
+}
+
+// Export 导出项目指定命名空间和环境下已正式发布的配置
+// allow 过滤调用方可访问的配置, decrypt 表示是否解密敏感字段; ttl 为 0 时使用默认有效期
+func (s *OfflineBundleService) Export(ctx context.Context, projectID int64, namespace, env string, ttl time.Duration, allow func(name string) bool, decrypt bool) (*model.OfflineBundle, *model.OfflineBundlePayload, error) {
+	if ttl == 0 {
+		ttl = s.defaultTTL
+	}
+	if ttl < 0 || ttl > s.maxTTL {
+		return nil, nil, ErrInvalidBundleTTL
+	}
+	namespace, env = ResolveNamespaceEnv(namespace, env)
+
+	configs, err := s.configRepo.List(ctx, projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	payload := &model.OfflineBundlePayload{
+		Format:      model.OfflineBundleFormat,
+		ProjectID:   projectID,
+		Namespace:   namespace,
+		Environment: env,
+		IssuedAt:    now,
+		ExpiresAt:   now.Add(ttl),
+		Configs:     []model.OfflineBundleConfig{},
+	}
+	for _, config := range configs {
+		if config.Namespace != namespace || config.Environment != env || !allow(config.Name) {
+			continue
+		}
+		// 只打包正式发布的版本, 未发布的草稿和灰度版本不下发到离线设备
+		release, err := s.releaseSvc.GetLatestReleased(ctx, config.ID, config.Environment)
+		if err != nil || release.ReleaseType == "gray" {
+			continue
+		}
+		version, err := s.versionRepo.GetByConfigAndVersion(ctx, config.ID, release.Version)
+		if err != nil {
+			continue
+		}
+
+		content := s.envSvc.ResolveVariables(ctx, config, ServedContent(version, release))
+		if decrypt {
+			if decrypted, err := s.encryptSvc.DecryptFields(ctx, projectID, content); err == nil {
+				content = decrypted
+			}
+		}
+		payload.Configs = append(payload.Configs, model.OfflineBundleConfig{
+			Name:        config.Name,
+			Namespace:   config.Namespace,
+			Environment: config.Environment,
+			Version:     version.Version,
+			Content:     content,
+			ContentHash: ServedContentHash(version, release),
+			ReleaseID:   release.ID,
+			ReleaseType: release.ReleaseType,
+			ReleasedAt:  release.ReleasedAt,
+		})
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, keyID := s.projectKey(projectID)
+	bundle := &model.OfflineBundle{
+		Algorithm: model.OfflineBundleAlgorithm,
+		KeyID:     keyID,
+		Payload:   base64.StdEncoding.EncodeToString(data),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, data)),
+	}
+	return bundle, payload, nil
+}
@@ -0,0 +1,438 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrProjectTemplateNotFound = errors.New("项目模板不存在")
+	ErrInvalidProjectTemplate  = errors.New("无效的项目模板")
+)
+
+// ProjectTemplate 项目初始化模板, 描述新项目预置的环境、命名空间、配置、Schema、密钥和入站集成
+type ProjectTemplate struct {
+	Name         string                `json:"name" yaml:"name"`
+	Description  string                `json:"description" yaml:"description"`
+	AccessMode   string                `json:"access_mode,omitempty" yaml:"access_mode"`
+	Environments []TemplateEnvironment `json:"environments,omitempty" yaml:"environments"` // 为空时使用默认环境
+	Namespaces   []string              `json:"namespaces,omitempty" yaml:"namespaces"`     // 为空时只使用 application
+	Configs      []TemplateConfig      `json:"configs,omitempty" yaml:"configs"`
+	Keys         []TemplateKey         `json:"keys,omitempty" yaml:"keys"`
+	Integrations []TemplateIntegration `json:"integrations,omitempty" yaml:"integrations"`
+}
+
+// TemplateEnvironment 模板中的环境
+type TemplateEnvironment struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+}
+
+// TemplateConfig 模板中的初始配置
+type TemplateConfig struct {
+	Name         string                 `json:"name" yaml:"name"`
+	Namespace    string                 `json:"namespace,omitempty" yaml:"namespace"`       // 为空时使用第一个命名空间
+	Environments []string               `json:"environments,omitempty" yaml:"environments"` // 为空时在每个环境中创建
+	FileType     string                 `json:"file_type" yaml:"file_type"`
+	Content      string                 `json:"content" yaml:"content"`
+	Schema       map[string]interface{} `json:"schema,omitempty" yaml:"schema"`
+}
+
+// TemplateKey 模板中的访问密钥
+type TemplateKey struct {
+	Name        string          `json:"name" yaml:"name"`
+	Permissions map[string]bool `json:"permissions,omitempty" yaml:"permissions"`
+	IPWhitelist []string        `json:"ip_whitelist,omitempty" yaml:"ip_whitelist"`
+	Priority    string          `json:"priority,omitempty" yaml:"priority"`
+}
+
+// TemplateIntegration 模板中的入站集成, 按名称、命名空间和环境指向模板中的配置
+type TemplateIntegration struct {
+	Name        string `json:"name" yaml:"name"`
+	Config      string `json:"config" yaml:"config"`
+	Namespace   string `json:"namespace,omitempty" yaml:"namespace"`
+	Environment string `json:"environment,omitempty" yaml:"environment"` // 配置只在一个环境中创建时可省略
+	Template    string `json:"template,omitempty" yaml:"template"`
+}
+
+// CreateFromTemplateRequest 从模板创建项目请求
+type CreateFromTemplateRequest struct {
+	Template    string `json:"template" binding:"required"`
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	AccessMode  string `json:"access_mode"` // 为空时使用模板的访问模式
+	Origin      string `json:"-"`           // 初始配置版本的变更来源
+}
+
+// OnboardedKey 创建的密钥及仅此一次返回的 Secret Key
+type OnboardedKey struct {
+	Key       *model.ProjectKey `json:"key"`
+	SecretKey string            `json:"secret_key"`
+}
+
+// OnboardedIntegration 创建的入站集成及仅此一次返回的令牌
+type OnboardedIntegration struct {
+	Integration *model.InboundIntegration `json:"integration"`
+	Token       string                    `json:"token"`
+}
+
+// OnboardingResult 从模板创建项目的结果
+type OnboardingResult struct {
+	Project      *model.Project          `json:"project"`
+	Template     string                  `json:"template"`
+	DefaultKey   *model.ProjectKey       `json:"default_key"` // 创建项目时自动生成的默认密钥
+	Environments []Environment           `json:"environments"`
+	Configs      []*model.Config         `json:"configs"`
+	Keys         []*OnboardedKey         `json:"keys"`
+	Integrations []*OnboardedIntegration `json:"integrations"`
+}
+
+// OnboardingService 项目自助开通服务
+// 模板在启动时从目录加载并校验, 创建过程中任一步失败都会删除已创建的项目
+type OnboardingService struct {
+	projectRepo    *repository.ProjectRepository
+	projectSvc     *ProjectService
+	configSvc      *ConfigService
+	schemaSvc      *SchemaService
+	keySvc         *KeyService
+	integrationSvc *IntegrationService
+	templates      map[string]*ProjectTemplate
+}
+
+// NewOnboardingService 创建项目开通服务并加载模板目录, templateDir 为空时没有可用模板
+func NewOnboardingService(projectRepo *repository.ProjectRepository, projectSvc *ProjectService, configSvc *ConfigService, schemaSvc *SchemaService, keySvc *KeyService, integrationSvc *IntegrationService, templateDir string) (*OnboardingService, error) {
+	s := &OnboardingService{
+		projectRepo:    projectRepo,
+		projectSvc:     projectSvc,
+		configSvc:      configSvc,
+		schemaSvc:      schemaSvc,
+		keySvc:         keySvc,
+		integrationSvc: integrationSvc,
+		templates:      make(map[string]*ProjectTemplate),
+	}
+	if templateDir == "" {
+		return s, nil
+	}
+
+	entries, err := os.ReadDir(templateDir)
+	if err != nil {
+		return s, err
+	}
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(templateDir, entry.Name()))
+		if err != nil {
+			return s, err
+		}
+		// JSON 是 YAML 的子集, 统一按 YAML 解析
+		var tmpl ProjectTemplate
+		if err := yaml.Unmarshal(data, &tmpl); err != nil {
+			return s, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		if tmpl.Name == "" {
+			tmpl.Name = strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		}
+		if err := s.validateTemplate(&tmpl); err != nil {
+			return s, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		if _, ok := s.templates[tmpl.Name]; ok {
+			return s, fmt.Errorf("%s: 模板名称 %s 重复", entry.Name(), tmpl.Name)
+		}
+		s.templates[tmpl.Name] = &tmpl
+	}
+	return s, nil
+}
+
+// Templates 获取所有可用模板, 按名称排序
+func (s *OnboardingService) Templates() []*ProjectTemplate {
+	templates := make([]*ProjectTemplate, 0, len(s.templates))
+	for _, tmpl := range s.templates {
+		templates = append(templates, tmpl)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates
+}
+
+// validateTemplate 校验模板并补全默认值, 保证创建时只会因名称冲突或数据库错误失败
+func (s *OnboardingService) validateTemplate(tmpl *ProjectTemplate) error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrInvalidProjectTemplate, fmt.Sprintf(format, args...))
+	}
+
+	switch tmpl.AccessMode {
+	case "", model.AccessModePublic, model.AccessModeKey, model.AccessModeAuth:
+	default:
+		return invalid("不支持的访问模式 %s", tmpl.AccessMode)
+	}
+
+	envNames := make([]string, 0, len(tmpl.Environments))
+	for _, env := range tmpl.Environments {
+		if env.Name == "" || containsString(envNames, env.Name) {
+			return invalid("环境名称为空或重复: %q", env.Name)
+		}
+		envNames = append(envNames, env.Name)
+	}
+	if len(envNames) == 0 {
+		for _, env := range DefaultEnvironments {
+			envNames = append(envNames, env.Name)
+		}
+	}
+
+	if len(tmpl.Namespaces) == 0 {
+		tmpl.Namespaces = []string{"application"}
+	}
+
+	// 配置按 命名空间/环境/名称 去重, 供入站集成引用
+	configEnvs := make(map[string][]string)
+	seen := make(map[string]bool)
+	for i := range tmpl.Configs {
+		cfg := &tmpl.Configs[i]
+		if cfg.Name == "" {
+			return invalid("配置名称不能为空")
+		}
+		if cfg.Namespace == "" {
+			cfg.Namespace = tmpl.Namespaces[0]
+		}
+		if !containsString(tmpl.Namespaces, cfg.Namespace) {
+			return invalid("配置 %s 的命名空间 %s 不在模板命名空间中", cfg.Name, cfg.Namespace)
+		}
+		if len(cfg.Environments) == 0 {
+			cfg.Environments = envNames
+		}
+		for _, env := range cfg.Environments {
+			if !containsString(envNames, env) {
+				return invalid("配置 %s 的环境 %s 不在模板环境中", cfg.Name, env)
+			}
+			key := cfg.Namespace + "/" + env + "/" + cfg.Name
+			if seen[key] {
+				return invalid("配置 %s 重复", key)
+			}
+			seen[key] = true
+		}
+		configEnvs[cfg.Namespace+"/"+cfg.Name] = append(configEnvs[cfg.Namespace+"/"+cfg.Name], cfg.Environments...)
+
+		content, err := templateContentJSON(cfg)
+		if err != nil {
+			return invalid("配置 %s: %v", cfg.Name, err)
+		}
+		if cfg.Schema != nil {
+			schema, _ := json.Marshal(cfg.Schema)
+			if err := s.schemaSvc.validateSchema(string(schema)); err != nil {
+				return invalid("配置 %s 的 Schema: %v", cfg.Name, err)
+			}
+			if content != "" {
+				result, err := s.schemaSvc.Validate(context.Background(), string(schema), content)
+				if err != nil {
+					return invalid("配置 %s 的 Schema: %v", cfg.Name, err)
+				}
+				if !result.Valid {
+					return invalid("配置 %s 不符合其 Schema", cfg.Name)
+				}
+			}
+		}
+	}
+
+	for _, key := range tmpl.Keys {
+		if key.Name == "" {
+			return invalid("密钥名称不能为空")
+		}
+	}
+
+	for i := range tmpl.Integrations {
+		in := &tmpl.Integrations[i]
+		if in.Name == "" {
+			return invalid("入站集成名称不能为空")
+		}
+		if in.Namespace == "" {
+			in.Namespace = tmpl.Namespaces[0]
+		}
+		envs, ok := configEnvs[in.Namespace+"/"+in.Config]
+		if !ok {
+			return invalid("入站集成 %s 引用的配置 %s 不存在", in.Name, in.Config)
+		}
+		if in.Environment == "" {
+			if len(envs) != 1 {
+				return invalid("入站集成 %s 需要指定环境", in.Name)
+			}
+			in.Environment = envs[0]
+		}
+		if !containsString(envs, in.Environment) {
+			return invalid("入站集成 %s 引用的配置 %s 不在环境 %s 中", in.Name, in.Config, in.Environment)
+		}
+		if _, err := parseIntegrationTemplate(in.Template); err != nil {
+			return invalid("入站集成 %s 的模板: %v", in.Name, err)
+		}
+	}
+	return nil
+}
+
+// templateContentJSON 校验模板配置的格式, 返回 JSON 形式的内容供 Schema 校验, protobuf 返回空
+func templateContentJSON(cfg *TemplateConfig) (string, error) {
+	switch cfg.FileType {
+	case "json":
+		if !json.Valid([]byte(cfg.Content)) {
+			return "", ErrInvalidJSON
+		}
+		return cfg.Content, nil
+	case "yaml":
+		var data interface{}
+		if err := yaml.Unmarshal([]byte(cfg.Content), &data); err != nil {
+			return "", ErrInvalidYAML
+		}
+		content, err := json.Marshal(data)
+		if err != nil {
+			return "", ErrInvalidYAML
+		}
+		return string(content), nil
+	case "hcl":
+		return normalizeHCL(cfg.Content)
+	case "protobuf":
+		return "", nil
+	default:
+		return "", ErrInvalidFileType
+	}
+}
+
+// CreateFromTemplate 按模板创建项目及其环境、配置、Schema、密钥和入站集成
+func (s *OnboardingService) CreateFromTemplate(ctx context.Context, req *CreateFromTemplateRequest, userID int64) (*OnboardingResult, error) {
+	tmpl, ok := s.templates[req.Template]
+	if !ok {
+		return nil, ErrProjectTemplateNotFound
+	}
+
+	accessMode := req.AccessMode
+	if accessMode == "" {
+		accessMode = tmpl.AccessMode
+	}
+	project, defaultKey, err := s.projectSvc.Create(ctx, &CreateProjectRequest{
+		Name:        req.Name,
+		Description: req.Description,
+		AccessMode:  accessMode,
+	}, userID)
+	if err != nil {
+		if project != nil {
+			s.projectRepo.Delete(ctx, project.ID)
+		}
+		return nil, err
+	}
+
+	result, err := s.populate(ctx, project, tmpl, userID, req.Origin)
+	if err != nil {
+		// 半初始化的项目没有保留价值, 直接删除以便使用同一名称重试
+		s.projectRepo.Delete(ctx, project.ID)
+		return nil, err
+	}
+	result.DefaultKey = defaultKey
+	return result, nil
+}
+
+// populate 在新项目中创建模板定义的资源
+func (s *OnboardingService) populate(ctx context.Context, project *model.Project, tmpl *ProjectTemplate, userID int64, origin string) (*OnboardingResult, error) {
+	result := &OnboardingResult{
+		Project:      project,
+		Template:     tmpl.Name,
+		Configs:      []*model.Config{},
+		Keys:         []*OnboardedKey{},
+		Integrations: []*OnboardedIntegration{},
+	}
+
+	if len(tmpl.Environments) > 0 {
+		envs := make([]Environment, len(tmpl.Environments))
+		for i, env := range tmpl.Environments {
+			envs[i] = Environment{Name: env.Name, Description: env.Description}
+		}
+		if err := setProjectEnvironments(project, envs); err != nil {
+			return nil, err
+		}
+		if err := s.projectRepo.Update(ctx, project); err != nil {
+			return nil, err
+		}
+	}
+	result.Environments = projectEnvironments(project)
+
+	author := "template:" + tmpl.Name
+	configIDs := make(map[string]int64)
+	for _, cfg := range tmpl.Configs {
+		var schema string
+		if cfg.Schema != nil {
+			schemaJSON, _ := json.Marshal(cfg.Schema)
+			schema = string(schemaJSON)
+		}
+		for _, env := range cfg.Environments {
+			config, err := s.configSvc.Upload(ctx, project.ID, &UploadRequest{
+				Name:        cfg.Name,
+				Namespace:   cfg.Namespace,
+				Environment: env,
+				FileType:    cfg.FileType,
+				Content:     cfg.Content,
+				Message:     "从模板 " + tmpl.Name + " 初始化",
+				Origin:      origin,
+			}, author)
+			if err != nil {
+				return nil, err
+			}
+			if schema != "" {
+				if err := s.schemaSvc.Update(ctx, config.ID, schema); err != nil {
+					return nil, err
+				}
+				config.SchemaJSON = schema
+			}
+			configIDs[cfg.Namespace+"/"+env+"/"+cfg.Name] = config.ID
+			result.Configs = append(result.Configs, config)
+		}
+	}
+
+	for _, k := range tmpl.Keys {
+		key, secretKey, err := s.keySvc.Create(ctx, project.ID, &CreateKeyRequest{
+			Name:        k.Name,
+			Permissions: k.Permissions,
+			IPWhitelist: k.IPWhitelist,
+			Priority:    k.Priority,
+		})
+		if err != nil {
+			return nil, err
+		}
+		result.Keys = append(result.Keys, &OnboardedKey{Key: key, SecretKey: secretKey})
+	}
+
+	for _, in := range tmpl.Integrations {
+		mapping := in.Template
+		integration, token, err := s.integrationSvc.Create(ctx, project.ID, &IntegrationRequest{
+			Name:     in.Name,
+			ConfigID: configIDs[in.Namespace+"/"+in.Environment+"/"+in.Config],
+			Template: &mapping,
+		}, userID)
+		if err != nil {
+			return nil, err
+		}
+		result.Integrations = append(result.Integrations, &OnboardedIntegration{Integration: integration, Token: token})
+	}
+
+	return result, nil
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+
+	"confighub/internal/repository"
+)
+
+// OrphanService 孤儿数据清理服务
+// 清理早期版本删除项目/配置时遗留的版本、发布、密钥和审计记录
+type OrphanService struct {
+	orphanRepo *repository.OrphanRepository
+}
+
+// NewOrphanService 创建孤儿数据清理服务
+func NewOrphanService(orphanRepo *repository.OrphanRepository) *OrphanService {
+	return &OrphanService{
+		orphanRepo: orphanRepo,
+	}
+}
+
+// Cleanup 清理孤儿数据, dryRun 为 true 时仅统计不删除, 返回各表行数
+func (s *OrphanService) Cleanup(ctx context.Context, dryRun bool) (map[string]int64, error) {
+	if dryRun {
+		return s.orphanRepo.Count(ctx)
+	}
+	return s.orphanRepo.Delete(ctx)
+}
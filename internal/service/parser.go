@@ -1,9 +1,13 @@
 package service
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -24,10 +28,43 @@ func NewParser() *Parser {
 
 // ParseResult 解析结果
 type ParseResult struct {
-	Valid       bool     `json:"valid"`
-	Content     string   `json:"content"`      // 标准化后的内容
-	FileType    string   `json:"file_type"`    // 检测到的文件类型
-	Errors      []string `json:"errors,omitempty"`
+	Valid    bool         `json:"valid"`
+	Content  string       `json:"content"`   // 标准化后的内容
+	FileType string       `json:"file_type"` // 检测到的文件类型
+	Errors   []string     `json:"errors,omitempty"`
+	Issues   []ParseIssue `json:"issues,omitempty"` // 带行列位置的问题, 供编辑器定位
+}
+
+// ParseIssue 解析问题 (行列号从 1 开始, 0 表示未知)
+type ParseIssue struct {
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"` // error, warning
+	Message  string `json:"message"`
+}
+
+// 问题级别
+const (
+	IssueSeverityError   = "error"
+	IssueSeverityWarning = "warning"
+)
+
+// YAMLLintError YAML 校验失败, 携带带位置的问题列表
+type YAMLLintError struct {
+	Issues []ParseIssue
+}
+
+func (e *YAMLLintError) Error() string {
+	if len(e.Issues) == 0 {
+		return ErrInvalidYAML.Error()
+	}
+	first := e.Issues[0]
+	return fmt.Sprintf("%s: 第 %d 行第 %d 列: %s", ErrInvalidYAML.Error(), first.Line, first.Column, first.Message)
+}
+
+// Unwrap 使 errors.Is(err, ErrInvalidYAML) 成立
+func (e *YAMLLintError) Unwrap() error {
+	return ErrInvalidYAML
 }
 
 // ParseJSON 解析并验证 JSON
@@ -71,6 +108,18 @@ func (p *Parser) ParseYAML(content string) (*ParseResult, error) {
 		Errors:   []string{},
 	}
 
+	// 检查重复键、未解析的锚点等, 避免静默的后者覆盖前者
+	result.Issues = p.LintYAML(content)
+	if hasLintErrors(result.Issues) {
+		result.Valid = false
+		for _, issue := range result.Issues {
+			if issue.Severity == IssueSeverityError {
+				result.Errors = append(result.Errors, fmt.Sprintf("第 %d 行第 %d 列: %s", issue.Line, issue.Column, issue.Message))
+			}
+		}
+		return result, ErrParseYAML
+	}
+
 	// 解析 YAML
 	var data interface{}
 	if err := yaml.Unmarshal([]byte(content), &data); err != nil {
@@ -95,6 +144,139 @@ func (p *Parser) ParseYAML(content string) (*ParseResult, error) {
 	return result, nil
 }
 
+// yamlErrorLine 匹配 yaml.v3 错误信息中的行号
+var yamlErrorLine = regexp.MustCompile(`line (\d+): (.*)`)
+
+// yamlUnknownAnchor 匹配未定义锚点的错误信息
+var yamlUnknownAnchor = regexp.MustCompile(`unknown anchor '([^']*)' referenced`)
+
+// LintYAML 检查 YAML 内容中的语法错误、重复键和未解析的锚点, 返回带行列位置的问题列表
+// 重复键和未解析的锚点为 error, 定义后未被引用的锚点为 warning
+func (p *Parser) LintYAML(content string) []ParseIssue {
+	issues := []ParseIssue{}
+	lines := strings.Split(content, "\n")
+
+	decoder := yaml.NewDecoder(bytes.NewReader([]byte(content)))
+	for {
+		var doc yaml.Node
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			issues = append(issues, yamlSyntaxIssue(err, lines))
+			break
+		}
+
+		linter := &yamlLinter{anchors: make(map[string]*yaml.Node), used: make(map[string]bool)}
+		linter.walk(&doc)
+		issues = append(issues, linter.issues...)
+		for name, anchor := range linter.anchors {
+			if !linter.used[name] {
+				issues = append(issues, ParseIssue{
+					Line:     anchor.Line,
+					Column:   anchor.Column,
+					Severity: IssueSeverityWarning,
+					Message:  fmt.Sprintf("锚点 &%s 未被引用", name),
+				})
+			}
+		}
+	}
+
+	return issues
+}
+
+// yamlSyntaxIssue 将 yaml.v3 解析错误转换为带位置的问题
+func yamlSyntaxIssue(err error, lines []string) ParseIssue {
+	issue := ParseIssue{
+		Severity: IssueSeverityError,
+		Message:  strings.TrimPrefix(err.Error(), "yaml: "),
+	}
+
+	if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+		issue.Line, _ = strconv.Atoi(m[1])
+		issue.Message = m[2]
+	}
+
+	// 未定义锚点: yaml.v3 不附带位置, 定位到首个引用处
+	if a := yamlUnknownAnchor.FindStringSubmatch(issue.Message); a != nil {
+		issue.Message = fmt.Sprintf("引用了未定义的锚点 *%s", a[1])
+		for i, line := range lines {
+			if issue.Line != 0 && issue.Line != i+1 {
+				continue
+			}
+			if col := strings.Index(line, "*"+a[1]); col >= 0 {
+				issue.Line = i + 1
+				issue.Column = col + 1
+				break
+			}
+		}
+	}
+	return issue
+}
+
+// yamlLinter 遍历 YAML 节点树收集问题
+type yamlLinter struct {
+	anchors map[string]*yaml.Node
+	used    map[string]bool
+	issues  []ParseIssue
+}
+
+func (l *yamlLinter) walk(node *yaml.Node) {
+	if node == nil {
+		return
+	}
+	if node.Anchor != "" {
+		l.anchors[node.Anchor] = node
+	}
+
+	switch node.Kind {
+	case yaml.AliasNode:
+		if node.Alias == nil {
+			l.issues = append(l.issues, ParseIssue{
+				Line:     node.Line,
+				Column:   node.Column,
+				Severity: IssueSeverityError,
+				Message:  fmt.Sprintf("引用了未定义的锚点 *%s", node.Value),
+			})
+			return
+		}
+		l.used[node.Value] = true
+		return
+	case yaml.MappingNode:
+		seen := make(map[string]*yaml.Node)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Kind == yaml.ScalarNode && key.Tag != "!!merge" {
+				if first, ok := seen[key.Value]; ok {
+					l.issues = append(l.issues, ParseIssue{
+						Line:     key.Line,
+						Column:   key.Column,
+						Severity: IssueSeverityError,
+						Message:  fmt.Sprintf("重复的键 %q (首次定义于第 %d 行第 %d 列)", key.Value, first.Line, first.Column),
+					})
+				} else {
+					seen[key.Value] = key
+				}
+			}
+		}
+	}
+
+	for _, child := range node.Content {
+		l.walk(child)
+	}
+}
+
+// hasLintErrors 判断问题列表中是否存在 error 级别的问题
+func hasLintErrors(issues []ParseIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == IssueSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
 // DetectAndParse 自动检测文件类型并解析
 func (p *Parser) DetectAndParse(content string) (*ParseResult, error) {
 	content = strings.TrimSpace(content)
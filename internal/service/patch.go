@@ -0,0 +1,478 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"confighub/internal/model"
+)
+
+var (
+	ErrInvalidPatch      = errors.New("无效的补丁")
+	ErrPatchTestFailed   = errors.New("补丁的 test 操作不满足")
+	ErrPatchBaseOutdated = errors.New("配置已有更新的版本, 请基于最新版本重试")
+)
+
+// 补丁格式
+const (
+	PatchTypeJSON  = "json-patch"  // RFC 6902 JSON Patch, 内容为操作数组
+	PatchTypeMerge = "merge-patch" // RFC 7386 JSON Merge Patch, 内容为对象, null 表示删除
+)
+
+// PatchRequest 以补丁修改配置的请求
+type PatchRequest struct {
+	Type        string // PatchTypeJSON 或 PatchTypeMerge
+	Patch       []byte
+	BaseVersion int // 期望的当前版本, 不一致时拒绝; 0 表示基于最新版本
+	Message     string
+	Origin      string
+}
+
+// Patch 将补丁应用到配置的最新版本并生成新版本, 只修改补丁涉及的字段
+// 新版本以读取时的版本为基础提交, 期间其他写入先提交时: 指定了 BaseVersion 则返回 ErrPatchBaseOutdated,
+// 否则基于新的最新版本重新应用补丁
+// 仅支持 JSON 和 kv 配置, 补丁作用于存储的原始内容 (不含继承合并的父配置内容)
+func (s *ConfigService) Patch(ctx context.Context, id int64, req *PatchRequest, author string) (*model.ConfigVersion, error) {
+	var version *model.ConfigVersion
+	err := retryOnConflict(func() error {
+		var err error
+		version, err = s.patch(ctx, id, req, author)
+		return err
+	})
+	if errors.Is(err, ErrConfigVersionConflict) && req.BaseVersion > 0 {
+		return nil, ErrPatchBaseOutdated
+	}
+	return version, err
+}
+
+// patch 将补丁应用到当前版本并以其为基础提交
+func (s *ConfigService) patch(ctx context.Context, id int64, req *PatchRequest, author string) (*model.ConfigVersion, error) {
+	config, err := s.configRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+	if config.FileType != "json" && config.FileType != "kv" {
+		return nil, fmt.Errorf("%w: 仅支持 JSON 和 kv 配置, 当前为 %s", ErrInvalidPatch, config.FileType)
+	}
+	if req.BaseVersion > 0 && req.BaseVersion != config.CurrentVersion {
+		return nil, fmt.Errorf("%w (当前版本: %d)", ErrPatchBaseOutdated, config.CurrentVersion)
+	}
+
+	var doc interface{} = map[string]interface{}{}
+	if current, err := s.versionRepo.GetByConfigAndVersion(ctx, id, config.CurrentVersion); err == nil {
+		if doc, err = decodeJSON([]byte(current.Content)); err != nil {
+			return nil, fmt.Errorf("%w: 当前内容不是有效的 JSON", ErrInvalidPatch)
+		}
+	}
+
+	switch req.Type {
+	case PatchTypeJSON:
+		doc, err = applyJSONPatch(doc, req.Patch)
+	case PatchTypeMerge:
+		doc, err = applyMergePatch(doc, req.Patch)
+	default:
+		err = fmt.Errorf("%w: 未知的补丁格式 %q", ErrInvalidPatch, req.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := encodeJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+	message := req.Message
+	if message == "" {
+		message = "通过补丁更新"
+	}
+	return s.update(ctx, id, content, message, author, req.Origin, config.CurrentVersion)
+}
+
+// decodeJSON 解析 JSON, 数字保留原始文本以免精度丢失
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("JSON 之后存在多余内容")
+	}
+	return value, nil
+}
+
+// encodeJSON 以两个空格缩进编码, 不转义 HTML 字符
+func encodeJSON(value interface{}) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// patchOperation JSON Patch 的单个操作
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"` // 值为 null 时为 "null", 缺少时为空
+}
+
+// applyJSONPatch 按 RFC 6902 依次应用操作, 任一操作失败时整个补丁不生效
+func applyJSONPatch(doc interface{}, patch []byte) (interface{}, error) {
+	var ops []patchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: JSON Patch 须为操作数组", ErrInvalidPatch)
+	}
+
+	for i, op := range ops {
+		if op.Path == nil {
+			return nil, fmt.Errorf("%w: 第 %d 个操作缺少 path", ErrInvalidPatch, i+1)
+		}
+		path, err := parsePointer(*op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: 第 %d 个操作: %v", ErrInvalidPatch, i+1, err)
+		}
+
+		var value interface{}
+		switch op.Op {
+		case "add", "replace", "test":
+			if len(op.Value) == 0 {
+				return nil, fmt.Errorf("%w: 第 %d 个操作 (%s) 缺少 value", ErrInvalidPatch, i+1, op.Op)
+			}
+			if value, err = decodeJSON(op.Value); err != nil {
+				return nil, fmt.Errorf("%w: 第 %d 个操作的 value 无效", ErrInvalidPatch, i+1)
+			}
+		case "move", "copy":
+			if op.From == nil {
+				return nil, fmt.Errorf("%w: 第 %d 个操作 (%s) 缺少 from", ErrInvalidPatch, i+1, op.Op)
+			}
+			from, err := parsePointer(*op.From)
+			if err != nil {
+				return nil, fmt.Errorf("%w: 第 %d 个操作: %v", ErrInvalidPatch, i+1, err)
+			}
+			if value, err = pointerGet(doc, from); err != nil {
+				return nil, fmt.Errorf("%w: 第 %d 个操作: %v", ErrInvalidPatch, i+1, err)
+			}
+			if op.Op == "move" {
+				if isPointerPrefix(from, path) && len(from) < len(path) {
+					return nil, fmt.Errorf("%w: 第 %d 个操作不能将 %s 移动到自身的子路径", ErrInvalidPatch, i+1, *op.From)
+				}
+				if doc, err = pointerRemove(doc, from); err != nil {
+					return nil, fmt.Errorf("%w: 第 %d 个操作: %v", ErrInvalidPatch, i+1, err)
+				}
+			} else {
+				value = cloneJSON(value)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("%w: 第 %d 个操作的 op %q 无效", ErrInvalidPatch, i+1, op.Op)
+		}
+
+		switch op.Op {
+		case "add", "move", "copy":
+			doc, err = pointerAdd(doc, path, value)
+		case "replace":
+			if _, err = pointerGet(doc, path); err == nil {
+				doc, err = pointerReplace(doc, path, value)
+			}
+		case "remove":
+			doc, err = pointerRemove(doc, path)
+		case "test":
+			var current interface{}
+			if current, err = pointerGet(doc, path); err == nil && !jsonEqual(current, value) {
+				return nil, fmt.Errorf("%w: %s 的当前值与期望不一致", ErrPatchTestFailed, *op.Path)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: 第 %d 个操作: %v", ErrInvalidPatch, i+1, err)
+		}
+	}
+	return doc, nil
+}
+
+// applyMergePatch 按 RFC 7386 合并: 对象逐键递归合并, null 删除键, 其他值整体替换
+func applyMergePatch(doc interface{}, patch []byte) (interface{}, error) {
+	value, err := decodeJSON(patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: Merge Patch 须为有效的 JSON", ErrInvalidPatch)
+	}
+	return mergePatch(doc, value), nil
+}
+
+// mergePatch RFC 7386 的 MergePatch 过程
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// parsePointer 按 RFC 6901 解析 JSON Pointer, 空串表示整个文档
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("路径 %q 须以 / 开头", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// isPointerPrefix prefix 是否为 path 自身或其祖先
+func isPointerPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// pointerGet 获取路径指向的值
+func pointerGet(doc interface{}, path []string) (interface{}, error) {
+	current := doc
+	for i, token := range path {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("路径 %s 不存在", formatPointer(path[:i+1]))
+			}
+			current = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, fmt.Errorf("路径 %s: %v", formatPointer(path[:i+1]), err)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("路径 %s 不存在", formatPointer(path[:i+1]))
+		}
+	}
+	return current, nil
+}
+
+// pointerAdd 在路径处添加值: 对象中添加或替换键, 数组中插入 ("-" 表示追加到末尾)
+func pointerAdd(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	return pointerUpdate(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			index := len(node)
+			if token != "-" {
+				var err error
+				if index, err = arrayIndex(token, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		}
+		return nil, errors.New("父节点不是对象或数组")
+	}, value)
+}
+
+// pointerReplace 替换路径处已存在的值
+func pointerReplace(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	return pointerUpdate(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			node[index] = value
+			return node, nil
+		}
+		return nil, errors.New("父节点不是对象或数组")
+	}, value)
+}
+
+// pointerRemove 删除路径处的值, 不能删除整个文档
+func pointerRemove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, errors.New("不能删除整个文档")
+	}
+	return pointerUpdate(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := node[token]; !ok {
+				return nil, fmt.Errorf("路径 %s 不存在", formatPointer(path))
+			}
+			delete(node, token)
+			return node, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(node[:index], node[index+1:]...), nil
+		}
+		return nil, errors.New("父节点不是对象或数组")
+	}, nil)
+}
+
+// pointerUpdate 定位路径的父节点并以 apply 修改, 返回修改后的文档; 数组长度变化时回写到祖父节点
+// 路径为空时以 root 替换整个文档
+func pointerUpdate(doc interface{}, path []string, apply func(parent interface{}, token string) (interface{}, error), root interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return root, nil
+	}
+	parentPath, token := path[:len(path)-1], path[len(path)-1]
+	parent, err := pointerGet(doc, parentPath)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := apply(parent, token)
+	if err != nil {
+		return nil, fmt.Errorf("路径 %s: %v", formatPointer(path), err)
+	}
+	if _, isArray := updated.([]interface{}); !isArray {
+		return doc, nil
+	}
+	return pointerReplaceNode(doc, parentPath, updated)
+}
+
+// pointerReplaceNode 将路径处的节点替换为 node, 路径已确认存在
+func pointerReplaceNode(doc interface{}, path []string, node interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return node, nil
+	}
+	parent, err := pointerGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	token := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		p[token] = node
+	case []interface{}:
+		index, err := arrayIndex(token, len(p)-1)
+		if err != nil {
+			return nil, err
+		}
+		p[index] = node
+	}
+	return doc, nil
+}
+
+// arrayIndex 解析数组下标, 不允许前导零, 须在 [0, max] 范围内
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("无效的数组下标 %q", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("无效的数组下标 %q", token)
+	}
+	if index > max {
+		return 0, fmt.Errorf("数组下标 %d 越界", index)
+	}
+	return index, nil
+}
+
+// formatPointer 将路径编码为 JSON Pointer
+func formatPointer(path []string) string {
+	var b strings.Builder
+	for _, token := range path {
+		b.WriteString("/")
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+// cloneJSON 深拷贝解析后的 JSON 值, copy 操作避免源和目标共享同一对象
+func cloneJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		cloned := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			cloned[key] = cloneJSON(child)
+		}
+		return cloned
+	case []interface{}:
+		cloned := make([]interface{}, len(v))
+		for i, child := range v {
+			cloned[i] = cloneJSON(child)
+		}
+		return cloned
+	}
+	return value
+}
+
+// jsonEqual 按 JSON 语义比较两个值: 数字按数值比较, 对象不区分键顺序
+func jsonEqual(a, b interface{}) bool {
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for key, value := range x {
+			other, ok := y[key]
+			if !ok || !jsonEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !jsonEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if x == y {
+			return true
+		}
+		fx, errX := x.Float64()
+		fy, errY := y.Float64()
+		return errX == nil && errY == nil && fx == fy
+	}
+	return a == b
+}
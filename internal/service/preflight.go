@@ -0,0 +1,442 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+)
+
+var (
+	ErrInvalidPreflightCheck = errors.New("未知的预检项")
+)
+
+// 预检项
+const (
+	PreflightSyntax      = "syntax"      // JSON/YAML 语法及 YAML 重复键、未定义锚点
+	PreflightSchema      = "schema"      // 生效的 Schema (自身或继承的默认 Schema)
+	PreflightReferences  = "references"  // ${env:VAR} 引用在目标环境中是否已定义
+	PreflightContracts   = "contracts"   // 消费契约
+	PreflightConsistency = "consistency" // 与基准环境对比缺失的配置和键
+	PreflightPipeline    = "pipeline"    // 发布流水线试运行
+	PreflightRisk        = "risk"        // 变更风险及审批要求
+)
+
+// preflightChecks 默认执行的全部预检项, 按执行顺序排列
+var preflightChecks = []string{
+	PreflightSyntax,
+	PreflightSchema,
+	PreflightReferences,
+	PreflightContracts,
+	PreflightConsistency,
+	PreflightPipeline,
+	PreflightRisk,
+}
+
+// 预检结果状态
+const (
+	PreflightPass = "pass"
+	PreflightWarn = "warn"
+	PreflightFail = "fail"
+)
+
+// PreflightOptions 预检选项
+type PreflightOptions struct {
+	Environment string   // 目标环境
+	Baseline    string   // 基准环境 (如 staging), 为空时跳过一致性检查
+	Checks      []string // 执行的预检项, 为空时执行全部
+	Strict      bool     // 为 true 时 warning 也视为不通过
+}
+
+// PreflightFinding 预检发现的问题
+type PreflightFinding struct {
+	Check    string `json:"check"`
+	Severity string `json:"severity"` // error, warning
+	Path     string `json:"path,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+}
+
+// PreflightConfigResult 单个配置的预检结果
+type PreflightConfigResult struct {
+	ConfigID  int64              `json:"config_id"`
+	Name      string             `json:"name"`
+	Namespace string             `json:"namespace"`
+	FileType  string             `json:"file_type"`
+	Version   int                `json:"version"`
+	RiskLevel string             `json:"risk_level,omitempty"`
+	Status    string             `json:"status"`
+	Findings  []PreflightFinding `json:"findings"`
+}
+
+// PreflightSummary 预检汇总
+type PreflightSummary struct {
+	Configs  int `json:"configs"`
+	Failed   int `json:"failed"`
+	Warned   int `json:"warned"`
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+}
+
+// PreflightReport 项目预检报告, 可作为发布前的 CI 门禁
+type PreflightReport struct {
+	ProjectID   int64                    `json:"project_id"`
+	Environment string                   `json:"environment"`
+	Baseline    string                   `json:"baseline,omitempty"`
+	Checks      []string                 `json:"checks"`
+	Strict      bool                     `json:"strict"`
+	Status      string                   `json:"status"`
+	Passed      bool                     `json:"passed"`
+	Summary     PreflightSummary         `json:"summary"`
+	Findings    []PreflightFinding       `json:"findings"` // 项目级问题, 如基准环境中存在而目标环境缺失的配置
+	Configs     []*PreflightConfigResult `json:"configs"`
+	GeneratedAt time.Time                `json:"generated_at"`
+}
+
+// PreflightService 项目预检服务, 一次调用对目标环境的全部配置执行发布前检查
+type PreflightService struct {
+	configRepo  *repository.ConfigRepository
+	projectRepo *repository.ProjectRepository
+	schemaSvc   *SchemaService
+	contractSvc *ContractService
+	envSvc      *EnvironmentService
+	envDiffSvc  *EnvDiffService
+	releaseSvc  *ReleaseService
+	parser      *Parser
+}
+
+// NewPreflightService 创建预检服务
+func NewPreflightService(configRepo *repository.ConfigRepository, projectRepo *repository.ProjectRepository, schemaSvc *SchemaService, contractSvc *ContractService, envSvc *EnvironmentService, envDiffSvc *EnvDiffService, releaseSvc *ReleaseService) *PreflightService {
+	return &PreflightService{
+		configRepo:  configRepo,
+		projectRepo: projectRepo,
+		schemaSvc:   schemaSvc,
+		contractSvc: contractSvc,
+		envSvc:      envSvc,
+		envDiffSvc:  envDiffSvc,
+		releaseSvc:  releaseSvc,
+		parser:      NewParser(),
+	}
+}
+
+// ParsePreflightChecks 解析逗号分隔的预检项, 为空时返回全部
+func ParsePreflightChecks(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return preflightChecks, nil
+	}
+	selected := map[string]bool{}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		known := false
+		for _, check := range preflightChecks {
+			if check == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPreflightCheck, name)
+		}
+		selected[name] = true
+	}
+
+	// 保持默认的执行顺序
+	checks := []string{}
+	for _, check := range preflightChecks {
+		if selected[check] {
+			checks = append(checks, check)
+		}
+	}
+	return checks, nil
+}
+
+// Run 对目标环境中每个配置的最新版本执行预检, 汇总为一份报告
+// 单个检查执行出错时记为该检查的 error, 不中断其他检查
+func (s *PreflightService) Run(ctx context.Context, projectID int64, opts PreflightOptions) (*PreflightReport, error) {
+	if _, err := s.projectRepo.GetByID(ctx, projectID); err != nil {
+		return nil, ErrProjectNotFound
+	}
+	if len(opts.Checks) == 0 {
+		opts.Checks = preflightChecks
+	}
+	enabled := make(map[string]bool, len(opts.Checks))
+	for _, check := range opts.Checks {
+		enabled[check] = true
+	}
+	if opts.Baseline == "" || opts.Baseline == opts.Environment {
+		delete(enabled, PreflightConsistency)
+	}
+
+	configs, err := s.configRepo.List(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &PreflightReport{
+		ProjectID:   projectID,
+		Environment: opts.Environment,
+		Baseline:    opts.Baseline,
+		Checks:      []string{},
+		Strict:      opts.Strict,
+		Findings:    []PreflightFinding{},
+		Configs:     []*PreflightConfigResult{},
+		GeneratedAt: time.Now(),
+	}
+	for _, check := range opts.Checks {
+		if enabled[check] {
+			report.Checks = append(report.Checks, check)
+		}
+	}
+
+	inTarget := map[string]bool{}
+	for _, config := range configs {
+		if config.Environment != opts.Environment {
+			continue
+		}
+		inTarget[config.Namespace+"/"+config.Name] = true
+		result := s.checkConfig(ctx, config, opts, enabled)
+		report.Configs = append(report.Configs, result)
+	}
+
+	if len(report.Configs) == 0 {
+		report.Findings = append(report.Findings, PreflightFinding{
+			Check:    PreflightConsistency,
+			Severity: IssueSeverityWarning,
+			Message:  fmt.Sprintf("环境 %s 中没有配置", opts.Environment),
+		})
+	}
+	if enabled[PreflightConsistency] {
+		for _, config := range configs {
+			if config.Environment != opts.Baseline || inTarget[config.Namespace+"/"+config.Name] {
+				continue
+			}
+			report.Findings = append(report.Findings, PreflightFinding{
+				Check:    PreflightConsistency,
+				Severity: IssueSeverityWarning,
+				Path:     config.Namespace + "/" + config.Name,
+				Message:  fmt.Sprintf("配置存在于 %s, 但 %s 中缺失", opts.Baseline, opts.Environment),
+			})
+		}
+	}
+
+	summarizePreflight(report)
+	return report, nil
+}
+
+// checkConfig 对单个配置执行启用的预检项
+func (s *PreflightService) checkConfig(ctx context.Context, config *model.Config, opts PreflightOptions, enabled map[string]bool) *PreflightConfigResult {
+	result := &PreflightConfigResult{
+		ConfigID:  config.ID,
+		Name:      config.Name,
+		Namespace: config.Namespace,
+		FileType:  config.FileType,
+		Version:   config.CurrentVersion,
+		Findings:  []PreflightFinding{},
+	}
+	add := func(check, severity, path, message string) {
+		result.Findings = append(result.Findings, PreflightFinding{Check: check, Severity: severity, Path: path, Message: message})
+	}
+	fail := func(check string, err error) {
+		add(check, IssueSeverityError, "", "检查失败: "+err.Error())
+	}
+
+	version, err := s.envSvc.GetConfigForEnv(ctx, config.ID, config.Environment)
+	if err != nil {
+		add(PreflightSyntax, IssueSeverityError, "", "配置没有可用的版本")
+		result.Status = PreflightFail
+		return result
+	}
+	content := version.Content
+	effective := s.envSvc.ResolveVariables(ctx, config, content)
+
+	syntaxOK := true
+	if enabled[PreflightSyntax] {
+		for _, finding := range s.checkSyntax(config.FileType, content) {
+			if finding.Severity == IssueSeverityError {
+				syntaxOK = false
+			}
+			result.Findings = append(result.Findings, finding)
+		}
+	}
+
+	if enabled[PreflightSchema] && syntaxOK {
+		errs, source, err := s.checkSchema(ctx, config, effective)
+		if err != nil {
+			fail(PreflightSchema, err)
+		}
+		for _, e := range errs {
+			add(PreflightSchema, IssueSeverityError, e.Field, fmt.Sprintf("%s (Schema 来源: %s)", e.Message, source))
+		}
+	}
+
+	if enabled[PreflightReferences] {
+		vars := s.envSvc.variables(ctx, config.ProjectID, config.Environment)
+		seen := map[string]bool{}
+		for _, match := range envVariablePattern.FindAllStringSubmatch(content, -1) {
+			name := match[1]
+			if _, ok := vars[name]; ok || seen[name] {
+				continue
+			}
+			seen[name] = true
+			add(PreflightReferences, IssueSeverityError, match[0], fmt.Sprintf("环境 %s 未定义变量 %s, 客户端将收到未解析的引用", config.Environment, name))
+		}
+	}
+
+	if enabled[PreflightContracts] && syntaxOK {
+		violations, err := s.contractSvc.Check(ctx, config, effective)
+		if err != nil {
+			fail(PreflightContracts, err)
+		}
+		for _, v := range violations {
+			severity := IssueSeverityWarning
+			if v.Enforcement == model.ContractEnforcementBlock {
+				severity = IssueSeverityError
+			}
+			message := fmt.Sprintf("%s 依赖的键类型由 %s 变为 %s", v.Consumer, v.Expected, v.Actual)
+			if v.Actual == "missing" {
+				message = fmt.Sprintf("%s 依赖的键被移除", v.Consumer)
+			}
+			add(PreflightContracts, severity, v.Path, message)
+		}
+	}
+
+	if enabled[PreflightConsistency] {
+		baseline, err := s.configRepo.GetByNameAndEnv(ctx, config.ProjectID, config.Name, config.Namespace, opts.Baseline)
+		if err == nil {
+			if baseline.FileType != config.FileType {
+				add(PreflightConsistency, IssueSeverityWarning, "", fmt.Sprintf("文件类型与 %s 不一致 (%s / %s)", opts.Baseline, baseline.FileType, config.FileType))
+			} else if syntaxOK {
+				comparison, err := s.envDiffSvc.Compare(ctx, baseline.ID, opts.Baseline, config.Environment, true)
+				if err != nil {
+					fail(PreflightConsistency, err)
+				} else {
+					for _, path := range comparison.OnlyInSource {
+						add(PreflightConsistency, IssueSeverityWarning, path, fmt.Sprintf("键存在于 %s, 但 %s 中缺失", opts.Baseline, config.Environment))
+					}
+				}
+			}
+		}
+	}
+
+	if enabled[PreflightPipeline] && syntaxOK {
+		if _, err := s.releaseSvc.pipelineSvc.Preview(ctx, config.ID, config.Environment, config.CurrentVersion, "preflight"); err != nil {
+			var transformErr *TransformError
+			if errors.As(err, &transformErr) {
+				add(PreflightPipeline, IssueSeverityError, "", transformErr.Error())
+			} else {
+				fail(PreflightPipeline, err)
+			}
+		}
+	}
+
+	if enabled[PreflightRisk] {
+		assessment, err := s.releaseSvc.assessRisk(ctx, config, config.Environment, config.CurrentVersion)
+		if err != nil {
+			fail(PreflightRisk, err)
+		} else {
+			result.RiskLevel = assessment.Level
+			if assessment.RequiredApprovals > 0 {
+				add(PreflightRisk, IssueSeverityWarning, "", fmt.Sprintf("风险等级 %s (%d 分), 发布需要 %d 人审批", assessment.Level, assessment.Score, assessment.RequiredApprovals))
+			}
+		}
+	}
+
+	result.Status = PreflightPass
+	for _, finding := range result.Findings {
+		if finding.Severity == IssueSeverityError {
+			result.Status = PreflightFail
+			break
+		}
+		result.Status = PreflightWarn
+	}
+	return result
+}
+
+// checkSyntax 检查 JSON/YAML 语法, YAML 同时检查重复键和锚点; 其他格式不检查
+func (s *PreflightService) checkSyntax(fileType, content string) []PreflightFinding {
+	var findings []PreflightFinding
+	switch fileType {
+	case "json", "hcl", "kv":
+		var data interface{}
+		if err := json.Unmarshal([]byte(content), &data); err != nil {
+			findings = append(findings, PreflightFinding{Check: PreflightSyntax, Severity: IssueSeverityError, Message: "无效的 JSON: " + err.Error()})
+		}
+	case "yaml":
+		for _, issue := range s.parser.LintYAML(content) {
+			findings = append(findings, PreflightFinding{Check: PreflightSyntax, Severity: issue.Severity, Line: issue.Line, Message: issue.Message})
+		}
+	}
+	return findings
+}
+
+// checkSchema 按生效的 Schema 校验内容, YAML 先转换为 JSON; 没有 Schema 或格式不支持时跳过
+func (s *PreflightService) checkSchema(ctx context.Context, config *model.Config, content string) ([]ValidationError, string, error) {
+	effective, err := s.schemaSvc.Effective(ctx, config.ID)
+	if err == ErrSchemaNotFound {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch config.FileType {
+	case "json", "hcl", "kv":
+	case "yaml":
+		parsed, err := s.parser.ParseYAML(content)
+		if err != nil {
+			return nil, "", nil
+		}
+		content = parsed.Content
+	default:
+		return nil, "", nil
+	}
+
+	result, err := s.schemaSvc.Validate(ctx, effective.Schema, content)
+	if err != nil {
+		return nil, "", err
+	}
+	return result.Errors, effective.Source, nil
+}
+
+// summarizePreflight 汇总问题数并计算整体状态, strict 模式下 warning 也视为不通过
+func summarizePreflight(report *PreflightReport) {
+	summary := PreflightSummary{Configs: len(report.Configs)}
+	count := func(findings []PreflightFinding) {
+		for _, f := range findings {
+			if f.Severity == IssueSeverityError {
+				summary.Errors++
+			} else {
+				summary.Warnings++
+			}
+		}
+	}
+	count(report.Findings)
+	for _, result := range report.Configs {
+		count(result.Findings)
+		switch result.Status {
+		case PreflightFail:
+			summary.Failed++
+		case PreflightWarn:
+			summary.Warned++
+		}
+	}
+
+	report.Summary = summary
+	switch {
+	case summary.Errors > 0:
+		report.Status = PreflightFail
+	case summary.Warnings > 0:
+		report.Status = PreflightWarn
+	default:
+		report.Status = PreflightPass
+	}
+	report.Passed = summary.Errors == 0 && (!report.Strict || summary.Warnings == 0)
+}
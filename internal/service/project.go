@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"time"
 
 	"confighub/internal/model"
 	"confighub/internal/repository"
@@ -16,22 +18,39 @@ var (
 	ErrProjectNotFound      = errors.New("项目不存在")
 	ErrProjectNameExists    = errors.New("项目名称已存在")
 	ErrProjectNameRequired  = errors.New("项目名称不能为空")
+	ErrProjectArchived      = errors.New("项目已归档")
+	ErrProjectNotArchived   = errors.New("项目未归档, 请先归档后再删除")
+	ErrProjectDeleteTooSoon = errors.New("项目归档未满宽限期, 暂不能删除")
+	ErrDecryptForbidden     = errors.New("无权使用此项目的数据密钥")
 )
 
 // ProjectService 项目服务
 type ProjectService struct {
 	projectRepo *repository.ProjectRepository
 	keyRepo     *repository.KeyRepository
+	notifySvc   *NotificationService
+	encryptSvc  *EncryptionService
+	cache       *ProjectCache
+	deleteGrace time.Duration // 归档后允许彻底删除前的宽限期
 }
 
 // NewProjectService 创建项目服务
-func NewProjectService(projectRepo *repository.ProjectRepository, keyRepo *repository.KeyRepository) *ProjectService {
+func NewProjectService(projectRepo *repository.ProjectRepository, keyRepo *repository.KeyRepository, notifySvc *NotificationService, encryptSvc *EncryptionService, deleteGrace time.Duration) *ProjectService {
 	return &ProjectService{
 		projectRepo: projectRepo,
 		keyRepo:     keyRepo,
+		notifySvc:   notifySvc,
+		encryptSvc:  encryptSvc,
+		cache:       NewProjectCache(projectRepo, projectCacheTTL),
+		deleteGrace: deleteGrace,
 	}
 }
 
+// GetCached 从项目缓存获取项目, 供公开接口的认证中间件使用
+func (s *ProjectService) GetCached(ctx context.Context, id int64) (*model.Project, error) {
+	return s.cache.Get(ctx, id)
+}
+
 // CreateProjectRequest 创建项目请求
 type CreateProjectRequest struct {
 	Name        string `json:"name" binding:"required"`
@@ -70,6 +89,14 @@ func (s *ProjectService) Create(ctx context.Context, req *CreateProjectRequest,
 	if gitBranch == "" {
 		gitBranch = "main"
 	}
+	if req.GitRepoURL != "" {
+		if err := ValidateGitRepoURL(req.GitRepoURL); err != nil {
+			return nil, nil, err
+		}
+		if err := ValidateGitBranch(ctx, gitBranch); err != nil {
+			return nil, nil, err
+		}
+	}
 
 	project := &model.Project{
 		Name:              req.Name,
@@ -113,6 +140,11 @@ func (s *ProjectService) createDefaultKey(ctx context.Context, projectID int64)
 	if err != nil {
 		return nil, err
 	}
+	// 与 KeyService 创建的密钥一致, 加密保存一份用于校验请求签名
+	secretEnc, err := s.encryptSvc.EncryptSecret(secretKey)
+	if err != nil {
+		return nil, err
+	}
 
 	perms, _ := json.Marshal(model.DefaultPermissions())
 
@@ -121,8 +153,10 @@ func (s *ProjectService) createDefaultKey(ctx context.Context, projectID int64)
 		Name:          "默认密钥",
 		AccessKey:     accessKey,
 		SecretKeyHash: string(secretHash),
+		SecretKeyEnc:  secretEnc,
 		Permissions:   string(perms),
 		IsActive:      true,
+		Priority:      model.KeyPriorityNormal,
 	}
 
 	if err := s.keyRepo.Create(ctx, key); err != nil {
@@ -131,13 +165,15 @@ func (s *ProjectService) createDefaultKey(ctx context.Context, projectID int64)
 
 	// 返回时包含明文 secret key (仅此一次)
 	return &model.ProjectKey{
-		ID:          key.ID,
-		ProjectID:   key.ProjectID,
-		Name:        key.Name,
-		AccessKey:   accessKey,
-		Permissions: key.Permissions,
-		IsActive:    key.IsActive,
-		CreatedAt:   key.CreatedAt,
+		ID:             key.ID,
+		ProjectID:      key.ProjectID,
+		Name:           key.Name,
+		AccessKey:      accessKey,
+		Permissions:    key.Permissions,
+		IsActive:       key.IsActive,
+		Priority:       key.Priority,
+		CreatedAt:      key.CreatedAt,
+		SignatureReady: true,
 	}, nil
 }
 
@@ -150,9 +186,9 @@ func (s *ProjectService) GetByID(ctx context.Context, id int64) (*model.Project,
 	return project, nil
 }
 
-// List 获取项目列表
-func (s *ProjectService) List(ctx context.Context, userID int64) ([]*model.Project, error) {
-	return s.projectRepo.List(ctx, userID)
+// List 获取项目列表, 默认不包含已归档项目
+func (s *ProjectService) List(ctx context.Context, userID int64, includeArchived bool) ([]*model.Project, error) {
+	return s.projectRepo.List(ctx, userID, includeArchived)
 }
 
 // UpdateProjectRequest 更新项目请求
@@ -162,6 +198,9 @@ type UpdateProjectRequest struct {
 	AccessMode  string `json:"access_mode"`
 	GitRepoURL  string `json:"git_repo_url"`
 	GitBranch   string `json:"git_branch"`
+
+	RejectQueryAccessKey *bool `json:"reject_query_access_key"` // 拒绝在查询参数中传递 Access Key
+	RequireSignature     *bool `json:"require_signature"`       // 要求 Access Key 请求带有 v2 签名并拒绝重放
 }
 
 // Update 更新项目
@@ -170,6 +209,9 @@ func (s *ProjectService) Update(ctx context.Context, id int64, req *UpdateProjec
 	if err != nil {
 		return ErrProjectNotFound
 	}
+	if project.IsArchived() {
+		return ErrProjectArchived
+	}
 
 	// 检查名称是否被其他项目使用
 	if req.Name != "" && req.Name != project.Name {
@@ -190,22 +232,149 @@ func (s *ProjectService) Update(ctx context.Context, id int64, req *UpdateProjec
 		project.AccessMode = req.AccessMode
 	}
 	if req.GitRepoURL != "" {
+		if err := ValidateGitRepoURL(req.GitRepoURL); err != nil {
+			return err
+		}
 		project.GitRepoURL = req.GitRepoURL
 	}
 	if req.GitBranch != "" {
+		if err := ValidateGitBranch(ctx, req.GitBranch); err != nil {
+			return err
+		}
 		project.GitBranch = req.GitBranch
 	}
+	if req.RejectQueryAccessKey != nil {
+		if err := setProjectSetting(project, projectSettingRejectQueryKey, *req.RejectQueryAccessKey); err != nil {
+			return err
+		}
+	}
+	if req.RequireSignature != nil {
+		if err := setProjectSetting(project, projectSettingRequireSignature, *req.RequireSignature); err != nil {
+			return err
+		}
+	}
 
 	return s.projectRepo.Update(ctx, project)
 }
 
-// Delete 删除项目
-func (s *ProjectService) Delete(ctx context.Context, id int64) error {
-	_, err := s.projectRepo.GetByID(ctx, id)
+// Archive 归档项目: 冻结写入和发布, 保留读取和历史
+func (s *ProjectService) Archive(ctx context.Context, id int64) (*model.Project, error) {
+	project, err := s.projectRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	if project.IsArchived() {
+		return project, nil
+	}
+
+	now := time.Now()
+	project.ArchivedAt = &now
+	if err := s.projectRepo.Update(ctx, project); err != nil {
+		return nil, err
+	}
+	// 归档后不再推送变更, 断开该项目的所有监听
+	s.notifySvc.RevokeProject(project.ID)
+	return project, nil
+}
+
+// Unarchive 取消归档, 恢复写入和发布
+func (s *ProjectService) Unarchive(ctx context.Context, id int64) (*model.Project, error) {
+	project, err := s.projectRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	if !project.IsArchived() {
+		return project, nil
+	}
+
+	project.ArchivedAt = nil
+	if err := s.projectRepo.Update(ctx, project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// Delete 删除项目, 仅允许删除已归档且超过宽限期的项目
+// dryRun 为 true 时只做同样的检查并返回将被删除的数据, 不删除
+func (s *ProjectService) Delete(ctx context.Context, id int64, dryRun bool) (*repository.DeleteImpact, error) {
+	project, err := s.projectRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	if !project.IsArchived() {
+		return nil, ErrProjectNotArchived
+	}
+	if deletableAt := project.ArchivedAt.Add(s.deleteGrace); time.Now().Before(deletableAt) {
+		return nil, fmt.Errorf("%w (可删除时间: %s)", ErrProjectDeleteTooSoon, deletableAt.Format(time.RFC3339))
+	}
+	if dryRun {
+		return s.projectRepo.DeleteImpact(ctx, id)
+	}
+	return nil, s.projectRepo.Delete(ctx, id)
+}
+
+// ListDataKeys 获取项目的数据加密密钥 (不含密钥材料)
+func (s *ProjectService) ListDataKeys(ctx context.Context, id int64) ([]*model.ProjectDataKey, error) {
+	if _, err := s.projectRepo.GetByID(ctx, id); err != nil {
+		return nil, ErrProjectNotFound
+	}
+	return s.encryptSvc.ListDataKeys(ctx, id)
+}
+
+// RotateDataKey 轮换项目的数据加密密钥, 之后加密的值使用新密钥
+func (s *ProjectService) RotateDataKey(ctx context.Context, id, userID int64) (*model.ProjectDataKey, error) {
+	if err := s.requireDecrypt(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	return s.encryptSvc.RotateDataKey(ctx, id)
+}
+
+// EncryptValue 使用项目当前的数据密钥加密值, 结果可直接写入配置; 加密不泄露已有的值, 有写权限即可调用
+func (s *ProjectService) EncryptValue(ctx context.Context, id, userID int64, value string) (string, error) {
+	if err := s.requirePermission(ctx, id, userID, func(p model.Permissions) bool { return p.Write }); err != nil {
+		return "", err
+	}
+	return s.encryptSvc.EncryptForProject(ctx, id, value)
+}
+
+// DecryptValue 解密项目中的单个加密值 (ENC: 开头), 不是加密值或无法解密时返回 ErrInvalidEncryptedValue
+func (s *ProjectService) DecryptValue(ctx context.Context, id, userID int64, value string) (string, error) {
+	if err := s.requireDecrypt(ctx, id, userID); err != nil {
+		return "", err
+	}
+	if !s.encryptSvc.IsEncrypted(value) {
+		return "", ErrInvalidEncryptedValue
+	}
+	plaintext, err := s.encryptSvc.DecryptValue(ctx, id, value)
+	if err != nil {
+		return "", ErrInvalidEncryptedValue
+	}
+	return plaintext, nil
+}
+
+// requireDecrypt 检查用户在项目中的角色是否有解密权限, 项目创建者视为管理员
+func (s *ProjectService) requireDecrypt(ctx context.Context, id, userID int64) error {
+	return s.requirePermission(ctx, id, userID, func(p model.Permissions) bool { return p.Decrypt })
+}
+
+// requirePermission 检查用户在项目中的角色是否满足 allowed, 项目创建者视为管理员
+// JWT 登录用户的权限不区分项目, 数据密钥相关的操作须按项目成员角色单独校验
+func (s *ProjectService) requirePermission(ctx context.Context, id, userID int64, allowed func(model.Permissions) bool) error {
+	project, err := s.projectRepo.GetByID(ctx, id)
 	if err != nil {
 		return ErrProjectNotFound
 	}
-	return s.projectRepo.Delete(ctx, id)
+	if project.CreatedBy == userID {
+		return nil
+	}
+	role, err := s.projectRepo.MemberRole(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if role == "" || !allowed(model.RolePermissions(role)) {
+		return ErrDecryptForbidden
+	}
+	return nil
 }
 
 // ListEnvironments 获取项目环境列表
@@ -222,3 +391,68 @@ func (s *ProjectService) CreateEnvironment(ctx context.Context, projectID int64,
 	}
 	return s.projectRepo.CreateEnvironment(ctx, env)
 }
+
+// projectSettingRejectQueryKey 项目设置: 拒绝查询参数中的 Access Key
+const projectSettingRejectQueryKey = "reject_query_access_key"
+
+// RejectsQueryAccessKey 项目是否拒绝在查询参数中传递 Access Key; 项目不存在时不拒绝, 由后续认证处理
+func (s *ProjectService) RejectsQueryAccessKey(ctx context.Context, projectID int64) bool {
+	project, err := s.cache.Get(ctx, projectID)
+	if err != nil {
+		return false
+	}
+	var reject bool
+	return projectSetting(project, projectSettingRejectQueryKey, &reject) && reject
+}
+
+// projectSettingRequireSignature 项目设置: 要求请求签名
+const projectSettingRequireSignature = "require_signature"
+
+// RequiresSignature 项目是否要求 Access Key 请求带有签名; 项目不存在时不要求, 由后续认证处理
+func (s *ProjectService) RequiresSignature(ctx context.Context, projectID int64) bool {
+	project, err := s.cache.Get(ctx, projectID)
+	if err != nil {
+		return false
+	}
+	var require bool
+	return projectSetting(project, projectSettingRequireSignature, &require) && require
+}
+
+// projectSetting 解析项目设置中的单个设置项, 未设置或无法解析时返回 false
+func projectSetting(project *model.Project, key string, out interface{}) bool {
+	if project.Settings == "" {
+		return false
+	}
+	var settings map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(project.Settings), &settings); err != nil {
+		return false
+	}
+	raw, ok := settings[key]
+	if !ok || string(raw) == "null" {
+		return false
+	}
+	return json.Unmarshal(raw, out) == nil
+}
+
+// setProjectSetting 写回项目设置中的单个设置项, 保留其他设置项
+func setProjectSetting(project *model.Project, key string, value interface{}) error {
+	settings := map[string]json.RawMessage{}
+	if project.Settings != "" {
+		if err := json.Unmarshal([]byte(project.Settings), &settings); err != nil {
+			settings = map[string]json.RawMessage{}
+		}
+	}
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	settings[key] = valueJSON
+
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	project.Settings = string(settingsJSON)
+	return nil
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+)
+
+var (
+	ErrInvalidProjectBundle  = errors.New("无效的项目导入包")
+	ErrInvalidConflictPolicy = errors.New("无效的冲突策略")
+)
+
+// 项目导出包的文件格式
+const (
+	ProjectBundleJSON = "json" // 单个 JSON 文件
+	ProjectBundleZip  = "zip"  // bundle.json 及按环境、命名空间展开的当前配置内容
+)
+
+// projectBundleEntry zip 包中完整导入包的文件名, 导入时只读取该文件
+const projectBundleEntry = "bundle.json"
+
+// MaxProjectBundleSize 导入包 (zip 解压后) 的最大字节数
+const MaxProjectBundleSize = 64 << 20
+
+// ProjectExportOptions 项目导出选项
+type ProjectExportOptions struct {
+	Format         string // json, zip
+	IncludeKeys    bool   // 包含访问密钥 (仅密钥哈希), 导入后客户端可沿用原密钥
+	IncludeHistory bool   // false 时仅导出当前版本及其发布记录
+	ExportedBy     string
+}
+
+// ProjectImportOptions 项目导入选项
+type ProjectImportOptions struct {
+	ConflictPolicy string // source_wins, target_wins, newer_wins, 默认 source_wins
+	IncludeKeys    bool   // 导入包中的访问密钥, 默认跳过
+	MergeSettings  bool   // 按顶层键合并包中的项目设置 (发布流水线、默认 Schema、多语言等)
+}
+
+// ProjectBundleService 项目导入导出服务, 复用跨实例复制的快照格式
+type ProjectBundleService struct {
+	repo        *repository.ReplicationRepository
+	projectRepo *repository.ProjectRepository
+	notifySvc   *NotificationService
+}
+
+// NewProjectBundleService 创建项目导入导出服务
+func NewProjectBundleService(repo *repository.ReplicationRepository, projectRepo *repository.ProjectRepository, notifySvc *NotificationService) *ProjectBundleService {
+	return &ProjectBundleService{
+		repo:        repo,
+		projectRepo: projectRepo,
+		notifySvc:   notifySvc,
+	}
+}
+
+// Export 导出项目的环境、配置、版本 (含 Schema) 和发布记录, 返回导出包及编码后的文件内容
+func (s *ProjectBundleService) Export(ctx context.Context, projectID int64, opts ProjectExportOptions) (*model.ProjectBundle, []byte, error) {
+	if opts.Format == "" {
+		opts.Format = ProjectBundleJSON
+	}
+	if opts.Format != ProjectBundleJSON && opts.Format != ProjectBundleZip {
+		return nil, nil, fmt.Errorf("%w: 不支持的格式 %q", ErrInvalidProjectBundle, opts.Format)
+	}
+
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, nil, ErrProjectNotFound
+	}
+	snapshot, err := s.repo.Snapshot(ctx, project.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !opts.IncludeKeys {
+		snapshot.Keys = []model.ReplicatedKey{}
+	}
+	// 导出包只带密钥哈希, 加密的 Secret Key 仅用于实例间复制; 导入的密钥需重新生成后才能用于签名校验
+	for i := range snapshot.Keys {
+		snapshot.Keys[i].SecretKeyEnc = ""
+	}
+	if !opts.IncludeHistory {
+		for i := range snapshot.Configs {
+			trimHistory(&snapshot.Configs[i])
+		}
+	}
+
+	bundle := &model.ProjectBundle{
+		Format:              model.ProjectBundleFormat,
+		ExportedAt:          snapshot.GeneratedAt,
+		ExportedBy:          opts.ExportedBy,
+		ReplicationSnapshot: *snapshot,
+	}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.Format == ProjectBundleJSON {
+		return bundle, data, nil
+	}
+
+	archive, err := zipProjectBundle(bundle, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bundle, archive, nil
+}
+
+// trimHistory 仅保留配置的当前版本及其发布记录
+func trimHistory(rc *model.ReplicatedConfig) {
+	versions := []model.ReplicatedVersion{}
+	for _, v := range rc.Versions {
+		if v.Version == rc.CurrentVersion {
+			versions = append(versions, v)
+		}
+	}
+	releases := []model.ReplicatedRelease{}
+	for _, r := range rc.Releases {
+		if r.Version == rc.CurrentVersion {
+			releases = append(releases, r)
+		}
+	}
+	rc.Versions = versions
+	rc.Releases = releases
+}
+
+// zipProjectBundle 打包 bundle.json, 并按 configs/<环境>/<命名空间>/<配置名> 写入当前版本内容便于查阅
+func zipProjectBundle(bundle *model.ProjectBundle, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+
+	write := func(name string, content []byte) error {
+		w, err := writer.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: bundle.ExportedAt})
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(content)
+		return err
+	}
+
+	if err := write(projectBundleEntry, data); err != nil {
+		return nil, err
+	}
+	for _, rc := range bundle.Configs {
+		for _, v := range rc.Versions {
+			if v.Version != rc.CurrentVersion {
+				continue
+			}
+			name := rc.Name
+			if path.Ext(name) == "" && rc.FileType != "" {
+				name += "." + rc.FileType
+			}
+			if err := write(path.Join("configs", rc.Environment, rc.Namespace, name), []byte(v.Content)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseProjectBundle 解析导入包, 支持单个 JSON 文件或包含 bundle.json 的 zip
+func ParseProjectBundle(data []byte) (*model.ProjectBundle, error) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidProjectBundle, err)
+		}
+		data = nil
+		for _, file := range reader.File {
+			if file.Name != projectBundleEntry {
+				continue
+			}
+			rc, err := file.Open()
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidProjectBundle, err)
+			}
+			data, err = io.ReadAll(io.LimitReader(rc, MaxProjectBundleSize+1))
+			rc.Close()
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidProjectBundle, err)
+			}
+			if len(data) > MaxProjectBundleSize {
+				return nil, fmt.Errorf("%w: %s 超过大小限制", ErrInvalidProjectBundle, projectBundleEntry)
+			}
+			break
+		}
+		if data == nil {
+			return nil, fmt.Errorf("%w: zip 中缺少 %s", ErrInvalidProjectBundle, projectBundleEntry)
+		}
+	}
+
+	var bundle model.ProjectBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProjectBundle, err)
+	}
+	if bundle.Format != model.ProjectBundleFormat {
+		return nil, fmt.Errorf("%w: 不支持的格式 %q", ErrInvalidProjectBundle, bundle.Format)
+	}
+	if err := validateProjectBundle(&bundle); err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+// validateProjectBundle 校验导入包的必填字段, 避免写入无法定位的配置
+func validateProjectBundle(bundle *model.ProjectBundle) error {
+	for _, env := range bundle.Environments {
+		if strings.TrimSpace(env.Name) == "" {
+			return fmt.Errorf("%w: 环境名称为空", ErrInvalidProjectBundle)
+		}
+	}
+	for _, key := range bundle.Keys {
+		if key.AccessKey == "" || key.SecretKeyHash == "" {
+			return fmt.Errorf("%w: 访问密钥 %q 缺少 access_key 或 secret_key_hash", ErrInvalidProjectBundle, key.Name)
+		}
+	}
+	for _, rc := range bundle.Configs {
+		if rc.Name == "" || rc.Namespace == "" || rc.Environment == "" {
+			return fmt.Errorf("%w: 配置缺少名称、命名空间或环境", ErrInvalidProjectBundle)
+		}
+		for _, v := range rc.Versions {
+			if v.Version <= 0 {
+				return fmt.Errorf("%w: 配置 %s/%s@%s 的版本号无效", ErrInvalidProjectBundle, rc.Namespace, rc.Name, rc.Environment)
+			}
+		}
+	}
+	return nil
+}
+
+// Import 将导入包应用到已有项目, 并通知监听方配置已变化
+// 按名称匹配环境和配置; 同一版本号内容不一致时按冲突策略处理并在结果中记录
+func (s *ProjectBundleService) Import(ctx context.Context, projectID int64, data []byte, opts ProjectImportOptions) (*model.ReplicationResult, error) {
+	switch opts.ConflictPolicy {
+	case "":
+		opts.ConflictPolicy = model.ReplicationSourceWins
+	case model.ReplicationSourceWins, model.ReplicationTargetWins, model.ReplicationNewerWins:
+	default:
+		return nil, ErrInvalidConflictPolicy
+	}
+
+	bundle, err := ParseProjectBundle(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.projectRepo.GetByID(ctx, projectID); err != nil {
+		return nil, ErrProjectNotFound
+	}
+	if !opts.IncludeKeys {
+		bundle.Keys = nil
+	}
+
+	result, err := s.repo.Import(ctx, projectID, &bundle.ReplicationSnapshot, opts.ConflictPolicy, opts.MergeSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, configID := range result.ChangedConfigs {
+		s.notifySvc.NotifyChange(ctx, &ConfigChange{
+			ConfigID:   configID,
+			ChangeType: "import",
+		})
+	}
+	return result, nil
+}
+
+// ProjectBundleFilename 导出包的下载文件名, 如 confighub-demo-20240101.bundle.json
+func ProjectBundleFilename(project string, exportedAt time.Time, format string) string {
+	name := fmt.Sprintf("confighub-%s-%s", project, exportedAt.Format("20060102"))
+	if format == ProjectBundleZip {
+		return name + ".zip"
+	}
+	return name + ".bundle.json"
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+)
+
+// projectCacheTTL 项目缓存条目的有效期
+// 本实例通过 ProjectRepository 更新或删除项目时立即失效; 其他实例及直接改写项目行的操作 (复制同步、所有权转移、环境迁移) 最迟在有效期后生效
+const projectCacheTTL = 30 * time.Second
+
+// ProjectCache 公开接口认证路径的项目缓存
+// 每个公开接口请求都要读取项目的访问模式、匿名权限和项目设置 (签名要求、拒绝查询参数中的 Access Key),
+// 访问模式和项目设置以同一条缓存记录读取, 避免每个请求重复查询项目
+type ProjectCache struct {
+	repo *repository.ProjectRepository
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[int64]*projectCacheEntry
+	gen     map[int64]uint64 // 项目 ID -> 失效次数, 避免加载期间发生的更新被旧数据覆盖
+}
+
+// projectCacheEntry 缓存的项目
+type projectCacheEntry struct {
+	project  *model.Project
+	loadedAt time.Time
+}
+
+// NewProjectCache 创建项目缓存, 并在项目更新或删除时失效对应条目
+func NewProjectCache(repo *repository.ProjectRepository, ttl time.Duration) *ProjectCache {
+	c := &ProjectCache{
+		repo:    repo,
+		ttl:     ttl,
+		entries: make(map[int64]*projectCacheEntry),
+		gen:     make(map[int64]uint64),
+	}
+	repo.OnChange(c.Invalidate)
+	return c
+}
+
+// Get 获取项目, 未命中或已过期时从数据库读取; 返回副本, 调用方可以修改
+func (c *ProjectCache) Get(ctx context.Context, projectID int64) (*model.Project, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[projectID]
+	gen := c.gen[projectID]
+	c.mu.Unlock()
+	if ok && time.Since(entry.loadedAt) < c.ttl {
+		project := *entry.project
+		return &project, nil
+	}
+
+	project, err := c.repo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	cached := *project
+	c.mu.Lock()
+	if c.gen[projectID] == gen {
+		c.entries[projectID] = &projectCacheEntry{project: &cached, loadedAt: time.Now()}
+	}
+	c.mu.Unlock()
+	return project, nil
+}
+
+// Invalidate 删除项目的缓存条目
+func (c *ProjectCache) Invalidate(projectID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, projectID)
+	c.gen[projectID]++
+}
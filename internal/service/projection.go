@@ -0,0 +1,68 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrInvalidPath  = errors.New("无效的配置路径")
+	ErrPathNotFound = errors.New("配置路径不存在")
+)
+
+// ParseContentPath 解析按路径读取配置时的路径: 以 / 开头时为 JSON Pointer (RFC 6901),
+// 否则为以 . 分隔的路径, 数组元素以下标表示 (如 db.hosts.0); 键名含 . 时须使用 JSON Pointer
+func ParseContentPath(path string) ([]string, error) {
+	if strings.HasPrefix(path, "/") {
+		tokens, err := parsePointer(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPath, err)
+		}
+		return tokens, nil
+	}
+	tokens := strings.Split(path, ".")
+	for _, token := range tokens {
+		if token == "" {
+			return nil, fmt.Errorf("%w: 路径 %q 包含空的片段", ErrInvalidPath, path)
+		}
+	}
+	return tokens, nil
+}
+
+// ProjectContent 返回配置内容中路径指向的子树, 以 JSON 编码 (字符串值带引号)
+// 支持 JSON、kv、HCL (以 JSON 保存) 和 YAML 配置
+func ProjectContent(fileType, content string, path []string) (string, error) {
+	doc, err := decodeContent(fileType, content)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPath, err)
+	}
+
+	value, err := pointerGet(doc, path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrPathNotFound, err)
+	}
+	return encodeJSON(value)
+}
+
+// decodeContent 将结构化配置内容解析为 JSON 值, 数字保留为 json.Number
+func decodeContent(fileType, content string) (interface{}, error) {
+	switch fileType {
+	case "json", "kv", "hcl":
+		value, err := decodeJSON([]byte(content))
+		if err != nil {
+			return nil, errors.New("配置内容不是有效的 JSON")
+		}
+		return value, nil
+	case "yaml":
+		var value interface{}
+		if err := yaml.Unmarshal([]byte(content), &value); err != nil {
+			return nil, errors.New("配置内容不是有效的 YAML")
+		}
+		return convertYAMLToJSON(value), nil
+	default:
+		return nil, fmt.Errorf("%s 类型的配置不是结构化内容", fileType)
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"confighub/internal/model"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// readCacheKeyPrefix Redis 中读取缓存的键前缀
+const readCacheKeyPrefix = "confighub:read:"
+
+// ConfigReadCache 公开读取路径的 Redis 读穿缓存
+// 以 (项目, 命名空间, 环境, 名称) 为键缓存配置和下发版本 (已合并父配置), 多个实例共享, 进程重启后仍然有效,
+// 避免客户端集中重启时每个请求都访问数据库; 条目由通知总线按配置 ID 删除, 其他实例上的写入最迟在 TTL 后生效
+// Redis 未连接或 ttl 不大于 0 时不缓存, Redis 出错时直接读取数据库
+type ConfigReadCache struct {
+	rdb *redis.Client
+	ttl time.Duration
+
+	mu  sync.Mutex
+	gen map[int64]uint64 // 配置 ID -> 失效次数, 避免加载期间发生的变更被旧数据覆盖
+
+	hits   uint64
+	misses uint64
+	errors uint64
+}
+
+// ReadCacheStats 读取缓存统计
+type ReadCacheStats struct {
+	Enabled bool   `json:"enabled"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Errors  uint64 `json:"errors"` // Redis 读写失败的次数
+}
+
+// readCacheEntry 缓存的读取结果
+type readCacheEntry struct {
+	Config  *model.Config        `json:"config"`
+	Version *model.ConfigVersion `json:"version,omitempty"` // 没有任何版本时为空
+}
+
+// NewConfigReadCache 创建读取缓存并订阅变更通知, rdb 为 nil 或 ttl 不大于 0 时不缓存
+func NewConfigReadCache(rdb *redis.Client, notifySvc *NotificationService, ttl time.Duration) *ConfigReadCache {
+	c := &ConfigReadCache{
+		rdb: rdb,
+		ttl: ttl,
+		gen: make(map[int64]uint64),
+	}
+	if c.Enabled() {
+		notifySvc.OnChange(func(change *ConfigChange) {
+			c.Invalidate(context.Background(), change.ConfigID)
+		})
+	}
+	return c
+}
+
+// Enabled 是否启用缓存
+func (c *ConfigReadCache) Enabled() bool {
+	return c != nil && c.rdb != nil && c.ttl > 0
+}
+
+// Get 获取缓存的配置和下发版本, 未命中或出错时返回 false
+func (c *ConfigReadCache) Get(ctx context.Context, projectID int64, namespace, env, name string) (*model.Config, *model.ConfigVersion, bool) {
+	if !c.Enabled() {
+		return nil, nil, false
+	}
+	data, err := c.rdb.Get(ctx, readCacheKey(projectID, namespace, env, name)).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			atomic.AddUint64(&c.errors, 1)
+		}
+		atomic.AddUint64(&c.misses, 1)
+		return nil, nil, false
+	}
+	var entry readCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Config == nil {
+		atomic.AddUint64(&c.misses, 1)
+		return nil, nil, false
+	}
+	atomic.AddUint64(&c.hits, 1)
+	return entry.Config, entry.Version, true
+}
+
+// Generation 配置当前的失效次数, 加载前获取并传给 Set
+func (c *ConfigReadCache) Generation(configID int64) uint64 {
+	if !c.Enabled() {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen[configID]
+}
+
+// Set 放入缓存, 同时记录配置 ID 到缓存键的索引以便失效; 加载期间本实例发生过失效时丢弃
+func (c *ConfigReadCache) Set(ctx context.Context, config *model.Config, version *model.ConfigVersion, gen uint64) {
+	if !c.Enabled() || c.Generation(config.ID) != gen {
+		return
+	}
+	data, err := json.Marshal(&readCacheEntry{Config: config, Version: version})
+	if err != nil {
+		return
+	}
+	key := readCacheKey(config.ProjectID, config.Namespace, config.Environment, config.Name)
+	pipe := c.rdb.TxPipeline()
+	pipe.Set(ctx, key, data, c.ttl)
+	pipe.Set(ctx, readCacheIndexKey(config.ID), key, c.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		atomic.AddUint64(&c.errors, 1)
+	}
+}
+
+// Invalidate 删除配置对应的缓存条目
+func (c *ConfigReadCache) Invalidate(ctx context.Context, configID int64) {
+	if !c.Enabled() {
+		return
+	}
+	c.mu.Lock()
+	c.gen[configID]++
+	c.mu.Unlock()
+
+	index := readCacheIndexKey(configID)
+	key, err := c.rdb.Get(ctx, index).Result()
+	if err == redis.Nil {
+		return
+	}
+	if err != nil {
+		atomic.AddUint64(&c.errors, 1)
+		return
+	}
+	if err := c.rdb.Del(ctx, key, index).Err(); err != nil {
+		atomic.AddUint64(&c.errors, 1)
+	}
+}
+
+// Stats 获取缓存统计
+func (c *ConfigReadCache) Stats() ReadCacheStats {
+	if c == nil {
+		return ReadCacheStats{}
+	}
+	return ReadCacheStats{
+		Enabled: c.Enabled(),
+		Hits:    atomic.LoadUint64(&c.hits),
+		Misses:  atomic.LoadUint64(&c.misses),
+		Errors:  atomic.LoadUint64(&c.errors),
+	}
+}
+
+// readCacheKey 缓存键
+func readCacheKey(projectID int64, namespace, env, name string) string {
+	return readCacheKeyPrefix + strconv.FormatInt(projectID, 10) + "/" + namespace + "/" + env + "/" + name
+}
+
+// readCacheIndexKey 配置 ID 到缓存键的索引
+func readCacheIndexKey(configID int64) string {
+	return readCacheKeyPrefix + "config:" + strconv.FormatInt(configID, 10)
+}
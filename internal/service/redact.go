@@ -0,0 +1,58 @@
+package service
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// redactedValue 替换凭据的占位值
+const redactedValue = "REDACTED"
+
+// credentialParams 携带凭据的查询参数和 JSON 字段名
+var credentialParams = map[string]bool{
+	"access_key":  true,
+	"secret_key":  true,
+	"signature":   true,
+	"watch_token": true,
+	"token":       true,
+	"password":    true,
+	"secret":      true,
+}
+
+var (
+	credentialQueryPattern = regexp.MustCompile(`(?i)\b(access_key|secret_key|signature|watch_token|token|password|secret)=([^&\s"'<>]+)`)
+	credentialJSONPattern  = regexp.MustCompile(`(?i)"(access_key|secret_key|signature|watch_token|token|password|secret)"(\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	urlUserinfoPattern     = regexp.MustCompile(`://([^/@:\s]+):[^/@\s]+@`)
+	secretKeyPattern       = regexp.MustCompile(`\bsk_[0-9a-fA-F-]{8,}`)
+)
+
+// RedactQuery 隐去查询字符串中携带凭据的参数值, 保留参数顺序
+func RedactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+	pairs := strings.Split(rawQuery, "&")
+	for i, pair := range pairs {
+		name, _, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		if key, err := url.QueryUnescape(name); err == nil && credentialParams[strings.ToLower(key)] {
+			pairs[i] = name + "=" + redactedValue
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// RedactCredentials 隐去文本中的凭据: 查询参数、JSON 字段、URL 中的密码和 Secret Key
+// 用于写入日志、审计记录和错误信息前的清理
+func RedactCredentials(s string) string {
+	if s == "" {
+		return s
+	}
+	s = credentialQueryPattern.ReplaceAllString(s, "${1}="+redactedValue)
+	s = credentialJSONPattern.ReplaceAllString(s, `"${1}"${2}"`+redactedValue+`"`)
+	s = urlUserinfoPattern.ReplaceAllString(s, "://${1}:"+redactedValue+"@")
+	return secretKeyPattern.ReplaceAllString(s, "sk_"+redactedValue)
+}
@@ -6,6 +6,9 @@ import (
 
 	"confighub/internal/model"
 	"confighub/internal/repository"
+	"confighub/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
@@ -16,23 +19,42 @@ var (
 type ReleaseService struct {
 	releaseRepo *repository.ReleaseRepository
 	configRepo  *repository.ConfigRepository
-	versionRepo *repository.VersionRepository
+	versionRepo repository.VersionStore
+	projectRepo *repository.ProjectRepository
+	notifySvc   *NotificationService
+	contractSvc *ContractService
+	schemaSvc   *SchemaService
+	pipelineSvc *ReleasePipelineService
+	diffSvc     *DiffService
 }
 
 // NewReleaseService 创建发布服务
-func NewReleaseService(releaseRepo *repository.ReleaseRepository, configRepo *repository.ConfigRepository, versionRepo *repository.VersionRepository) *ReleaseService {
+func NewReleaseService(releaseRepo *repository.ReleaseRepository, configRepo *repository.ConfigRepository, versionRepo repository.VersionStore, projectRepo *repository.ProjectRepository, notifySvc *NotificationService, contractSvc *ContractService, schemaSvc *SchemaService, pipelineSvc *ReleasePipelineService) *ReleaseService {
 	return &ReleaseService{
 		releaseRepo: releaseRepo,
 		configRepo:  configRepo,
 		versionRepo: versionRepo,
+		projectRepo: projectRepo,
+		notifySvc:   notifySvc,
+		contractSvc: contractSvc,
+		schemaSvc:   schemaSvc,
+		pipelineSvc: pipelineSvc,
+		diffSvc:     NewDiffService(),
 	}
 }
 
 // Create 创建发布
-func (s *ReleaseService) Create(ctx context.Context, configID int64, env string, version int, author string) (*model.Release, error) {
+// 违反项目发布护栏时返回 GuardrailViolationError; override 为 true 时仍然发布, 并返回被覆盖的违规项供审计
+// 发布附带风险评估, 按项目风险策略需要审批时进入待审批状态
+// 发布的版本破坏 block 级别的消费方契约时返回 ContractViolationError, 配置的 Schema 校验策略为 block 且不符合时返回 SchemaValidationError
+// 项目配置了发布流水线时生成发布产物, 任一步骤失败返回 TransformError
+func (s *ReleaseService) Create(ctx context.Context, configID int64, env string, version int, author string, override bool) (*model.Release, []GuardrailViolation, error) {
+	ctx, span := tracing.Start(ctx, "ReleaseService.Create", attribute.Int64("config.id", configID), attribute.String("config.env", env))
+	defer span.End()
+
 	config, err := s.configRepo.GetByID(ctx, configID)
 	if err != nil {
-		return nil, ErrConfigNotFound
+		return nil, nil, ErrConfigNotFound
 	}
 
 	// 如果未指定版本，使用当前版本
@@ -41,9 +63,28 @@ func (s *ReleaseService) Create(ctx context.Context, configID int64, env string,
 	}
 
 	// 验证版本存在
-	_, err = s.versionRepo.GetByConfigAndVersion(ctx, configID, version)
+	target, err := s.versionRepo.GetByConfigAndVersion(ctx, configID, version)
+	if err != nil {
+		return nil, nil, ErrVersionNotFound
+	}
+	if err := s.schemaSvc.Enforce(ctx, config, target.Content); err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.contractSvc.Enforce(ctx, config, target.Content); err != nil {
+		return nil, nil, err
+	}
+
+	violations, err := s.checkGuardrails(ctx, config, env)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(violations) > 0 && !override {
+		return nil, nil, &GuardrailViolationError{Violations: violations}
+	}
+
+	assessment, err := s.assessRisk(ctx, config, env, version)
 	if err != nil {
-		return nil, ErrVersionNotFound
+		return nil, nil, err
 	}
 
 	release := &model.Release{
@@ -55,12 +96,20 @@ func (s *ReleaseService) Create(ctx context.Context, configID int64, env string,
 		ReleaseType: "full",
 		ReleasedBy:  author,
 	}
+	applyRisk(release, assessment)
+	if err := s.pipelineSvc.Apply(ctx, release); err != nil {
+		return nil, nil, err
+	}
 
 	if err := s.releaseRepo.Create(ctx, release); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return release, nil
+	// 需要审批的发布在审批通过后才生效
+	if release.Status == "released" {
+		notifyRelease(ctx, s.notifySvc, release, "release")
+	}
+	return release, violations, nil
 }
 
 // List 获取发布历史
@@ -68,8 +117,20 @@ func (s *ReleaseService) List(ctx context.Context, configID int64) ([]*model.Rel
 	return s.releaseRepo.List(ctx, configID)
 }
 
+// GetByID 获取发布记录
+func (s *ReleaseService) GetByID(ctx context.Context, releaseID int64) (*model.Release, error) {
+	release, err := s.releaseRepo.GetByID(ctx, releaseID)
+	if err != nil {
+		return nil, ErrReleaseNotFound
+	}
+	return release, nil
+}
+
 // Rollback 回滚发布
 func (s *ReleaseService) Rollback(ctx context.Context, releaseID int64, author string) (*model.Release, error) {
+	ctx, span := tracing.Start(ctx, "ReleaseService.Rollback", attribute.Int64("release.id", releaseID))
+	defer span.End()
+
 	release, err := s.releaseRepo.GetByID(ctx, releaseID)
 	if err != nil {
 		return nil, ErrReleaseNotFound
@@ -110,19 +171,44 @@ func (s *ReleaseService) Rollback(ctx context.Context, releaseID int64, author s
 		ReleaseType: "full",
 		ReleasedBy:  author,
 	}
+	if err := s.pipelineSvc.Apply(ctx, newRelease); err != nil {
+		return nil, err
+	}
 
 	if err := s.releaseRepo.Create(ctx, newRelease); err != nil {
 		return nil, err
 	}
 
+	notifyRelease(ctx, s.notifySvc, newRelease, "rollback")
 	return newRelease, nil
 }
 
+// notifyRelease 发布状态变化会改变下发的版本或发布元数据, 通知监听方及缓存
+func notifyRelease(ctx context.Context, notifySvc *NotificationService, release *model.Release, changeType string) {
+	notifySvc.NotifyChange(ctx, &ConfigChange{
+		ConfigID:   release.ConfigID,
+		Env:        release.Environment,
+		Version:    release.Version,
+		ChangeType: changeType,
+		ReleaseID:  release.ID,
+	})
+}
+
 // GetByEnv 获取指定环境的当前发布
 func (s *ReleaseService) GetByEnv(ctx context.Context, configID int64, env string) (*model.Release, error) {
 	return s.releaseRepo.GetByConfigAndEnv(ctx, configID, env)
 }
 
+// GetLatestReleased 获取指定环境的最新正式发布
+func (s *ReleaseService) GetLatestReleased(ctx context.Context, configID int64, env string) (*model.Release, error) {
+	return s.releaseRepo.GetLatestReleased(ctx, configID, env)
+}
+
+// ListRecentlyReleasedConfigIDs 获取最近发布过的配置 ID (按发布时间倒序)
+func (s *ReleaseService) ListRecentlyReleasedConfigIDs(ctx context.Context, limit int) ([]int64, error) {
+	return s.releaseRepo.ListRecentlyReleasedConfigIDs(ctx, limit)
+}
+
 // ListEnvironments 获取项目环境列表
 func (s *ReleaseService) ListEnvironments(ctx context.Context, projectID int64) ([]string, error) {
 	// 返回默认环境列表
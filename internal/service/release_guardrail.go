@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"confighub/internal/model"
+)
+
+var (
+	ErrInvalidReleaseGuardrails = errors.New("无效的发布护栏配置")
+)
+
+// 发布护栏规则
+const (
+	GuardrailMaxReleasesPerHour = "max_releases_per_hour"
+	GuardrailMinBakeTime        = "min_bake_time"
+)
+
+// ReleaseGuardrails 项目级发布频率护栏, 保存在项目设置的 release_guardrails 中
+// 仅约束正式发布; 回滚用于止损, 不受护栏限制
+type ReleaseGuardrails struct {
+	Environments       []string `json:"environments"`          // 生效的环境, 为空时对所有环境生效
+	MaxReleasesPerHour int      `json:"max_releases_per_hour"` // 项目在单个环境内最近一小时最多发布次数, 0 表示不限制
+	MinBakeMinutes     int      `json:"min_bake_minutes"`      // 同一配置在同一环境两次发布的最小间隔 (分钟), 0 表示不限制
+}
+
+// GuardrailViolation 违反的护栏规则
+type GuardrailViolation struct {
+	Rule    string    `json:"rule"`
+	Message string    `json:"message"`
+	RetryAt time.Time `json:"retry_at"` // 最早可再次发布的时间
+}
+
+// GuardrailViolationError 发布违反护栏, 具备覆盖权限的用户可附带原因强制发布
+type GuardrailViolationError struct {
+	Violations []GuardrailViolation
+}
+
+func (e *GuardrailViolationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return "发布违反护栏: " + strings.Join(messages, "; ")
+}
+
+// RetryAt 所有规则均满足的最早时间
+func (e *GuardrailViolationError) RetryAt() time.Time {
+	var retryAt time.Time
+	for _, v := range e.Violations {
+		if v.RetryAt.After(retryAt) {
+			retryAt = v.RetryAt
+		}
+	}
+	return retryAt
+}
+
+// Validate 校验护栏配置
+func (g *ReleaseGuardrails) Validate() error {
+	if g.MaxReleasesPerHour < 0 || g.MinBakeMinutes < 0 {
+		return ErrInvalidReleaseGuardrails
+	}
+	for _, env := range g.Environments {
+		if strings.TrimSpace(env) == "" {
+			return ErrInvalidReleaseGuardrails
+		}
+	}
+	return nil
+}
+
+// applies 护栏是否对环境生效
+func (g *ReleaseGuardrails) applies(env string) bool {
+	if g.MaxReleasesPerHour == 0 && g.MinBakeMinutes == 0 {
+		return false
+	}
+	if len(g.Environments) == 0 {
+		return true
+	}
+	for _, e := range g.Environments {
+		if e == env {
+			return true
+		}
+	}
+	return false
+}
+
+// projectGuardrails 解析项目设置中的发布护栏, 未设置时返回空护栏 (不限制)
+func projectGuardrails(project *model.Project) *ReleaseGuardrails {
+	guardrails := &ReleaseGuardrails{}
+	if !projectSetting(project, "release_guardrails", guardrails) {
+		guardrails = &ReleaseGuardrails{}
+	}
+	if guardrails.Environments == nil {
+		guardrails.Environments = []string{}
+	}
+	return guardrails
+}
+
+// GetGuardrails 获取项目的发布护栏
+func (s *ReleaseService) GetGuardrails(ctx context.Context, projectID int64) (*ReleaseGuardrails, error) {
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	return projectGuardrails(project), nil
+}
+
+// UpdateGuardrails 更新项目的发布护栏
+func (s *ReleaseService) UpdateGuardrails(ctx context.Context, projectID int64, guardrails *ReleaseGuardrails) error {
+	if err := guardrails.Validate(); err != nil {
+		return err
+	}
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return ErrProjectNotFound
+	}
+
+	if guardrails.Environments == nil {
+		guardrails.Environments = []string{}
+	}
+	if err := setProjectSetting(project, "release_guardrails", guardrails); err != nil {
+		return err
+	}
+	return s.projectRepo.Update(ctx, project)
+}
+
+// checkGuardrails 检查在环境中发布配置是否违反项目护栏
+func (s *ReleaseService) checkGuardrails(ctx context.Context, config *model.Config, env string) ([]GuardrailViolation, error) {
+	project, err := s.projectRepo.GetByID(ctx, config.ProjectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	guardrails := projectGuardrails(project)
+	if !guardrails.applies(env) {
+		return nil, nil
+	}
+
+	now := time.Now()
+	var violations []GuardrailViolation
+
+	if guardrails.MaxReleasesPerHour > 0 {
+		releases, err := s.releaseRepo.ListInWindow(ctx, config.ProjectID, env, now.Add(-time.Hour), now)
+		if err != nil {
+			return nil, err
+		}
+		var full []*model.Release
+		for _, r := range releases {
+			if r.ReleaseType == "full" {
+				full = append(full, r)
+			}
+		}
+		if len(full) >= guardrails.MaxReleasesPerHour {
+			// 窗口内的发布按时间升序, 最早的若干条滑出窗口后才能再次发布
+			oldest := full[len(full)-guardrails.MaxReleasesPerHour]
+			violations = append(violations, GuardrailViolation{
+				Rule:    GuardrailMaxReleasesPerHour,
+				Message: fmt.Sprintf("环境 %s 最近一小时已发布 %d 次, 上限为 %d 次", env, len(full), guardrails.MaxReleasesPerHour),
+				RetryAt: oldest.ReleasedAt.Add(time.Hour),
+			})
+		}
+	}
+
+	if guardrails.MinBakeMinutes > 0 {
+		bake := time.Duration(guardrails.MinBakeMinutes) * time.Minute
+		if last, err := s.releaseRepo.GetLatestReleased(ctx, config.ID, env); err == nil && now.Sub(last.ReleasedAt) < bake {
+			violations = append(violations, GuardrailViolation{
+				Rule:    GuardrailMinBakeTime,
+				Message: fmt.Sprintf("配置在环境 %s 的上次发布距今不足 %d 分钟", env, guardrails.MinBakeMinutes),
+				RetryAt: last.ReleasedAt.Add(bake),
+			})
+		}
+	}
+
+	return violations, nil
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReleaseNotes 发布说明: 汇总时间窗口内各配置发布涉及的提交、作者和差异
+type ReleaseNotes struct {
+	ProjectID   int64                 `json:"project_id"`
+	Environment string                `json:"environment,omitempty"`
+	From        time.Time             `json:"from"`
+	To          time.Time             `json:"to"`
+	Configs     []*ConfigReleaseNotes `json:"configs"`
+	Authors     []string              `json:"authors"`
+}
+
+// ConfigReleaseNotes 单个配置在某环境的发布说明
+type ConfigReleaseNotes struct {
+	ConfigID    int64              `json:"config_id"`
+	Name        string             `json:"name"`
+	Namespace   string             `json:"namespace"`
+	Environment string             `json:"environment"`
+	FromVersion int                `json:"from_version"` // 窗口开始前生效的版本, 0 表示首次发布
+	ToVersion   int                `json:"to_version"`
+	Releases    []ReleaseNoteEntry `json:"releases"`
+	Commits     []CommitNoteEntry  `json:"commits"`
+	Diff        map[string]int     `json:"diff"`
+	Authors     []string           `json:"authors"`
+}
+
+// ReleaseNoteEntry 发布记录摘要
+type ReleaseNoteEntry struct {
+	ID          int64     `json:"id"`
+	Version     int       `json:"version"`
+	ReleaseType string    `json:"release_type"`
+	Status      string    `json:"status"`
+	ReleasedBy  string    `json:"released_by"`
+	ReleasedAt  time.Time `json:"released_at"`
+}
+
+// CommitNoteEntry 版本提交摘要
+type CommitNoteEntry struct {
+	Version   int       `json:"version"`
+	Hash      string    `json:"hash"`
+	Message   string    `json:"message"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReleaseNotes 生成项目在 [from, to) 时间窗口内的发布说明, env 为空时包含所有环境
+func (s *ReleaseService) ReleaseNotes(ctx context.Context, projectID int64, env string, from, to time.Time) (*ReleaseNotes, error) {
+	releases, err := s.releaseRepo.ListInWindow(ctx, projectID, env, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	notes := &ReleaseNotes{
+		ProjectID:   projectID,
+		Environment: env,
+		From:        from,
+		To:          to,
+		Configs:     []*ConfigReleaseNotes{},
+	}
+
+	// 按配置和环境分组, 保持首次发布的先后顺序
+	groups := make(map[string]*ConfigReleaseNotes)
+	authors := make(map[string]bool)
+	for _, release := range releases {
+		key := fmt.Sprintf("%d/%s", release.ConfigID, release.Environment)
+		group, ok := groups[key]
+		if !ok {
+			config, err := s.configRepo.GetByID(ctx, release.ConfigID)
+			if err != nil {
+				continue
+			}
+			group = &ConfigReleaseNotes{
+				ConfigID:    config.ID,
+				Name:        config.Name,
+				Namespace:   config.Namespace,
+				Environment: release.Environment,
+			}
+			if before, err := s.releaseRepo.GetLatestReleasedBefore(ctx, config.ID, release.Environment, from); err == nil {
+				group.FromVersion = before.Version
+			}
+			groups[key] = group
+			notes.Configs = append(notes.Configs, group)
+		}
+
+		group.ToVersion = release.Version
+		group.Releases = append(group.Releases, ReleaseNoteEntry{
+			ID:          release.ID,
+			Version:     release.Version,
+			ReleaseType: release.ReleaseType,
+			Status:      release.Status,
+			ReleasedBy:  release.ReleasedBy,
+			ReleasedAt:  release.ReleasedAt,
+		})
+	}
+
+	for _, group := range notes.Configs {
+		if err := s.fillReleaseNoteChanges(ctx, group); err != nil {
+			return nil, err
+		}
+		for _, author := range group.Authors {
+			authors[author] = true
+		}
+	}
+	notes.Authors = sortedKeys(authors)
+
+	return notes, nil
+}
+
+// fillReleaseNoteChanges 填充配置在窗口内的提交记录、作者和行级差异摘要
+func (s *ReleaseService) fillReleaseNoteChanges(ctx context.Context, group *ConfigReleaseNotes) error {
+	authors := make(map[string]bool)
+	for _, release := range group.Releases {
+		if release.ReleasedBy != "" {
+			authors[release.ReleasedBy] = true
+		}
+	}
+
+	// 回滚到旧版本时没有新增提交, 仅计算差异
+	if group.ToVersion > group.FromVersion {
+		versions, err := s.versionRepo.ListRange(ctx, group.ConfigID, group.FromVersion, group.ToVersion)
+		if err != nil {
+			return err
+		}
+		for _, v := range versions {
+			group.Commits = append(group.Commits, CommitNoteEntry{
+				Version:   v.Version,
+				Hash:      v.CommitHash,
+				Message:   v.CommitMessage,
+				Author:    v.Author,
+				CreatedAt: v.CreatedAt,
+			})
+			if v.Author != "" {
+				authors[v.Author] = true
+			}
+		}
+	}
+
+	oldContent := ""
+	if group.FromVersion > 0 {
+		if v, err := s.versionRepo.GetByConfigAndVersion(ctx, group.ConfigID, group.FromVersion); err == nil {
+			oldContent = v.Content
+		}
+	}
+	newContent := ""
+	if v, err := s.versionRepo.GetByConfigAndVersion(ctx, group.ConfigID, group.ToVersion); err == nil {
+		newContent = v.Content
+	}
+	group.Diff = s.diffSvc.GetDiffSummary(s.diffSvc.DiffLines(oldContent, newContent))
+	group.Authors = sortedKeys(authors)
+	return nil
+}
+
+// Markdown 将发布说明渲染为 Markdown, 用于变更评审会议
+func (n *ReleaseNotes) Markdown() string {
+	var b strings.Builder
+
+	env := n.Environment
+	if env == "" {
+		env = "全部环境"
+	}
+	fmt.Fprintf(&b, "# 发布说明 (%s)\n\n", env)
+	fmt.Fprintf(&b, "时间范围: %s ~ %s\n\n", n.From.Format(time.RFC3339), n.To.Format(time.RFC3339))
+
+	if len(n.Configs) == 0 {
+		b.WriteString("该时间范围内没有发布。\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "共 %d 个配置发布, 参与人: %s\n", len(n.Configs), strings.Join(n.Authors, ", "))
+
+	for _, c := range n.Configs {
+		fmt.Fprintf(&b, "\n## %s/%s [%s]\n\n", c.Namespace, c.Name, c.Environment)
+		fmt.Fprintf(&b, "- 版本: v%d → v%d\n", c.FromVersion, c.ToVersion)
+		fmt.Fprintf(&b, "- 差异: +%d / -%d 行\n", c.Diff["added"], c.Diff["removed"])
+		fmt.Fprintf(&b, "- 参与人: %s\n", strings.Join(c.Authors, ", "))
+
+		if len(c.Commits) > 0 {
+			b.WriteString("\n### 提交\n\n")
+			for _, commit := range c.Commits {
+				message := commit.Message
+				if message == "" {
+					message = "(无提交说明)"
+				}
+				fmt.Fprintf(&b, "- v%d `%s` %s — %s\n", commit.Version, commit.Hash, message, commit.Author)
+			}
+		}
+
+		b.WriteString("\n### 发布记录\n\n")
+		for _, r := range c.Releases {
+			fmt.Fprintf(&b, "- %s v%d %s/%s by %s\n", r.ReleasedAt.Format(time.RFC3339), r.Version, r.ReleaseType, r.Status, r.ReleasedBy)
+		}
+	}
+
+	return b.String()
+}
+
+// sortedKeys 返回集合中按字典序排列的键
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+)
+
+var (
+	ErrInvalidReleasePipeline = errors.New("无效的发布流水线配置")
+)
+
+// maxPipelineSteps 发布流水线的最大步骤数
+const maxPipelineSteps = 20
+
+// 转换步骤执行结果
+const (
+	TransformApplied   = "applied"   // 内容已改变
+	TransformUnchanged = "unchanged" // 执行成功但内容未变
+	TransformSkipped   = "skipped"   // 配置格式不支持该步骤
+)
+
+// ReleasePipeline 项目级发布转换流水线, 保存在项目设置的 release_pipeline 中
+// 正式发布时按顺序执行, 结果作为发布产物下发给客户端; 配置版本本身不受影响
+type ReleasePipeline struct {
+	Environments []string        `json:"environments"` // 生效的环境, 为空时对所有环境生效
+	Steps        []TransformStep `json:"steps"`
+}
+
+// TransformStep 流水线中的一个转换步骤
+type TransformStep struct {
+	Type   string   `json:"type"`             // strip_comments, inject_build_metadata, minify, re_encrypt, sort_keys
+	Key    string   `json:"key,omitempty"`    // inject_build_metadata: 写入的顶层键, 默认 _build
+	Fields []string `json:"fields,omitempty"` // re_encrypt: 需要额外加密的字段 (字段名或点分路径)
+}
+
+// TransformLogEntry 单个步骤的执行记录, 保存在发布记录的 pipeline_log 中
+type TransformLogEntry struct {
+	Step        string `json:"step"`
+	Status      string `json:"status"`
+	Message     string `json:"message,omitempty"`
+	BytesBefore int    `json:"bytes_before"`
+	BytesAfter  int    `json:"bytes_after"`
+	DurationMs  int64  `json:"duration_ms"`
+}
+
+// PipelineResult 流水线执行结果
+type PipelineResult struct {
+	Artifact     string              `json:"artifact"`
+	ArtifactHash string              `json:"artifact_hash"`
+	Log          []TransformLogEntry `json:"log"`
+}
+
+// TransformError 流水线步骤执行失败, 发布不会创建
+type TransformError struct {
+	Index int
+	Step  string
+	Err   error
+}
+
+func (e *TransformError) Error() string {
+	return fmt.Sprintf("发布流水线第 %d 步 (%s) 执行失败: %v", e.Index+1, e.Step, e.Err)
+}
+
+func (e *TransformError) Unwrap() error {
+	return e.Err
+}
+
+// Validate 校验流水线配置
+func (p *ReleasePipeline) Validate() error {
+	if len(p.Steps) > maxPipelineSteps {
+		return ErrInvalidReleasePipeline
+	}
+	for _, env := range p.Environments {
+		if strings.TrimSpace(env) == "" {
+			return ErrInvalidReleasePipeline
+		}
+	}
+	for _, step := range p.Steps {
+		if _, ok := transforms[step.Type]; !ok {
+			return fmt.Errorf("%w: 未知的步骤 %q", ErrInvalidReleasePipeline, step.Type)
+		}
+	}
+	return nil
+}
+
+// applies 流水线是否对环境生效
+func (p *ReleasePipeline) applies(env string) bool {
+	if len(p.Steps) == 0 {
+		return false
+	}
+	if len(p.Environments) == 0 {
+		return true
+	}
+	for _, e := range p.Environments {
+		if e == env {
+			return true
+		}
+	}
+	return false
+}
+
+// ReleasePipelineService 发布流水线服务
+type ReleasePipelineService struct {
+	projectRepo *repository.ProjectRepository
+	configRepo  *repository.ConfigRepository
+	versionRepo repository.VersionStore
+	encryptSvc  *EncryptionService
+}
+
+// NewReleasePipelineService 创建发布流水线服务
+func NewReleasePipelineService(projectRepo *repository.ProjectRepository, configRepo *repository.ConfigRepository, versionRepo repository.VersionStore, encryptSvc *EncryptionService) *ReleasePipelineService {
+	return &ReleasePipelineService{
+		projectRepo: projectRepo,
+		configRepo:  configRepo,
+		versionRepo: versionRepo,
+		encryptSvc:  encryptSvc,
+	}
+}
+
+// projectPipeline 解析项目设置中的发布流水线, 未设置时返回空流水线
+func projectPipeline(project *model.Project) *ReleasePipeline {
+	pipeline := &ReleasePipeline{}
+	if !projectSetting(project, "release_pipeline", pipeline) {
+		pipeline = &ReleasePipeline{}
+	}
+	if pipeline.Environments == nil {
+		pipeline.Environments = []string{}
+	}
+	if pipeline.Steps == nil {
+		pipeline.Steps = []TransformStep{}
+	}
+	return pipeline
+}
+
+// Get 获取项目的发布流水线
+func (s *ReleasePipelineService) Get(ctx context.Context, projectID int64) (*ReleasePipeline, error) {
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	return projectPipeline(project), nil
+}
+
+// Update 更新项目的发布流水线
+func (s *ReleasePipelineService) Update(ctx context.Context, projectID int64, pipeline *ReleasePipeline) error {
+	if err := pipeline.Validate(); err != nil {
+		return err
+	}
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return ErrProjectNotFound
+	}
+
+	if pipeline.Environments == nil {
+		pipeline.Environments = []string{}
+	}
+	if pipeline.Steps == nil {
+		pipeline.Steps = []TransformStep{}
+	}
+	if err := setProjectSetting(project, "release_pipeline", pipeline); err != nil {
+		return err
+	}
+	return s.projectRepo.Update(ctx, project)
+}
+
+// Preview 按当前流水线对配置版本试运行, 不创建发布; 流水线对环境不生效时产物即原始内容
+func (s *ReleasePipelineService) Preview(ctx context.Context, configID int64, env string, version int, author string) (*PipelineResult, error) {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+	if version == 0 {
+		version = config.CurrentVersion
+	}
+	target, err := s.versionRepo.GetByConfigAndVersion(ctx, configID, version)
+	if err != nil {
+		return nil, ErrVersionNotFound
+	}
+	project, err := s.projectRepo.GetByID(ctx, config.ProjectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+
+	pipeline := projectPipeline(project)
+	if !pipeline.applies(env) {
+		return &PipelineResult{Artifact: target.Content, ArtifactHash: target.CommitHash, Log: []TransformLogEntry{}}, nil
+	}
+	return s.run(ctx, pipeline, config, target, &model.Release{Environment: env, Version: version, ReleasedBy: author, ReleasedAt: time.Now()})
+}
+
+// Apply 为即将创建的正式发布执行项目流水线, 写入发布产物及执行记录
+// 流水线对发布环境不生效时不生成产物, 客户端收到的是版本原始内容
+func (s *ReleasePipelineService) Apply(ctx context.Context, release *model.Release) error {
+	config, err := s.configRepo.GetByID(ctx, release.ConfigID)
+	if err != nil {
+		return ErrConfigNotFound
+	}
+	project, err := s.projectRepo.GetByID(ctx, config.ProjectID)
+	if err != nil {
+		return ErrProjectNotFound
+	}
+	pipeline := projectPipeline(project)
+	if !pipeline.applies(release.Environment) {
+		return nil
+	}
+
+	version, err := s.versionRepo.GetByConfigAndVersion(ctx, release.ConfigID, release.Version)
+	if err != nil {
+		return ErrVersionNotFound
+	}
+	if release.ReleasedAt.IsZero() {
+		release.ReleasedAt = time.Now()
+	}
+
+	result, err := s.run(ctx, pipeline, config, version, release)
+	if err != nil {
+		return err
+	}
+	logJSON, err := json.Marshal(result.Log)
+	if err != nil {
+		return err
+	}
+	release.Artifact = result.Artifact
+	release.ArtifactHash = result.ArtifactHash
+	release.PipelineLog = string(logJSON)
+	return nil
+}
+
+// run 按顺序执行流水线步骤, 任一步骤失败即中止
+func (s *ReleasePipelineService) run(ctx context.Context, pipeline *ReleasePipeline, config *model.Config, version *model.ConfigVersion, release *model.Release) (*PipelineResult, error) {
+	tc := &transformContext{
+		ctx:        ctx,
+		config:     config,
+		version:    version,
+		release:    release,
+		encryptSvc: s.encryptSvc,
+	}
+
+	content := version.Content
+	result := &PipelineResult{Log: make([]TransformLogEntry, 0, len(pipeline.Steps))}
+	for i, step := range pipeline.Steps {
+		transform, ok := transforms[step.Type]
+		if !ok {
+			return nil, &TransformError{Index: i, Step: step.Type, Err: ErrInvalidReleasePipeline}
+		}
+
+		started := time.Now()
+		status := TransformApplied
+		output, message, err := transform(tc, step, content)
+		if errors.Is(err, errTransformUnsupported) {
+			output, message, status = content, err.Error(), TransformSkipped
+		} else if err != nil {
+			return nil, &TransformError{Index: i, Step: step.Type, Err: err}
+		} else if output == content {
+			status = TransformUnchanged
+		}
+
+		result.Log = append(result.Log, TransformLogEntry{
+			Step:        step.Type,
+			Status:      status,
+			Message:     message,
+			BytesBefore: len(content),
+			BytesAfter:  len(output),
+			DurationMs:  time.Since(started).Milliseconds(),
+		})
+		content = output
+	}
+
+	sum := sha256.Sum256([]byte(content))
+	result.Artifact = content
+	result.ArtifactHash = hex.EncodeToString(sum[:])[:16]
+	return result, nil
+}
+
+// ServedContent 下发给客户端的内容: 版本已正式发布且生成了产物时为发布产物, 否则为版本原始内容
+func ServedContent(version *model.ConfigVersion, release *model.Release) string {
+	if release != nil && release.Version == version.Version && release.ArtifactHash != "" {
+		return release.Artifact
+	}
+	return version.Content
+}
+
+// ServedContentHash 下发内容的哈希, 与 ServedContent 对应
+func ServedContentHash(version *model.ConfigVersion, release *model.Release) string {
+	if release != nil && release.Version == version.Version && release.ArtifactHash != "" {
+		return release.ArtifactHash
+	}
+	return version.CommitHash
+}
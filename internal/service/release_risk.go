@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"confighub/internal/model"
+)
+
+var (
+	ErrInvalidRiskPolicy = errors.New("无效的风险审批策略")
+	ErrReleaseNotPending = errors.New("发布不处于待审批状态")
+	ErrSelfApproval      = errors.New("不能审批自己创建的发布")
+	ErrAlreadyApproved   = errors.New("已审批过该发布")
+)
+
+// 风险等级
+const (
+	RiskLevelLow    = "low"
+	RiskLevelMedium = "medium"
+	RiskLevelHigh   = "high"
+)
+
+// 风险分阈值及各因素权重, 各因素满分之和为 100
+const (
+	riskMediumThreshold = 30
+	riskHighThreshold   = 60
+
+	riskDiffSmall     = 5  // 变更 10 行以内
+	riskDiffMedium    = 15 // 变更 50 行以内
+	riskDiffLarge     = 25 // 变更超过 50 行
+	riskProd          = 25
+	riskEncrypted     = 20
+	riskRollbackEach  = 10 // 最近 7 天每次回滚
+	riskRollbackLimit = 20
+	riskOffHours      = 10
+)
+
+// defaultProdEnvironments 风险策略未指定时视为生产的环境
+var defaultProdEnvironments = []string{"prod", "production"}
+
+// RiskPolicy 项目级风险审批策略, 保存在项目设置的 risk_policy 中
+type RiskPolicy struct {
+	RequiredApprovals map[string]int `json:"required_approvals"` // 风险等级 -> 需要的审批人数, 如 {"high": 2}
+	ProdEnvironments  []string       `json:"prod_environments"`  // 视为生产的环境, 为空时为 prod 和 production
+}
+
+// RiskFactor 风险因素
+type RiskFactor struct {
+	Name   string `json:"name"` // diff_size, prod, encrypted_fields, rollback_history, off_hours
+	Score  int    `json:"score"`
+	Detail string `json:"detail"`
+}
+
+// RiskAssessment 变更风险评估结果
+type RiskAssessment struct {
+	Score             int          `json:"score"`
+	Level             string       `json:"level"`
+	Factors           []RiskFactor `json:"factors"`
+	RequiredApprovals int          `json:"required_approvals"`
+}
+
+// Validate 校验风险策略
+func (p *RiskPolicy) Validate() error {
+	for level, n := range p.RequiredApprovals {
+		if n < 0 {
+			return ErrInvalidRiskPolicy
+		}
+		switch level {
+		case RiskLevelLow, RiskLevelMedium, RiskLevelHigh:
+		default:
+			return ErrInvalidRiskPolicy
+		}
+	}
+	for _, env := range p.ProdEnvironments {
+		if strings.TrimSpace(env) == "" {
+			return ErrInvalidRiskPolicy
+		}
+	}
+	return nil
+}
+
+// isProd 环境是否视为生产
+func (p *RiskPolicy) isProd(env string) bool {
+	envs := p.ProdEnvironments
+	if len(envs) == 0 {
+		envs = defaultProdEnvironments
+	}
+	for _, e := range envs {
+		if e == env {
+			return true
+		}
+	}
+	return false
+}
+
+// projectRiskPolicy 解析项目设置中的风险策略, 未设置时返回空策略 (不要求审批)
+func projectRiskPolicy(project *model.Project) *RiskPolicy {
+	policy := &RiskPolicy{}
+	if !projectSetting(project, "risk_policy", policy) {
+		policy = &RiskPolicy{}
+	}
+	if policy.RequiredApprovals == nil {
+		policy.RequiredApprovals = map[string]int{}
+	}
+	if policy.ProdEnvironments == nil {
+		policy.ProdEnvironments = []string{}
+	}
+	return policy
+}
+
+// GetRiskPolicy 获取项目的风险审批策略
+func (s *ReleaseService) GetRiskPolicy(ctx context.Context, projectID int64) (*RiskPolicy, error) {
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	return projectRiskPolicy(project), nil
+}
+
+// UpdateRiskPolicy 更新项目的风险审批策略
+func (s *ReleaseService) UpdateRiskPolicy(ctx context.Context, projectID int64, policy *RiskPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return ErrProjectNotFound
+	}
+
+	if policy.RequiredApprovals == nil {
+		policy.RequiredApprovals = map[string]int{}
+	}
+	if policy.ProdEnvironments == nil {
+		policy.ProdEnvironments = []string{}
+	}
+	if err := setProjectSetting(project, "risk_policy", policy); err != nil {
+		return err
+	}
+	return s.projectRepo.Update(ctx, project)
+}
+
+// AssessRisk 预估将配置的指定版本发布到环境的风险, version 为 0 时使用当前版本
+func (s *ReleaseService) AssessRisk(ctx context.Context, configID int64, env string, version int) (*RiskAssessment, error) {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+	if version == 0 {
+		version = config.CurrentVersion
+	}
+	return s.assessRisk(ctx, config, env, version)
+}
+
+// assessRisk 按变更大小、是否生产环境、是否涉及加密字段、近期回滚次数和发布时间计算风险分
+func (s *ReleaseService) assessRisk(ctx context.Context, config *model.Config, env string, version int) (*RiskAssessment, error) {
+	project, err := s.projectRepo.GetByID(ctx, config.ProjectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	policy := projectRiskPolicy(project)
+
+	target, err := s.versionRepo.GetByConfigAndVersion(ctx, config.ID, version)
+	if err != nil {
+		return nil, ErrVersionNotFound
+	}
+	// 与环境中当前生效的版本对比, 首次发布时与空内容对比
+	current := ""
+	if release, err := s.releaseRepo.GetLatestReleased(ctx, config.ID, env); err == nil {
+		if v, err := s.versionRepo.GetByConfigAndVersion(ctx, config.ID, release.Version); err == nil {
+			current = v.Content
+		}
+	}
+
+	assessment := &RiskAssessment{Factors: []RiskFactor{}}
+	add := func(name string, score int, detail string) {
+		assessment.Factors = append(assessment.Factors, RiskFactor{Name: name, Score: score, Detail: detail})
+		assessment.Score += score
+	}
+
+	changed, encrypted := 0, false
+	for _, d := range s.diffSvc.DiffLines(current, target.Content) {
+		if d.Type == "add" || d.Type == "remove" {
+			changed++
+			if strings.Contains(d.Content, EncryptedPrefix) {
+				encrypted = true
+			}
+		}
+	}
+	switch {
+	case changed > 50:
+		add("diff_size", riskDiffLarge, fmt.Sprintf("变更 %d 行", changed))
+	case changed > 10:
+		add("diff_size", riskDiffMedium, fmt.Sprintf("变更 %d 行", changed))
+	case changed > 0:
+		add("diff_size", riskDiffSmall, fmt.Sprintf("变更 %d 行", changed))
+	}
+
+	if policy.isProd(env) {
+		add("prod", riskProd, "发布到生产环境 "+env)
+	}
+	if encrypted {
+		add("encrypted_fields", riskEncrypted, "变更涉及加密字段")
+	}
+
+	if rollbacks, err := s.releaseRepo.CountRolledBackSince(ctx, config.ID, env, time.Now().AddDate(0, 0, -7)); err == nil && rollbacks > 0 {
+		score := int(rollbacks) * riskRollbackEach
+		if score > riskRollbackLimit {
+			score = riskRollbackLimit
+		}
+		add("rollback_history", score, fmt.Sprintf("最近 7 天回滚 %d 次", rollbacks))
+	}
+
+	now := time.Now()
+	if now.Weekday() == time.Saturday || now.Weekday() == time.Sunday || now.Hour() < 9 || now.Hour() >= 18 {
+		add("off_hours", riskOffHours, "非工作时间发布")
+	}
+
+	switch {
+	case assessment.Score >= riskHighThreshold:
+		assessment.Level = RiskLevelHigh
+	case assessment.Score >= riskMediumThreshold:
+		assessment.Level = RiskLevelMedium
+	default:
+		assessment.Level = RiskLevelLow
+	}
+	assessment.RequiredApprovals = policy.RequiredApprovals[assessment.Level]
+	return assessment, nil
+}
+
+// applyRisk 将风险评估结果写入发布记录, 需要审批时发布进入待审批状态
+func applyRisk(release *model.Release, assessment *RiskAssessment) {
+	factors, _ := json.Marshal(assessment.Factors)
+	release.RiskScore = assessment.Score
+	release.RiskLevel = assessment.Level
+	release.RiskFactors = string(factors)
+	release.RequiredApprovals = assessment.RequiredApprovals
+	if assessment.RequiredApprovals > 0 {
+		release.Status = "pending"
+	}
+}
+
+// Approve 审批待审批的发布, 审批人数达到要求时发布生效
+// 发布创建者不能审批自己的发布
+func (s *ReleaseService) Approve(ctx context.Context, releaseID, userID int64, comment string) (*model.Release, error) {
+	release, err := s.releaseRepo.GetByID(ctx, releaseID)
+	if err != nil {
+		return nil, ErrReleaseNotFound
+	}
+	if release.Status != "pending" {
+		return nil, ErrReleaseNotPending
+	}
+	if release.ReleasedBy == strconv.FormatInt(userID, 10) {
+		return nil, ErrSelfApproval
+	}
+
+	approvals, err := s.releaseRepo.ListApprovals(ctx, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range approvals {
+		if a.UserID == userID {
+			return nil, ErrAlreadyApproved
+		}
+	}
+
+	if err := s.releaseRepo.CreateApproval(ctx, &model.ReleaseApproval{
+		ReleaseID: releaseID,
+		UserID:    userID,
+		Comment:   comment,
+	}); err != nil {
+		return nil, err
+	}
+
+	if len(approvals)+1 < release.RequiredApprovals {
+		return release, nil
+	}
+
+	// 以审批通过时间作为发布时间, 使其成为环境中最新的发布
+	release.Status = "released"
+	release.ReleasedAt = time.Now()
+	if err := s.releaseRepo.Update(ctx, release); err != nil {
+		return nil, err
+	}
+
+	notifyRelease(ctx, s.notifySvc, release, "release")
+	return release, nil
+}
+
+// Reject 驳回待审批的发布
+func (s *ReleaseService) Reject(ctx context.Context, releaseID int64) (*model.Release, error) {
+	release, err := s.releaseRepo.GetByID(ctx, releaseID)
+	if err != nil {
+		return nil, ErrReleaseNotFound
+	}
+	if release.Status != "pending" {
+		return nil, ErrReleaseNotPending
+	}
+
+	release.Status = "rejected"
+	if err := s.releaseRepo.Update(ctx, release); err != nil {
+		return nil, err
+	}
+	return release, nil
+}
+
+// ListApprovals 获取发布的审批记录
+func (s *ReleaseService) ListApprovals(ctx context.Context, releaseID int64) ([]*model.ReleaseApproval, error) {
+	if _, err := s.releaseRepo.GetByID(ctx, releaseID); err != nil {
+		return nil, ErrReleaseNotFound
+	}
+	return s.releaseRepo.ListApprovals(ctx, releaseID)
+}
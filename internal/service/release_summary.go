@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"confighub/internal/model"
+)
+
+const (
+	summaryMaxChanges   = 20 // 摘要中保留的结构化差异数
+	summaryTextChanges  = 3  // 摘要文本中列出的差异数
+	summaryMaxValueLen  = 40 // 摘要文本中单个值的最大长度 (字符)
+	summaryMaskedValue  = "******"
+	summaryOmittedValue = "…"
+)
+
+// summaryEvents 附带变更摘要的发布类事件
+var summaryEvents = map[string]bool{
+	"release":      true,
+	"rollback":     true,
+	"gray_release": true,
+	"promote":      true,
+}
+
+// ReleaseSummary 发布内容相对上一次生效发布的变更摘要, 用于通知中展示改动而无需打开控制台
+type ReleaseSummary struct {
+	FromVersion int        `json:"from_version"` // 0 表示首次发布
+	ToVersion   int        `json:"to_version"`
+	Added       int        `json:"added"`
+	Removed     int        `json:"removed"`
+	Modified    int        `json:"modified"`
+	Lines       bool       `json:"lines,omitempty"` // 内容不是结构化配置时按行统计新增和删除
+	Text        string     `json:"text"`            // 如 "修改 3 个键, 新增 1 个键: db.pool.max 20→50; ..."
+	Changes     []JSONDiff `json:"changes,omitempty"`
+	Truncated   bool       `json:"truncated,omitempty"` // 差异超过 changes 的上限
+}
+
+// SummarizeChanges 生成两个版本内容的变更摘要: JSON、kv、HCL 和 YAML 配置按键对比 (应用配置的对比忽略规则),
+// 其他类型按行统计; 加密字段的值以掩码显示
+func SummarizeChanges(fileType, oldContent, newContent string, rules *DiffRules) *ReleaseSummary {
+	diffSvc := NewDiffService().WithRules(rules)
+	summary := &ReleaseSummary{}
+
+	structural, err := structuredDiff(diffSvc, fileType, oldContent, newContent)
+	if err != nil {
+		lines := diffSvc.GetDiffSummary(diffSvc.DiffLines(oldContent, newContent))
+		summary.Lines = true
+		summary.Added = lines["added"]
+		summary.Removed = lines["removed"]
+		summary.Text = fmt.Sprintf("新增 %d 行, 删除 %d 行", summary.Added, summary.Removed)
+		if summary.Added == 0 && summary.Removed == 0 {
+			summary.Text = "内容无变化"
+		}
+		return summary
+	}
+
+	for i := range structural {
+		d := &structural[i]
+		d.OldValue = maskSummaryValue(d.OldValue)
+		d.NewValue = maskSummaryValue(d.NewValue)
+		switch d.Type {
+		case "add":
+			summary.Added++
+		case "remove":
+			summary.Removed++
+		default:
+			summary.Modified++
+		}
+	}
+	summary.Text = summaryText(summary, structural)
+	if len(structural) > summaryMaxChanges {
+		structural = structural[:summaryMaxChanges]
+		summary.Truncated = true
+	}
+	summary.Changes = structural
+	return summary
+}
+
+// structuredDiff 按键对比结构化配置, 首次发布时旧内容视为空对象
+func structuredDiff(diffSvc *DiffService, fileType, oldContent, newContent string) ([]JSONDiff, error) {
+	switch fileType {
+	case "json", "kv", "hcl", "yaml":
+	default:
+		return nil, fmt.Errorf("%s 类型的配置不是结构化内容", fileType)
+	}
+	oldJSON := "{}"
+	if strings.TrimSpace(oldContent) != "" {
+		var err error
+		if oldJSON, err = structuredContent(fileType, oldContent); err != nil {
+			return nil, err
+		}
+	}
+	newJSON, err := structuredContent(fileType, newContent)
+	if err != nil {
+		return nil, err
+	}
+	return diffSvc.DiffJSON(oldJSON, newJSON)
+}
+
+// summaryText 生成摘要文本: 先列出各类差异的数量, 再列出前几项差异
+func summaryText(summary *ReleaseSummary, diffs []JSONDiff) string {
+	if len(diffs) == 0 {
+		return "内容无变化"
+	}
+
+	var counts []string
+	if summary.Modified > 0 {
+		counts = append(counts, fmt.Sprintf("修改 %d 个键", summary.Modified))
+	}
+	if summary.Added > 0 {
+		counts = append(counts, fmt.Sprintf("新增 %d 个键", summary.Added))
+	}
+	if summary.Removed > 0 {
+		counts = append(counts, fmt.Sprintf("删除 %d 个键", summary.Removed))
+	}
+
+	var items []string
+	for i, d := range diffs {
+		if i == summaryTextChanges {
+			items = append(items, fmt.Sprintf("共 %d 项", len(diffs)))
+			break
+		}
+		path := d.Path
+		if path == "" {
+			path = "(根)"
+		}
+		switch d.Type {
+		case "add":
+			items = append(items, fmt.Sprintf("+%s %s", path, formatSummaryValue(d.NewValue)))
+		case "remove":
+			items = append(items, "-"+path)
+		default:
+			items = append(items, fmt.Sprintf("%s %s→%s", path, formatSummaryValue(d.OldValue), formatSummaryValue(d.NewValue)))
+		}
+	}
+	return strings.Join(counts, ", ") + ": " + strings.Join(items, "; ")
+}
+
+// maskSummaryValue 将加密字段的值替换为掩码, 避免密文出现在通知中
+func maskSummaryValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if strings.HasPrefix(v, EncryptedPrefix) {
+			return summaryMaskedValue
+		}
+		return v
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			masked[key] = maskSummaryValue(item)
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, item := range v {
+			masked[i] = maskSummaryValue(item)
+		}
+		return masked
+	default:
+		return v
+	}
+}
+
+// formatSummaryValue 以 JSON 格式化摘要文本中的值, 对象和数组只显示类型, 过长的值截断
+func formatSummaryValue(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "{" + summaryOmittedValue + "}"
+	case []interface{}:
+		return "[" + summaryOmittedValue + "]"
+	case string:
+		if value == summaryMaskedValue {
+			return summaryMaskedValue
+		}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	text := []rune(string(data))
+	if len(text) > summaryMaxValueLen {
+		return string(text[:summaryMaxValueLen]) + summaryOmittedValue
+	}
+	return string(text)
+}
+
+// releaseSummary 生成发布类变更相对上一次生效发布的摘要, 无法确定发布或版本时返回 nil
+func (s *WebhookService) releaseSummary(ctx context.Context, config *model.Config, change *ConfigChange) *ReleaseSummary {
+	if !summaryEvents[change.ChangeType] || change.ReleaseID == 0 {
+		return nil
+	}
+	release, err := s.releaseRepo.GetByID(ctx, change.ReleaseID)
+	if err != nil {
+		return nil
+	}
+	version, err := s.versionRepo.GetByConfigAndVersion(ctx, config.ID, release.Version)
+	if err != nil {
+		return nil
+	}
+
+	fromVersion, oldContent := 0, ""
+	if previous, err := s.releaseRepo.GetLatestReleasedBefore(ctx, config.ID, release.Environment, release.ReleasedAt); err == nil {
+		if v, err := s.versionRepo.GetByConfigAndVersion(ctx, config.ID, previous.Version); err == nil {
+			fromVersion, oldContent = v.Version, v.Content
+		}
+	}
+
+	// 对比规则无效时不忽略任何差异
+	rules, _ := ParseDiffRules(config.DiffRules)
+	summary := SummarizeChanges(config.FileType, oldContent, version.Content, rules)
+	summary.FromVersion = fromVersion
+	summary.ToVersion = release.Version
+	return summary
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrUnsupportedFormat = errors.New("不支持的响应格式")
+
+// 公开读取接口可协商的内容格式
+const (
+	FormatJSON       = "json"       // 默认: JSON 响应, 内容为 content 字段
+	FormatYAML       = "yaml"       // application/yaml
+	FormatProperties = "properties" // application/x-properties
+)
+
+// RenderContent 将配置内容 (path 不为空时为路径指向的子树) 转换为 YAML 或 properties 文本
+// 支持 JSON、kv、HCL 和 YAML 配置; YAML 配置不指定路径时原样返回, 保留注释和键的顺序
+// properties 的键为以 . 连接的路径 (数组元素以下标表示), 指定路径时以该路径为前缀
+func RenderContent(fileType, content string, path []string, format string) (string, error) {
+	if format == FormatYAML && fileType == "yaml" && len(path) == 0 {
+		return content, nil
+	}
+
+	doc, err := decodeContent(fileType, content)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	value, err := pointerGet(doc, path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrPathNotFound, err)
+	}
+
+	switch format {
+	case FormatYAML:
+		data, err := yaml.Marshal(yamlValue(value))
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	case FormatProperties:
+		lines := make(map[string]string)
+		flattenProperties(strings.Join(path, "."), value, lines)
+		keys := make([]string, 0, len(lines))
+		for key := range lines {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var b strings.Builder
+		for _, key := range keys {
+			b.WriteString(escapePropertyKey(key))
+			b.WriteByte('=')
+			b.WriteString(escapePropertyValue(lines[key]))
+			b.WriteByte('\n')
+		}
+		return b.String(), nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+}
+
+// yamlValue 将 json.Number 转换为整数或浮点数, 避免编码为字符串
+func yamlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = yamlValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = yamlValue(item)
+		}
+		return result
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	default:
+		return v
+	}
+}
+
+// flattenProperties 将 JSON 值展开为 properties 键值, 空对象和空数组不输出, null 输出为空值
+func flattenProperties(prefix string, value interface{}, out map[string]string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			flattenProperties(join(key), item, out)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flattenProperties(join(strconv.Itoa(i)), item, out)
+		}
+	case nil:
+		out[prefix] = ""
+	case string:
+		out[prefix] = v
+	default:
+		out[prefix] = fmt.Sprint(v)
+	}
+}
+
+// escapePropertyKey 转义 properties 键中的分隔符、注释符和空白
+func escapePropertyKey(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		switch r {
+		case '=', ':', ' ', '#', '!':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		default:
+			writePropertyRune(&b, r)
+		}
+	}
+	return b.String()
+}
+
+// escapePropertyValue 转义 properties 值中的反斜杠、换行和行首空白
+func escapePropertyValue(value string) string {
+	var b strings.Builder
+	for i, r := range value {
+		if r == ' ' && i == 0 {
+			b.WriteString(`\ `)
+			continue
+		}
+		writePropertyRune(&b, r)
+	}
+	return b.String()
+}
+
+func writePropertyRune(b *strings.Builder, r rune) {
+	switch r {
+	case '\\':
+		b.WriteString(`\\`)
+	case '\n':
+		b.WriteString(`\n`)
+	case '\r':
+		b.WriteString(`\r`)
+	case '\t':
+		b.WriteString(`\t`)
+	default:
+		b.WriteRune(r)
+	}
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+)
+
+var (
+	ErrReplicationDisabled          = errors.New("未启用跨实例复制")
+	ErrReplicationProjectNotAllowed = errors.New("项目不在复制范围内")
+	ErrReplicationInvalidSnapshot   = errors.New("无效的复制快照")
+	ErrFollowerReadOnly             = errors.New("跟随节点只读")
+	ErrReplicationNotPushTarget     = errors.New("本实例不接收复制推送")
+)
+
+// ReplicationTokenHeader 复制接口的共享令牌请求头
+const ReplicationTokenHeader = "X-Replication-Token"
+
+// ReplicationOptions 复制选项
+type ReplicationOptions struct {
+	Enabled        bool
+	Mode           string // push, pull, receive
+	PeerURL        string
+	Token          string
+	Projects       []string
+	Interval       time.Duration
+	ConflictPolicy string
+}
+
+// ReplicationService 跨实例复制服务
+// 以项目为单位在主实例和副本之间同步快照, 副本可就近提供读取并用于容灾
+type ReplicationService struct {
+	repo      *repository.ReplicationRepository
+	notifySvc *NotificationService
+	opts      ReplicationOptions
+	client    *ReplicationClient
+
+	mu       sync.RWMutex
+	lastRun  time.Time
+	projects map[string]*ProjectReplicationStatus
+}
+
+// ProjectReplicationStatus 单个项目的最近一次同步状态
+type ProjectReplicationStatus struct {
+	LastSyncAt time.Time                `json:"last_sync_at"`
+	LastError  string                   `json:"last_error,omitempty"`
+	Result     *model.ReplicationResult `json:"result,omitempty"`
+}
+
+// ReplicationStatus 复制状态
+type ReplicationStatus struct {
+	Enabled        bool                                 `json:"enabled"`
+	Mode           string                               `json:"mode"`
+	PeerURL        string                               `json:"peer_url"`
+	ConflictPolicy string                               `json:"conflict_policy"`
+	LastRunAt      time.Time                            `json:"last_run_at"`
+	Projects       map[string]*ProjectReplicationStatus `json:"projects"`
+}
+
+// NewReplicationService 创建复制服务
+func NewReplicationService(repo *repository.ReplicationRepository, notifySvc *NotificationService, opts ReplicationOptions) *ReplicationService {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	if opts.ConflictPolicy == "" {
+		opts.ConflictPolicy = model.ReplicationSourceWins
+	}
+	return &ReplicationService{
+		repo:      repo,
+		notifySvc: notifySvc,
+		opts:      opts,
+		client:    NewReplicationClient(opts.PeerURL, opts.Token),
+		projects:  make(map[string]*ProjectReplicationStatus),
+	}
+}
+
+// Token 复制令牌, 为空时复制接口不可用
+func (s *ReplicationService) Token() string {
+	return s.opts.Token
+}
+
+// Snapshot 导出项目快照, 未启用复制时拒绝; 配置了项目列表时仅允许导出列表中的项目
+func (s *ReplicationService) Snapshot(ctx context.Context, projectName string) (*model.ReplicationSnapshot, error) {
+	if !s.opts.Enabled {
+		return nil, ErrReplicationDisabled
+	}
+	if !s.allowed(projectName) {
+		return nil, ErrReplicationProjectNotAllowed
+	}
+	snapshot, err := s.repo.Snapshot(ctx, projectName)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	return snapshot, nil
+}
+
+// Receive 应用主实例推送的快照, 仅 receive 模式的副本接收推送
+func (s *ReplicationService) Receive(ctx context.Context, snapshot *model.ReplicationSnapshot) (*model.ReplicationResult, error) {
+	if !s.opts.Enabled || s.opts.Mode != model.ReplicationModeReceive {
+		return nil, ErrReplicationNotPushTarget
+	}
+	return s.Apply(ctx, snapshot)
+}
+
+// Apply 应用对端推送或拉取的快照, 并通知本地监听方配置已变化
+func (s *ReplicationService) Apply(ctx context.Context, snapshot *model.ReplicationSnapshot) (*model.ReplicationResult, error) {
+	if snapshot == nil || snapshot.Project.Name == "" {
+		return nil, ErrReplicationInvalidSnapshot
+	}
+	if !s.allowed(snapshot.Project.Name) {
+		return nil, ErrReplicationProjectNotAllowed
+	}
+
+	result, err := s.repo.Apply(ctx, snapshot, s.opts.ConflictPolicy)
+	s.record(snapshot.Project.Name, result, err)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, configID := range result.ChangedConfigs {
+		s.notifySvc.NotifyChange(ctx, &ConfigChange{
+			ConfigID:   configID,
+			ChangeType: "replication",
+		})
+	}
+	return result, nil
+}
+
+// Run 按间隔执行同步, 直到 ctx 结束; 未启用、未配置对端或只接收推送时直接返回
+func (s *ReplicationService) Run(ctx context.Context) {
+	if !s.syncs() {
+		return
+	}
+
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.SyncOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncOnce 立即同步所有配置的项目 (push 模式推送, pull 模式拉取)
+func (s *ReplicationService) SyncOnce(ctx context.Context) (*ReplicationStatus, error) {
+	if !s.syncs() {
+		return nil, ErrReplicationDisabled
+	}
+
+	for _, name := range s.opts.Projects {
+		var err error
+		if s.opts.Mode == model.ReplicationModePush {
+			err = s.push(ctx, name)
+		} else {
+			err = s.pull(ctx, name)
+		}
+		if err != nil {
+			s.record(name, nil, err)
+		}
+	}
+
+	s.mu.Lock()
+	s.lastRun = time.Now()
+	s.mu.Unlock()
+	return s.Status(), nil
+}
+
+// Status 获取复制状态
+func (s *ReplicationService) Status() *ReplicationStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	projects := make(map[string]*ProjectReplicationStatus, len(s.projects))
+	for name, status := range s.projects {
+		copied := *status
+		projects[name] = &copied
+	}
+	return &ReplicationStatus{
+		Enabled:        s.opts.Enabled,
+		Mode:           s.opts.Mode,
+		PeerURL:        s.opts.PeerURL,
+		ConflictPolicy: s.opts.ConflictPolicy,
+		LastRunAt:      s.lastRun,
+		Projects:       projects,
+	}
+}
+
+// syncs 本实例是否主动同步: 已启用、配置了对端且不是只接收推送的副本
+func (s *ReplicationService) syncs() bool {
+	return s.opts.Enabled && s.opts.PeerURL != "" && s.opts.Mode != model.ReplicationModeReceive
+}
+
+// push 导出本地快照并推送到对端
+func (s *ReplicationService) push(ctx context.Context, name string) error {
+	snapshot, err := s.repo.Snapshot(ctx, name)
+	if err != nil {
+		return fmt.Errorf("导出快照失败: %w", err)
+	}
+	result, err := s.client.PushSnapshot(ctx, snapshot)
+	if err != nil {
+		return err
+	}
+	s.record(name, result, nil)
+	return nil
+}
+
+// pull 从对端拉取快照并应用到本地
+func (s *ReplicationService) pull(ctx context.Context, name string) error {
+	snapshot, err := s.client.FetchSnapshot(ctx, name)
+	if err != nil {
+		return err
+	}
+	_, err = s.Apply(ctx, snapshot)
+	return err
+}
+
+// record 记录项目同步结果
+func (s *ReplicationService) record(name string, result *model.ReplicationResult, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := &ProjectReplicationStatus{LastSyncAt: time.Now(), Result: result}
+	if err != nil {
+		status.LastError = err.Error()
+	}
+	s.projects[name] = status
+}
+
+// allowed 项目是否在复制范围内, 未配置项目列表时不限制
+func (s *ReplicationService) allowed(name string) bool {
+	if len(s.opts.Projects) == 0 {
+		return true
+	}
+	for _, p := range s.opts.Projects {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplicationClient 对端实例复制接口客户端
+type ReplicationClient struct {
+	peerURL string
+	token   string
+	http    *http.Client
+}
+
+// NewReplicationClient 创建复制客户端
+func NewReplicationClient(peerURL, token string) *ReplicationClient {
+	return &ReplicationClient{
+		peerURL: strings.TrimRight(peerURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// FetchSnapshot 从对端拉取项目快照
+func (c *ReplicationClient) FetchSnapshot(ctx context.Context, name string) (*model.ReplicationSnapshot, error) {
+	var snapshot model.ReplicationSnapshot
+	path := "/api/replication/projects/" + url.PathEscape(name) + "/snapshot"
+	if err := c.call(ctx, http.MethodGet, path, nil, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// PushSnapshot 推送项目快照到对端
+func (c *ReplicationClient) PushSnapshot(ctx context.Context, snapshot *model.ReplicationSnapshot) (*model.ReplicationResult, error) {
+	body, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	var result model.ReplicationResult
+	if err := c.call(ctx, http.MethodPost, "/api/replication/apply", bytes.NewReader(body), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// call 调用对端复制接口
+func (c *ReplicationClient) call(ctx context.Context, method, path string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.peerURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(ReplicationTokenHeader, c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求对端失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("对端返回 %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"confighub/internal/repository"
+)
+
+// resiliencePingTimeout 单次数据库探测的超时
+const resiliencePingTimeout = 2 * time.Second
+
+// ResilienceStatus 数据库可用性状态
+type ResilienceStatus struct {
+	Enabled   bool       `json:"enabled"`
+	Degraded  bool       `json:"degraded"`
+	Since     *time.Time `json:"since,omitempty"` // 进入降级的时间
+	Reason    string     `json:"reason,omitempty"`
+	CheckedAt time.Time  `json:"checked_at"`
+}
+
+// ResilienceService 数据库故障降级服务
+// 定期探测数据库, 连续失败达到阈值后进入降级: 公开读取接口返回缓存中最近一次下发的内容, 写请求被拒绝;
+// 探测恢复后自动退出降级
+type ResilienceService struct {
+	migrationRepo *repository.MigrationRepository
+	enabled       bool
+	threshold     int
+
+	mu       sync.RWMutex
+	failures int
+	status   *ResilienceStatus
+}
+
+// NewResilienceService 创建降级服务, enabled 为 false 时始终不降级
+func NewResilienceService(migrationRepo *repository.MigrationRepository, enabled bool, threshold int) *ResilienceService {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &ResilienceService{
+		migrationRepo: migrationRepo,
+		enabled:       enabled,
+		threshold:     threshold,
+		status:        &ResilienceStatus{Enabled: enabled},
+	}
+}
+
+// Check 探测一次数据库, 返回是否发生了降级状态切换
+func (s *ResilienceService) Check(ctx context.Context) bool {
+	if !s.enabled {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resiliencePingTimeout)
+	err := s.migrationRepo.Ping(ctx)
+	cancel()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	status := *s.status
+	status.CheckedAt = now
+	if err == nil {
+		s.failures = 0
+		status.Degraded, status.Since, status.Reason = false, nil, ""
+	} else {
+		s.failures++
+		status.Reason = "数据库不可用: " + err.Error()
+		if s.failures >= s.threshold && !status.Degraded {
+			status.Degraded, status.Since = true, &now
+		}
+	}
+	changed := status.Degraded != s.status.Degraded
+	s.status = &status
+	return changed
+}
+
+// Run 按间隔探测数据库, 降级状态切换时回调 onChange
+func (s *ResilienceService) Run(ctx context.Context, interval time.Duration, onChange func(status *ResilienceStatus)) {
+	if !s.enabled {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.Check(ctx) && onChange != nil {
+				onChange(s.Status())
+			}
+		}
+	}
+}
+
+// Status 获取最近一次探测结果
+func (s *ResilienceService) Status() *ResilienceStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := *s.status
+	return &status
+}
+
+// Degraded 当前是否处于降级状态
+func (s *ResilienceService) Degraded() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.status.Degraded
+}
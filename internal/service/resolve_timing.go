@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// 配置解析的耗时阶段
+const (
+	ResolveStageDB      = iota // 热点缓存未命中时从数据库 (或 Redis 读取缓存) 加载
+	ResolveStageCache          // 热点缓存查找
+	ResolveStageGray           // 灰度判定及灰度版本加载
+	ResolveStageDecrypt        // 按权限解密敏感字段
+	resolveStageCount
+)
+
+// ResolveStageNames 各阶段在指标中的名称
+var ResolveStageNames = [resolveStageCount]string{"db", "cache", "gray", "decrypt"}
+
+// ResolveLatencyBuckets 配置解析各阶段耗时直方图的桶上界 (秒)
+var ResolveLatencyBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+
+// ResolveTiming 一次公开读取请求中配置解析各阶段的累计耗时, 批量读取时累计全部配置
+// 同一请求内按顺序解析, 不可并发使用
+type ResolveTiming struct {
+	durations [resolveStageCount]time.Duration
+	used      [resolveStageCount]bool
+}
+
+type resolveTimingKey struct{}
+
+// WithResolveTiming 在上下文中携带耗时记录, 解析路径上的服务据此累计各阶段耗时
+func WithResolveTiming(ctx context.Context, timing *ResolveTiming) context.Context {
+	return context.WithValue(ctx, resolveTimingKey{}, timing)
+}
+
+// ResolveTimingFrom 获取上下文中的耗时记录, 没有时返回 nil
+func ResolveTimingFrom(ctx context.Context) *ResolveTiming {
+	timing, _ := ctx.Value(resolveTimingKey{}).(*ResolveTiming)
+	return timing
+}
+
+// Add 累计一个阶段的耗时, 接收者为 nil 时忽略
+func (t *ResolveTiming) Add(stage int, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.durations[stage] += d
+	t.used[stage] = true
+}
+
+// Since 累计一个阶段从 start 到现在的耗时
+func (t *ResolveTiming) Since(stage int, start time.Time) {
+	t.Add(stage, time.Since(start))
+}
+
+// Duration 阶段的累计耗时
+func (t *ResolveTiming) Duration(stage int) time.Duration {
+	return t.durations[stage]
+}
+
+// ResolveStats 配置解析各阶段的耗时统计
+type ResolveStats struct {
+	stages [resolveStageCount]*latencyHistogram
+}
+
+// ResolveStageStats 一个阶段的耗时统计
+type ResolveStageStats struct {
+	Stage        string   `json:"stage"`
+	Latency      []uint64 `json:"latency"` // 落入各桶的次数, 与 ResolveLatencyBuckets 对应, 最后一项为 +Inf
+	LatencySum   float64  `json:"latency_sum"`
+	LatencyCount uint64   `json:"latency_count"` // 经过该阶段的请求数
+}
+
+// NewResolveStats 创建配置解析耗时统计
+func NewResolveStats() *ResolveStats {
+	s := &ResolveStats{}
+	for i := range s.stages {
+		s.stages[i] = newLatencyHistogram(ResolveLatencyBuckets)
+	}
+	return s
+}
+
+// Record 记录一次请求中经过的各阶段耗时
+func (s *ResolveStats) Record(timing *ResolveTiming) {
+	for stage, used := range timing.used {
+		if used {
+			s.stages[stage].observe(timing.durations[stage])
+		}
+	}
+}
+
+// Stats 获取各阶段的耗时统计
+func (s *ResolveStats) Stats() []ResolveStageStats {
+	stats := make([]ResolveStageStats, 0, resolveStageCount)
+	for stage, h := range s.stages {
+		st := ResolveStageStats{Stage: ResolveStageNames[stage]}
+		st.Latency, st.LatencySum, st.LatencyCount = h.snapshot()
+		stats = append(stats, st)
+	}
+	return stats
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+)
+
+var ErrInvalidVersionRetention = errors.New("无效的版本保留策略")
+
+// VersionRetention 项目级版本保留策略, 保存在项目设置的 version_retention 中
+// 超出最近 keep_versions 个版本且早于 keep_days 天的版本会被清理, 只设置一项时只按该项判断;
+// 最新版本和被发布记录引用的版本始终保留
+type VersionRetention struct {
+	KeepVersions int `json:"keep_versions"` // 每个配置至少保留最近的版本数, 0 表示不按数量保留
+	KeepDays     int `json:"keep_days"`     // 保留最近多少天内创建的版本, 0 表示不按时间保留
+}
+
+// Enabled 是否设置了保留策略, 未设置时不清理
+func (r *VersionRetention) Enabled() bool {
+	return r.KeepVersions > 0 || r.KeepDays > 0
+}
+
+// Validate 校验保留策略
+func (r *VersionRetention) Validate() error {
+	if r.KeepVersions < 0 || r.KeepDays < 0 {
+		return ErrInvalidVersionRetention
+	}
+	return nil
+}
+
+// ProjectPruneResult 单个项目的清理结果
+type ProjectPruneResult struct {
+	ProjectID int64             `json:"project_id"`
+	Name      string            `json:"name"`
+	Retention *VersionRetention `json:"retention"`
+	Configs   int               `json:"configs"`  // 有版本被清理的配置数
+	Versions  int64             `json:"versions"` // 清理的版本数, 预演时为将清理的版本数
+}
+
+// PruneResult 一次清理的结果
+type PruneResult struct {
+	DryRun   bool                  `json:"dry_run"`
+	Projects []*ProjectPruneResult `json:"projects"`
+	Versions int64                 `json:"versions"`
+	Duration float64               `json:"duration_seconds"`
+}
+
+// PruneStats 版本清理统计, 只包含实际执行的清理
+type PruneStats struct {
+	Runs         uint64     `json:"runs"`
+	Failures     uint64     `json:"failures"`
+	Versions     uint64     `json:"versions"` // 累计清理的版本数
+	LastRunAt    *time.Time `json:"last_run_at,omitempty"`
+	LastDuration float64    `json:"last_duration_seconds"`
+}
+
+// RetentionService 配置版本保留策略与清理
+type RetentionService struct {
+	retentionRepo *repository.RetentionRepository
+	projectRepo   *repository.ProjectRepository
+	configRepo    *repository.ConfigRepository
+
+	mu      sync.Mutex // 串行化清理, 后台任务与手动触发不会同时执行
+	statsMu sync.Mutex
+	stats   PruneStats
+}
+
+// NewRetentionService 创建版本保留服务
+func NewRetentionService(retentionRepo *repository.RetentionRepository, projectRepo *repository.ProjectRepository, configRepo *repository.ConfigRepository) *RetentionService {
+	return &RetentionService{
+		retentionRepo: retentionRepo,
+		projectRepo:   projectRepo,
+		configRepo:    configRepo,
+	}
+}
+
+// projectRetention 解析项目设置中的保留策略, 未设置时返回空策略 (不清理)
+func projectRetention(project *model.Project) *VersionRetention {
+	retention := &VersionRetention{}
+	if !projectSetting(project, "version_retention", retention) {
+		retention = &VersionRetention{}
+	}
+	return retention
+}
+
+// Get 获取项目的版本保留策略
+func (s *RetentionService) Get(ctx context.Context, projectID int64) (*VersionRetention, error) {
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	return projectRetention(project), nil
+}
+
+// Update 更新项目的版本保留策略, 在下一次清理时生效
+func (s *RetentionService) Update(ctx context.Context, projectID int64, retention *VersionRetention) error {
+	if err := retention.Validate(); err != nil {
+		return err
+	}
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return ErrProjectNotFound
+	}
+	if err := setProjectSetting(project, "version_retention", retention); err != nil {
+		return err
+	}
+	return s.projectRepo.Update(ctx, project)
+}
+
+// Prune 按保留策略清理版本, projectID 为 0 时清理所有设置了策略的未归档项目
+// 指定项目时即使已归档也会清理; 单个配置一次最多清理 1000 个版本, 剩余的在下一次清理
+func (s *RetentionService) Prune(ctx context.Context, projectID int64, dryRun bool) (*PruneResult, error) {
+	var projects []*model.Project
+	if projectID > 0 {
+		project, err := s.projectRepo.GetByID(ctx, projectID)
+		if err != nil {
+			return nil, ErrProjectNotFound
+		}
+		projects = []*model.Project{project}
+	} else {
+		var err error
+		if projects, err = s.projectRepo.List(ctx, 0, false); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := time.Now()
+	result := &PruneResult{DryRun: dryRun, Projects: []*ProjectPruneResult{}}
+	var err error
+	for _, project := range projects {
+		retention := projectRetention(project)
+		if !retention.Enabled() {
+			continue
+		}
+		var pruned *ProjectPruneResult
+		if pruned, err = s.pruneProject(ctx, project, retention, dryRun); pruned != nil {
+			result.Projects = append(result.Projects, pruned)
+			result.Versions += pruned.Versions
+		}
+		if err != nil {
+			break
+		}
+	}
+	result.Duration = time.Since(start).Seconds()
+
+	if !dryRun {
+		s.record(start, result, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// pruneProject 清理项目下所有配置超出保留策略的版本, 出错时返回已完成部分的结果
+func (s *RetentionService) pruneProject(ctx context.Context, project *model.Project, retention *VersionRetention, dryRun bool) (*ProjectPruneResult, error) {
+	configs, err := s.configRepo.List(ctx, project.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var before time.Time
+	if retention.KeepDays > 0 {
+		before = time.Now().AddDate(0, 0, -retention.KeepDays)
+	}
+	result := &ProjectPruneResult{ProjectID: project.ID, Name: project.Name, Retention: retention}
+	for _, config := range configs {
+		ids, err := s.retentionRepo.PruneCandidates(ctx, config.ID, retention.KeepVersions, before)
+		if err != nil {
+			return result, err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		count := int64(len(ids))
+		if !dryRun {
+			if count, err = s.retentionRepo.DeleteVersions(ctx, ids); err != nil {
+				return result, err
+			}
+		}
+		result.Configs++
+		result.Versions += count
+	}
+	return result, nil
+}
+
+// record 记录一次清理的统计
+func (s *RetentionService) record(start time.Time, result *PruneResult, err error) {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+
+	s.stats.Runs++
+	if err != nil {
+		s.stats.Failures++
+	}
+	s.stats.Versions += uint64(result.Versions)
+	s.stats.LastRunAt = &start
+	s.stats.LastDuration = result.Duration
+}
+
+// Stats 获取版本清理统计
+func (s *RetentionService) Stats() PruneStats {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	return s.stats
+}
+
+// RunPrune 定期按保留策略清理版本
+func (s *RetentionService) RunPrune(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Prune(ctx, 0, false); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
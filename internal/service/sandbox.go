@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"confighub/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// 沙箱有效期
+const (
+	DefaultSandboxTTL = 24 * time.Hour
+	MaxSandboxTTL     = 7 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidSandboxTTL = errors.New("沙箱有效期需在 1 小时到 7 天之间")
+)
+
+// SandboxResult 克隆沙箱结果
+type SandboxResult struct {
+	Environment Environment `json:"environment"`
+	Configs     int         `json:"configs"` // 复制的配置数
+}
+
+// CloneToSandbox 将环境当前生效的正式发布复制到一个临时沙箱环境, 用于安全地试验配置变更
+// name 为空时自动生成; 沙箱到期后由 RunSandboxCleanup 自动删除
+func (s *EnvironmentService) CloneToSandbox(ctx context.Context, projectID int64, from, name string, ttl time.Duration, author string) (*SandboxResult, error) {
+	if ttl == 0 {
+		ttl = DefaultSandboxTTL
+	}
+	if ttl < time.Hour || ttl > MaxSandboxTTL {
+		return nil, ErrInvalidSandboxTTL
+	}
+
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	envs := projectEnvironments(project)
+	idx := findEnvironment(envs, from)
+	if idx < 0 {
+		return nil, ErrEnvironmentNotFound
+	}
+
+	if name == "" {
+		name = "sandbox-" + from + "-" + strings.ReplaceAll(uuid.New().String(), "-", "")[:6]
+	}
+	if findEnvironment(envs, name) >= 0 {
+		return nil, ErrEnvironmentExists
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	sandbox := Environment{
+		Name:        name,
+		Description: "沙箱 (克隆自 " + from + ")",
+		Sandbox:     true,
+		Source:      from,
+		ExpiresAt:   &expiresAt,
+	}
+	if err := setProjectEnvironments(project, append(envs, sandbox)); err != nil {
+		return nil, err
+	}
+
+	cloned, err := s.envRepo.CloneReleased(ctx, project, from, name, author)
+	if err != nil {
+		return nil, err
+	}
+
+	sandbox.Order = len(envs) + 1
+	return &SandboxResult{Environment: sandbox, Configs: cloned}, nil
+}
+
+// CleanupExpiredSandboxes 删除所有项目中已过期的沙箱环境, 返回删除的沙箱数
+func (s *EnvironmentService) CleanupExpiredSandboxes(ctx context.Context) (int, error) {
+	projects, err := s.projectRepo.List(ctx, 0, true)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	removed := 0
+	for _, project := range projects {
+		for {
+			envs := projectEnvironments(project)
+			idx := -1
+			for i, env := range envs {
+				if env.Sandbox && env.ExpiresAt != nil && now.After(*env.ExpiresAt) {
+					idx = i
+					break
+				}
+			}
+			if idx < 0 {
+				break
+			}
+			if err := s.purgeSandbox(ctx, project, envs, idx); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// RunSandboxCleanup 按间隔清理过期沙箱, 直到 ctx 结束
+func (s *EnvironmentService) RunSandboxCleanup(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.CleanupExpiredSandboxes(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// purgeSandbox 从环境列表中移除沙箱并丢弃其配置、版本和发布
+func (s *EnvironmentService) purgeSandbox(ctx context.Context, project *model.Project, envs []Environment, idx int) error {
+	name := envs[idx].Name
+	envs = append(envs[:idx], envs[idx+1:]...)
+	if err := setProjectEnvironments(project, envs); err != nil {
+		return err
+	}
+
+	configs, err := s.envRepo.Purge(ctx, project, name)
+	if err != nil {
+		return err
+	}
+	for _, config := range configs {
+		s.notifySvc.NotifyChange(ctx, deleteChange(config))
+	}
+	return nil
+}
@@ -19,14 +19,18 @@ var (
 // SchemaService Schema 服务
 type SchemaService struct {
 	configRepo  *repository.ConfigRepository
-	versionRepo *repository.VersionRepository
+	versionRepo repository.VersionStore
+	projectRepo *repository.ProjectRepository
+	protoRepo   *repository.ProtoDescriptorRepository
 }
 
 // NewSchemaService 创建 Schema 服务
-func NewSchemaService(configRepo *repository.ConfigRepository, versionRepo *repository.VersionRepository) *SchemaService {
+func NewSchemaService(configRepo *repository.ConfigRepository, versionRepo repository.VersionStore, projectRepo *repository.ProjectRepository, protoRepo *repository.ProtoDescriptorRepository) *SchemaService {
 	return &SchemaService{
 		configRepo:  configRepo,
 		versionRepo: versionRepo,
+		projectRepo: projectRepo,
+		protoRepo:   protoRepo,
 	}
 }
 
@@ -131,9 +135,9 @@ func (s *SchemaService) Validate(ctx context.Context, schema, content string) (*
 	return result, nil
 }
 
-// ValidateConfig 验证配置内容是否符合其 Schema
+// ValidateConfig 验证配置内容是否符合其生效的 Schema (自身或继承的默认 Schema)
 func (s *SchemaService) ValidateConfig(ctx context.Context, configID int64, content string) (*ValidationResult, error) {
-	schema, err := s.Get(ctx, configID)
+	effective, err := s.Effective(ctx, configID)
 	if err != nil {
 		if err == ErrSchemaNotFound {
 			// 没有 Schema，跳过验证
@@ -142,7 +146,7 @@ func (s *SchemaService) ValidateConfig(ctx context.Context, configID int64, cont
 		return nil, err
 	}
 
-	return s.Validate(ctx, schema, content)
+	return s.Validate(ctx, effective.Schema, content)
 }
 
 // validateSchema 验证 Schema 是否有效
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"confighub/internal/model"
+)
+
+var (
+	ErrInvalidSchemaDefaults = errors.New("无效的默认 Schema 设置")
+)
+
+// Schema 来源
+const (
+	SchemaSourceConfig    = "config"    // 配置自身的 Schema
+	SchemaSourceNamespace = "namespace" // 命名空间级默认 Schema
+	SchemaSourceProject   = "project"   // 项目级默认 Schema
+)
+
+// SchemaDefaults 项目级默认 Schema, 保存在项目设置的 schema_defaults 中
+// 没有自身 Schema 的配置依次继承所在命名空间和项目的默认 Schema
+type SchemaDefaults struct {
+	Project    json.RawMessage            `json:"project,omitempty"`    // 项目级默认 Schema
+	Namespaces map[string]json.RawMessage `json:"namespaces,omitempty"` // 命名空间级默认 Schema, 优先于项目级
+	Exclude    []string                   `json:"exclude,omitempty"`    // 不继承默认 Schema 的配置名称, 以 * 结尾表示前缀匹配
+	Enforce    bool                       `json:"enforce"`              // 未单独设置校验策略的配置按 block 策略校验, 否则按 off
+}
+
+// EffectiveSchema 配置生效的 Schema 及其来源
+type EffectiveSchema struct {
+	Schema string `json:"schema"`
+	Source string `json:"source"` // config, namespace, project
+}
+
+// SchemaValidationError 配置内容不符合生效的 Schema
+type SchemaValidationError struct {
+	Source string
+	Errors []ValidationError
+}
+
+func (e *SchemaValidationError) Error() string {
+	return fmt.Sprintf("配置内容不符合 Schema (%s): %d 处错误", e.Source, len(e.Errors))
+}
+
+// projectSchemaDefaults 解析项目设置中的默认 Schema, 未设置时返回空设置
+func projectSchemaDefaults(project *model.Project) *SchemaDefaults {
+	defaults := &SchemaDefaults{}
+	if project == nil || !projectSetting(project, "schema_defaults", defaults) {
+		defaults = &SchemaDefaults{}
+	}
+	if defaults.Namespaces == nil {
+		defaults.Namespaces = map[string]json.RawMessage{}
+	}
+	if defaults.Exclude == nil {
+		defaults.Exclude = []string{}
+	}
+	return defaults
+}
+
+// excludes 配置是否不继承默认 Schema
+func (d *SchemaDefaults) excludes(name string) bool {
+	for _, pattern := range d.Exclude {
+		if pattern == name {
+			return true
+		}
+		if strings.HasSuffix(pattern, "*") && strings.HasPrefix(name, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveSchema 按 配置自身 > 命名空间 > 项目 的顺序确定生效的 Schema, 都没有时返回 nil
+func resolveSchema(defaults *SchemaDefaults, config *model.Config) *EffectiveSchema {
+	if config.SchemaJSON != "" {
+		return &EffectiveSchema{Schema: config.SchemaJSON, Source: SchemaSourceConfig}
+	}
+	if defaults == nil || defaults.excludes(config.Name) {
+		return nil
+	}
+	if schema, ok := defaults.Namespaces[config.Namespace]; ok && len(schema) > 0 {
+		return &EffectiveSchema{Schema: string(schema), Source: SchemaSourceNamespace}
+	}
+	if len(defaults.Project) > 0 && string(defaults.Project) != "null" {
+		return &EffectiveSchema{Schema: string(defaults.Project), Source: SchemaSourceProject}
+	}
+	return nil
+}
+
+// GetDefaults 获取项目的默认 Schema
+func (s *SchemaService) GetDefaults(ctx context.Context, projectID int64) (*SchemaDefaults, error) {
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	return projectSchemaDefaults(project), nil
+}
+
+// UpdateDefaults 更新项目的默认 Schema
+func (s *SchemaService) UpdateDefaults(ctx context.Context, projectID int64, defaults *SchemaDefaults) error {
+	if len(defaults.Project) > 0 && string(defaults.Project) != "null" {
+		if err := s.validateSchema(string(defaults.Project)); err != nil {
+			return fmt.Errorf("%w: 项目级 Schema 无效", ErrInvalidSchemaDefaults)
+		}
+	}
+	for namespace, schema := range defaults.Namespaces {
+		if strings.TrimSpace(namespace) == "" {
+			return ErrInvalidSchemaDefaults
+		}
+		if err := s.validateSchema(string(schema)); err != nil {
+			return fmt.Errorf("%w: 命名空间 %s 的 Schema 无效", ErrInvalidSchemaDefaults, namespace)
+		}
+	}
+	for _, pattern := range defaults.Exclude {
+		if strings.TrimSpace(pattern) == "" {
+			return ErrInvalidSchemaDefaults
+		}
+	}
+
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return ErrProjectNotFound
+	}
+	if defaults.Namespaces == nil {
+		defaults.Namespaces = map[string]json.RawMessage{}
+	}
+	if defaults.Exclude == nil {
+		defaults.Exclude = []string{}
+	}
+	if err := setProjectSetting(project, "schema_defaults", defaults); err != nil {
+		return err
+	}
+	return s.projectRepo.Update(ctx, project)
+}
+
+// Effective 获取配置生效的 Schema, 没有任何 Schema 时返回 ErrSchemaNotFound
+func (s *SchemaService) Effective(ctx context.Context, configID int64) (*EffectiveSchema, error) {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+	return s.effective(ctx, config)
+}
+
+func (s *SchemaService) effective(ctx context.Context, config *model.Config) (*EffectiveSchema, error) {
+	var defaults *SchemaDefaults
+	if config.SchemaJSON == "" {
+		if project, err := s.projectRepo.GetByID(ctx, config.ProjectID); err == nil {
+			defaults = projectSchemaDefaults(project)
+		}
+	}
+	effective := resolveSchema(defaults, config)
+	if effective == nil {
+		return nil, ErrSchemaNotFound
+	}
+	return effective, nil
+}
+
+// Enforce 按配置的 Schema 校验策略校验 JSON 配置内容, block 策略下不符合时返回 SchemaValidationError
+// 策略为 off 或 warn、配置不是 JSON 或没有生效的 Schema 时不阻止写入; warn 策略的不符合项见 Warnings
+func (s *SchemaService) Enforce(ctx context.Context, config *model.Config, content string) error {
+	effective, policy := s.policyCheck(ctx, config)
+	if effective == nil || policy != model.SchemaPolicyBlock {
+		return nil
+	}
+
+	result, err := s.Validate(ctx, effective.Schema, content)
+	if err != nil {
+		return err
+	}
+	if !result.Valid {
+		return &SchemaValidationError{Source: effective.Source, Errors: result.Errors}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"confighub/internal/model"
+)
+
+var (
+	ErrInvalidSchemaPolicy = errors.New("无效的 Schema 校验策略")
+)
+
+// SchemaPolicyInfo 配置的 Schema 校验策略
+type SchemaPolicyInfo struct {
+	Policy    string `json:"policy"`    // 配置单独设置的策略, 为空表示继承项目设置
+	Effective string `json:"effective"` // 实际生效的策略: off, warn, block
+	Inherited bool   `json:"inherited"` // 是否继承项目的 schema_defaults.enforce
+}
+
+// resolveSchemaPolicy 配置单独设置的策略优先, 否则项目开启强制校验时为 block, 未开启时为 off
+func resolveSchemaPolicy(config *model.Config, defaults *SchemaDefaults) string {
+	if config.SchemaPolicy != "" {
+		return config.SchemaPolicy
+	}
+	if defaults != nil && defaults.Enforce {
+		return model.SchemaPolicyBlock
+	}
+	return model.SchemaPolicyOff
+}
+
+// policyCheck 返回需要校验时生效的 Schema 和策略; 配置不是 JSON、策略为 off 或没有生效的 Schema 时 Schema 为 nil
+func (s *SchemaService) policyCheck(ctx context.Context, config *model.Config) (*EffectiveSchema, string) {
+	if !jsonContent(config.FileType) {
+		return nil, model.SchemaPolicyOff
+	}
+	var defaults *SchemaDefaults
+	if project, err := s.projectRepo.GetByID(ctx, config.ProjectID); err == nil {
+		defaults = projectSchemaDefaults(project)
+	}
+	policy := resolveSchemaPolicy(config, defaults)
+	if policy == model.SchemaPolicyOff {
+		return nil, policy
+	}
+	return resolveSchema(defaults, config), policy
+}
+
+// GetPolicy 获取配置的 Schema 校验策略
+func (s *SchemaService) GetPolicy(ctx context.Context, configID int64) (*SchemaPolicyInfo, error) {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+	var defaults *SchemaDefaults
+	if project, err := s.projectRepo.GetByID(ctx, config.ProjectID); err == nil {
+		defaults = projectSchemaDefaults(project)
+	}
+	return &SchemaPolicyInfo{
+		Policy:    config.SchemaPolicy,
+		Effective: resolveSchemaPolicy(config, defaults),
+		Inherited: config.SchemaPolicy == "",
+	}, nil
+}
+
+// UpdatePolicy 设置配置的 Schema 校验策略, 为空表示继承项目设置
+// 只修改策略, 不校验已有版本; 已发布的不符合版本可通过预检报告查看
+func (s *SchemaService) UpdatePolicy(ctx context.Context, configID int64, policy string) (*SchemaPolicyInfo, error) {
+	switch policy {
+	case "", model.SchemaPolicyOff, model.SchemaPolicyWarn, model.SchemaPolicyBlock:
+	default:
+		return nil, fmt.Errorf("%w: 可选 off, warn, block, 为空表示继承项目设置", ErrInvalidSchemaPolicy)
+	}
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+	config.SchemaPolicy = policy
+	if err := s.configRepo.Update(ctx, config); err != nil {
+		return nil, err
+	}
+	return s.GetPolicy(ctx, configID)
+}
+
+// Warnings 返回 warn 策略下配置指定版本不符合 Schema 的项, 供写入或发布成功后提示
+func (s *SchemaService) Warnings(ctx context.Context, configID int64, version int) []ValidationError {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil
+	}
+	effective, policy := s.policyCheck(ctx, config)
+	if effective == nil || policy != model.SchemaPolicyWarn {
+		return nil
+	}
+	target, err := s.versionRepo.GetByConfigAndVersion(ctx, configID, version)
+	if err != nil {
+		return nil
+	}
+	result, err := s.Validate(ctx, effective.Schema, target.Content)
+	if err != nil || result.Valid {
+		return nil
+	}
+	return result.Errors
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"confighub/internal/model"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+var (
+	ErrProtoDescriptorNotFound = errors.New("Protobuf 描述符不存在")
+	ErrInvalidProtoDescriptor  = errors.New("无效的 Protobuf 描述符")
+	ErrInvalidProtobuf         = errors.New("配置内容不符合 Protobuf 描述符")
+)
+
+// protobuf 配置内容的格式
+const (
+	ProtoFormatText   = "text"   // text format, 如 name: "app" port: 8080
+	ProtoFormatBinary = "binary" // base64 编码的二进制
+	ProtoFormatJSON   = "json"   // protojson, 仅用于编辑器提交, 保存时转换为当前版本的格式
+)
+
+// maxProtoDescriptorSize 描述符集 (解码后) 的最大字节数
+const maxProtoDescriptorSize = 4 << 20
+
+// ProtoDescriptorInfo 描述符及其包含的文件和消息类型
+type ProtoDescriptorInfo struct {
+	*model.ProtoDescriptor
+	Files    []string `json:"files"`
+	Messages []string `json:"messages"`
+}
+
+// ProtoRendering protobuf 配置版本渲染的 JSON, 供对比和表单编辑器使用
+type ProtoRendering struct {
+	ConfigID    int64           `json:"config_id"`
+	Version     int             `json:"version"`
+	MessageType string          `json:"message_type"`
+	Format      string          `json:"format"` // 版本内容的格式: text, binary
+	JSON        json.RawMessage `json:"json"`
+}
+
+// parseProtoDescriptor 解析 base64 编码的描述符集并查找消息类型
+func parseProtoDescriptor(descriptorSet, messageType string) (protoreflect.MessageDescriptor, *protoregistry.Files, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(descriptorSet))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: 描述符集应为 base64 编码", ErrInvalidProtoDescriptor)
+	}
+	if len(raw) > maxProtoDescriptorSize {
+		return nil, nil, fmt.Errorf("%w: 描述符集超过 %d 字节", ErrInvalidProtoDescriptor, maxProtoDescriptorSize)
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(raw, &set); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidProtoDescriptor, err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v (生成时需加上 --include_imports)", ErrInvalidProtoDescriptor, err)
+	}
+
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(strings.TrimPrefix(messageType, ".")))
+	if err != nil {
+		return nil, files, fmt.Errorf("%w: 找不到消息类型 %s", ErrInvalidProtoDescriptor, messageType)
+	}
+	md, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, files, fmt.Errorf("%w: %s 不是消息类型", ErrInvalidProtoDescriptor, messageType)
+	}
+	return md, files, nil
+}
+
+// decodeProtoContent 按描述符解析配置内容, 依次尝试 protojson (以 { 开头)、text format 和 base64 编码的二进制
+func decodeProtoContent(md protoreflect.MessageDescriptor, content string) (*dynamicpb.Message, string, error) {
+	trimmed := strings.TrimSpace(content)
+	msg := dynamicpb.NewMessage(md)
+	if strings.HasPrefix(trimmed, "{") {
+		if err := protojson.Unmarshal([]byte(trimmed), msg); err != nil {
+			return nil, "", fmt.Errorf("%w: %v", ErrInvalidProtobuf, err)
+		}
+		return msg, ProtoFormatJSON, nil
+	}
+
+	textErr := prototext.Unmarshal([]byte(content), msg)
+	if textErr == nil {
+		return msg, ProtoFormatText, nil
+	}
+
+	// 二进制解析对任意字节都较宽松, 存在未知字段时视为不匹配
+	if raw, err := base64.StdEncoding.DecodeString(trimmed); err == nil && len(raw) > 0 {
+		binary := dynamicpb.NewMessage(md)
+		if err := proto.Unmarshal(raw, binary); err == nil && len(binary.GetUnknown()) == 0 {
+			return binary, ProtoFormatBinary, nil
+		}
+	}
+	return nil, "", fmt.Errorf("%w: %v", ErrInvalidProtobuf, textErr)
+}
+
+// encodeProtoContent 按格式编码消息, JSON 以外的格式与解析时一致
+func encodeProtoContent(msg proto.Message, format string) (string, error) {
+	if format == ProtoFormatBinary {
+		raw, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+		if err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(raw), nil
+	}
+	text, err := prototext.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
+
+// renderProtoJSON 将消息渲染为缩进的 JSON, 字段名使用 .proto 中的名称
+func renderProtoJSON(msg proto.Message) (string, error) {
+	raw, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+	// protojson 的输出空白不稳定, 重新缩进以便逐行对比
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, bytes.TrimSpace(raw), "", "  "); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// descriptorInfo 填充描述符中的文件和消息类型
+func descriptorInfo(descriptor *model.ProtoDescriptor, files *protoregistry.Files) *ProtoDescriptorInfo {
+	info := &ProtoDescriptorInfo{ProtoDescriptor: descriptor, Files: []string{}, Messages: []string{}}
+	if files == nil {
+		return info
+	}
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		info.Files = append(info.Files, fd.Path())
+		messages := fd.Messages()
+		for i := 0; i < messages.Len(); i++ {
+			info.Messages = append(info.Messages, string(messages.Get(i).FullName()))
+		}
+		return true
+	})
+	sort.Strings(info.Files)
+	sort.Strings(info.Messages)
+	return info
+}
+
+// GetProtoDescriptor 获取 protobuf 配置的描述符
+func (s *SchemaService) GetProtoDescriptor(ctx context.Context, configID int64) (*ProtoDescriptorInfo, error) {
+	if _, err := s.configRepo.GetByID(ctx, configID); err != nil {
+		return nil, ErrConfigNotFound
+	}
+	descriptor, err := s.protoRepo.GetByConfig(ctx, configID)
+	if err != nil {
+		return nil, ErrProtoDescriptorNotFound
+	}
+	_, files, _ := parseProtoDescriptor(descriptor.DescriptorSet, descriptor.MessageType)
+	return descriptorInfo(descriptor, files), nil
+}
+
+// UpdateProtoDescriptor 设置 protobuf 配置的描述符, 当前版本内容须符合新的描述符
+func (s *SchemaService) UpdateProtoDescriptor(ctx context.Context, configID int64, descriptorSet, messageType string, userID int64) (*ProtoDescriptorInfo, error) {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+	if config.FileType != "protobuf" {
+		return nil, fmt.Errorf("%w: 仅 protobuf 配置可以设置描述符", ErrInvalidProtoDescriptor)
+	}
+
+	messageType = strings.TrimPrefix(strings.TrimSpace(messageType), ".")
+	md, files, err := parseProtoDescriptor(descriptorSet, messageType)
+	if err != nil {
+		return nil, err
+	}
+	if current, err := s.versionRepo.GetByConfigAndVersion(ctx, configID, config.CurrentVersion); err == nil {
+		if _, _, err := decodeProtoContent(md, current.Content); err != nil {
+			return nil, fmt.Errorf("当前版本 %d: %w", current.Version, err)
+		}
+	}
+
+	descriptor := &model.ProtoDescriptor{
+		ProjectID:     config.ProjectID,
+		ConfigID:      configID,
+		MessageType:   messageType,
+		DescriptorSet: strings.TrimSpace(descriptorSet),
+		UpdatedBy:     userID,
+	}
+	if err := s.protoRepo.Upsert(ctx, descriptor); err != nil {
+		return nil, err
+	}
+	return descriptorInfo(descriptor, files), nil
+}
+
+// DeleteProtoDescriptor 删除 protobuf 配置的描述符, 之后的修改不再校验
+func (s *SchemaService) DeleteProtoDescriptor(ctx context.Context, configID int64) error {
+	if _, err := s.configRepo.GetByID(ctx, configID); err != nil {
+		return ErrConfigNotFound
+	}
+	deleted, err := s.protoRepo.Delete(ctx, configID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrProtoDescriptorNotFound
+	}
+	return nil
+}
+
+// NormalizeProto 按描述符校验 protobuf 配置的新内容, 没有描述符时不校验
+// 编辑器提交的 protojson 转换为当前版本的格式 (text 或 binary) 保存
+func (s *SchemaService) NormalizeProto(ctx context.Context, config *model.Config, content string) (string, error) {
+	if config.FileType != "protobuf" {
+		return content, nil
+	}
+	descriptor, err := s.protoRepo.GetByConfig(ctx, config.ID)
+	if err != nil {
+		return content, nil
+	}
+	md, _, err := parseProtoDescriptor(descriptor.DescriptorSet, descriptor.MessageType)
+	if err != nil {
+		return "", err
+	}
+
+	msg, format, err := decodeProtoContent(md, content)
+	if err != nil {
+		return "", err
+	}
+	if format != ProtoFormatJSON {
+		return content, nil
+	}
+
+	target := ProtoFormatText
+	if current, err := s.versionRepo.GetByConfigAndVersion(ctx, config.ID, config.CurrentVersion); err == nil {
+		if _, currentFormat, err := decodeProtoContent(md, current.Content); err == nil {
+			target = currentFormat
+		}
+	}
+	return encodeProtoContent(msg, target)
+}
+
+// RenderProto 将 protobuf 配置的指定版本渲染为 JSON, version 为 0 表示当前版本
+func (s *SchemaService) RenderProto(ctx context.Context, configID int64, version int) (*ProtoRendering, error) {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+	descriptor, err := s.protoRepo.GetByConfig(ctx, configID)
+	if err != nil {
+		return nil, ErrProtoDescriptorNotFound
+	}
+	if version == 0 {
+		version = config.CurrentVersion
+	}
+	target, err := s.versionRepo.GetByConfigAndVersion(ctx, configID, version)
+	if err != nil {
+		return nil, ErrVersionNotFound
+	}
+
+	md, _, err := parseProtoDescriptor(descriptor.DescriptorSet, descriptor.MessageType)
+	if err != nil {
+		return nil, err
+	}
+	msg, format, err := decodeProtoContent(md, target.Content)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := renderProtoJSON(msg)
+	if err != nil {
+		return nil, err
+	}
+	return &ProtoRendering{
+		ConfigID:    configID,
+		Version:     target.Version,
+		MessageType: descriptor.MessageType,
+		Format:      format,
+		JSON:        json.RawMessage(rendered),
+	}, nil
+}
+
+// protoJSON 按配置的描述符将 protobuf 内容渲染为 JSON, 没有描述符或无法解析时返回 false
+func (s *SchemaService) protoJSON(ctx context.Context, configID int64, content string) (string, bool) {
+	descriptor, err := s.protoRepo.GetByConfig(ctx, configID)
+	if err != nil {
+		return "", false
+	}
+	md, _, err := parseProtoDescriptor(descriptor.DescriptorSet, descriptor.MessageType)
+	if err != nil {
+		return "", false
+	}
+	msg, _, err := decodeProtoContent(md, content)
+	if err != nil {
+		return "", false
+	}
+	rendered, err := renderProtoJSON(msg)
+	if err != nil {
+		return "", false
+	}
+	return rendered, true
+}
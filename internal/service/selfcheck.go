@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"confighub/internal/config"
+	"confighub/internal/repository"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// selfCheckTimeout 单项外部依赖探测的超时时间
+const selfCheckTimeout = 3 * time.Second
+
+// SelfCheckReport 启动自检报告
+type SelfCheckReport struct {
+	Status    string           `json:"status"` // 所有结果中最严重的级别
+	Env       string           `json:"env"`
+	Findings  []config.Finding `json:"findings"`
+	CheckedAt time.Time        `json:"checked_at"`
+}
+
+// SelfCheckService 启动自检服务
+// 合并配置项检查结果与数据库、Redis 的连通性探测, 保留最近一次报告
+type SelfCheckService struct {
+	migrationRepo *repository.MigrationRepository
+	rdb           *redis.Client
+	env           string
+	static        []config.Finding
+
+	mu     sync.RWMutex
+	report *SelfCheckReport
+}
+
+// NewSelfCheckService 创建自检服务, static 为配置项检查结果; rdb 为 nil 表示 Redis 未连接
+func NewSelfCheckService(migrationRepo *repository.MigrationRepository, rdb *redis.Client, env string, static []config.Finding) *SelfCheckService {
+	return &SelfCheckService{
+		migrationRepo: migrationRepo,
+		rdb:           rdb,
+		env:           env,
+		static:        static,
+	}
+}
+
+// Report 获取最近一次自检报告, 尚未执行时立即执行
+func (s *SelfCheckService) Report(ctx context.Context) *SelfCheckReport {
+	s.mu.RLock()
+	report := s.report
+	s.mu.RUnlock()
+	if report != nil {
+		return report
+	}
+	return s.Refresh(ctx)
+}
+
+// Refresh 重新探测外部依赖并生成报告
+func (s *SelfCheckService) Refresh(ctx context.Context) *SelfCheckReport {
+	findings := make([]config.Finding, 0, len(s.static)+2)
+	findings = append(findings, s.static...)
+	findings = append(findings, s.checkDatabase(ctx), s.checkRedis(ctx))
+
+	report := &SelfCheckReport{
+		Status:    config.SeverityOK,
+		Env:       s.env,
+		Findings:  findings,
+		CheckedAt: time.Now(),
+	}
+	for _, f := range findings {
+		if severityRank(f.Severity) > severityRank(report.Status) {
+			report.Status = f.Severity
+		}
+	}
+
+	s.mu.Lock()
+	s.report = report
+	s.mu.Unlock()
+	return report
+}
+
+// checkDatabase 探测数据库是否可达
+func (s *SelfCheckService) checkDatabase(ctx context.Context) config.Finding {
+	ctx, cancel := context.WithTimeout(ctx, selfCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := s.migrationRepo.Ping(ctx); err != nil {
+		return config.Finding{
+			Check:    "database",
+			Severity: config.SeverityError,
+			Message:  "数据库不可达: " + err.Error(),
+			Hint:     "检查 database.dsn 及网络连通性",
+		}
+	}
+	return config.Finding{
+		Check:    "database",
+		Severity: config.SeverityOK,
+		Message:  "数据库可达, 耗时 " + time.Since(start).Round(time.Millisecond).String(),
+	}
+}
+
+// checkRedis 探测 Redis 是否可达及其部署模式
+func (s *SelfCheckService) checkRedis(ctx context.Context) config.Finding {
+	if s.rdb == nil {
+		return config.Finding{
+			Check:    "redis_connection",
+			Severity: config.SeverityWarn,
+			Message:  "Redis 未连接, 缓存已禁用, 变更通知仅在本实例内生效",
+			Hint:     "检查 redis.addr 及启动日志",
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, selfCheckTimeout)
+	defer cancel()
+
+	if err := s.rdb.Ping(ctx).Err(); err != nil {
+		return config.Finding{
+			Check:    "redis_connection",
+			Severity: config.SeverityError,
+			Message:  "Redis 不可达: " + err.Error(),
+			Hint:     "检查 redis.addr、redis.password 及 TLS 设置",
+		}
+	}
+
+	// 部分托管 Redis 禁用了 INFO 命令, 此时不判断模式
+	info, err := s.rdb.Info(ctx, "server").Result()
+	if err != nil {
+		return config.Finding{Check: "redis_connection", Severity: config.SeverityOK, Message: "Redis 可达"}
+	}
+	mode := redisInfoField(info, "redis_mode")
+	if mode == "cluster" {
+		return config.Finding{
+			Check:    "redis_connection",
+			Severity: config.SeverityWarn,
+			Message:  "Redis 运行在集群模式, 但客户端按单节点连接, 键可能被重定向",
+			Hint:     "使用单节点或主从模式的 Redis",
+		}
+	}
+	if mode == "" {
+		mode = "standalone"
+	}
+	return config.Finding{
+		Check:    "redis_connection",
+		Severity: config.SeverityOK,
+		Message:  "Redis 可达, 模式 " + mode + ", 版本 " + redisInfoField(info, "redis_version"),
+	}
+}
+
+// redisInfoField 从 INFO 输出中读取字段
+func redisInfoField(info, field string) string {
+	for _, line := range strings.Split(info, "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), field+":"); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// severityRank 级别排序, 用于汇总报告状态
+func severityRank(severity string) int {
+	switch severity {
+	case config.SeverityError:
+		return 2
+	case config.SeverityWarn:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"time"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+var (
+	ErrSigningKeyNotFound = errors.New("签名密钥不存在")
+	ErrSigningKeyExists   = errors.New("签名公钥已登记")
+	ErrInvalidSigningKey  = errors.New("无效的签名公钥")
+	ErrInvalidSignature   = errors.New("签名未通过任何已登记密钥的验证")
+)
+
+// maxSignatureSize 分离签名的最大字节数
+const maxSignatureSize = 64 << 10
+
+// pgpSignatureHeader ASCII armor 格式 OpenPGP 签名的起始行
+const pgpSignatureHeader = "-----BEGIN PGP SIGNATURE-----"
+
+// SignatureService 配置版本签名服务
+// 作者或 CI 对版本内容生成分离签名, 附加时使用项目登记的公钥验证, 版本列表中展示验证状态
+type SignatureService struct {
+	sigRepo     *repository.SignatureRepository
+	configRepo  *repository.ConfigRepository
+	versionRepo repository.VersionStore
+	projectRepo *repository.ProjectRepository
+}
+
+// NewSignatureService 创建签名服务
+func NewSignatureService(sigRepo *repository.SignatureRepository, configRepo *repository.ConfigRepository, versionRepo repository.VersionStore, projectRepo *repository.ProjectRepository) *SignatureService {
+	return &SignatureService{
+		sigRepo:     sigRepo,
+		configRepo:  configRepo,
+		versionRepo: versionRepo,
+		projectRepo: projectRepo,
+	}
+}
+
+// SignatureInfo 版本签名及其密钥信息
+type SignatureInfo struct {
+	*model.VersionSignature
+	KeyName     string `json:"key_name"`
+	KeyType     string `json:"key_type"`
+	Fingerprint string `json:"fingerprint"`
+	Identity    string `json:"identity,omitempty"`
+	Status      string `json:"status"` // verified, revoked, invalid
+}
+
+// signingKeyFingerprint 解析公钥并计算指纹: OpenPGP 为主密钥指纹, 其他为 DER 编码公钥的 SHA-256
+func signingKeyFingerprint(keyType, publicKey string) (string, error) {
+	switch keyType {
+	case model.SigningKeyGPG:
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(publicKey))
+		if err != nil || len(entities) != 1 {
+			return "", ErrInvalidSigningKey
+		}
+		return strings.ToUpper(hex.EncodeToString(entities[0].PrimaryKey.Fingerprint[:])), nil
+	case model.SigningKeySigstore:
+		block, _ := pem.Decode([]byte(publicKey))
+		if block == nil || block.Type != "PUBLIC KEY" {
+			return "", ErrInvalidSigningKey
+		}
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return "", ErrInvalidSigningKey
+		}
+		switch parsed.(type) {
+		case *ecdsa.PublicKey, ed25519.PublicKey:
+		default:
+			return "", ErrInvalidSigningKey
+		}
+		sum := sha256.Sum256(block.Bytes)
+		return hex.EncodeToString(sum[:]), nil
+	}
+	return "", ErrInvalidSigningKey
+}
+
+// verifySignature 使用登记的公钥验证内容的分离签名
+func verifySignature(key *model.SigningKey, content []byte, signature string) bool {
+	switch key.Type {
+	case model.SigningKeyGPG:
+		keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key.PublicKey))
+		if err != nil {
+			return false
+		}
+		if strings.HasPrefix(signature, pgpSignatureHeader) {
+			_, err = openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(content), strings.NewReader(signature))
+			return err == nil
+		}
+		raw, err := base64.StdEncoding.DecodeString(signature)
+		if err != nil {
+			return false
+		}
+		_, err = openpgp.CheckDetachedSignature(keyring, bytes.NewReader(content), bytes.NewReader(raw))
+		return err == nil
+	case model.SigningKeySigstore:
+		block, _ := pem.Decode([]byte(key.PublicKey))
+		if block == nil {
+			return false
+		}
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return false
+		}
+		raw, err := base64.StdEncoding.DecodeString(signature)
+		if err != nil {
+			return false
+		}
+		switch pub := parsed.(type) {
+		case *ecdsa.PublicKey:
+			digest := sha256.Sum256(content)
+			return ecdsa.VerifyASN1(pub, digest[:], raw)
+		case ed25519.PublicKey:
+			return ed25519.Verify(pub, content, raw)
+		}
+	}
+	return false
+}
+
+// CreateKey 登记签名公钥, 同一项目下相同指纹的公钥只能登记一次
+func (s *SignatureService) CreateKey(ctx context.Context, key *model.SigningKey) error {
+	if _, err := s.projectRepo.GetByID(ctx, key.ProjectID); err != nil {
+		return ErrProjectNotFound
+	}
+	key.Name = strings.TrimSpace(key.Name)
+	key.Identity = strings.TrimSpace(key.Identity)
+	key.PublicKey = strings.TrimSpace(key.PublicKey)
+	if key.Name == "" {
+		return ErrInvalidSigningKey
+	}
+
+	fingerprint, err := signingKeyFingerprint(key.Type, key.PublicKey)
+	if err != nil {
+		return err
+	}
+	if _, err := s.sigRepo.GetKeyByFingerprint(ctx, key.ProjectID, fingerprint); err == nil {
+		return ErrSigningKeyExists
+	}
+	key.Fingerprint = fingerprint
+	key.RevokedAt = nil
+	return s.sigRepo.CreateKey(ctx, key)
+}
+
+// ListKeys 获取项目下的签名密钥
+func (s *SignatureService) ListKeys(ctx context.Context, projectID int64) ([]*model.SigningKey, error) {
+	return s.sigRepo.ListKeys(ctx, projectID)
+}
+
+// RevokeKey 吊销签名密钥, 已有签名保留但不再视为有效, 也不能再用于附加签名
+func (s *SignatureService) RevokeKey(ctx context.Context, keyID int64) (*model.SigningKey, error) {
+	key, err := s.sigRepo.GetKey(ctx, keyID)
+	if err != nil {
+		return nil, ErrSigningKeyNotFound
+	}
+	if key.RevokedAt != nil {
+		return key, nil
+	}
+	now := time.Now()
+	key.RevokedAt = &now
+	if err := s.sigRepo.UpdateKey(ctx, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Attach 为配置版本附加分离签名, 签名须通过项目中某个未吊销密钥的验证
+// 同一密钥重复签名同一版本时返回已有签名
+func (s *SignatureService) Attach(ctx context.Context, configID int64, version int, signature, signedBy string) (*SignatureInfo, error) {
+	signature = strings.TrimSpace(signature)
+	if signature == "" || len(signature) > maxSignatureSize {
+		return nil, ErrInvalidSignature
+	}
+
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+	target, err := s.versionRepo.GetByConfigAndVersion(ctx, configID, version)
+	if err != nil {
+		return nil, ErrVersionNotFound
+	}
+	keys, err := s.sigRepo.ListKeys(ctx, config.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		if key.RevokedAt != nil || !verifySignature(key, []byte(target.Content), signature) {
+			continue
+		}
+		if existing, err := s.sigRepo.GetSignature(ctx, target.ID, key.ID); err == nil {
+			return signatureInfo(existing, key, target), nil
+		}
+		record := &model.VersionSignature{
+			ProjectID:  config.ProjectID,
+			ConfigID:   configID,
+			VersionID:  target.ID,
+			Version:    target.Version,
+			KeyID:      key.ID,
+			CommitHash: target.CommitHash,
+			Signature:  signature,
+			SignedBy:   signedBy,
+		}
+		if err := s.sigRepo.CreateSignature(ctx, record); err != nil {
+			return nil, err
+		}
+		return signatureInfo(record, key, target), nil
+	}
+	return nil, ErrInvalidSignature
+}
+
+// List 获取配置版本的签名
+func (s *SignatureService) List(ctx context.Context, configID int64, version int) ([]*SignatureInfo, error) {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+	target, err := s.versionRepo.GetByConfigAndVersion(ctx, configID, version)
+	if err != nil {
+		return nil, ErrVersionNotFound
+	}
+	keys, err := s.keysByID(ctx, config.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	signatures, err := s.sigRepo.ListByVersions(ctx, []int64{target.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]*SignatureInfo, 0, len(signatures))
+	for _, sig := range signatures {
+		if key, ok := keys[sig.KeyID]; ok {
+			infos = append(infos, signatureInfo(sig, key, target))
+		}
+	}
+	return infos, nil
+}
+
+// Annotate 为版本列表填充签名验证状态
+func (s *SignatureService) Annotate(ctx context.Context, configID int64, versions []*model.ConfigVersion) error {
+	if len(versions) == 0 {
+		return nil
+	}
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return ErrConfigNotFound
+	}
+	keys, err := s.keysByID(ctx, config.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	versionIDs := make([]int64, 0, len(versions))
+	for _, v := range versions {
+		versionIDs = append(versionIDs, v.ID)
+	}
+	signatures, err := s.sigRepo.ListByVersions(ctx, versionIDs)
+	if err != nil {
+		return err
+	}
+	byVersion := make(map[int64][]*model.VersionSignature, len(versions))
+	for _, sig := range signatures {
+		byVersion[sig.VersionID] = append(byVersion[sig.VersionID], sig)
+	}
+
+	for _, v := range versions {
+		status := &model.SignatureStatus{Status: model.SignatureUnsigned}
+		for _, sig := range byVersion[v.ID] {
+			key, ok := keys[sig.KeyID]
+			if !ok {
+				continue
+			}
+			switch signatureState(sig, key, v) {
+			case model.SignatureVerified:
+				status.Status = model.SignatureVerified
+				status.Signers = append(status.Signers, key.Name)
+				if key.Identity != "" && key.Identity == v.Author {
+					status.AuthorVerified = true
+				}
+			case model.SignatureInvalid:
+				if status.Status != model.SignatureVerified {
+					status.Status = model.SignatureInvalid
+				}
+			case model.SignatureRevoked:
+				if status.Status == model.SignatureUnsigned {
+					status.Status = model.SignatureRevoked
+				}
+			}
+		}
+		v.Signature = status
+	}
+	return nil
+}
+
+// keysByID 获取项目下的签名密钥, 按 ID 索引
+func (s *SignatureService) keysByID(ctx context.Context, projectID int64) (map[int64]*model.SigningKey, error) {
+	keys, err := s.sigRepo.ListKeys(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]*model.SigningKey, len(keys))
+	for _, key := range keys {
+		byID[key.ID] = key
+	}
+	return byID, nil
+}
+
+// signatureState 签名的当前状态: 内容在签名后变化为 invalid, 密钥已吊销为 revoked
+func signatureState(sig *model.VersionSignature, key *model.SigningKey, version *model.ConfigVersion) string {
+	if sig.CommitHash != version.CommitHash {
+		return model.SignatureInvalid
+	}
+	if key.RevokedAt != nil {
+		return model.SignatureRevoked
+	}
+	return model.SignatureVerified
+}
+
+func signatureInfo(sig *model.VersionSignature, key *model.SigningKey, version *model.ConfigVersion) *SignatureInfo {
+	return &SignatureInfo{
+		VersionSignature: sig,
+		KeyName:          key.Name,
+		KeyType:          key.Type,
+		Fingerprint:      key.Fingerprint,
+		Identity:         key.Identity,
+		Status:           signatureState(sig, key, version),
+	}
+}
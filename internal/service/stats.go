@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"confighub/internal/repository"
+)
+
+// statsErrorWindow 近期错误的统计窗口
+const statsErrorWindow = time.Hour
+
+// GlobalStats 全局统计概览
+type GlobalStats struct {
+	Projects         int64     `json:"projects"`
+	ArchivedProjects int64     `json:"archived_projects"`
+	Configs          int64     `json:"configs"`
+	Versions         int64     `json:"versions"`
+	ReleasesToday    int64     `json:"releases_today"`  // 当日 (UTC) 生效的发布数
+	ActiveWatchers   int       `json:"active_watchers"` // 本实例当前的监听连接数
+	RecentErrors     int64     `json:"recent_errors"`   // 本实例最近一小时公开接口的服务端错误 (5xx) 数
+	RefreshedAt      time.Time `json:"refreshed_at"`    // 计数的刷新时间, 监听连接数和错误数为实时值
+}
+
+// ProjectStats 项目统计概览
+type ProjectStats struct {
+	ProjectID      int64     `json:"project_id"`
+	Configs        int64     `json:"configs"`
+	Versions       int64     `json:"versions"`
+	ReleasesToday  int64     `json:"releases_today"`
+	ActiveWatchers int       `json:"active_watchers"`
+	RecentErrors   int64     `json:"recent_errors"`
+	RefreshedAt    time.Time `json:"refreshed_at"`
+}
+
+// statsSnapshot 一次刷新得到的聚合计数
+type statsSnapshot struct {
+	projects    int64
+	archived    int64
+	configs     map[int64]int64
+	versions    map[int64]int64
+	releases    map[int64]int64
+	refreshedAt time.Time
+}
+
+// StatsService 控制台统计概览
+// 项目、配置、版本和发布数由后台任务定期聚合后缓存, 读取时不执行 COUNT 查询;
+// 监听连接数和近期错误数来自本实例内存, 为实时值
+type StatsService struct {
+	statsRepo   *repository.StatsRepository
+	projectRepo *repository.ProjectRepository
+	notifySvc   *NotificationService
+
+	refreshMu sync.Mutex // 串行化刷新, 避免首次读取与后台任务重复聚合
+	mu        sync.RWMutex
+	snapshot  *statsSnapshot
+
+	errorsMu sync.Mutex
+	errors   map[int64]map[int64]int64 // 项目 ID -> 分钟 (Unix) -> 错误数
+}
+
+// NewStatsService 创建统计服务
+func NewStatsService(statsRepo *repository.StatsRepository, projectRepo *repository.ProjectRepository, notifySvc *NotificationService) *StatsService {
+	return &StatsService{
+		statsRepo:   statsRepo,
+		projectRepo: projectRepo,
+		notifySvc:   notifySvc,
+		errors:      make(map[int64]map[int64]int64),
+	}
+}
+
+// RecordError 记录一次公开接口的服务端错误, 未识别项目的请求 projectID 为 0, 只计入全局
+func (s *StatsService) RecordError(projectID int64) {
+	minute := time.Now().Unix() / 60
+
+	s.errorsMu.Lock()
+	defer s.errorsMu.Unlock()
+
+	buckets, ok := s.errors[projectID]
+	if !ok {
+		buckets = make(map[int64]int64)
+		s.errors[projectID] = buckets
+	}
+	buckets[minute]++
+}
+
+// Global 获取全局统计概览
+func (s *StatsService) Global(ctx context.Context) (*GlobalStats, error) {
+	snapshot, err := s.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &GlobalStats{
+		Projects:         snapshot.projects,
+		ArchivedProjects: snapshot.archived,
+		Configs:          sumCounts(snapshot.configs),
+		Versions:         sumCounts(snapshot.versions),
+		ReleasesToday:    sumCounts(snapshot.releases),
+		RecentErrors:     sumCounts(s.recentErrors()),
+		RefreshedAt:      snapshot.refreshedAt,
+	}
+	for _, count := range s.notifySvc.SubscribersByProject() {
+		stats.ActiveWatchers += count
+	}
+	return stats, nil
+}
+
+// Project 获取项目统计概览
+func (s *StatsService) Project(ctx context.Context, projectID int64) (*ProjectStats, error) {
+	if _, err := s.projectRepo.GetByID(ctx, projectID); err != nil {
+		return nil, ErrProjectNotFound
+	}
+	snapshot, err := s.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProjectStats{
+		ProjectID:      projectID,
+		Configs:        snapshot.configs[projectID],
+		Versions:       snapshot.versions[projectID],
+		ReleasesToday:  snapshot.releases[projectID],
+		ActiveWatchers: s.notifySvc.SubscribersByProject()[projectID],
+		RecentErrors:   s.recentErrors()[projectID],
+		RefreshedAt:    snapshot.refreshedAt,
+	}, nil
+}
+
+// Refresh 重新聚合计数并替换缓存
+func (s *StatsService) Refresh(ctx context.Context) error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	return s.refresh(ctx)
+}
+
+// Run 启动时聚合一次, 之后每隔 interval 刷新, 并清理统计窗口之外的错误计数, 直到 ctx 取消
+func (s *StatsService) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Refresh(ctx); err != nil && onError != nil {
+			onError(err)
+		}
+		s.recentErrors()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// current 获取缓存的计数, 尚未聚合过时同步聚合一次
+func (s *StatsService) current(ctx context.Context) (*statsSnapshot, error) {
+	s.mu.RLock()
+	snapshot := s.snapshot
+	s.mu.RUnlock()
+	if snapshot != nil {
+		return snapshot, nil
+	}
+
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	s.mu.RLock()
+	snapshot = s.snapshot
+	s.mu.RUnlock()
+	if snapshot != nil {
+		return snapshot, nil
+	}
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.snapshot, nil
+}
+
+// refresh 执行聚合查询, 调用方需持有 refreshMu; 任一查询失败时保留上一次的结果
+func (s *StatsService) refresh(ctx context.Context) error {
+	now := time.Now().UTC()
+	snapshot := &statsSnapshot{refreshedAt: now}
+
+	var err error
+	if snapshot.projects, snapshot.archived, err = s.statsRepo.CountProjects(ctx); err != nil {
+		return err
+	}
+	if snapshot.configs, err = s.statsRepo.ConfigsByProject(ctx); err != nil {
+		return err
+	}
+	if snapshot.versions, err = s.statsRepo.VersionsByProject(ctx); err != nil {
+		return err
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if snapshot.releases, err = s.statsRepo.ReleasesByProject(ctx, today); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.snapshot = snapshot
+	s.mu.Unlock()
+	return nil
+}
+
+// recentErrors 统计窗口内各项目的错误数, 同时丢弃窗口之外的计数
+func (s *StatsService) recentErrors() map[int64]int64 {
+	oldest := time.Now().Add(-statsErrorWindow).Unix() / 60
+
+	s.errorsMu.Lock()
+	defer s.errorsMu.Unlock()
+
+	counts := make(map[int64]int64, len(s.errors))
+	for projectID, buckets := range s.errors {
+		for minute, count := range buckets {
+			if minute <= oldest {
+				delete(buckets, minute)
+				continue
+			}
+			counts[projectID] += count
+		}
+		if len(buckets) == 0 {
+			delete(s.errors, projectID)
+		}
+	}
+	return counts
+}
+
+// sumCounts 各项目计数之和
+func sumCounts(counts map[int64]int64) int64 {
+	var total int64
+	for _, count := range counts {
+		total += count
+	}
+	return total
+}
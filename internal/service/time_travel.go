@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"confighub/internal/model"
+)
+
+var ErrConfigNotExistAt = errors.New("配置在该时间点尚未创建")
+
+// ConfigSnapshot 配置在某一时刻的状态, 用于事故复盘
+// 内容为配置自身存储的内容 (含发布流水线产物), 不包含当时的父配置合并和环境变量解析
+type ConfigSnapshot struct {
+	ConfigID    int64          `json:"config_id"`
+	Name        string         `json:"name"`
+	Namespace   string         `json:"namespace"`
+	Environment string         `json:"environment"`
+	At          time.Time      `json:"at"`
+	Version     int            `json:"version"` // 该时刻的最新版本, 即当时下发的版本; 0 表示尚无版本
+	Author      string         `json:"author,omitempty"`
+	Message     string         `json:"message,omitempty"`
+	Origin      string         `json:"origin,omitempty"`
+	CreatedAt   *time.Time     `json:"created_at,omitempty"` // 该版本的创建时间
+	Release     *model.Release `json:"release,omitempty"`    // 该时刻最近一次生效的正式发布
+	ContentHash string         `json:"content_hash,omitempty"`
+	Content     string         `json:"content,omitempty"`
+}
+
+// EnvironmentSnapshot 项目某个环境下所有配置在某一时刻的状态
+type EnvironmentSnapshot struct {
+	ProjectID   int64             `json:"project_id"`
+	Environment string            `json:"environment"`
+	At          time.Time         `json:"at"`
+	Configs     []*ConfigSnapshot `json:"configs"` // 该时刻之后创建的配置不包含在内
+}
+
+// ConfigAt 获取配置在某一时刻的版本和生效的发布
+func (s *ReleaseService) ConfigAt(ctx context.Context, configID int64, at time.Time) (*ConfigSnapshot, error) {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, ErrConfigNotFound
+	}
+	if config.CreatedAt.After(at) {
+		return nil, ErrConfigNotExistAt
+	}
+	return s.snapshot(ctx, config, at), nil
+}
+
+// EnvironmentAt 获取项目某个环境下所有配置在某一时刻的状态, namespace 不为空时只包含该命名空间
+func (s *ReleaseService) EnvironmentAt(ctx context.Context, projectID int64, env, namespace string, at time.Time) (*EnvironmentSnapshot, error) {
+	if _, err := s.projectRepo.GetByID(ctx, projectID); err != nil {
+		return nil, ErrProjectNotFound
+	}
+	configs, err := s.configRepo.List(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &EnvironmentSnapshot{
+		ProjectID:   projectID,
+		Environment: env,
+		At:          at,
+		Configs:     []*ConfigSnapshot{},
+	}
+	for _, config := range configs {
+		if config.Environment != env || (namespace != "" && config.Namespace != namespace) || config.CreatedAt.After(at) {
+			continue
+		}
+		result.Configs = append(result.Configs, s.snapshot(ctx, config, at))
+	}
+	return result, nil
+}
+
+// snapshot 按版本和发布历史还原配置在 at 时刻的状态
+func (s *ReleaseService) snapshot(ctx context.Context, config *model.Config, at time.Time) *ConfigSnapshot {
+	snapshot := &ConfigSnapshot{
+		ConfigID:    config.ID,
+		Name:        config.Name,
+		Namespace:   config.Namespace,
+		Environment: config.Environment,
+		At:          at,
+	}
+	if release, err := s.releaseRepo.GetReleasedAsOf(ctx, config.ID, config.Environment, at); err == nil {
+		snapshot.Release = release
+	}
+
+	version, err := s.versionRepo.GetAsOf(ctx, config.ID, at)
+	if err != nil {
+		return snapshot
+	}
+	snapshot.Version = version.Version
+	snapshot.Author = version.Author
+	snapshot.Message = version.CommitMessage
+	snapshot.Origin = version.Origin
+	snapshot.CreatedAt = &version.CreatedAt
+	snapshot.Content = ServedContent(version, snapshot.Release)
+	snapshot.ContentHash = ServedContentHash(version, snapshot.Release)
+	return snapshot
+}
@@ -0,0 +1,388 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+)
+
+var (
+	ErrTransferNotFound   = errors.New("转移申请不存在")
+	ErrInvalidTransfer    = errors.New("无效的转移申请")
+	ErrTransferForbidden  = errors.New("无权处理此转移申请")
+	ErrTransferNotPending = errors.New("转移申请已处理或已过期")
+	ErrTransferPending    = errors.New("项目已有等待确认的所有权转移")
+)
+
+// transferTTL 转移申请的有效期, 超过后接收方不能再确认
+const transferTTL = 7 * 24 * time.Hour
+
+// TransferRequest 发起所有权转移的请求
+// ConfigIDs 为空时转移整个项目, 否则将这些配置迁入接收方管理的 TargetProjectID 项目
+type TransferRequest struct {
+	ToUserID        int64   `json:"to_user_id" binding:"required"`
+	ConfigIDs       []int64 `json:"config_ids"`
+	TargetProjectID *int64  `json:"target_project_id"`
+	Message         string  `json:"message" binding:"max=500"`
+}
+
+// TransferService 项目和配置的所有权转移: 发起方 (项目所有者或管理员) 申请, 接收方确认后执行
+type TransferService struct {
+	transferRepo *repository.TransferRepository
+	projectRepo  *repository.ProjectRepository
+	configRepo   *repository.ConfigRepository
+	notifySvc    *NotificationService
+}
+
+// NewTransferService 创建所有权转移服务
+func NewTransferService(transferRepo *repository.TransferRepository, projectRepo *repository.ProjectRepository, configRepo *repository.ConfigRepository, notifySvc *NotificationService) *TransferService {
+	return &TransferService{
+		transferRepo: transferRepo,
+		projectRepo:  projectRepo,
+		configRepo:   configRepo,
+		notifySvc:    notifySvc,
+	}
+}
+
+// Request 发起转移申请, 发起人的申请即视为发起方确认
+func (s *TransferService) Request(ctx context.Context, projectID, userID int64, req *TransferRequest) (*model.OwnershipTransfer, error) {
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	if project.IsArchived() {
+		return nil, ErrProjectArchived
+	}
+	if err := s.requireManager(ctx, project, userID); err != nil {
+		return nil, err
+	}
+	if req.ToUserID == userID {
+		return nil, fmt.Errorf("%w: 不能转移给自己", ErrInvalidTransfer)
+	}
+	recipient, err := s.transferRepo.GetUser(ctx, req.ToUserID)
+	if err != nil || !recipient.IsActive {
+		return nil, fmt.Errorf("%w: 接收方用户不存在或已停用", ErrInvalidTransfer)
+	}
+
+	transfer := &model.OwnershipTransfer{
+		ProjectID:  projectID,
+		FromUserID: userID,
+		ToUserID:   req.ToUserID,
+		Status:     model.TransferPending,
+		Message:    req.Message,
+		ExpiresAt:  time.Now().Add(transferTTL),
+	}
+
+	if len(req.ConfigIDs) == 0 {
+		if req.TargetProjectID != nil {
+			return nil, fmt.Errorf("%w: 转移整个项目时不能指定目标项目", ErrInvalidTransfer)
+		}
+		if project.CreatedBy == req.ToUserID {
+			return nil, fmt.Errorf("%w: 接收方已是项目所有者", ErrInvalidTransfer)
+		}
+		pending, err := s.pending(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range pending {
+			if !t.IsConfigTransfer() {
+				return nil, ErrTransferPending
+			}
+		}
+	} else {
+		if req.TargetProjectID == nil {
+			return nil, fmt.Errorf("%w: 迁移配置时须指定接收方管理的目标项目", ErrInvalidTransfer)
+		}
+		transfer.TargetProjectID = req.TargetProjectID
+		ids, err := json.Marshal(uniqueIDs(req.ConfigIDs))
+		if err != nil {
+			return nil, err
+		}
+		transfer.ConfigIDs = string(ids)
+		if _, _, err := s.checkConfigMove(ctx, transfer); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.transferRepo.Create(ctx, transfer); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// Get 获取转移申请, 仅发起方、接收方和项目管理者可见
+func (s *TransferService) Get(ctx context.Context, id, userID int64) (*model.OwnershipTransfer, error) {
+	transfer, err := s.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.FromUserID == userID || transfer.ToUserID == userID {
+		return transfer, nil
+	}
+	project, err := s.projectRepo.GetByID(ctx, transfer.ProjectID)
+	if err != nil {
+		return nil, ErrTransferForbidden
+	}
+	if err := s.requireManager(ctx, project, userID); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// ListByProject 获取项目发起或接收的转移申请, 仅项目管理者可见
+func (s *TransferService) ListByProject(ctx context.Context, projectID, userID int64) ([]*model.OwnershipTransfer, error) {
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	if err := s.requireManager(ctx, project, userID); err != nil {
+		return nil, err
+	}
+	transfers, err := s.transferRepo.ListByProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range transfers {
+		s.expire(ctx, t)
+	}
+	return transfers, nil
+}
+
+// ListByUser 获取用户发起或需要用户确认的转移申请
+func (s *TransferService) ListByUser(ctx context.Context, userID int64, status string) ([]*model.OwnershipTransfer, error) {
+	transfers, err := s.transferRepo.ListByUser(ctx, userID, status)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*model.OwnershipTransfer, 0, len(transfers))
+	for _, t := range transfers {
+		// 过期的申请在读取时更新状态, 按状态筛选时随之剔除
+		if s.expire(ctx, t) && status == model.TransferPending {
+			continue
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// Accept 接收方确认转移并立即执行; 执行前重新检查发起方仍有权转移、配置仍可迁移
+func (s *TransferService) Accept(ctx context.Context, id, userID int64) (*model.OwnershipTransfer, error) {
+	transfer, err := s.decide(ctx, id, userID, transferRecipient)
+	if err != nil {
+		return nil, err
+	}
+	project, err := s.projectRepo.GetByID(ctx, transfer.ProjectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	if project.IsArchived() {
+		return nil, ErrProjectArchived
+	}
+	if err := s.requireManager(ctx, project, transfer.FromUserID); err != nil {
+		return nil, fmt.Errorf("%w: 发起方已无权转移此项目", ErrInvalidTransfer)
+	}
+
+	now := time.Now()
+	transfer.Status = model.TransferAccepted
+	transfer.DecidedAt = &now
+
+	if !transfer.IsConfigTransfer() {
+		if err := s.transferRepo.TransferProject(ctx, transfer, project.CreatedBy); err != nil {
+			return nil, err
+		}
+		return transfer, nil
+	}
+
+	configs, target, err := s.checkConfigMove(ctx, transfer)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.transferRepo.MoveConfigs(ctx, transfer, configs); err != nil {
+		return nil, err
+	}
+	for _, config := range configs {
+		// 原项目的客户端看到配置被删除, 目标项目的客户端看到新配置
+		s.notifySvc.NotifyChange(ctx, deleteChange(config))
+		s.notifySvc.NotifyChange(ctx, &ConfigChange{
+			ProjectID:  target.ID,
+			ConfigID:   config.ID,
+			ConfigName: config.Name,
+			Namespace:  config.Namespace,
+			Env:        config.Environment,
+			Version:    config.CurrentVersion,
+			ChangeType: "create",
+		})
+	}
+	return transfer, nil
+}
+
+// Reject 接收方拒绝转移
+func (s *TransferService) Reject(ctx context.Context, id, userID int64) (*model.OwnershipTransfer, error) {
+	return s.close(ctx, id, userID, transferRecipient, model.TransferRejected)
+}
+
+// Cancel 发起方撤回转移申请
+func (s *TransferService) Cancel(ctx context.Context, id, userID int64) (*model.OwnershipTransfer, error) {
+	return s.close(ctx, id, userID, transferInitiator, model.TransferCancelled)
+}
+
+// TransferConfigIDs 解析转移申请中的配置 ID 列表
+func TransferConfigIDs(transfer *model.OwnershipTransfer) []int64 {
+	var ids []int64
+	if transfer.ConfigIDs != "" {
+		json.Unmarshal([]byte(transfer.ConfigIDs), &ids)
+	}
+	return ids
+}
+
+// 处理转移申请的一方
+const (
+	transferInitiator = "initiator"
+	transferRecipient = "recipient"
+)
+
+// close 以指定状态结束等待确认的转移申请
+func (s *TransferService) close(ctx context.Context, id, userID int64, party, status string) (*model.OwnershipTransfer, error) {
+	transfer, err := s.decide(ctx, id, userID, party)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	transfer.Status = status
+	transfer.DecidedAt = &now
+	if err := s.transferRepo.Update(ctx, transfer); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// decide 获取等待确认的转移申请并检查调用方是否为指定的一方
+func (s *TransferService) decide(ctx context.Context, id, userID int64, party string) (*model.OwnershipTransfer, error) {
+	transfer, err := s.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if (party == transferRecipient && transfer.ToUserID != userID) || (party == transferInitiator && transfer.FromUserID != userID) {
+		return nil, ErrTransferForbidden
+	}
+	if transfer.Status != model.TransferPending {
+		return nil, ErrTransferNotPending
+	}
+	return transfer, nil
+}
+
+// load 获取转移申请, 已过期的申请同时更新状态
+func (s *TransferService) load(ctx context.Context, id int64) (*model.OwnershipTransfer, error) {
+	transfer, err := s.transferRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrTransferNotFound
+	}
+	s.expire(ctx, transfer)
+	return transfer, nil
+}
+
+// pending 获取项目中仍在有效期内的待确认申请
+func (s *TransferService) pending(ctx context.Context, projectID int64) ([]*model.OwnershipTransfer, error) {
+	transfers, err := s.transferRepo.ListPending(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	result := transfers[:0]
+	for _, t := range transfers {
+		if !s.expire(ctx, t) {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+// expire 将超过有效期的待确认申请标记为过期, 返回是否已过期
+func (s *TransferService) expire(ctx context.Context, transfer *model.OwnershipTransfer) bool {
+	if transfer.Status == model.TransferExpired {
+		return true
+	}
+	if transfer.Status != model.TransferPending || time.Now().Before(transfer.ExpiresAt) {
+		return false
+	}
+	transfer.Status = model.TransferExpired
+	s.transferRepo.Update(ctx, transfer)
+	return true
+}
+
+// requireManager 用户须为项目所有者或管理员
+func (s *TransferService) requireManager(ctx context.Context, project *model.Project, userID int64) error {
+	if project.CreatedBy == userID {
+		return nil
+	}
+	role, err := s.transferRepo.MemberRole(ctx, project.ID, userID)
+	if err != nil {
+		return err
+	}
+	if role != "admin" {
+		return ErrTransferForbidden
+	}
+	return nil
+}
+
+// checkConfigMove 检查配置迁移: 目标项目由接收方管理, 配置均属于原项目,
+// 继承关系不跨越迁移范围, 目标项目中没有同名配置. 返回待迁移的配置和目标项目
+func (s *TransferService) checkConfigMove(ctx context.Context, transfer *model.OwnershipTransfer) ([]*model.Config, *model.Project, error) {
+	target, err := s.projectRepo.GetByID(ctx, *transfer.TargetProjectID)
+	if err != nil || target.ID == transfer.ProjectID {
+		return nil, nil, fmt.Errorf("%w: 目标项目不存在", ErrInvalidTransfer)
+	}
+	if target.IsArchived() {
+		return nil, nil, fmt.Errorf("%w: 目标项目已归档", ErrInvalidTransfer)
+	}
+	if err := s.requireManager(ctx, target, transfer.ToUserID); err != nil {
+		return nil, nil, fmt.Errorf("%w: 接收方不是目标项目的所有者或管理员", ErrInvalidTransfer)
+	}
+
+	ids := TransferConfigIDs(transfer)
+	selected := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+	configs := make([]*model.Config, 0, len(ids))
+	for _, id := range ids {
+		config, err := s.configRepo.GetByID(ctx, id)
+		if err != nil || config.ProjectID != transfer.ProjectID {
+			return nil, nil, fmt.Errorf("%w: 配置 %d 不属于项目", ErrInvalidTransfer, id)
+		}
+		if config.ParentID != nil && !selected[*config.ParentID] {
+			return nil, nil, fmt.Errorf("%w: 配置 %s 继承的父配置须一并迁移", ErrInvalidTransfer, config.Name)
+		}
+		children, err := s.configRepo.ListChildren(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, child := range children {
+			if !selected[child.ID] {
+				return nil, nil, fmt.Errorf("%w: 配置 %s 被 %s 继承, 须一并迁移", ErrInvalidTransfer, config.Name, child.Name)
+			}
+		}
+		if _, err := s.configRepo.GetByNameAndEnv(ctx, target.ID, config.Name, config.Namespace, config.Environment); err == nil {
+			return nil, nil, fmt.Errorf("%w: 目标项目已存在配置 %s (%s/%s)", ErrInvalidTransfer, config.Name, config.Namespace, config.Environment)
+		}
+		configs = append(configs, config)
+	}
+	return configs, target, nil
+}
+
+// uniqueIDs 去除重复的 ID, 保持原有顺序
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	result := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"confighub/internal/model"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 发布流水线支持的转换步骤
+const (
+	TransformStripComments       = "strip_comments"
+	TransformInjectBuildMetadata = "inject_build_metadata"
+	TransformMinify              = "minify"
+	TransformReEncrypt           = "re_encrypt"
+	TransformSortKeys            = "sort_keys"
+)
+
+// defaultBuildMetadataKey 构建元数据默认写入的顶层键
+const defaultBuildMetadataKey = "_build"
+
+// errTransformUnsupported 配置格式不支持该步骤, 步骤被跳过而不是中止流水线
+var errTransformUnsupported = errors.New("配置格式不支持该步骤")
+
+// transformContext 转换步骤可使用的发布信息
+type transformContext struct {
+	ctx        context.Context
+	config     *model.Config
+	version    *model.ConfigVersion
+	release    *model.Release
+	encryptSvc *EncryptionService
+}
+
+// transformFunc 转换步骤, 返回转换后的内容及执行说明
+type transformFunc func(tc *transformContext, step TransformStep, content string) (string, string, error)
+
+var transforms = map[string]transformFunc{
+	TransformStripComments:       stripComments,
+	TransformInjectBuildMetadata: injectBuildMetadata,
+	TransformMinify:              minifyContent,
+	TransformReEncrypt:           reEncrypt,
+	TransformSortKeys:            sortKeys,
+}
+
+// stripComments 删除 YAML 注释; JSON 不允许注释, 内容保持不变
+func stripComments(tc *transformContext, step TransformStep, content string) (string, string, error) {
+	switch tc.config.FileType {
+	case "json", "hcl", "kv":
+		return content, "", nil
+	case "yaml":
+		doc, err := parseYAMLNode(content)
+		if err != nil || doc == nil {
+			return content, "", err
+		}
+		clearYAMLComments(doc)
+		output, err := encodeYAMLNode(doc, false)
+		return output, "", err
+	}
+	return "", "", unsupportedTransform(tc.config.FileType)
+}
+
+// injectBuildMetadata 在顶层对象中写入构建元数据 (配置名、环境、版本、提交哈希、发布人、发布时间)
+func injectBuildMetadata(tc *transformContext, step TransformStep, content string) (string, string, error) {
+	key := step.Key
+	if key == "" {
+		key = defaultBuildMetadataKey
+	}
+	metadata := map[string]interface{}{
+		"config":      tc.config.Name,
+		"environment": tc.release.Environment,
+		"version":     tc.version.Version,
+		"commit_hash": tc.version.CommitHash,
+		"released_by": tc.release.ReleasedBy,
+		"released_at": tc.release.ReleasedAt.UTC().Format(time.RFC3339),
+	}
+	message := "写入 " + key
+
+	switch tc.config.FileType {
+	case "json", "hcl", "kv":
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(content), &fields); err != nil {
+			return "", "", errors.New("内容不是 JSON 对象")
+		}
+		if _, exists := fields[key]; exists {
+			return "", "", fmt.Errorf("顶层键 %s 已存在", key)
+		}
+		encoded, err := json.Marshal(metadata)
+		if err != nil {
+			return "", "", err
+		}
+
+		// 在最后一个右括号前插入, 保留原有的键顺序和格式
+		trimmed := strings.TrimRight(content, " \t\r\n")
+		body := strings.TrimRight(strings.TrimSuffix(trimmed, "}"), " \t\r\n")
+		separator, closing := "", "}"
+		if strings.Contains(trimmed, "\n") {
+			separator, closing = "\n  ", "\n}"
+		}
+		if len(fields) > 0 {
+			body += ","
+		}
+		return body + separator + fmt.Sprintf("%q: ", key) + string(encoded) + closing + content[len(trimmed):], message, nil
+	case "yaml":
+		doc, err := parseYAMLNode(content)
+		if err != nil {
+			return "", "", err
+		}
+		if doc == nil || doc.Content[0].Kind != yaml.MappingNode {
+			return "", "", errors.New("内容不是 YAML 映射")
+		}
+		root := doc.Content[0]
+		for i := 0; i < len(root.Content); i += 2 {
+			if root.Content[i].Value == key {
+				return "", "", fmt.Errorf("顶层键 %s 已存在", key)
+			}
+		}
+		var value yaml.Node
+		if err := value.Encode(metadata); err != nil {
+			return "", "", err
+		}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, &value)
+		output, err := encodeYAMLNode(doc, false)
+		return output, message, err
+	}
+	return "", "", unsupportedTransform(tc.config.FileType)
+}
+
+// minifyContent 压缩内容: JSON 去除空白, YAML 去除注释并转为单行流式风格
+func minifyContent(tc *transformContext, step TransformStep, content string) (string, string, error) {
+	switch tc.config.FileType {
+	case "json", "hcl", "kv":
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, []byte(content)); err != nil {
+			return "", "", errors.New("内容不是有效的 JSON")
+		}
+		return buf.String(), "", nil
+	case "yaml":
+		doc, err := parseYAMLNode(content)
+		if err != nil || doc == nil {
+			return content, "", err
+		}
+		clearYAMLComments(doc)
+		output, err := encodeYAMLNode(doc, true)
+		return output, "", err
+	}
+	return "", "", unsupportedTransform(tc.config.FileType)
+}
+
+// reEncrypt 使用项目当前的数据密钥重新加密已加密的字段 (包括主密钥加密的旧格式值), 并加密 fields 中指定的明文字段
+// 无法解密的字段 (如使用旧密钥加密) 会中止流水线, 避免下发无法解密的内容
+func reEncrypt(tc *transformContext, step TransformStep, content string) (string, string, error) {
+	if !jsonContent(tc.config.FileType) {
+		return "", "", unsupportedTransform(tc.config.FileType)
+	}
+
+	var data map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return "", "", errors.New("内容不是 JSON 对象")
+	}
+
+	fields := make(map[string]bool, len(step.Fields))
+	for _, f := range step.Fields {
+		fields[f] = true
+	}
+	var reEncrypted, encrypted int
+	var walk func(value interface{}, key, path string) (interface{}, error)
+	walk = func(value interface{}, key, path string) (interface{}, error) {
+		switch v := value.(type) {
+		case string:
+			plaintext := v
+			if tc.encryptSvc.IsEncrypted(v) {
+				decrypted, err := tc.encryptSvc.DecryptStoredValue(tc.ctx, tc.config.ProjectID, v)
+				if err != nil {
+					return nil, fmt.Errorf("字段 %s 无法解密", path)
+				}
+				plaintext = decrypted
+				reEncrypted++
+			} else if fields[path] || fields[key] {
+				encrypted++
+			} else {
+				return v, nil
+			}
+			return tc.encryptSvc.EncryptForProject(tc.ctx, tc.config.ProjectID, plaintext)
+		case map[string]interface{}:
+			for k, item := range v {
+				childPath := k
+				if path != "" {
+					childPath = path + "." + k
+				}
+				updated, err := walk(item, k, childPath)
+				if err != nil {
+					return nil, err
+				}
+				v[k] = updated
+			}
+		case []interface{}:
+			for i, item := range v {
+				updated, err := walk(item, key, path)
+				if err != nil {
+					return nil, err
+				}
+				v[i] = updated
+			}
+		}
+		return value, nil
+	}
+	if _, err := walk(data, "", ""); err != nil {
+		return "", "", err
+	}
+	if reEncrypted == 0 && encrypted == 0 {
+		return content, "没有需要加密的字段", nil
+	}
+
+	output, err := encodeJSONLike(content, data)
+	return output, fmt.Sprintf("重新加密 %d 个字段, 新加密 %d 个字段", reEncrypted, encrypted), err
+}
+
+// sortKeys 按字母顺序递归排序对象的键
+func sortKeys(tc *transformContext, step TransformStep, content string) (string, string, error) {
+	switch tc.config.FileType {
+	case "json", "hcl", "kv":
+		var data interface{}
+		decoder := json.NewDecoder(strings.NewReader(content))
+		decoder.UseNumber()
+		if err := decoder.Decode(&data); err != nil {
+			return "", "", errors.New("内容不是有效的 JSON")
+		}
+		output, err := encodeJSONLike(content, data)
+		return output, "", err
+	case "yaml":
+		doc, err := parseYAMLNode(content)
+		if err != nil || doc == nil {
+			return content, "", err
+		}
+		sortYAMLKeys(doc)
+		output, err := encodeYAMLNode(doc, false)
+		return output, "", err
+	}
+	return "", "", unsupportedTransform(tc.config.FileType)
+}
+
+func unsupportedTransform(fileType string) error {
+	return fmt.Errorf("%w: %s", errTransformUnsupported, fileType)
+}
+
+// encodeJSONLike 编码 JSON, 原内容为多行时使用两空格缩进, 否则输出紧凑格式
+// encoding/json 按键排序输出对象
+func encodeJSONLike(original string, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if strings.Contains(strings.TrimSpace(original), "\n") {
+		encoder.SetIndent("", "  ")
+	}
+	if err := encoder.Encode(data); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// parseYAMLNode 解析 YAML 文档节点, 空文档返回 nil
+func parseYAMLNode(content string) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, errors.New("内容不是有效的 YAML")
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, nil
+	}
+	return &doc, nil
+}
+
+// encodeYAMLNode 编码 YAML 节点, flow 为 true 时所有集合输出为单行流式风格
+func encodeYAMLNode(doc *yaml.Node, flow bool) (string, error) {
+	if flow {
+		setYAMLFlowStyle(doc)
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func clearYAMLComments(node *yaml.Node) {
+	node.HeadComment, node.LineComment, node.FootComment = "", "", ""
+	for _, child := range node.Content {
+		clearYAMLComments(child)
+	}
+}
+
+func setYAMLFlowStyle(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
+		node.Style |= yaml.FlowStyle
+	}
+	for _, child := range node.Content {
+		setYAMLFlowStyle(child)
+	}
+}
+
+// sortYAMLKeys 递归排序映射节点的键值对
+func sortYAMLKeys(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+		}
+		sort.SliceStable(pairs, func(i, j int) bool {
+			return pairs[i][0].Value < pairs[j][0].Value
+		})
+		for i, pair := range pairs {
+			node.Content[2*i], node.Content[2*i+1] = pair[0], pair[1]
+		}
+	}
+	for _, child := range node.Content {
+		sortYAMLKeys(child)
+	}
+}
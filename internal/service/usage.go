@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"confighub/internal/repository"
+)
+
+var (
+	ErrInvalidUsageMonth = errors.New("无效的月份, 格式为 2006-01")
+)
+
+// usageDayLayout 用量按 UTC 自然日汇总
+const usageDayLayout = "2006-01-02"
+
+// usageKey 项目当日用量
+type usageKey struct {
+	projectID int64
+	day       string
+}
+
+// usageCounter 尚未写入数据库的用量
+type usageCounter struct {
+	calls         int64
+	watcherMillis int64
+}
+
+// UsageReport 项目月度用量
+type UsageReport struct {
+	ProjectID        int64   `json:"project_id"`
+	ProjectName      string  `json:"project_name"` // 项目已删除时为空
+	Month            string  `json:"month"`
+	APICalls         int64   `json:"api_calls"`
+	WatcherHours     float64 `json:"watcher_hours"`
+	StorageBytesAvg  int64   `json:"storage_bytes_avg"`
+	StorageBytesPeak int64   `json:"storage_bytes_peak"`
+	ActiveDays       int     `json:"active_days"`
+}
+
+// UsageService 项目用量计量服务
+// 调用次数和监听时长先在内存中累计, 定期批量写入; 存储量每天快照一次
+// 同时记录密钥的最近使用时间, 与用量一同写入, 供访问审查使用
+type UsageService struct {
+	usageRepo   *repository.UsageRepository
+	projectRepo *repository.ProjectRepository
+
+	mu       sync.Mutex
+	pending  map[usageKey]*usageCounter
+	keysUsed map[int64]time.Time // 密钥 ID -> 尚未写入的最近使用时间
+}
+
+// NewUsageService 创建用量计量服务
+func NewUsageService(usageRepo *repository.UsageRepository, projectRepo *repository.ProjectRepository) *UsageService {
+	return &UsageService{
+		usageRepo:   usageRepo,
+		projectRepo: projectRepo,
+		pending:     make(map[usageKey]*usageCounter),
+		keysUsed:    make(map[int64]time.Time),
+	}
+}
+
+// RecordCall 记录一次公开接口调用
+func (s *UsageService) RecordCall(projectID int64) {
+	s.add(projectID, 1, 0)
+}
+
+// RecordWatch 记录一次监听连接的保持时长
+func (s *UsageService) RecordWatch(projectID int64, d time.Duration) {
+	s.add(projectID, 0, d.Milliseconds())
+}
+
+// RecordKeyUse 记录密钥的一次使用
+func (s *UsageService) RecordKeyUse(keyID int64) {
+	if keyID == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keysUsed[keyID] = time.Now()
+}
+
+func (s *UsageService) add(projectID, calls, watcherMillis int64) {
+	if projectID == 0 {
+		return
+	}
+	key := usageKey{projectID: projectID, day: time.Now().UTC().Format(usageDayLayout)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counter, ok := s.pending[key]
+	if !ok {
+		counter = &usageCounter{}
+		s.pending[key] = counter
+	}
+	counter.calls += calls
+	counter.watcherMillis += watcherMillis
+}
+
+// Flush 将内存中的用量写入数据库, 写入失败的部分留待下次重试
+// 不足一秒的监听时长保留到下次
+func (s *UsageService) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[usageKey]*usageCounter)
+	keysUsed := s.keysUsed
+	s.keysUsed = make(map[int64]time.Time)
+	s.mu.Unlock()
+
+	var errs []error
+	for key, counter := range pending {
+		seconds := counter.watcherMillis / 1000
+		if counter.calls == 0 && seconds == 0 {
+			s.restore(key, counter)
+			continue
+		}
+		if err := s.usageRepo.Add(ctx, key.projectID, key.day, counter.calls, seconds); err != nil {
+			s.restore(key, counter)
+			errs = append(errs, err)
+			continue
+		}
+		if rest := counter.watcherMillis % 1000; rest > 0 {
+			s.restore(key, &usageCounter{watcherMillis: rest})
+		}
+	}
+	for keyID, at := range keysUsed {
+		if err := s.usageRepo.TouchKey(ctx, keyID, at); err != nil {
+			s.restoreKeyUse(keyID, at)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// restore 将未写入的用量放回原日期
+func (s *UsageService) restore(key usageKey, counter *usageCounter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.pending[key]
+	if !ok {
+		s.pending[key] = counter
+		return
+	}
+	existing.calls += counter.calls
+	existing.watcherMillis += counter.watcherMillis
+}
+
+// restoreKeyUse 将未写入的密钥使用时间放回, 期间有更新的使用时保留较新的
+func (s *UsageService) restoreKeyUse(keyID int64, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.keysUsed[keyID]; !ok || existing.Before(at) {
+		s.keysUsed[keyID] = at
+	}
+}
+
+// SnapshotStorage 记录各项目当日的存储量 (所有配置版本内容的总字节数)
+func (s *UsageService) SnapshotStorage(ctx context.Context) error {
+	storage, err := s.usageRepo.StorageByProject(ctx)
+	if err != nil {
+		return err
+	}
+
+	day := time.Now().UTC().Format(usageDayLayout)
+	for projectID, bytes := range storage {
+		if err := s.usageRepo.SetStorage(ctx, projectID, day, bytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run 定期写入用量, 并在每天首次运行时记录存储快照
+func (s *UsageService) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	snapshotDay := ""
+	for {
+		if day := time.Now().UTC().Format(usageDayLayout); day != snapshotDay {
+			if err := s.SnapshotStorage(ctx); err != nil {
+				if onError != nil {
+					onError(err)
+				}
+			} else {
+				snapshotDay = day
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			s.Flush(context.Background())
+			return
+		case <-ticker.C:
+			if err := s.Flush(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// MonthlyReport 汇总指定月份 (2006-01) 各项目的用量, projectID 为 0 时包含所有项目
+func (s *UsageService) MonthlyReport(ctx context.Context, month string, projectID int64) ([]*UsageReport, error) {
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil, ErrInvalidUsageMonth
+	}
+	end := start.AddDate(0, 1, -1)
+
+	usages, err := s.usageRepo.ListRange(ctx, projectID, start.Format(usageDayLayout), end.Format(usageDayLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	reports := []*UsageReport{}
+	byProject := make(map[int64]*UsageReport)
+	storageDays := make(map[int64]int64)
+	watcherSeconds := make(map[int64]int64)
+	for _, u := range usages {
+		report, ok := byProject[u.ProjectID]
+		if !ok {
+			report = &UsageReport{ProjectID: u.ProjectID, Month: month}
+			if project, err := s.projectRepo.GetByID(ctx, u.ProjectID); err == nil {
+				report.ProjectName = project.Name
+			}
+			byProject[u.ProjectID] = report
+			reports = append(reports, report)
+		}
+
+		report.APICalls += u.APICalls
+		watcherSeconds[u.ProjectID] += u.WatcherSeconds
+		if u.APICalls > 0 || u.WatcherSeconds > 0 {
+			report.ActiveDays++
+		}
+		// 平均存储量仅统计有快照的日期
+		if u.StorageBytes > 0 {
+			report.StorageBytesAvg += u.StorageBytes
+			storageDays[u.ProjectID]++
+			if u.StorageBytes > report.StorageBytesPeak {
+				report.StorageBytesPeak = u.StorageBytes
+			}
+		}
+	}
+
+	for _, report := range reports {
+		if days := storageDays[report.ProjectID]; days > 0 {
+			report.StorageBytesAvg /= days
+		}
+		report.WatcherHours = float64(watcherSeconds[report.ProjectID]*100/3600) / 100
+	}
+	return reports, nil
+}
+
+// ExportCSV 导出月度用量为 CSV
+func (s *UsageService) ExportCSV(ctx context.Context, month string, projectID int64, w io.Writer) error {
+	reports, err := s.MonthlyReport(ctx, month, projectID)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+
+	header := []string{"project_id", "project_name", "month", "api_calls", "watcher_hours", "storage_bytes_avg", "storage_bytes_peak", "active_days"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range reports {
+		row := []string{
+			fmt.Sprintf("%d", r.ProjectID),
+			r.ProjectName,
+			r.Month,
+			fmt.Sprintf("%d", r.APICalls),
+			fmt.Sprintf("%.2f", r.WatcherHours),
+			fmt.Sprintf("%d", r.StorageBytesAvg),
+			fmt.Sprintf("%d", r.StorageBytesPeak),
+			fmt.Sprintf("%d", r.ActiveDays),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExportJSON 导出月度用量为 JSON
+func (s *UsageService) ExportJSON(ctx context.Context, month string, projectID int64, w io.Writer) error {
+	reports, err := s.MonthlyReport(ctx, month, projectID)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(reports)
+}
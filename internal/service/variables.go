@@ -0,0 +1,48 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+)
+
+var (
+	ErrInvalidVariableName = errors.New("变量名只能包含字母、数字和下划线, 且不能以数字开头")
+)
+
+// envVariablePattern 匹配配置中的环境变量引用 ${env:VAR}
+var envVariablePattern = regexp.MustCompile(`\$\{env:([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// variableNamePattern 合法的变量名
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateVariables 校验环境变量名
+func ValidateVariables(vars map[string]string) error {
+	for name := range vars {
+		if !variableNamePattern.MatchString(name) {
+			return ErrInvalidVariableName
+		}
+	}
+	return nil
+}
+
+// InterpolateVariables 将配置内容中的 ${env:VAR} 替换为环境变量值, 未定义的变量保持原样
+// JSON 配置中的引用通常位于字符串字面量内, 因此替换值会按 JSON 字符串转义
+func InterpolateVariables(content, fileType string, vars map[string]string) string {
+	if len(vars) == 0 {
+		return content
+	}
+
+	return envVariablePattern.ReplaceAllStringFunc(content, func(ref string) string {
+		name := envVariablePattern.FindStringSubmatch(ref)[1]
+		value, ok := vars[name]
+		if !ok {
+			return ref
+		}
+		if jsonContent(fileType) {
+			escaped, _ := json.Marshal(value)
+			return string(escaped[1 : len(escaped)-1])
+		}
+		return value
+	})
+}
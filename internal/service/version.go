@@ -17,21 +17,25 @@ var (
 
 // VersionService 版本服务
 type VersionService struct {
-	versionRepo *repository.VersionRepository
+	versionRepo repository.VersionStore
 	configRepo  *repository.ConfigRepository
+	notifySvc   *NotificationService
+	schemaSvc   *SchemaService
 }
 
 // NewVersionService 创建版本服务
-func NewVersionService(versionRepo *repository.VersionRepository, configRepo *repository.ConfigRepository) *VersionService {
+func NewVersionService(versionRepo repository.VersionStore, configRepo *repository.ConfigRepository, notifySvc *NotificationService, schemaSvc *SchemaService) *VersionService {
 	return &VersionService{
 		versionRepo: versionRepo,
 		configRepo:  configRepo,
+		notifySvc:   notifySvc,
+		schemaSvc:   schemaSvc,
 	}
 }
 
-// List 获取版本列表
-func (s *VersionService) List(ctx context.Context, configID int64) ([]*model.ConfigVersion, error) {
-	return s.versionRepo.List(ctx, configID)
+// List 获取版本列表, origin 不为空时只返回该来源的版本, automation 匹配所有自动化工具
+func (s *VersionService) List(ctx context.Context, configID int64, origin string) ([]*model.ConfigVersion, error) {
+	return s.versionRepo.List(ctx, configID, origin)
 }
 
 // GetByVersion 获取指定版本
@@ -80,41 +84,56 @@ func (s *VersionService) Diff(ctx context.Context, configID int64, fromV, toV in
 }
 
 // Rollback 回滚到指定版本
-func (s *VersionService) Rollback(ctx context.Context, configID int64, toVersion int, author string) (*model.ConfigVersion, error) {
+// 回滚内容不依赖当前版本, 提交时遇到并发写入则基于最新版本重试
+func (s *VersionService) Rollback(ctx context.Context, configID int64, toVersion int, author, origin string) (*model.ConfigVersion, error) {
 	// 获取目标版本
 	targetVersion, err := s.versionRepo.GetByConfigAndVersion(ctx, configID, toVersion)
 	if err != nil {
 		return nil, ErrVersionNotFound
 	}
 
-	// 获取当前配置
-	config, err := s.configRepo.GetByID(ctx, configID)
-	if err != nil {
-		return nil, ErrConfigNotFound
-	}
+	var config *model.Config
+	var newVersion *model.ConfigVersion
+	err = retryOnConflict(func() error {
+		// 获取当前配置
+		var err error
+		config, err = s.configRepo.GetByID(ctx, configID)
+		if err != nil {
+			return ErrConfigNotFound
+		}
 
-	// 创建新版本（内容为目标版本的内容）
-	newVersionNum := config.CurrentVersion + 1
-	commitHash := GenerateHash(targetVersion.Content)
-
-	newVersion := &model.ConfigVersion{
-		ConfigID:      configID,
-		Version:       newVersionNum,
-		Content:       targetVersion.Content,
-		CommitHash:    commitHash,
-		CommitMessage: "回滚到版本 " + string(rune(toVersion+'0')),
-		Author:        author,
-	}
+		// 创建新版本（内容为目标版本的内容）
+		newVersion = &model.ConfigVersion{
+			ConfigID:      configID,
+			Version:       config.CurrentVersion + 1,
+			Content:       targetVersion.Content,
+			CommitHash:    GenerateHash(targetVersion.Content),
+			CommitMessage: "回滚到版本 " + string(rune(toVersion+'0')),
+			Author:        author,
+			Origin:        origin,
+		}
 
-	if err := s.versionRepo.Create(ctx, newVersion); err != nil {
+		// 写入版本并推进配置的当前版本
+		if err := s.versionRepo.Commit(ctx, newVersion, config.CurrentVersion); err != nil {
+			if errors.Is(err, repository.ErrVersionConflict) {
+				return ErrConfigVersionConflict
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// 更新配置的当前版本
-	config.CurrentVersion = newVersionNum
-	if err := s.configRepo.Update(ctx, config); err != nil {
-		return nil, err
-	}
+	s.notifySvc.NotifyChange(ctx, &ConfigChange{
+		ConfigID:   config.ID,
+		ConfigName: config.Name,
+		Namespace:  config.Namespace,
+		Env:        config.Environment,
+		Version:    newVersion.Version,
+		ChangeType: "rollback",
+	})
 
 	return newVersion, nil
 }
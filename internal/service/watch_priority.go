@@ -0,0 +1,134 @@
+package service
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"confighub/internal/model"
+)
+
+// 监听优先级分类, 按变更投递顺序排列
+const (
+	classHigh = iota
+	classNormal
+	classBatch
+	priorityClassCount
+)
+
+// priorityClassNames 各分类的名称, 与密钥的监听优先级取值一致
+var priorityClassNames = [priorityClassCount]string{model.KeyPriorityHigh, model.KeyPriorityNormal, model.KeyPriorityBatch}
+
+// priorityClass 监听优先级对应的分类, 用户、匿名访问和未知取值按 normal 处理
+func priorityClass(priority string) int {
+	switch priority {
+	case model.KeyPriorityHigh:
+		return classHigh
+	case model.KeyPriorityBatch:
+		return classBatch
+	}
+	return classNormal
+}
+
+// WatchShedding 监听负载保护设置
+// 监听连接数达到 MaxWatchers 后进入负载保护: normal 和 batch 密钥的新监听请求按各自速率准入,
+// batch 订阅的变更延后 BatchDelay 合并投递; high 密钥始终直接准入并最先收到变更
+type WatchShedding struct {
+	MaxWatchers int           // 0 表示不启用
+	NormalRate  float64       // normal 密钥每秒准入的监听请求数, 不大于 0 时全部拒绝
+	BatchRate   float64       // batch 密钥每秒准入的监听请求数, 不大于 0 时全部拒绝
+	BatchDelay  time.Duration // batch 订阅的变更延后投递的间隔
+}
+
+// sheddingState 生效中的负载保护设置及各分类的准入令牌桶
+type sheddingState struct {
+	cfg     WatchShedding
+	buckets [priorityClassCount]*tokenBucket // high 不限流, 为 nil
+}
+
+// tokenBucket 令牌桶, 容量为一秒的速率 (至少 1)
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64) *tokenBucket {
+	return &tokenBucket{rate: rate, tokens: math.Max(rate, 1), last: time.Now()}
+}
+
+// take 取一个令牌, 失败时返回下一个令牌可用前需等待的时间
+func (b *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	if b.rate <= 0 {
+		return false, time.Second
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(math.Max(b.rate, 1), b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// classStats 一个监听优先级分类的分发统计
+type classStats struct {
+	delivered uint64
+	dropped   uint64
+	throttled uint64
+	latency   *latencyHistogram // 变更从通知到投递给该分类全部订阅的延迟
+}
+
+// ClassFanoutStats 一个监听优先级分类的分发统计
+type ClassFanoutStats struct {
+	Class        string   `json:"class"`
+	Subscribers  int      `json:"subscribers"`
+	Delivered    uint64   `json:"delivered"`
+	Dropped      uint64   `json:"dropped"`
+	Throttled    uint64   `json:"throttled"` // 负载保护期间被拒绝的监听请求数
+	Latency      []uint64 `json:"latency"`
+	LatencySum   float64  `json:"latency_sum"`
+	LatencyCount uint64   `json:"latency_count"`
+}
+
+// SetShedding 设置监听负载保护, 需在开始提供监听接口前调用
+func (s *NotificationService) SetShedding(cfg WatchShedding) {
+	state := &sheddingState{cfg: cfg}
+	state.buckets[classNormal] = newTokenBucket(cfg.NormalRate)
+	state.buckets[classBatch] = newTokenBucket(cfg.BatchRate)
+	s.sheddingCfg.Store(state)
+}
+
+// Shedding 当前是否处于负载保护
+func (s *NotificationService) Shedding() bool {
+	state := s.sheddingCfg.Load()
+	return state != nil && state.cfg.MaxWatchers > 0 && atomic.LoadInt64(&s.watchers) >= int64(state.cfg.MaxWatchers)
+}
+
+// batchDelay 负载保护期间 batch 订阅的变更延后投递的间隔, 未处于负载保护时返回 0
+func (s *NotificationService) batchDelay() time.Duration {
+	if !s.Shedding() {
+		return 0
+	}
+	return s.sheddingCfg.Load().cfg.BatchDelay
+}
+
+// AdmitWatch 监听请求准入: 负载保护期间 normal 和 batch 密钥按各自速率准入, high 密钥始终准入
+// 拒绝时返回建议的重试间隔
+func (s *NotificationService) AdmitWatch(priority string) (bool, time.Duration) {
+	class := priorityClass(priority)
+	if class == classHigh || !s.Shedding() {
+		return true, 0
+	}
+
+	ok, wait := s.sheddingCfg.Load().buckets[class].take(time.Now())
+	if !ok {
+		atomic.AddUint64(&s.classes[class].throttled, 1)
+	}
+	return ok, wait
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidWatchToken = errors.New("无效的监听令牌")
+	ErrWatchTokenRevoked = errors.New("监听令牌已失效")
+)
+
+// WatchTokenTTL 监听令牌有效期
+const WatchTokenTTL = 10 * time.Minute
+
+// WatchTokenClaims 监听令牌声明
+type WatchTokenClaims struct {
+	AccessKeyID int64 `json:"kid"`
+	ProjectID   int64 `json:"pid"`
+	jwt.RegisteredClaims
+
+	// Configs 签发密钥当前的配置范围, 校验时从密钥读取, 不写入令牌
+	Configs []string `json:"-"`
+	// Priority 签发密钥当前的监听优先级, 同样在校验时读取
+	Priority string `json:"-"`
+}
+
+// WatchTokenService 监听令牌服务
+// 客户端通过一次签名握手换取短期令牌, 长轮询/流式重连时无需重复签名;
+// 每次使用都会校验签发密钥仍然有效, 密钥被禁用后令牌立即失效
+type WatchTokenService struct {
+	keyRepo *repository.KeyRepository
+	secret  []byte
+}
+
+// NewWatchTokenService 创建监听令牌服务
+// 令牌使用独立派生的密钥签名, 不能作为用户 JWT 使用
+func NewWatchTokenService(keyRepo *repository.KeyRepository, jwtSecret string) *WatchTokenService {
+	return &WatchTokenService{
+		keyRepo: keyRepo,
+		secret:  []byte(jwtSecret + ":watch"),
+	}
+}
+
+// Issue 为 Access Key 签发监听令牌
+func (s *WatchTokenService) Issue(accessKeyID, projectID int64) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(WatchTokenTTL)
+	claims := &WatchTokenClaims{
+		AccessKeyID: accessKeyID,
+		ProjectID:   projectID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// Verify 校验监听令牌, 并确认签发密钥仍然有效
+func (s *WatchTokenService) Verify(ctx context.Context, token string) (*WatchTokenClaims, error) {
+	claims := &WatchTokenClaims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
+	if err != nil || !parsed.Valid {
+		return nil, ErrInvalidWatchToken
+	}
+
+	key, err := s.keyRepo.GetByID(ctx, claims.AccessKeyID)
+	if err != nil || !key.IsActive || key.ProjectID != claims.ProjectID {
+		return nil, ErrWatchTokenRevoked
+	}
+	if key.ExpiresAt != nil && key.ExpiresAt.Before(time.Now()) {
+		return nil, ErrWatchTokenRevoked
+	}
+
+	var permissions model.Permissions
+	if key.Permissions != "" {
+		json.Unmarshal([]byte(key.Permissions), &permissions)
+	}
+	claims.Configs = permissions.Configs
+	claims.Priority = key.Priority
+	return claims, nil
+}
@@ -0,0 +1,629 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"confighub/internal/model"
+	"confighub/internal/repository"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("Webhook 不存在")
+	ErrInvalidWebhook          = errors.New("无效的 Webhook")
+	ErrWebhookDeliveryNotFound = errors.New("投递记录不存在")
+	ErrWebhookDeliveryPending  = errors.New("投递仍在重试中, 无法重放")
+)
+
+const (
+	// WebhookSignatureHeader 签名头, 值为 sha256=<hex>, 对 "<时间戳>.<请求体>" 计算 HMAC-SHA256
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	// WebhookTimestampHeader 签名时间戳头 (Unix 秒)
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	// WebhookEventHeader 事件类型头
+	WebhookEventHeader = "X-Webhook-Event"
+	// WebhookDeliveryHeader 投递记录 ID 头, 重试时不变, 接收方可用于去重
+	WebhookDeliveryHeader = "X-Webhook-Delivery"
+	// WebhookReplayHeader 重放时携带原投递记录 ID
+	WebhookReplayHeader = "X-Webhook-Replay-Of"
+)
+
+// WebhookPingEvent 测试事件, 不可订阅, 只能通过测试接口发送
+const WebhookPingEvent = "ping"
+
+// WebhookEvents 可订阅的变更类型
+var WebhookEvents = []string{"create", "update", "rollback", "release", "gray_release", "promote", "gray_cancel", "sync", "variables"}
+
+// webhookBackoff 投递失败后的重试间隔, 用完后标记为失败
+var webhookBackoff = []time.Duration{10 * time.Second, time.Minute, 5 * time.Minute, 30 * time.Minute, 2 * time.Hour}
+
+const (
+	webhookTimeout     = 10 * time.Second
+	webhookConcurrency = 8         // 同时进行的投递数
+	webhookBatchSize   = 100       // 每次处理的到期投递数
+	webhookMaxResponse = 16 * 1024 // 保存的响应体上限
+	webhookReplayBatch = 100       // 批量重放的最大记录数
+)
+
+// WebhookRequest 创建或更新 Webhook 请求
+type WebhookRequest struct {
+	Name         string   `json:"name"`
+	URL          string   `json:"url"`
+	Secret       *string  `json:"secret"`       // 为空时使用项目的 WebhookSecret
+	Events       []string `json:"events"`       // 更新时为 null 表示不修改, [] 表示全部
+	Environments []string `json:"environments"` // 同上
+	IsActive     *bool    `json:"is_active"`
+}
+
+// WebhookPayload 推送的事件内容
+type WebhookPayload struct {
+	Event       string          `json:"event"`
+	EventID     int64           `json:"event_id,omitempty"` // 变更事件日志 ID, 与 SSE 事件流一致
+	ProjectID   int64           `json:"project_id"`
+	ConfigID    int64           `json:"config_id"`
+	ConfigName  string          `json:"config_name"`
+	Namespace   string          `json:"namespace"`
+	Environment string          `json:"environment"`
+	Version     int             `json:"version"`
+	Summary     *ReleaseSummary `json:"summary,omitempty"` // 发布、回滚、灰度和全量推广事件携带相对上一次生效发布的变更摘要
+	OccurredAt  time.Time       `json:"occurred_at"`
+	WebhookID   int64           `json:"webhook_id,omitempty"` // 仅测试事件携带
+}
+
+// WebhookService 出站 Webhook 服务
+// 配置创建、更新、发布、回滚等变更经通知总线触发, 为项目下匹配的 Webhook 生成投递记录并以 HMAC 签名推送;
+// 非 2xx 响应或网络错误按退避间隔重试, 投递记录保存在数据库中, 重启后继续重试
+type WebhookService struct {
+	webhookRepo *repository.WebhookRepository
+	projectRepo *repository.ProjectRepository
+	configRepo  *repository.ConfigRepository
+	versionRepo repository.VersionStore
+	releaseRepo *repository.ReleaseRepository
+	client      *http.Client
+
+	queue chan *ConfigChange
+}
+
+// NewWebhookService 创建 Webhook 服务, 并注册为通知服务的变更监听器
+func NewWebhookService(webhookRepo *repository.WebhookRepository, projectRepo *repository.ProjectRepository, configRepo *repository.ConfigRepository, versionRepo repository.VersionStore, releaseRepo *repository.ReleaseRepository, notifySvc *NotificationService) *WebhookService {
+	s := &WebhookService{
+		webhookRepo: webhookRepo,
+		projectRepo: projectRepo,
+		configRepo:  configRepo,
+		versionRepo: versionRepo,
+		releaseRepo: releaseRepo,
+		client:      &http.Client{Timeout: webhookTimeout},
+		queue:       make(chan *ConfigChange, 1000),
+	}
+	notifySvc.OnChange(func(change *ConfigChange) {
+		// 变更对象会继续传给订阅方, 复制一份避免并发读写
+		c := *change
+		select {
+		case s.queue <- &c:
+		default:
+			// 队列已满时丢弃, 避免阻塞写请求
+		}
+	})
+	return s
+}
+
+// Create 创建 Webhook; 项目尚无签名密钥且未指定 secret 时生成项目密钥, 仅此一次返回
+func (s *WebhookService) Create(ctx context.Context, projectID int64, req *WebhookRequest, createdBy int64) (*model.Webhook, string, error) {
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, "", ErrProjectNotFound
+	}
+
+	webhook := &model.Webhook{
+		ProjectID: projectID,
+		IsActive:  req.IsActive == nil || *req.IsActive,
+		CreatedBy: createdBy,
+	}
+	if err := applyWebhookRequest(webhook, req, true); err != nil {
+		return nil, "", err
+	}
+
+	generated := ""
+	if webhook.Secret == "" && project.WebhookSecret == "" {
+		if generated, err = generateWebhookSecret(); err != nil {
+			return nil, "", err
+		}
+		project.WebhookSecret = generated
+		if err := s.projectRepo.Update(ctx, project); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		return nil, "", err
+	}
+	return webhook, generated, nil
+}
+
+// Get 获取 Webhook
+func (s *WebhookService) Get(ctx context.Context, id int64) (*model.Webhook, error) {
+	webhook, err := s.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// List 获取项目下的 Webhook
+func (s *WebhookService) List(ctx context.Context, projectID int64) ([]*model.Webhook, error) {
+	return s.webhookRepo.ListByProject(ctx, projectID)
+}
+
+// Update 更新 Webhook, 请求中为空的字段保持不变
+func (s *WebhookService) Update(ctx context.Context, id int64, req *WebhookRequest) (*model.Webhook, error) {
+	webhook, err := s.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+	if err := applyWebhookRequest(webhook, req, false); err != nil {
+		return nil, err
+	}
+	if req.IsActive != nil {
+		webhook.IsActive = *req.IsActive
+	}
+	if err := s.webhookRepo.Update(ctx, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// Delete 删除 Webhook 及其投递记录
+func (s *WebhookService) Delete(ctx context.Context, id int64) error {
+	if _, err := s.webhookRepo.GetByID(ctx, id); err != nil {
+		return ErrWebhookNotFound
+	}
+	return s.webhookRepo.Delete(ctx, id)
+}
+
+// Deliveries 获取 Webhook 最近的投递记录, 包含请求体、请求头和响应体; status 为空表示全部
+func (s *WebhookService) Deliveries(ctx context.Context, id int64, status string, limit int) ([]*model.WebhookDelivery, error) {
+	if _, err := s.webhookRepo.GetByID(ctx, id); err != nil {
+		return nil, ErrWebhookNotFound
+	}
+	if status != "" && status != model.WebhookDeliveryPending && status != model.WebhookDeliverySuccess && status != model.WebhookDeliveryFailed {
+		return nil, fmt.Errorf("%w: status 可选 pending, success, failed", ErrInvalidWebhook)
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	return s.webhookRepo.ListDeliveries(ctx, id, status, limit)
+}
+
+// GetDelivery 获取 Webhook 下的单条投递记录
+func (s *WebhookService) GetDelivery(ctx context.Context, webhookID, deliveryID int64) (*model.WebhookDelivery, error) {
+	delivery, err := s.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil || delivery.WebhookID != webhookID {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	return delivery, nil
+}
+
+// Test 立即向 Webhook 发送一次测试事件并返回投递结果; 停用的 Webhook 也可测试, 失败时不重试
+func (s *WebhookService) Test(ctx context.Context, id int64) (*model.WebhookDelivery, error) {
+	webhook, err := s.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+	body, err := json.Marshal(&WebhookPayload{
+		Event:      WebhookPingEvent,
+		ProjectID:  webhook.ProjectID,
+		OccurredAt: time.Now(),
+		WebhookID:  webhook.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	delivery := &model.WebhookDelivery{
+		WebhookID: webhook.ID,
+		ProjectID: webhook.ProjectID,
+		Event:     WebhookPingEvent,
+		Payload:   string(body),
+		Status:    model.WebhookDeliveryPending,
+	}
+	if err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, s.deliverOnce(ctx, webhook, delivery)
+}
+
+// Replay 以原请求体重新投递一次已结束的投递记录, 生成新的投递记录并同步返回结果, 失败时不重试
+func (s *WebhookService) Replay(ctx context.Context, webhookID, deliveryID int64) (*model.WebhookDelivery, error) {
+	webhook, err := s.webhookRepo.GetByID(ctx, webhookID)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+	original, err := s.GetDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if original.Status == model.WebhookDeliveryPending {
+		return nil, ErrWebhookDeliveryPending
+	}
+	delivery := replayDelivery(original, nil)
+	if err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, s.deliverOnce(ctx, webhook, delivery)
+}
+
+// ReplayFailed 将尚未重放过的失败投递重新加入投递队列, 按正常的退避间隔重试, 返回被重放的原投递 ID
+// 用于接收方故障恢复后补发, 每次最多处理 webhookReplayBatch 条; dryRun 为 true 时只返回将被重放的投递
+func (s *WebhookService) ReplayFailed(ctx context.Context, webhookID int64, dryRun bool) ([]int64, error) {
+	webhook, err := s.webhookRepo.GetByID(ctx, webhookID)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+	if !webhook.IsActive {
+		return nil, fmt.Errorf("%w: Webhook 已停用", ErrInvalidWebhook)
+	}
+	failed, err := s.webhookRepo.ListUnreplayedFailed(ctx, webhookID, webhookReplayBatch)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, len(failed))
+	for i, original := range failed {
+		ids[i] = original.ID
+	}
+	if dryRun {
+		return ids, nil
+	}
+	now := time.Now()
+	for i, original := range failed {
+		if err := s.webhookRepo.CreateDelivery(ctx, replayDelivery(original, &now)); err != nil {
+			return ids[:i], err
+		}
+	}
+	return ids, nil
+}
+
+// replayDelivery 复制原投递的事件和请求体, nextAttempt 不为空时由后台按退避间隔投递
+func replayDelivery(original *model.WebhookDelivery, nextAttempt *time.Time) *model.WebhookDelivery {
+	replayOf := original.ID
+	return &model.WebhookDelivery{
+		WebhookID:     original.WebhookID,
+		ProjectID:     original.ProjectID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        model.WebhookDeliveryPending,
+		NextAttemptAt: nextAttempt,
+		ReplayOf:      &replayOf,
+	}
+}
+
+// RotateSecret 重新生成项目的签名密钥, 未单独设置 secret 的 Webhook 立即使用新密钥
+func (s *WebhookService) RotateSecret(ctx context.Context, projectID int64) (string, error) {
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return "", ErrProjectNotFound
+	}
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return "", err
+	}
+	project.WebhookSecret = secret
+	if err := s.projectRepo.Update(ctx, project); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// Run 为变更生成投递记录并立即投递, 同时按间隔重试到期的失败投递
+func (s *WebhookService) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	report := func(err error) {
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case change := <-s.queue:
+			report(s.enqueue(ctx, change))
+			report(s.deliverDue(ctx))
+		case <-ticker.C:
+			report(s.deliverDue(ctx))
+		}
+	}
+}
+
+// enqueue 为匹配变更的 Webhook 创建投递记录; 已删除的配置无法确定所属项目, 不推送
+func (s *WebhookService) enqueue(ctx context.Context, change *ConfigChange) error {
+	config, err := s.configRepo.GetByID(ctx, change.ConfigID)
+	if err != nil {
+		return nil
+	}
+	webhooks, err := s.webhookRepo.ListActiveByProject(ctx, config.ProjectID)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	payload := &WebhookPayload{
+		Event:       change.ChangeType,
+		EventID:     change.ID,
+		ProjectID:   config.ProjectID,
+		ConfigID:    config.ID,
+		ConfigName:  config.Name,
+		Namespace:   config.Namespace,
+		Environment: change.Env,
+		Version:     change.Version,
+		OccurredAt:  time.Now(),
+	}
+	if payload.Environment == "" {
+		payload.Environment = config.Environment
+	}
+	if payload.Version == 0 {
+		payload.Version = config.CurrentVersion
+	}
+	payload.Summary = s.releaseSummary(ctx, config, change)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, webhook := range webhooks {
+		if !webhookMatches(webhook, payload.Event, payload.Environment) {
+			continue
+		}
+		delivery := &model.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			ProjectID:     webhook.ProjectID,
+			Event:         payload.Event,
+			Payload:       string(body),
+			Status:        model.WebhookDeliveryPending,
+			NextAttemptAt: &now,
+		}
+		if err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliverDue 并发投递到期的记录
+func (s *WebhookService) deliverDue(ctx context.Context) error {
+	deliveries, err := s.webhookRepo.ListDueDeliveries(ctx, time.Now(), webhookBatchSize)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	sem := make(chan struct{}, webhookConcurrency)
+	for _, delivery := range deliveries {
+		// 认领期间其他实例或下一轮不会重复投递; 进程中断时租约到期后重试
+		claimed, err := s.webhookRepo.ClaimDelivery(ctx, delivery, time.Now().Add(2*webhookTimeout))
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(delivery *model.WebhookDelivery) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := s.deliver(ctx, delivery); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(delivery)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// deliver 发送一次投递并记录结果, 失败时按退避间隔安排重试
+func (s *WebhookService) deliver(ctx context.Context, delivery *model.WebhookDelivery) error {
+	webhook, err := s.webhookRepo.GetByID(ctx, delivery.WebhookID)
+	if err != nil || !webhook.IsActive {
+		delivery.Status = model.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.LastError = "Webhook 已删除或停用"
+		return s.webhookRepo.UpdateDelivery(ctx, delivery)
+	}
+
+	err = s.send(ctx, webhook.URL, s.secret(ctx, webhook), delivery)
+	if err == nil {
+		delivery.Status = model.WebhookDeliverySuccess
+		delivery.NextAttemptAt = nil
+		delivery.LastError = ""
+		return s.webhookRepo.UpdateDelivery(ctx, delivery)
+	}
+
+	delivery.LastError = truncateRunes(RedactCredentials(err.Error()), 500)
+	if delivery.Attempts > len(webhookBackoff) {
+		delivery.Status = model.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+	} else {
+		next := time.Now().Add(webhookBackoff[delivery.Attempts-1])
+		delivery.NextAttemptAt = &next
+	}
+	return s.webhookRepo.UpdateDelivery(ctx, delivery)
+}
+
+// deliverOnce 同步发送一次并记录结果, 用于测试和手动重放; 接收方返回失败不作为错误返回
+func (s *WebhookService) deliverOnce(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) error {
+	if err := s.send(ctx, webhook.URL, s.secret(ctx, webhook), delivery); err != nil {
+		delivery.Status = model.WebhookDeliveryFailed
+		delivery.LastError = truncateRunes(RedactCredentials(err.Error()), 500)
+	} else {
+		delivery.Status = model.WebhookDeliverySuccess
+	}
+	delivery.NextAttemptAt = nil
+	return s.webhookRepo.UpdateDelivery(ctx, delivery)
+}
+
+// secret Webhook 的签名密钥, 未单独设置时使用项目密钥
+func (s *WebhookService) secret(ctx context.Context, webhook *model.Webhook) string {
+	if webhook.Secret != "" {
+		return webhook.Secret
+	}
+	if project, err := s.projectRepo.GetByID(ctx, webhook.ProjectID); err == nil {
+		return project.WebhookSecret
+	}
+	return ""
+}
+
+// send 发送签名请求并在投递记录上保存请求头、响应状态码、响应体和耗时; 非 2xx 响应视为失败
+func (s *WebhookService) send(ctx context.Context, target, secret string, delivery *model.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ConfigHub-Webhook/1.0")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	if delivery.ReplayOf != nil {
+		req.Header.Set(WebhookReplayHeader, strconv.FormatInt(*delivery.ReplayOf, 10))
+	}
+	if secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhook(secret, timestamp, []byte(delivery.Payload)))
+	}
+
+	headers := make(map[string]string, len(req.Header))
+	for name := range req.Header {
+		headers[name] = req.Header.Get(name)
+	}
+	encoded, _ := json.Marshal(headers)
+	delivery.RequestHeaders = string(encoded)
+	delivery.Attempts++
+	delivery.ResponseStatus = 0
+	delivery.ResponseBody = ""
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	delivery.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxResponse))
+	delivery.DurationMS = time.Since(start).Milliseconds()
+	delivery.ResponseStatus = resp.StatusCode
+	delivery.ResponseBody = strings.ToValidUTF8(string(body), "")
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(delivery.ResponseBody))
+	}
+	return nil
+}
+
+// SignWebhook 计算 Webhook 签名, 接收方使用同一密钥对 "<时间戳>.<请求体>" 计算后比较
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// applyWebhookRequest 校验请求并写入 Webhook; create 为 false 时空字段保持不变
+func applyWebhookRequest(webhook *model.Webhook, req *WebhookRequest, create bool) error {
+	if name := strings.TrimSpace(req.Name); name != "" || create {
+		if name == "" || len(name) > 100 {
+			return fmt.Errorf("%w: 名称不能为空且不超过 100 个字符", ErrInvalidWebhook)
+		}
+		webhook.Name = name
+	}
+	if target := strings.TrimSpace(req.URL); target != "" || create {
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(target) > 500 {
+			return fmt.Errorf("%w: url 必须是 http 或 https 地址", ErrInvalidWebhook)
+		}
+		webhook.URL = target
+	}
+	if req.Secret != nil {
+		if len(*req.Secret) > 128 {
+			return fmt.Errorf("%w: secret 不超过 128 个字符", ErrInvalidWebhook)
+		}
+		webhook.Secret = *req.Secret
+	}
+	if req.Events != nil {
+		for _, event := range req.Events {
+			if !containsString(WebhookEvents, event) {
+				return fmt.Errorf("%w: 不支持的事件 %s, 可选 %s", ErrInvalidWebhook, event, strings.Join(WebhookEvents, ", "))
+			}
+		}
+		webhook.Events = encodeWebhookFilter(req.Events)
+	}
+	if req.Environments != nil {
+		webhook.Environments = encodeWebhookFilter(req.Environments)
+	}
+	return nil
+}
+
+// encodeWebhookFilter 过滤条件以 JSON 数组保存, 为空时保存为空字符串表示全部
+func encodeWebhookFilter(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+// webhookMatches Webhook 是否订阅了指定事件和环境
+func webhookMatches(webhook *model.Webhook, event, env string) bool {
+	return webhookFilterMatches(webhook.Events, event) && webhookFilterMatches(webhook.Environments, env)
+}
+
+func webhookFilterMatches(filter, value string) bool {
+	if filter == "" || filter == "[]" || filter == "null" {
+		return true
+	}
+	var values []string
+	if err := json.Unmarshal([]byte(filter), &values); err != nil || len(values) == 0 {
+		return true
+	}
+	return containsString(values, value)
+}
+
+// generateWebhookSecret 生成项目签名密钥
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// truncateRunes 按字符截断字符串
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
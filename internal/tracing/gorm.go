@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// gormPlugin 为每条 SQL 创建 span, 父 span 取自查询的 WithContext
+type gormPlugin struct {
+	system string
+}
+
+// InstrumentGORM 为数据库连接注册追踪回调, driver 为 mysql 或 postgres
+func InstrumentGORM(db *gorm.DB, driver string) error {
+	system := semconv.DBSystemMySQL.Value.AsString()
+	if driver == "postgres" {
+		system = semconv.DBSystemPostgreSQL.Value.AsString()
+	}
+	return db.Use(&gormPlugin{system: system})
+}
+
+// Name 插件名称
+func (p *gormPlugin) Name() string {
+	return "confighub:tracing"
+}
+
+// Initialize 在各类操作前后注册回调
+func (p *gormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		op     string
+		before func(name string, fn func(*gorm.DB)) error
+		after  func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+	for _, h := range hooks {
+		if err := h.before("tracing:before_"+h.op, p.before(h.op)); err != nil {
+			return err
+		}
+		if err := h.after("tracing:after_"+h.op, p.after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// before 开始 span, 保存在语句实例中供 after 结束
+// 不替换语句上下文, 同一会话中的后续查询仍以调用方 span 为父
+func (p *gormPlugin) before(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement.Context == nil {
+			return
+		}
+		_, span := Start(db.Statement.Context, "db."+op, semconv.DBSystemKey.String(p.system))
+		db.InstanceSet("tracing:span", span)
+	}
+}
+
+// after 记录 SQL (参数为占位符, 不含数据)、表名、影响行数和错误
+func (p *gormPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet("tracing:span")
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	defer span.End()
+
+	span.SetAttributes(
+		semconv.DBStatement(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if db.Statement.Table != "" {
+		span.SetAttributes(semconv.DBSQLTable(db.Statement.Table))
+	}
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
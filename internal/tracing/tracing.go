@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 服务内埋点使用的 Tracer 名称
+const instrumentationName = "confighub"
+
+// Options 链路追踪配置
+type Options struct {
+	ServiceName string
+	Env         string
+	Endpoint    string  // OTLP/HTTP 地址 (host:port), 为空时使用 OTEL_EXPORTER_OTLP_ENDPOINT 或 localhost:4318
+	Insecure    bool    // 使用 HTTP 而非 HTTPS 连接 Collector
+	SampleRatio float64 // 无上游采样决定时的采样比例
+}
+
+// Setup 初始化全局 TracerProvider 和 W3C Trace Context 传播
+// 返回的 shutdown 在退出时调用, 以导出缓冲中的 span
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	exporterOpts := []otlptracehttp.Option{}
+	if opts.Endpoint != "" {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithEndpoint(opts.Endpoint))
+	}
+	if opts.Insecure {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(opts.ServiceName),
+		semconv.DeploymentEnvironment(opts.Env),
+	)
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// 客户端已采样的请求始终记录, 保证端到端链路完整
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Start 创建服务内部 span, 未启用追踪时为空操作
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// TraceID 返回上下文中的 trace ID, 不在链路中时为空
+func TraceID(ctx context.Context) string {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.HasTraceID() {
+		return ""
+	}
+	return spanCtx.TraceID().String()
+}
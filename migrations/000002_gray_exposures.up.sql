@@ -0,0 +1,22 @@
+-- 灰度实验曝光记录表
+CREATE TABLE IF NOT EXISTS gray_exposures (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    project_id BIGINT NOT NULL,
+    config_id BIGINT NOT NULL,
+    release_id BIGINT NOT NULL,
+    client_id VARCHAR(100) NOT NULL,
+    variant VARCHAR(20) NOT NULL,
+    environment VARCHAR(50),
+    version INT,
+    ip_address VARCHAR(45),
+    hit_count BIGINT DEFAULT 1,
+    first_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (release_id) REFERENCES releases(id) ON DELETE CASCADE,
+    UNIQUE KEY idx_exposure_release_client_variant (release_id, client_id, variant),
+    INDEX idx_project (project_id),
+    INDEX idx_config (config_id),
+    INDEX idx_client (client_id),
+    INDEX idx_first_seen (first_seen_at),
+    INDEX idx_last_seen (last_seen_at)
+);
@@ -0,0 +1,22 @@
+-- 灰度实验曝光记录表
+CREATE TABLE IF NOT EXISTS gray_exposures (
+    id BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL,
+    config_id BIGINT NOT NULL,
+    release_id BIGINT NOT NULL REFERENCES releases(id) ON DELETE CASCADE,
+    client_id VARCHAR(100) NOT NULL,
+    variant VARCHAR(20) NOT NULL,
+    environment VARCHAR(50),
+    version INT,
+    ip_address VARCHAR(45),
+    hit_count BIGINT DEFAULT 1,
+    first_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exposure_release_client_variant ON gray_exposures(release_id, client_id, variant);
+CREATE INDEX IF NOT EXISTS idx_gray_exposures_project ON gray_exposures(project_id);
+CREATE INDEX IF NOT EXISTS idx_gray_exposures_config ON gray_exposures(config_id);
+CREATE INDEX IF NOT EXISTS idx_gray_exposures_client ON gray_exposures(client_id);
+CREATE INDEX IF NOT EXISTS idx_gray_exposures_first_seen ON gray_exposures(first_seen_at);
+CREATE INDEX IF NOT EXISTS idx_gray_exposures_last_seen ON gray_exposures(last_seen_at);
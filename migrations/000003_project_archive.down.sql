@@ -0,0 +1,2 @@
+DROP INDEX idx_archived_at ON projects;
+ALTER TABLE projects DROP COLUMN archived_at;
@@ -0,0 +1,3 @@
+-- 项目归档
+ALTER TABLE projects ADD COLUMN archived_at TIMESTAMP NULL DEFAULT NULL;
+CREATE INDEX idx_archived_at ON projects(archived_at);
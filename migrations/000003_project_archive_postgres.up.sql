@@ -0,0 +1,3 @@
+-- 项目归档
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP NULL;
+CREATE INDEX IF NOT EXISTS idx_projects_archived_at ON projects(archived_at);
@@ -0,0 +1 @@
+ALTER TABLE project_environments DROP COLUMN variables;
@@ -0,0 +1,2 @@
+-- 环境变量
+ALTER TABLE project_environments ADD COLUMN IF NOT EXISTS variables JSONB NULL;
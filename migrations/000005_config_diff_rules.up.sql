@@ -0,0 +1,2 @@
+-- 配置对比忽略规则
+ALTER TABLE configs ADD COLUMN diff_rules JSON NULL;
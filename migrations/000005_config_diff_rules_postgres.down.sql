@@ -0,0 +1 @@
+ALTER TABLE configs DROP COLUMN IF EXISTS diff_rules;
@@ -0,0 +1,2 @@
+-- 配置对比忽略规则
+ALTER TABLE configs ADD COLUMN IF NOT EXISTS diff_rules JSONB NULL;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS release_approvals;
+ALTER TABLE releases DROP COLUMN required_approvals;
+ALTER TABLE releases DROP COLUMN risk_factors;
+ALTER TABLE releases DROP COLUMN risk_level;
+ALTER TABLE releases DROP COLUMN risk_score;
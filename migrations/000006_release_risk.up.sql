@@ -0,0 +1,15 @@
+-- 发布风险评估及审批
+ALTER TABLE releases ADD COLUMN risk_score INT DEFAULT 0;
+ALTER TABLE releases ADD COLUMN risk_level VARCHAR(10) NULL;
+ALTER TABLE releases ADD COLUMN risk_factors JSON NULL;
+ALTER TABLE releases ADD COLUMN required_approvals INT DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS release_approvals (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    release_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    comment VARCHAR(500),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (release_id) REFERENCES releases(id) ON DELETE CASCADE,
+    UNIQUE KEY idx_approval_release_user (release_id, user_id)
+);
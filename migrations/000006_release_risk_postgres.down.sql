@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS release_approvals;
+ALTER TABLE releases DROP COLUMN IF EXISTS required_approvals;
+ALTER TABLE releases DROP COLUMN IF EXISTS risk_factors;
+ALTER TABLE releases DROP COLUMN IF EXISTS risk_level;
+ALTER TABLE releases DROP COLUMN IF EXISTS risk_score;
@@ -0,0 +1,15 @@
+-- 发布风险评估及审批
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS risk_score INT DEFAULT 0;
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS risk_level VARCHAR(10) NULL;
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS risk_factors JSONB NULL;
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS required_approvals INT DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS release_approvals (
+    id BIGSERIAL PRIMARY KEY,
+    release_id BIGINT NOT NULL REFERENCES releases(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    comment VARCHAR(500),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_approval_release_user ON release_approvals(release_id, user_id);
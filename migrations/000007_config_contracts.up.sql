@@ -0,0 +1,13 @@
+-- 配置消费契约
+CREATE TABLE IF NOT EXISTS config_contracts (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    config_id BIGINT NOT NULL,
+    consumer VARCHAR(100) NOT NULL,
+    required_keys JSON,
+    enforcement VARCHAR(10) DEFAULT 'block',
+    access_key_id BIGINT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (config_id) REFERENCES configs(id) ON DELETE CASCADE,
+    UNIQUE KEY idx_contract_config_consumer (config_id, consumer)
+);
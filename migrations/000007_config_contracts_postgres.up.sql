@@ -0,0 +1,13 @@
+-- 配置消费契约
+CREATE TABLE IF NOT EXISTS config_contracts (
+    id BIGSERIAL PRIMARY KEY,
+    config_id BIGINT NOT NULL REFERENCES configs(id) ON DELETE CASCADE,
+    consumer VARCHAR(100) NOT NULL,
+    required_keys JSONB,
+    enforcement VARCHAR(10) DEFAULT 'block',
+    access_key_id BIGINT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_contract_config_consumer ON config_contracts(config_id, consumer);
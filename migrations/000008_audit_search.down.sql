@@ -0,0 +1,7 @@
+DROP INDEX idx_audit_request_body ON audit_logs;
+DROP INDEX idx_audit_status ON audit_logs;
+DROP INDEX idx_audit_resource_name ON audit_logs;
+DROP INDEX idx_audit_key ON audit_logs;
+DROP INDEX idx_audit_user ON audit_logs;
+
+ALTER TABLE audit_logs DROP COLUMN status_code;
@@ -0,0 +1,8 @@
+-- 审计日志检索: 响应状态码及查询索引
+ALTER TABLE audit_logs ADD COLUMN status_code INT DEFAULT 0;
+
+CREATE INDEX idx_audit_user ON audit_logs(user_id);
+CREATE INDEX idx_audit_key ON audit_logs(access_key_id);
+CREATE INDEX idx_audit_resource_name ON audit_logs(resource_name);
+CREATE INDEX idx_audit_status ON audit_logs(status_code);
+CREATE FULLTEXT INDEX idx_audit_request_body ON audit_logs(request_body);
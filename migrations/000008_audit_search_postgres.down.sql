@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_audit_request_body;
+DROP INDEX IF EXISTS idx_audit_status;
+DROP INDEX IF EXISTS idx_audit_resource_name;
+DROP INDEX IF EXISTS idx_audit_key;
+DROP INDEX IF EXISTS idx_audit_user;
+
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS status_code;
@@ -0,0 +1,11 @@
+-- 审计日志检索: 响应状态码及查询索引
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS status_code INT DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_audit_user ON audit_logs(user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_key ON audit_logs(access_key_id);
+CREATE INDEX IF NOT EXISTS idx_audit_resource_name ON audit_logs(resource_name);
+CREATE INDEX IF NOT EXISTS idx_audit_status ON audit_logs(status_code);
+
+-- 全文匹配使用 ILIKE, 由三元组索引加速
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_audit_request_body ON audit_logs USING GIN (request_body gin_trgm_ops);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS inbound_integrations;
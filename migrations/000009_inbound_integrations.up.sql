@@ -0,0 +1,19 @@
+-- 入站集成: 外部系统推送数据生成配置新版本
+CREATE TABLE IF NOT EXISTS inbound_integrations (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    project_id BIGINT NOT NULL,
+    config_id BIGINT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    token_prefix VARCHAR(16),
+    token_hash VARCHAR(128) NOT NULL,
+    template TEXT,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_by BIGINT,
+    last_received_at TIMESTAMP NULL,
+    last_version INT DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (config_id) REFERENCES configs(id) ON DELETE CASCADE,
+    INDEX idx_inbound_integrations_project (project_id),
+    INDEX idx_inbound_integrations_config (config_id)
+);
@@ -0,0 +1,12 @@
+-- 项目每日用量: 调用次数、监听时长、存储快照
+CREATE TABLE IF NOT EXISTS project_usage (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    project_id BIGINT NOT NULL,
+    day VARCHAR(10) NOT NULL,
+    api_calls BIGINT DEFAULT 0,
+    watcher_seconds BIGINT DEFAULT 0,
+    storage_bytes BIGINT DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY idx_usage_project_day (project_id, day),
+    INDEX idx_project_usage_day (day)
+);
@@ -0,0 +1,13 @@
+-- 项目每日用量: 调用次数、监听时长、存储快照
+CREATE TABLE IF NOT EXISTS project_usage (
+    id BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL,
+    day VARCHAR(10) NOT NULL,
+    api_calls BIGINT DEFAULT 0,
+    watcher_seconds BIGINT DEFAULT 0,
+    storage_bytes BIGINT DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_usage_project_day ON project_usage(project_id, day);
+CREATE INDEX IF NOT EXISTS idx_project_usage_day ON project_usage(day);
@@ -0,0 +1,5 @@
+DROP INDEX idx_audit_origin ON audit_logs;
+DROP INDEX idx_config_versions_origin ON config_versions;
+
+ALTER TABLE audit_logs DROP COLUMN origin;
+ALTER TABLE config_versions DROP COLUMN origin;
@@ -0,0 +1,6 @@
+-- 配置变更来源: human, api, ci, sync, automation:<名称>, 历史记录为空
+ALTER TABLE config_versions ADD COLUMN origin VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE audit_logs ADD COLUMN origin VARCHAR(100);
+
+CREATE INDEX idx_config_versions_origin ON config_versions(origin);
+CREATE INDEX idx_audit_origin ON audit_logs(origin);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_audit_origin;
+DROP INDEX IF EXISTS idx_config_versions_origin;
+
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS origin;
+ALTER TABLE config_versions DROP COLUMN IF EXISTS origin;
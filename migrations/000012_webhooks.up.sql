@@ -0,0 +1,37 @@
+-- 出站 Webhook: 配置变更时向外部地址推送签名事件
+CREATE TABLE IF NOT EXISTS webhooks (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    project_id BIGINT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    url VARCHAR(500) NOT NULL,
+    secret VARCHAR(128),
+    events JSON,
+    environments JSON,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_by BIGINT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    INDEX idx_webhooks_project (project_id)
+);
+
+-- Webhook 投递记录, 失败后按退避间隔重试
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    webhook_id BIGINT NOT NULL,
+    project_id BIGINT NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    payload TEXT,
+    status VARCHAR(20) NOT NULL,
+    attempts INT DEFAULT 0,
+    next_attempt_at TIMESTAMP NULL,
+    response_status INT DEFAULT 0,
+    last_error VARCHAR(500),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE,
+    INDEX idx_webhook_deliveries_webhook (webhook_id),
+    INDEX idx_webhook_deliveries_project (project_id),
+    INDEX idx_webhook_deliveries_status (status),
+    INDEX idx_webhook_deliveries_next_attempt (next_attempt_at)
+);
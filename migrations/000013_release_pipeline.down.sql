@@ -0,0 +1,3 @@
+ALTER TABLE releases DROP COLUMN pipeline_log;
+ALTER TABLE releases DROP COLUMN artifact_hash;
+ALTER TABLE releases DROP COLUMN artifact;
@@ -0,0 +1,4 @@
+-- 发布流水线产物及执行记录
+ALTER TABLE releases ADD COLUMN artifact LONGTEXT NULL;
+ALTER TABLE releases ADD COLUMN artifact_hash VARCHAR(64) NULL;
+ALTER TABLE releases ADD COLUMN pipeline_log TEXT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE releases DROP COLUMN IF EXISTS pipeline_log;
+ALTER TABLE releases DROP COLUMN IF EXISTS artifact_hash;
+ALTER TABLE releases DROP COLUMN IF EXISTS artifact;
@@ -0,0 +1,4 @@
+-- 发布流水线产物及执行记录
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS artifact TEXT NULL;
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS artifact_hash VARCHAR(64) NULL;
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS pipeline_log TEXT NULL;
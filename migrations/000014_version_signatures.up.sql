@@ -0,0 +1,35 @@
+-- 签名公钥: 用于验证配置版本的分离签名 (OpenPGP / Sigstore)
+CREATE TABLE IF NOT EXISTS signing_keys (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    project_id BIGINT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    public_key TEXT NOT NULL,
+    fingerprint VARCHAR(64),
+    identity VARCHAR(100),
+    created_by BIGINT,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    INDEX idx_signing_keys_project (project_id),
+    INDEX idx_signing_keys_fingerprint (fingerprint)
+);
+
+-- 配置版本签名, 附加时已通过验证
+CREATE TABLE IF NOT EXISTS version_signatures (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    project_id BIGINT NOT NULL,
+    config_id BIGINT NOT NULL,
+    version_id BIGINT NOT NULL,
+    version INT NOT NULL,
+    key_id BIGINT NOT NULL,
+    commit_hash VARCHAR(64),
+    signature TEXT NOT NULL,
+    signed_by VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (config_id) REFERENCES configs(id) ON DELETE CASCADE,
+    FOREIGN KEY (key_id) REFERENCES signing_keys(id) ON DELETE CASCADE,
+    UNIQUE INDEX idx_version_signature (version_id, key_id),
+    INDEX idx_version_signatures_project (project_id),
+    INDEX idx_version_signatures_config (config_id)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS version_signatures;
+DROP TABLE IF EXISTS signing_keys;
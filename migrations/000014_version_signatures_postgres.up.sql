@@ -0,0 +1,34 @@
+-- 签名公钥: 用于验证配置版本的分离签名 (OpenPGP / Sigstore)
+CREATE TABLE IF NOT EXISTS signing_keys (
+    id BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    public_key TEXT NOT NULL,
+    fingerprint VARCHAR(64),
+    identity VARCHAR(100),
+    created_by BIGINT,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_signing_keys_project ON signing_keys(project_id);
+CREATE INDEX IF NOT EXISTS idx_signing_keys_fingerprint ON signing_keys(fingerprint);
+
+-- 配置版本签名, 附加时已通过验证
+CREATE TABLE IF NOT EXISTS version_signatures (
+    id BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL,
+    config_id BIGINT NOT NULL REFERENCES configs(id) ON DELETE CASCADE,
+    version_id BIGINT NOT NULL,
+    version INT NOT NULL,
+    key_id BIGINT NOT NULL REFERENCES signing_keys(id) ON DELETE CASCADE,
+    commit_hash VARCHAR(64),
+    signature TEXT NOT NULL,
+    signed_by VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_version_signature ON version_signatures(version_id, key_id);
+CREATE INDEX IF NOT EXISTS idx_version_signatures_project ON version_signatures(project_id);
+CREATE INDEX IF NOT EXISTS idx_version_signatures_config ON version_signatures(config_id);
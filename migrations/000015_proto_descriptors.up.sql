@@ -0,0 +1,14 @@
+-- protobuf 配置的描述符: 校验配置内容并渲染为 JSON
+CREATE TABLE IF NOT EXISTS proto_descriptors (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    project_id BIGINT NOT NULL,
+    config_id BIGINT NOT NULL,
+    message_type VARCHAR(255) NOT NULL,
+    descriptor_set LONGTEXT NOT NULL,
+    updated_by BIGINT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (config_id) REFERENCES configs(id) ON DELETE CASCADE,
+    UNIQUE INDEX idx_proto_descriptors_config (config_id),
+    INDEX idx_proto_descriptors_project (project_id)
+);
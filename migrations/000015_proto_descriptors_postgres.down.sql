@@ -0,0 +1 @@
+DROP TABLE IF EXISTS proto_descriptors;
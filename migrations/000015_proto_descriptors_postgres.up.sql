@@ -0,0 +1,14 @@
+-- protobuf 配置的描述符: 校验配置内容并渲染为 JSON
+CREATE TABLE IF NOT EXISTS proto_descriptors (
+    id BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL,
+    config_id BIGINT NOT NULL REFERENCES configs(id) ON DELETE CASCADE,
+    message_type VARCHAR(255) NOT NULL,
+    descriptor_set TEXT NOT NULL,
+    updated_by BIGINT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_proto_descriptors_config ON proto_descriptors(config_id);
+CREATE INDEX IF NOT EXISTS idx_proto_descriptors_project ON proto_descriptors(project_id);
@@ -0,0 +1,6 @@
+DROP INDEX idx_webhook_deliveries_replay_of ON webhook_deliveries;
+
+ALTER TABLE webhook_deliveries DROP COLUMN replay_of;
+ALTER TABLE webhook_deliveries DROP COLUMN duration_ms;
+ALTER TABLE webhook_deliveries DROP COLUMN response_body;
+ALTER TABLE webhook_deliveries DROP COLUMN request_headers;
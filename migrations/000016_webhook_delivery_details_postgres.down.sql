@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_webhook_deliveries_replay_of;
+
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS replay_of;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS duration_ms;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS response_body;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS request_headers;
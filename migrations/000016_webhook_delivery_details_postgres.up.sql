@@ -0,0 +1,7 @@
+-- Webhook 投递详情: 请求头、响应体、耗时, 以及重放时的原投递记录
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS request_headers TEXT;
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS response_body TEXT;
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS duration_ms BIGINT DEFAULT 0;
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS replay_of BIGINT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_replay_of ON webhook_deliveries(replay_of);
@@ -0,0 +1,2 @@
+-- 配置的 Schema 校验策略: off, warn, block, 为空时继承项目设置
+ALTER TABLE configs ADD COLUMN schema_policy VARCHAR(10);
@@ -0,0 +1,6 @@
+DROP INDEX idx_config_notifications_project_id ON config_notifications;
+
+ALTER TABLE config_notifications DROP COLUMN environment;
+ALTER TABLE config_notifications DROP COLUMN namespace;
+ALTER TABLE config_notifications DROP COLUMN config_name;
+ALTER TABLE config_notifications DROP COLUMN project_id;
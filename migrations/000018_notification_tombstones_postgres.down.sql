@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_config_notifications_project_id;
+
+ALTER TABLE config_notifications DROP COLUMN IF EXISTS environment;
+ALTER TABLE config_notifications DROP COLUMN IF EXISTS namespace;
+ALTER TABLE config_notifications DROP COLUMN IF EXISTS config_name;
+ALTER TABLE config_notifications DROP COLUMN IF EXISTS project_id;
@@ -0,0 +1,7 @@
+-- 变更通知记录事件发生时配置的归属, 配置删除后仍可按命名空间和环境补发删除事件
+ALTER TABLE config_notifications ADD COLUMN IF NOT EXISTS project_id BIGINT NULL;
+ALTER TABLE config_notifications ADD COLUMN IF NOT EXISTS config_name VARCHAR(200) NULL;
+ALTER TABLE config_notifications ADD COLUMN IF NOT EXISTS namespace VARCHAR(100) NULL;
+ALTER TABLE config_notifications ADD COLUMN IF NOT EXISTS environment VARCHAR(50) NULL;
+
+CREATE INDEX IF NOT EXISTS idx_config_notifications_project_id ON config_notifications(project_id);
@@ -0,0 +1,3 @@
+DROP INDEX idx_configs_parent_id ON configs;
+
+ALTER TABLE configs DROP COLUMN parent_id;
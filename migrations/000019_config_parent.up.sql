@@ -0,0 +1,4 @@
+-- 配置继承: 下发内容为父配置链与自身内容的深度合并
+ALTER TABLE configs ADD COLUMN parent_id BIGINT NULL;
+
+CREATE INDEX idx_configs_parent_id ON configs(parent_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_configs_parent_id;
+
+ALTER TABLE configs DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1 @@
+ALTER TABLE config_versions DROP COLUMN git_ref;
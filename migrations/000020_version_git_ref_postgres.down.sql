@@ -0,0 +1 @@
+ALTER TABLE config_versions DROP COLUMN IF EXISTS git_ref;
@@ -0,0 +1,2 @@
+-- 版本内容保存在 Git 仓库时指向提交中的文件: <提交>:<路径>
+ALTER TABLE config_versions ADD COLUMN IF NOT EXISTS git_ref VARCHAR(300) NULL;
@@ -0,0 +1,19 @@
+-- 所有权转移申请: 项目所有权转移及配置迁移, 接收方确认后执行
+CREATE TABLE IF NOT EXISTS ownership_transfers (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    project_id BIGINT NOT NULL,
+    target_project_id BIGINT NULL,
+    config_ids JSON,
+    from_user_id BIGINT NOT NULL,
+    to_user_id BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    message VARCHAR(500),
+    expires_at TIMESTAMP NOT NULL,
+    decided_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_ownership_transfers_project_id (project_id),
+    INDEX idx_ownership_transfers_target_project_id (target_project_id),
+    INDEX idx_ownership_transfers_from_user_id (from_user_id),
+    INDEX idx_ownership_transfers_to_user_id (to_user_id),
+    INDEX idx_ownership_transfers_status (status)
+);
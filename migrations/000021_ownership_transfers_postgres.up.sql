@@ -0,0 +1,20 @@
+-- 所有权转移申请: 项目所有权转移及配置迁移, 接收方确认后执行
+CREATE TABLE IF NOT EXISTS ownership_transfers (
+    id BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL,
+    target_project_id BIGINT NULL,
+    config_ids JSON,
+    from_user_id BIGINT NOT NULL,
+    to_user_id BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    message VARCHAR(500),
+    expires_at TIMESTAMP NOT NULL,
+    decided_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_ownership_transfers_project_id ON ownership_transfers(project_id);
+CREATE INDEX IF NOT EXISTS idx_ownership_transfers_target_project_id ON ownership_transfers(target_project_id);
+CREATE INDEX IF NOT EXISTS idx_ownership_transfers_from_user_id ON ownership_transfers(from_user_id);
+CREATE INDEX IF NOT EXISTS idx_ownership_transfers_to_user_id ON ownership_transfers(to_user_id);
+CREATE INDEX IF NOT EXISTS idx_ownership_transfers_status ON ownership_transfers(status);
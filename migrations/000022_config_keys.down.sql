@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS config_key_history;
+DROP TABLE IF EXISTS config_keys;
@@ -0,0 +1,30 @@
+-- kv 配置: 每个键一行, 以及单个键的修改历史
+CREATE TABLE IF NOT EXISTS config_keys (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    config_id BIGINT NOT NULL,
+    key_name VARCHAR(200) NOT NULL,
+    value TEXT,
+    revision INT NOT NULL,
+    config_version INT NOT NULL,
+    updated_by VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (config_id) REFERENCES configs(id) ON DELETE CASCADE,
+    UNIQUE INDEX idx_config_key (config_id, key_name)
+);
+
+CREATE TABLE IF NOT EXISTS config_key_history (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    config_id BIGINT NOT NULL,
+    key_name VARCHAR(200) NOT NULL,
+    revision INT NOT NULL,
+    value TEXT,
+    deleted BOOLEAN DEFAULT FALSE,
+    config_version INT NOT NULL,
+    author VARCHAR(100),
+    message VARCHAR(500),
+    origin VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (config_id) REFERENCES configs(id) ON DELETE CASCADE,
+    INDEX idx_config_key_history (config_id, key_name)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN last_login_at;
+ALTER TABLE project_keys DROP COLUMN last_used_at;
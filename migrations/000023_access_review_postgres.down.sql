@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS last_login_at;
+ALTER TABLE project_keys DROP COLUMN IF EXISTS last_used_at;
@@ -0,0 +1,3 @@
+-- 访问审查: 密钥最近使用时间和用户最近登录时间
+ALTER TABLE project_keys ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMP NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMP NULL;
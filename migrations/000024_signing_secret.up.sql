@@ -0,0 +1,2 @@
+-- 签名校验: 以加密形式保存 Secret Key, 用于校验请求签名
+ALTER TABLE project_keys ADD COLUMN secret_key_enc VARCHAR(255) NULL;
@@ -0,0 +1,9 @@
+-- 项目数据加密密钥: 每个项目独立的 DEK, 以主密钥包装后保存
+CREATE TABLE IF NOT EXISTS project_data_keys (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    project_id BIGINT NOT NULL,
+    key_id VARCHAR(32) NOT NULL,
+    wrapped_key VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_project_data_key (project_id, key_id)
+);
@@ -0,0 +1 @@
+ALTER TABLE project_keys DROP COLUMN priority;
@@ -0,0 +1,2 @@
+-- 监听优先级: 负载过高时优先保障 high 密钥的监听, 限流 batch 密钥
+ALTER TABLE project_keys ADD COLUMN priority VARCHAR(20) NOT NULL DEFAULT 'normal';
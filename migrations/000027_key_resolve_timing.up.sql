@@ -0,0 +1,2 @@
+-- 解析耗时响应头: 开启后公开读取响应携带 X-Resolve-* 耗时分解
+ALTER TABLE project_keys ADD COLUMN resolve_timing BOOLEAN NOT NULL DEFAULT FALSE;
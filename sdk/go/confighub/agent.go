@@ -0,0 +1,404 @@
+package confighub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultAgentIdleTimeout is how long the agent keeps watching a config no
+// process has asked for
+const defaultAgentIdleTimeout = 10 * time.Minute
+
+// maxAgentWatchTimeout caps the watch timeout requested by processes
+const maxAgentWatchTimeout = 120 * time.Second
+
+// AgentOptions configures a local agent
+type AgentOptions struct {
+	// Client configures the upstream connection (required). Its credentials,
+	// Transports, StreamWatch, NotifyOnly, Locale, ClientID and InstanceLabels apply to
+	// every process served by the agent; gray releases therefore see the
+	// host, not the individual process
+	Client *ClientOptions
+
+	// SocketMode is the permission of the socket file created by
+	// ListenAndServe (default: 0660). Any process that can connect reads
+	// configs with the agent's credentials
+	SocketMode os.FileMode
+
+	// IdleTimeout stops the upstream watch of a config no process asked for
+	// within this duration (default: 10 minutes)
+	IdleTimeout time.Duration
+}
+
+// Agent shares one upstream connection per host: processes on the host
+// connect to it over a Unix socket (ClientOptions.AgentSocket) and the agent
+// keeps a single upstream watch per config, however many processes read it.
+type Agent struct {
+	opts   AgentOptions
+	client *Client
+	ctx    context.Context
+	cancel context.CancelFunc
+	flight flightGroup
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	entries map[string]*agentEntry
+	servers []*http.Server
+	closed  bool
+}
+
+// agentEntry is a config shared by the processes on the host
+type agentEntry struct {
+	name, namespace, env string
+
+	// guarded by Agent.mu
+	config   *Config
+	deleted  bool
+	changed  chan struct{} // closed and replaced whenever config or deleted changes
+	lastUsed time.Time
+	waiters  int
+}
+
+// NewAgent creates a local agent
+func NewAgent(opts *AgentOptions) (*Agent, error) {
+	if opts.Client == nil {
+		return nil, errors.New("client options are required")
+	}
+	if opts.Client.AgentSocket != "" {
+		return nil, errors.New("agent client options must not set AgentSocket")
+	}
+	client, err := NewClient(opts.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	agentOpts := *opts
+	if agentOpts.SocketMode == 0 {
+		agentOpts.SocketMode = 0660
+	}
+	if agentOpts.IdleTimeout <= 0 {
+		agentOpts.IdleTimeout = defaultAgentIdleTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Agent{
+		opts:    agentOpts,
+		client:  client,
+		ctx:     ctx,
+		cancel:  cancel,
+		entries: make(map[string]*agentEntry),
+	}, nil
+}
+
+// ListenAndServe listens on the Unix socket at path, replacing a stale socket
+// file left by a previous agent, and serves until Close
+func (a *Agent) ListenAndServe(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(path, a.opts.SocketMode); err != nil {
+		ln.Close()
+		return err
+	}
+	return a.Serve(ln)
+}
+
+// Serve serves processes on ln until Close. It returns nil after Close.
+func (a *Agent) Serve(ln net.Listener) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/config", a.handleConfig)
+	mux.HandleFunc("/v1/watch", a.handleWatch)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		ln.Close()
+		return ErrClientClosed
+	}
+	a.servers = append(a.servers, server)
+	a.mu.Unlock()
+
+	if err := server.Serve(ln); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Close stops serving, ends all upstream watches and closes the upstream client
+func (a *Agent) Close() error {
+	a.mu.Lock()
+	a.closed = true
+	servers := a.servers
+	a.servers = nil
+	a.mu.Unlock()
+
+	for _, server := range servers {
+		server.Close()
+	}
+	a.cancel()
+	a.wg.Wait()
+	return a.client.Close()
+}
+
+// handleConfig serves the current config, fetching it upstream on first use
+func (a *Agent) handleConfig(w http.ResponseWriter, r *http.Request) {
+	e, ok := a.requestEntry(w, r)
+	if !ok {
+		return
+	}
+
+	a.mu.Lock()
+	config, deleted := e.config, e.deleted
+	a.mu.Unlock()
+	if deleted || config == nil {
+		writeAgentError(w, http.StatusNotFound, ErrNotFound)
+		return
+	}
+	writeAgentJSON(w, http.StatusOK, config)
+}
+
+// handleWatch blocks until the config moves past the version the process
+// has, the config is deleted or the timeout elapses
+func (a *Agent) handleWatch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	version, _ := strconv.Atoi(q.Get("version"))
+	timeout := time.Duration(a.client.opts.WatchTimeout) * time.Second
+	if v, err := strconv.Atoi(q.Get("timeout")); err == nil && v > 0 {
+		timeout = time.Duration(v) * time.Second
+	}
+	if timeout > maxAgentWatchTimeout {
+		timeout = maxAgentWatchTimeout
+	}
+
+	e, ok := a.requestEntry(w, r)
+	if !ok {
+		return
+	}
+
+	a.mu.Lock()
+	e.waiters++
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		e.waiters--
+		e.lastUsed = time.Now()
+		a.mu.Unlock()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		a.mu.Lock()
+		config, deleted, changed := e.config, e.deleted, e.changed
+		a.mu.Unlock()
+
+		if deleted {
+			writeAgentError(w, http.StatusGone, ErrConfigDeleted)
+			return
+		}
+		if config != nil && config.Version > version {
+			writeAgentJSON(w, http.StatusOK, struct {
+				Changed bool `json:"changed"`
+				*Config
+			}{true, config})
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			writeAgentJSON(w, http.StatusOK, map[string]bool{"changed": false})
+			return
+		case <-r.Context().Done():
+			return
+		case <-a.ctx.Done():
+			writeAgentError(w, http.StatusServiceUnavailable, ErrClientClosed)
+			return
+		}
+	}
+}
+
+// requestEntry returns the entry for the config named in the request,
+// writing an error response when it cannot be loaded
+func (a *Agent) requestEntry(w http.ResponseWriter, r *http.Request) (*agentEntry, bool) {
+	if r.Method != http.MethodGet {
+		writeAgentError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return nil, false
+	}
+	q := r.URL.Query()
+	name := q.Get("name")
+	if name == "" {
+		writeAgentError(w, http.StatusBadRequest, errors.New("name is required"))
+		return nil, false
+	}
+	// Localized values are resolved upstream with the agent's locale
+	if locale := q.Get("locale"); locale != a.client.opts.Locale {
+		writeAgentError(w, http.StatusBadRequest, fmt.Errorf("agent serves locale %q, not %q", a.client.opts.Locale, locale))
+		return nil, false
+	}
+
+	namespace := q.Get("namespace")
+	if namespace == "" {
+		namespace = a.client.opts.Namespace
+	}
+	env := q.Get("env")
+	if env == "" {
+		env = a.client.opts.Environment
+	}
+
+	e, err := a.entry(r.Context(), name, namespace, env)
+	switch {
+	case err == nil:
+		return e, true
+	case errors.Is(err, ErrNotFound):
+		writeAgentError(w, http.StatusNotFound, err)
+	case errors.Is(err, ErrUnauthorized):
+		writeAgentError(w, http.StatusUnauthorized, err)
+	case errors.Is(err, context.Canceled):
+	default:
+		writeAgentError(w, http.StatusBadGateway, err)
+	}
+	return nil, false
+}
+
+// entry returns the shared entry of a config. On first use the config is
+// fetched upstream, coalescing concurrent requests, and its upstream watch
+// is started.
+func (a *Agent) entry(ctx context.Context, name, namespace, env string) (*agentEntry, error) {
+	key := a.client.cacheKey(name, namespace, env)
+
+	a.mu.Lock()
+	if e, ok := a.entries[key]; ok {
+		e.lastUsed = time.Now()
+		a.mu.Unlock()
+		return e, nil
+	}
+	a.mu.Unlock()
+
+	config, err := a.flight.do(ctx, key, func() (*Config, error) {
+		return a.client.fetchConfig(a.ctx, name, namespace, env, 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return nil, ErrClientClosed
+	}
+	if e, ok := a.entries[key]; ok {
+		e.lastUsed = time.Now()
+		return e, nil
+	}
+	e := &agentEntry{
+		name:      name,
+		namespace: namespace,
+		env:       env,
+		config:    config,
+		changed:   make(chan struct{}),
+		lastUsed:  time.Now(),
+	}
+	a.entries[key] = e
+	a.wg.Add(1)
+	go a.watchUpstream(key, e)
+	return e, nil
+}
+
+// watchUpstream keeps the entry up to date with a single upstream watch
+// until the agent is closed or no process used the config for IdleTimeout
+func (a *Agent) watchUpstream(key string, e *agentEntry) {
+	defer a.wg.Done()
+	ctx := a.ctx
+
+	for ctx.Err() == nil {
+		a.mu.Lock()
+		if e.waiters == 0 && time.Since(e.lastUsed) > a.opts.IdleTimeout {
+			delete(a.entries, key)
+			a.mu.Unlock()
+			return
+		}
+		cached := e.config
+		a.mu.Unlock()
+
+		currentVersion, currentContent := 0, ""
+		if cached != nil {
+			currentVersion, currentContent = cached.Version, cached.Content
+		}
+
+		config, err := a.client.watchOnce(ctx, e.name, e.namespace, e.env, currentVersion)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			if err == ErrWatchTimeout {
+				continue
+			}
+			if errors.Is(err, ErrConfigDeleted) || errors.Is(err, ErrNotFound) {
+				a.update(e, nil)
+				// Wait for the config to be created again
+				sleepContext(ctx, deletedPollInterval)
+				continue
+			}
+			if a.client.opts.OnError != nil {
+				a.client.opts.OnError(err)
+			}
+			sleepContext(ctx, watchErrorBackoff)
+			continue
+		}
+
+		if config != nil && config.contentOmitted {
+			if config, err = a.client.completeNotification(ctx, e.name, e.namespace, e.env, cached, config); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				if a.client.opts.OnError != nil {
+					a.client.opts.OnError(err)
+				}
+				sleepContext(ctx, watchErrorBackoff)
+				continue
+			}
+		}
+
+		if config != nil && (config.Version > currentVersion || config.Content != currentContent) {
+			a.update(e, config)
+		}
+	}
+}
+
+// update replaces the entry's config (nil when deleted upstream) and wakes
+// the waiting watch requests
+func (a *Agent) update(e *agentEntry, config *Config) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if config == nil && e.deleted {
+		return
+	}
+	e.config = config
+	e.deleted = config == nil
+	close(e.changed)
+	e.changed = make(chan struct{})
+}
+
+func writeAgentJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAgentError(w http.ResponseWriter, status int, err error) {
+	writeAgentJSON(w, status, map[string]string{"error": err.Error()})
+}
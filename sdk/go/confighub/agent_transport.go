@@ -0,0 +1,163 @@
+package confighub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TransportAgent is the name of the transport that reads configs through a
+// local agent (ClientOptions.AgentSocket)
+const TransportAgent = "agent"
+
+// agentRetryInterval is how long requests go to the server directly after
+// the agent was unreachable, before the agent is tried again
+const agentRetryInterval = 30 * time.Second
+
+// agentTransport reads configs from a local Agent over a Unix socket. While
+// the agent is unreachable (not started yet, restarting) requests go to the
+// server directly with long-polling.
+type agentTransport struct {
+	c          *Client
+	socket     string
+	httpClient *http.Client
+	direct     *longPollTransport
+
+	mu        sync.Mutex
+	downUntil time.Time
+}
+
+func newAgentTransport(c *Client) *agentTransport {
+	socket := c.opts.AgentSocket
+	return &agentTransport{
+		c:      c,
+		socket: socket,
+		httpClient: &http.Client{
+			Timeout: time.Duration(c.opts.WatchTimeout+10) * time.Second,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", socket)
+				},
+			},
+		},
+		direct: &longPollTransport{c: c},
+	}
+}
+
+// Name returns the transport name
+func (t *agentTransport) Name() string {
+	return TransportAgent
+}
+
+// Fetch returns the config cached by the agent
+func (t *agentTransport) Fetch(ctx context.Context, name, namespace, env string) (*Config, error) {
+	if !t.available() {
+		return t.direct.Fetch(ctx, name, namespace, env)
+	}
+
+	config, err := t.get(ctx, "/v1/config", name, namespace, env, nil)
+	if t.unreachable(err) {
+		return t.direct.Fetch(ctx, name, namespace, env)
+	}
+	return config, err
+}
+
+// Watch waits for a change announced by the agent
+func (t *agentTransport) Watch(ctx context.Context, name, namespace, env string, currentVersion int) (*Config, error) {
+	if !t.available() {
+		return t.direct.Watch(ctx, name, namespace, env, currentVersion)
+	}
+
+	config, err := t.get(ctx, "/v1/watch", name, namespace, env, url.Values{
+		"version": {strconv.Itoa(currentVersion)},
+		"timeout": {strconv.Itoa(t.c.opts.WatchTimeout)},
+	})
+	if t.unreachable(err) {
+		return t.direct.Watch(ctx, name, namespace, env, currentVersion)
+	}
+	return config, err
+}
+
+// get performs a request against the agent
+func (t *agentTransport) get(ctx context.Context, path, name, namespace, env string, extra url.Values) (*Config, error) {
+	q := url.Values{}
+	for key, values := range extra {
+		q[key] = values
+	}
+	q.Set("name", name)
+	q.Set("namespace", namespace)
+	q.Set("env", env)
+	if t.c.opts.Locale != "" {
+		q.Set("locale", t.c.opts.Locale)
+	}
+
+	// The host is ignored: the connection is dialed to the socket
+	req, err := http.NewRequestWithContext(ctx, "GET", "http://agent"+path+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	case http.StatusUnauthorized:
+		return nil, ErrUnauthorized
+	case http.StatusGone:
+		return nil, ErrConfigDeleted
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var result struct {
+		Changed *bool `json:"changed"`
+		Config
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Changed != nil && !*result.Changed {
+		return nil, ErrWatchTimeout
+	}
+	return &result.Config, nil
+}
+
+// available reports whether requests should go to the agent
+func (t *agentTransport) available() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return time.Now().After(t.downUntil)
+}
+
+// unreachable reports whether err means the agent could not be connected
+// to, and if so sends requests to the server directly for agentRetryInterval
+func (t *agentTransport) unreachable(err error) bool {
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) || opErr.Op != "dial" {
+		return false
+	}
+
+	t.mu.Lock()
+	t.downUntil = time.Now().Add(agentRetryInterval)
+	t.mu.Unlock()
+
+	if t.c.opts.OnError != nil {
+		t.c.opts.OnError(fmt.Errorf("agent at %s unreachable, connecting to the server directly: %w", t.socket, err))
+	}
+	return true
+}
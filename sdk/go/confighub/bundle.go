@@ -0,0 +1,125 @@
+package confighub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// LoadBundle fetches every config of the default namespace and environment
+// that the access key can read in a single request and stores them in the
+// cache, so subsequent Get calls don't hit the server. It returns the number
+// of configs loaded. The response is gzip-compressed; the default HTTP
+// transport decompresses it transparently.
+func (c *Client) LoadBundle(ctx context.Context) (int, error) {
+	configs, _, err := c.fetchConfigs(ctx, "/api/v1/bootstrap", c.opts.Namespace, url.Values{})
+	if err != nil {
+		return 0, fmt.Errorf("bootstrap error: %w", err)
+	}
+	c.storeConfigs(c.opts.Namespace, configs)
+	return len(configs), nil
+}
+
+// GetAll fetches configs of the default namespace and environment in a single
+// request and stores them in the cache, returning them by name. Without names
+// every config the access key can read is returned. Named configs that do not
+// exist or cannot be read are reported in an error wrapping ErrNotFound; the
+// configs that were found are still returned.
+func (c *Client) GetAll(ctx context.Context, names ...string) (map[string]*Config, error) {
+	q := url.Values{}
+	if len(names) > 0 {
+		q.Set("names", strings.Join(names, ","))
+	}
+	configs, missing, err := c.fetchConfigs(ctx, "/api/v1/configs", c.opts.Namespace, q)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*Config, len(configs))
+	for _, config := range c.storeConfigs(c.opts.Namespace, configs) {
+		result[config.Name] = config
+	}
+	if len(missing) > 0 {
+		return result, fmt.Errorf("%w: %s", ErrNotFound, strings.Join(missing, ", "))
+	}
+	return result, nil
+}
+
+// fetchConfigs requests a bulk endpoint for namespace in the default
+// environment, returning the configs and the names reported missing
+func (c *Client) fetchConfigs(ctx context.Context, path, namespace string, q url.Values) ([]*Config, []string, error) {
+	u, err := url.Parse(c.opts.ServerURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	u.Path = path
+
+	q.Set("namespace", namespace)
+	q.Set("env", c.opts.Environment)
+	if c.opts.Locale != "" {
+		q.Set("locale", c.opts.Locale)
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.signRequest(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	c.observeInstance(resp.Header.Get(InstanceHeader))
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, nil, ErrUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, nil, &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var bundle struct {
+		Configs []*Config `json:"configs"`
+		Missing []string  `json:"missing"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&bundle); err != nil {
+		return nil, nil, err
+	}
+	return bundle.Configs, bundle.Missing, nil
+}
+
+// storeConfigs caches configs of namespace in the default environment and
+// persists them to the local fallback cache, returning the cached configs
+// with dev overrides applied
+func (c *Client) storeConfigs(namespace string, configs []*Config) []*Config {
+	cached := make([]*Config, len(configs))
+	c.cacheMu.Lock()
+	for i, config := range configs {
+		cached[i] = c.applyOverride(config)
+		c.cache[c.cacheKey(config.Name, namespace, c.opts.Environment)] = cached[i]
+	}
+	c.cacheMu.Unlock()
+	for _, config := range configs {
+		c.saveLocal(config.Name, namespace, c.opts.Environment, config)
+	}
+	return cached
+}
+
+// loadBundleOnce loads the bundle on the first cache miss when
+// ClientOptions.LoadBundle is set. Failures are reported via OnError and the
+// client falls back to fetching configs one by one.
+func (c *Client) loadBundleOnce(ctx context.Context) {
+	c.bundleOnce.Do(func() {
+		if _, err := c.LoadBundle(ctx); err != nil && c.opts.OnError != nil {
+			c.opts.OnError(fmt.Errorf("load bundle: %w", err))
+		}
+	})
+}
@@ -3,16 +3,18 @@ package confighub
 
 import (
 	"context"
+	"crypto/cipher"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"log"
 	"net/http"
-	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -23,8 +25,23 @@ var (
 	ErrInvalidConfig  = errors.New("invalid configuration")
 	ErrWatchTimeout   = errors.New("watch timeout")
 	ErrClientClosed   = errors.New("client closed")
+	ErrNotReady       = errors.New("required configs not loaded")
+	ErrUnreachable    = errors.New("server unreachable")
+	ErrBundleInvalid  = errors.New("invalid offline bundle")
+	ErrBundleExpired  = errors.New("offline bundle expired")
+	ErrConfigDeleted  = errors.New("config deleted")
 )
 
+// waitRetryInterval is the delay between retries in WaitForConfigs
+const waitRetryInterval = time.Second
+
+// deletedPollInterval is the delay between checks whether a deleted watched
+// config was created again
+const deletedPollInterval = 30 * time.Second
+
+// watchErrorBackoff is the delay before retrying a failed watch request
+const watchErrorBackoff = 5 * time.Second
+
 // Config represents a configuration item
 type Config struct {
 	Name        string `json:"name"`
@@ -32,6 +49,29 @@ type Config struct {
 	Environment string `json:"environment"`
 	Version     int    `json:"version"`
 	Content     string `json:"content"`
+
+	// Locale is the requested locale the localized values were resolved for;
+	// empty when ClientOptions.Locale is not set
+	Locale string `json:"locale,omitempty"`
+
+	// Release metadata of the served variant. ReleaseID, ReleaseType and
+	// ReleasedAt are empty when the served version has not been released.
+	ReleaseID   int64     `json:"release_id,omitempty"`
+	ReleaseType string    `json:"release_type,omitempty"` // full, gray, override
+	ContentHash string    `json:"content_hash,omitempty"`
+	ReleasedAt  time.Time `json:"released_at,omitempty"`
+
+	// contentOmitted marks a notify-only watch response without content
+	contentOmitted bool
+
+	// expiresAt is the expiry of the offline bundle the config was loaded
+	// from; zero for configs fetched from the server
+	expiresAt time.Time
+}
+
+// IsGray reports whether the config was served from a gray release
+func (c *Config) IsGray() bool {
+	return c.ReleaseType == "gray"
 }
 
 // ClientOptions configures the ConfigHub client
@@ -57,11 +97,125 @@ type ClientOptions struct {
 	// HTTPClient is a custom HTTP client (optional)
 	HTTPClient *http.Client
 
-	// OnChange is called when configuration changes
+	// DisableCompression stops requesting gzip-compressed responses. By
+	// default responses are compressed, which pays off for large configs
+	DisableCompression bool
+
+	// OnChange is called when any watched configuration changes.
+	//
+	// Deprecated: use Client.OnKeyChange, which supports multiple
+	// subscribers per config and unsubscribing.
 	OnChange func(config *Config)
 
 	// OnError is called when an error occurs during watch
 	OnError func(err error)
+
+	// OnDelete is called once when a watched configuration is deleted on the
+	// server, with the last known copy. The config is dropped from the cache
+	// and the local cache; if it is created again, the watch picks it up and
+	// reports it like a change.
+	OnDelete func(config *Config)
+
+	// NotifyOnly makes watch responses carry only the version and content
+	// hash; the content is fetched separately, and only when the hash differs
+	// from the cached one. Saves bandwidth for large configs with frequent
+	// no-op saves.
+	NotifyOnly bool
+
+	// OnResync is called after the watched configs were refreshed because
+	// the server restarted (its instance ID changed)
+	OnResync func(event *ResyncEvent)
+
+	// SignatureVersion selects the request signing scheme: 1 (default) or 2
+	// (canonical query, signed headers, nonce and body digest). Projects that
+	// require signatures accept only 2
+	SignatureVersion int
+
+	// UseWatchToken exchanges the access key for a short-lived watch token and
+	// uses it for long-poll reconnects instead of signing every request
+	UseWatchToken bool
+
+	// FallbackEnvironments are tried in order when a config does not exist in
+	// the requested environment, e.g. []string{"default"} (optional)
+	FallbackEnvironments []string
+
+	// Transports are custom push transports tried in order if the server
+	// offers them (by Name); long-polling is always the fallback
+	Transports []Transport
+
+	// StreamWatch keeps one server-sent events stream open per watched config
+	// instead of sending a long-poll request per change, if the server offers
+	// it. It is tried after Transports (optional)
+	StreamWatch bool
+
+	// AgentSocket is the Unix socket of a local agent (see Agent and
+	// cmd/confighub-agent). Get and Watch then go through the agent, which
+	// keeps one upstream watch per config for all processes on the host,
+	// instead of each process connecting to the server. While the agent is
+	// unreachable the client connects directly with its own credentials.
+	// Transports is ignored (optional)
+	AgentSocket string
+
+	// OverridesPath enables dev mode: configs found in this directory or
+	// JSON file take precedence over server values (optional)
+	OverridesPath string
+
+	// LoadBundle loads all configs of the default namespace and environment
+	// in one request on the first cache miss, making cold starts a single
+	// round trip. Configs missing from the bundle are fetched individually.
+	LoadBundle bool
+
+	// CacheDir enables the local fallback cache: fetched configs are written
+	// to this directory and served from it when the server is unreachable.
+	// Files are encrypted with AES-256-GCM (optional)
+	CacheDir string
+
+	// CacheKey encrypts the local fallback cache. If empty, a key is derived
+	// from the machine ID and the access and secret keys, so cache files are
+	// unreadable on other hosts and after rotating the secret key (optional)
+	CacheKey []byte
+
+	// InjectTraceContext adds trace context headers (e.g. W3C traceparent) for
+	// the request context to every outgoing request, so a config fetch can be
+	// traced from the client through the server to the database. With
+	// OpenTelemetry:
+	//
+	//	InjectTraceContext: func(ctx context.Context, h http.Header) {
+	//		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(h))
+	//	}
+	InjectTraceContext func(ctx context.Context, header http.Header)
+
+	// OfflineBundlePublicKey is the base64 Ed25519 public key offline bundles
+	// are verified with, as served by
+	// /api/v1/offline-bundle/public-key?project_id=<OfflineBundleProjectID>.
+	// Required for LoadOfflineBundle
+	OfflineBundlePublicKey string
+
+	// OfflineBundleProjectID is the ID of the project offline bundles must
+	// belong to; bundles of other projects are rejected even if validly
+	// signed. Required for LoadOfflineBundle
+	OfflineBundleProjectID int64
+
+	// Retry retries config fetches that fail with a transport error or a
+	// retryable status code, with exponential backoff (optional; by default a
+	// failed fetch is returned immediately)
+	Retry *RetryPolicy
+
+	// Locale resolves localized values ({"$i18n": {"zh": "...", "en": "..."}})
+	// in JSON and YAML configs to a single variant, following the project's
+	// fallback chain, e.g. "zh-CN" (optional; by default all variants are
+	// returned)
+	Locale string
+
+	// ClientID identifies this instance for gray releases: percentage rules
+	// hash it into a stable bucket and client_id rules match it. Sent as the
+	// X-Client-ID header (default: the host name)
+	ClientID string
+
+	// InstanceLabels describe this instance, e.g. {"region": "eu-west",
+	// "version": "1.4.2"}, and are sent as the X-Client-Labels header
+	// (optional)
+	InstanceLabels map[string]string
 }
 
 // Client is the ConfigHub SDK client
@@ -71,9 +225,54 @@ type Client struct {
 	cache      map[string]*Config
 	cacheMu    sync.RWMutex
 	watching   bool
+	watched    []string
 	watchMu    sync.Mutex
 	stopCh     chan struct{}
 	wg         sync.WaitGroup
+	required   map[string]bool
+	requiredMu sync.RWMutex
+	flight     flightGroup
+	bundleOnce sync.Once
+
+	// watchCtx is derived from the Watch context and canceled on StopWatch;
+	// guarded by watchMu
+	watchCtx    context.Context
+	watchCancel context.CancelFunc
+
+	transport     Transport
+	transportOnce sync.Once
+	transportMu   sync.RWMutex
+
+	watchToken        string
+	watchTokenExpires time.Time
+	watchTokenMu      sync.Mutex
+
+	keyWatchers      map[string][]*keyWatcher
+	nextKeyWatcherID int
+	keyWatchMu       sync.Mutex
+
+	changeSubs      map[string][]*changeSubscriber
+	nextChangeSubID int
+	changeSubMu     sync.Mutex
+
+	nsWatches     map[int]context.CancelFunc
+	nextNSWatchID int
+	nsWatchMu     sync.Mutex
+	nsWatchWG     sync.WaitGroup
+
+	instanceID string
+	resyncing  bool
+	instanceMu sync.Mutex
+
+	localAEAD    cipher.AEAD
+	localKeyErr  error
+	localKeyOnce sync.Once
+
+	// labels is the encoded InstanceLabels header value
+	labels string
+
+	// overrideWarned records the override warnings already logged
+	overrideWarned sync.Map
 }
 
 // NewClient creates a new ConfigHub client
@@ -94,6 +293,9 @@ func NewClient(opts *ClientOptions) (*Client, error) {
 	if opts.WatchTimeout <= 0 {
 		opts.WatchTimeout = 30
 	}
+	if opts.ClientID == "" {
+		opts.ClientID = defaultClientID()
+	}
 
 	httpClient := opts.HTTPClient
 	if httpClient == nil {
@@ -101,12 +303,21 @@ func NewClient(opts *ClientOptions) (*Client, error) {
 			Timeout: time.Duration(opts.WatchTimeout+10) * time.Second,
 		}
 	}
+	if !opts.DisableCompression {
+		httpClient = withCompression(httpClient)
+	}
+
+	if opts.OverridesPath != "" {
+		log.Printf("[confighub] WARNING: DEV MODE — local overrides from %s take precedence over server configs. Do not use in production!", opts.OverridesPath)
+	}
 
 	return &Client{
 		opts:       opts,
 		httpClient: httpClient,
 		cache:      make(map[string]*Config),
 		stopCh:     make(chan struct{}),
+		required:   make(map[string]bool),
+		labels:     encodeLabels(opts.InstanceLabels),
 	}, nil
 }
 
@@ -121,24 +332,39 @@ func (c *Client) GetWithOptions(ctx context.Context, name, namespace, env string
 	// Check cache first
 	cacheKey := c.cacheKey(name, namespace, env)
 	c.cacheMu.RLock()
-	if cached, ok := c.cache[cacheKey]; ok {
-		c.cacheMu.RUnlock()
+	cached, ok := c.cache[cacheKey]
+	c.cacheMu.RUnlock()
+	if ok && !cached.offlineExpired() {
 		return cached, nil
 	}
-	c.cacheMu.RUnlock()
+	if ok {
+		c.evictExpired(cacheKey, cached)
+	}
 
-	// Fetch from server
-	config, err := c.fetchConfig(ctx, name, namespace, env, 0)
-	if err != nil {
-		return nil, err
+	if c.opts.LoadBundle && namespace == c.opts.Namespace && env == c.opts.Environment {
+		c.loadBundleOnce(ctx)
+		c.cacheMu.RLock()
+		cached, ok := c.cache[cacheKey]
+		c.cacheMu.RUnlock()
+		if ok {
+			return cached, nil
+		}
 	}
 
-	// Update cache
-	c.cacheMu.Lock()
-	c.cache[cacheKey] = config
-	c.cacheMu.Unlock()
+	// Fetch from server, coalescing concurrent requests for the same config
+	return c.flight.do(ctx, cacheKey, func() (*Config, error) {
+		config, err := c.fetchOrLocal(context.WithoutCancel(ctx), name, namespace, env)
+		if err != nil {
+			return nil, err
+		}
 
-	return config, nil
+		// Update cache
+		c.cacheMu.Lock()
+		c.cache[cacheKey] = config
+		c.cacheMu.Unlock()
+
+		return config, nil
+	})
 }
 
 // GetString returns the configuration content as string
@@ -163,7 +389,7 @@ func (c *Client) GetJSON(ctx context.Context, name string, v interface{}) error
 func (c *Client) Refresh(ctx context.Context, name string) (*Config, error) {
 	cacheKey := c.cacheKey(name, c.opts.Namespace, c.opts.Environment)
 	
-	config, err := c.fetchConfig(ctx, name, c.opts.Namespace, c.opts.Environment, 0)
+	config, err := c.fetchOrLocal(ctx, name, c.opts.Namespace, c.opts.Environment)
 	if err != nil {
 		return nil, err
 	}
@@ -175,58 +401,54 @@ func (c *Client) Refresh(ctx context.Context, name string) (*Config, error) {
 	return config, nil
 }
 
-// fetchConfig fetches configuration from the server
-func (c *Client) fetchConfig(ctx context.Context, name, namespace, env string, currentVersion int) (*Config, error) {
-	u, err := url.Parse(c.opts.ServerURL)
-	if err != nil {
-		return nil, err
-	}
-	u.Path = "/api/v1/config"
-
-	q := u.Query()
-	q.Set("name", name)
-	if namespace != "" {
-		q.Set("namespace", namespace)
-	}
-	if env != "" {
-		q.Set("env", env)
-	}
-	u.RawQuery = q.Encode()
-
-	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
-	if err != nil {
-		return nil, err
+// fetchWithFallback fetches configuration from the requested environment, then
+// from each fallback environment while the config is not found. The returned
+// Config's Environment is the environment it was actually loaded from.
+func (c *Client) fetchWithFallback(ctx context.Context, name, namespace, env string) (*Config, error) {
+	config, err := c.fetchConfig(ctx, name, namespace, env, 0)
+	for _, fallback := range c.opts.FallbackEnvironments {
+		if !errors.Is(err, ErrNotFound) {
+			break
+		}
+		if fallback == env {
+			continue
+		}
+		config, err = c.fetchConfig(ctx, name, namespace, fallback, 0)
 	}
+	return config, err
+}
 
-	c.signRequest(req)
-
-	resp, err := c.httpClient.Do(req)
+// fetchConfig fetches configuration from the server via the negotiated
+// transport, retrying transient failures according to ClientOptions.Retry
+func (c *Client) fetchConfig(ctx context.Context, name, namespace, env string, currentVersion int) (*Config, error) {
+	config, err := c.withRetry(ctx, name, namespace, env, func() (*Config, error) {
+		return c.currentTransport(ctx).Fetch(ctx, name, namespace, env)
+	})
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, ErrNotFound
-	}
-	if resp.StatusCode == http.StatusUnauthorized {
-		return nil, ErrUnauthorized
-	}
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("server error: %s", string(body))
-	}
-
-	var config Config
-	if err := json.NewDecoder(resp.Body).Decode(&config); err != nil {
+		// Dev overrides may define configs that don't exist on the server yet,
+		// and keep working while the server is unreachable
+		if _, _, ok := c.loadOverride(name); ok {
+			return c.applyOverride(&Config{Name: name, Namespace: namespace, Environment: env}), nil
+		}
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrNotFound
+		}
 		return nil, err
 	}
 
-	return &config, nil
+	return c.applyOverride(config), nil
 }
 
-// signRequest adds authentication headers to the request
+// signRequest adds trace context, identity and authentication headers to the
+// request
 func (c *Client) signRequest(req *http.Request) {
+	c.injectTraceContext(req)
+	c.setIdentity(req)
+	if c.opts.SignatureVersion == 2 {
+		c.signRequestV2(req)
+		return
+	}
+
 	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
 	
 	// Create signature: HMAC-SHA256(timestamp + method + path, secretKey)
@@ -244,25 +466,61 @@ func (c *Client) signRequest(req *http.Request) {
 	req.Header.Set("X-Signature", signature)
 }
 
+// injectTraceContext adds trace context headers if InjectTraceContext is set.
+// Trace headers are not covered by the signature.
+func (c *Client) injectTraceContext(req *http.Request) {
+	if c.opts.InjectTraceContext != nil {
+		c.opts.InjectTraceContext(req.Context(), req.Header)
+	}
+}
+
 // cacheKey generates a cache key for the configuration
 func (c *Client) cacheKey(name, namespace, env string) string {
 	return fmt.Sprintf("%s:%s:%s", namespace, env, name)
 }
 
+// cachedContentHash returns the content hash of the cached config, or "" when
+// it is not cached. Configs loaded via environment fallback are cached under
+// the configured environment.
+func (c *Client) cachedContentHash(name, namespace, env string) string {
+	c.cacheMu.RLock()
+	defer c.cacheMu.RUnlock()
+	if cached, ok := c.cache[c.cacheKey(name, namespace, env)]; ok {
+		return cached.ContentHash
+	}
+	if cached, ok := c.cache[c.cacheKey(name, namespace, c.opts.Environment)]; ok {
+		return cached.ContentHash
+	}
+	return ""
+}
+
 
-// Watch starts watching for configuration changes
+// Watch fetches the named configs and starts watching them for changes.
+//
+// The watch runs until ctx is done or StopWatch is called: every long-poll
+// request, content fetch and retry backoff uses ctx, so canceling the
+// application context stops network activity promptly. After ctx is done,
+// Watch may be called again. To keep watching beyond a short-lived ctx
+// (e.g. one with a startup timeout), pass context.WithoutCancel(ctx).
 func (c *Client) Watch(ctx context.Context, names ...string) error {
 	c.watchMu.Lock()
-	if c.watching {
+	if c.watching && c.watchCtx.Err() == nil {
 		c.watchMu.Unlock()
 		return errors.New("already watching")
 	}
+	watchCtx, cancel := context.WithCancel(ctx)
 	c.watching = true
+	c.watched = append([]string(nil), names...)
+	c.watchCtx = watchCtx
+	c.watchCancel = cancel
 	c.watchMu.Unlock()
 
+	c.markRequired(names)
+
 	// Initial fetch for all configs
 	for _, name := range names {
-		if _, err := c.Get(ctx, name); err != nil {
+		if _, err := c.Get(watchCtx, name); err != nil {
+			cancel()
 			return fmt.Errorf("failed to fetch initial config %s: %w", name, err)
 		}
 	}
@@ -270,142 +528,225 @@ func (c *Client) Watch(ctx context.Context, names ...string) error {
 	// Start watch goroutines
 	for _, name := range names {
 		c.wg.Add(1)
-		go c.watchConfig(name)
+		go c.watchConfig(watchCtx, name)
 	}
 
 	return nil
 }
 
-// watchConfig watches a single configuration for changes
-func (c *Client) watchConfig(name string) {
+// watchConfig watches a single configuration for changes until ctx is done
+func (c *Client) watchConfig(ctx context.Context, name string) {
 	defer c.wg.Done()
 
 	namespace := c.opts.Namespace
 	env := c.opts.Environment
 	cacheKey := c.cacheKey(name, namespace, env)
 
-	for {
-		select {
-		case <-c.stopCh:
-			return
-		default:
-		}
-
-		// Get current version from cache
+	for ctx.Err() == nil {
+		// Get current version from cache; configs loaded via fallback are
+		// watched in the environment they were loaded from
 		c.cacheMu.RLock()
 		currentVersion := 0
-		if cached, ok := c.cache[cacheKey]; ok {
+		currentContent := ""
+		watchEnv := env
+		cached, ok := c.cache[cacheKey]
+		if ok {
 			currentVersion = cached.Version
+			currentContent = cached.Content
+			if cached.Environment != "" {
+				watchEnv = cached.Environment
+			}
 		}
 		c.cacheMu.RUnlock()
 
 		// Long-poll for changes
-		config, err := c.watchOnce(name, namespace, env, currentVersion)
+		config, err := c.watchOnce(ctx, name, namespace, watchEnv, currentVersion)
+		if ctx.Err() != nil {
+			return
+		}
 		if err != nil {
 			if err == ErrWatchTimeout {
 				continue // Normal timeout, retry
 			}
+			if errors.Is(err, ErrConfigDeleted) || errors.Is(err, ErrNotFound) {
+				if ok {
+					c.removeDeleted(name, namespace, env, cached)
+				}
+				// Wait for the config to be created again
+				sleepContext(ctx, deletedPollInterval)
+				continue
+			}
 			if c.opts.OnError != nil {
 				c.opts.OnError(err)
 			}
-			time.Sleep(5 * time.Second) // Backoff on error
+			sleepContext(ctx, watchErrorBackoff)
 			continue
 		}
 
-		if config != nil && config.Version > currentVersion {
+		if config != nil && config.contentOmitted {
+			if config, err = c.completeNotification(ctx, name, namespace, watchEnv, cached, config); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				if c.opts.OnError != nil {
+					c.opts.OnError(err)
+				}
+				sleepContext(ctx, watchErrorBackoff)
+				continue
+			}
+		}
+
+		// Content may change without a new version, e.g. when environment
+		// variables referenced via ${env:VAR} are updated
+		if config != nil && (config.Version > currentVersion || config.Content != currentContent) {
 			// Update cache
 			c.cacheMu.Lock()
 			c.cache[cacheKey] = config
 			c.cacheMu.Unlock()
+			c.saveLocal(name, namespace, env, config)
 
-			// Notify callback
-			if c.opts.OnChange != nil {
-				c.opts.OnChange(config)
-			}
+			// Notify callbacks
+			c.notifyChange(name, cached, config)
 		}
 	}
 }
 
-// watchOnce performs a single long-poll request
-func (c *Client) watchOnce(name, namespace, env string, currentVersion int) (*Config, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.opts.WatchTimeout+5)*time.Second)
-	defer cancel()
+// removeDeleted drops a deleted config from the caches and reports it to OnDelete
+func (c *Client) removeDeleted(name, namespace, env string, config *Config) {
+	c.cacheMu.Lock()
+	delete(c.cache, c.cacheKey(name, namespace, env))
+	c.cacheMu.Unlock()
+	c.removeLocal(name, namespace, env)
 
-	u, err := url.Parse(c.opts.ServerURL)
-	if err != nil {
-		return nil, err
+	if c.opts.OnDelete != nil {
+		c.opts.OnDelete(config)
 	}
-	u.Path = "/api/v1/config/watch"
+}
 
-	q := u.Query()
-	q.Set("name", name)
-	if namespace != "" {
-		q.Set("namespace", namespace)
-	}
-	if env != "" {
-		q.Set("env", env)
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
 	}
-	q.Set("version", strconv.Itoa(currentVersion))
-	q.Set("timeout", strconv.Itoa(c.opts.WatchTimeout))
-	u.RawQuery = q.Encode()
+}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+// watchOnce waits for a single change via the negotiated transport. A failing
+// push transport is dropped in favour of long-polling; canceling ctx does not
+// count as a failure.
+func (c *Client) watchOnce(ctx context.Context, name, namespace, env string, currentVersion int) (*Config, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(c.opts.WatchTimeout+5)*time.Second)
+	defer cancel()
+
+	transport := c.currentTransport(ctx)
+	config, err := transport.Watch(ctx, name, namespace, env, currentVersion)
 	if err != nil {
+		// The agent transport falls back to the server by itself while the
+		// agent is unreachable, and is tried again afterwards
+		if err != ErrWatchTimeout && err != ErrConfigDeleted && err != ErrNotFound && transport.Name() != TransportLongPolling && transport.Name() != TransportAgent && !errors.Is(ctx.Err(), context.Canceled) {
+			c.fallbackTransport(transport, err)
+		}
 		return nil, err
 	}
 
-	c.signRequest(req)
+	return c.applyOverride(config), nil
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
+// completeNotification fills in the content of a notify-only watch response.
+// When the content hash matches the cached config (e.g. a no-op save that
+// only bumped the version) the cached content is reused; otherwise the config
+// is fetched from the server.
+func (c *Client) completeNotification(ctx context.Context, name, namespace, env string, cached, notified *Config) (*Config, error) {
+	if cached != nil && notified.ContentHash != "" && notified.ContentHash == cached.ContentHash && notified.Version != cached.Version {
+		config := *notified
+		config.Content = cached.Content
+		config.contentOmitted = false
+		return &config, nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusNotModified {
-		return nil, ErrWatchTimeout
-	}
-	if resp.StatusCode == http.StatusUnauthorized {
-		return nil, ErrUnauthorized
-	}
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("watch error: %s", string(body))
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(c.opts.WatchTimeout+5)*time.Second)
+	defer cancel()
+	return c.fetchConfig(ctx, name, namespace, env, 0)
+}
+
+// WaitForConfigs blocks until all named configs are loaded into the cache.
+// Transient errors are retried until ctx is done; ErrNotFound and ErrUnauthorized
+// fail fast. The returned error names every config that could not be loaded.
+// The names are also registered as required for Healthy.
+func (c *Client) WaitForConfigs(ctx context.Context, names ...string) error {
+	c.markRequired(names)
+
+	pending := names
+	for {
+		var failed []string
+		var errs []error
+		for _, name := range pending {
+			if _, err := c.Get(ctx, name); err != nil {
+				if errors.Is(err, ErrNotFound) || errors.Is(err, ErrUnauthorized) {
+					return fmt.Errorf("%w: config %s: %w", ErrNotReady, name, err)
+				}
+				failed = append(failed, name)
+				errs = append(errs, fmt.Errorf("config %s: %w", name, err))
+			}
+		}
+		if len(failed) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrNotReady, errors.Join(append(errs, ctx.Err())...))
+		case <-time.After(waitRetryInterval):
+		}
+		pending = failed
 	}
+}
 
-	var result struct {
-		Changed     bool   `json:"changed"`
-		Name        string `json:"name"`
-		Namespace   string `json:"namespace"`
-		Environment string `json:"environment"`
-		Version     int    `json:"version"`
-		Content     string `json:"content"`
+// Healthy reports whether every required config (registered via WaitForConfigs
+// or Watch) is loaded. It returns nil when ready, suitable for readiness probes.
+func (c *Client) Healthy() error {
+	c.requiredMu.RLock()
+	defer c.requiredMu.RUnlock()
+	c.cacheMu.RLock()
+	defer c.cacheMu.RUnlock()
+
+	var missing []string
+	for name := range c.required {
+		if _, ok := c.cache[c.cacheKey(name, c.opts.Namespace, c.opts.Environment)]; !ok {
+			missing = append(missing, name)
+		}
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("%w: %s", ErrNotReady, strings.Join(missing, ", "))
 	}
+	return nil
+}
 
-	if !result.Changed {
-		return nil, ErrWatchTimeout
+// markRequired registers config names that must be loaded for Healthy
+func (c *Client) markRequired(names []string) {
+	c.requiredMu.Lock()
+	defer c.requiredMu.Unlock()
+	for _, name := range names {
+		c.required[name] = true
 	}
-
-	return &Config{
-		Name:        result.Name,
-		Namespace:   result.Namespace,
-		Environment: result.Environment,
-		Version:     result.Version,
-		Content:     result.Content,
-	}, nil
 }
 
-// StopWatch stops watching for configuration changes
+// StopWatch stops watching for configuration changes, including namespace
+// watches started with WatchNamespace
 func (c *Client) StopWatch() {
+	c.stopNamespaceWatches()
+
 	c.watchMu.Lock()
 	if !c.watching {
 		c.watchMu.Unlock()
 		return
 	}
 	c.watching = false
+	c.watched = nil
+	c.watchCancel()
 	c.watchMu.Unlock()
 
 	close(c.stopCh)
@@ -0,0 +1,91 @@
+// Command confighub-agent shares one ConfigHub connection among the processes
+// on a host. Processes set ClientOptions.AgentSocket to the agent's socket;
+// the agent keeps a single upstream watch per config for all of them.
+//
+// The access and secret keys are read from CONFIGHUB_ACCESS_KEY and
+// CONFIGHUB_SECRET_KEY, so they do not show up in the process list.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/confighub/sdk-go/confighub"
+)
+
+func main() {
+	server := flag.String("server", os.Getenv("CONFIGHUB_SERVER_URL"), "ConfigHub server URL")
+	socket := flag.String("socket", "/run/confighub/agent.sock", "Unix socket to serve processes on")
+	socketMode := flag.String("socket-mode", "0660", "permission of the socket file (octal)")
+	namespace := flag.String("namespace", "", "default namespace")
+	env := flag.String("env", "", "default environment")
+	locale := flag.String("locale", "", "locale localized values are resolved to")
+	watchTimeout := flag.Int("watch-timeout", 30, "upstream long-polling timeout in seconds")
+	notifyOnly := flag.Bool("notify-only", false, "fetch content only when the content hash changes")
+	signatureVersion := flag.Int("signature-version", 2, "request signing scheme (1 or 2)")
+	cacheDir := flag.String("cache-dir", "", "local fallback cache directory (optional)")
+	labels := flag.String("labels", "", "instance labels, e.g. region=eu-west,zone=a (optional)")
+	flag.Parse()
+
+	mode, err := strconv.ParseUint(*socketMode, 8, 32)
+	if err != nil {
+		log.Fatalf("Invalid socket mode %q: %v", *socketMode, err)
+	}
+
+	agent, err := confighub.NewAgent(&confighub.AgentOptions{
+		Client: &confighub.ClientOptions{
+			ServerURL:        *server,
+			AccessKey:        os.Getenv("CONFIGHUB_ACCESS_KEY"),
+			SecretKey:        os.Getenv("CONFIGHUB_SECRET_KEY"),
+			Namespace:        *namespace,
+			Environment:      *env,
+			Locale:           *locale,
+			WatchTimeout:     *watchTimeout,
+			NotifyOnly:       *notifyOnly,
+			SignatureVersion: *signatureVersion,
+			CacheDir:         *cacheDir,
+			InstanceLabels:   parseLabels(*labels),
+			OnError: func(err error) {
+				log.Printf("Upstream error: %v", err)
+			},
+		},
+		SocketMode: os.FileMode(mode),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create agent: %v", err)
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-quit
+		log.Println("Shutting down agent...")
+		agent.Close()
+	}()
+
+	log.Printf("Serving on %s, upstream %s", *socket, *server)
+	if err := agent.ListenAndServe(*socket); err != nil {
+		log.Fatalf("Failed to serve: %v", err)
+	}
+	os.Remove(*socket)
+}
+
+// parseLabels parses comma-separated key=value pairs
+func parseLabels(s string) map[string]string {
+	if s == "" {
+		return nil
+	}
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, _ := strings.Cut(pair, "=")
+		if key = strings.TrimSpace(key); key != "" {
+			labels[key] = strings.TrimSpace(value)
+		}
+	}
+	return labels
+}
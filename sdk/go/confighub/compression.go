@@ -0,0 +1,73 @@
+package confighub
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+)
+
+// gzipTransport asks the server for gzip-compressed responses and
+// decompresses them. net/http only does this transparently for its own
+// Transport; wrapping the round tripper also covers custom transports
+// (HTTP/2, proxies, instrumentation) set via ClientOptions.HTTPClient.
+type gzipTransport struct {
+	base http.RoundTripper
+}
+
+// withCompression returns a copy of client whose transport requests and
+// decodes gzip responses
+func withCompression(client *http.Client) *http.Client {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped := *client
+	wrapped.Transport = &gzipTransport{base: base}
+	return &wrapped
+}
+
+func (t *gzipTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Requests that set their own Accept-Encoding handle the body themselves
+	if req.Header.Get("Accept-Encoding") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.Header.Get("Content-Encoding") != "gzip" {
+		return resp, err
+	}
+
+	resp.Body = &gzipBody{body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// gzipBody decompresses a response body lazily, so reading the header errors
+// surface from Read rather than from RoundTrip
+type gzipBody struct {
+	body io.ReadCloser
+	zr   *gzip.Reader
+	err  error
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.zr == nil {
+		b.zr, b.err = gzip.NewReader(b.body)
+		if b.err != nil {
+			return 0, b.err
+		}
+	}
+	return b.zr.Read(p)
+}
+
+func (b *gzipBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,49 @@
+package confighub
+
+import (
+	"context"
+	"sync"
+)
+
+// flightCall is an in-flight or completed fetch shared by concurrent callers
+type flightCall struct {
+	done   chan struct{}
+	config *Config
+	err    error
+}
+
+// flightGroup coalesces concurrent fetches for the same key into a single call
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// do runs fn once per key among concurrent callers. fn runs detached from any
+// single caller, so one caller cancelling does not fail the others; each caller
+// stops waiting when its own ctx is done.
+func (g *flightGroup) do(ctx context.Context, key string, fn func() (*Config, error)) (*Config, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	call, ok := g.calls[key]
+	if !ok {
+		call = &flightCall{done: make(chan struct{})}
+		g.calls[key] = call
+		go func() {
+			call.config, call.err = fn()
+			g.mu.Lock()
+			delete(g.calls, key)
+			g.mu.Unlock()
+			close(call.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.config, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
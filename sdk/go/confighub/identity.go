@@ -0,0 +1,52 @@
+package confighub
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Headers identifying the client instance to the server
+const (
+	ClientIDHeader     = "X-Client-ID"
+	ClientLabelsHeader = "X-Client-Labels"
+)
+
+// defaultClientID returns the host name, so gray release percentages stay
+// stable for an instance across restarts
+func defaultClientID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return hostname
+}
+
+// encodeLabels formats labels as sorted, URL-escaped key=value pairs joined
+// by commas, e.g. "region=eu-west,zone=a"
+func encodeLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, url.QueryEscape(key)+"="+url.QueryEscape(labels[key]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// setIdentity adds the client ID and instance labels to the request. Like
+// trace headers they are not covered by the signature.
+func (c *Client) setIdentity(req *http.Request) {
+	if c.opts.ClientID != "" {
+		req.Header.Set(ClientIDHeader, c.opts.ClientID)
+	}
+	if c.labels != "" {
+		req.Header.Set(ClientLabelsHeader, c.labels)
+	}
+}
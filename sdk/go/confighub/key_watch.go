@@ -0,0 +1,117 @@
+package confighub
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// keyWatcher is a callback subscribed to a single path of a config
+type keyWatcher struct {
+	id   int
+	path []string
+	fn   func(old, new interface{})
+}
+
+// WatchKey subscribes fn to changes of a single key of a JSON config. path is
+// dot separated, with array elements addressed by index, e.g. "db.hosts.0".
+//
+// When Watch picks up a new version of the config, old and new contents are
+// diffed client-side and fn is only invoked if the value at path changed.
+// Values are decoded as by encoding/json; a missing key is reported as nil.
+// Callbacks run on the watch goroutine of the config, after OnChange and OnKeyChange.
+//
+// WatchKey only registers the subscription; the config must also be watched
+// via Watch. The returned function removes the subscription.
+func (c *Client) WatchKey(name, path string, fn func(old, new interface{})) func() {
+	c.keyWatchMu.Lock()
+	defer c.keyWatchMu.Unlock()
+
+	if c.keyWatchers == nil {
+		c.keyWatchers = make(map[string][]*keyWatcher)
+	}
+	c.nextKeyWatcherID++
+	w := &keyWatcher{
+		id:   c.nextKeyWatcherID,
+		path: splitKeyPath(path),
+		fn:   fn,
+	}
+	c.keyWatchers[name] = append(c.keyWatchers[name], w)
+
+	return func() {
+		c.keyWatchMu.Lock()
+		defer c.keyWatchMu.Unlock()
+		watchers := c.keyWatchers[name]
+		for i, existing := range watchers {
+			if existing.id == w.id {
+				c.keyWatchers[name] = append(watchers[:i:i], watchers[i+1:]...)
+				break
+			}
+		}
+		if len(c.keyWatchers[name]) == 0 {
+			delete(c.keyWatchers, name)
+		}
+	}
+}
+
+// notifyKeyWatchers invokes the key callbacks of name whose value differs
+// between oldContent and newContent. Content that is not valid JSON is
+// treated as empty.
+func (c *Client) notifyKeyWatchers(name, oldContent, newContent string) {
+	c.keyWatchMu.Lock()
+	watchers := append([]*keyWatcher(nil), c.keyWatchers[name]...)
+	c.keyWatchMu.Unlock()
+	if len(watchers) == 0 {
+		return
+	}
+
+	oldDoc := decodeContent(oldContent)
+	newDoc := decodeContent(newContent)
+	for _, w := range watchers {
+		oldValue := lookupKey(oldDoc, w.path)
+		newValue := lookupKey(newDoc, w.path)
+		if !reflect.DeepEqual(oldValue, newValue) {
+			w.fn(oldValue, newValue)
+		}
+	}
+}
+
+// splitKeyPath splits a dot separated path; an empty path addresses the
+// whole document
+func splitKeyPath(path string) []string {
+	path = strings.Trim(path, ".")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+// decodeContent parses JSON content, returning nil if it is empty or invalid
+func decodeContent(content string) interface{} {
+	var doc interface{}
+	if content == "" || json.Unmarshal([]byte(content), &doc) != nil {
+		return nil
+	}
+	return doc
+}
+
+// lookupKey returns the value at path, or nil if it does not exist
+func lookupKey(doc interface{}, path []string) interface{} {
+	current := doc
+	for _, segment := range path {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			current = v[segment]
+		case []interface{}:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			current = v[i]
+		default:
+			return nil
+		}
+	}
+	return current
+}
@@ -0,0 +1,184 @@
+package confighub
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// localCacheMagic prefixes encrypted cache files and identifies the format
+const localCacheMagic = "CHC1"
+
+// machineIDFiles are read in order to derive the default cache key
+var machineIDFiles = []string{"/etc/machine-id", "/var/lib/dbus/machine-id"}
+
+// fetchOrLocal fetches a config from the server and, when CacheDir is set,
+// persists it to the local fallback cache. If the server cannot be reached
+// the last cached copy is returned instead; ErrNotFound and ErrUnauthorized
+// are never masked by the cache.
+func (c *Client) fetchOrLocal(ctx context.Context, name, namespace, env string) (*Config, error) {
+	config, err := c.fetchWithFallback(ctx, name, namespace, env)
+	if c.opts.CacheDir == "" {
+		return config, err
+	}
+	if err == nil {
+		c.saveLocal(name, namespace, env, config)
+		return config, nil
+	}
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrUnauthorized) {
+		return nil, err
+	}
+
+	cached, loadErr := c.loadLocal(name, namespace, env)
+	if loadErr != nil {
+		return nil, err
+	}
+	if c.opts.OnError != nil {
+		c.opts.OnError(fmt.Errorf("using local cache for config %s: %w", name, err))
+	}
+	return cached, nil
+}
+
+// saveLocal encrypts and writes a config to the local fallback cache.
+// Failures are reported via OnError; the in-memory cache is unaffected.
+func (c *Client) saveLocal(name, namespace, env string, config *Config) {
+	if c.opts.CacheDir == "" {
+		return
+	}
+	if err := c.writeLocal(c.cacheKey(name, namespace, env), config); err != nil && c.opts.OnError != nil {
+		c.opts.OnError(fmt.Errorf("write local cache for config %s: %w", name, err))
+	}
+}
+
+// removeLocal deletes a config from the local fallback cache
+func (c *Client) removeLocal(name, namespace, env string) {
+	if c.opts.CacheDir == "" {
+		return
+	}
+	if err := os.Remove(c.localPath(c.cacheKey(name, namespace, env))); err != nil && !os.IsNotExist(err) && c.opts.OnError != nil {
+		c.opts.OnError(fmt.Errorf("remove local cache for config %s: %w", name, err))
+	}
+}
+
+// writeLocal writes the encrypted file atomically with owner-only permissions
+func (c *Client) writeLocal(cacheKey string, config *Config) error {
+	plaintext, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	aead, err := c.localCipher()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	// The cache key is bound as additional data, so files can't be swapped
+	data := append([]byte(localCacheMagic), nonce...)
+	data = aead.Seal(data, nonce, plaintext, []byte(cacheKey))
+
+	if err := os.MkdirAll(c.opts.CacheDir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.opts.CacheDir, ".confighub-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.localPath(cacheKey))
+}
+
+// loadLocal reads and decrypts a config from the local fallback cache.
+// Files written with another key (e.g. after rotating the secret key or on
+// another machine) fail to decrypt and are treated as missing.
+func (c *Client) loadLocal(name, namespace, env string) (*Config, error) {
+	cacheKey := c.cacheKey(name, namespace, env)
+	data, err := os.ReadFile(c.localPath(cacheKey))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := c.localCipher()
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(string(data), localCacheMagic) || len(data) < len(localCacheMagic)+aead.NonceSize() {
+		return nil, errors.New("invalid local cache file")
+	}
+	data = data[len(localCacheMagic):]
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(cacheKey))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt local cache: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(plaintext, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// localPath returns the cache file of a config; names are hashed so the
+// directory listing doesn't reveal which configs are cached
+func (c *Client) localPath(cacheKey string) string {
+	sum := sha256.Sum256([]byte(cacheKey))
+	return filepath.Join(c.opts.CacheDir, hex.EncodeToString(sum[:16])+".cache")
+}
+
+// localCipher returns the AES-256-GCM cipher of the local cache, keyed by
+// ClientOptions.CacheKey or, if unset, by a key derived from the machine ID
+// and the client credentials
+func (c *Client) localCipher() (cipher.AEAD, error) {
+	c.localKeyOnce.Do(func() {
+		var key [sha256.Size]byte
+		if len(c.opts.CacheKey) > 0 {
+			key = sha256.Sum256(c.opts.CacheKey)
+		} else {
+			mac := hmac.New(sha256.New, []byte(c.opts.SecretKey))
+			mac.Write([]byte("confighub-local-cache\x00" + machineID() + "\x00" + c.opts.AccessKey))
+			copy(key[:], mac.Sum(nil))
+		}
+
+		block, err := aes.NewCipher(key[:])
+		if err != nil {
+			c.localKeyErr = err
+			return
+		}
+		c.localAEAD, c.localKeyErr = cipher.NewGCM(block)
+	})
+	return c.localAEAD, c.localKeyErr
+}
+
+// machineID returns a stable identifier of the host, falling back to the
+// hostname where no machine ID is available
+func machineID() string {
+	for _, file := range machineIDFiles {
+		if data, err := os.ReadFile(file); err == nil {
+			if id := strings.TrimSpace(string(data)); id != "" {
+				return id
+			}
+		}
+	}
+	hostname, _ := os.Hostname()
+	return hostname
+}
@@ -0,0 +1,310 @@
+package confighub
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// namespaceRetryDelay is the delay before reconnecting a failed namespace
+	// event stream
+	namespaceRetryDelay = 5 * time.Second
+
+	// namespaceStreamIdle drops an event stream that sent nothing, not even a
+	// heartbeat (every 15s), for this long
+	namespaceStreamIdle = 45 * time.Second
+)
+
+// namespaceEvent is a change event of the server's event stream
+type namespaceEvent struct {
+	ConfigName string `json:"config_name"`
+	Namespace  string `json:"namespace"`
+	Version    int    `json:"version"`
+	ChangeType string `json:"change_type"`
+}
+
+// namespaceWatcher tracks the configs of a watched namespace
+type namespaceWatcher struct {
+	c         *Client
+	namespace string
+	handler   func(old, new *Config)
+	configs   map[string]*Config
+	lastEvent int64
+}
+
+// WatchNamespace subscribes handler to every config of namespace in the
+// default environment, including configs created after the watch started, so
+// applications don't need to know config names ahead of time. An empty
+// namespace selects the default namespace.
+//
+// handler is first called with old == nil for each config that exists when
+// the watch starts, then for every change; new is nil when a config was
+// deleted. Calls run one at a time on the watch goroutine of the namespace.
+// Changes are received over the server's event stream, which is resumed
+// after reconnects; if the server no longer has the missed events, the
+// namespace is reloaded and the differences are reported.
+//
+// Configs are cached like those fetched with Get, but handler is the only
+// callback notified: OnChange, OnKeyChange and WatchKey apply to Watch.
+// The watch stops when ctx is done, the subscription is cancelled or on
+// StopWatch.
+func (c *Client) WatchNamespace(ctx context.Context, namespace string, handler func(old, new *Config)) (*Subscription, error) {
+	if namespace == "" {
+		namespace = c.opts.Namespace
+	}
+	w := &namespaceWatcher{
+		c:         c,
+		namespace: namespace,
+		handler:   handler,
+		configs:   make(map[string]*Config),
+	}
+	if err := w.reload(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load namespace %s: %w", namespace, err)
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	c.nsWatchMu.Lock()
+	if c.nsWatches == nil {
+		c.nsWatches = make(map[int]context.CancelFunc)
+	}
+	c.nextNSWatchID++
+	id := c.nextNSWatchID
+	c.nsWatches[id] = cancel
+	c.nsWatchWG.Add(1)
+	c.nsWatchMu.Unlock()
+
+	go func() {
+		defer c.nsWatchWG.Done()
+		w.run(watchCtx)
+	}()
+
+	return &Subscription{cancel: func() {
+		c.nsWatchMu.Lock()
+		delete(c.nsWatches, id)
+		c.nsWatchMu.Unlock()
+		cancel()
+	}}, nil
+}
+
+// stopNamespaceWatches cancels every namespace watch and waits for them to
+// return
+func (c *Client) stopNamespaceWatches() {
+	c.nsWatchMu.Lock()
+	for id, cancel := range c.nsWatches {
+		cancel()
+		delete(c.nsWatches, id)
+	}
+	c.nsWatchMu.Unlock()
+	c.nsWatchWG.Wait()
+}
+
+// run consumes the event stream until ctx is done, reconnecting on errors
+func (w *namespaceWatcher) run(ctx context.Context) {
+	for {
+		err := w.stream(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil && w.c.opts.OnError != nil {
+			w.c.opts.OnError(fmt.Errorf("watch namespace %s: %w", w.namespace, err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(namespaceRetryDelay):
+		}
+	}
+}
+
+// stream opens the event stream and applies its events until it ends
+func (w *namespaceWatcher) stream(ctx context.Context) error {
+	c := w.c
+	u, err := url.Parse(c.opts.ServerURL)
+	if err != nil {
+		return err
+	}
+	u.Path = "/api/v1/config/events"
+	q := url.Values{}
+	q.Set("namespace", w.namespace)
+	q.Set("env", c.opts.Environment)
+	u.RawQuery = q.Encode()
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(streamCtx, "GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if w.lastEvent > 0 {
+		req.Header.Set("Last-Event-ID", strconv.FormatInt(w.lastEvent, 10))
+	}
+	if err := c.authorizeWatch(ctx, req); err != nil {
+		return err
+	}
+
+	// The stream outlives the client's request timeout; a silent connection
+	// is detected by the missing heartbeats instead
+	streamClient := *c.httpClient
+	streamClient.Timeout = 0
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	c.observeInstance(resp.Header.Get(InstanceHeader))
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		c.resetWatchToken()
+		return ErrUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	// Changes between the initial load (or a reconnect without a resume
+	// position) and the subscription are not replayed
+	if w.lastEvent == 0 {
+		if err := w.reload(ctx); err != nil {
+			return err
+		}
+	}
+
+	idle := time.AfterFunc(namespaceStreamIdle, cancel)
+	defer idle.Stop()
+
+	scanner := bufio.NewScanner(resp.Body)
+	var id int64
+	var event, data string
+	for scanner.Scan() {
+		idle.Reset(namespaceStreamIdle)
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if err := w.dispatch(ctx, event, data); err != nil {
+				return err
+			}
+			if id > 0 {
+				w.lastEvent = id
+			}
+			id, event, data = 0, "", ""
+		case strings.HasPrefix(line, "id:"):
+			id, _ = strconv.ParseInt(strings.TrimSpace(line[3:]), 10, 64)
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(line[6:])
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimSpace(line[5:])
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// dispatch applies a single stream event
+func (w *namespaceWatcher) dispatch(ctx context.Context, event, data string) error {
+	switch event {
+	case "change":
+		var change namespaceEvent
+		if err := json.Unmarshal([]byte(data), &change); err != nil {
+			return err
+		}
+		if change.Namespace != w.namespace || change.ConfigName == "" {
+			return nil
+		}
+		if change.ChangeType == "delete" {
+			w.remove(change.ConfigName)
+			return nil
+		}
+		return w.refresh(ctx, change.ConfigName)
+	case "reset":
+		// The server no longer has the missed events
+		return w.reload(ctx)
+	case "revoked":
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// refresh fetches a changed config and reports it if it differs from the
+// known one
+func (w *namespaceWatcher) refresh(ctx context.Context, name string) error {
+	fetchCtx, cancel := context.WithTimeout(ctx, time.Duration(w.c.opts.WatchTimeout+5)*time.Second)
+	defer cancel()
+
+	config, err := w.c.fetchConfig(fetchCtx, name, w.namespace, w.c.opts.Environment, 0)
+	if errors.Is(err, ErrNotFound) {
+		w.remove(name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	w.update(config)
+	return nil
+}
+
+// reload fetches every config of the namespace and reports the differences
+// to the known configs, including deleted ones
+func (w *namespaceWatcher) reload(ctx context.Context) error {
+	configs, _, err := w.c.fetchConfigs(ctx, "/api/v1/configs", w.namespace, url.Values{})
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		seen[config.Name] = true
+		w.update(w.c.applyOverride(config))
+	}
+	for name := range w.configs {
+		if !seen[name] {
+			w.remove(name)
+		}
+	}
+	return nil
+}
+
+// update caches config and calls the handler if it is new or changed
+func (w *namespaceWatcher) update(config *Config) {
+	old := w.configs[config.Name]
+	if old != nil && old.Version == config.Version && old.Content == config.Content {
+		return
+	}
+	w.configs[config.Name] = config
+
+	c := w.c
+	c.cacheMu.Lock()
+	c.cache[c.cacheKey(config.Name, w.namespace, c.opts.Environment)] = config
+	c.cacheMu.Unlock()
+	c.saveLocal(config.Name, w.namespace, c.opts.Environment, config)
+
+	w.handler(old, config)
+}
+
+// remove drops a deleted config and calls the handler with new == nil
+func (w *namespaceWatcher) remove(name string) {
+	old, ok := w.configs[name]
+	if !ok {
+		return
+	}
+	delete(w.configs, name)
+
+	c := w.c
+	c.cacheMu.Lock()
+	delete(c.cache, c.cacheKey(name, w.namespace, c.opts.Environment))
+	c.cacheMu.Unlock()
+	c.removeLocal(name, w.namespace, c.opts.Environment)
+
+	w.handler(old, nil)
+}
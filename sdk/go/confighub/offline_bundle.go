@@ -0,0 +1,200 @@
+package confighub
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// offlineBundleFormat identifies the payload format the SDK understands
+const offlineBundleFormat = "confighub-offline-bundle/v1"
+
+// offlineBundle is the signed envelope served by /api/v1/offline-bundle
+type offlineBundle struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// offlineBundlePayload is the signed content of an offline bundle
+type offlineBundlePayload struct {
+	Format      string    `json:"format"`
+	ProjectID   int64     `json:"project_id"`
+	Namespace   string    `json:"namespace"`
+	Environment string    `json:"environment"`
+	IssuedAt    time.Time `json:"issued_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Configs     []*Config `json:"configs"`
+}
+
+// ExpiresAt returns the expiry of the offline bundle the config was loaded
+// from, or the zero time for configs fetched from the server
+func (c *Config) ExpiresAt() time.Time {
+	return c.expiresAt
+}
+
+// offlineExpired reports whether the config came from an offline bundle that
+// has expired
+func (c *Config) offlineExpired() bool {
+	return !c.expiresAt.IsZero() && time.Now().After(c.expiresAt)
+}
+
+// FetchOfflineBundle downloads a signed offline bundle of the released configs
+// of the default namespace and environment that the access key can read. Store
+// the returned bytes on the device and load them with LoadOfflineBundle when
+// the server cannot be reached. ttl of 0 uses the server default.
+func (c *Client) FetchOfflineBundle(ctx context.Context, ttl time.Duration) ([]byte, error) {
+	u, err := url.Parse(c.opts.ServerURL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = "/api/v1/offline-bundle"
+
+	q := u.Query()
+	q.Set("namespace", c.opts.Namespace)
+	q.Set("env", c.opts.Environment)
+	if ttl > 0 {
+		hours := int((ttl + time.Hour - 1) / time.Hour)
+		q.Set("ttl_hours", strconv.Itoa(hours))
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.signRequest(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	c.observeInstance(resp.Header.Get(InstanceHeader))
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, ErrUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("offline bundle error: %s", string(body))
+	}
+	return body, nil
+}
+
+// LoadOfflineBundle loads an offline bundle file written from
+// FetchOfflineBundle; see LoadOfflineBundleData.
+func (c *Client) LoadOfflineBundle(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return c.LoadOfflineBundleData(data)
+}
+
+// LoadOfflineBundleData verifies an offline bundle against
+// ClientOptions.OfflineBundlePublicKey and stores its configs in the cache,
+// returning the number of configs loaded. Bundles that are tampered with, that
+// belong to another project, namespace or environment than the client's, or
+// that are past their expiry are rejected with ErrBundleInvalid or
+// ErrBundleExpired.
+//
+// Configs already fetched from the server are kept. Once the bundle expires
+// its configs are evicted and Get falls back to the server again.
+func (c *Client) LoadOfflineBundleData(data []byte) (int, error) {
+	payload, err := c.verifyOfflineBundle(data)
+	if err != nil {
+		return 0, err
+	}
+
+	loaded := 0
+	c.cacheMu.Lock()
+	for _, config := range payload.Configs {
+		key := c.cacheKey(config.Name, config.Namespace, config.Environment)
+		if existing, ok := c.cache[key]; ok && existing.expiresAt.IsZero() {
+			continue
+		}
+		config.expiresAt = payload.ExpiresAt
+		c.cache[key] = c.applyOverride(config)
+		loaded++
+	}
+	c.cacheMu.Unlock()
+
+	return loaded, nil
+}
+
+// verifyOfflineBundle checks the signature, format, scope and expiry of a
+// bundle and returns its payload
+func (c *Client) verifyOfflineBundle(data []byte) (*offlineBundlePayload, error) {
+	publicKey, err := base64.StdEncoding.DecodeString(c.opts.OfflineBundlePublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: OfflineBundlePublicKey must be a base64 Ed25519 public key", ErrBundleInvalid)
+	}
+	if c.opts.OfflineBundleProjectID <= 0 {
+		return nil, fmt.Errorf("%w: OfflineBundleProjectID is required", ErrBundleInvalid)
+	}
+
+	var bundle offlineBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBundleInvalid, err)
+	}
+	if bundle.Algorithm != "ed25519" {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrBundleInvalid, bundle.Algorithm)
+	}
+	raw, err := base64.StdEncoding.DecodeString(bundle.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBundleInvalid, err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(bundle.Signature)
+	if err != nil || !ed25519.Verify(ed25519.PublicKey(publicKey), raw, signature) {
+		return nil, fmt.Errorf("%w: signature mismatch (key %s)", ErrBundleInvalid, bundle.KeyID)
+	}
+
+	var payload offlineBundlePayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBundleInvalid, err)
+	}
+	if payload.Format != offlineBundleFormat {
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrBundleInvalid, payload.Format)
+	}
+	// A bundle signed for another tenant, namespace or environment must not be
+	// served in place of this client's configs
+	if payload.ProjectID != c.opts.OfflineBundleProjectID {
+		return nil, fmt.Errorf("%w: bundle is for project %d, want %d", ErrBundleInvalid, payload.ProjectID, c.opts.OfflineBundleProjectID)
+	}
+	if payload.Namespace != c.opts.Namespace || payload.Environment != c.opts.Environment {
+		return nil, fmt.Errorf("%w: bundle is for %s/%s, want %s/%s", ErrBundleInvalid, payload.Namespace, payload.Environment, c.opts.Namespace, c.opts.Environment)
+	}
+	for _, config := range payload.Configs {
+		if config.Namespace != payload.Namespace || config.Environment != payload.Environment {
+			return nil, fmt.Errorf("%w: config %s is outside the bundle scope", ErrBundleInvalid, config.Name)
+		}
+	}
+	if !time.Now().Before(payload.ExpiresAt) {
+		return nil, fmt.Errorf("%w: expired at %s", ErrBundleExpired, payload.ExpiresAt.Format(time.RFC3339))
+	}
+	return &payload, nil
+}
+
+// evictExpired removes a config loaded from an expired offline bundle from
+// the cache, unless it has been replaced in the meantime
+func (c *Client) evictExpired(key string, config *Config) {
+	c.cacheMu.Lock()
+	if c.cache[key] == config {
+		delete(c.cache, key)
+	}
+	c.cacheMu.Unlock()
+}
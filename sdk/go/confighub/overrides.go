@@ -0,0 +1,83 @@
+package confighub
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// loadOverride returns the local override content for a config, if any.
+//
+// OverridesPath may point to a directory, where each file named after the
+// config (optionally with an extension, e.g. "app-config.yaml") holds its
+// content, or to a JSON file mapping config names to content. In a directory,
+// names containing ".." or glob characters are never looked up, so a config
+// name cannot reach files outside the directory or match other configs.
+func (c *Client) loadOverride(name string) (string, string, bool) {
+	path := c.opts.OverridesPath
+	if path == "" {
+		return "", "", false
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", "", false
+	}
+
+	if !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", false
+		}
+		var overrides map[string]string
+		if err := json.Unmarshal(data, &overrides); err != nil {
+			c.warnOverrideOnce(path, "[confighub] WARNING: invalid overrides file %s: %v", path, err)
+			return "", "", false
+		}
+		content, ok := overrides[name]
+		return content, path, ok
+	}
+
+	if strings.Contains(name, "..") || strings.ContainsAny(name, `*?[\`) {
+		return "", "", false
+	}
+
+	candidates := []string{filepath.Join(path, name)}
+	if matches, err := filepath.Glob(filepath.Join(path, name+".*")); err == nil {
+		candidates = append(candidates, matches...)
+	}
+	for _, file := range candidates {
+		data, err := os.ReadFile(file)
+		if err == nil {
+			return string(data), file, true
+		}
+	}
+	return "", "", false
+}
+
+// applyOverride replaces the config content with its local override, if any
+func (c *Client) applyOverride(config *Config) *Config {
+	content, source, ok := c.loadOverride(config.Name)
+	if !ok {
+		return config
+	}
+
+	c.warnOverrideOnce(config.Name+"\x00"+source, "[confighub] WARNING: DEV OVERRIDE ACTIVE — config %q served from %s, server value ignored", config.Name, source)
+
+	overridden := *config
+	overridden.Content = content
+	overridden.ReleaseID = 0
+	overridden.ReleaseType = "override"
+	overridden.ContentHash = ""
+	return &overridden
+}
+
+// warnOverrideOnce logs an override warning the first time it occurs for key,
+// instead of on every fetch and watch round
+func (c *Client) warnOverrideOnce(key, format string, args ...interface{}) {
+	if _, logged := c.overrideWarned.LoadOrStore(key, true); !logged {
+		log.Printf(format, args...)
+	}
+}
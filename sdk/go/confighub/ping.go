@@ -0,0 +1,80 @@
+package confighub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// PingResult describes the identity the server resolved for the client
+type PingResult struct {
+	// Auth is how the request was authenticated: access_key, user or anonymous
+	Auth        string          `json:"auth"`
+	ProjectID   int64           `json:"project_id"`
+	AccessKeyID int64           `json:"access_key_id"`
+	Permissions map[string]bool `json:"permissions"`
+
+	// ServerTime and ClockSkew (client clock minus server clock) help
+	// diagnose expired signatures
+	ServerTime time.Time     `json:"-"`
+	ClockSkew  time.Duration `json:"-"`
+
+	// Latency is the round-trip time of the ping request
+	Latency time.Duration `json:"-"`
+}
+
+// Ping requests the built-in _ping pseudo-config through the same signing
+// and authentication path as Get, to tell network problems from credential
+// problems during onboarding. Network failures are wrapped in ErrUnreachable;
+// rejected credentials in ErrUnauthorized, with the server's reason.
+func (c *Client) Ping(ctx context.Context) (*PingResult, error) {
+	u, err := url.Parse(c.opts.ServerURL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = "/api/v1/config/_ping"
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.signRequest(req)
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnreachable, err)
+	}
+	defer resp.Body.Close()
+	latency := time.Since(start)
+	c.observeInstance(resp.Header.Get(InstanceHeader))
+
+	var body struct {
+		PingResult
+		Code        string `json:"code"`
+		Message     string `json:"message"`
+		ServerTime  int64  `json:"server_time"`
+		SkewSeconds int64  `json:"skew_seconds"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("unexpected ping response (status %d): %w", resp.StatusCode, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, fmt.Errorf("%w: %s %s", ErrUnauthorized, body.Code, body.Message)
+	default:
+		return nil, fmt.Errorf("ping failed (status %d): %s %s", resp.StatusCode, body.Code, body.Message)
+	}
+
+	result := body.PingResult
+	result.ServerTime = time.Unix(body.ServerTime, 0)
+	result.ClockSkew = time.Duration(body.SkewSeconds) * time.Second
+	result.Latency = latency
+	return &result, nil
+}
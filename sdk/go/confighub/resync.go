@@ -0,0 +1,103 @@
+package confighub
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// InstanceHeader carries the server instance ID. The server generates a new
+// ID on every start, so a change means the server restarted or the client
+// failed over to another instance.
+const InstanceHeader = "X-Instance-ID"
+
+// resyncTimeout bounds the full refresh performed after a server restart
+const resyncTimeout = 30 * time.Second
+
+// ResyncEvent describes a full refresh of the watched configs, performed
+// when the client detects that the server instance changed
+type ResyncEvent struct {
+	PreviousInstance string
+	Instance         string
+
+	// Refreshed lists the watched configs fetched again from the server
+	Refreshed []string
+
+	// Changed lists the configs whose content differed from the cache;
+	// change callbacks have already been invoked for them
+	Changed []string
+
+	// Failed lists the configs that could not be refreshed; the errors are
+	// reported via OnError and the watch loop keeps retrying them
+	Failed []string
+}
+
+// observeInstance records the instance ID of a server response and triggers
+// a resync when it differs from the previously seen one. Push transports
+// that don't go through long-polling are not observed.
+func (c *Client) observeInstance(id string) {
+	if id == "" {
+		return
+	}
+
+	c.instanceMu.Lock()
+	previous := c.instanceID
+	c.instanceID = id
+	if previous == "" || previous == id || c.resyncing {
+		c.instanceMu.Unlock()
+		return
+	}
+	c.resyncing = true
+	c.instanceMu.Unlock()
+
+	go func() {
+		defer func() {
+			c.instanceMu.Lock()
+			c.resyncing = false
+			c.instanceMu.Unlock()
+		}()
+		c.resync(previous, id)
+	}()
+}
+
+// resync re-registers with the restarted server and immediately refreshes
+// every watched config, so changes missed while disconnected are not lost
+func (c *Client) resync(previous, current string) {
+	// Watch tokens may have been revoked while the server was down
+	c.resetWatchToken()
+
+	c.watchMu.Lock()
+	names := append([]string(nil), c.watched...)
+	c.watchMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), resyncTimeout)
+	defer cancel()
+
+	event := &ResyncEvent{PreviousInstance: previous, Instance: current}
+	for _, name := range names {
+		cacheKey := c.cacheKey(name, c.opts.Namespace, c.opts.Environment)
+		c.cacheMu.RLock()
+		old, hadOld := c.cache[cacheKey]
+		c.cacheMu.RUnlock()
+
+		config, err := c.Refresh(ctx, name)
+		if err != nil {
+			event.Failed = append(event.Failed, name)
+			if c.opts.OnError != nil {
+				c.opts.OnError(fmt.Errorf("resync config %s: %w", name, err))
+			}
+			continue
+		}
+		event.Refreshed = append(event.Refreshed, name)
+
+		if hadOld && old.Version == config.Version && old.Content == config.Content {
+			continue
+		}
+		event.Changed = append(event.Changed, name)
+		c.notifyChange(name, old, config)
+	}
+
+	if c.opts.OnResync != nil {
+		c.opts.OnResync(event)
+	}
+}
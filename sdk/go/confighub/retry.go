@@ -0,0 +1,186 @@
+package confighub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Defaults applied to zero fields of RetryPolicy
+const (
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 200 * time.Millisecond
+	defaultRetryMaxBackoff     = 5 * time.Second
+	defaultRetryMultiplier     = 2.0
+)
+
+// defaultRetryableStatusCodes are retried when RetryableStatusCodes is empty
+var defaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// StatusError is returned for unexpected HTTP responses from the server
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("server error: %s", e.Body)
+}
+
+// RetryPolicy configures retries of config fetches. Transport errors and
+// responses with a retryable status code are retried with exponential backoff;
+// ErrNotFound, ErrUnauthorized and other status codes fail immediately.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first
+	// (default: 3)
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry (default: 200ms)
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between retries (default: 5s)
+	MaxBackoff time.Duration
+
+	// Multiplier grows the delay after each retry (default: 2)
+	Multiplier float64
+
+	// Jitter randomizes each delay by up to this fraction, e.g. 0.2 waits
+	// between 80% and 100% of the backoff, so clients restarted together do
+	// not retry in lockstep (0 to 1, default: 0)
+	Jitter float64
+
+	// RetryableStatusCodes are the HTTP status codes that are retried
+	// (default: 429, 502, 503, 504)
+	RetryableStatusCodes []int
+
+	// OnRetry is called before waiting for each retry, e.g. to count retries
+	OnRetry func(event *RetryEvent)
+
+	// OnGiveUp is called when a fetch fails after a retryable error because
+	// the attempts are exhausted or the context is done
+	OnGiveUp func(event *RetryEvent)
+}
+
+// RetryEvent describes a failed fetch attempt
+type RetryEvent struct {
+	Name        string
+	Namespace   string
+	Environment string
+
+	// Attempt is the number of the failed attempt, starting at 1
+	Attempt int
+
+	// Delay is the wait before the next attempt; zero when giving up
+	Delay time.Duration
+
+	Err error
+}
+
+// retryable reports whether err is worth retrying under the policy
+func (p *RetryPolicy) retryable(err error) bool {
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrUnauthorized) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		codes := p.RetryableStatusCodes
+		if len(codes) == 0 {
+			codes = defaultRetryableStatusCodes
+		}
+		for _, code := range codes {
+			if code == statusErr.StatusCode {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// backoff returns the delay before the retry following attempt
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	initial, maxBackoff, multiplier := p.InitialBackoff, p.MaxBackoff, p.Multiplier
+	if initial <= 0 {
+		initial = defaultRetryInitialBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = defaultRetryMaxBackoff
+	}
+	if multiplier < 1 {
+		multiplier = defaultRetryMultiplier
+	}
+
+	delay := float64(initial)
+	for i := 1; i < attempt && delay < float64(maxBackoff); i++ {
+		delay *= multiplier
+	}
+	if delay > float64(maxBackoff) {
+		delay = float64(maxBackoff)
+	}
+	if p.Jitter > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		delay -= delay * jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
+
+// withRetry runs fetch under ClientOptions.Retry. Waiting between attempts
+// stops when ctx is done or the client is closed.
+func (c *Client) withRetry(ctx context.Context, name, namespace, env string, fetch func() (*Config, error)) (*Config, error) {
+	policy := c.opts.Retry
+	if policy == nil {
+		return fetch()
+	}
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultRetryMaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		config, err := fetch()
+		if err == nil || !policy.retryable(err) {
+			return config, err
+		}
+
+		event := &RetryEvent{Name: name, Namespace: namespace, Environment: env, Attempt: attempt, Err: err}
+		if attempt >= maxAttempts {
+			if policy.OnGiveUp != nil {
+				policy.OnGiveUp(event)
+			}
+			return nil, err
+		}
+
+		event.Delay = policy.backoff(attempt)
+		if policy.OnRetry != nil {
+			policy.OnRetry(event)
+		}
+
+		timer := time.NewTimer(event.Delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			event.Delay = 0
+			if policy.OnGiveUp != nil {
+				policy.OnGiveUp(event)
+			}
+			return nil, err
+		case <-c.stopCh:
+			timer.Stop()
+			return nil, ErrClientClosed
+		}
+	}
+}
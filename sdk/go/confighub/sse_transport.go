@@ -0,0 +1,261 @@
+package confighub
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TransportSSE is the name of the built-in server-sent events transport
+const TransportSSE = "sse"
+
+// maxStreamEvent is the largest event accepted on a watch stream; change
+// events carry the full config content
+const maxStreamEvent = 16 << 20
+
+// sseTransport keeps one server-sent events stream open per watched config,
+// so a change is pushed over an existing connection instead of completing a
+// long-poll request that has to be opened again. Fetch uses plain HTTP.
+type sseTransport struct {
+	c *Client
+
+	mu      sync.Mutex
+	streams map[string]*watchStream
+}
+
+// watchStream is an open change stream of a single config
+type watchStream struct {
+	events chan *Config
+	done   chan struct{}
+	err    error // why the stream ended, set before done is closed
+
+	// claimed is the time (unix nanoseconds) of the last Watch call reading
+	// the stream; a stream nobody reads from is closed
+	claimed int64
+}
+
+func newSSETransport(c *Client) *sseTransport {
+	return &sseTransport{c: c, streams: make(map[string]*watchStream)}
+}
+
+// Name returns the transport name
+func (t *sseTransport) Name() string {
+	return TransportSSE
+}
+
+// Fetch fetches configuration with a plain HTTP request
+func (t *sseTransport) Fetch(ctx context.Context, name, namespace, env string) (*Config, error) {
+	return (&longPollTransport{c: t.c}).Fetch(ctx, name, namespace, env)
+}
+
+// Watch waits for the next change pushed on the config's stream, opening the
+// stream on first use and again after it ended
+func (t *sseTransport) Watch(ctx context.Context, name, namespace, env string, currentVersion int) (*Config, error) {
+	key := t.c.cacheKey(name, namespace, env)
+	s := t.stream(key, name, namespace, env, currentVersion)
+	atomic.StoreInt64(&s.claimed, time.Now().UnixNano())
+
+	select {
+	case config := <-s.events:
+		return config, nil
+	case <-s.done:
+		t.drop(key, s)
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, ErrWatchTimeout
+	case <-ctx.Done():
+		return nil, ErrWatchTimeout
+	}
+}
+
+// stream returns the open stream of a config, opening a new one if needed
+func (t *sseTransport) stream(key, name, namespace, env string, currentVersion int) *watchStream {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s, ok := t.streams[key]; ok {
+		return s
+	}
+
+	// Streams end with the watch (StopWatch); streams opened outside a
+	// watch end once nobody reads from them
+	base := context.Background()
+	t.c.watchMu.Lock()
+	if t.c.watchCtx != nil && t.c.watchCtx.Err() == nil {
+		base = t.c.watchCtx
+	}
+	t.c.watchMu.Unlock()
+
+	ctx, cancel := context.WithCancel(base)
+	s := &watchStream{
+		events:  make(chan *Config),
+		done:    make(chan struct{}),
+		claimed: time.Now().UnixNano(),
+	}
+	t.streams[key] = s
+	go func() {
+		defer cancel()
+		s.err = t.run(ctx, s, name, namespace, env, currentVersion)
+		close(s.done)
+	}()
+	return s
+}
+
+// drop forgets an ended stream so that the next Watch opens a new one
+func (t *sseTransport) drop(key string, s *watchStream) {
+	t.mu.Lock()
+	if t.streams[key] == s {
+		delete(t.streams, key)
+	}
+	t.mu.Unlock()
+}
+
+// idle is how long a stream is kept open without a Watch call reading from
+// it: several watch request timeouts
+func (t *sseTransport) idle() time.Duration {
+	return 3 * time.Duration(t.c.opts.WatchTimeout+5) * time.Second
+}
+
+// unclaimed reports whether no Watch call has read from the stream for idle
+func (t *sseTransport) unclaimed(s *watchStream) bool {
+	return time.Since(time.Unix(0, atomic.LoadInt64(&s.claimed))) > t.idle()
+}
+
+// run opens the stream and forwards its changes until it ends
+func (t *sseTransport) run(ctx context.Context, s *watchStream, name, namespace, env string, currentVersion int) error {
+	c := t.c
+	u, err := url.Parse(c.opts.ServerURL)
+	if err != nil {
+		return err
+	}
+	u.Path = "/api/v1/config/watch/stream"
+
+	q := u.Query()
+	q.Set("name", name)
+	if namespace != "" {
+		q.Set("namespace", namespace)
+	}
+	if env != "" {
+		q.Set("env", env)
+	}
+	if c.opts.Locale != "" {
+		q.Set("locale", c.opts.Locale)
+	}
+	q.Set("version", strconv.Itoa(currentVersion))
+	if hash := c.cachedContentHash(name, namespace, env); hash != "" {
+		q.Set("content_hash", hash)
+	}
+	if c.opts.NotifyOnly {
+		q.Set("mode", "notify")
+	}
+	u.RawQuery = q.Encode()
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(streamCtx, "GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if err := c.authorizeWatch(ctx, req); err != nil {
+		return err
+	}
+
+	// The stream outlives the client's request timeout; a silent connection
+	// is detected by the missing heartbeats instead
+	streamClient := *c.httpClient
+	streamClient.Timeout = 0
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	c.observeInstance(resp.Header.Get(InstanceHeader))
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		c.resetWatchToken()
+		return ErrUnauthorized
+	case http.StatusNotFound:
+		return ErrNotFound
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	idle := time.AfterFunc(namespaceStreamIdle, cancel)
+	defer idle.Stop()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, maxStreamEvent)
+	var event, data string
+	for scanner.Scan() {
+		idle.Reset(namespaceStreamIdle)
+		line := scanner.Text()
+		switch {
+		case line == "":
+			config, err := t.dispatch(event, data)
+			if err != nil {
+				return err
+			}
+			if config != nil {
+				select {
+				case s.events <- config:
+				case <-streamCtx.Done():
+					return nil
+				case <-time.After(t.idle()):
+					// Nobody watches the config any more
+					return nil
+				}
+			} else if t.unclaimed(s) {
+				return nil
+			}
+			event, data = "", ""
+		case strings.HasPrefix(line, ":"):
+			// Heartbeat
+			if t.unclaimed(s) {
+				return nil
+			}
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(line[6:])
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimSpace(line[5:])
+		}
+	}
+	if err := scanner.Err(); err != nil && streamCtx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// dispatch decodes a single stream event; it returns the changed config, or
+// nil for events that carry none
+func (t *sseTransport) dispatch(event, data string) (*Config, error) {
+	switch event {
+	case "change":
+		var result struct {
+			NotifyOnly bool `json:"notify_only"`
+			Config
+		}
+		if err := json.Unmarshal([]byte(data), &result); err != nil {
+			return nil, err
+		}
+		result.Config.contentOmitted = result.NotifyOnly
+		return &result.Config, nil
+	case "deleted":
+		return nil, ErrConfigDeleted
+	case "revoked":
+		t.c.resetWatchToken()
+		return nil, ErrUnauthorized
+	}
+	return nil, nil
+}
@@ -0,0 +1,77 @@
+package confighub
+
+import "sync"
+
+// Subscription is a handle to a change callback registered with OnKeyChange
+type Subscription struct {
+	once   sync.Once
+	cancel func()
+}
+
+// Unsubscribe removes the callback. It is safe to call more than once and
+// from within the callback itself.
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(s.cancel)
+}
+
+// changeSubscriber is a callback subscribed to changes of a single config
+type changeSubscriber struct {
+	id int
+	fn func(old, new *Config)
+}
+
+// OnKeyChange subscribes fn to changes of the config name, so different parts
+// of an application can react to different configs without a shared OnChange
+// switch. Any number of callbacks can be registered per config; they run in
+// registration order on the watch goroutine of the config, after OnChange and
+// before WatchKey callbacks. old is nil if the config was not cached before.
+//
+// OnKeyChange only registers the subscription; the config must also be
+// watched via Watch.
+func (c *Client) OnKeyChange(name string, fn func(old, new *Config)) *Subscription {
+	c.changeSubMu.Lock()
+	defer c.changeSubMu.Unlock()
+
+	if c.changeSubs == nil {
+		c.changeSubs = make(map[string][]*changeSubscriber)
+	}
+	c.nextChangeSubID++
+	sub := &changeSubscriber{id: c.nextChangeSubID, fn: fn}
+	c.changeSubs[name] = append(c.changeSubs[name], sub)
+
+	return &Subscription{cancel: func() {
+		c.changeSubMu.Lock()
+		defer c.changeSubMu.Unlock()
+		subs := c.changeSubs[name]
+		for i, existing := range subs {
+			if existing.id == sub.id {
+				c.changeSubs[name] = append(subs[:i:i], subs[i+1:]...)
+				break
+			}
+		}
+		if len(c.changeSubs[name]) == 0 {
+			delete(c.changeSubs, name)
+		}
+	}}
+}
+
+// notifyChange reports a changed config to OnChange, the OnKeyChange
+// subscribers of name and the WatchKey callbacks
+func (c *Client) notifyChange(name string, old, config *Config) {
+	if c.opts.OnChange != nil {
+		c.opts.OnChange(config)
+	}
+
+	c.changeSubMu.Lock()
+	subs := append([]*changeSubscriber(nil), c.changeSubs[name]...)
+	c.changeSubMu.Unlock()
+	for _, sub := range subs {
+		sub.fn(old, config)
+	}
+
+	oldContent := ""
+	if old != nil {
+		oldContent = old.Content
+	}
+	c.notifyKeyWatchers(name, oldContent, config.Content)
+}
@@ -0,0 +1,277 @@
+package confighub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// TransportLongPolling is the name of the built-in long-polling transport
+const TransportLongPolling = "long-polling"
+
+// Transport delivers configurations from the server. The SDK ships
+// long-polling (the fallback), server-sent events (see
+// ClientOptions.StreamWatch) and the local agent (see
+// ClientOptions.AgentSocket); the server offers only "sse" and
+// "long-polling". There are no gRPC or WebSocket transports: a custom
+// Transport is only used when the server lists its Name, e.g. behind a proxy
+// that adds its own endpoint.
+type Transport interface {
+	// Name identifies the transport during negotiation, e.g. "sse"
+	Name() string
+
+	// Fetch returns the current configuration, or ErrNotFound
+	Fetch(ctx context.Context, name, namespace, env string) (*Config, error)
+
+	// Watch blocks until the configuration moves past currentVersion and
+	// returns it, or returns ErrWatchTimeout when nothing changed before ctx ends
+	// and ErrConfigDeleted when the configuration was deleted
+	Watch(ctx context.Context, name, namespace, env string, currentVersion int) (*Config, error)
+}
+
+// longPollTransport is the default HTTP long-polling transport
+type longPollTransport struct {
+	c *Client
+}
+
+// Name returns the transport name
+func (t *longPollTransport) Name() string {
+	return TransportLongPolling
+}
+
+// Fetch fetches configuration with a plain HTTP request
+func (t *longPollTransport) Fetch(ctx context.Context, name, namespace, env string) (*Config, error) {
+	u, err := url.Parse(t.c.opts.ServerURL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = "/api/v1/config"
+
+	q := u.Query()
+	q.Set("name", name)
+	if namespace != "" {
+		q.Set("namespace", namespace)
+	}
+	if env != "" {
+		q.Set("env", env)
+	}
+	if t.c.opts.Locale != "" {
+		q.Set("locale", t.c.opts.Locale)
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	t.c.signRequest(req)
+
+	resp, err := t.c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	t.c.observeInstance(resp.Header.Get(InstanceHeader))
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, ErrUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var config Config
+	if err := json.NewDecoder(resp.Body).Decode(&config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// Watch performs a single long-poll request
+func (t *longPollTransport) Watch(ctx context.Context, name, namespace, env string, currentVersion int) (*Config, error) {
+	u, err := url.Parse(t.c.opts.ServerURL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = "/api/v1/config/watch"
+
+	q := u.Query()
+	q.Set("name", name)
+	if namespace != "" {
+		q.Set("namespace", namespace)
+	}
+	if env != "" {
+		q.Set("env", env)
+	}
+	if t.c.opts.Locale != "" {
+		q.Set("locale", t.c.opts.Locale)
+	}
+	q.Set("version", strconv.Itoa(currentVersion))
+	// Lets the server report content that changed without a new version,
+	// e.g. an inherited parent config updated between two polls
+	if hash := t.c.cachedContentHash(name, namespace, env); hash != "" {
+		q.Set("content_hash", hash)
+	}
+	q.Set("timeout", strconv.Itoa(t.c.opts.WatchTimeout))
+	if t.c.opts.NotifyOnly {
+		q.Set("mode", "notify")
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.c.authorizeWatch(ctx, req); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	t.c.observeInstance(resp.Header.Get(InstanceHeader))
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, ErrWatchTimeout
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		// Token expired or revoked: fetch a fresh one on the next attempt
+		t.c.resetWatchToken()
+		return nil, ErrUnauthorized
+	}
+	if resp.StatusCode == http.StatusGone {
+		return nil, ErrConfigDeleted
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("watch error: %s", string(body))
+	}
+
+	var result struct {
+		Changed    bool `json:"changed"`
+		NotifyOnly bool `json:"notify_only"`
+		Config
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	if !result.Changed {
+		return nil, ErrWatchTimeout
+	}
+
+	result.Config.contentOmitted = result.NotifyOnly
+	return &result.Config, nil
+}
+
+// currentTransport returns the transport in use, negotiating it on first call
+func (c *Client) currentTransport(ctx context.Context) Transport {
+	c.transportOnce.Do(func() {
+		transport := c.negotiateTransport(ctx)
+		c.transportMu.Lock()
+		c.transport = transport
+		c.transportMu.Unlock()
+	})
+
+	c.transportMu.RLock()
+	defer c.transportMu.RUnlock()
+	return c.transport
+}
+
+// negotiateTransport picks the first configured transport the server offers,
+// falling back to long-polling when none match or negotiation fails. With an
+// agent socket the agent is used without negotiation.
+func (c *Client) negotiateTransport(ctx context.Context) Transport {
+	if c.opts.AgentSocket != "" {
+		return newAgentTransport(c)
+	}
+	fallback := &longPollTransport{c: c}
+	candidates := c.opts.Transports
+	if c.opts.StreamWatch {
+		candidates = append(candidates[:len(candidates):len(candidates)], newSSETransport(c))
+	}
+	if len(candidates) == 0 {
+		return fallback
+	}
+
+	offered, err := c.serverTransports(ctx)
+	if err != nil {
+		return fallback
+	}
+	for _, transport := range candidates {
+		if offered[transport.Name()] {
+			return transport
+		}
+	}
+	return fallback
+}
+
+// serverTransports queries the transports offered by the server
+func (c *Client) serverTransports(ctx context.Context) (map[string]bool, error) {
+	u, err := url.Parse(c.opts.ServerURL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = "/api/v1/config/transports"
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.signRequest(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("transport negotiation not supported")
+	}
+
+	var result struct {
+		Transports []string `json:"transports"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	offered := make(map[string]bool, len(result.Transports))
+	for _, name := range result.Transports {
+		offered[name] = true
+	}
+	return offered, nil
+}
+
+// fallbackTransport switches from a failing push transport to long-polling
+func (c *Client) fallbackTransport(failed Transport, err error) {
+	c.transportMu.Lock()
+	defer c.transportMu.Unlock()
+	if c.transport != failed {
+		return
+	}
+	c.transport = &longPollTransport{c: c}
+
+	if c.opts.OnError != nil {
+		c.opts.OnError(fmt.Errorf("transport %s failed, falling back to %s: %w", failed.Name(), TransportLongPolling, err))
+	}
+}
@@ -0,0 +1,397 @@
+package confighub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidValue is returned when a config value cannot be converted to the
+// requested type
+var ErrInvalidValue = errors.New("invalid config value")
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// GetInt returns the integer at path of a JSON config (see WatchKey for the
+// path syntax), or def if the key does not exist. Numeric strings are accepted.
+func (c *Client) GetInt(ctx context.Context, name, path string, def int) (int, error) {
+	var v int
+	ok, err := c.getValue(ctx, name, path, &v)
+	if err != nil || !ok {
+		return def, err
+	}
+	return v, nil
+}
+
+// GetBool returns the boolean at path of a JSON config, or def if the key does
+// not exist. Strings accepted by strconv.ParseBool are converted.
+func (c *Client) GetBool(ctx context.Context, name, path string, def bool) (bool, error) {
+	var v bool
+	ok, err := c.getValue(ctx, name, path, &v)
+	if err != nil || !ok {
+		return def, err
+	}
+	return v, nil
+}
+
+// GetDuration returns the duration at path of a JSON config, or def if the key
+// does not exist. Strings are parsed with time.ParseDuration ("30s", "5m");
+// plain numbers are interpreted as seconds.
+func (c *Client) GetDuration(ctx context.Context, name, path string, def time.Duration) (time.Duration, error) {
+	var v time.Duration
+	ok, err := c.getValue(ctx, name, path, &v)
+	if err != nil || !ok {
+		return def, err
+	}
+	return v, nil
+}
+
+// GetStringSlice returns the list at path of a JSON config, or def if the key
+// does not exist. A string value is split on commas.
+func (c *Client) GetStringSlice(ctx context.Context, name, path string, def []string) ([]string, error) {
+	var v []string
+	ok, err := c.getValue(ctx, name, path, &v)
+	if err != nil || !ok {
+		return def, err
+	}
+	return v, nil
+}
+
+// getValue converts the value at path of a JSON config into out, reporting
+// whether the key exists
+func (c *Client) getValue(ctx context.Context, name, path string, out interface{}) (bool, error) {
+	config, err := c.Get(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	doc, err := parseDocument(name, config.Content)
+	if err != nil {
+		return false, err
+	}
+	raw := lookupKey(doc, splitKeyPath(path))
+	if raw == nil {
+		return false, nil
+	}
+	if err := assignValue(reflect.ValueOf(out).Elem(), raw); err != nil {
+		return false, fmt.Errorf("config %s key %s: %w", name, path, err)
+	}
+	return true, nil
+}
+
+// Bind populates the struct pointed to by v from a JSON config. Fields are
+// mapped with `confighub:"path,default=x"` tags, where path is dot separated
+// and relative to the enclosing struct; untagged exported fields use the field
+// name, matched case-insensitively. A tag of "-" skips the field.
+//
+// When the key is missing the default is applied, parsed like a string value
+// (lists are comma separated, structs and maps are JSON); without a default
+// the field is left untouched. Nested structs are bound recursively, so their
+// defaults apply even if the enclosing object is absent.
+func (c *Client) Bind(ctx context.Context, name string, v interface{}) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Ptr || target.IsNil() || target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: Bind requires a non-nil pointer to a struct", ErrInvalidValue)
+	}
+
+	config, err := c.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	doc, err := parseDocument(name, config.Content)
+	if err != nil {
+		return err
+	}
+	if err := bindStruct(target.Elem(), doc, ""); err != nil {
+		return fmt.Errorf("config %s: %w", name, err)
+	}
+	return nil
+}
+
+// parseDocument decodes JSON content; empty content is an empty document
+func parseDocument(name, content string) (interface{}, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, nil
+	}
+	var doc interface{}
+	if err := json.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, fmt.Errorf("%w: config %s is not JSON: %v", ErrInvalidValue, name, err)
+	}
+	return doc, nil
+}
+
+// bindStruct assigns the fields of target from doc; prefix is the path of
+// target, used in error messages
+func bindStruct(target reflect.Value, doc interface{}, prefix string) error {
+	t := target.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		path, def, hasDefault := parseBindTag(field)
+		if path == "-" {
+			continue
+		}
+		fullPath := path
+		if prefix != "" {
+			fullPath = prefix + "." + path
+		}
+
+		value := target.Field(i)
+		raw := lookupKeyFold(doc, splitKeyPath(path))
+
+		if isNestedStruct(field.Type) && (isObject(raw) || (raw == nil && !hasDefault)) {
+			if field.Type.Kind() == reflect.Ptr {
+				if raw == nil && value.IsNil() {
+					continue
+				}
+				if value.IsNil() {
+					value.Set(reflect.New(field.Type.Elem()))
+				}
+				value = value.Elem()
+			}
+			if err := bindStruct(value, raw, fullPath); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if raw == nil {
+			if !hasDefault {
+				continue
+			}
+			raw = def
+		}
+		if err := assignValue(value, raw); err != nil {
+			return fmt.Errorf("field %s (%s): %w", field.Name, fullPath, err)
+		}
+	}
+	return nil
+}
+
+// parseBindTag returns the path and default of a field
+func parseBindTag(field reflect.StructField) (path, def string, hasDefault bool) {
+	tag, ok := field.Tag.Lookup("confighub")
+	if !ok {
+		return field.Name, "", false
+	}
+	if tag == "-" {
+		return "-", "", false
+	}
+
+	path = tag
+	if i := strings.Index(tag, ","); i >= 0 {
+		path = tag[:i]
+		if opt := tag[i+1:]; strings.HasPrefix(opt, "default=") {
+			def, hasDefault = strings.TrimPrefix(opt, "default="), true
+		}
+	}
+	if path == "" {
+		path = field.Name
+	}
+	return path, def, hasDefault
+}
+
+// lookupKeyFold is lookupKey with case-insensitive matching of object keys
+// when there is no exact match
+func lookupKeyFold(doc interface{}, path []string) interface{} {
+	current := doc
+	for _, segment := range path {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return lookupKey(current, []string{segment})
+		}
+		next, found := obj[segment]
+		if !found {
+			for key, value := range obj {
+				if strings.EqualFold(key, segment) {
+					next = value
+					break
+				}
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// isNestedStruct reports whether t is a struct (or pointer to one) that Bind
+// descends into rather than decoding as a single value
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{})
+}
+
+func isObject(raw interface{}) bool {
+	_, ok := raw.(map[string]interface{})
+	return ok
+}
+
+// assignValue converts a JSON decoded value (or a default string) into target
+func assignValue(target reflect.Value, raw interface{}) error {
+	if target.Type() == durationType {
+		d, err := toDuration(raw)
+		if err != nil {
+			return err
+		}
+		target.SetInt(int64(d))
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.Ptr:
+		value := reflect.New(target.Type().Elem())
+		if err := assignValue(value.Elem(), raw); err != nil {
+			return err
+		}
+		target.Set(value)
+	case reflect.String:
+		s, err := toString(raw)
+		if err != nil {
+			return err
+		}
+		target.SetString(s)
+	case reflect.Bool:
+		b, err := toBool(raw)
+		if err != nil {
+			return err
+		}
+		target.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f, err := toNumber(raw)
+		if err != nil {
+			return err
+		}
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 || target.OverflowInt(int64(f)) {
+			return fmt.Errorf("%w: %v is not a valid %s", ErrInvalidValue, raw, target.Type())
+		}
+		target.SetInt(int64(f))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f, err := toNumber(raw)
+		if err != nil {
+			return err
+		}
+		if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 || target.OverflowUint(uint64(f)) {
+			return fmt.Errorf("%w: %v is not a valid %s", ErrInvalidValue, raw, target.Type())
+		}
+		target.SetUint(uint64(f))
+	case reflect.Float32, reflect.Float64:
+		f, err := toNumber(raw)
+		if err != nil {
+			return err
+		}
+		if target.OverflowFloat(f) {
+			return fmt.Errorf("%w: %v overflows %s", ErrInvalidValue, raw, target.Type())
+		}
+		target.SetFloat(f)
+	case reflect.Slice:
+		items, err := toList(raw)
+		if err != nil {
+			return err
+		}
+		slice := reflect.MakeSlice(target.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := assignValue(slice.Index(i), item); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		target.Set(slice)
+	default:
+		// structs, maps and interfaces are decoded as JSON; a string (such as
+		// a default) holds the JSON text
+		data, ok := raw.(string)
+		encoded := []byte(data)
+		if !ok {
+			var err error
+			if encoded, err = json.Marshal(raw); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidValue, err)
+			}
+		}
+		if err := json.Unmarshal(encoded, target.Addr().Interface()); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidValue, err)
+		}
+	}
+	return nil
+}
+
+func toString(raw interface{}) (string, error) {
+	switch v := raw.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", fmt.Errorf("%w: expected a string, got %T", ErrInvalidValue, raw)
+}
+
+func toBool(raw interface{}) (bool, error) {
+	switch v := raw.(type) {
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return false, fmt.Errorf("%w: %q is not a boolean", ErrInvalidValue, v)
+		}
+		return b, nil
+	}
+	return false, fmt.Errorf("%w: expected a boolean, got %T", ErrInvalidValue, raw)
+}
+
+func toNumber(raw interface{}) (float64, error) {
+	switch v := raw.(type) {
+	case float64:
+		return v, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q is not a number", ErrInvalidValue, v)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("%w: expected a number, got %T", ErrInvalidValue, raw)
+}
+
+func toDuration(raw interface{}) (time.Duration, error) {
+	switch v := raw.(type) {
+	case float64:
+		return time.Duration(v * float64(time.Second)), nil
+	case string:
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q is not a duration", ErrInvalidValue, v)
+		}
+		return d, nil
+	}
+	return 0, fmt.Errorf("%w: expected a duration, got %T", ErrInvalidValue, raw)
+}
+
+// toList accepts a JSON array or a comma separated string
+func toList(raw interface{}) ([]interface{}, error) {
+	switch v := raw.(type) {
+	case []interface{}:
+		return v, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return []interface{}{}, nil
+		}
+		parts := strings.Split(v, ",")
+		items := make([]interface{}, len(parts))
+		for i, part := range parts {
+			items[i] = strings.TrimSpace(part)
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("%w: expected a list, got %T", ErrInvalidValue, raw)
+}
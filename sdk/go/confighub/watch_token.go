@@ -0,0 +1,89 @@
+package confighub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// watchTokenRefreshMargin renews watch tokens this long before they expire
+const watchTokenRefreshMargin = 30 * time.Second
+
+// authorizeWatch authenticates a watch request with a watch token when
+// UseWatchToken is set, otherwise signs it
+func (c *Client) authorizeWatch(ctx context.Context, req *http.Request) error {
+	if !c.opts.UseWatchToken {
+		c.signRequest(req)
+		return nil
+	}
+
+	token, err := c.currentWatchToken(ctx)
+	if err != nil {
+		return err
+	}
+	c.injectTraceContext(req)
+	c.setIdentity(req)
+	req.Header.Set("X-Watch-Token", token)
+	return nil
+}
+
+// currentWatchToken returns a cached watch token, requesting a new one when
+// missing or about to expire
+func (c *Client) currentWatchToken(ctx context.Context) (string, error) {
+	c.watchTokenMu.Lock()
+	defer c.watchTokenMu.Unlock()
+
+	if c.watchToken != "" && time.Until(c.watchTokenExpires) > watchTokenRefreshMargin {
+		return c.watchToken, nil
+	}
+
+	u, err := url.Parse(c.opts.ServerURL)
+	if err != nil {
+		return "", err
+	}
+	u.Path = "/api/v1/config/watch-token"
+
+	req, err := http.NewRequestWithContext(ctx, "POST", u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	c.signRequest(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return "", ErrUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("watch token error: %s", string(body))
+	}
+
+	var result struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	c.watchToken = result.Token
+	c.watchTokenExpires = result.ExpiresAt
+	return c.watchToken, nil
+}
+
+// resetWatchToken drops the cached watch token
+func (c *Client) resetWatchToken() {
+	c.watchTokenMu.Lock()
+	c.watchToken = ""
+	c.watchTokenMu.Unlock()
+}
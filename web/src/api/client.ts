@@ -48,6 +48,7 @@ export interface Project {
   name: string
   description: string
   access_mode: string
+  archived_at?: string
   created_at: string
   updated_at: string
 }
@@ -64,6 +65,14 @@ export interface Config {
   updated_at: string
 }
 
+// 内容校验问题 (行列号从 1 开始, 0 表示未知)
+export interface ParseIssue {
+  line: number
+  column: number
+  severity: 'error' | 'warning'
+  message: string
+}
+
 export interface ConfigVersion {
   id: number
   config_id: number
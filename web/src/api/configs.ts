@@ -1,5 +1,12 @@
 import client, { Config, ConfigVersion, Release } from './client'
 
+export interface DiffRules {
+  ignore_key_order: boolean
+  ignore_array_order: boolean
+  ignore_paths: string[]
+  numeric_tolerance: number
+}
+
 export const configApi = {
   get: (id: number) =>
     client.get<{ config: Config; content: string; version: number }>(`/configs/${id}`),
@@ -21,6 +28,33 @@ export const configApi = {
       `/configs/${configId}/diff?v1=${v1}&v2=${v2}`
     ),
 
+  // 任意两个版本对比 (可跨配置、跨环境), 版本省略时使用当前版本
+  compareVersions: (
+    from: { configId: number; version?: number },
+    to: { configId: number; version?: number }
+  ) => {
+    const params = new URLSearchParams({
+      from_config: String(from.configId),
+      to_config: String(to.configId),
+    })
+    if (from.version) params.set('from_version', String(from.version))
+    if (to.version) params.set('to_version', String(to.version))
+    return client.get<{
+      identical: boolean
+      lines: Array<{ type: string; line_number: number; content: string }>
+      summary: Record<string, number>
+      structural: Array<{ type: string; path: string; old_value?: unknown; new_value?: unknown }>
+      structural_error?: string
+    }>(`/configs/compare?${params}`)
+  },
+
+  // 对比忽略规则
+  getDiffRules: (configId: number) =>
+    client.get<{ rules: DiffRules }>(`/configs/${configId}/diff-rules`),
+
+  updateDiffRules: (configId: number, rules: DiffRules) =>
+    client.put(`/configs/${configId}/diff-rules`, rules),
+
   rollback: (configId: number, version: number) =>
     client.post<{ version: ConfigVersion }>(`/configs/${configId}/rollback/${version}`),
 
@@ -54,8 +88,8 @@ export const configApi = {
   }) => client.post(`/configs/${configId}/gray-release`, data),
 
   // 环境对比
-  compare: (configId: number, source: string, target: string) =>
-    client.get(`/configs/${configId}/compare?source=${source}&target=${target}`),
+  compare: (configId: number, source: string, target: string, effective = false) =>
+    client.get(`/configs/${configId}/compare?source=${source}&target=${target}&effective=${effective}`),
 
   sync: (configId: number, data: { source_env: string; target_env: string; keys?: string[] }) =>
     client.post(`/configs/${configId}/sync`, data),
@@ -1,7 +1,8 @@
 import client, { Project, Config } from './client'
 
 export const projectApi = {
-  list: () => client.get<{ projects: Project[] }>('/projects'),
+  list: (includeArchived = false) =>
+    client.get<{ projects: Project[] }>('/projects', { params: includeArchived ? { include_archived: true } : undefined }),
   
   get: (id: number) => client.get<{ project: Project }>(`/projects/${id}`),
   
@@ -13,6 +14,10 @@ export const projectApi = {
   
   delete: (id: number) => client.delete(`/projects/${id}`),
   
+  archive: (id: number) => client.post<{ project: Project }>(`/projects/${id}/archive`),
+  
+  unarchive: (id: number) => client.post<{ project: Project }>(`/projects/${id}/unarchive`),
+  
   listConfigs: (projectId: number) =>
     client.get<{ configs: Config[] }>(`/projects/${projectId}/configs`),
   
@@ -2,9 +2,10 @@ import { useState, useEffect, useRef } from 'react'
 import { useParams, useNavigate } from 'react-router-dom'
 import { Card, Button, Space, Typography, Breadcrumb, Spin, Modal, Input, message, Tabs, Tooltip } from 'antd'
 import { SaveOutlined, HistoryOutlined, RocketOutlined, ReloadOutlined } from '@ant-design/icons'
-import Editor from '@monaco-editor/react'
+import Editor, { type Monaco } from '@monaco-editor/react'
+import type { AxiosError } from 'axios'
 import { configApi } from '../api/configs'
-import type { Config } from '../api/client'
+import type { Config, ParseIssue } from '../api/client'
 
 const { Title, Text } = Typography
 
@@ -20,6 +21,7 @@ export default function ConfigEditorPage() {
   const [commitModalOpen, setCommitModalOpen] = useState(false)
   const [commitMessage, setCommitMessage] = useState('')
   const editorRef = useRef<unknown>(null)
+  const monacoRef = useRef<Monaco | null>(null)
 
   const fetchConfig = async () => {
     if (!configId) return
@@ -40,6 +42,21 @@ export default function ConfigEditorPage() {
 
   const hasChanges = content !== originalContent
 
+  // 在编辑器中标记服务端返回的校验问题 (重复键、未定义锚点等)
+  const showIssues = (issues: ParseIssue[]) => {
+    const monaco = monacoRef.current
+    const model = monaco?.editor.getModels()[0]
+    if (!monaco || !model) return
+    monaco.editor.setModelMarkers(model, 'server-lint', issues.map((issue) => ({
+      startLineNumber: Math.max(issue.line, 1),
+      startColumn: Math.max(issue.column, 1),
+      endLineNumber: Math.max(issue.line, 1),
+      endColumn: issue.column > 0 ? issue.column + 1 : model.getLineMaxColumn(Math.max(issue.line, 1)),
+      message: issue.message,
+      severity: issue.severity === 'error' ? monaco.MarkerSeverity.Error : monaco.MarkerSeverity.Warning,
+    })))
+  }
+
   const handleSave = async () => {
     if (!configId || !hasChanges) return
     setCommitModalOpen(true)
@@ -57,7 +74,14 @@ export default function ConfigEditorPage() {
       setVersion(data.version.version)
       setCommitModalOpen(false)
       setCommitMessage('')
+      showIssues([])
       message.success('保存成功')
+    } catch (error) {
+      const issues = (error as AxiosError<{ issues?: ParseIssue[] }>).response?.data?.issues
+      if (issues) {
+        setCommitModalOpen(false)
+        showIssues(issues)
+      }
     } finally {
       setSaving(false)
     }
@@ -140,7 +164,10 @@ export default function ConfigEditorPage() {
                     language={getLanguage()}
                     value={content}
                     onChange={(value) => setContent(value || '')}
-                    onMount={(editor) => { editorRef.current = editor }}
+                    onMount={(editor, monaco) => {
+                      editorRef.current = editor
+                      monacoRef.current = monaco
+                    }}
                     options={{
                       minimap: { enabled: false },
                       fontSize: 14,
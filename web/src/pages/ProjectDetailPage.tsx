@@ -57,9 +57,11 @@ export default function ProjectDetailPage() {
     const reader = new FileReader()
     reader.onload = (e) => {
       const content = e.target?.result as string
-      const fileType = file.name.endsWith('.yaml') || file.name.endsWith('.yml') ? 'yaml' : 'json'
+      let fileType = 'json'
+      if (file.name.endsWith('.yaml') || file.name.endsWith('.yml')) fileType = 'yaml'
+      if (file.name.endsWith('.hcl') || file.name.endsWith('.tfvars')) fileType = 'hcl'
       form.setFieldsValue({
-        name: file.name.replace(/\.(json|yaml|yml)$/, ''),
+        name: file.name.replace(/\.(json|yaml|yml|hcl|tfvars)$/, ''),
         content,
         file_type: fileType,
       })
@@ -197,13 +199,13 @@ export default function ProjectDetailPage() {
           <Dragger beforeUpload={handleFileUpload} showUploadList={false} style={{ marginBottom: 16 }}>
             <p className="ant-upload-drag-icon"><UploadOutlined /></p>
             <p className="ant-upload-text">点击或拖拽文件上传</p>
-            <p className="ant-upload-hint">支持 JSON、YAML 格式</p>
+            <p className="ant-upload-hint">支持 JSON、YAML、HCL 格式</p>
           </Dragger>
           <Form.Item name="name" label="配置名称" rules={[{ required: true, message: '请输入配置名称' }]}>
             <Input placeholder="如: app-config" />
           </Form.Item>
           <Form.Item name="file_type" label="文件类型" rules={[{ required: true }]}>
-            <Select options={[{ value: 'json', label: 'JSON' }, { value: 'yaml', label: 'YAML' }]} />
+            <Select options={[{ value: 'json', label: 'JSON' }, { value: 'yaml', label: 'YAML' }, { value: 'hcl', label: 'HCL' }]} />
           </Form.Item>
           <Form.Item name="namespace" label="命名空间">
             <Input placeholder="可选，如: application" />